@@ -89,26 +89,84 @@ func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
 
 // The interface specification for the client above.
 type ClientInterface interface {
+	// RemoveAllowEntryWithBody request with any body
+	RemoveAllowEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	RemoveAllowEntry(ctx context.Context, body RemoveAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// AddAllowEntryWithBody request with any body
+	AddAllowEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	AddAllowEntry(ctx context.Context, body AddAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// BlockingCheck request
+	BlockingCheck(ctx context.Context, params *BlockingCheckParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// RemoveDenyEntryWithBody request with any body
+	RemoveDenyEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	RemoveDenyEntry(ctx context.Context, body RemoveDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// AddDenyEntryWithBody request with any body
+	AddDenyEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	AddDenyEntry(ctx context.Context, body AddDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// DisableBlocking request
 	DisableBlocking(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	// EnableBlocking request
-	EnableBlocking(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+	EnableBlocking(ctx context.Context, params *EnableBlockingParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// BlockingEntries request
+	BlockingEntries(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	// BlockingStatus request
 	BlockingStatus(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// CustomDNSRecords request
+	CustomDNSRecords(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// RemoveCustomDNSRecords request
+	RemoveCustomDNSRecords(ctx context.Context, name string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// SetCustomDNSRecordsWithBody request with any body
+	SetCustomDNSRecordsWithBody(ctx context.Context, name string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	SetCustomDNSRecords(ctx context.Context, name string, body SetCustomDNSRecordsJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// Lists request
+	Lists(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListLookup request
+	ListLookup(ctx context.Context, params *ListLookupParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// ListRefresh request
 	ListRefresh(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// ListSources request
+	ListSources(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// QueryWithBody request with any body
 	QueryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	Query(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// StartupSummary request
+	StartupSummary(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ResetClientStats request
+	ResetClientStats(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ClientStats request
+	ClientStats(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UpstreamStatus request
+	UpstreamStatus(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
-func (c *Client) DisableBlocking(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewDisableBlockingRequest(c.Server, params)
+func (c *Client) RemoveAllowEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewRemoveAllowEntryRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -119,8 +177,8 @@ func (c *Client) DisableBlocking(ctx context.Context, params *DisableBlockingPar
 	return c.Client.Do(req)
 }
 
-func (c *Client) EnableBlocking(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewEnableBlockingRequest(c.Server)
+func (c *Client) RemoveAllowEntry(ctx context.Context, body RemoveAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewRemoveAllowEntryRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
@@ -131,8 +189,8 @@ func (c *Client) EnableBlocking(ctx context.Context, reqEditors ...RequestEditor
 	return c.Client.Do(req)
 }
 
-func (c *Client) BlockingStatus(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewBlockingStatusRequest(c.Server)
+func (c *Client) AddAllowEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewAddAllowEntryRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -143,8 +201,8 @@ func (c *Client) BlockingStatus(ctx context.Context, reqEditors ...RequestEditor
 	return c.Client.Do(req)
 }
 
-func (c *Client) ListRefresh(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewListRefreshRequest(c.Server)
+func (c *Client) AddAllowEntry(ctx context.Context, body AddAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewAddAllowEntryRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
@@ -155,8 +213,8 @@ func (c *Client) ListRefresh(ctx context.Context, reqEditors ...RequestEditorFn)
 	return c.Client.Do(req)
 }
 
-func (c *Client) QueryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewQueryRequestWithBody(c.Server, contentType, body)
+func (c *Client) BlockingCheck(ctx context.Context, params *BlockingCheckParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewBlockingCheckRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
@@ -167,8 +225,8 @@ func (c *Client) QueryWithBody(ctx context.Context, contentType string, body io.
 	return c.Client.Do(req)
 }
 
-func (c *Client) Query(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewQueryRequest(c.Server, body)
+func (c *Client) RemoveDenyEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewRemoveDenyEntryRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -179,461 +237,2283 @@ func (c *Client) Query(ctx context.Context, body QueryJSONRequestBody, reqEditor
 	return c.Client.Do(req)
 }
 
-// NewDisableBlockingRequest generates requests for DisableBlocking
-func NewDisableBlockingRequest(server string, params *DisableBlockingParams) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) RemoveDenyEntry(ctx context.Context, body RemoveDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewRemoveDenyEntryRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/blocking/disable")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) AddDenyEntryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewAddDenyEntryRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
-
-	if params != nil {
-		queryValues := queryURL.Query()
-
-		if params.Duration != nil {
-
-			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "duration", runtime.ParamLocationQuery, *params.Duration); err != nil {
-				return nil, err
-			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
-				return nil, err
-			} else {
-				for k, v := range parsed {
-					for _, v2 := range v {
-						queryValues.Add(k, v2)
-					}
-				}
-			}
-
-		}
-
-		if params.Groups != nil {
-
-			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "groups", runtime.ParamLocationQuery, *params.Groups); err != nil {
-				return nil, err
-			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
-				return nil, err
-			} else {
-				for k, v := range parsed {
-					for _, v2 := range v {
-						queryValues.Add(k, v2)
-					}
-				}
-			}
-
-		}
-
-		queryURL.RawQuery = queryValues.Encode()
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
+func (c *Client) AddDenyEntry(ctx context.Context, body AddDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewAddDenyEntryRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewEnableBlockingRequest generates requests for EnableBlocking
-func NewEnableBlockingRequest(server string) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) DisableBlocking(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDisableBlockingRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/blocking/enable")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) EnableBlocking(ctx context.Context, params *EnableBlockingParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewEnableBlockingRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
-
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
-	if err != nil {
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	return c.Client.Do(req)
 }
 
-// NewBlockingStatusRequest generates requests for BlockingStatus
-func NewBlockingStatusRequest(server string) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) BlockingEntries(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewBlockingEntriesRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/blocking/status")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) BlockingStatus(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewBlockingStatusRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
-
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
-	if err != nil {
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	return c.Client.Do(req)
 }
 
-// NewListRefreshRequest generates requests for ListRefresh
-func NewListRefreshRequest(server string) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) CustomDNSRecords(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCustomDNSRecordsRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/lists/refresh")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) RemoveCustomDNSRecords(ctx context.Context, name string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewRemoveCustomDNSRecordsRequest(c.Server, name)
 	if err != nil {
 		return nil, err
 	}
-
-	req, err := http.NewRequest("POST", queryURL.String(), nil)
-	if err != nil {
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	return c.Client.Do(req)
 }
 
-// NewQueryRequest calls the generic Query builder with application/json body
-func NewQueryRequest(server string, body QueryJSONRequestBody) (*http.Request, error) {
-	var bodyReader io.Reader
-	buf, err := json.Marshal(body)
+func (c *Client) SetCustomDNSRecordsWithBody(ctx context.Context, name string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewSetCustomDNSRecordsRequestWithBody(c.Server, name, contentType, body)
 	if err != nil {
 		return nil, err
 	}
-	bodyReader = bytes.NewReader(buf)
-	return NewQueryRequestWithBody(server, "application/json", bodyReader)
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewQueryRequestWithBody generates requests for Query with any type of body
-func NewQueryRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) SetCustomDNSRecords(ctx context.Context, name string, body SetCustomDNSRecordsJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewSetCustomDNSRecordsRequest(c.Server, name, body)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/query")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) Lists(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListsRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("POST", queryURL.String(), body)
+func (c *Client) ListLookup(ctx context.Context, params *ListLookupParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListLookupRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
-
-	req.Header.Add("Content-Type", contentType)
-
-	return req, nil
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
-	for _, r := range c.RequestEditors {
-		if err := r(ctx, req); err != nil {
-			return err
-		}
+func (c *Client) ListRefresh(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListRefreshRequest(c.Server)
+	if err != nil {
+		return nil, err
 	}
-	for _, r := range additionalEditors {
-		if err := r(ctx, req); err != nil {
-			return err
-		}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
-	return nil
+	return c.Client.Do(req)
 }
 
-// ClientWithResponses builds on ClientInterface to offer response payloads
-type ClientWithResponses struct {
-	ClientInterface
+func (c *Client) ListSources(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListSourcesRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewClientWithResponses creates a new ClientWithResponses, which wraps
-// Client with return type handling
-func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
-	client, err := NewClient(server, opts...)
+func (c *Client) QueryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQueryRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
-	return &ClientWithResponses{client}, nil
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// WithBaseURL overrides the baseURL.
-func WithBaseURL(baseURL string) ClientOption {
-	return func(c *Client) error {
-		newBaseURL, err := url.Parse(baseURL)
-		if err != nil {
-			return err
+func (c *Client) Query(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQueryRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) StartupSummary(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewStartupSummaryRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ResetClientStats(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewResetClientStatsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ClientStats(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewClientStatsRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) UpstreamStatus(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUpstreamStatusRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+// NewRemoveAllowEntryRequest calls the generic RemoveAllowEntry builder with application/json body
+func NewRemoveAllowEntryRequest(server string, body RemoveAllowEntryJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewRemoveAllowEntryRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewRemoveAllowEntryRequestWithBody generates requests for RemoveAllowEntry with any type of body
+func NewRemoveAllowEntryRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/allow")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewAddAllowEntryRequest calls the generic AddAllowEntry builder with application/json body
+func NewAddAllowEntryRequest(server string, body AddAllowEntryJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewAddAllowEntryRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewAddAllowEntryRequestWithBody generates requests for AddAllowEntry with any type of body
+func NewAddAllowEntryRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/allow")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewBlockingCheckRequest generates requests for BlockingCheck
+func NewBlockingCheckRequest(server string, params *BlockingCheckParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/check")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "domain", runtime.ParamLocationQuery, params.Domain); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
 		}
-		c.Server = newBaseURL.String()
-		return nil
+
+		if params.Client != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "client", runtime.ParamLocationQuery, *params.Client); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRemoveDenyEntryRequest calls the generic RemoveDenyEntry builder with application/json body
+func NewRemoveDenyEntryRequest(server string, body RemoveDenyEntryJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewRemoveDenyEntryRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewRemoveDenyEntryRequestWithBody generates requests for RemoveDenyEntry with any type of body
+func NewRemoveDenyEntryRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/deny")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewAddDenyEntryRequest calls the generic AddDenyEntry builder with application/json body
+func NewAddDenyEntryRequest(server string, body AddDenyEntryJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewAddDenyEntryRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewAddDenyEntryRequestWithBody generates requests for AddDenyEntry with any type of body
+func NewAddDenyEntryRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/deny")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewDisableBlockingRequest generates requests for DisableBlocking
+func NewDisableBlockingRequest(server string, params *DisableBlockingParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/disable")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Duration != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "duration", runtime.ParamLocationQuery, *params.Duration); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Groups != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "groups", runtime.ParamLocationQuery, *params.Groups); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Client != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "client", runtime.ParamLocationQuery, *params.Client); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewEnableBlockingRequest generates requests for EnableBlocking
+func NewEnableBlockingRequest(server string, params *EnableBlockingParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/enable")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Client != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "client", runtime.ParamLocationQuery, *params.Client); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewBlockingEntriesRequest generates requests for BlockingEntries
+func NewBlockingEntriesRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/entries")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewBlockingStatusRequest generates requests for BlockingStatus
+func NewBlockingStatusRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/status")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewCustomDNSRecordsRequest generates requests for CustomDNSRecords
+func NewCustomDNSRecordsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/customdns/records")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRemoveCustomDNSRecordsRequest generates requests for RemoveCustomDNSRecords
+func NewRemoveCustomDNSRecordsRequest(server string, name string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "name", runtime.ParamLocationPath, name)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/customdns/records/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewSetCustomDNSRecordsRequest calls the generic SetCustomDNSRecords builder with application/json body
+func NewSetCustomDNSRecordsRequest(server string, name string, body SetCustomDNSRecordsJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewSetCustomDNSRecordsRequestWithBody(server, name, "application/json", bodyReader)
+}
+
+// NewSetCustomDNSRecordsRequestWithBody generates requests for SetCustomDNSRecords with any type of body
+func NewSetCustomDNSRecordsRequestWithBody(server string, name string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "name", runtime.ParamLocationPath, name)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/customdns/records/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewListsRequest generates requests for Lists
+func NewListsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/lists")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewListLookupRequest generates requests for ListLookup
+func NewListLookupRequest(server string, params *ListLookupParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/lists/lookup")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "domain", runtime.ParamLocationQuery, params.Domain); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewListRefreshRequest generates requests for ListRefresh
+func NewListRefreshRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/lists/refresh")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewListSourcesRequest generates requests for ListSources
+func NewListSourcesRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/lists/sources")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewQueryRequest calls the generic Query builder with application/json body
+func NewQueryRequest(server string, body QueryJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewQueryRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewQueryRequestWithBody generates requests for Query with any type of body
+func NewQueryRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/query")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewStartupSummaryRequest generates requests for StartupSummary
+func NewStartupSummaryRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/startup")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewResetClientStatsRequest generates requests for ResetClientStats
+func NewResetClientStatsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/stats/clients")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewClientStatsRequest generates requests for ClientStats
+func NewClientStatsRequest(server string, params *ClientStatsParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/stats/clients")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Sort != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "sort", runtime.ParamLocationQuery, *params.Sort); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Limit != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "limit", runtime.ParamLocationQuery, *params.Limit); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewUpstreamStatusRequest generates requests for UpstreamStatus
+func NewUpstreamStatusRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/upstreams/status")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// RemoveAllowEntryWithBodyWithResponse request with any body
+	RemoveAllowEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*RemoveAllowEntryResponse, error)
+
+	RemoveAllowEntryWithResponse(ctx context.Context, body RemoveAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*RemoveAllowEntryResponse, error)
+
+	// AddAllowEntryWithBodyWithResponse request with any body
+	AddAllowEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*AddAllowEntryResponse, error)
+
+	AddAllowEntryWithResponse(ctx context.Context, body AddAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*AddAllowEntryResponse, error)
+
+	// BlockingCheckWithResponse request
+	BlockingCheckWithResponse(ctx context.Context, params *BlockingCheckParams, reqEditors ...RequestEditorFn) (*BlockingCheckResponse, error)
+
+	// RemoveDenyEntryWithBodyWithResponse request with any body
+	RemoveDenyEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*RemoveDenyEntryResponse, error)
+
+	RemoveDenyEntryWithResponse(ctx context.Context, body RemoveDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*RemoveDenyEntryResponse, error)
+
+	// AddDenyEntryWithBodyWithResponse request with any body
+	AddDenyEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*AddDenyEntryResponse, error)
+
+	AddDenyEntryWithResponse(ctx context.Context, body AddDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*AddDenyEntryResponse, error)
+
+	// DisableBlockingWithResponse request
+	DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error)
+
+	// EnableBlockingWithResponse request
+	EnableBlockingWithResponse(ctx context.Context, params *EnableBlockingParams, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error)
+
+	// BlockingEntriesWithResponse request
+	BlockingEntriesWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingEntriesResponse, error)
+
+	// BlockingStatusWithResponse request
+	BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error)
+
+	// CustomDNSRecordsWithResponse request
+	CustomDNSRecordsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*CustomDNSRecordsResponse, error)
+
+	// RemoveCustomDNSRecordsWithResponse request
+	RemoveCustomDNSRecordsWithResponse(ctx context.Context, name string, reqEditors ...RequestEditorFn) (*RemoveCustomDNSRecordsResponse, error)
+
+	// SetCustomDNSRecordsWithBodyWithResponse request with any body
+	SetCustomDNSRecordsWithBodyWithResponse(ctx context.Context, name string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*SetCustomDNSRecordsResponse, error)
+
+	SetCustomDNSRecordsWithResponse(ctx context.Context, name string, body SetCustomDNSRecordsJSONRequestBody, reqEditors ...RequestEditorFn) (*SetCustomDNSRecordsResponse, error)
+
+	// ListsWithResponse request
+	ListsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListsResponse, error)
+
+	// ListLookupWithResponse request
+	ListLookupWithResponse(ctx context.Context, params *ListLookupParams, reqEditors ...RequestEditorFn) (*ListLookupResponse, error)
+
+	// ListRefreshWithResponse request
+	ListRefreshWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error)
+
+	// ListSourcesWithResponse request
+	ListSourcesWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListSourcesResponse, error)
+
+	// QueryWithBodyWithResponse request with any body
+	QueryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error)
+
+	QueryWithResponse(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error)
+
+	// StartupSummaryWithResponse request
+	StartupSummaryWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*StartupSummaryResponse, error)
+
+	// ResetClientStatsWithResponse request
+	ResetClientStatsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ResetClientStatsResponse, error)
+
+	// ClientStatsWithResponse request
+	ClientStatsWithResponse(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*ClientStatsResponse, error)
+
+	// UpstreamStatusWithResponse request
+	UpstreamStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*UpstreamStatusResponse, error)
+}
+
+type RemoveAllowEntryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r RemoveAllowEntryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RemoveAllowEntryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type AddAllowEntryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r AddAllowEntryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r AddAllowEntryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type BlockingCheckResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiBlockingCheck
+}
+
+// Status returns HTTPResponse.Status
+func (r BlockingCheckResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r BlockingCheckResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RemoveDenyEntryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r RemoveDenyEntryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RemoveDenyEntryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type AddDenyEntryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r AddDenyEntryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r AddDenyEntryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DisableBlockingResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DisableBlockingResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DisableBlockingResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type EnableBlockingResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r EnableBlockingResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r EnableBlockingResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type BlockingEntriesResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiBlockingEntry
+}
+
+// Status returns HTTPResponse.Status
+func (r BlockingEntriesResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r BlockingEntriesResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type BlockingStatusResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiBlockingStatus
+}
+
+// Status returns HTTPResponse.Status
+func (r BlockingStatusResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r BlockingStatusResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type CustomDNSRecordsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiCustomDNSRecords
+}
+
+// Status returns HTTPResponse.Status
+func (r CustomDNSRecordsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CustomDNSRecordsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RemoveCustomDNSRecordsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r RemoveCustomDNSRecordsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RemoveCustomDNSRecordsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type SetCustomDNSRecordsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r SetCustomDNSRecordsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r SetCustomDNSRecordsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiListGroup
+}
+
+// Status returns HTTPResponse.Status
+func (r ListsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListLookupResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiListLookup
+}
+
+// Status returns HTTPResponse.Status
+func (r ListLookupResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListLookupResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListRefreshResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r ListRefreshResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListRefreshResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListSourcesResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiListSource
+}
+
+// Status returns HTTPResponse.Status
+func (r ListSourcesResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListSourcesResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type QueryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiQueryResult
+	JSON400      *ApiError
+	JSON502      *ApiError
+	JSON504      *ApiError
+}
+
+// Status returns HTTPResponse.Status
+func (r QueryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r QueryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type StartupSummaryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiStartupSummary
+}
+
+// Status returns HTTPResponse.Status
+func (r StartupSummaryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r StartupSummaryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ResetClientStatsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r ResetClientStatsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ResetClientStatsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ClientStatsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiClientStats
+}
+
+// Status returns HTTPResponse.Status
+func (r ClientStatsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ClientStatsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type UpstreamStatusResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiUpstreamStatus
+}
+
+// Status returns HTTPResponse.Status
+func (r UpstreamStatusResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UpstreamStatusResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// RemoveAllowEntryWithBodyWithResponse request with arbitrary body returning *RemoveAllowEntryResponse
+func (c *ClientWithResponses) RemoveAllowEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*RemoveAllowEntryResponse, error) {
+	rsp, err := c.RemoveAllowEntryWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRemoveAllowEntryResponse(rsp)
+}
+
+func (c *ClientWithResponses) RemoveAllowEntryWithResponse(ctx context.Context, body RemoveAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*RemoveAllowEntryResponse, error) {
+	rsp, err := c.RemoveAllowEntry(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRemoveAllowEntryResponse(rsp)
+}
+
+// AddAllowEntryWithBodyWithResponse request with arbitrary body returning *AddAllowEntryResponse
+func (c *ClientWithResponses) AddAllowEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*AddAllowEntryResponse, error) {
+	rsp, err := c.AddAllowEntryWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAddAllowEntryResponse(rsp)
+}
+
+func (c *ClientWithResponses) AddAllowEntryWithResponse(ctx context.Context, body AddAllowEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*AddAllowEntryResponse, error) {
+	rsp, err := c.AddAllowEntry(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
+	return ParseAddAllowEntryResponse(rsp)
 }
 
-// ClientWithResponsesInterface is the interface specification for the client with responses above.
-type ClientWithResponsesInterface interface {
-	// DisableBlockingWithResponse request
-	DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error)
+// BlockingCheckWithResponse request returning *BlockingCheckResponse
+func (c *ClientWithResponses) BlockingCheckWithResponse(ctx context.Context, params *BlockingCheckParams, reqEditors ...RequestEditorFn) (*BlockingCheckResponse, error) {
+	rsp, err := c.BlockingCheck(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBlockingCheckResponse(rsp)
+}
 
-	// EnableBlockingWithResponse request
-	EnableBlockingWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error)
+// RemoveDenyEntryWithBodyWithResponse request with arbitrary body returning *RemoveDenyEntryResponse
+func (c *ClientWithResponses) RemoveDenyEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*RemoveDenyEntryResponse, error) {
+	rsp, err := c.RemoveDenyEntryWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRemoveDenyEntryResponse(rsp)
+}
 
-	// BlockingStatusWithResponse request
-	BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error)
+func (c *ClientWithResponses) RemoveDenyEntryWithResponse(ctx context.Context, body RemoveDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*RemoveDenyEntryResponse, error) {
+	rsp, err := c.RemoveDenyEntry(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRemoveDenyEntryResponse(rsp)
+}
 
-	// ListRefreshWithResponse request
-	ListRefreshWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error)
+// AddDenyEntryWithBodyWithResponse request with arbitrary body returning *AddDenyEntryResponse
+func (c *ClientWithResponses) AddDenyEntryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*AddDenyEntryResponse, error) {
+	rsp, err := c.AddDenyEntryWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAddDenyEntryResponse(rsp)
+}
 
-	// QueryWithBodyWithResponse request with any body
-	QueryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error)
+func (c *ClientWithResponses) AddDenyEntryWithResponse(ctx context.Context, body AddDenyEntryJSONRequestBody, reqEditors ...RequestEditorFn) (*AddDenyEntryResponse, error) {
+	rsp, err := c.AddDenyEntry(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAddDenyEntryResponse(rsp)
+}
 
-	QueryWithResponse(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error)
+// DisableBlockingWithResponse request returning *DisableBlockingResponse
+func (c *ClientWithResponses) DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error) {
+	rsp, err := c.DisableBlocking(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDisableBlockingResponse(rsp)
 }
 
-type DisableBlockingResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
+// EnableBlockingWithResponse request returning *EnableBlockingResponse
+func (c *ClientWithResponses) EnableBlockingWithResponse(ctx context.Context, params *EnableBlockingParams, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error) {
+	rsp, err := c.EnableBlocking(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEnableBlockingResponse(rsp)
 }
 
-// Status returns HTTPResponse.Status
-func (r DisableBlockingResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// BlockingEntriesWithResponse request returning *BlockingEntriesResponse
+func (c *ClientWithResponses) BlockingEntriesWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingEntriesResponse, error) {
+	rsp, err := c.BlockingEntries(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+	return ParseBlockingEntriesResponse(rsp)
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r DisableBlockingResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// BlockingStatusWithResponse request returning *BlockingStatusResponse
+func (c *ClientWithResponses) BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error) {
+	rsp, err := c.BlockingStatus(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
-	return 0
+	return ParseBlockingStatusResponse(rsp)
 }
 
-type EnableBlockingResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
+// CustomDNSRecordsWithResponse request returning *CustomDNSRecordsResponse
+func (c *ClientWithResponses) CustomDNSRecordsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*CustomDNSRecordsResponse, error) {
+	rsp, err := c.CustomDNSRecords(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCustomDNSRecordsResponse(rsp)
 }
 
-// Status returns HTTPResponse.Status
-func (r EnableBlockingResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// RemoveCustomDNSRecordsWithResponse request returning *RemoveCustomDNSRecordsResponse
+func (c *ClientWithResponses) RemoveCustomDNSRecordsWithResponse(ctx context.Context, name string, reqEditors ...RequestEditorFn) (*RemoveCustomDNSRecordsResponse, error) {
+	rsp, err := c.RemoveCustomDNSRecords(ctx, name, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+	return ParseRemoveCustomDNSRecordsResponse(rsp)
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r EnableBlockingResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// SetCustomDNSRecordsWithBodyWithResponse request with arbitrary body returning *SetCustomDNSRecordsResponse
+func (c *ClientWithResponses) SetCustomDNSRecordsWithBodyWithResponse(ctx context.Context, name string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*SetCustomDNSRecordsResponse, error) {
+	rsp, err := c.SetCustomDNSRecordsWithBody(ctx, name, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSetCustomDNSRecordsResponse(rsp)
+}
+
+func (c *ClientWithResponses) SetCustomDNSRecordsWithResponse(ctx context.Context, name string, body SetCustomDNSRecordsJSONRequestBody, reqEditors ...RequestEditorFn) (*SetCustomDNSRecordsResponse, error) {
+	rsp, err := c.SetCustomDNSRecords(ctx, name, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSetCustomDNSRecordsResponse(rsp)
+}
+
+// ListsWithResponse request returning *ListsResponse
+func (c *ClientWithResponses) ListsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListsResponse, error) {
+	rsp, err := c.Lists(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListsResponse(rsp)
+}
+
+// ListLookupWithResponse request returning *ListLookupResponse
+func (c *ClientWithResponses) ListLookupWithResponse(ctx context.Context, params *ListLookupParams, reqEditors ...RequestEditorFn) (*ListLookupResponse, error) {
+	rsp, err := c.ListLookup(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListLookupResponse(rsp)
+}
+
+// ListRefreshWithResponse request returning *ListRefreshResponse
+func (c *ClientWithResponses) ListRefreshWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error) {
+	rsp, err := c.ListRefresh(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListRefreshResponse(rsp)
+}
+
+// ListSourcesWithResponse request returning *ListSourcesResponse
+func (c *ClientWithResponses) ListSourcesWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListSourcesResponse, error) {
+	rsp, err := c.ListSources(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListSourcesResponse(rsp)
+}
+
+// QueryWithBodyWithResponse request with arbitrary body returning *QueryResponse
+func (c *ClientWithResponses) QueryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
+	rsp, err := c.QueryWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQueryResponse(rsp)
+}
+
+func (c *ClientWithResponses) QueryWithResponse(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
+	rsp, err := c.Query(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQueryResponse(rsp)
+}
+
+// StartupSummaryWithResponse request returning *StartupSummaryResponse
+func (c *ClientWithResponses) StartupSummaryWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*StartupSummaryResponse, error) {
+	rsp, err := c.StartupSummary(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStartupSummaryResponse(rsp)
+}
+
+// ResetClientStatsWithResponse request returning *ResetClientStatsResponse
+func (c *ClientWithResponses) ResetClientStatsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ResetClientStatsResponse, error) {
+	rsp, err := c.ResetClientStats(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResetClientStatsResponse(rsp)
+}
+
+// ClientStatsWithResponse request returning *ClientStatsResponse
+func (c *ClientWithResponses) ClientStatsWithResponse(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*ClientStatsResponse, error) {
+	rsp, err := c.ClientStats(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseClientStatsResponse(rsp)
+}
+
+// UpstreamStatusWithResponse request returning *UpstreamStatusResponse
+func (c *ClientWithResponses) UpstreamStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*UpstreamStatusResponse, error) {
+	rsp, err := c.UpstreamStatus(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUpstreamStatusResponse(rsp)
+}
+
+// ParseRemoveAllowEntryResponse parses an HTTP response from a RemoveAllowEntryWithResponse call
+func ParseRemoveAllowEntryResponse(rsp *http.Response) (*RemoveAllowEntryResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RemoveAllowEntryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseAddAllowEntryResponse parses an HTTP response from a AddAllowEntryWithResponse call
+func ParseAddAllowEntryResponse(rsp *http.Response) (*AddAllowEntryResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &AddAllowEntryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseBlockingCheckResponse parses an HTTP response from a BlockingCheckWithResponse call
+func ParseBlockingCheckResponse(rsp *http.Response) (*BlockingCheckResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &BlockingCheckResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ApiBlockingCheck
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseRemoveDenyEntryResponse parses an HTTP response from a RemoveDenyEntryWithResponse call
+func ParseRemoveDenyEntryResponse(rsp *http.Response) (*RemoveDenyEntryResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RemoveDenyEntryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseAddDenyEntryResponse parses an HTTP response from a AddDenyEntryWithResponse call
+func ParseAddDenyEntryResponse(rsp *http.Response) (*AddDenyEntryResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &AddDenyEntryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseDisableBlockingResponse parses an HTTP response from a DisableBlockingWithResponse call
+func ParseDisableBlockingResponse(rsp *http.Response) (*DisableBlockingResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DisableBlockingResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseEnableBlockingResponse parses an HTTP response from a EnableBlockingWithResponse call
+func ParseEnableBlockingResponse(rsp *http.Response) (*EnableBlockingResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &EnableBlockingResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type BlockingStatusResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *ApiBlockingStatus
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r BlockingStatusResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseBlockingEntriesResponse parses an HTTP response from a BlockingEntriesWithResponse call
+func ParseBlockingEntriesResponse(rsp *http.Response) (*BlockingEntriesResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r BlockingStatusResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &BlockingEntriesResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type ListRefreshResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-}
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiBlockingEntry
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-// Status returns HTTPResponse.Status
-func (r ListRefreshResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r ListRefreshResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
-	}
-	return 0
+	return response, nil
 }
 
-type QueryResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *ApiQueryResult
-}
+// ParseBlockingStatusResponse parses an HTTP response from a BlockingStatusWithResponse call
+func ParseBlockingStatusResponse(rsp *http.Response) (*BlockingStatusResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
 
-// Status returns HTTPResponse.Status
-func (r QueryResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+	response := &BlockingStatusResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r QueryResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ApiBlockingStatus
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
 	}
-	return 0
+
+	return response, nil
 }
 
-// DisableBlockingWithResponse request returning *DisableBlockingResponse
-func (c *ClientWithResponses) DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error) {
-	rsp, err := c.DisableBlocking(ctx, params, reqEditors...)
+// ParseCustomDNSRecordsResponse parses an HTTP response from a CustomDNSRecordsWithResponse call
+func ParseCustomDNSRecordsResponse(rsp *http.Response) (*CustomDNSRecordsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseDisableBlockingResponse(rsp)
+
+	response := &CustomDNSRecordsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiCustomDNSRecords
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// EnableBlockingWithResponse request returning *EnableBlockingResponse
-func (c *ClientWithResponses) EnableBlockingWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error) {
-	rsp, err := c.EnableBlocking(ctx, reqEditors...)
+// ParseRemoveCustomDNSRecordsResponse parses an HTTP response from a RemoveCustomDNSRecordsWithResponse call
+func ParseRemoveCustomDNSRecordsResponse(rsp *http.Response) (*RemoveCustomDNSRecordsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseEnableBlockingResponse(rsp)
+
+	response := &RemoveCustomDNSRecordsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
 }
 
-// BlockingStatusWithResponse request returning *BlockingStatusResponse
-func (c *ClientWithResponses) BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error) {
-	rsp, err := c.BlockingStatus(ctx, reqEditors...)
+// ParseSetCustomDNSRecordsResponse parses an HTTP response from a SetCustomDNSRecordsWithResponse call
+func ParseSetCustomDNSRecordsResponse(rsp *http.Response) (*SetCustomDNSRecordsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseBlockingStatusResponse(rsp)
+
+	response := &SetCustomDNSRecordsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
 }
 
-// ListRefreshWithResponse request returning *ListRefreshResponse
-func (c *ClientWithResponses) ListRefreshWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error) {
-	rsp, err := c.ListRefresh(ctx, reqEditors...)
+// ParseListsResponse parses an HTTP response from a ListsWithResponse call
+func ParseListsResponse(rsp *http.Response) (*ListsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseListRefreshResponse(rsp)
+
+	response := &ListsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiListGroup
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// QueryWithBodyWithResponse request with arbitrary body returning *QueryResponse
-func (c *ClientWithResponses) QueryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
-	rsp, err := c.QueryWithBody(ctx, contentType, body, reqEditors...)
+// ParseListLookupResponse parses an HTTP response from a ListLookupWithResponse call
+func ParseListLookupResponse(rsp *http.Response) (*ListLookupResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseQueryResponse(rsp)
+
+	response := &ListLookupResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ApiListLookup
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-func (c *ClientWithResponses) QueryWithResponse(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
-	rsp, err := c.Query(ctx, body, reqEditors...)
+// ParseListRefreshResponse parses an HTTP response from a ListRefreshWithResponse call
+func ParseListRefreshResponse(rsp *http.Response) (*ListRefreshResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseQueryResponse(rsp)
+
+	response := &ListRefreshResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
 }
 
-// ParseDisableBlockingResponse parses an HTTP response from a DisableBlockingWithResponse call
-func ParseDisableBlockingResponse(rsp *http.Response) (*DisableBlockingResponse, error) {
+// ParseListSourcesResponse parses an HTTP response from a ListSourcesWithResponse call
+func ParseListSourcesResponse(rsp *http.Response) (*ListSourcesResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &DisableBlockingResponse{
+	response := &ListSourcesResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiListSource
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
 	return response, nil
 }
 
-// ParseEnableBlockingResponse parses an HTTP response from a EnableBlockingWithResponse call
-func ParseEnableBlockingResponse(rsp *http.Response) (*EnableBlockingResponse, error) {
+// ParseQueryResponse parses an HTTP response from a QueryWithResponse call
+func ParseQueryResponse(rsp *http.Response) (*QueryResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &EnableBlockingResponse{
+	response := &QueryResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ApiQueryResult
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest ApiError
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 502:
+		var dest ApiError
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON502 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 504:
+		var dest ApiError
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON504 = &dest
+
+	}
+
 	return response, nil
 }
 
-// ParseBlockingStatusResponse parses an HTTP response from a BlockingStatusWithResponse call
-func ParseBlockingStatusResponse(rsp *http.Response) (*BlockingStatusResponse, error) {
+// ParseStartupSummaryResponse parses an HTTP response from a StartupSummaryWithResponse call
+func ParseStartupSummaryResponse(rsp *http.Response) (*StartupSummaryResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &BlockingStatusResponse{
+	response := &StartupSummaryResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest ApiBlockingStatus
+		var dest ApiStartupSummary
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -644,15 +2524,15 @@ func ParseBlockingStatusResponse(rsp *http.Response) (*BlockingStatusResponse, e
 	return response, nil
 }
 
-// ParseListRefreshResponse parses an HTTP response from a ListRefreshWithResponse call
-func ParseListRefreshResponse(rsp *http.Response) (*ListRefreshResponse, error) {
+// ParseResetClientStatsResponse parses an HTTP response from a ResetClientStatsWithResponse call
+func ParseResetClientStatsResponse(rsp *http.Response) (*ResetClientStatsResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &ListRefreshResponse{
+	response := &ResetClientStatsResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -660,22 +2540,48 @@ func ParseListRefreshResponse(rsp *http.Response) (*ListRefreshResponse, error)
 	return response, nil
 }
 
-// ParseQueryResponse parses an HTTP response from a QueryWithResponse call
-func ParseQueryResponse(rsp *http.Response) (*QueryResponse, error) {
+// ParseClientStatsResponse parses an HTTP response from a ClientStatsWithResponse call
+func ParseClientStatsResponse(rsp *http.Response) (*ClientStatsResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &QueryResponse{
+	response := &ClientStatsResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest ApiQueryResult
+		var dest ApiClientStats
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseUpstreamStatusResponse parses an HTTP response from a UpstreamStatusWithResponse call
+func ParseUpstreamStatusResponse(rsp *http.Response) (*UpstreamStatusResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UpstreamStatusResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiUpstreamStatus
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
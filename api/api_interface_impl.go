@@ -5,8 +5,13 @@
 package api
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,18 +30,229 @@ type BlockingStatus struct {
 	DisabledGroups []string
 	// If blocking is temporary disabled: amount of seconds until blocking will be enabled
 	AutoEnableInSec int
+	// Clients with disabled blocking
+	DisabledClients []DisabledClient
 }
 
-// BlockingControl interface to control the blocking status
+// DisabledClient represents a client (IP or client name) with disabled blocking
+type DisabledClient struct {
+	Client string
+	// Disabled group names
+	DisabledGroups []string
+	// If blocking is temporary disabled: amount of seconds until blocking will be enabled for the client
+	AutoEnableInSec int
+}
+
+// BlockingControl interface to control the blocking status.
+// An empty client controls the blocking status of all clients
 type BlockingControl interface {
-	EnableBlocking()
-	DisableBlocking(duration time.Duration, disableGroups []string) error
+	EnableBlocking(client string)
+	DisableBlocking(duration time.Duration, disableGroups []string, client string) error
 	BlockingStatus() BlockingStatus
 }
 
-// ListRefresher interface to control the list refresh
+// BlockingEntryType type of a black- or whitelist entry added at runtime
+type BlockingEntryType string
+
+const (
+	// BlockingEntryDeny blacklist entry
+	BlockingEntryDeny BlockingEntryType = "deny"
+	// BlockingEntryAllow whitelist entry
+	BlockingEntryAllow BlockingEntryType = "allow"
+)
+
+// BlockingEntry a black- or whitelist entry added at runtime
+type BlockingEntry struct {
+	Type   BlockingEntryType
+	Group  string
+	Domain string
+	// TTL of a temporary entry (remaining TTL if listed), 0 for permanent entries
+	TTL time.Duration
+}
+
+// BlockingEntries interface to add and remove black- and whitelist entries at runtime
+type BlockingEntries interface {
+	AddBlockingEntry(entry BlockingEntry) error
+	RemoveBlockingEntry(entry BlockingEntry) error
+	BlockingEntries() []BlockingEntry
+}
+
+// BlockingCheckMatch a black- or whitelist entry which matched the checked domain
+type BlockingCheckMatch struct {
+	Group string
+	// List source or "runtime entries"
+	Source string
+	Entry  string
+}
+
+// BlockingCheck result of a check if a domain would be blocked
+type BlockingCheck struct {
+	Domain string
+	// Groups checked for the client
+	Groups  []string
+	Blocked bool
+	// Reason of the block, empty if not blocked
+	Reason string
+	// True if an allow entry prevents the domain from being blocked
+	AllowOverride bool
+	DenyMatches   []BlockingCheckMatch
+	AllowMatches  []BlockingCheckMatch
+}
+
+// BlockingChecker interface to check if a domain would be blocked
+type BlockingChecker interface {
+	CheckBlocking(domain, client string) (BlockingCheck, error)
+}
+
+// ListSource a source of a black- or whitelist
+type ListSource struct {
+	// Type of the list (blacklist or whitelist)
+	Type   string
+	Group  string
+	Source string
+	// Time of the last refresh which loaded changes, zero if the source wasn't loaded yet
+	LastChanged time.Time
+}
+
+// ListGroup a black- or whitelist group with the statistics of its sources
+type ListGroup struct {
+	// Type of the list (blacklist or whitelist)
+	Type  string
+	Group string
+	// Number of entries of all sources
+	Entries int
+	Sources []ListGroupSource
+}
+
+// ListGroupSource the statistics of the refreshes of a list source
+type ListGroupSource struct {
+	Source  string
+	Entries int
+	// Time of the last refresh which loaded changes, zero if the source wasn't loaded yet
+	LastChanged time.Time
+	// Time of the last successful refresh, zero if there was none
+	LastSuccess time.Time
+	// Error of the last refresh, nil if it succeeded
+	LastError  error
+	ErrorCount uint
+}
+
+// ListLookup the list entries which match a domain
+type ListLookup struct {
+	Domain  string
+	Matches []ListLookupMatch
+}
+
+// ListLookupMatch a list entry which matches a domain
+type ListLookupMatch struct {
+	// Type of the list (blacklist or whitelist)
+	Type   string
+	Group  string
+	Source string
+	Entry  string
+	// Match kind of the match (exact, parent, wildcard, regex or cidr)
+	Match string
+	// True for exception entries, which exempt the domain from the other entries of the group
+	Exception bool
+}
+
+// ListRefresher interface to control the list refresh and inspect the loaded lists
 type ListRefresher interface {
 	RefreshLists() error
+	ListSources() []ListSource
+	ListGroups() []ListGroup
+	LookupLists(domain string) (ListLookup, error)
+}
+
+// ClientStats query statistics of the clients with the most queries
+type ClientStats struct {
+	// Start of the statistics (last reset)
+	Since   time.Time
+	Clients []ClientStatsEntry
+}
+
+// ClientStatsEntry query statistics of a single client
+type ClientStatsEntry struct {
+	Client  string
+	Total   int
+	Blocked int
+	// Max number of queries which were sent before the client was tracked
+	ErrorBound int
+	// Number of queries per query type
+	QueryTypes map[string]int
+	// Number of queries per response type
+	ResponseTypes map[string]int
+}
+
+// ClientStatsProvider interface to read and reset the per client query statistics
+type ClientStatsProvider interface {
+	ClientStats() (ClientStats, error)
+	ResetClientStats() error
+}
+
+// UpstreamVerificationState verification state of an upstream
+type UpstreamVerificationState string
+
+const (
+	// UpstreamVerificationActive the upstream is used (verified or verification disabled)
+	UpstreamVerificationActive UpstreamVerificationState = "active"
+	// UpstreamVerificationPending the verification failed and is retried in the background
+	UpstreamVerificationPending UpstreamVerificationState = "pending"
+	// UpstreamVerificationFailed all verification attempts failed, the upstream is not used
+	UpstreamVerificationFailed UpstreamVerificationState = "failed"
+)
+
+// UpstreamStatus verification status of a single upstream
+type UpstreamStatus struct {
+	Group    string
+	Upstream string
+	State    UpstreamVerificationState
+	// Number of failed verification attempts
+	FailedAttempts uint
+	// Error of the last failed verification
+	LastError string
+}
+
+// UpstreamStatusProvider interface to read the verification status of the upstreams
+type UpstreamStatusProvider interface {
+	UpstreamStatus() []UpstreamStatus
+}
+
+// StartupPhase duration of a single phase of the startup
+type StartupPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// StartupSummary duration of the startup, broken down by phase
+type StartupSummary struct {
+	Duration time.Duration
+	Phases   []StartupPhase
+}
+
+// StartupReporter interface to read the durations of the startup
+type StartupReporter interface {
+	StartupSummary() StartupSummary
+}
+
+// CustomDNSRecords the records of a custom DNS name
+type CustomDNSRecords struct {
+	Name string
+	// Source of the records: runtime, mapping, zone file or the client group, e.g. "client group 10.6.0.0/24"
+	Source string
+	// Records in zone file syntax without name, TTL and class, e.g. "A 192.168.178.3"
+	Records []string
+	// TTL of the records, nil for zone file records which keep their own TTL
+	TTL *time.Duration
+}
+
+// CustomDNSRecordsManager interface to set and remove custom DNS records at runtime
+type CustomDNSRecordsManager interface {
+	// SetCustomDNSRecords replaces the runtime records of the name, without TTL the customTTL is used
+	SetCustomDNSRecords(name string, records []string, ttl *time.Duration) error
+	RemoveCustomDNSRecords(name string) error
+	// CustomDNSRecords returns the effective records: the runtime records and the configured ones not replaced by them
+	CustomDNSRecords() []CustomDNSRecords
 }
 
 type Querier interface {
@@ -48,16 +264,34 @@ func RegisterOpenAPIEndpoints(router chi.Router, impl StrictServerInterface) {
 }
 
 type OpenAPIInterfaceImpl struct {
-	control   BlockingControl
-	querier   Querier
-	refresher ListRefresher
+	control     BlockingControl
+	entries     BlockingEntries
+	checker     BlockingChecker
+	querier     Querier
+	refresher   ListRefresher
+	clientStats ClientStatsProvider
+	upstreams   UpstreamStatusProvider
+	startup     StartupReporter
+	customDNS   CustomDNSRecordsManager
+	// errorDetails adds the internal error, which may contain upstream addresses, to error responses
+	errorDetails bool
 }
 
-func NewOpenAPIInterfaceImpl(control BlockingControl, querier Querier, refresher ListRefresher) *OpenAPIInterfaceImpl {
+func NewOpenAPIInterfaceImpl(control BlockingControl, entries BlockingEntries, checker BlockingChecker,
+	querier Querier, refresher ListRefresher, clientStats ClientStatsProvider, upstreams UpstreamStatusProvider,
+	startup StartupReporter, customDNS CustomDNSRecordsManager, errorDetails bool,
+) *OpenAPIInterfaceImpl {
 	return &OpenAPIInterfaceImpl{
-		control:   control,
-		querier:   querier,
-		refresher: refresher,
+		control:      control,
+		entries:      entries,
+		checker:      checker,
+		querier:      querier,
+		refresher:    refresher,
+		clientStats:  clientStats,
+		upstreams:    upstreams,
+		startup:      startup,
+		customDNS:    customDNS,
+		errorDetails: errorDetails,
 	}
 }
 
@@ -81,7 +315,7 @@ func (i *OpenAPIInterfaceImpl) DisableBlocking(_ context.Context,
 		groups = strings.Split(*request.Params.Groups, ",")
 	}
 
-	err = i.control.DisableBlocking(duration, groups)
+	err = i.control.DisableBlocking(duration, groups, clientParam(request.Params.Client))
 
 	if err != nil {
 		return DisableBlocking400TextResponse(log.EscapeInput(err.Error())), nil
@@ -90,13 +324,22 @@ func (i *OpenAPIInterfaceImpl) DisableBlocking(_ context.Context,
 	return DisableBlocking200Response{}, nil
 }
 
-func (i *OpenAPIInterfaceImpl) EnableBlocking(_ context.Context, _ EnableBlockingRequestObject,
+func (i *OpenAPIInterfaceImpl) EnableBlocking(_ context.Context, request EnableBlockingRequestObject,
 ) (EnableBlockingResponseObject, error) {
-	i.control.EnableBlocking()
+	i.control.EnableBlocking(clientParam(request.Params.Client))
 
 	return EnableBlocking200Response{}, nil
 }
 
+// clientParam returns the value of the optional client parameter, empty for all clients
+func clientParam(client *string) string {
+	if client == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(*client)
+}
+
 func (i *OpenAPIInterfaceImpl) BlockingStatus(_ context.Context, _ BlockingStatusRequestObject,
 ) (BlockingStatusResponseObject, error) {
 	blStatus := i.control.BlockingStatus()
@@ -113,9 +356,266 @@ func (i *OpenAPIInterfaceImpl) BlockingStatus(_ context.Context, _ BlockingStatu
 		result.DisabledGroups = &blStatus.DisabledGroups
 	}
 
+	if len(blStatus.DisabledClients) > 0 {
+		clients := make([]ApiDisabledClient, 0, len(blStatus.DisabledClients))
+
+		for _, c := range blStatus.DisabledClients {
+			client := ApiDisabledClient{
+				Client:         c.Client,
+				DisabledGroups: c.DisabledGroups,
+			}
+
+			if c.AutoEnableInSec > 0 {
+				client.AutoEnableInSec = &c.AutoEnableInSec
+			}
+
+			clients = append(clients, client)
+		}
+
+		result.DisabledClients = &clients
+	}
+
 	return BlockingStatus200JSONResponse(result), nil
 }
 
+func (i *OpenAPIInterfaceImpl) AddDenyEntry(_ context.Context,
+	request AddDenyEntryRequestObject,
+) (AddDenyEntryResponseObject, error) {
+	entry, err := temporaryBlockingEntry(BlockingEntryDeny, request.Body)
+	if err == nil {
+		err = i.entries.AddBlockingEntry(entry)
+	}
+
+	if err != nil {
+		return AddDenyEntry400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return AddDenyEntry200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) RemoveDenyEntry(_ context.Context,
+	request RemoveDenyEntryRequestObject,
+) (RemoveDenyEntryResponseObject, error) {
+	err := i.entries.RemoveBlockingEntry(blockingEntry(BlockingEntryDeny, request.Body))
+	if err != nil {
+		return RemoveDenyEntry400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return RemoveDenyEntry200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) AddAllowEntry(_ context.Context,
+	request AddAllowEntryRequestObject,
+) (AddAllowEntryResponseObject, error) {
+	entry, err := temporaryBlockingEntry(BlockingEntryAllow, request.Body)
+	if err == nil {
+		err = i.entries.AddBlockingEntry(entry)
+	}
+
+	if err != nil {
+		return AddAllowEntry400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return AddAllowEntry200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) RemoveAllowEntry(_ context.Context,
+	request RemoveAllowEntryRequestObject,
+) (RemoveAllowEntryResponseObject, error) {
+	err := i.entries.RemoveBlockingEntry(blockingEntry(BlockingEntryAllow, request.Body))
+	if err != nil {
+		return RemoveAllowEntry400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return RemoveAllowEntry200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) BlockingEntries(_ context.Context,
+	_ BlockingEntriesRequestObject,
+) (BlockingEntriesResponseObject, error) {
+	entries := slices.Clone(i.entries.BlockingEntries())
+
+	slices.SortFunc(entries, func(a, b BlockingEntry) int {
+		if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+			return c
+		}
+
+		if c := strings.Compare(a.Group, b.Group); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Domain, b.Domain)
+	})
+
+	result := make([]ApiBlockingEntry, 0, len(entries))
+
+	for _, e := range entries {
+		entry := ApiBlockingEntry{
+			Type:   string(e.Type),
+			Group:  e.Group,
+			Domain: e.Domain,
+		}
+
+		if e.TTL > 0 {
+			// round up, so an entry which is about to expire isn't listed with 0 seconds
+			expiresInSec := int(math.Ceil(e.TTL.Seconds()))
+			entry.ExpiresInSec = &expiresInSec
+		}
+
+		result = append(result, entry)
+	}
+
+	return BlockingEntries200JSONResponse(result), nil
+}
+
+func blockingEntry(entryType BlockingEntryType, request *ApiBlockingEntryRequest) BlockingEntry {
+	return BlockingEntry{
+		Type:   entryType,
+		Group:  request.Group,
+		Domain: request.Domain,
+	}
+}
+
+// temporaryBlockingEntry returns the entry with the TTL of the request, if set
+func temporaryBlockingEntry(entryType BlockingEntryType, request *ApiBlockingEntryRequest) (BlockingEntry, error) {
+	entry := blockingEntry(entryType, request)
+
+	if request.Ttl != nil {
+		ttl, err := time.ParseDuration(*request.Ttl)
+		if err != nil {
+			return entry, err
+		}
+
+		if ttl <= 0 {
+			return entry, fmt.Errorf("invalid ttl '%s', must be positive", *request.Ttl)
+		}
+
+		entry.TTL = ttl
+	}
+
+	return entry, nil
+}
+
+func (i *OpenAPIInterfaceImpl) SetCustomDNSRecords(_ context.Context,
+	request SetCustomDNSRecordsRequestObject,
+) (SetCustomDNSRecordsResponseObject, error) {
+	var ttl *time.Duration
+
+	if request.Body.Ttl != nil {
+		d, err := time.ParseDuration(*request.Body.Ttl)
+		if err != nil {
+			return SetCustomDNSRecords400TextResponse(log.EscapeInput(err.Error())), nil
+		}
+
+		if d < 0 {
+			return SetCustomDNSRecords400TextResponse(
+				log.EscapeInput(fmt.Sprintf("invalid ttl '%s', must not be negative", *request.Body.Ttl))), nil
+		}
+
+		ttl = &d
+	}
+
+	err := i.customDNS.SetCustomDNSRecords(request.Name, request.Body.Records, ttl)
+	if err != nil {
+		return SetCustomDNSRecords400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return SetCustomDNSRecords200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) RemoveCustomDNSRecords(_ context.Context,
+	request RemoveCustomDNSRecordsRequestObject,
+) (RemoveCustomDNSRecordsResponseObject, error) {
+	err := i.customDNS.RemoveCustomDNSRecords(request.Name)
+	if err != nil {
+		return RemoveCustomDNSRecords400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return RemoveCustomDNSRecords200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) CustomDNSRecords(_ context.Context,
+	_ CustomDNSRecordsRequestObject,
+) (CustomDNSRecordsResponseObject, error) {
+	records := slices.Clone(i.customDNS.CustomDNSRecords())
+
+	slices.SortFunc(records, func(a, b CustomDNSRecords) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Source, b.Source)
+	})
+
+	result := make([]ApiCustomDNSRecords, 0, len(records))
+
+	for _, r := range records {
+		entry := ApiCustomDNSRecords{
+			Name:    r.Name,
+			Source:  r.Source,
+			Records: nonNil(r.Records),
+		}
+
+		if r.TTL != nil {
+			ttlInSec := int(r.TTL.Seconds())
+			entry.TtlInSec = &ttlInSec
+		}
+
+		result = append(result, entry)
+	}
+
+	return CustomDNSRecords200JSONResponse(result), nil
+}
+
+func (i *OpenAPIInterfaceImpl) BlockingCheck(_ context.Context,
+	request BlockingCheckRequestObject,
+) (BlockingCheckResponseObject, error) {
+	var client string
+
+	if request.Params.Client != nil {
+		client = *request.Params.Client
+	}
+
+	check, err := i.checker.CheckBlocking(request.Params.Domain, client)
+	if err != nil {
+		return BlockingCheck400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return BlockingCheck200JSONResponse{
+		Domain:         util.ToUnicode(check.Domain),
+		DomainPunycode: check.Domain,
+		Groups:         nonNil(check.Groups),
+		Blocked:        check.Blocked,
+		Reason:         check.Reason,
+		AllowOverride:  check.AllowOverride,
+		DenyMatches:    blockingCheckMatches(check.DenyMatches),
+		AllowMatches:   blockingCheckMatches(check.AllowMatches),
+	}, nil
+}
+
+func blockingCheckMatches(matches []BlockingCheckMatch) []ApiBlockingCheckMatch {
+	result := make([]ApiBlockingCheckMatch, 0, len(matches))
+
+	for _, m := range matches {
+		result = append(result, ApiBlockingCheckMatch{
+			Group:  m.Group,
+			Source: m.Source,
+			Entry:  m.Entry,
+		})
+	}
+
+	return result
+}
+
+// nonNil returns an empty slice instead of nil, so it is rendered as empty JSON array
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
+
 func (i *OpenAPIInterfaceImpl) ListRefresh(_ context.Context,
 	_ ListRefreshRequestObject,
 ) (ListRefreshResponseObject, error) {
@@ -127,21 +627,292 @@ func (i *OpenAPIInterfaceImpl) ListRefresh(_ context.Context,
 	return ListRefresh200Response{}, nil
 }
 
+func (i *OpenAPIInterfaceImpl) ListSources(_ context.Context,
+	_ ListSourcesRequestObject,
+) (ListSourcesResponseObject, error) {
+	sources := i.refresher.ListSources()
+	result := make([]ApiListSource, 0, len(sources))
+
+	for _, source := range sources {
+		entry := ApiListSource{
+			Type:   source.Type,
+			Group:  source.Group,
+			Source: source.Source,
+		}
+
+		if !source.LastChanged.IsZero() {
+			lastChanged := source.LastChanged
+			entry.LastChanged = &lastChanged
+		}
+
+		result = append(result, entry)
+	}
+
+	return ListSources200JSONResponse(result), nil
+}
+
+func (i *OpenAPIInterfaceImpl) Lists(_ context.Context, _ ListsRequestObject) (ListsResponseObject, error) {
+	groups := i.refresher.ListGroups()
+	result := make([]ApiListGroup, 0, len(groups))
+
+	for _, group := range groups {
+		sources := make([]ApiListGroupSource, 0, len(group.Sources))
+
+		for _, source := range group.Sources {
+			entry := ApiListGroupSource{
+				Source:     source.Source,
+				Entries:    source.Entries,
+				ErrorCount: int(source.ErrorCount),
+			}
+
+			if !source.LastChanged.IsZero() {
+				lastChanged := source.LastChanged
+				entry.LastChanged = &lastChanged
+			}
+
+			if !source.LastSuccess.IsZero() {
+				lastSuccess := source.LastSuccess
+				entry.LastSuccess = &lastSuccess
+			}
+
+			if source.LastError != nil {
+				lastError := log.EscapeInput(source.LastError.Error())
+				entry.LastError = &lastError
+			}
+
+			sources = append(sources, entry)
+		}
+
+		result = append(result, ApiListGroup{
+			Type:    group.Type,
+			Group:   group.Group,
+			Entries: group.Entries,
+			Sources: sources,
+		})
+	}
+
+	return Lists200JSONResponse(result), nil
+}
+
+func (i *OpenAPIInterfaceImpl) ListLookup(_ context.Context,
+	request ListLookupRequestObject,
+) (ListLookupResponseObject, error) {
+	lookup, err := i.refresher.LookupLists(request.Params.Domain)
+	if err != nil {
+		return ListLookup400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	matches := make([]ApiListLookupMatch, 0, len(lookup.Matches))
+
+	for _, m := range lookup.Matches {
+		matches = append(matches, ApiListLookupMatch{
+			Type:      m.Type,
+			Group:     m.Group,
+			Source:    m.Source,
+			Entry:     m.Entry,
+			Match:     ApiListLookupMatchMatch(m.Match),
+			Exception: m.Exception,
+		})
+	}
+
+	return ListLookup200JSONResponse{
+		Domain:         util.ToUnicode(lookup.Domain),
+		DomainPunycode: lookup.Domain,
+		Matches:        matches,
+	}, nil
+}
+
 func (i *OpenAPIInterfaceImpl) Query(_ context.Context, request QueryRequestObject) (QueryResponseObject, error) {
 	qType := dns.Type(dns.StringToType[request.Body.Type])
 	if qType == dns.Type(dns.TypeNone) {
-		return Query400TextResponse(fmt.Sprintf("unknown query type '%s'", request.Body.Type)), nil
+		return Query400JSONResponse(ApiError{
+			Code:    INVALIDQUERY,
+			Message: log.EscapeInput(fmt.Sprintf("unknown query type '%s'", request.Body.Type)),
+		}), nil
+	}
+
+	if _, ok := dns.IsDomainName(request.Body.Query); !ok || request.Body.Query == "" {
+		return Query400JSONResponse(ApiError{
+			Code:    INVALIDQUERY,
+			Message: log.EscapeInput(fmt.Sprintf("invalid domain name '%s'", request.Body.Query)),
+		}), nil
+	}
+
+	question := dns.Fqdn(request.Body.Query)
+
+	resp, err := i.querier.Query(question, qType)
+	if err != nil {
+		log.PrefixedLog("api").Warnf("query of '%s' (%s) failed: %s",
+			log.EscapeInput(request.Body.Query), qType, err)
+
+		return i.queryError(err), nil
+	}
+
+	result := ApiQueryResult{
+		Question:         util.ToUnicode(question),
+		QuestionPunycode: question,
+		Reason:           resp.Reason,
+		ResponseType:     resp.RType.String(),
+		Response:         util.AnswerToString(resp.Res.Answer),
+		ReturnCode:       dns.RcodeToString[resp.Res.Rcode],
+	}
+
+	if resp.BlockOverride != "" {
+		result.BlockOverride = &resp.BlockOverride
+	}
+
+	return Query200JSONResponse(result), nil
+}
+
+// queryError maps a resolver error to an error response without internal details, unless they are enabled
+func (i *OpenAPIInterfaceImpl) queryError(err error) QueryResponseObject {
+	var details *string
+
+	if i.errorDetails {
+		msg := err.Error()
+		details = &msg
+	}
+
+	var netErr net.Error
+
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Query504JSONResponse(ApiError{
+			Code:    UPSTREAMTIMEOUT,
+			Message: "upstream DNS servers didn't answer in time",
+			Details: details,
+		})
+	}
+
+	return Query502JSONResponse(ApiError{
+		Code:    UPSTREAMUNREACHABLE,
+		Message: "upstream DNS servers couldn't be reached or failed to answer",
+		Details: details,
+	})
+}
+
+func (i *OpenAPIInterfaceImpl) ClientStats(_ context.Context,
+	request ClientStatsRequestObject,
+) (ClientStatsResponseObject, error) {
+	sortKey, err := clientStatsSortKey(request.Params.Sort)
+	if err != nil {
+		return ClientStats400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	stats, err := i.clientStats.ClientStats()
+	if err != nil {
+		return ClientStats400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	clients := slices.Clone(stats.Clients)
+
+	slices.SortFunc(clients, func(a, b ClientStatsEntry) int {
+		if c := cmp.Compare(sortKey(b), sortKey(a)); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Client, b.Client)
+	})
+
+	if request.Params.Limit != nil && *request.Params.Limit > 0 && *request.Params.Limit < len(clients) {
+		clients = clients[:*request.Params.Limit]
 	}
 
-	resp, err := i.querier.Query(dns.Fqdn(request.Body.Query), qType)
+	result := ApiClientStats{
+		Since:   stats.Since,
+		Clients: make([]ApiClientStatsEntry, 0, len(clients)),
+	}
+
+	for _, c := range clients {
+		result.Clients = append(result.Clients, ApiClientStatsEntry{
+			Client:         util.ToUnicode(c.Client),
+			ClientPunycode: c.Client,
+			Total:          c.Total,
+			Blocked:        c.Blocked,
+			ErrorBound:     c.ErrorBound,
+			QueryTypes:     c.QueryTypes,
+			ResponseTypes:  c.ResponseTypes,
+		})
+	}
+
+	return ClientStats200JSONResponse(result), nil
+}
+
+func (i *OpenAPIInterfaceImpl) ResetClientStats(_ context.Context,
+	_ ResetClientStatsRequestObject,
+) (ResetClientStatsResponseObject, error) {
+	err := i.clientStats.ResetClientStats()
 	if err != nil {
-		return nil, err
+		return ResetClientStats400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return ResetClientStats200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) UpstreamStatus(_ context.Context,
+	_ UpstreamStatusRequestObject,
+) (UpstreamStatusResponseObject, error) {
+	upstreams := slices.Clone(i.upstreams.UpstreamStatus())
+
+	// keep the configured order within a group
+	slices.SortStableFunc(upstreams, func(a, b UpstreamStatus) int {
+		return strings.Compare(a.Group, b.Group)
+	})
+
+	result := make([]ApiUpstreamStatus, 0, len(upstreams))
+
+	for _, u := range upstreams {
+		status := ApiUpstreamStatus{
+			Group:          u.Group,
+			Upstream:       u.Upstream,
+			State:          string(u.State),
+			FailedAttempts: int(u.FailedAttempts),
+		}
+
+		if u.LastError != "" {
+			lastError := u.LastError
+			status.LastError = &lastError
+		}
+
+		result = append(result, status)
+	}
+
+	return UpstreamStatus200JSONResponse(result), nil
+}
+
+// clientStatsSortKey returns the value to sort the clients by: total (default), blocked or a query type
+func clientStatsSortKey(sort *string) (func(ClientStatsEntry) int, error) {
+	if sort == nil || strings.EqualFold(*sort, "total") {
+		return func(c ClientStatsEntry) int { return c.Total }, nil
+	}
+
+	if strings.EqualFold(*sort, "blocked") {
+		return func(c ClientStatsEntry) int { return c.Blocked }, nil
+	}
+
+	qType := strings.ToUpper(*sort)
+	if _, ok := dns.StringToType[qType]; !ok {
+		return nil, fmt.Errorf("unknown sort order '%s'", *sort)
+	}
+
+	return func(c ClientStatsEntry) int { return c.QueryTypes[qType] }, nil
+}
+
+func (i *OpenAPIInterfaceImpl) StartupSummary(_ context.Context,
+	_ StartupSummaryRequestObject,
+) (StartupSummaryResponseObject, error) {
+	summary := i.startup.StartupSummary()
+
+	result := ApiStartupSummary{
+		DurationMs: summary.Duration.Milliseconds(),
+		Phases:     make([]ApiStartupPhase, 0, len(summary.Phases)),
+	}
+
+	for _, phase := range summary.Phases {
+		result.Phases = append(result.Phases, ApiStartupPhase{
+			Name:       phase.Name,
+			DurationMs: phase.Duration.Milliseconds(),
+		})
 	}
 
-	return Query200JSONResponse(ApiQueryResult{
-		Reason:       resp.Reason,
-		ResponseType: resp.RType.String(),
-		Response:     util.AnswerToString(resp.Res.Answer),
-		ReturnCode:   dns.RcodeToString[resp.Res.Rcode],
-	}), nil
+	return StartupSummary200JSONResponse(result), nil
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	//	. "github.com/0xERR0R/blocky/helpertest"
@@ -20,6 +21,38 @@ type BlockingControlMock struct {
 	mock.Mock
 }
 
+type BlockingEntriesMock struct {
+	mock.Mock
+}
+
+func (m *BlockingEntriesMock) AddBlockingEntry(entry BlockingEntry) error {
+	args := m.Called(entry)
+
+	return args.Error(0)
+}
+
+func (m *BlockingEntriesMock) RemoveBlockingEntry(entry BlockingEntry) error {
+	args := m.Called(entry)
+
+	return args.Error(0)
+}
+
+func (m *BlockingEntriesMock) BlockingEntries() []BlockingEntry {
+	args := m.Called()
+
+	return args.Get(0).([]BlockingEntry)
+}
+
+type BlockingCheckerMock struct {
+	mock.Mock
+}
+
+func (m *BlockingCheckerMock) CheckBlocking(domain, client string) (BlockingCheck, error) {
+	args := m.Called(domain, client)
+
+	return args.Get(0).(BlockingCheck), args.Error(1)
+}
+
 type ListRefreshMock struct {
 	mock.Mock
 }
@@ -28,18 +61,94 @@ type QuerierMock struct {
 	mock.Mock
 }
 
+type ClientStatsMock struct {
+	mock.Mock
+}
+
+func (m *ClientStatsMock) ClientStats() (ClientStats, error) {
+	args := m.Called()
+
+	return args.Get(0).(ClientStats), args.Error(1)
+}
+
+func (m *ClientStatsMock) ResetClientStats() error {
+	args := m.Called()
+
+	return args.Error(0)
+}
+
+type UpstreamStatusMock struct {
+	mock.Mock
+}
+
+func (m *UpstreamStatusMock) UpstreamStatus() []UpstreamStatus {
+	args := m.Called()
+
+	return args.Get(0).([]UpstreamStatus)
+}
+
+type StartupReporterMock struct {
+	mock.Mock
+}
+
+func (m *StartupReporterMock) StartupSummary() StartupSummary {
+	args := m.Called()
+
+	return args.Get(0).(StartupSummary)
+}
+
+type CustomDNSRecordsMock struct {
+	mock.Mock
+}
+
+func (m *CustomDNSRecordsMock) SetCustomDNSRecords(name string, records []string, ttl *time.Duration) error {
+	args := m.Called(name, records, ttl)
+
+	return args.Error(0)
+}
+
+func (m *CustomDNSRecordsMock) RemoveCustomDNSRecords(name string) error {
+	args := m.Called(name)
+
+	return args.Error(0)
+}
+
+func (m *CustomDNSRecordsMock) CustomDNSRecords() []CustomDNSRecords {
+	args := m.Called()
+
+	return args.Get(0).([]CustomDNSRecords)
+}
+
 func (m *ListRefreshMock) RefreshLists() error {
 	args := m.Called()
 
 	return args.Error(0)
 }
 
-func (m *BlockingControlMock) EnableBlocking() {
-	_ = m.Called()
+func (m *ListRefreshMock) ListSources() []ListSource {
+	args := m.Called()
+
+	return args.Get(0).([]ListSource)
+}
+
+func (m *ListRefreshMock) ListGroups() []ListGroup {
+	args := m.Called()
+
+	return args.Get(0).([]ListGroup)
 }
 
-func (m *BlockingControlMock) DisableBlocking(t time.Duration, g []string) error {
-	args := m.Called(t, g)
+func (m *ListRefreshMock) LookupLists(domain string) (ListLookup, error) {
+	args := m.Called(domain)
+
+	return args.Get(0).(ListLookup), args.Error(1)
+}
+
+func (m *BlockingControlMock) EnableBlocking(c string) {
+	_ = m.Called(c)
+}
+
+func (m *BlockingControlMock) DisableBlocking(t time.Duration, g []string, c string) error {
+	args := m.Called(t, g, c)
 
 	return args.Error(0)
 }
@@ -59,22 +168,41 @@ func (m *QuerierMock) Query(question string, qType dns.Type) (*model.Response, e
 var _ = Describe("API implementation tests", func() {
 	var (
 		blockingControlMock *BlockingControlMock
+		blockingEntriesMock *BlockingEntriesMock
+		blockingCheckerMock *BlockingCheckerMock
 		querierMock         *QuerierMock
 		listRefreshMock     *ListRefreshMock
+		clientStatsMock     *ClientStatsMock
+		upstreamStatusMock  *UpstreamStatusMock
+		startupMock         *StartupReporterMock
+		customDNSMock       *CustomDNSRecordsMock
 		sut                 *OpenAPIInterfaceImpl
 	)
 
 	BeforeEach(func() {
 		blockingControlMock = &BlockingControlMock{}
+		blockingEntriesMock = &BlockingEntriesMock{}
+		blockingCheckerMock = &BlockingCheckerMock{}
 		querierMock = &QuerierMock{}
 		listRefreshMock = &ListRefreshMock{}
-		sut = NewOpenAPIInterfaceImpl(blockingControlMock, querierMock, listRefreshMock)
+		clientStatsMock = &ClientStatsMock{}
+		upstreamStatusMock = &UpstreamStatusMock{}
+		startupMock = &StartupReporterMock{}
+		customDNSMock = &CustomDNSRecordsMock{}
+		sut = NewOpenAPIInterfaceImpl(blockingControlMock, blockingEntriesMock, blockingCheckerMock, querierMock,
+			listRefreshMock, clientStatsMock, upstreamStatusMock, startupMock, customDNSMock, false)
 	})
 
 	AfterEach(func() {
 		blockingControlMock.AssertExpectations(GinkgoT())
+		blockingEntriesMock.AssertExpectations(GinkgoT())
+		blockingCheckerMock.AssertExpectations(GinkgoT())
 		querierMock.AssertExpectations(GinkgoT())
 		listRefreshMock.AssertExpectations(GinkgoT())
+		clientStatsMock.AssertExpectations(GinkgoT())
+		upstreamStatusMock.AssertExpectations(GinkgoT())
+		startupMock.AssertExpectations(GinkgoT())
+		customDNSMock.AssertExpectations(GinkgoT())
 	})
 
 	Describe("Query API", func() {
@@ -99,10 +227,49 @@ var _ = Describe("API implementation tests", func() {
 				var resp200 Query200JSONResponse
 				Expect(resp).Should(BeAssignableToTypeOf(resp200))
 				resp200 = resp.(Query200JSONResponse)
+				Expect(resp200.Question).Should(Equal("google.com."))
+				Expect(resp200.QuestionPunycode).Should(Equal("google.com."))
 				Expect(resp200.Reason).Should(Equal("reason"))
 				Expect(resp200.Response).Should(Equal("A (0.0.0.0)"))
 				Expect(resp200.ResponseType).Should(Equal("RESOLVED"))
 				Expect(resp200.ReturnCode).Should(Equal("NOERROR"))
+				Expect(resp200.BlockOverride).Should(BeNil())
+			})
+
+			It("should return the question in unicode and punycode form", func() {
+				queryResponse, err := util.NewMsgWithAnswer("xn--mller-kva.example.", 100, A, "192.0.2.1")
+				Expect(err).Should(Succeed())
+
+				querierMock.On("Query", "xn--mller-kva.example.", A).Return(&model.Response{
+					Res:    queryResponse,
+					Reason: "RESOLVED",
+				}, nil)
+
+				resp, err := sut.Query(context.Background(), QueryRequestObject{
+					Body: &ApiQueryRequest{Query: "xn--mller-kva.example", Type: "A"},
+				})
+				Expect(err).Should(Succeed())
+
+				resp200 := resp.(Query200JSONResponse)
+				Expect(resp200.Question).Should(Equal("müller.example."))
+				Expect(resp200.QuestionPunycode).Should(Equal("xn--mller-kva.example."))
+			})
+
+			It("should return the block override", func() {
+				queryResponse, err := util.NewMsgWithAnswer("example.com.", 100, A, "192.0.2.1")
+				Expect(err).Should(Succeed())
+
+				querierMock.On("Query", "example.com.", A).Return(&model.Response{
+					Res:           queryResponse,
+					Reason:        "RESOLVED",
+					BlockOverride: "WHITELIST (ads: example.com)",
+				}, nil)
+
+				resp, err := sut.Query(context.Background(), QueryRequestObject{
+					Body: &ApiQueryRequest{Query: "example.com", Type: "A"},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp.(Query200JSONResponse).BlockOverride).Should(HaveValue(Equal("WHITELIST (ads: example.com)")))
 			})
 
 			It("should return 400 on wrong parameter", func() {
@@ -113,9 +280,72 @@ var _ = Describe("API implementation tests", func() {
 					},
 				})
 				Expect(err).Should(Succeed())
-				var resp400 Query400TextResponse
-				Expect(resp).Should(BeAssignableToTypeOf(resp400))
-				Expect(resp).Should(Equal(Query400TextResponse("unknown query type 'WRONGTYPE'")))
+				Expect(resp).Should(Equal(Query400JSONResponse(ApiError{
+					Code:    INVALIDQUERY,
+					Message: "unknown query type 'WRONGTYPE'",
+				})))
+			})
+
+			It("should return 400 on an invalid domain name", func() {
+				resp, err := sut.Query(context.Background(), QueryRequestObject{
+					Body: &ApiQueryRequest{
+						Query: "google..com",
+						Type:  "A",
+					},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(Query400JSONResponse{}))
+				Expect(resp.(Query400JSONResponse).Code).Should(Equal(INVALIDQUERY))
+			})
+
+			It("should return 504 if the upstreams timed out", func() {
+				querierMock.On("Query", "google.com.", A).Return((*model.Response)(nil),
+					fmt.Errorf("resolution failed via 192.0.2.1: %w", context.DeadlineExceeded))
+
+				resp, err := sut.Query(context.Background(), QueryRequestObject{
+					Body: &ApiQueryRequest{Query: "google.com", Type: "A"},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(Query504JSONResponse{}))
+
+				resp504 := resp.(Query504JSONResponse)
+				Expect(resp504.Code).Should(Equal(UPSTREAMTIMEOUT))
+				Expect(resp504.Message).ShouldNot(ContainSubstring("192.0.2.1"))
+				Expect(resp504.Details).Should(BeNil())
+			})
+
+			It("should return 502 if the upstreams failed", func() {
+				querierMock.On("Query", "google.com.", A).Return((*model.Response)(nil),
+					errors.New("connection refused by 192.0.2.1"))
+
+				resp, err := sut.Query(context.Background(), QueryRequestObject{
+					Body: &ApiQueryRequest{Query: "google.com", Type: "A"},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(Query502JSONResponse{}))
+
+				resp502 := resp.(Query502JSONResponse)
+				Expect(resp502.Code).Should(Equal(UPSTREAMUNREACHABLE))
+				Expect(resp502.Message).ShouldNot(ContainSubstring("192.0.2.1"))
+				Expect(resp502.Details).Should(BeNil())
+			})
+
+			When("error details are enabled", func() {
+				BeforeEach(func() {
+					sut.errorDetails = true
+				})
+
+				It("should return the internal error", func() {
+					querierMock.On("Query", "google.com.", A).Return((*model.Response)(nil),
+						errors.New("connection refused by 192.0.2.1"))
+
+					resp, err := sut.Query(context.Background(), QueryRequestObject{
+						Body: &ApiQueryRequest{Query: "google.com", Type: "A"},
+					})
+					Expect(err).Should(Succeed())
+					Expect(resp).Should(BeAssignableToTypeOf(Query502JSONResponse{}))
+					Expect(resp.(Query502JSONResponse).Details).Should(HaveValue(Equal("connection refused by 192.0.2.1")))
+				})
 			})
 		})
 	})
@@ -141,12 +371,130 @@ var _ = Describe("API implementation tests", func() {
 				Expect(resp).Should(Equal(ListRefresh500TextResponse("failed")))
 			})
 		})
+
+		When("List sources are requested", func() {
+			It("should return the sources with the time of the last change", func() {
+				changed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+				listRefreshMock.On("ListSources").Return([]ListSource{
+					{Type: "blacklist", Group: "ads", Source: "https://example.com/ads.txt", LastChanged: changed},
+					{Type: "whitelist", Group: "ads", Source: "allowed.com"},
+				})
+
+				Expect(sut.ListSources(context.Background(), ListSourcesRequestObject{})).
+					Should(Equal(ListSources200JSONResponse{
+						{Type: "blacklist", Group: "ads", Source: "https://example.com/ads.txt", LastChanged: &changed},
+						{Type: "whitelist", Group: "ads", Source: "allowed.com"},
+					}))
+			})
+		})
+		When("list groups are requested", func() {
+			It("should return the groups with the statistics of their sources", func() {
+				changed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+				success := changed.Add(time.Hour)
+				listRefreshMock.On("ListGroups").Return([]ListGroup{
+					{
+						Type: "blacklist", Group: "ads", Entries: 10,
+						Sources: []ListGroupSource{
+							{
+								Source: "https://example.com/ads.txt", Entries: 10,
+								LastChanged: changed, LastSuccess: success,
+							},
+							{Source: "https://example.com/down.txt", LastError: errors.New("got status code 500"), ErrorCount: 2},
+						},
+					},
+				})
+
+				lastError := "got status code 500"
+
+				Expect(sut.Lists(context.Background(), ListsRequestObject{})).
+					Should(Equal(Lists200JSONResponse{
+						{
+							Type: "blacklist", Group: "ads", Entries: 10,
+							Sources: []ApiListGroupSource{
+								{
+									Source: "https://example.com/ads.txt", Entries: 10,
+									LastChanged: &changed, LastSuccess: &success,
+								},
+								{Source: "https://example.com/down.txt", LastError: &lastError, ErrorCount: 2},
+							},
+						},
+					}))
+			})
+		})
+
+		When("a domain is looked up", func() {
+			It("should return the matching entries", func() {
+				listRefreshMock.On("LookupLists", "ads.example.com").Return(ListLookup{
+					Domain: "ads.example.com",
+					Matches: []ListLookupMatch{
+						{
+							Type: "blacklist", Group: "ads", Source: "https://example.com/ads.txt",
+							Entry: "*.example.com", Match: "wildcard",
+						},
+						{
+							Type: "whitelist", Group: "ads", Source: "allowed.txt",
+							Entry: "ads.example.com", Match: "exact", Exception: true,
+						},
+					},
+				}, nil)
+
+				Expect(sut.ListLookup(context.Background(), ListLookupRequestObject{
+					Params: ListLookupParams{Domain: "ads.example.com"},
+				})).Should(Equal(ListLookup200JSONResponse{
+					Domain:         "ads.example.com",
+					DomainPunycode: "ads.example.com",
+					Matches: []ApiListLookupMatch{
+						{
+							Type: "blacklist", Group: "ads", Source: "https://example.com/ads.txt",
+							Entry: "*.example.com", Match: Wildcard,
+						},
+						{
+							Type: "whitelist", Group: "ads", Source: "allowed.txt",
+							Entry: "ads.example.com", Match: Exact, Exception: true,
+						},
+					},
+				}))
+			})
+
+			It("should return an empty list without matches", func() {
+				listRefreshMock.On("LookupLists", "example.com").Return(ListLookup{Domain: "example.com"}, nil)
+
+				Expect(sut.ListLookup(context.Background(), ListLookupRequestObject{
+					Params: ListLookupParams{Domain: "example.com"},
+				})).Should(Equal(ListLookup200JSONResponse{
+					Domain:         "example.com",
+					DomainPunycode: "example.com",
+					Matches:        []ApiListLookupMatch{},
+				}))
+			})
+
+			It("should return an internationalized domain in unicode and punycode form", func() {
+				listRefreshMock.On("LookupLists", "xn--mller-kva.example").
+					Return(ListLookup{Domain: "xn--mller-kva.example"}, nil)
+
+				Expect(sut.ListLookup(context.Background(), ListLookupRequestObject{
+					Params: ListLookupParams{Domain: "xn--mller-kva.example"},
+				})).Should(Equal(ListLookup200JSONResponse{
+					Domain:         "müller.example",
+					DomainPunycode: "xn--mller-kva.example",
+					Matches:        []ApiListLookupMatch{},
+				}))
+			})
+
+			It("should return 400 for an invalid domain", func() {
+				listRefreshMock.On("LookupLists", " ").Return(ListLookup{}, errors.New("invalid domain ''"))
+
+				Expect(sut.ListLookup(context.Background(), ListLookupRequestObject{
+					Params: ListLookupParams{Domain: " "},
+				})).Should(Equal(ListLookup400TextResponse("invalid domain ''")))
+			})
+		})
 	})
 
 	Describe("Control blocking status via API", func() {
 		When("Disable blocking is called", func() {
 			It("should return 200 on success", func() {
-				blockingControlMock.On("DisableBlocking", 3*time.Second, []string{"gr1", "gr2"}).Return(nil)
+				blockingControlMock.On("DisableBlocking", 3*time.Second, []string{"gr1", "gr2"}, "").Return(nil)
 				duration := "3s"
 				grroups := "gr1,gr2"
 
@@ -161,8 +509,25 @@ var _ = Describe("API implementation tests", func() {
 				Expect(resp).Should(BeAssignableToTypeOf(resp200))
 			})
 
+			It("should disable blocking for a client", func() {
+				blockingControlMock.On("DisableBlocking", 15*time.Minute, []string(nil), "laptop").Return(nil)
+				duration := "15m"
+				client := " laptop "
+
+				resp, err := sut.DisableBlocking(context.Background(), DisableBlockingRequestObject{
+					Params: DisableBlockingParams{
+						Duration: &duration,
+						Client:   &client,
+					},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(DisableBlocking200Response{}))
+				blockingControlMock.AssertExpectations(GinkgoT())
+			})
+
 			It("should return 400 on failure", func() {
-				blockingControlMock.On("DisableBlocking", mock.Anything, mock.Anything).Return(errors.New("failed"))
+				blockingControlMock.On("DisableBlocking", mock.Anything, mock.Anything, mock.Anything).
+					Return(errors.New("failed"))
 				resp, err := sut.DisableBlocking(context.Background(), DisableBlockingRequestObject{})
 				Expect(err).Should(Succeed())
 				var resp400 DisableBlocking400TextResponse
@@ -185,13 +550,25 @@ var _ = Describe("API implementation tests", func() {
 		})
 		When("Enable blocking is called", func() {
 			It("should return 200 on success", func() {
-				blockingControlMock.On("EnableBlocking").Return()
+				blockingControlMock.On("EnableBlocking", "").Return()
 
 				resp, err := sut.EnableBlocking(context.Background(), EnableBlockingRequestObject{})
 				Expect(err).Should(Succeed())
 				var resp200 EnableBlocking200Response
 				Expect(resp).Should(BeAssignableToTypeOf(resp200))
 			})
+
+			It("should enable blocking for a client", func() {
+				blockingControlMock.On("EnableBlocking", "192.168.178.10").Return()
+				client := "192.168.178.10"
+
+				resp, err := sut.EnableBlocking(context.Background(), EnableBlockingRequestObject{
+					Params: EnableBlockingParams{Client: &client},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(EnableBlocking200Response{}))
+				blockingControlMock.AssertExpectations(GinkgoT())
+			})
 		})
 
 		When("Blocking status is called", func() {
@@ -210,7 +587,418 @@ var _ = Describe("API implementation tests", func() {
 				Expect(resp200.Enabled).Should(Equal(false))
 				Expect(resp200.DisabledGroups).Should(HaveValue(Equal([]string{"gr1", "gr2"})))
 				Expect(resp200.AutoEnableInSec).Should(HaveValue(BeNumerically("==", 47)))
+				Expect(resp200.DisabledClients).Should(BeNil())
+			})
+
+			It("should return the disabled clients", func() {
+				blockingControlMock.On("BlockingStatus").Return(BlockingStatus{
+					Enabled: true,
+					DisabledClients: []DisabledClient{
+						{Client: "192.168.178.10", DisabledGroups: []string{"gr1"}},
+						{Client: "laptop", DisabledGroups: []string{"gr1", "gr2"}, AutoEnableInSec: 900},
+					},
+				})
+
+				resp, err := sut.BlockingStatus(context.Background(), BlockingStatusRequestObject{})
+				Expect(err).Should(Succeed())
+				resp200 := resp.(BlockingStatus200JSONResponse)
+				Expect(resp200.Enabled).Should(BeTrue())
+				Expect(resp200.DisabledClients).Should(HaveValue(HaveLen(2)))
+
+				clients := *resp200.DisabledClients
+				Expect(clients[0].Client).Should(Equal("192.168.178.10"))
+				Expect(clients[0].DisabledGroups).Should(Equal([]string{"gr1"}))
+				Expect(clients[0].AutoEnableInSec).Should(BeNil())
+				Expect(clients[1].Client).Should(Equal("laptop"))
+				Expect(clients[1].AutoEnableInSec).Should(HaveValue(Equal(900)))
+			})
+		})
+	})
+
+	Describe("Client statistics API", func() {
+		var since time.Time
+
+		BeforeEach(func() {
+			since = time.Now()
+		})
+
+		When("client statistics are requested", func() {
+			BeforeEach(func() {
+				clientStatsMock.On("ClientStats").Return(ClientStats{
+					Since: since,
+					Clients: []ClientStatsEntry{
+						{
+							Client: "laptop", Total: 10, Blocked: 5,
+							QueryTypes:    map[string]int{"A": 10},
+							ResponseTypes: map[string]int{"RESOLVED": 5, "BLOCKED": 5},
+						},
+						{
+							Client: "tv", Total: 30, Blocked: 1, ErrorBound: 2,
+							QueryTypes:    map[string]int{"A": 10, "PTR": 20},
+							ResponseTypes: map[string]int{"RESOLVED": 29, "BLOCKED": 1},
+						},
+						{
+							Client: "phone", Total: 20, Blocked: 2,
+							QueryTypes:    map[string]int{"AAAA": 20},
+							ResponseTypes: map[string]int{"CACHED": 18, "BLOCKED": 2},
+						},
+					},
+				}, nil)
+			})
+
+			clientNames := func(resp ClientStatsResponseObject) []string {
+				Expect(resp).Should(BeAssignableToTypeOf(ClientStats200JSONResponse{}))
+
+				var names []string
+				for _, c := range resp.(ClientStats200JSONResponse).Clients {
+					names = append(names, c.Client)
+				}
+
+				return names
+			}
+
+			It("should return all clients sorted by total queries", func() {
+				resp, err := sut.ClientStats(context.Background(), ClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(clientNames(resp)).Should(Equal([]string{"tv", "phone", "laptop"}))
+
+				resp200 := resp.(ClientStats200JSONResponse)
+				Expect(resp200.Since).Should(Equal(since))
+				Expect(resp200.Clients[0]).Should(Equal(ApiClientStatsEntry{
+					Client: "tv", ClientPunycode: "tv", Total: 30, Blocked: 1, ErrorBound: 2,
+					QueryTypes:    map[string]int{"A": 10, "PTR": 20},
+					ResponseTypes: map[string]int{"RESOLVED": 29, "BLOCKED": 1},
+				}))
+			})
+
+			It("should sort by blocked queries", func() {
+				sort := "blocked"
+
+				resp, err := sut.ClientStats(context.Background(), ClientStatsRequestObject{
+					Params: ClientStatsParams{Sort: &sort},
+				})
+				Expect(err).Should(Succeed())
+				Expect(clientNames(resp)).Should(Equal([]string{"laptop", "phone", "tv"}))
+			})
+
+			It("should sort by query type", func() {
+				sort := "aaaa"
+
+				resp, err := sut.ClientStats(context.Background(), ClientStatsRequestObject{
+					Params: ClientStatsParams{Sort: &sort},
+				})
+				Expect(err).Should(Succeed())
+				Expect(clientNames(resp)).Should(Equal([]string{"phone", "laptop", "tv"}))
+			})
+
+			It("should limit the number of clients", func() {
+				sort := "PTR"
+				limit := 1
+
+				resp, err := sut.ClientStats(context.Background(), ClientStatsRequestObject{
+					Params: ClientStatsParams{Sort: &sort, Limit: &limit},
+				})
+				Expect(err).Should(Succeed())
+				Expect(clientNames(resp)).Should(Equal([]string{"tv"}))
+			})
+		})
+
+		When("a client name is internationalized", func() {
+			It("should return the name in unicode and punycode form", func() {
+				clientStatsMock.On("ClientStats").Return(ClientStats{
+					Since:   since,
+					Clients: []ClientStatsEntry{{Client: "xn--mller-kva.lan", Total: 1}},
+				}, nil)
+
+				resp, err := sut.ClientStats(context.Background(), ClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+
+				client := resp.(ClientStats200JSONResponse).Clients[0]
+				Expect(client.Client).Should(Equal("müller.lan"))
+				Expect(client.ClientPunycode).Should(Equal("xn--mller-kva.lan"))
+			})
+		})
+
+		When("the sort order is unknown", func() {
+			It("should return 400", func() {
+				sort := "WRONGTYPE"
+
+				resp, err := sut.ClientStats(context.Background(), ClientStatsRequestObject{
+					Params: ClientStatsParams{Sort: &sort},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ClientStats400TextResponse("unknown sort order 'WRONGTYPE'")))
+			})
+		})
+
+		When("client statistics are disabled", func() {
+			It("should return 400", func() {
+				clientStatsMock.On("ClientStats").Return(ClientStats{}, errors.New("disabled"))
+
+				resp, err := sut.ClientStats(context.Background(), ClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ClientStats400TextResponse("disabled")))
+			})
+		})
+
+		When("client statistics are reset", func() {
+			It("should return 200 on success", func() {
+				clientStatsMock.On("ResetClientStats").Return(nil)
+
+				resp, err := sut.ResetClientStats(context.Background(), ResetClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(ResetClientStats200Response{}))
+			})
+
+			It("should return 400 on failure", func() {
+				clientStatsMock.On("ResetClientStats").Return(errors.New("disabled"))
+
+				resp, err := sut.ResetClientStats(context.Background(), ResetClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ResetClientStats400TextResponse("disabled")))
 			})
 		})
 	})
+
+	Describe("Upstream status API", func() {
+		It("should return the upstreams ordered by group", func() {
+			upstreamStatusMock.On("UpstreamStatus").Return([]UpstreamStatus{
+				{Group: "laptop", Upstream: "tcp+udp:1.1.1.1:53", State: UpstreamVerificationActive},
+				{
+					Group: "default", Upstream: "tcp+udp:8.8.8.8:53", State: UpstreamVerificationPending,
+					FailedAttempts: 2, LastError: "timeout",
+				},
+				{Group: "default", Upstream: "tcp+udp:9.9.9.9:53", State: UpstreamVerificationActive},
+			})
+
+			resp, err := sut.UpstreamStatus(context.Background(), UpstreamStatusRequestObject{})
+			Expect(err).Should(Succeed())
+
+			lastError := "timeout"
+
+			Expect(resp).Should(Equal(UpstreamStatus200JSONResponse{
+				{
+					Group: "default", Upstream: "tcp+udp:8.8.8.8:53", State: "pending",
+					FailedAttempts: 2, LastError: &lastError,
+				},
+				{Group: "default", Upstream: "tcp+udp:9.9.9.9:53", State: "active"},
+				{Group: "laptop", Upstream: "tcp+udp:1.1.1.1:53", State: "active"},
+			}))
+		})
+	})
+
+	Describe("Startup API", func() {
+		It("should return the durations in milliseconds", func() {
+			startupMock.On("StartupSummary").Return(StartupSummary{
+				Duration: 1500 * time.Millisecond,
+				Phases: []StartupPhase{
+					{Name: "config load", Duration: 20 * time.Millisecond},
+					{Name: "list load blacklist ads", Duration: 1200 * time.Millisecond},
+				},
+			})
+
+			Expect(sut.StartupSummary(context.Background(), StartupSummaryRequestObject{})).
+				Should(Equal(StartupSummary200JSONResponse{
+					DurationMs: 1500,
+					Phases: []ApiStartupPhase{
+						{Name: "config load", DurationMs: 20},
+						{Name: "list load blacklist ads", DurationMs: 1200},
+					},
+				}))
+		})
+	})
+
+	Describe("Blocking entries API", func() {
+		request := &ApiBlockingEntryRequest{Domain: "evil.example.com", Group: "manual"}
+
+		It("should add and remove blacklist entries", func() {
+			entry := BlockingEntry{Type: BlockingEntryDeny, Group: "manual", Domain: "evil.example.com"}
+			blockingEntriesMock.On("AddBlockingEntry", entry).Return(nil)
+			blockingEntriesMock.On("RemoveBlockingEntry", entry).Return(nil)
+
+			Expect(sut.AddDenyEntry(context.Background(), AddDenyEntryRequestObject{Body: request})).
+				Should(BeAssignableToTypeOf(AddDenyEntry200Response{}))
+			Expect(sut.RemoveDenyEntry(context.Background(), RemoveDenyEntryRequestObject{Body: request})).
+				Should(BeAssignableToTypeOf(RemoveDenyEntry200Response{}))
+		})
+
+		It("should add and remove whitelist entries", func() {
+			entry := BlockingEntry{Type: BlockingEntryAllow, Group: "manual", Domain: "evil.example.com"}
+			blockingEntriesMock.On("AddBlockingEntry", entry).Return(nil)
+			blockingEntriesMock.On("RemoveBlockingEntry", entry).Return(nil)
+
+			Expect(sut.AddAllowEntry(context.Background(), AddAllowEntryRequestObject{Body: request})).
+				Should(BeAssignableToTypeOf(AddAllowEntry200Response{}))
+			Expect(sut.RemoveAllowEntry(context.Background(), RemoveAllowEntryRequestObject{Body: request})).
+				Should(BeAssignableToTypeOf(RemoveAllowEntry200Response{}))
+		})
+
+		It("should add temporary entries", func() {
+			ttl := "24h"
+			entry := BlockingEntry{Type: BlockingEntryAllow, Group: "manual", Domain: "evil.example.com", TTL: 24 * time.Hour}
+			blockingEntriesMock.On("AddBlockingEntry", entry).Return(nil)
+
+			Expect(sut.AddAllowEntry(context.Background(), AddAllowEntryRequestObject{Body: &ApiBlockingEntryRequest{
+				Domain: "evil.example.com", Group: "manual", Ttl: &ttl,
+			}})).Should(BeAssignableToTypeOf(AddAllowEntry200Response{}))
+		})
+
+		It("should return 400 on invalid TTL", func() {
+			for _, ttl := range []string{"abc", "0s", "-1h"} {
+				ttl := ttl
+
+				Expect(sut.AddDenyEntry(context.Background(), AddDenyEntryRequestObject{Body: &ApiBlockingEntryRequest{
+					Domain: "evil.example.com", Group: "manual", Ttl: &ttl,
+				}})).Should(BeAssignableToTypeOf(AddDenyEntry400TextResponse("")))
+			}
+
+			blockingEntriesMock.AssertNotCalled(GinkgoT(), "AddBlockingEntry", mock.Anything)
+		})
+
+		It("should return 400 on error", func() {
+			blockingEntriesMock.On("AddBlockingEntry", mock.Anything).Return(errors.New("group 'manual' is unknown"))
+			blockingEntriesMock.On("RemoveBlockingEntry", mock.Anything).Return(errors.New("entry not found"))
+
+			Expect(sut.AddDenyEntry(context.Background(), AddDenyEntryRequestObject{Body: request})).
+				Should(Equal(AddDenyEntry400TextResponse("group 'manual' is unknown")))
+			Expect(sut.RemoveAllowEntry(context.Background(), RemoveAllowEntryRequestObject{Body: request})).
+				Should(Equal(RemoveAllowEntry400TextResponse("entry not found")))
+		})
+
+		It("should list the entries ordered by type, group and domain", func() {
+			expiresInSec := 2
+
+			blockingEntriesMock.On("BlockingEntries").Return([]BlockingEntry{
+				{Type: BlockingEntryDeny, Group: "manual", Domain: "b.com"},
+				{Type: BlockingEntryAllow, Group: "manual", Domain: "c.com"},
+				{Type: BlockingEntryDeny, Group: "kids", Domain: "c.com"},
+				{Type: BlockingEntryDeny, Group: "manual", Domain: "a.com", TTL: 1500 * time.Millisecond},
+			})
+
+			Expect(sut.BlockingEntries(context.Background(), BlockingEntriesRequestObject{})).
+				Should(Equal(BlockingEntries200JSONResponse{
+					{Type: "allow", Group: "manual", Domain: "c.com"},
+					{Type: "deny", Group: "kids", Domain: "c.com"},
+					{Type: "deny", Group: "manual", Domain: "a.com", ExpiresInSec: &expiresInSec},
+					{Type: "deny", Group: "manual", Domain: "b.com"},
+				}))
+		})
+	})
+
+	Describe("Custom DNS records API", func() {
+		records := []string{"A 192.168.178.3", "AAAA 2001:db8::3"}
+
+		It("should set and remove records", func() {
+			customDNSMock.On("SetCustomDNSRecords", "nas.home", records, (*time.Duration)(nil)).Return(nil)
+			customDNSMock.On("RemoveCustomDNSRecords", "nas.home").Return(nil)
+
+			Expect(sut.SetCustomDNSRecords(context.Background(), SetCustomDNSRecordsRequestObject{
+				Name: "nas.home", Body: &ApiCustomDNSRecordsRequest{Records: records},
+			})).Should(BeAssignableToTypeOf(SetCustomDNSRecords200Response{}))
+			Expect(sut.RemoveCustomDNSRecords(context.Background(), RemoveCustomDNSRecordsRequestObject{
+				Name: "nas.home",
+			})).Should(BeAssignableToTypeOf(RemoveCustomDNSRecords200Response{}))
+		})
+
+		It("should set records with TTL", func() {
+			ttl := "5m"
+			expected := 5 * time.Minute
+			customDNSMock.On("SetCustomDNSRecords", "nas.home", records, &expected).Return(nil)
+
+			Expect(sut.SetCustomDNSRecords(context.Background(), SetCustomDNSRecordsRequestObject{
+				Name: "nas.home", Body: &ApiCustomDNSRecordsRequest{Records: records, Ttl: &ttl},
+			})).Should(BeAssignableToTypeOf(SetCustomDNSRecords200Response{}))
+		})
+
+		It("should return 400 on invalid TTL", func() {
+			for _, ttl := range []string{"abc", "-1h"} {
+				ttl := ttl
+
+				Expect(sut.SetCustomDNSRecords(context.Background(), SetCustomDNSRecordsRequestObject{
+					Name: "nas.home", Body: &ApiCustomDNSRecordsRequest{Records: records, Ttl: &ttl},
+				})).Should(BeAssignableToTypeOf(SetCustomDNSRecords400TextResponse("")))
+			}
+
+			customDNSMock.AssertNotCalled(GinkgoT(), "SetCustomDNSRecords", mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		It("should return 400 on error", func() {
+			customDNSMock.On("SetCustomDNSRecords", mock.Anything, mock.Anything, mock.Anything).
+				Return(errors.New("invalid record 'A abc' for nas.home"))
+			customDNSMock.On("RemoveCustomDNSRecords", mock.Anything).Return(errors.New("no runtime records: nas.home"))
+
+			Expect(sut.SetCustomDNSRecords(context.Background(), SetCustomDNSRecordsRequestObject{
+				Name: "nas.home", Body: &ApiCustomDNSRecordsRequest{Records: []string{"A abc"}},
+			})).Should(Equal(SetCustomDNSRecords400TextResponse("invalid record 'A abc' for nas.home")))
+			Expect(sut.RemoveCustomDNSRecords(context.Background(), RemoveCustomDNSRecordsRequestObject{
+				Name: "nas.home",
+			})).Should(Equal(RemoveCustomDNSRecords400TextResponse("no runtime records: nas.home")))
+		})
+
+		It("should list the records ordered by name", func() {
+			ttl := time.Hour
+			ttlInSec := 3600
+
+			customDNSMock.On("CustomDNSRecords").Return([]CustomDNSRecords{
+				{Name: "printer.home", Source: "zone file", Records: []string{"300 A 192.168.178.4"}},
+				{Name: "nas.home", Source: "runtime", Records: records, TTL: &ttl},
+			})
+
+			Expect(sut.CustomDNSRecords(context.Background(), CustomDNSRecordsRequestObject{})).
+				Should(Equal(CustomDNSRecords200JSONResponse{
+					{Name: "nas.home", Source: "runtime", Records: records, TtlInSec: &ttlInSec},
+					{Name: "printer.home", Source: "zone file", Records: []string{"300 A 192.168.178.4"}},
+				}))
+		})
+	})
+
+	Describe("Blocking check API", func() {
+		It("should return the result of the check", func() {
+			client := "laptop"
+			blockingCheckerMock.On("CheckBlocking", "ads.example.com", "laptop").Return(BlockingCheck{
+				Domain:  "ads.example.com",
+				Groups:  []string{"ads"},
+				Blocked: true,
+				Reason:  "BLOCKED (ads)",
+				DenyMatches: []BlockingCheckMatch{
+					{Group: "ads", Source: "https://example.com/ads.txt", Entry: "*.example.com"},
+				},
+			}, nil)
+
+			Expect(sut.BlockingCheck(context.Background(), BlockingCheckRequestObject{
+				Params: BlockingCheckParams{Domain: "ads.example.com", Client: &client},
+			})).Should(Equal(BlockingCheck200JSONResponse{
+				Domain:         "ads.example.com",
+				DomainPunycode: "ads.example.com",
+				Groups:         []string{"ads"},
+				Blocked:        true,
+				Reason:         "BLOCKED (ads)",
+				DenyMatches: []ApiBlockingCheckMatch{
+					{Group: "ads", Source: "https://example.com/ads.txt", Entry: "*.example.com"},
+				},
+				AllowMatches: []ApiBlockingCheckMatch{},
+			}))
+		})
+
+		It("should return an internationalized domain in unicode and punycode form", func() {
+			blockingCheckerMock.On("CheckBlocking", "xn--mller-kva.example", "").
+				Return(BlockingCheck{Domain: "xn--mller-kva.example", Groups: []string{"ads"}}, nil)
+
+			resp, err := sut.BlockingCheck(context.Background(), BlockingCheckRequestObject{
+				Params: BlockingCheckParams{Domain: "xn--mller-kva.example"},
+			})
+			Expect(err).Should(Succeed())
+
+			resp200 := resp.(BlockingCheck200JSONResponse)
+			Expect(resp200.Domain).Should(Equal("müller.example"))
+			Expect(resp200.DomainPunycode).Should(Equal("xn--mller-kva.example"))
+		})
+
+		It("should return 400 on error", func() {
+			blockingCheckerMock.On("CheckBlocking", "", "").Return(BlockingCheck{}, errors.New("invalid domain ''"))
+
+			Expect(sut.BlockingCheck(context.Background(), BlockingCheckRequestObject{})).
+				Should(Equal(BlockingCheck400TextResponse("invalid domain ''")))
+		})
+	})
 })
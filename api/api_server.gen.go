@@ -16,27 +16,105 @@ import (
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Remove whitelist entry
+	// (DELETE /blocking/allow)
+	RemoveAllowEntry(w http.ResponseWriter, r *http.Request)
+	// Add whitelist entry
+	// (POST /blocking/allow)
+	AddAllowEntry(w http.ResponseWriter, r *http.Request)
+	// Check domain
+	// (GET /blocking/check)
+	BlockingCheck(w http.ResponseWriter, r *http.Request, params BlockingCheckParams)
+	// Remove blacklist entry
+	// (DELETE /blocking/deny)
+	RemoveDenyEntry(w http.ResponseWriter, r *http.Request)
+	// Add blacklist entry
+	// (POST /blocking/deny)
+	AddDenyEntry(w http.ResponseWriter, r *http.Request)
 	// Disable blocking
 	// (GET /blocking/disable)
 	DisableBlocking(w http.ResponseWriter, r *http.Request, params DisableBlockingParams)
 	// Enable blocking
 	// (GET /blocking/enable)
-	EnableBlocking(w http.ResponseWriter, r *http.Request)
+	EnableBlocking(w http.ResponseWriter, r *http.Request, params EnableBlockingParams)
+	// Runtime entries
+	// (GET /blocking/entries)
+	BlockingEntries(w http.ResponseWriter, r *http.Request)
 	// Blocking status
 	// (GET /blocking/status)
 	BlockingStatus(w http.ResponseWriter, r *http.Request)
+	// Custom DNS records
+	// (GET /customdns/records)
+	CustomDNSRecords(w http.ResponseWriter, r *http.Request)
+	// Remove custom DNS records
+	// (DELETE /customdns/records/{name})
+	RemoveCustomDNSRecords(w http.ResponseWriter, r *http.Request, name string)
+	// Set custom DNS records
+	// (PUT /customdns/records/{name})
+	SetCustomDNSRecords(w http.ResponseWriter, r *http.Request, name string)
+	// List groups
+	// (GET /lists)
+	Lists(w http.ResponseWriter, r *http.Request)
+	// Look up domain in lists
+	// (GET /lists/lookup)
+	ListLookup(w http.ResponseWriter, r *http.Request, params ListLookupParams)
 	// List refresh
 	// (POST /lists/refresh)
 	ListRefresh(w http.ResponseWriter, r *http.Request)
+	// List sources
+	// (GET /lists/sources)
+	ListSources(w http.ResponseWriter, r *http.Request)
 	// Performs DNS query
 	// (POST /query)
 	Query(w http.ResponseWriter, r *http.Request)
+	// Startup summary
+	// (GET /startup)
+	StartupSummary(w http.ResponseWriter, r *http.Request)
+	// Reset client statistics
+	// (DELETE /stats/clients)
+	ResetClientStats(w http.ResponseWriter, r *http.Request)
+	// Client statistics
+	// (GET /stats/clients)
+	ClientStats(w http.ResponseWriter, r *http.Request, params ClientStatsParams)
+	// Upstream status
+	// (GET /upstreams/status)
+	UpstreamStatus(w http.ResponseWriter, r *http.Request)
 }
 
 // Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
 
 type Unimplemented struct{}
 
+// Remove whitelist entry
+// (DELETE /blocking/allow)
+func (_ Unimplemented) RemoveAllowEntry(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Add whitelist entry
+// (POST /blocking/allow)
+func (_ Unimplemented) AddAllowEntry(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Check domain
+// (GET /blocking/check)
+func (_ Unimplemented) BlockingCheck(w http.ResponseWriter, r *http.Request, params BlockingCheckParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Remove blacklist entry
+// (DELETE /blocking/deny)
+func (_ Unimplemented) RemoveDenyEntry(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Add blacklist entry
+// (POST /blocking/deny)
+func (_ Unimplemented) AddDenyEntry(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Disable blocking
 // (GET /blocking/disable)
 func (_ Unimplemented) DisableBlocking(w http.ResponseWriter, r *http.Request, params DisableBlockingParams) {
@@ -45,7 +123,13 @@ func (_ Unimplemented) DisableBlocking(w http.ResponseWriter, r *http.Request, p
 
 // Enable blocking
 // (GET /blocking/enable)
-func (_ Unimplemented) EnableBlocking(w http.ResponseWriter, r *http.Request) {
+func (_ Unimplemented) EnableBlocking(w http.ResponseWriter, r *http.Request, params EnableBlockingParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Runtime entries
+// (GET /blocking/entries)
+func (_ Unimplemented) BlockingEntries(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
@@ -55,18 +139,78 @@ func (_ Unimplemented) BlockingStatus(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Custom DNS records
+// (GET /customdns/records)
+func (_ Unimplemented) CustomDNSRecords(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Remove custom DNS records
+// (DELETE /customdns/records/{name})
+func (_ Unimplemented) RemoveCustomDNSRecords(w http.ResponseWriter, r *http.Request, name string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Set custom DNS records
+// (PUT /customdns/records/{name})
+func (_ Unimplemented) SetCustomDNSRecords(w http.ResponseWriter, r *http.Request, name string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List groups
+// (GET /lists)
+func (_ Unimplemented) Lists(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Look up domain in lists
+// (GET /lists/lookup)
+func (_ Unimplemented) ListLookup(w http.ResponseWriter, r *http.Request, params ListLookupParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // List refresh
 // (POST /lists/refresh)
 func (_ Unimplemented) ListRefresh(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// List sources
+// (GET /lists/sources)
+func (_ Unimplemented) ListSources(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Performs DNS query
 // (POST /query)
 func (_ Unimplemented) Query(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Startup summary
+// (GET /startup)
+func (_ Unimplemented) StartupSummary(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Reset client statistics
+// (DELETE /stats/clients)
+func (_ Unimplemented) ResetClientStats(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Client statistics
+// (GET /stats/clients)
+func (_ Unimplemented) ClientStats(w http.ResponseWriter, r *http.Request, params ClientStatsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Upstream status
+// (GET /upstreams/status)
+func (_ Unimplemented) UpstreamStatus(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // ServerInterfaceWrapper converts contexts to parameters.
 type ServerInterfaceWrapper struct {
 	Handler            ServerInterface
@@ -76,6 +220,109 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// RemoveAllowEntry operation middleware
+func (siw *ServerInterfaceWrapper) RemoveAllowEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RemoveAllowEntry(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// AddAllowEntry operation middleware
+func (siw *ServerInterfaceWrapper) AddAllowEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AddAllowEntry(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// BlockingCheck operation middleware
+func (siw *ServerInterfaceWrapper) BlockingCheck(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params BlockingCheckParams
+
+	// ------------- Required query parameter "domain" -------------
+
+	if paramValue := r.URL.Query().Get("domain"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "domain"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "domain", r.URL.Query(), &params.Domain)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "domain", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "client" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "client", r.URL.Query(), &params.Client)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "client", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.BlockingCheck(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RemoveDenyEntry operation middleware
+func (siw *ServerInterfaceWrapper) RemoveDenyEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RemoveDenyEntry(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// AddDenyEntry operation middleware
+func (siw *ServerInterfaceWrapper) AddDenyEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AddDenyEntry(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // DisableBlocking operation middleware
 func (siw *ServerInterfaceWrapper) DisableBlocking(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -101,6 +348,14 @@ func (siw *ServerInterfaceWrapper) DisableBlocking(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// ------------- Optional query parameter "client" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "client", r.URL.Query(), &params.Client)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "client", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.DisableBlocking(w, r, params)
 	}))
@@ -116,8 +371,21 @@ func (siw *ServerInterfaceWrapper) DisableBlocking(w http.ResponseWriter, r *htt
 func (siw *ServerInterfaceWrapper) EnableBlocking(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params EnableBlockingParams
+
+	// ------------- Optional query parameter "client" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "client", r.URL.Query(), &params.Client)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "client", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.EnableBlocking(w, r)
+		siw.Handler.EnableBlocking(w, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -127,12 +395,12 @@ func (siw *ServerInterfaceWrapper) EnableBlocking(w http.ResponseWriter, r *http
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// BlockingStatus operation middleware
-func (siw *ServerInterfaceWrapper) BlockingStatus(w http.ResponseWriter, r *http.Request) {
+// BlockingEntries operation middleware
+func (siw *ServerInterfaceWrapper) BlockingEntries(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.BlockingStatus(w, r)
+		siw.Handler.BlockingEntries(w, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -142,12 +410,12 @@ func (siw *ServerInterfaceWrapper) BlockingStatus(w http.ResponseWriter, r *http
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// ListRefresh operation middleware
-func (siw *ServerInterfaceWrapper) ListRefresh(w http.ResponseWriter, r *http.Request) {
+// BlockingStatus operation middleware
+func (siw *ServerInterfaceWrapper) BlockingStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.ListRefresh(w, r)
+		siw.Handler.BlockingStatus(w, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -157,12 +425,12 @@ func (siw *ServerInterfaceWrapper) ListRefresh(w http.ResponseWriter, r *http.Re
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// Query operation middleware
-func (siw *ServerInterfaceWrapper) Query(w http.ResponseWriter, r *http.Request) {
+// CustomDNSRecords operation middleware
+func (siw *ServerInterfaceWrapper) CustomDNSRecords(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.Query(w, r)
+		siw.Handler.CustomDNSRecords(w, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -172,102 +440,330 @@ func (siw *ServerInterfaceWrapper) Query(w http.ResponseWriter, r *http.Request)
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-type UnescapedCookieParamError struct {
-	ParamName string
-	Err       error
-}
+// RemoveCustomDNSRecords operation middleware
+func (siw *ServerInterfaceWrapper) RemoveCustomDNSRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-func (e *UnescapedCookieParamError) Error() string {
-	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
-}
+	var err error
 
-func (e *UnescapedCookieParamError) Unwrap() error {
-	return e.Err
-}
+	// ------------- Path parameter "name" -------------
+	var name string
 
-type UnmarshalingParamError struct {
-	ParamName string
-	Err       error
-}
+	err = runtime.BindStyledParameterWithLocation("simple", false, "name", runtime.ParamLocationPath, chi.URLParam(r, "name"), &name)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
 
-func (e *UnmarshalingParamError) Error() string {
-	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
-}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RemoveCustomDNSRecords(w, r, name)
+	}))
 
-func (e *UnmarshalingParamError) Unwrap() error {
-	return e.Err
-}
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-type RequiredParamError struct {
-	ParamName string
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-func (e *RequiredParamError) Error() string {
-	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
-}
+// SetCustomDNSRecords operation middleware
+func (siw *ServerInterfaceWrapper) SetCustomDNSRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-type RequiredHeaderError struct {
-	ParamName string
-	Err       error
-}
+	var err error
 
-func (e *RequiredHeaderError) Error() string {
-	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
-}
+	// ------------- Path parameter "name" -------------
+	var name string
 
-func (e *RequiredHeaderError) Unwrap() error {
-	return e.Err
-}
+	err = runtime.BindStyledParameterWithLocation("simple", false, "name", runtime.ParamLocationPath, chi.URLParam(r, "name"), &name)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
 
-type InvalidParamFormatError struct {
-	ParamName string
-	Err       error
-}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SetCustomDNSRecords(w, r, name)
+	}))
 
-func (e *InvalidParamFormatError) Error() string {
-	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
-}
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-func (e *InvalidParamFormatError) Unwrap() error {
-	return e.Err
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-type TooManyValuesForParamError struct {
-	ParamName string
-	Count     int
-}
+// Lists operation middleware
+func (siw *ServerInterfaceWrapper) Lists(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-func (e *TooManyValuesForParamError) Error() string {
-	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
-}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Lists(w, r)
+	}))
 
-// Handler creates http.Handler with routing matching OpenAPI spec.
-func Handler(si ServerInterface) http.Handler {
-	return HandlerWithOptions(si, ChiServerOptions{})
-}
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-type ChiServerOptions struct {
-	BaseURL          string
-	BaseRouter       chi.Router
-	Middlewares      []MiddlewareFunc
-	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
-func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
-	return HandlerWithOptions(si, ChiServerOptions{
-		BaseRouter: r,
-	})
-}
+// ListLookup operation middleware
+func (siw *ServerInterfaceWrapper) ListLookup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
-	return HandlerWithOptions(si, ChiServerOptions{
-		BaseURL:    baseURL,
-		BaseRouter: r,
-	})
-}
+	var err error
 
-// HandlerWithOptions creates http.Handler with additional options
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListLookupParams
+
+	// ------------- Required query parameter "domain" -------------
+
+	if paramValue := r.URL.Query().Get("domain"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "domain"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "domain", r.URL.Query(), &params.Domain)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "domain", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListLookup(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// ListRefresh operation middleware
+func (siw *ServerInterfaceWrapper) ListRefresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListRefresh(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// ListSources operation middleware
+func (siw *ServerInterfaceWrapper) ListSources(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListSources(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// Query operation middleware
+func (siw *ServerInterfaceWrapper) Query(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Query(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// StartupSummary operation middleware
+func (siw *ServerInterfaceWrapper) StartupSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.StartupSummary(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// ResetClientStats operation middleware
+func (siw *ServerInterfaceWrapper) ResetClientStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ResetClientStats(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// ClientStats operation middleware
+func (siw *ServerInterfaceWrapper) ClientStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ClientStatsParams
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ClientStats(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// UpstreamStatus operation middleware
+func (siw *ServerInterfaceWrapper) UpstreamStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpstreamStatus(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{})
+}
+
+type ChiServerOptions struct {
+	BaseURL          string
+	BaseRouter       chi.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
 func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
 	r := options.BaseRouter
 
@@ -285,44 +781,313 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
-	r.Group(func(r chi.Router) {
-		r.Get(options.BaseURL+"/blocking/disable", wrapper.DisableBlocking)
-	})
-	r.Group(func(r chi.Router) {
-		r.Get(options.BaseURL+"/blocking/enable", wrapper.EnableBlocking)
-	})
-	r.Group(func(r chi.Router) {
-		r.Get(options.BaseURL+"/blocking/status", wrapper.BlockingStatus)
-	})
-	r.Group(func(r chi.Router) {
-		r.Post(options.BaseURL+"/lists/refresh", wrapper.ListRefresh)
-	})
-	r.Group(func(r chi.Router) {
-		r.Post(options.BaseURL+"/query", wrapper.Query)
-	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/blocking/allow", wrapper.RemoveAllowEntry)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/blocking/allow", wrapper.AddAllowEntry)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/check", wrapper.BlockingCheck)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/blocking/deny", wrapper.RemoveDenyEntry)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/blocking/deny", wrapper.AddDenyEntry)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/disable", wrapper.DisableBlocking)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/enable", wrapper.EnableBlocking)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/entries", wrapper.BlockingEntries)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/status", wrapper.BlockingStatus)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/customdns/records", wrapper.CustomDNSRecords)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/customdns/records/{name}", wrapper.RemoveCustomDNSRecords)
+	})
+	r.Group(func(r chi.Router) {
+		r.Put(options.BaseURL+"/customdns/records/{name}", wrapper.SetCustomDNSRecords)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/lists", wrapper.Lists)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/lists/lookup", wrapper.ListLookup)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/lists/refresh", wrapper.ListRefresh)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/lists/sources", wrapper.ListSources)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/query", wrapper.Query)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/startup", wrapper.StartupSummary)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/stats/clients", wrapper.ResetClientStats)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/stats/clients", wrapper.ClientStats)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/upstreams/status", wrapper.UpstreamStatus)
+	})
+
+	return r
+}
+
+type RemoveAllowEntryRequestObject struct {
+	Body *RemoveAllowEntryJSONRequestBody
+}
+
+type RemoveAllowEntryResponseObject interface {
+	VisitRemoveAllowEntryResponse(w http.ResponseWriter) error
+}
+
+type RemoveAllowEntry200Response struct {
+}
+
+func (response RemoveAllowEntry200Response) VisitRemoveAllowEntryResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type RemoveAllowEntry400TextResponse string
+
+func (response RemoveAllowEntry400TextResponse) VisitRemoveAllowEntryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type AddAllowEntryRequestObject struct {
+	Body *AddAllowEntryJSONRequestBody
+}
+
+type AddAllowEntryResponseObject interface {
+	VisitAddAllowEntryResponse(w http.ResponseWriter) error
+}
+
+type AddAllowEntry200Response struct {
+}
+
+func (response AddAllowEntry200Response) VisitAddAllowEntryResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type AddAllowEntry400TextResponse string
+
+func (response AddAllowEntry400TextResponse) VisitAddAllowEntryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type BlockingCheckRequestObject struct {
+	Params BlockingCheckParams
+}
+
+type BlockingCheckResponseObject interface {
+	VisitBlockingCheckResponse(w http.ResponseWriter) error
+}
+
+type BlockingCheck200JSONResponse ApiBlockingCheck
+
+func (response BlockingCheck200JSONResponse) VisitBlockingCheckResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type BlockingCheck400TextResponse string
+
+func (response BlockingCheck400TextResponse) VisitBlockingCheckResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type RemoveDenyEntryRequestObject struct {
+	Body *RemoveDenyEntryJSONRequestBody
+}
+
+type RemoveDenyEntryResponseObject interface {
+	VisitRemoveDenyEntryResponse(w http.ResponseWriter) error
+}
+
+type RemoveDenyEntry200Response struct {
+}
+
+func (response RemoveDenyEntry200Response) VisitRemoveDenyEntryResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type RemoveDenyEntry400TextResponse string
+
+func (response RemoveDenyEntry400TextResponse) VisitRemoveDenyEntryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type AddDenyEntryRequestObject struct {
+	Body *AddDenyEntryJSONRequestBody
+}
+
+type AddDenyEntryResponseObject interface {
+	VisitAddDenyEntryResponse(w http.ResponseWriter) error
+}
+
+type AddDenyEntry200Response struct {
+}
+
+func (response AddDenyEntry200Response) VisitAddDenyEntryResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type AddDenyEntry400TextResponse string
+
+func (response AddDenyEntry400TextResponse) VisitAddDenyEntryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type DisableBlockingRequestObject struct {
+	Params DisableBlockingParams
+}
+
+type DisableBlockingResponseObject interface {
+	VisitDisableBlockingResponse(w http.ResponseWriter) error
+}
+
+type DisableBlocking200Response struct {
+}
+
+func (response DisableBlocking200Response) VisitDisableBlockingResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type DisableBlocking400TextResponse string
+
+func (response DisableBlocking400TextResponse) VisitDisableBlockingResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type EnableBlockingRequestObject struct {
+	Params EnableBlockingParams
+}
+
+type EnableBlockingResponseObject interface {
+	VisitEnableBlockingResponse(w http.ResponseWriter) error
+}
+
+type EnableBlocking200Response struct {
+}
+
+func (response EnableBlocking200Response) VisitEnableBlockingResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type BlockingEntriesRequestObject struct {
+}
+
+type BlockingEntriesResponseObject interface {
+	VisitBlockingEntriesResponse(w http.ResponseWriter) error
+}
+
+type BlockingEntries200JSONResponse []ApiBlockingEntry
+
+func (response BlockingEntries200JSONResponse) VisitBlockingEntriesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type BlockingStatusRequestObject struct {
+}
+
+type BlockingStatusResponseObject interface {
+	VisitBlockingStatusResponse(w http.ResponseWriter) error
+}
+
+type BlockingStatus200JSONResponse ApiBlockingStatus
+
+func (response BlockingStatus200JSONResponse) VisitBlockingStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
 
-	return r
+type CustomDNSRecordsRequestObject struct {
 }
 
-type DisableBlockingRequestObject struct {
-	Params DisableBlockingParams
+type CustomDNSRecordsResponseObject interface {
+	VisitCustomDNSRecordsResponse(w http.ResponseWriter) error
 }
 
-type DisableBlockingResponseObject interface {
-	VisitDisableBlockingResponse(w http.ResponseWriter) error
+type CustomDNSRecords200JSONResponse []ApiCustomDNSRecords
+
+func (response CustomDNSRecords200JSONResponse) VisitCustomDNSRecordsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
 }
 
-type DisableBlocking200Response struct {
+type RemoveCustomDNSRecordsRequestObject struct {
+	Name string `json:"name"`
 }
 
-func (response DisableBlocking200Response) VisitDisableBlockingResponse(w http.ResponseWriter) error {
+type RemoveCustomDNSRecordsResponseObject interface {
+	VisitRemoveCustomDNSRecordsResponse(w http.ResponseWriter) error
+}
+
+type RemoveCustomDNSRecords200Response struct {
+}
+
+func (response RemoveCustomDNSRecords200Response) VisitRemoveCustomDNSRecordsResponse(w http.ResponseWriter) error {
 	w.WriteHeader(200)
 	return nil
 }
 
-type DisableBlocking400TextResponse string
+type RemoveCustomDNSRecords400TextResponse string
 
-func (response DisableBlocking400TextResponse) VisitDisableBlockingResponse(w http.ResponseWriter) error {
+func (response RemoveCustomDNSRecords400TextResponse) VisitRemoveCustomDNSRecordsResponse(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(400)
 
@@ -330,37 +1095,76 @@ func (response DisableBlocking400TextResponse) VisitDisableBlockingResponse(w ht
 	return err
 }
 
-type EnableBlockingRequestObject struct {
+type SetCustomDNSRecordsRequestObject struct {
+	Name string `json:"name"`
+	Body *SetCustomDNSRecordsJSONRequestBody
 }
 
-type EnableBlockingResponseObject interface {
-	VisitEnableBlockingResponse(w http.ResponseWriter) error
+type SetCustomDNSRecordsResponseObject interface {
+	VisitSetCustomDNSRecordsResponse(w http.ResponseWriter) error
 }
 
-type EnableBlocking200Response struct {
+type SetCustomDNSRecords200Response struct {
 }
 
-func (response EnableBlocking200Response) VisitEnableBlockingResponse(w http.ResponseWriter) error {
+func (response SetCustomDNSRecords200Response) VisitSetCustomDNSRecordsResponse(w http.ResponseWriter) error {
 	w.WriteHeader(200)
 	return nil
 }
 
-type BlockingStatusRequestObject struct {
+type SetCustomDNSRecords400TextResponse string
+
+func (response SetCustomDNSRecords400TextResponse) VisitSetCustomDNSRecordsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
 }
 
-type BlockingStatusResponseObject interface {
-	VisitBlockingStatusResponse(w http.ResponseWriter) error
+type ListsRequestObject struct {
 }
 
-type BlockingStatus200JSONResponse ApiBlockingStatus
+type ListsResponseObject interface {
+	VisitListsResponse(w http.ResponseWriter) error
+}
 
-func (response BlockingStatus200JSONResponse) VisitBlockingStatusResponse(w http.ResponseWriter) error {
+type Lists200JSONResponse []ApiListGroup
+
+func (response Lists200JSONResponse) VisitListsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListLookupRequestObject struct {
+	Params ListLookupParams
+}
+
+type ListLookupResponseObject interface {
+	VisitListLookupResponse(w http.ResponseWriter) error
+}
+
+type ListLookup200JSONResponse ApiListLookup
+
+func (response ListLookup200JSONResponse) VisitListLookupResponse(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(200)
 
 	return json.NewEncoder(w).Encode(response)
 }
 
+type ListLookup400TextResponse string
+
+func (response ListLookup400TextResponse) VisitListLookupResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
 type ListRefreshRequestObject struct {
 }
 
@@ -386,6 +1190,22 @@ func (response ListRefresh500TextResponse) VisitListRefreshResponse(w http.Respo
 	return err
 }
 
+type ListSourcesRequestObject struct {
+}
+
+type ListSourcesResponseObject interface {
+	VisitListSourcesResponse(w http.ResponseWriter) error
+}
+
+type ListSources200JSONResponse []ApiListSource
+
+func (response ListSources200JSONResponse) VisitListSourcesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type QueryRequestObject struct {
 	Body *QueryJSONRequestBody
 }
@@ -403,9 +1223,94 @@ func (response Query200JSONResponse) VisitQueryResponse(w http.ResponseWriter) e
 	return json.NewEncoder(w).Encode(response)
 }
 
-type Query400TextResponse string
+type Query400JSONResponse ApiError
+
+func (response Query400JSONResponse) VisitQueryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type Query502JSONResponse ApiError
+
+func (response Query502JSONResponse) VisitQueryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(502)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type Query504JSONResponse ApiError
+
+func (response Query504JSONResponse) VisitQueryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(504)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type StartupSummaryRequestObject struct {
+}
+
+type StartupSummaryResponseObject interface {
+	VisitStartupSummaryResponse(w http.ResponseWriter) error
+}
+
+type StartupSummary200JSONResponse ApiStartupSummary
+
+func (response StartupSummary200JSONResponse) VisitStartupSummaryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ResetClientStatsRequestObject struct {
+}
+
+type ResetClientStatsResponseObject interface {
+	VisitResetClientStatsResponse(w http.ResponseWriter) error
+}
+
+type ResetClientStats200Response struct {
+}
+
+func (response ResetClientStats200Response) VisitResetClientStatsResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type ResetClientStats400TextResponse string
+
+func (response ResetClientStats400TextResponse) VisitResetClientStatsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type ClientStatsRequestObject struct {
+	Params ClientStatsParams
+}
+
+type ClientStatsResponseObject interface {
+	VisitClientStatsResponse(w http.ResponseWriter) error
+}
+
+type ClientStats200JSONResponse ApiClientStats
+
+func (response ClientStats200JSONResponse) VisitClientStatsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ClientStats400TextResponse string
 
-func (response Query400TextResponse) VisitQueryResponse(w http.ResponseWriter) error {
+func (response ClientStats400TextResponse) VisitClientStatsResponse(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(400)
 
@@ -413,52 +1318,266 @@ func (response Query400TextResponse) VisitQueryResponse(w http.ResponseWriter) e
 	return err
 }
 
+type UpstreamStatusRequestObject struct {
+}
+
+type UpstreamStatusResponseObject interface {
+	VisitUpstreamStatusResponse(w http.ResponseWriter) error
+}
+
+type UpstreamStatus200JSONResponse []ApiUpstreamStatus
+
+func (response UpstreamStatus200JSONResponse) VisitUpstreamStatusResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 // StrictServerInterface represents all server handlers.
 type StrictServerInterface interface {
+	// Remove whitelist entry
+	// (DELETE /blocking/allow)
+	RemoveAllowEntry(ctx context.Context, request RemoveAllowEntryRequestObject) (RemoveAllowEntryResponseObject, error)
+	// Add whitelist entry
+	// (POST /blocking/allow)
+	AddAllowEntry(ctx context.Context, request AddAllowEntryRequestObject) (AddAllowEntryResponseObject, error)
+	// Check domain
+	// (GET /blocking/check)
+	BlockingCheck(ctx context.Context, request BlockingCheckRequestObject) (BlockingCheckResponseObject, error)
+	// Remove blacklist entry
+	// (DELETE /blocking/deny)
+	RemoveDenyEntry(ctx context.Context, request RemoveDenyEntryRequestObject) (RemoveDenyEntryResponseObject, error)
+	// Add blacklist entry
+	// (POST /blocking/deny)
+	AddDenyEntry(ctx context.Context, request AddDenyEntryRequestObject) (AddDenyEntryResponseObject, error)
 	// Disable blocking
 	// (GET /blocking/disable)
 	DisableBlocking(ctx context.Context, request DisableBlockingRequestObject) (DisableBlockingResponseObject, error)
 	// Enable blocking
 	// (GET /blocking/enable)
 	EnableBlocking(ctx context.Context, request EnableBlockingRequestObject) (EnableBlockingResponseObject, error)
+	// Runtime entries
+	// (GET /blocking/entries)
+	BlockingEntries(ctx context.Context, request BlockingEntriesRequestObject) (BlockingEntriesResponseObject, error)
 	// Blocking status
 	// (GET /blocking/status)
 	BlockingStatus(ctx context.Context, request BlockingStatusRequestObject) (BlockingStatusResponseObject, error)
+	// Custom DNS records
+	// (GET /customdns/records)
+	CustomDNSRecords(ctx context.Context, request CustomDNSRecordsRequestObject) (CustomDNSRecordsResponseObject, error)
+	// Remove custom DNS records
+	// (DELETE /customdns/records/{name})
+	RemoveCustomDNSRecords(ctx context.Context, request RemoveCustomDNSRecordsRequestObject) (RemoveCustomDNSRecordsResponseObject, error)
+	// Set custom DNS records
+	// (PUT /customdns/records/{name})
+	SetCustomDNSRecords(ctx context.Context, request SetCustomDNSRecordsRequestObject) (SetCustomDNSRecordsResponseObject, error)
+	// List groups
+	// (GET /lists)
+	Lists(ctx context.Context, request ListsRequestObject) (ListsResponseObject, error)
+	// Look up domain in lists
+	// (GET /lists/lookup)
+	ListLookup(ctx context.Context, request ListLookupRequestObject) (ListLookupResponseObject, error)
 	// List refresh
 	// (POST /lists/refresh)
 	ListRefresh(ctx context.Context, request ListRefreshRequestObject) (ListRefreshResponseObject, error)
+	// List sources
+	// (GET /lists/sources)
+	ListSources(ctx context.Context, request ListSourcesRequestObject) (ListSourcesResponseObject, error)
 	// Performs DNS query
 	// (POST /query)
 	Query(ctx context.Context, request QueryRequestObject) (QueryResponseObject, error)
+	// Startup summary
+	// (GET /startup)
+	StartupSummary(ctx context.Context, request StartupSummaryRequestObject) (StartupSummaryResponseObject, error)
+	// Reset client statistics
+	// (DELETE /stats/clients)
+	ResetClientStats(ctx context.Context, request ResetClientStatsRequestObject) (ResetClientStatsResponseObject, error)
+	// Client statistics
+	// (GET /stats/clients)
+	ClientStats(ctx context.Context, request ClientStatsRequestObject) (ClientStatsResponseObject, error)
+	// Upstream status
+	// (GET /upstreams/status)
+	UpstreamStatus(ctx context.Context, request UpstreamStatusRequestObject) (UpstreamStatusResponseObject, error)
+}
+
+type StrictHandlerFunc = strictnethttp.StrictHttpHandlerFunc
+type StrictMiddlewareFunc = strictnethttp.StrictHttpMiddlewareFunc
+
+type StrictHTTPServerOptions struct {
+	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
+	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		},
+	}}
+}
+
+func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: options}
+}
+
+type strictHandler struct {
+	ssi         StrictServerInterface
+	middlewares []StrictMiddlewareFunc
+	options     StrictHTTPServerOptions
+}
+
+// RemoveAllowEntry operation middleware
+func (sh *strictHandler) RemoveAllowEntry(w http.ResponseWriter, r *http.Request) {
+	var request RemoveAllowEntryRequestObject
+
+	var body RemoveAllowEntryJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.RemoveAllowEntry(ctx, request.(RemoveAllowEntryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "RemoveAllowEntry")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(RemoveAllowEntryResponseObject); ok {
+		if err := validResponse.VisitRemoveAllowEntryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// AddAllowEntry operation middleware
+func (sh *strictHandler) AddAllowEntry(w http.ResponseWriter, r *http.Request) {
+	var request AddAllowEntryRequestObject
+
+	var body AddAllowEntryJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.AddAllowEntry(ctx, request.(AddAllowEntryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "AddAllowEntry")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(AddAllowEntryResponseObject); ok {
+		if err := validResponse.VisitAddAllowEntryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// BlockingCheck operation middleware
+func (sh *strictHandler) BlockingCheck(w http.ResponseWriter, r *http.Request, params BlockingCheckParams) {
+	var request BlockingCheckRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.BlockingCheck(ctx, request.(BlockingCheckRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "BlockingCheck")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(BlockingCheckResponseObject); ok {
+		if err := validResponse.VisitBlockingCheckResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type StrictHandlerFunc = strictnethttp.StrictHttpHandlerFunc
-type StrictMiddlewareFunc = strictnethttp.StrictHttpMiddlewareFunc
+// RemoveDenyEntry operation middleware
+func (sh *strictHandler) RemoveDenyEntry(w http.ResponseWriter, r *http.Request) {
+	var request RemoveDenyEntryRequestObject
+
+	var body RemoveDenyEntryJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.RemoveDenyEntry(ctx, request.(RemoveDenyEntryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "RemoveDenyEntry")
+	}
 
-type StrictHTTPServerOptions struct {
-	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
-	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
-}
+	response, err := handler(r.Context(), w, r, request)
 
-func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
-	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
-		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		},
-		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		},
-	}}
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(RemoveDenyEntryResponseObject); ok {
+		if err := validResponse.VisitRemoveDenyEntryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
-	return &strictHandler{ssi: ssi, middlewares: middlewares, options: options}
-}
+// AddDenyEntry operation middleware
+func (sh *strictHandler) AddDenyEntry(w http.ResponseWriter, r *http.Request) {
+	var request AddDenyEntryRequestObject
 
-type strictHandler struct {
-	ssi         StrictServerInterface
-	middlewares []StrictMiddlewareFunc
-	options     StrictHTTPServerOptions
+	var body AddDenyEntryJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.AddDenyEntry(ctx, request.(AddDenyEntryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "AddDenyEntry")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(AddDenyEntryResponseObject); ok {
+		if err := validResponse.VisitAddDenyEntryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
 // DisableBlocking operation middleware
@@ -488,9 +1607,11 @@ func (sh *strictHandler) DisableBlocking(w http.ResponseWriter, r *http.Request,
 }
 
 // EnableBlocking operation middleware
-func (sh *strictHandler) EnableBlocking(w http.ResponseWriter, r *http.Request) {
+func (sh *strictHandler) EnableBlocking(w http.ResponseWriter, r *http.Request, params EnableBlockingParams) {
 	var request EnableBlockingRequestObject
 
+	request.Params = params
+
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
 		return sh.ssi.EnableBlocking(ctx, request.(EnableBlockingRequestObject))
 	}
@@ -511,6 +1632,30 @@ func (sh *strictHandler) EnableBlocking(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// BlockingEntries operation middleware
+func (sh *strictHandler) BlockingEntries(w http.ResponseWriter, r *http.Request) {
+	var request BlockingEntriesRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.BlockingEntries(ctx, request.(BlockingEntriesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "BlockingEntries")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(BlockingEntriesResponseObject); ok {
+		if err := validResponse.VisitBlockingEntriesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // BlockingStatus operation middleware
 func (sh *strictHandler) BlockingStatus(w http.ResponseWriter, r *http.Request) {
 	var request BlockingStatusRequestObject
@@ -535,6 +1680,139 @@ func (sh *strictHandler) BlockingStatus(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// CustomDNSRecords operation middleware
+func (sh *strictHandler) CustomDNSRecords(w http.ResponseWriter, r *http.Request) {
+	var request CustomDNSRecordsRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CustomDNSRecords(ctx, request.(CustomDNSRecordsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CustomDNSRecords")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CustomDNSRecordsResponseObject); ok {
+		if err := validResponse.VisitCustomDNSRecordsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// RemoveCustomDNSRecords operation middleware
+func (sh *strictHandler) RemoveCustomDNSRecords(w http.ResponseWriter, r *http.Request, name string) {
+	var request RemoveCustomDNSRecordsRequestObject
+
+	request.Name = name
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.RemoveCustomDNSRecords(ctx, request.(RemoveCustomDNSRecordsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "RemoveCustomDNSRecords")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(RemoveCustomDNSRecordsResponseObject); ok {
+		if err := validResponse.VisitRemoveCustomDNSRecordsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// SetCustomDNSRecords operation middleware
+func (sh *strictHandler) SetCustomDNSRecords(w http.ResponseWriter, r *http.Request, name string) {
+	var request SetCustomDNSRecordsRequestObject
+
+	request.Name = name
+
+	var body SetCustomDNSRecordsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.SetCustomDNSRecords(ctx, request.(SetCustomDNSRecordsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "SetCustomDNSRecords")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(SetCustomDNSRecordsResponseObject); ok {
+		if err := validResponse.VisitSetCustomDNSRecordsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// Lists operation middleware
+func (sh *strictHandler) Lists(w http.ResponseWriter, r *http.Request) {
+	var request ListsRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.Lists(ctx, request.(ListsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "Lists")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListsResponseObject); ok {
+		if err := validResponse.VisitListsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListLookup operation middleware
+func (sh *strictHandler) ListLookup(w http.ResponseWriter, r *http.Request, params ListLookupParams) {
+	var request ListLookupRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListLookup(ctx, request.(ListLookupRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListLookup")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListLookupResponseObject); ok {
+		if err := validResponse.VisitListLookupResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // ListRefresh operation middleware
 func (sh *strictHandler) ListRefresh(w http.ResponseWriter, r *http.Request) {
 	var request ListRefreshRequestObject
@@ -559,6 +1837,30 @@ func (sh *strictHandler) ListRefresh(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ListSources operation middleware
+func (sh *strictHandler) ListSources(w http.ResponseWriter, r *http.Request) {
+	var request ListSourcesRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListSources(ctx, request.(ListSourcesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListSources")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListSourcesResponseObject); ok {
+		if err := validResponse.VisitListSourcesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // Query operation middleware
 func (sh *strictHandler) Query(w http.ResponseWriter, r *http.Request) {
 	var request QueryRequestObject
@@ -589,3 +1891,101 @@ func (sh *strictHandler) Query(w http.ResponseWriter, r *http.Request) {
 		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
 	}
 }
+
+// StartupSummary operation middleware
+func (sh *strictHandler) StartupSummary(w http.ResponseWriter, r *http.Request) {
+	var request StartupSummaryRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.StartupSummary(ctx, request.(StartupSummaryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "StartupSummary")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(StartupSummaryResponseObject); ok {
+		if err := validResponse.VisitStartupSummaryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ResetClientStats operation middleware
+func (sh *strictHandler) ResetClientStats(w http.ResponseWriter, r *http.Request) {
+	var request ResetClientStatsRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ResetClientStats(ctx, request.(ResetClientStatsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ResetClientStats")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ResetClientStatsResponseObject); ok {
+		if err := validResponse.VisitResetClientStatsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ClientStats operation middleware
+func (sh *strictHandler) ClientStats(w http.ResponseWriter, r *http.Request, params ClientStatsParams) {
+	var request ClientStatsRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ClientStats(ctx, request.(ClientStatsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ClientStats")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ClientStatsResponseObject); ok {
+		if err := validResponse.VisitClientStatsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UpstreamStatus operation middleware
+func (sh *strictHandler) UpstreamStatus(w http.ResponseWriter, r *http.Request) {
+	var request UpstreamStatusRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UpstreamStatus(ctx, request.(UpstreamStatusRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UpstreamStatus")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UpstreamStatusResponseObject); ok {
+		if err := validResponse.VisitUpstreamStatusResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
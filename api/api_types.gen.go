@@ -3,11 +3,100 @@
 // Code generated by github.com/deepmap/oapi-codegen version v1.14.0 DO NOT EDIT.
 package api
 
+import (
+	"time"
+)
+
+// Defines values for ApiErrorCode.
+const (
+	INVALIDQUERY        ApiErrorCode = "INVALID_QUERY"
+	UPSTREAMTIMEOUT     ApiErrorCode = "UPSTREAM_TIMEOUT"
+	UPSTREAMUNREACHABLE ApiErrorCode = "UPSTREAM_UNREACHABLE"
+)
+
+// Defines values for ApiListLookupMatchMatch.
+const (
+	Cidr     ApiListLookupMatchMatch = "cidr"
+	Exact    ApiListLookupMatchMatch = "exact"
+	Parent   ApiListLookupMatchMatch = "parent"
+	Regex    ApiListLookupMatchMatch = "regex"
+	Wildcard ApiListLookupMatchMatch = "wildcard"
+)
+
+// ApiBlockingCheck defines model for api.BlockingCheck.
+type ApiBlockingCheck struct {
+	// AllowMatches matching whitelist entries
+	AllowMatches []ApiBlockingCheckMatch `json:"allowMatches"`
+
+	// AllowOverride true if an allow entry prevents the domain from being blocked
+	AllowOverride bool `json:"allowOverride"`
+
+	// Blocked true if the domain would be blocked
+	Blocked bool `json:"blocked"`
+
+	// DenyMatches matching blacklist entries
+	DenyMatches []ApiBlockingCheckMatch `json:"denyMatches"`
+
+	// Domain checked domain name, internationalized names (IDN) in unicode form
+	Domain string `json:"domain"`
+
+	// DomainPunycode checked domain name in punycode (ASCII) form, as it is matched against the lists
+	DomainPunycode string `json:"domainPunycode"`
+
+	// Groups groups which were checked for the client
+	Groups []string `json:"groups"`
+
+	// Reason reason of the block, e.g. "BLOCKED (ads)" or "WOULD_BLOCK (ads)" for audited groups, empty if not blocked
+	Reason string `json:"reason"`
+}
+
+// ApiBlockingCheckMatch defines model for api.BlockingCheckMatch.
+type ApiBlockingCheckMatch struct {
+	// Entry matching entry, e.g. "*.example.com" for a wildcard or "/regex/" for a regex entry
+	Entry string `json:"entry"`
+
+	// Group black- or whitelist group name
+	Group string `json:"group"`
+
+	// Source list source (URL, file or inline content) or "runtime entries"
+	Source string `json:"source"`
+}
+
+// ApiBlockingEntry defines model for api.BlockingEntry.
+type ApiBlockingEntry struct {
+	// Domain domain name
+	Domain string `json:"domain"`
+
+	// ExpiresInSec remaining seconds until a temporary entry expires, not set for permanent entries
+	ExpiresInSec *int `json:"expiresInSec,omitempty"`
+
+	// Group black- or whitelist group name
+	Group string `json:"group"`
+
+	// Type entry type (deny or allow)
+	Type string `json:"type"`
+}
+
+// ApiBlockingEntryRequest defines model for api.BlockingEntryRequest.
+type ApiBlockingEntryRequest struct {
+	// Domain domain name
+	Domain string `json:"domain"`
+
+	// Group black- or whitelist group name
+	Group string `json:"group"`
+
+	// Ttl optional lifetime of a temporary entry (e.g. 30m, 24h). The entry is removed automatically after it expires. Adding an existing entry again replaces its lifetime. Ignored on removal
+	Ttl *string `json:"ttl,omitempty"`
+}
+
 // ApiBlockingStatus defines model for api.BlockingStatus.
 type ApiBlockingStatus struct {
 	// AutoEnableInSec If blocking is temporary disabled: amount of seconds until blocking will be enabled
 	AutoEnableInSec *int `json:"autoEnableInSec,omitempty"`
 
+	// DisabledClients Clients with temporary or permanently disabled blocking
+	DisabledClients *[]ApiDisabledClient `json:"disabledClients,omitempty"`
+
 	// DisabledGroups Disabled group names
 	DisabledGroups *[]string `json:"disabledGroups,omitempty"`
 
@@ -15,6 +104,175 @@ type ApiBlockingStatus struct {
 	Enabled bool `json:"enabled"`
 }
 
+// ApiClientStats defines model for api.ClientStats.
+type ApiClientStats struct {
+	// Clients statistics per client
+	Clients []ApiClientStatsEntry `json:"clients"`
+
+	// Since start of the statistics (last reset)
+	Since time.Time `json:"since"`
+}
+
+// ApiClientStatsEntry defines model for api.ClientStatsEntry.
+type ApiClientStatsEntry struct {
+	// Blocked number of blocked queries
+	Blocked int `json:"blocked"`
+
+	// Client client name (or IP address if the name is unknown), internationalized names (IDN) in unicode form
+	Client string `json:"client"`
+
+	// ClientPunycode client name (or IP address) in punycode (ASCII) form
+	ClientPunycode string `json:"clientPunycode"`
+
+	// ErrorBound max number of queries which were sent before the client was tracked, since only the clients with the most queries are kept
+	ErrorBound int `json:"errorBound"`
+
+	// QueryTypes number of queries per query type (A, AAAA, PTR, ...)
+	QueryTypes map[string]int `json:"queryTypes"`
+
+	// ResponseTypes number of queries per response type (RESOLVED, CACHED, BLOCKED, ...)
+	ResponseTypes map[string]int `json:"responseTypes"`
+
+	// Total number of queries
+	Total int `json:"total"`
+}
+
+// ApiCustomDNSRecords defines model for api.CustomDNSRecords.
+type ApiCustomDNSRecords struct {
+	// Name domain name, a wildcard name for wildcard entries of the mapping
+	Name string `json:"name"`
+
+	// Records records in zone file syntax without name, TTL and class
+	Records []string `json:"records"`
+
+	// Source source of the records (runtime, mapping, zone file or the client group, e.g. "client group 10.6.0.0/24")
+	Source string `json:"source"`
+
+	// TtlInSec TTL of the records, not set for zone file records which keep their own TTL
+	TtlInSec *int `json:"ttlInSec,omitempty"`
+}
+
+// ApiCustomDNSRecordsRequest defines model for api.CustomDNSRecordsRequest.
+type ApiCustomDNSRecordsRequest struct {
+	// Records records in zone file syntax without name, TTL and class. A CNAME record must be the only record of the name
+	Records []string `json:"records"`
+
+	// Ttl optional TTL of the records (e.g. 30s, 1h), customTTL if not set
+	Ttl *string `json:"ttl,omitempty"`
+}
+
+// ApiDisabledClient defines model for api.DisabledClient.
+type ApiDisabledClient struct {
+	// AutoEnableInSec If blocking is temporary disabled: amount of seconds until blocking will be enabled for the client
+	AutoEnableInSec *int `json:"autoEnableInSec,omitempty"`
+
+	// Client Client (IP or client name)
+	Client string `json:"client"`
+
+	// DisabledGroups Disabled group names
+	DisabledGroups []string `json:"disabledGroups"`
+}
+
+// ApiError defines model for api.Error.
+type ApiError struct {
+	// Code machine readable error code
+	Code ApiErrorCode `json:"code"`
+
+	// Details internal error details, only returned if enabled by the configuration (api.errorDetails)
+	Details *string `json:"details,omitempty"`
+
+	// Message error message, it doesn't contain internal details like upstream addresses
+	Message string `json:"message"`
+}
+
+// ApiErrorCode machine readable error code
+type ApiErrorCode string
+
+// ApiListGroup defines model for api.ListGroup.
+type ApiListGroup struct {
+	// Entries number of entries of all sources
+	Entries int `json:"entries"`
+
+	// Group group name
+	Group   string               `json:"group"`
+	Sources []ApiListGroupSource `json:"sources"`
+
+	// Type list type (blacklist or whitelist)
+	Type string `json:"type"`
+}
+
+// ApiListGroupSource defines model for api.ListGroupSource.
+type ApiListGroupSource struct {
+	// Entries number of entries of the source
+	Entries int `json:"entries"`
+
+	// ErrorCount number of failed refreshes
+	ErrorCount int `json:"errorCount"`
+
+	// LastChanged time of the last refresh which loaded changes, missing if the source wasn't loaded yet
+	LastChanged *time.Time `json:"lastChanged,omitempty"`
+
+	// LastError error of the last refresh, missing if it succeeded
+	LastError *string `json:"lastError,omitempty"`
+
+	// LastSuccess time of the last successful refresh, changed or not, missing if there was none
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+
+	// Source list source (URL, file or inline content)
+	Source string `json:"source"`
+}
+
+// ApiListLookup defines model for api.ListLookup.
+type ApiListLookup struct {
+	// Domain looked up domain name, internationalized names (IDN) in unicode form
+	Domain string `json:"domain"`
+
+	// DomainPunycode looked up domain name in punycode (ASCII) form, as it is matched against the lists
+	DomainPunycode string `json:"domainPunycode"`
+
+	// Matches matching list entries
+	Matches []ApiListLookupMatch `json:"matches"`
+}
+
+// ApiListLookupMatch defines model for api.ListLookupMatch.
+type ApiListLookupMatch struct {
+	// Entry entry as it is stored after parsing, e.g. "*.example.com" for a wildcard or "/regex/" for a regex entry
+	Entry string `json:"entry"`
+
+	// Exception true for exception entries (e.g. "@@" rules of Adblock Plus lists), which exempt the domain from the other entries of the group
+	Exception bool `json:"exception"`
+
+	// Group group name
+	Group string `json:"group"`
+
+	// Match kind of the match. "parent" is an entry of a parent domain, which doesn't match the domain itself: only wildcard and regex entries match subdomains
+	Match ApiListLookupMatchMatch `json:"match"`
+
+	// Source list source (URL, file or inline content)
+	Source string `json:"source"`
+
+	// Type list type (blacklist or whitelist)
+	Type string `json:"type"`
+}
+
+// ApiListLookupMatchMatch kind of the match. "parent" is an entry of a parent domain, which doesn't match the domain itself: only wildcard and regex entries match subdomains
+type ApiListLookupMatchMatch string
+
+// ApiListSource defines model for api.ListSource.
+type ApiListSource struct {
+	// Group group name
+	Group string `json:"group"`
+
+	// LastChanged time of the last refresh which loaded changes, missing if the source wasn't loaded yet. Sources which support conditional downloads (ETag or Last-Modified) are only loaded if they changed
+	LastChanged *time.Time `json:"lastChanged,omitempty"`
+
+	// Source list source (URL, file or inline content)
+	Source string `json:"source"`
+
+	// Type list type (blacklist or whitelist)
+	Type string `json:"type"`
+}
+
 // ApiQueryRequest defines model for api.QueryRequest.
 type ApiQueryRequest struct {
 	// Query query for DNS request
@@ -26,6 +284,15 @@ type ApiQueryRequest struct {
 
 // ApiQueryResult defines model for api.QueryResult.
 type ApiQueryResult struct {
+	// BlockOverride Why a deny match of the domain didn't block the query, e.g. "WHITELIST (group: entry)". Only set if blocking.annotateOverrides is enabled
+	BlockOverride *string `json:"blockOverride,omitempty"`
+
+	// Question queried domain name, internationalized names (IDN) in unicode form
+	Question string `json:"question"`
+
+	// QuestionPunycode queried domain name in punycode (ASCII) form, as it was sent in the DNS request
+	QuestionPunycode string `json:"questionPunycode"`
+
 	// Reason blocky reason for resolution
 	Reason string `json:"reason"`
 
@@ -39,6 +306,51 @@ type ApiQueryResult struct {
 	ReturnCode string `json:"returnCode"`
 }
 
+// ApiStartupPhase defines model for api.StartupPhase.
+type ApiStartupPhase struct {
+	// DurationMs duration of the phase in milliseconds
+	DurationMs int64 `json:"durationMs"`
+
+	// Name name of the phase
+	Name string `json:"name"`
+}
+
+// ApiStartupSummary defines model for api.StartupSummary.
+type ApiStartupSummary struct {
+	// DurationMs total duration of the startup in milliseconds
+	DurationMs int64 `json:"durationMs"`
+
+	// Phases phases of the startup, ordered by their start
+	Phases []ApiStartupPhase `json:"phases"`
+}
+
+// ApiUpstreamStatus defines model for api.UpstreamStatus.
+type ApiUpstreamStatus struct {
+	// FailedAttempts number of failed verification attempts
+	FailedAttempts int `json:"failedAttempts"`
+
+	// Group upstream group name
+	Group string `json:"group"`
+
+	// LastError error of the last failed verification
+	LastError *string `json:"lastError,omitempty"`
+
+	// State verification state (active, pending or failed)
+	State string `json:"state"`
+
+	// Upstream upstream definition
+	Upstream string `json:"upstream"`
+}
+
+// BlockingCheckParams defines parameters for BlockingCheck.
+type BlockingCheckParams struct {
+	// Domain domain name to check
+	Domain string `form:"domain" json:"domain"`
+
+	// Client client name or IP address, used to determine the groups to check (default: groups of the default client)
+	Client *string `form:"client,omitempty" json:"client,omitempty"`
+}
+
 // DisableBlockingParams defines parameters for DisableBlocking.
 type DisableBlockingParams struct {
 	// Duration duration of blocking (Example: 300s, 5m, 1h, 5m30s)
@@ -46,7 +358,46 @@ type DisableBlockingParams struct {
 
 	// Groups groups to disable (comma separated). If empty, disable all groups
 	Groups *string `form:"groups,omitempty" json:"groups,omitempty"`
+
+	// Client client (IP or client name) to disable blocking for. If empty, disable blocking for all clients
+	Client *string `form:"client,omitempty" json:"client,omitempty"`
+}
+
+// EnableBlockingParams defines parameters for EnableBlocking.
+type EnableBlockingParams struct {
+	// Client client (IP or client name) to enable blocking for again. If empty, enable blocking globally
+	Client *string `form:"client,omitempty" json:"client,omitempty"`
+}
+
+// ListLookupParams defines parameters for ListLookup.
+type ListLookupParams struct {
+	// Domain domain name to look up
+	Domain string `form:"domain" json:"domain"`
 }
 
+// ClientStatsParams defines parameters for ClientStats.
+type ClientStatsParams struct {
+	// Sort sort order (descending): "total" (default), "blocked" or a query type (Example: PTR)
+	Sort *string `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Limit max number of returned clients
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// RemoveAllowEntryJSONRequestBody defines body for RemoveAllowEntry for application/json ContentType.
+type RemoveAllowEntryJSONRequestBody = ApiBlockingEntryRequest
+
+// AddAllowEntryJSONRequestBody defines body for AddAllowEntry for application/json ContentType.
+type AddAllowEntryJSONRequestBody = ApiBlockingEntryRequest
+
+// RemoveDenyEntryJSONRequestBody defines body for RemoveDenyEntry for application/json ContentType.
+type RemoveDenyEntryJSONRequestBody = ApiBlockingEntryRequest
+
+// AddDenyEntryJSONRequestBody defines body for AddDenyEntry for application/json ContentType.
+type AddDenyEntryJSONRequestBody = ApiBlockingEntryRequest
+
+// SetCustomDNSRecordsJSONRequestBody defines body for SetCustomDNSRecords for application/json ContentType.
+type SetCustomDNSRecordsJSONRequestBody = ApiCustomDNSRecordsRequest
+
 // QueryJSONRequestBody defines body for Query for application/json ContentType.
 type QueryJSONRequestBody = ApiQueryRequest
@@ -9,9 +9,15 @@ type ExpiringCache[T any] interface {
 	// Get returns the value of cached entry with remained TTL. If entry is not cached, returns nil
 	Get(key string) (val *T, expiration time.Duration)
 
+	// Delete removes the entry of the passed key, the evict function isn't called
+	Delete(key string)
+
 	// TotalCount returns the total count of valid (not expired) elements
 	TotalCount() int
 
+	// TotalSize returns the approximate size of all elements in bytes (only tracked if max bytes are limited)
+	TotalSize() int64
+
 	// Clear removes all cache entries
 	Clear()
 }
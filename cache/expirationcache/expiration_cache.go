@@ -1,8 +1,12 @@
 package expirationcache
 
 import (
+	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/0xERR0R/blocky/util"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -12,14 +16,26 @@ const (
 )
 
 type element[T any] struct {
-	val            *T
-	expiresEpochMs int64
+	val *T
+	// expiresAt is measured with the monotonic clock, wall clock jumps must not expire the elements
+	expiresAt util.MonotonicTime
+	size      int64
 }
 
 type ExpiringLRUCache[T any] struct {
 	cleanUpInterval time.Duration
 	preExpirationFn OnExpirationCallback[T]
+	onEvictedFn     OnEvictedCallback
 	lru             *lru.Cache
+
+	maxSize  uint
+	maxBytes int64
+	sizeFn   SizeFunc[T]
+
+	// lock guards all modifications of lru to keep the size bookkeeping consistent
+	lock       sync.Mutex
+	totalBytes atomic.Int64
+	evicting   bool
 }
 
 type CacheOption[T any] func(c *ExpiringLRUCache[T])
@@ -41,34 +57,89 @@ func WithOnExpiredFn[T any](fn OnExpirationCallback[T]) CacheOption[T] {
 	}
 }
 
+// OnEvictedCallback will be called if an element was removed from cache
+// to respect the max size (count or bytes) of the cache
+type OnEvictedCallback func(key string)
+
+func WithOnEvictedFn[T any](fn OnEvictedCallback) CacheOption[T] {
+	return func(c *ExpiringLRUCache[T]) {
+		c.onEvictedFn = fn
+	}
+}
+
+// SizeFunc returns the approximate size of the value in bytes
+type SizeFunc[T any] func(val *T) int
+
 func WithMaxSize[T any](size uint) CacheOption[T] {
 	return func(c *ExpiringLRUCache[T]) {
-		if size > 0 {
-			l, _ := lru.New(int(size))
-			c.lru = l
+		c.maxSize = size
+	}
+}
+
+// WithMaxBytes limits the approximate total size of all elements in bytes,
+// least recently used elements are evicted if the limit is exceeded.
+// The size of an element is calculated with sizeFn.
+func WithMaxBytes[T any](maxBytes uint64, sizeFn SizeFunc[T]) CacheOption[T] {
+	return func(c *ExpiringLRUCache[T]) {
+		if maxBytes > 0 && maxBytes <= math.MaxInt64 && sizeFn != nil {
+			c.maxBytes = int64(maxBytes)
+			c.sizeFn = sizeFn
 		}
 	}
 }
 
 func NewCache[T any](options ...CacheOption[T]) *ExpiringLRUCache[T] {
-	l, _ := lru.New(defaultSize)
+	c := newCache(defaultSize, options...)
+
+	go periodicCleanup(c)
+
+	return c
+}
+
+// newCache creates the cache without starting its periodic clean up.
+// defaultCount is the max count of elements, if the options don't limit the size
+func newCache[T any](defaultCount int, options ...CacheOption[T]) *ExpiringLRUCache[T] {
 	c := &ExpiringLRUCache[T]{
 		cleanUpInterval: defaultCleanUpInterval,
 		preExpirationFn: func(key string) (val *T, ttl time.Duration) {
 			return nil, 0
 		},
-		lru: l,
+		onEvictedFn: func(key string) {},
 	}
 
 	for _, opt := range options {
 		opt(c)
 	}
 
-	go periodicCleanup(c)
+	size := defaultCount
+
+	switch {
+	case c.maxSize > 0:
+		size = int(c.maxSize)
+	case c.maxBytes > 0:
+		// count is not limited, only the size in bytes
+		size = math.MaxInt
+	}
+
+	c.lru, _ = lru.NewWithEvict(size, c.onRemoved)
 
 	return c
 }
 
+// isLimited returns true if the options limit the count or the size in bytes of the cache
+func (e *ExpiringLRUCache[T]) isLimited() bool {
+	return e.maxSize > 0 || e.maxBytes > 0
+}
+
+// onRemoved is called by lru (synchronously, while lock is held) for each removed element
+func (e *ExpiringLRUCache[T]) onRemoved(key, value interface{}) {
+	e.totalBytes.Add(-value.(*element[T]).size)
+
+	if e.evicting {
+		e.onEvictedFn(key.(string))
+	}
+}
+
 func periodicCleanup[T any](c *ExpiringLRUCache[T]) {
 	ticker := time.NewTicker(c.cleanUpInterval)
 	defer ticker.Stop()
@@ -103,6 +174,9 @@ func (e *ExpiringLRUCache[T]) cleanUp() {
 			}
 		}
 
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
 		for _, key := range keysToDelete {
 			e.lru.Remove(key)
 		}
@@ -115,41 +189,80 @@ func (e *ExpiringLRUCache[T]) Put(key string, val *T, ttl time.Duration) {
 		return
 	}
 
-	expiresEpochMs := time.Now().UnixMilli() + ttl.Milliseconds()
+	el := &element[T]{
+		val:       val,
+		expiresAt: util.MonotonicNow().Add(ttl),
+	}
+
+	if e.sizeFn != nil {
+		el.size = int64(e.sizeFn(val))
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// update of an existing key doesn't call the evict function
+	if old, found := e.lru.Peek(key); found {
+		e.totalBytes.Add(-old.(*element[T]).size)
+	}
+
+	e.totalBytes.Add(el.size)
+
+	// all removals from now on are evictions
+	e.evicting = true
+	defer func() { e.evicting = false }()
 
 	// add new item
-	e.lru.Add(key, &element[T]{
-		val:            val,
-		expiresEpochMs: expiresEpochMs,
-	})
+	e.lru.Add(key, el)
+
+	for e.maxBytes > 0 && e.totalBytes.Load() > e.maxBytes {
+		if _, _, ok := e.lru.RemoveOldest(); !ok {
+			break
+		}
+	}
 }
 
 func (e *ExpiringLRUCache[T]) Get(key string) (val *T, ttl time.Duration) {
 	el, found := e.lru.Get(key)
 
 	if found {
-		return el.(*element[T]).val, calculateRemainTTL(el.(*element[T]).expiresEpochMs)
+		return el.(*element[T]).val, calculateRemainTTL(el.(*element[T]).expiresAt)
 	}
 
 	return nil, 0
 }
 
 func isExpired[T any](el *element[T]) bool {
-	return el.expiresEpochMs > 0 && time.Now().UnixMilli() > el.expiresEpochMs
+	return !el.expiresAt.IsZero() && util.MonotonicNow().After(el.expiresAt)
 }
 
-func calculateRemainTTL(expiresEpoch int64) time.Duration {
-	if now := time.Now().UnixMilli(); now < expiresEpoch {
-		return time.Duration(expiresEpoch-now) * time.Millisecond
+func calculateRemainTTL(expiresAt util.MonotonicTime) time.Duration {
+	if remaining := util.MonotonicUntil(expiresAt); remaining > 0 {
+		return remaining
 	}
 
 	return 0
 }
 
+func (e *ExpiringLRUCache[T]) Delete(key string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.lru.Remove(key)
+}
+
 func (e *ExpiringLRUCache[T]) TotalCount() (count int) {
 	return e.lru.Len()
 }
 
+// TotalSize returns the approximate size of all elements in bytes, 0 if max bytes is not set
+func (e *ExpiringLRUCache[T]) TotalSize() int64 {
+	return e.totalBytes.Load()
+}
+
 func (e *ExpiringLRUCache[T]) Clear() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
 	e.lru.Purge()
 }
@@ -3,6 +3,7 @@ package expirationcache
 import (
 	"time"
 
+	"github.com/0xERR0R/blocky/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -77,6 +78,21 @@ var _ = Describe("Expiration cache", func() {
 				Expect(cache.TotalCount()).Should(Equal(1))
 			})
 		})
+		When("Deleting an entry", func() {
+			It("Should remove only this entry", func() {
+				cache := NewCache[string]()
+				v1 := "v1"
+				v2 := "v2"
+				cache.Put("key1", &v1, time.Second)
+				cache.Put("key2", &v2, time.Second)
+
+				cache.Delete("key1")
+
+				val, _ := cache.Get("key1")
+				Expect(val).Should(BeNil())
+				Expect(cache.TotalCount()).Should(Equal(1))
+			})
+		})
 		When("Purging after usage", func() {
 			It("Should be empty after purge", func() {
 				cache := NewCache[string]()
@@ -91,6 +107,48 @@ var _ = Describe("Expiration cache", func() {
 			})
 		})
 	})
+	Describe("Clock changes", func() {
+		var clock *util.FakeClock
+
+		BeforeEach(func() {
+			clock = util.NewFakeClock()
+			DeferCleanup(util.SetClock(clock))
+		})
+
+		When("the wall clock jumps forward", func() {
+			It("should not expire the elements", func() {
+				cache := NewCache[string]()
+				v := "v1"
+				cache.Put("key1", &v, time.Hour)
+
+				clock.JumpWallClock(5 * time.Hour)
+				cache.cleanUp()
+
+				val, ttl := cache.Get("key1")
+				Expect(val).Should(HaveValue(Equal("v1")))
+				Expect(ttl).Should(BeNumerically("~", time.Hour, time.Minute))
+			})
+		})
+
+		When("the wall clock jumps backward", func() {
+			It("should expire the elements after the TTL elapsed", func() {
+				cache := NewCache[string]()
+				v := "v1"
+				cache.Put("key1", &v, time.Hour)
+
+				clock.JumpWallClock(-5 * time.Hour)
+				clock.Advance(time.Hour + time.Second)
+
+				val, ttl := cache.Get("key1")
+				Expect(val).Should(HaveValue(Equal("v1")))
+				Expect(ttl).Should(BeZero())
+
+				cache.cleanUp()
+
+				Expect(cache.TotalCount()).Should(Equal(0))
+			})
+		})
+	})
 	Describe("preExpiration function", func() {
 		When(" function is defined", func() {
 			It("should update the value and TTL if function returns values", func() {
@@ -187,5 +245,91 @@ var _ = Describe("Expiration cache", func() {
 				Expect(cache.lru.Contains("key5")).Should(BeTrue())
 			})
 		})
+		When("Defined max bytes are reached", func() {
+			It("should remove least recently used elements", func() {
+				var evicted []string
+
+				cache := NewCache(
+					WithMaxBytes(10, func(val *string) int { return len(*val) }),
+					WithOnEvictedFn[string](func(key string) { evicted = append(evicted, key) }),
+				)
+
+				v1 := "1234"
+				v2 := "5678"
+				v3 := "90"
+				v4 := "abcdef"
+
+				cache.Put("key1", &v1, time.Second)
+				cache.Put("key2", &v2, time.Second)
+				cache.Put("key3", &v3, time.Second)
+
+				Expect(cache.TotalCount()).Should(Equal(3))
+				Expect(cache.TotalSize()).Should(BeNumerically("==", 10))
+				Expect(evicted).Should(BeEmpty())
+
+				// now get key1 to increase usage count
+				_, _ = cache.Get("key1")
+
+				// put key4 -> key2 and key3 must be removed
+				cache.Put("key4", &v4, time.Second)
+
+				Expect(evicted).Should(Equal([]string{"key2", "key3"}))
+				Expect(cache.lru.Contains("key1")).Should(BeTrue())
+				Expect(cache.lru.Contains("key4")).Should(BeTrue())
+				Expect(cache.TotalCount()).Should(Equal(2))
+				Expect(cache.TotalSize()).Should(BeNumerically("==", 10))
+			})
+
+			It("should track the size on update and clear", func() {
+				cache := NewCache(WithMaxBytes(100, func(val *string) int { return len(*val) }))
+
+				v1 := "1234"
+				v2 := "12"
+
+				cache.Put("key1", &v1, time.Second)
+				Expect(cache.TotalSize()).Should(BeNumerically("==", 4))
+
+				cache.Put("key1", &v2, time.Second)
+				Expect(cache.TotalSize()).Should(BeNumerically("==", 2))
+
+				cache.Clear()
+				Expect(cache.TotalSize()).Should(BeNumerically("==", 0))
+			})
+
+			It("should track the size on expiration", func() {
+				cache := NewCache(
+					WithMaxBytes(100, func(val *string) int { return len(*val) }),
+					WithCleanUpInterval[string](time.Hour),
+				)
+
+				v1 := "1234"
+				cache.Put("key1", &v1, time.Millisecond)
+
+				time.Sleep(2 * time.Millisecond)
+
+				cache.cleanUp()
+
+				Expect(cache.TotalCount()).Should(Equal(0))
+				Expect(cache.TotalSize()).Should(BeNumerically("==", 0))
+			})
+		})
+		When("Defined max count is reached", func() {
+			It("should call the evicted function", func() {
+				var evicted []string
+
+				cache := NewCache(
+					WithMaxSize[string](1),
+					WithOnEvictedFn[string](func(key string) { evicted = append(evicted, key) }),
+				)
+
+				v1 := "val1"
+				v2 := "val2"
+
+				cache.Put("key1", &v1, time.Second)
+				cache.Put("key2", &v2, time.Second)
+
+				Expect(evicted).Should(Equal([]string{"key1"}))
+			})
+		})
 	})
 })
@@ -0,0 +1,123 @@
+package expirationcache
+
+import (
+	"context"
+	"time"
+)
+
+// ShardedCache distributes the elements to multiple ExpiringLRUCache shards by a hash of the key.
+// Each shard has its own lock, which reduces the lock contention with concurrent access.
+// A cache with max size or max bytes has only one shard, so the limits and the LRU eviction stay global.
+type ShardedCache[T any] struct {
+	shards []*ExpiringLRUCache[T]
+}
+
+// NewShardedCache creates a new cache with count shards, all options are applied to each shard.
+// If the options limit the size, only one shard is created, otherwise the default size is split across the shards.
+// The expired elements of all shards are cleaned up by one goroutine, which stops when ctx is done.
+func NewShardedCache[T any](ctx context.Context, count uint, options ...CacheOption[T]) *ShardedCache[T] {
+	if count == 0 {
+		count = 1
+	}
+
+	perShard := divCeil(defaultSize, int(count))
+	first := newCache(perShard, options...)
+
+	if first.isLimited() {
+		count = 1
+	}
+
+	c := &ShardedCache[T]{
+		shards: make([]*ExpiringLRUCache[T], count),
+	}
+
+	c.shards[0] = first
+
+	for i := 1; i < len(c.shards); i++ {
+		c.shards[i] = newCache(perShard, options...)
+	}
+
+	go c.periodicCleanup(ctx, first.cleanUpInterval)
+
+	return c
+}
+
+func divCeil(a, b int) int {
+	return (a + b - 1) / b
+}
+
+func (c *ShardedCache[T]) periodicCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			for _, s := range c.shards {
+				s.cleanUp()
+			}
+		}
+	}
+}
+
+func (c *ShardedCache[T]) shard(key string) *ExpiringLRUCache[T] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+
+	return c.shards[fnv32a(key)%uint32(len(c.shards))]
+}
+
+// fnv32a is an allocation free FNV-1a hash of the key
+func fnv32a(key string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	h := uint32(offset32)
+
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime32
+	}
+
+	return h
+}
+
+func (c *ShardedCache[T]) Put(key string, val *T, ttl time.Duration) {
+	c.shard(key).Put(key, val, ttl)
+}
+
+func (c *ShardedCache[T]) Get(key string) (val *T, ttl time.Duration) {
+	return c.shard(key).Get(key)
+}
+
+func (c *ShardedCache[T]) Delete(key string) {
+	c.shard(key).Delete(key)
+}
+
+func (c *ShardedCache[T]) TotalCount() (count int) {
+	for _, s := range c.shards {
+		count += s.TotalCount()
+	}
+
+	return count
+}
+
+func (c *ShardedCache[T]) TotalSize() (size int64) {
+	for _, s := range c.shards {
+		size += s.TotalSize()
+	}
+
+	return size
+}
+
+func (c *ShardedCache[T]) Clear() {
+	for _, s := range c.shards {
+		s.Clear()
+	}
+}
@@ -0,0 +1,134 @@
+package expirationcache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sharded cache", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
+	Describe("Basic operations", func() {
+		It("should distribute the elements to the shards", func() {
+			cache := NewShardedCache[string](ctx, 4)
+
+			for i := 0; i < 100; i++ {
+				v := fmt.Sprintf("val%d", i)
+				cache.Put(fmt.Sprintf("key%d", i), &v, time.Second)
+			}
+
+			Expect(cache.TotalCount()).Should(Equal(100))
+
+			for _, s := range cache.shards {
+				Expect(s.TotalCount()).Should(BeNumerically(">", 0))
+			}
+
+			val, ttl := cache.Get("key42")
+			Expect(val).Should(HaveValue(Equal("val42")))
+			Expect(ttl.Milliseconds()).Should(BeNumerically("<=", 1000))
+
+			cache.Delete("key42")
+
+			val, _ = cache.Get("key42")
+			Expect(val).Should(BeNil())
+			Expect(cache.TotalCount()).Should(Equal(99))
+
+			cache.Clear()
+
+			Expect(cache.TotalCount()).Should(Equal(0))
+		})
+
+		It("should use one shard if count is 0", func() {
+			cache := NewShardedCache[string](ctx, 0)
+
+			Expect(cache.shards).Should(HaveLen(1))
+		})
+	})
+
+	Describe("Limits", func() {
+		It("should split the default size across the shards", func() {
+			cache := NewShardedCache[string](ctx, 4)
+
+			for i := 0; i < 2*defaultSize; i++ {
+				v := "val"
+				cache.Put(fmt.Sprintf("key%d", i), &v, time.Second)
+			}
+
+			Expect(cache.TotalCount()).Should(Equal(defaultSize))
+		})
+
+		It("should use one shard with max size", func() {
+			cache := NewShardedCache(ctx, 4, WithMaxSize[string](10))
+
+			Expect(cache.shards).Should(HaveLen(1))
+
+			for i := 0; i < 100; i++ {
+				v := "val"
+				cache.Put(fmt.Sprintf("key%d", i), &v, time.Second)
+			}
+
+			Expect(cache.TotalCount()).Should(Equal(10))
+		})
+
+		It("should use one shard with max bytes", func() {
+			cache := NewShardedCache(ctx, 4, WithMaxBytes(40, func(val *string) int { return len(*val) }))
+
+			Expect(cache.shards).Should(HaveLen(1))
+
+			By("keeping an element of max bytes", func() {
+				v := strings.Repeat("x", 40)
+				cache.Put("big", &v, time.Second)
+
+				val, _ := cache.Get("big")
+				Expect(val).Should(HaveValue(Equal(v)))
+			})
+
+			for i := 0; i < 100; i++ {
+				v := "12345"
+				cache.Put(fmt.Sprintf("key%d", i), &v, time.Second)
+			}
+
+			Expect(cache.TotalSize()).Should(BeNumerically("==", 40))
+			Expect(cache.TotalCount()).Should(Equal(8))
+		})
+	})
+
+	Describe("Clean up", func() {
+		It("should remove the expired elements of all shards", func() {
+			cache := NewShardedCache(ctx, 4, WithCleanUpInterval[string](50*time.Millisecond))
+
+			for i := 0; i < 100; i++ {
+				v := "val"
+				cache.Put(fmt.Sprintf("key%d", i), &v, 10*time.Millisecond)
+			}
+
+			Eventually(cache.TotalCount, "1s").Should(Equal(0))
+		})
+
+		It("should stop when the context is done", func() {
+			cache := NewShardedCache(ctx, 4, WithCleanUpInterval[string](10*time.Millisecond))
+
+			cancel()
+
+			for i := 0; i < 100; i++ {
+				v := "val"
+				cache.Put(fmt.Sprintf("key%d", i), &v, time.Millisecond)
+			}
+
+			Consistently(cache.TotalCount, "100ms").Should(Equal(100))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package stringcache
 
 import (
+	"slices"
 	"sort"
 
 	"golang.org/x/exp/maps"
@@ -25,13 +26,31 @@ func (c *ChainedGroupedCache) ElementCount(group string) int {
 	return sum
 }
 
+// Contains checks the caches in order, each cache is only asked for the groups which didn't match yet.
+// Expensive caches (e.g. regexes) should be last, so they only run if the cheaper ones miss
 func (c *ChainedGroupedCache) Contains(searchString string, groups []string) []string {
 	groupMatchedMap := make(map[string]struct{}, len(groups))
+	remaining := groups
 
 	for _, cache := range c.caches {
-		for _, group := range cache.Contains(searchString, groups) {
+		if len(remaining) == 0 {
+			break
+		}
+
+		matched := cache.Contains(searchString, remaining)
+		if len(matched) == 0 {
+			continue
+		}
+
+		for _, group := range matched {
 			groupMatchedMap[group] = struct{}{}
 		}
+
+		remaining = slices.DeleteFunc(slices.Clone(remaining), func(group string) bool {
+			_, found := groupMatchedMap[group]
+
+			return found
+		})
 	}
 
 	matchedGroups := maps.Keys(groupMatchedMap)
@@ -41,6 +60,34 @@ func (c *ChainedGroupedCache) Contains(searchString string, groups []string) []s
 	return matchedGroups
 }
 
+// Explain returns the entry of the first cache which matches, for each group
+func (c *ChainedGroupedCache) Explain(searchString string, groups []string) map[string]string {
+	result := make(map[string]string, len(groups))
+
+	for _, cache := range c.caches {
+		for group, entry := range cache.Explain(searchString, groups) {
+			if _, found := result[group]; !found {
+				result[group] = entry
+			}
+		}
+	}
+
+	return result
+}
+
+// Lookup returns the matches of all caches, in the order of the caches
+func (c *ChainedGroupedCache) Lookup(searchString string, groups []string) map[string][]Match {
+	result := make(map[string][]Match, len(groups))
+
+	for _, cache := range c.caches {
+		for group, matches := range cache.Lookup(searchString, groups) {
+			result[group] = append(result[group], matches...)
+		}
+	}
+
+	return result
+}
+
 func (c *ChainedGroupedCache) Refresh(group string) GroupFactory {
 	cacheFactories := make([]GroupFactory, len(c.caches))
 	for i, cache := range c.caches {
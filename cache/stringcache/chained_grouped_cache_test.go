@@ -90,4 +90,79 @@ var _ = Describe("Chained grouped cache", func() {
 			})
 		})
 	})
+
+	Describe("Matching order", func() {
+		When("a group matched in a previous cache", func() {
+			stringCache := stringcache.NewInMemoryGroupedStringCache()
+			regexCache := &recordingGroupedCache{GroupedStringCache: stringcache.NewInMemoryGroupedRegexCache()}
+			cache := stringcache.NewChainedGroupedCache(stringCache, regexCache)
+
+			for _, group := range []string{"group1", "group2"} {
+				factory := cache.Refresh(group)
+				factory.AddEntry("string1")
+				factory.AddEntry("/^string/")
+				factory.Finish()
+			}
+
+			It("should only check the remaining groups in the next cache", func() {
+				Expect(cache.Contains("string1", []string{"group1", "group2", "group3"})).
+					Should(ConsistOf("group1", "group2"))
+				Expect(regexCache.checkedGroups).Should(Equal([][]string{{"group3"}}))
+
+				Expect(cache.Contains("string2", []string{"group1", "group2"})).
+					Should(ConsistOf("group1", "group2"))
+				Expect(regexCache.checkedGroups).Should(HaveLen(2))
+			})
+
+			It("should explain the entry of the first matching cache", func() {
+				Expect(cache.Explain("string1", []string{"group1", "group3"})).
+					Should(Equal(map[string]string{"group1": "string1"}))
+				Expect(cache.Explain("string2", []string{"group1", "group2"})).
+					Should(Equal(map[string]string{"group1": "/^string/", "group2": "/^string/"}))
+			})
+		})
+	})
+
+	Describe("Lookup", func() {
+		cache := stringcache.NewChainedGroupedCache(
+			stringcache.NewInMemoryGroupedStringCache(),
+			stringcache.NewInMemoryGroupedWildcardCache(),
+			stringcache.NewInMemoryGroupedRegexCache(),
+		)
+
+		factory := cache.Refresh("group1")
+		factory.AddEntry("ads.example.com")
+		factory.AddEntry("example.com")
+		factory.AddEntry("*.example.com")
+		factory.AddEntry("/^ads/")
+		factory.Finish()
+
+		It("should return the matches of all caches", func() {
+			Expect(cache.Lookup("ads.example.com", []string{"group1", "group2"})).
+				Should(Equal(map[string][]stringcache.Match{
+					"group1": {
+						{Type: stringcache.MatchTypeExact, Entry: "ads.example.com"},
+						{Type: stringcache.MatchTypeParent, Entry: "example.com"},
+						{Type: stringcache.MatchTypeWildcard, Entry: "*.example.com"},
+						{Type: stringcache.MatchTypeRegex, Entry: "/^ads/"},
+					},
+				}))
+		})
+
+		It("should omit groups without a match", func() {
+			Expect(cache.Lookup("example.org", []string{"group1"})).Should(BeEmpty())
+		})
+	})
 })
+
+type recordingGroupedCache struct {
+	stringcache.GroupedStringCache
+
+	checkedGroups [][]string
+}
+
+func (c *recordingGroupedCache) Contains(searchString string, groups []string) []string {
+	c.checkedGroups = append(c.checkedGroups, groups)
+
+	return c.GroupedStringCache.Contains(searchString, groups)
+}
@@ -5,6 +5,14 @@ type GroupedStringCache interface {
 	// Returns group(s) containing the string or empty slice if string was not found
 	Contains(searchString string, groups []string) []string
 
+	// Explain checks which entry of the groups matches the search string.
+	// Returns the matching entry per group, groups without a match are omitted
+	Explain(searchString string, groups []string) map[string]string
+
+	// Lookup returns all matches of the groups for diagnostics, unlike `Explain` also exact entries
+	// of parent domains, which don't match the search string. Groups without a match are omitted
+	Lookup(searchString string, groups []string) map[string][]Match
+
 	// Refresh creates new factory for the group to be refreshed.
 	// Calling Finish on the factory will perform the group refresh.
 	Refresh(group string) GroupFactory
@@ -13,6 +21,28 @@ type GroupedStringCache interface {
 	ElementCount(group string) int
 }
 
+// MatchType is the kind of entry which matched in `Lookup`
+type MatchType string
+
+const (
+	// MatchTypeExact is an entry equal to the search string
+	MatchTypeExact MatchType = "exact"
+	// MatchTypeParent is an exact entry of a parent domain, it doesn't match the search string itself
+	MatchTypeParent MatchType = "parent"
+	// MatchTypeWildcard is a wildcard entry like "*.example.com"
+	MatchTypeWildcard MatchType = "wildcard"
+	// MatchTypeRegex is a regex entry like "/^ads/"
+	MatchTypeRegex MatchType = "regex"
+	// MatchTypeCIDR is a network entry like "10.0.0.0/8"
+	MatchTypeCIDR MatchType = "cidr"
+)
+
+// Match is an entry found by `Lookup`
+type Match struct {
+	Type  MatchType
+	Entry string
+}
+
 type GroupFactory interface {
 	// AddEntry adds a new string to the factory to be added later to the cache groups.
 	AddEntry(entry string)
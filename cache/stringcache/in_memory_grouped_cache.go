@@ -1,6 +1,9 @@
 package stringcache
 
-import "sync"
+import (
+	"strings"
+	"sync"
+)
 
 type stringCacheFactoryFn func() cacheFactory
 
@@ -8,12 +11,14 @@ type InMemoryGroupedCache struct {
 	caches    map[string]stringCache
 	lock      sync.RWMutex
 	factoryFn stringCacheFactoryFn
+	matchType MatchType
 }
 
 func NewInMemoryGroupedStringCache() *InMemoryGroupedCache {
 	return &InMemoryGroupedCache{
 		caches:    make(map[string]stringCache),
 		factoryFn: newStringCacheFactory,
+		matchType: MatchTypeExact,
 	}
 }
 
@@ -21,6 +26,23 @@ func NewInMemoryGroupedRegexCache() *InMemoryGroupedCache {
 	return &InMemoryGroupedCache{
 		caches:    make(map[string]stringCache),
 		factoryFn: newRegexCacheFactory,
+		matchType: MatchTypeRegex,
+	}
+}
+
+func NewInMemoryGroupedWildcardCache() *InMemoryGroupedCache {
+	return &InMemoryGroupedCache{
+		caches:    make(map[string]stringCache),
+		factoryFn: newWildcardCacheFactory,
+		matchType: MatchTypeWildcard,
+	}
+}
+
+func NewInMemoryGroupedCIDRCache() *InMemoryGroupedCache {
+	return &InMemoryGroupedCache{
+		caches:    make(map[string]stringCache),
+		factoryFn: newCIDRCacheFactory,
+		matchType: MatchTypeCIDR,
 	}
 }
 
@@ -52,6 +74,64 @@ func (c *InMemoryGroupedCache) Contains(searchString string, groups []string) []
 	return result
 }
 
+func (c *InMemoryGroupedCache) Explain(searchString string, groups []string) map[string]string {
+	result := make(map[string]string)
+
+	for _, group := range groups {
+		c.lock.RLock()
+		cache, found := c.caches[group]
+		c.lock.RUnlock()
+
+		if !found {
+			continue
+		}
+
+		if entry, found := cache.explain(searchString); found {
+			result[group] = entry
+		}
+	}
+
+	return result
+}
+
+func (c *InMemoryGroupedCache) Lookup(searchString string, groups []string) map[string][]Match {
+	result := make(map[string][]Match)
+
+	for _, group := range groups {
+		c.lock.RLock()
+		cache, found := c.caches[group]
+		c.lock.RUnlock()
+
+		if !found {
+			continue
+		}
+
+		if entry, found := cache.explain(searchString); found {
+			result[group] = append(result[group], Match{Type: c.matchType, Entry: entry})
+		}
+
+		if c.matchType != MatchTypeExact {
+			continue
+		}
+
+		// exact entries only match the domain itself, entries of parent domains are reported for diagnostics
+		for parent := searchString; ; {
+			_, rest, found := strings.Cut(parent, ".")
+			if !found || rest == "" {
+				break
+			}
+
+			parent = rest
+
+			if cache.contains(parent) {
+				result[group] = append(result[group], Match{Type: MatchTypeParent, Entry: normalizeEntry(parent)})
+			}
+		}
+	}
+
+	return result
+}
+
 func (c *InMemoryGroupedCache) Refresh(group string) GroupFactory {
 	return &inMemoryGroupFactory{
 		factory: c.factoryFn(),
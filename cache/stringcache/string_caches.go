@@ -1,16 +1,21 @@
 package stringcache
 
 import (
+	"fmt"
+	"net/netip"
 	"regexp"
 	"sort"
 	"strings"
 
 	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/trie"
 )
 
 type stringCache interface {
 	elementCount() int
 	contains(searchString string) bool
+	// explain returns the entry which matches the search string
+	explain(searchString string) (entry string, found bool)
 }
 
 type cacheFactory interface {
@@ -55,6 +60,14 @@ func (cache stringMap) contains(searchString string) bool {
 	return false
 }
 
+func (cache stringMap) explain(searchString string) (string, bool) {
+	if cache.contains(searchString) {
+		return normalizeEntry(searchString), true
+	}
+
+	return "", false
+}
+
 type stringCacheFactory struct {
 	// temporary map which holds sorted slice of strings grouped by string length
 	tmp map[int][]string
@@ -99,8 +112,8 @@ func (s *stringCacheFactory) insertString(entry string) {
 }
 
 func (s *stringCacheFactory) addEntry(entry string) {
-	// skip empty strings and regex
-	if len(entry) > 0 && !isRegex(entry) {
+	// skip empty strings, regex, wildcards and CIDRs
+	if len(entry) > 0 && !isRegex(entry) && !trie.IsWildcard(entry) && !isCIDR(entry) {
 		s.cnt++
 		s.insertString(entry)
 	}
@@ -118,7 +131,7 @@ func (s *stringCacheFactory) create() stringCache {
 }
 
 func isRegex(s string) bool {
-	return strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/")
+	return len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/")
 }
 
 type regexCache []*regexp.Regexp
@@ -139,6 +152,16 @@ func (cache regexCache) contains(searchString string) bool {
 	return false
 }
 
+func (cache regexCache) explain(searchString string) (string, bool) {
+	for _, regex := range cache {
+		if regex.MatchString(searchString) {
+			return fmt.Sprintf("/%s/", regex), true
+		}
+	}
+
+	return "", false
+}
+
 type regexCacheFactory struct {
 	cache regexCache
 }
@@ -169,3 +192,205 @@ func newRegexCacheFactory() cacheFactory {
 		cache: make(regexCache, 0),
 	}
 }
+
+// wildcardCache contains the parent domains of wildcard entries, "*.example.com" is stored as "example.com"
+type wildcardCache struct {
+	trie *trie.Trie
+}
+
+func (cache wildcardCache) elementCount() int {
+	return cache.trie.Count()
+}
+
+// contains checks if one of the parent domains of searchString is a wildcard entry.
+// The parent domain itself doesn't match: "*.example.com" matches "www.example.com" but not "example.com"
+func (cache wildcardCache) contains(searchString string) bool {
+	domain := normalizeEntry(searchString)
+
+	idx := strings.IndexByte(domain, '.')
+	if idx < 0 {
+		return false
+	}
+
+	return cache.trie.HasParentOf(domain[idx+1:])
+}
+
+// explain returns the wildcard entry which matches, e.g. "*.example.com" for "www.ads.example.com"
+func (cache wildcardCache) explain(searchString string) (string, bool) {
+	if !cache.contains(searchString) {
+		return "", false
+	}
+
+	domain := normalizeEntry(searchString)
+
+	// the shortest parent domain which has a parent (or itself) in the trie is the entry
+	for idx := len(domain); idx > 0; {
+		idx = strings.LastIndexByte(domain[:idx], '.')
+		parent := domain[idx+1:]
+
+		if cache.trie.HasParentOf(parent) {
+			return trie.WildcardPrefix + parent, true
+		}
+
+		if idx < 0 {
+			break
+		}
+	}
+
+	return "", false
+}
+
+type wildcardCacheFactory struct {
+	trie *trie.Trie
+}
+
+func (r *wildcardCacheFactory) addEntry(entry string) {
+	if trie.IsWildcard(entry) {
+		r.trie.Insert(normalizeEntry(strings.TrimPrefix(entry, trie.WildcardPrefix)))
+	}
+}
+
+func (r *wildcardCacheFactory) count() int {
+	return r.trie.Count()
+}
+
+func (r *wildcardCacheFactory) create() stringCache {
+	return wildcardCache{r.trie}
+}
+
+func newWildcardCacheFactory() cacheFactory {
+	return &wildcardCacheFactory{
+		trie: trie.NewTrie(trie.SplitTLD),
+	}
+}
+
+func isCIDR(s string) bool {
+	if !strings.ContainsRune(s, '/') {
+		return false
+	}
+
+	_, err := netip.ParsePrefix(s)
+
+	return err == nil
+}
+
+// prefixNode is a node of a binary tree over the bits of an IP address
+type prefixNode struct {
+	children [2]*prefixNode
+	// terminal marks the end of a prefix: all addresses below this node match
+	terminal bool
+}
+
+// cidrCache contains IP networks and matches the IPs contained in one of them
+type cidrCache struct {
+	v4, v6 *prefixNode
+	cnt    int
+}
+
+func (cache *cidrCache) elementCount() int {
+	return cache.cnt
+}
+
+// contains checks if searchString is an IP address in one of the networks
+func (cache *cidrCache) contains(searchString string) bool {
+	_, found := cache.explain(searchString)
+
+	return found
+}
+
+// explain returns the network which contains the IP address searchString
+func (cache *cidrCache) explain(searchString string) (string, bool) {
+	addr, err := netip.ParseAddr(searchString)
+	if err != nil {
+		return "", false
+	}
+
+	addr = addr.Unmap()
+
+	node := cache.root(addr)
+	bytes := addr.AsSlice()
+
+	for i := 0; node != nil; i++ {
+		if node.terminal {
+			return netip.PrefixFrom(addr, i).Masked().String(), true
+		}
+
+		if i == addr.BitLen() {
+			return "", false
+		}
+
+		node = node.children[bit(bytes, i)]
+	}
+
+	return "", false
+}
+
+func (cache *cidrCache) root(addr netip.Addr) *prefixNode {
+	if addr.Is4() {
+		return cache.v4
+	}
+
+	return cache.v6
+}
+
+// insert adds the network, it is skipped if it is contained in an already inserted one
+func (cache *cidrCache) insert(prefix netip.Prefix) {
+	prefix = prefix.Masked()
+
+	node := cache.root(prefix.Addr())
+	bytes := prefix.Addr().AsSlice()
+
+	for i := 0; i < prefix.Bits(); i++ {
+		if node.terminal {
+			// already contained in a shorter prefix
+			return
+		}
+
+		b := bit(bytes, i)
+
+		if node.children[b] == nil {
+			node.children[b] = &prefixNode{}
+		}
+
+		node = node.children[b]
+	}
+
+	if !node.terminal {
+		node.terminal = true
+		cache.cnt++
+	}
+}
+
+func bit(bytes []byte, i int) byte {
+	const bitsPerByte = 8
+
+	return (bytes[i/bitsPerByte] >> (bitsPerByte - 1 - i%bitsPerByte)) & 1
+}
+
+type cidrCacheFactory struct {
+	cache *cidrCache
+}
+
+func (r *cidrCacheFactory) addEntry(entry string) {
+	if !isCIDR(entry) {
+		return
+	}
+
+	prefix, _ := netip.ParsePrefix(entry)
+
+	r.cache.insert(prefix)
+}
+
+func (r *cidrCacheFactory) count() int {
+	return r.cache.cnt
+}
+
+func (r *cidrCacheFactory) create() stringCache {
+	return r.cache
+}
+
+func newCIDRCacheFactory() cacheFactory {
+	return &cidrCacheFactory{
+		cache: &cidrCache{v4: &prefixNode{}, v6: &prefixNode{}},
+	}
+}
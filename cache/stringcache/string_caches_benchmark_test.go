@@ -1,8 +1,14 @@
 package stringcache
 
 import (
+	"fmt"
 	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/0xERR0R/blocky/trie"
 )
 
 func BenchmarkStringCache(b *testing.B) {
@@ -21,6 +27,175 @@ func BenchmarkStringCache(b *testing.B) {
 	}
 }
 
+// wildcard benchmarks compare the trie with the previous map of parent domains,
+// the list size is similar to large public wildcard lists
+const wildcardBenchmarkEntries = 1_000_000
+
+var wildcardBenchmarkData = sync.OnceValues(func() (entries, queries []string) {
+	rnd := rand.New(rand.NewSource(1))
+
+	entries = make([]string, wildcardBenchmarkEntries)
+	for i := range entries {
+		entries[i] = trie.WildcardPrefix + randDomain(rnd, 1+rnd.Intn(3))
+	}
+
+	// realistic mix: most queries miss, some hit subdomains of entries, some the wildcard parent itself
+	queries = make([]string, 10_000)
+	for i := range queries {
+		switch entry := strings.TrimPrefix(entries[rnd.Intn(len(entries))], trie.WildcardPrefix); i % 10 {
+		case 0, 1:
+			queries[i] = "www." + entry
+		case 2:
+			queries[i] = entry
+		default:
+			queries[i] = randLabel(rnd) + "." + randDomain(rnd, 1+rnd.Intn(2))
+		}
+	}
+
+	return entries, queries
+})
+
+func BenchmarkWildcardCache(b *testing.B) {
+	entries, queries := wildcardBenchmarkData()
+
+	factories := map[string]func() cacheFactory{
+		"map":  newMapWildcardCacheFactory,
+		"trie": newWildcardCacheFactory,
+	}
+
+	for _, name := range []string{"map", "trie"} {
+		newFactory := factories[name]
+
+		b.Run(name+"/build", func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				factory := newFactory()
+
+				for _, entry := range entries {
+					factory.addEntry(entry)
+				}
+
+				factory.create()
+			}
+		})
+
+		b.Run(name+"/memory", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				factory := newFactory()
+
+				for _, entry := range entries {
+					// like parsed list lines, each entry has its own memory which is retained by the cache
+					factory.addEntry(strings.Clone(entry))
+				}
+
+				cache := factory.create()
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(cache)
+
+				b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/(1<<20), "MiB")
+			}
+		})
+
+		b.Run(name+"/contains", func(b *testing.B) {
+			factory := newFactory()
+
+			for _, entry := range entries {
+				factory.addEntry(entry)
+			}
+
+			cache := factory.create()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				cache.contains(queries[i%len(queries)])
+			}
+		})
+	}
+}
+
+// mapWildcardCache is the previous wildcard cache implementation, only kept as benchmark baseline
+type mapWildcardCache map[string]struct{}
+
+func (cache mapWildcardCache) elementCount() int {
+	return len(cache)
+}
+
+func (cache mapWildcardCache) contains(searchString string) bool {
+	domain := normalizeEntry(searchString)
+
+	for {
+		idx := strings.IndexByte(domain, '.')
+		if idx < 0 {
+			return false
+		}
+
+		domain = domain[idx+1:]
+
+		if _, found := cache[domain]; found {
+			return true
+		}
+	}
+}
+
+func (cache mapWildcardCache) explain(string) (string, bool) {
+	return "", false
+}
+
+type mapWildcardCacheFactory struct {
+	cache mapWildcardCache
+}
+
+func newMapWildcardCacheFactory() cacheFactory {
+	return &mapWildcardCacheFactory{cache: make(mapWildcardCache)}
+}
+
+func (r *mapWildcardCacheFactory) addEntry(entry string) {
+	if trie.IsWildcard(entry) {
+		r.cache[normalizeEntry(strings.TrimPrefix(entry, trie.WildcardPrefix))] = struct{}{}
+	}
+}
+
+func (r *mapWildcardCacheFactory) count() int {
+	return len(r.cache)
+}
+
+func (r *mapWildcardCacheFactory) create() stringCache {
+	return r.cache
+}
+
+func randLabel(rnd *rand.Rand) string {
+	const charPool = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	b := make([]byte, 3+rnd.Intn(10))
+
+	for i := range b {
+		b[i] = charPool[rnd.Intn(len(charPool))]
+	}
+
+	return string(b)
+}
+
+func randDomain(rnd *rand.Rand, labels int) string {
+	tlds := []string{"com", "net", "org", "de", "io", "info", "co.uk"}
+
+	domain := tlds[rnd.Intn(len(tlds))]
+	for i := 0; i < labels; i++ {
+		domain = fmt.Sprintf("%s.%s", randLabel(rnd), domain)
+	}
+
+	return domain
+}
+
 func randString(n int) string {
 	const charPool = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-."
 
@@ -14,6 +14,8 @@ var _ = Describe("Caches", func() {
 			factory.addEntry("")
 			factory.addEntry("google.com")
 			factory.addEntry("APPLe.com")
+			// CIDRs are handled by the CIDR cache
+			factory.addEntry("10.0.0.0/8")
 
 			cache := factory.create()
 
@@ -32,6 +34,10 @@ var _ = Describe("Caches", func() {
 			It("should return correct element count", func() {
 				Expect(cache.elementCount()).Should(Equal(2))
 			})
+			It("should explain the matching entry", func() {
+				Expect(explain(cache, "APPLE.com")).Should(Equal("apple.com"))
+				Expect(explain(cache, "www.google.com")).Should(BeEmpty())
+			})
 		})
 	})
 
@@ -59,10 +65,88 @@ var _ = Describe("Caches", func() {
 				Expect(cache.contains("amazon.com")).Should(BeTrue())
 				Expect(cache.contains("myamazon.com")).Should(BeTrue())
 			})
+			It("should explain the matching regex", func() {
+				Expect(explain(cache, "apple.de")).Should(Equal("/^apple\\.(de|com)$/"))
+				Expect(explain(cache, "apple.it")).Should(BeEmpty())
+			})
 			It("should return correct element count", func() {
 				Expect(factory.count()).Should(Equal(3))
 				Expect(cache.elementCount()).Should(Equal(3))
 			})
 		})
 	})
+	Describe("Wildcard StringCache", func() {
+		When("wildcard StringCache was created", func() {
+			factory := newWildcardCacheFactory()
+			factory.addEntry("*.example.com")
+			factory.addEntry("*.Example.org")
+			// not a wildcard, will be ignored
+			factory.addEntry("plaintext.com")
+			factory.addEntry("/regex/")
+			cache := factory.create()
+			It("should match subdomains of wildcard entries", func() {
+				Expect(cache.contains("www.example.com")).Should(BeTrue())
+				Expect(cache.contains("a.b.example.com")).Should(BeTrue())
+				Expect(cache.contains("WWW.example.org")).Should(BeTrue())
+				Expect(cache.contains("example.com")).Should(BeFalse())
+				Expect(cache.contains("myexample.com")).Should(BeFalse())
+				Expect(cache.contains("www.plaintext.com")).Should(BeFalse())
+			})
+			It("should explain the matching wildcard entry", func() {
+				Expect(explain(cache, "a.b.Example.com")).Should(Equal("*.example.com"))
+				Expect(explain(cache, "www.example.org")).Should(Equal("*.example.org"))
+				Expect(explain(cache, "example.com")).Should(BeEmpty())
+			})
+			It("should return correct element count", func() {
+				Expect(factory.count()).Should(Equal(2))
+				Expect(cache.elementCount()).Should(Equal(2))
+			})
+		})
+	})
+	Describe("CIDR StringCache", func() {
+		When("CIDR StringCache was created", func() {
+			factory := newCIDRCacheFactory()
+			factory.addEntry("10.0.0.0/8")
+			factory.addEntry("192.168.178.17/24")
+			factory.addEntry("203.0.113.5/32")
+			factory.addEntry("2001:db8::/32")
+			// contained in 10.0.0.0/8, will be skipped
+			factory.addEntry("10.1.0.0/16")
+			// not a CIDR, will be ignored
+			factory.addEntry("10.0.0.1")
+			factory.addEntry("example.com")
+			factory.addEntry("/regex/")
+			cache := factory.create()
+			It("should match IPs in the networks", func() {
+				Expect(cache.contains("10.0.0.1")).Should(BeTrue())
+				Expect(cache.contains("10.255.255.255")).Should(BeTrue())
+				Expect(cache.contains("192.168.178.1")).Should(BeTrue())
+				Expect(cache.contains("203.0.113.5")).Should(BeTrue())
+				Expect(cache.contains("2001:db8::1")).Should(BeTrue())
+				Expect(cache.contains("::ffff:10.0.0.1")).Should(BeTrue())
+				Expect(cache.contains("11.0.0.1")).Should(BeFalse())
+				Expect(cache.contains("192.168.179.1")).Should(BeFalse())
+				Expect(cache.contains("203.0.113.6")).Should(BeFalse())
+				Expect(cache.contains("2001:db9::1")).Should(BeFalse())
+				Expect(cache.contains("example.com")).Should(BeFalse())
+			})
+			It("should explain the matching network", func() {
+				Expect(explain(cache, "10.1.2.3")).Should(Equal("10.0.0.0/8"))
+				Expect(explain(cache, "192.168.178.1")).Should(Equal("192.168.178.0/24"))
+				Expect(explain(cache, "203.0.113.5")).Should(Equal("203.0.113.5/32"))
+				Expect(explain(cache, "2001:db8::1")).Should(Equal("2001:db8::/32"))
+				Expect(explain(cache, "11.0.0.1")).Should(BeEmpty())
+			})
+			It("should return correct element count", func() {
+				Expect(factory.count()).Should(Equal(4))
+				Expect(cache.elementCount()).Should(Equal(4))
+			})
+		})
+	})
 })
+
+func explain(cache stringCache, searchString string) string {
+	entry, _ := cache.explain(searchString)
+
+	return entry
+}
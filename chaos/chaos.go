@@ -0,0 +1,151 @@
+//go:build chaos
+
+// Package chaos injects faults for development and integration tests.
+//
+// Fault injection is only compiled into binaries built with the `chaos` build tag
+// and is only active if the environment variable BLOCKY_CHAOS_CONFIG points to a chaos configuration file.
+// Release builds contain the no-op implementation from chaos_disabled.go.
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// configEnvVar points to the chaos configuration file
+	configEnvVar = "BLOCKY_CHAOS_CONFIG"
+
+	loggerPrefix = "chaos"
+)
+
+// Config defines the faults to inject
+type Config struct {
+	// random latency in [min, max] added to each upstream query
+	UpstreamLatency struct {
+		Min config.Duration `yaml:"min"`
+		Max config.Duration `yaml:"max"`
+	} `yaml:"upstreamLatency"`
+	// percentage of UDP upstream responses which are dropped
+	UDPPacketLossPercent int `yaml:"udpPacketLossPercent"`
+	// upstreams (host or full upstream definition) which always return SERVFAIL
+	ServFailUpstreams []string `yaml:"servFailUpstreams"`
+	// percentage of cache hits which return a corrupted response
+	CacheCorruptionPercent int `yaml:"cacheCorruptionPercent"`
+	// delay before each list download
+	ListDownloadDelay config.Duration `yaml:"listDownloadDelay"`
+}
+
+//nolint:gochecknoglobals
+var cfg *Config
+
+//nolint:gochecknoinits
+func init() {
+	path, ok := os.LookupEnv(configEnvVar)
+	if !ok {
+		return
+	}
+
+	c, err := loadConfig(path)
+	if err != nil {
+		log.PrefixedLog(loggerPrefix).Fatalf("can't load chaos configuration: %v", err)
+	}
+
+	Configure(c)
+}
+
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("wrong file structure: %w", err)
+	}
+
+	return &c, nil
+}
+
+// Configure activates the faults defined in c, nil disables fault injection
+func Configure(c *Config) {
+	cfg = c
+
+	if c != nil {
+		log.PrefixedLog(loggerPrefix).Warnf("FAULT INJECTION IS ACTIVE, DO NOT USE IN PRODUCTION: %+v", *c)
+	}
+}
+
+// Enabled returns true if fault injection is active
+func Enabled() bool {
+	return cfg != nil
+}
+
+// UpstreamLatency returns the latency to add to an upstream query
+func UpstreamLatency() time.Duration {
+	if cfg == nil || !cfg.UpstreamLatency.Max.IsAboveZero() {
+		return 0
+	}
+
+	minLatency := cfg.UpstreamLatency.Min.ToDuration()
+	maxLatency := cfg.UpstreamLatency.Max.ToDuration()
+
+	if maxLatency <= minLatency {
+		return minLatency
+	}
+
+	return minLatency + time.Duration(rand.Int63n(int64(maxLatency-minLatency))) //nolint:gosec
+}
+
+// DropUDPResponse returns true if an UDP upstream response should be dropped
+func DropUDPResponse() bool {
+	return cfg != nil && hit(cfg.UDPPacketLossPercent)
+}
+
+// ForceServFail returns true if the upstream should answer with SERVFAIL
+func ForceServFail(upstream config.Upstream) bool {
+	if cfg == nil {
+		return false
+	}
+
+	for _, u := range cfg.ServFailUpstreams {
+		if u == upstream.Host || u == upstream.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CorruptCacheEntry corrupts the response from cache with the configured probability
+func CorruptCacheEntry(msg *dns.Msg) {
+	if cfg == nil || !hit(cfg.CacheCorruptionPercent) {
+		return
+	}
+
+	// the answer gets lost and the response is a server failure
+	msg.Answer = nil
+	msg.Rcode = dns.RcodeServerFailure
+}
+
+// ListDownloadDelay returns the delay before a list download
+func ListDownloadDelay() time.Duration {
+	if cfg == nil {
+		return 0
+	}
+
+	return cfg.ListDownloadDelay.ToDuration()
+}
+
+func hit(percent int) bool {
+	return percent > 0 && rand.Intn(100) < percent //nolint:gomnd,gosec
+}
@@ -0,0 +1,38 @@
+//go:build !chaos
+
+package chaos
+
+import (
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/miekg/dns"
+)
+
+// Enabled returns true if fault injection is active, always false without the `chaos` build tag
+func Enabled() bool {
+	return false
+}
+
+// UpstreamLatency returns the latency to add to an upstream query
+func UpstreamLatency() time.Duration {
+	return 0
+}
+
+// DropUDPResponse returns true if an UDP upstream response should be dropped
+func DropUDPResponse() bool {
+	return false
+}
+
+// ForceServFail returns true if the upstream should answer with SERVFAIL
+func ForceServFail(config.Upstream) bool {
+	return false
+}
+
+// CorruptCacheEntry corrupts the response from cache with the configured probability
+func CorruptCacheEntry(*dns.Msg) {}
+
+// ListDownloadDelay returns the delay before a list download
+func ListDownloadDelay() time.Duration {
+	return 0
+}
@@ -0,0 +1,30 @@
+//go:build !chaos
+
+package chaos
+
+import (
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chaos without build tag", func() {
+	It("should never inject faults", func() {
+		Expect(Enabled()).Should(BeFalse())
+		Expect(UpstreamLatency()).Should(BeZero())
+		Expect(DropUDPResponse()).Should(BeFalse())
+		Expect(ForceServFail(config.Upstream{Host: "1.1.1.1"})).Should(BeFalse())
+		Expect(ListDownloadDelay()).Should(BeZero())
+
+		msg := new(dns.Msg)
+		msg.Answer = []dns.RR{&dns.A{A: net.ParseIP("1.2.3.4")}}
+
+		CorruptCacheEntry(msg)
+
+		Expect(msg.Answer).Should(HaveLen(1))
+		Expect(msg.Rcode).Should(Equal(dns.RcodeSuccess))
+	})
+})
@@ -0,0 +1,119 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chaos", func() {
+	AfterEach(func() {
+		Configure(nil)
+	})
+
+	When("not configured", func() {
+		It("should not inject faults", func() {
+			Expect(Enabled()).Should(BeFalse())
+			Expect(UpstreamLatency()).Should(BeZero())
+			Expect(DropUDPResponse()).Should(BeFalse())
+			Expect(ForceServFail(config.Upstream{Host: "1.1.1.1"})).Should(BeFalse())
+			Expect(ListDownloadDelay()).Should(BeZero())
+		})
+	})
+
+	When("configured", func() {
+		BeforeEach(func() {
+			c := &Config{
+				UDPPacketLossPercent:   100,
+				ServFailUpstreams:      []string{"1.1.1.1"},
+				CacheCorruptionPercent: 100,
+				ListDownloadDelay:      config.Duration(time.Second),
+			}
+			c.UpstreamLatency.Min = config.Duration(10 * time.Millisecond)
+			c.UpstreamLatency.Max = config.Duration(20 * time.Millisecond)
+
+			Configure(c)
+		})
+
+		It("should inject faults", func() {
+			Expect(Enabled()).Should(BeTrue())
+			Expect(UpstreamLatency()).Should(And(
+				BeNumerically(">=", 10*time.Millisecond),
+				BeNumerically("<", 20*time.Millisecond),
+			))
+			Expect(DropUDPResponse()).Should(BeTrue())
+			Expect(ForceServFail(config.Upstream{Host: "1.1.1.1", Port: 53, Net: config.NetProtocolTcpUdp})).Should(BeTrue())
+			Expect(ForceServFail(config.Upstream{Host: "9.9.9.9"})).Should(BeFalse())
+			Expect(ListDownloadDelay()).Should(Equal(time.Second))
+		})
+
+		It("should be safe for concurrent use", func() {
+			var wg sync.WaitGroup
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					for j := 0; j < 100; j++ {
+						Expect(UpstreamLatency()).Should(BeNumerically("<", 20*time.Millisecond))
+						Expect(DropUDPResponse()).Should(BeTrue())
+					}
+				}()
+			}
+
+			wg.Wait()
+		})
+
+		It("should corrupt cache entries", func() {
+			msg := new(dns.Msg)
+			msg.Answer = []dns.RR{&dns.A{A: net.ParseIP("1.2.3.4")}}
+
+			CorruptCacheEntry(msg)
+
+			Expect(msg.Answer).Should(BeEmpty())
+			Expect(msg.Rcode).Should(Equal(dns.RcodeServerFailure))
+		})
+	})
+
+	Describe("loadConfig", func() {
+		It("should load the configuration file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "chaos.yml")
+			Expect(os.WriteFile(path, []byte(`
+upstreamLatency:
+  min: 10ms
+  max: 1s
+udpPacketLossPercent: 5
+servFailUpstreams:
+  - 1.1.1.1
+listDownloadDelay: 5s
+`), 0o600)).Should(Succeed())
+
+			c, err := loadConfig(path)
+			Expect(err).Should(Succeed())
+			Expect(c.UpstreamLatency.Max).Should(Equal(config.Duration(time.Second)))
+			Expect(c.UDPPacketLossPercent).Should(Equal(5))
+			Expect(c.ServFailUpstreams).Should(ConsistOf("1.1.1.1"))
+			Expect(c.ListDownloadDelay).Should(Equal(config.Duration(5 * time.Second)))
+		})
+
+		It("should fail on unknown fields", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "chaos.yml")
+			Expect(os.WriteFile(path, []byte("unknown: 1\n"), 0o600)).Should(Succeed())
+
+			_, err := loadConfig(path)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,11 @@
+package chaos
+
+// ErrDroppedPacket simulates a timeout of an UDP exchange caused by packet loss
+var ErrDroppedPacket error = timeoutError{} //nolint:gochecknoglobals
+
+// timeoutError implements `net.Error`, so the upstream resolver handles it like a real timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "chaos: dropped UDP packet (i/o timeout)" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
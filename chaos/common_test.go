@@ -0,0 +1,18 @@
+package chaos
+
+import (
+	"errors"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ErrDroppedPacket", func() {
+	It("should be handled as network timeout", func() {
+		var netErr net.Error
+
+		Expect(errors.As(ErrDroppedPacket, &netErr)).Should(BeTrue())
+		Expect(netErr.Timeout()).Should(BeTrue())
+	})
+})
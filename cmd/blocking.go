@@ -17,13 +17,15 @@ func newBlockingCommand() *cobra.Command {
 		Aliases: []string{"block"},
 		Short:   "Control status of blocking resolver",
 	}
-	c.AddCommand(&cobra.Command{
+	enableCommand := &cobra.Command{
 		Use:     "enable",
 		Args:    cobra.NoArgs,
 		Aliases: []string{"on"},
 		Short:   "Enable blocking",
 		RunE:    enableBlocking,
-	})
+	}
+	enableCommand.Flags().StringP("client", "c", "", "client (IP or name) to enable blocking for again")
+	c.AddCommand(enableCommand)
 
 	disableCommand := &cobra.Command{
 		Use:     "disable",
@@ -34,6 +36,7 @@ func newBlockingCommand() *cobra.Command {
 	}
 	disableCommand.Flags().DurationP("duration", "d", 0, "duration in min")
 	disableCommand.Flags().StringArrayP("groups", "g", []string{}, "blocking groups to disable")
+	disableCommand.Flags().StringP("client", "c", "", "client (IP or name) to disable blocking for")
 	c.AddCommand(disableCommand)
 
 	c.AddCommand(&cobra.Command{
@@ -46,13 +49,17 @@ func newBlockingCommand() *cobra.Command {
 	return c
 }
 
-func enableBlocking(_ *cobra.Command, _ []string) error {
+func enableBlocking(cmd *cobra.Command, _ []string) error {
+	blockingClient, _ := cmd.Flags().GetString("client")
+
 	client, err := api.NewClientWithResponses(apiURL())
 	if err != nil {
 		return fmt.Errorf("can't create client: %w", err)
 	}
 
-	resp, err := client.EnableBlockingWithResponse(context.Background())
+	resp, err := client.EnableBlockingWithResponse(context.Background(), &api.EnableBlockingParams{
+		Client: &blockingClient,
+	})
 	if err != nil {
 		return fmt.Errorf("can't execute %w", err)
 	}
@@ -69,6 +76,7 @@ func enableBlocking(_ *cobra.Command, _ []string) error {
 func disableBlocking(cmd *cobra.Command, _ []string) error {
 	duration, _ := cmd.Flags().GetDuration("duration")
 	groups, _ := cmd.Flags().GetStringArray("groups")
+	blockingClient, _ := cmd.Flags().GetString("client")
 
 	durationString := duration.String()
 	groupsString := strings.Join(groups, ",")
@@ -81,6 +89,7 @@ func disableBlocking(cmd *cobra.Command, _ []string) error {
 	resp, err := client.DisableBlockingWithResponse(context.Background(), &api.DisableBlockingParams{
 		Duration: &durationString,
 		Groups:   &groupsString,
+		Client:   &blockingClient,
 	})
 	if err != nil {
 		return fmt.Errorf("can't execute %w", err)
@@ -129,5 +138,18 @@ func statusBlocking(_ *cobra.Command, _ []string) error {
 		}
 	}
 
+	if resp.JSON200.DisabledClients != nil {
+		for _, c := range *resp.JSON200.DisabledClients {
+			groupNames := strings.Join(c.DisabledGroups, "; ")
+
+			if c.AutoEnableInSec == nil || *c.AutoEnableInSec == 0 {
+				log.Log().Infof("blocking disabled for client '%s' for groups: %s", c.Client, groupNames)
+			} else {
+				log.Log().Infof("blocking disabled for client '%s' for groups: '%s', for %d seconds",
+					c.Client, groupNames, *c.AutoEnableInSec)
+			}
+		}
+	}
+
 	return nil
 }
@@ -141,6 +141,29 @@ var _ = Describe("Blocking command", func() {
 				Expect(loggerHook.LastEntry().Message).Should(Equal("blocking disabled for groups: abc"))
 			})
 		})
+		When("status blocking is called via REST and blocking is disabled for a client", func() {
+			BeforeEach(func() {
+				mockFn = func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Add("Content-Type", "application/json")
+					autoEnable := 900
+					response, err := json.Marshal(api.ApiBlockingStatus{
+						Enabled: true,
+						DisabledClients: &[]api.ApiDisabledClient{
+							{Client: "laptop", DisabledGroups: []string{"ads", "kids"}, AutoEnableInSec: &autoEnable},
+						},
+					})
+					Expect(err).Should(Succeed())
+
+					_, err = w.Write(response)
+					Expect(err).Should(Succeed())
+				}
+			})
+			It("should show the disabled clients", func() {
+				Expect(statusBlocking(newBlockingCommand(), []string{})).Should(Succeed())
+				Expect(loggerHook.LastEntry().Message).
+					Should(Equal("blocking disabled for client 'laptop' for groups: 'ads; kids', for 900 seconds"))
+			})
+		})
 		When("Wrong url is used", func() {
 			It("Should end with error", func() {
 				apiPort = 0
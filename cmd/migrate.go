@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	migrateFromPihole      = "pihole"
+	migrateFromAdGuardHome = "adguardhome"
+
+	defaultMigrateOutput = "./blocky-migrated.yml"
+
+	// migratedGroupName is the blocking group all imported lists are put in
+	migratedGroupName = "migrated"
+)
+
+// NewMigrateCommand creates new command instance
+func NewMigrateCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "migrate",
+		Args:  cobra.NoArgs,
+		Short: "creates a blocky config fragment from a Pi-hole or AdGuard Home installation",
+		Long: `Reads the configuration and data of another DNS blocker and writes an equivalent blocky
+configuration fragment to a new file. The running blocky configuration is never modified.
+
+Supported sources:
+  --from pihole      --path /etc/pihole
+  --from adguardhome --path AdGuardHome.yaml`,
+		RunE: migrate,
+	}
+
+	c.Flags().String("from", "", "software to migrate from (pihole, adguardhome)")
+	c.Flags().String("path", "", "path to the Pi-hole directory or the AdGuard Home config file")
+	c.Flags().StringP("output", "o", defaultMigrateOutput, "path of the config fragment to write")
+
+	_ = c.MarkFlagRequired("from")
+	_ = c.MarkFlagRequired("path")
+
+	return c
+}
+
+func migrate(cmd *cobra.Command, _ []string) error {
+	from, _ := cmd.Flags().GetString("from")
+	path, _ := cmd.Flags().GetString("path")
+	output, _ := cmd.Flags().GetString("output")
+
+	var (
+		res *migrationResult
+		err error
+	)
+
+	switch strings.ToLower(from) {
+	case migrateFromPihole:
+		res, err = migrateFromPiholeDir(path)
+	case migrateFromAdGuardHome:
+		res, err = migrateFromAdGuardHomeFile(path)
+	default:
+		return fmt.Errorf("unknown migration source '%s', supported: %s, %s",
+			from, migrateFromPihole, migrateFromAdGuardHome)
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't read %s data from '%s': %w", from, path, err)
+	}
+
+	data, err := res.render(from, path)
+	if err != nil {
+		return fmt.Errorf("can't create config fragment: %w", err)
+	}
+
+	// never overwrite: the output could be the active blocky config
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gomnd
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("output file '%s' already exists, refusing to overwrite it", output)
+		}
+
+		return fmt.Errorf("can't create output file: %w", err)
+	}
+
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("can't write output file: %w", err)
+	}
+
+	for _, warning := range res.warnings {
+		log.Log().Warn(warning)
+	}
+
+	log.Log().Infof("wrote blocky config fragment to '%s' (%d warnings)", output, len(res.warnings))
+
+	return nil
+}
+
+// migrationResult is the blocky equivalent of a foreign configuration
+type migrationResult struct {
+	upstreams    []string
+	blackLists   []string
+	blackEntries []string
+	whiteLists   []string
+	whiteEntries []string
+	hostIPs      map[string][]string
+	conditional  map[string][]string
+	dnssec       bool
+	warnings     []string
+}
+
+func newMigrationResult() *migrationResult {
+	return &migrationResult{
+		hostIPs:     make(map[string][]string),
+		conditional: make(map[string][]string),
+	}
+}
+
+func (r *migrationResult) warnf(format string, args ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(format, args...))
+}
+
+// addUpstream converts an upstream from a foreign notation and adds it if blocky supports it
+func (r *migrationResult) addUpstream(upstream string) {
+	if upstream, ok := r.convertUpstream(upstream); ok {
+		r.upstreams = appendUnique(r.upstreams, upstream)
+	}
+}
+
+func (r *migrationResult) addConditional(domain, upstream string) {
+	domain = strings.Trim(strings.TrimSpace(domain), ".")
+	if domain == "" {
+		r.warnf("conditional upstream '%s' without domain skipped", upstream)
+
+		return
+	}
+
+	if upstream, ok := r.convertUpstream(upstream); ok {
+		r.conditional[domain] = appendUnique(r.conditional[domain], upstream)
+	}
+}
+
+func (r *migrationResult) addHostIP(host, ip string) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	r.hostIPs[host] = appendUnique(r.hostIPs[host], ip)
+}
+
+func (r *migrationResult) convertUpstream(upstream string) (string, bool) {
+	res := strings.TrimSpace(upstream)
+
+	switch {
+	case strings.HasPrefix(res, "tls://"):
+		res = "tcp-tls:" + strings.TrimPrefix(res, "tls://")
+	case strings.HasPrefix(res, "udp://"), strings.HasPrefix(res, "tcp://"):
+		res = res[len("xxx://"):]
+	case strings.HasPrefix(res, "quic://"), strings.HasPrefix(res, "h3://"), strings.HasPrefix(res, "sdns://"):
+		r.warnf("upstream '%s' uses a protocol blocky doesn't support, skipped", upstream)
+
+		return "", false
+	}
+
+	if _, err := config.ParseUpstream(res); err != nil {
+		r.warnf("upstream '%s' can't be converted, skipped: %s", upstream, err)
+
+		return "", false
+	}
+
+	return res, true
+}
+
+// render creates the YAML config fragment, with the warnings as header comments
+func (r *migrationResult) render(from, path string) ([]byte, error) {
+	var fragment yaml.MapSlice
+
+	if len(r.upstreams) > 0 {
+		fragment = append(fragment, yaml.MapItem{Key: "upstreams", Value: yaml.MapSlice{
+			{Key: "groups", Value: yaml.MapSlice{{Key: "default", Value: r.upstreams}}},
+		}})
+	}
+
+	if len(r.hostIPs) > 0 {
+		fragment = append(fragment, yaml.MapItem{Key: "customDNS", Value: yaml.MapSlice{
+			{Key: "mapping", Value: joinedMapping(r.hostIPs)},
+		}})
+	}
+
+	if len(r.conditional) > 0 {
+		fragment = append(fragment, yaml.MapItem{Key: "conditional", Value: yaml.MapSlice{
+			{Key: "mapping", Value: joinedMapping(r.conditional)},
+		}})
+	}
+
+	if r.dnssec {
+		fragment = append(fragment, yaml.MapItem{Key: "dnssec", Value: yaml.MapSlice{{Key: "enable", Value: true}}})
+	}
+
+	blackLists := listSources(r.blackLists, r.blackEntries)
+	whiteLists := listSources(r.whiteLists, r.whiteEntries)
+
+	if len(blackLists) > 0 || len(whiteLists) > 0 {
+		var blocking yaml.MapSlice
+
+		if len(blackLists) > 0 {
+			blocking = append(blocking, yaml.MapItem{
+				Key: "blackLists", Value: yaml.MapSlice{{Key: migratedGroupName, Value: blackLists}},
+			})
+		}
+
+		if len(whiteLists) > 0 {
+			blocking = append(blocking, yaml.MapItem{
+				Key: "whiteLists", Value: yaml.MapSlice{{Key: migratedGroupName, Value: whiteLists}},
+			})
+		}
+
+		blocking = append(blocking, yaml.MapItem{
+			Key: "clientGroupsBlock", Value: yaml.MapSlice{{Key: "default", Value: []string{migratedGroupName}}},
+		})
+
+		fragment = append(fragment, yaml.MapItem{Key: "blocking", Value: blocking})
+	}
+
+	body, err := yaml.Marshal(fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# blocky config fragment migrated from %s (%s)\n", from, path)
+	sb.WriteString("# Review it before merging it into your blocky configuration.\n")
+
+	if len(r.warnings) > 0 {
+		sb.WriteString("#\n# The following could not be migrated:\n")
+
+		for _, warning := range r.warnings {
+			fmt.Fprintf(&sb, "#   - %s\n", warning)
+		}
+	}
+
+	sb.WriteString("\n")
+	sb.Write(body)
+
+	return []byte(sb.String()), nil
+}
+
+// listSources returns the list URLs/paths followed by an inline list containing `entries`
+func listSources(sources, entries []string) []string {
+	res := append([]string{}, sources...)
+
+	if len(entries) > 0 {
+		// the trailing newline makes sure blocky reads it as an inline list, even with a single entry
+		res = append(res, strings.Join(entries, "\n")+"\n")
+	}
+
+	return res
+}
+
+func joinedMapping(m map[string][]string) yaml.MapSlice {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	res := make(yaml.MapSlice, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, yaml.MapItem{Key: k, Value: strings.Join(m[k], ",")})
+	}
+
+	return res
+}
+
+func appendUnique(list []string, val string) []string {
+	for _, existing := range list {
+		if existing == val {
+			return list
+		}
+	}
+
+	return append(list, val)
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	"github.com/0xERR0R/blocky/trie"
+	"gopkg.in/yaml.v2"
+)
+
+// adGuardHomeConfig contains the parts of `AdGuardHome.yaml` that can be migrated
+type adGuardHomeConfig struct {
+	DNS struct {
+		UpstreamDNS     []string             `yaml:"upstream_dns"`
+		UpstreamDNSFile string               `yaml:"upstream_dns_file"`
+		Rewrites        []adGuardHomeRewrite `yaml:"rewrites"`
+	} `yaml:"dns"`
+	Filtering struct {
+		Rewrites []adGuardHomeRewrite `yaml:"rewrites"`
+	} `yaml:"filtering"`
+	Filters          []adGuardHomeFilter `yaml:"filters"`
+	WhitelistFilters []adGuardHomeFilter `yaml:"whitelist_filters"`
+	UserRules        []string            `yaml:"user_rules"`
+	Clients          struct {
+		Persistent []interface{} `yaml:"persistent"`
+	} `yaml:"clients"`
+}
+
+type adGuardHomeFilter struct {
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"`
+	Name    string `yaml:"name"`
+}
+
+type adGuardHomeRewrite struct {
+	Domain string `yaml:"domain"`
+	Answer string `yaml:"answer"`
+}
+
+// migrateFromAdGuardHomeFile reads an AdGuard Home config file, usually `AdGuardHome.yaml`
+func migrateFromAdGuardHomeFile(path string) (*migrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg adGuardHomeConfig
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	res := newMigrationResult()
+
+	for _, upstream := range cfg.DNS.UpstreamDNS {
+		res.addAdGuardHomeUpstream(upstream)
+	}
+
+	if cfg.DNS.UpstreamDNSFile != "" {
+		res.warnf("upstreams from upstream_dns_file '%s' are not migrated", cfg.DNS.UpstreamDNSFile)
+	}
+
+	res.blackLists = res.adGuardHomeFilterURLs(cfg.Filters)
+	res.whiteLists = res.adGuardHomeFilterURLs(cfg.WhitelistFilters)
+
+	for _, rule := range cfg.UserRules {
+		res.addAdGuardHomeRule(rule)
+	}
+
+	for _, rewrite := range append(cfg.DNS.Rewrites, cfg.Filtering.Rewrites...) {
+		res.addAdGuardHomeRewrite(rewrite)
+	}
+
+	if len(cfg.Clients.Persistent) > 0 {
+		res.warnf("%d persistent client settings are not migrated", len(cfg.Clients.Persistent))
+	}
+
+	return res, nil
+}
+
+// addAdGuardHomeUpstream handles plain upstreams and `[/domain1/domain2/]upstream` conditional ones
+func (r *migrationResult) addAdGuardHomeUpstream(upstream string) {
+	upstream = strings.TrimSpace(upstream)
+
+	if upstream == "" || strings.HasPrefix(upstream, "#") {
+		return
+	}
+
+	if !strings.HasPrefix(upstream, "[/") {
+		r.addUpstream(upstream)
+
+		return
+	}
+
+	domains, target, found := strings.Cut(upstream[1:], "/]")
+	if !found {
+		r.warnf("upstream '%s' can't be converted, skipped", upstream)
+
+		return
+	}
+
+	if target == "#" {
+		r.warnf("upstream '%s' excludes domains from conditional forwarding, skipped", upstream)
+
+		return
+	}
+
+	for _, targetUpstream := range strings.Fields(target) {
+		for _, domain := range strings.Split(strings.Trim(domains, "/"), "/") {
+			r.addConditional(domain, targetUpstream)
+		}
+	}
+}
+
+func (r *migrationResult) adGuardHomeFilterURLs(filters []adGuardHomeFilter) []string {
+	var res []string
+
+	for _, filter := range filters {
+		if !filter.Enabled {
+			r.warnf("disabled filter list '%s' skipped", filter.URL)
+
+			continue
+		}
+
+		res = appendUnique(res, filter.URL)
+	}
+
+	return res
+}
+
+// addAdGuardHomeRule converts a custom filtering rule.
+// Only rules blocking or allowing whole domains can be converted.
+func (r *migrationResult) addAdGuardHomeRule(rule string) {
+	rule = strings.TrimSpace(rule)
+
+	if rule == "" || strings.HasPrefix(rule, "!") || strings.HasPrefix(rule, "#") {
+		return
+	}
+
+	entries := &r.blackEntries
+	entry := rule
+
+	if strings.HasPrefix(entry, "@@") {
+		entries = &r.whiteEntries
+		entry = strings.TrimPrefix(entry, "@@")
+	}
+
+	switch {
+	case strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") && len(entry) > 1:
+		// regex, same syntax in blocky
+		*entries = appendUnique(*entries, entry)
+
+		return
+
+	case strings.HasPrefix(entry, "||") && strings.HasSuffix(entry, "^"):
+		entry = strings.TrimSuffix(strings.TrimPrefix(entry, "||"), "^")
+
+	default:
+		// hosts file syntax: `0.0.0.0 example.com` blocks, other IPs are local DNS records
+		if fields := strings.Fields(entry); len(fields) == 2 { //nolint:gomnd
+			if ip := net.ParseIP(fields[0]); ip != nil {
+				if !ip.IsUnspecified() && !ip.IsLoopback() {
+					r.addHostIP(fields[1], ip.String())
+
+					return
+				}
+
+				entry = fields[1]
+			}
+		}
+	}
+
+	if strings.ContainsAny(entry, "|^$*@ ") {
+		r.warnf("filtering rule '%s' can't be converted, skipped", rule)
+
+		return
+	}
+
+	*entries = appendUnique(*entries, entry)
+}
+
+func (r *migrationResult) addAdGuardHomeRewrite(rewrite adGuardHomeRewrite) {
+	if trie.IsWildcard(rewrite.Domain) {
+		r.warnf("wildcard rewrite '%s' skipped", rewrite.Domain)
+
+		return
+	}
+
+	ip := net.ParseIP(rewrite.Answer)
+	if ip == nil {
+		r.warnf("rewrite of '%s' to '%s' skipped: only IP answers are supported", rewrite.Domain, rewrite.Answer)
+
+		return
+	}
+
+	r.addHostIP(rewrite.Domain, ip.String())
+}
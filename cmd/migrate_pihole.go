@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/0xERR0R/blocky/lists/parsers"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	piholeGravityDB   = "gravity.db"
+	piholeCustomList  = "custom.list"
+	piholeSetupVars   = "setupVars.conf"
+	piholeAdlistsList = "adlists.list"
+	piholeWhitelist   = "whitelist.txt"
+	piholeBlacklist   = "blacklist.txt"
+	piholeRegexList   = "regex.list"
+
+	// Pi-hole domainlist types
+	piholeExactAllow = 0
+	piholeExactDeny  = 1
+	piholeRegexAllow = 2
+	piholeRegexDeny  = 3
+)
+
+var errNoPiholeData = errors.New("no Pi-hole data found (gravity.db, adlists.list, custom.list or setupVars.conf)")
+
+// migrateFromPiholeDir reads the Pi-hole data directory, usually `/etc/pihole`
+func migrateFromPiholeDir(dir string) (*migrationResult, error) {
+	res := newMigrationResult()
+	found := false
+
+	readers := []struct {
+		file string
+		read func(r *migrationResult, path string) error
+	}{
+		{piholeSetupVars, (*migrationResult).readPiholeSetupVars},
+		{piholeGravityDB, (*migrationResult).readPiholeGravity},
+		{piholeCustomList, (*migrationResult).readPiholeCustomList},
+	}
+
+	for _, reader := range readers {
+		path := filepath.Join(dir, reader.file)
+
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		found = true
+
+		if err := reader.read(res, path); err != nil {
+			return nil, fmt.Errorf("%s: %w", reader.file, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, piholeGravityDB)); err != nil {
+		// older Pi-hole versions stored lists in plain text files
+		legacyFound, err := res.readPiholeLegacyLists(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		found = found || legacyFound
+	}
+
+	if !found {
+		return nil, errNoPiholeData
+	}
+
+	return res, nil
+}
+
+func (r *migrationResult) readPiholeSetupVars(path string) error {
+	vars, err := readKeyValueFile(path)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; ; i++ {
+		upstream, ok := vars["PIHOLE_DNS_"+strconv.Itoa(i)]
+		if !ok {
+			break
+		}
+
+		r.addUpstream(piholeUpstream(upstream))
+	}
+
+	switch {
+	case strings.EqualFold(vars["REV_SERVER"], "true"):
+		target := piholeUpstream(vars["REV_SERVER_TARGET"])
+
+		if domain := vars["REV_SERVER_DOMAIN"]; domain != "" {
+			r.addConditional(domain, target)
+		}
+
+		if cidr := vars["REV_SERVER_CIDR"]; cidr != "" {
+			zone, err := reverseZone(cidr)
+			if err != nil {
+				r.warnf("conditional forwarding of reverse lookups for '%s' skipped: %s", cidr, err)
+			} else {
+				r.addConditional(zone, target)
+			}
+		}
+
+	case strings.EqualFold(vars["CONDITIONAL_FORWARDING"], "true"):
+		target := piholeUpstream(vars["CONDITIONAL_FORWARDING_IP"])
+
+		for _, key := range []string{"CONDITIONAL_FORWARDING_DOMAIN", "CONDITIONAL_FORWARDING_REVERSE"} {
+			if domain := vars[key]; domain != "" {
+				r.addConditional(domain, target)
+			}
+		}
+	}
+
+	r.dnssec = strings.EqualFold(vars["DNSSEC"], "true")
+
+	return nil
+}
+
+func (r *migrationResult) readPiholeGravity(path string) error {
+	adlists, err := querySqlite(path, "SELECT address, enabled FROM adlist")
+	if err != nil {
+		return err
+	}
+
+	disabled := 0
+
+	for _, row := range adlists {
+		if len(row) != 2 { //nolint:gomnd
+			continue
+		}
+
+		if enabled, _ := strconv.ParseBool(row[1]); !enabled {
+			disabled++
+
+			continue
+		}
+
+		r.blackLists = appendUnique(r.blackLists, row[0])
+	}
+
+	domains, err := querySqlite(path, "SELECT type, domain, enabled FROM domainlist")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range domains {
+		if len(row) != 3 { //nolint:gomnd
+			continue
+		}
+
+		if enabled, _ := strconv.ParseBool(row[2]); !enabled {
+			disabled++
+
+			continue
+		}
+
+		typ, _ := strconv.Atoi(row[0])
+
+		r.addPiholeDomain(typ, row[1])
+	}
+
+	if disabled > 0 {
+		r.warnf("%d disabled adlists and domains skipped", disabled)
+	}
+
+	groups, err := querySqlite(path, `SELECT COUNT(*) FROM "group" WHERE id != 0`)
+	if err == nil && len(groups) == 1 && groups[0][0] != "0" {
+		r.warnf("Pi-hole groups are not migrated: all lists are assigned to the blocky group '%s' for all clients",
+			migratedGroupName)
+	}
+
+	return nil
+}
+
+func (r *migrationResult) addPiholeDomain(typ int, domain string) {
+	switch typ {
+	case piholeExactAllow:
+		r.whiteEntries = appendUnique(r.whiteEntries, domain)
+	case piholeExactDeny:
+		r.blackEntries = appendUnique(r.blackEntries, domain)
+	case piholeRegexAllow:
+		r.whiteEntries = appendUnique(r.whiteEntries, "/"+domain+"/")
+	case piholeRegexDeny:
+		r.blackEntries = appendUnique(r.blackEntries, "/"+domain+"/")
+	default:
+		r.warnf("domain '%s' has unknown Pi-hole list type %d, skipped", domain, typ)
+	}
+}
+
+func (r *migrationResult) readPiholeLegacyLists(dir string) (bool, error) {
+	found := false
+
+	files := []struct {
+		name string
+		add  func(string)
+	}{
+		{piholeAdlistsList, func(s string) { r.blackLists = appendUnique(r.blackLists, s) }},
+		{piholeWhitelist, func(s string) { r.addPiholeDomain(piholeExactAllow, s) }},
+		{piholeBlacklist, func(s string) { r.addPiholeDomain(piholeExactDeny, s) }},
+		{piholeRegexList, func(s string) { r.addPiholeDomain(piholeRegexDeny, s) }},
+	}
+
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(dir, file.name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return found, err
+		}
+
+		found = true
+
+		err = parsers.ForEach(context.Background(), parsers.Lines(f), func(line string) error {
+			file.add(line)
+
+			return nil
+		})
+
+		f.Close()
+
+		if err != nil {
+			return found, fmt.Errorf("%s: %w", file.name, err)
+		}
+	}
+
+	return found, nil
+}
+
+func (r *migrationResult) readPiholeCustomList(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	p := parsers.AllowErrors(parsers.HostsFile(f), parsers.NoErrorLimit)
+	p.OnErr(func(err error) {
+		r.warnf("local DNS record skipped: %s", err)
+	})
+
+	return parsers.ForEach(context.Background(), p, func(entry *parsers.HostsFileEntry) error {
+		for _, host := range append([]string{entry.Name}, entry.Aliases...) {
+			r.addHostIP(host, entry.IP.String())
+		}
+
+		return nil
+	})
+}
+
+// piholeUpstream converts Pi-hole's `IP#port` notation
+func piholeUpstream(upstream string) string {
+	host, port, found := strings.Cut(strings.TrimSpace(upstream), "#")
+	if !found {
+		return host
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// reverseZone returns the in-addr.arpa zone of an octet aligned IPv4 CIDR
+func reverseZone(cidr string) (string, error) {
+	const bitsPerOctet = 8
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	ip := ipNet.IP.To4()
+
+	if ip == nil || bits != net.IPv4len*bitsPerOctet {
+		return "", errors.New("only IPv4 networks are supported")
+	}
+
+	if ones == 0 || ones%bitsPerOctet != 0 {
+		return "", errors.New("prefix length must be a multiple of 8")
+	}
+
+	labels := make([]string, 0, net.IPv4len)
+	for i := ones/bitsPerOctet - 1; i >= 0; i-- {
+		labels = append(labels, strconv.Itoa(int(ip[i])))
+	}
+
+	return strings.Join(labels, ".") + ".in-addr.arpa", nil
+}
+
+// readKeyValueFile reads a shell style `KEY=value` file
+func readKeyValueFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if key, value, found := strings.Cut(line, "="); found {
+			res[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+
+	return res, scanner.Err()
+}
+
+// querySqlite runs a read only query and returns the columns of all rows as strings.
+// The driver is pure Go: release binaries are built without cgo.
+func querySqlite(dbPath, query string) ([][]string, error) {
+	db, err := gorm.Open(sqlite.Open("file:"+dbPath+"?mode=ro"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't open %s: %w", piholeGravityDB, err)
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	rows, err := db.Raw(query).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var res [][]string
+
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("sqlite query failed: %w", err)
+		}
+
+		row := make([]string, len(columns))
+		for i, value := range values {
+			row[i] = value.String
+		}
+
+		res = append(res, row)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+
+	"github.com/glebarez/sqlite"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Migrate command", func() {
+	var (
+		tmpDir *TmpFolder
+		output string
+	)
+
+	BeforeEach(func() {
+		tmpDir = NewTmpFolder("migrate")
+		Expect(tmpDir.Error).Should(Succeed())
+		DeferCleanup(tmpDir.Clean)
+
+		output = filepath.Join(tmpDir.Path, "out.yml")
+	})
+
+	runMigrate := func(from, path string) error {
+		c := NewMigrateCommand()
+		c.SetArgs([]string{"--from", from, "--path", path, "--output", output})
+
+		return c.Execute()
+	}
+
+	readOutput := func() (string, config.Config) {
+		data, err := os.ReadFile(output)
+		Expect(err).Should(Succeed())
+
+		var cfg config.Config
+		Expect(yaml.UnmarshalStrict(data, &cfg)).Should(Succeed())
+
+		return string(data), cfg
+	}
+
+	Describe("from pihole", func() {
+		var piholeDir *TmpFolder
+
+		BeforeEach(func() {
+			piholeDir = tmpDir.CreateSubFolder("pihole")
+			Expect(piholeDir.Error).Should(Succeed())
+
+			piholeDir.CreateStringFile("setupVars.conf",
+				"PIHOLE_DNS_1=1.1.1.1",
+				"PIHOLE_DNS_2=127.0.0.1#5335",
+				"REV_SERVER=true",
+				"REV_SERVER_CIDR=192.168.178.0/24",
+				"REV_SERVER_TARGET=192.168.178.1",
+				"REV_SERVER_DOMAIN=fritz.box",
+				"DNSSEC=true",
+			)
+			piholeDir.CreateStringFile("custom.list",
+				"192.168.178.10 nas.lan nas",
+				"not-an-ip broken.lan",
+			)
+		})
+
+		When("legacy list files are used", func() {
+			BeforeEach(func() {
+				piholeDir.CreateStringFile("adlists.list", "https://example.com/list.txt")
+				piholeDir.CreateStringFile("whitelist.txt", "allowed.com")
+				piholeDir.CreateStringFile("blacklist.txt", "blocked.com")
+				piholeDir.CreateStringFile("regex.list", "^ads[0-9]+\\.")
+			})
+
+			It("should create a valid config fragment", func() {
+				Expect(runMigrate("pihole", piholeDir.Path)).Should(Succeed())
+
+				text, cfg := readOutput()
+
+				Expect(text).Should(ContainSubstring("local DNS record skipped"))
+
+				Expect(cfg.Upstreams.Groups["default"]).Should(HaveLen(2))
+				Expect(cfg.Upstreams.Groups["default"][1].Port).Should(BeEquivalentTo(5335))
+
+				Expect(cfg.CustomDNS.Mapping.HostIPs).Should(HaveKey("nas.lan"))
+				Expect(cfg.CustomDNS.Mapping.HostIPs).Should(HaveKey("nas"))
+				Expect(cfg.CustomDNS.Mapping.HostIPs).ShouldNot(HaveKey("broken.lan"))
+
+				Expect(cfg.Conditional.Mapping.Upstreams).Should(HaveKey("fritz.box"))
+				Expect(cfg.Conditional.Mapping.Upstreams).Should(HaveKey("178.168.192.in-addr.arpa"))
+
+				Expect(cfg.DNSSEC.Enable).Should(BeTrue())
+
+				black := cfg.Blocking.BlackLists[migratedGroupName]
+				Expect(black).Should(HaveLen(2))
+				Expect(black[0]).Should(Equal(config.BytesSource{
+					Type: config.BytesSourceTypeHttp, From: "https://example.com/list.txt",
+				}))
+				Expect(black[1]).Should(Equal(config.BytesSource{
+					Type: config.BytesSourceTypeText, From: "blocked.com\n/^ads[0-9]+\\./\n",
+				}))
+
+				Expect(cfg.Blocking.WhiteLists[migratedGroupName]).Should(ConsistOf(config.BytesSource{
+					Type: config.BytesSourceTypeText, From: "allowed.com\n",
+				}))
+				Expect(cfg.Blocking.ClientGroupsBlock["default"]).Should(ConsistOf(migratedGroupName))
+			})
+		})
+
+		When("gravity.db is used", func() {
+			BeforeEach(func() {
+				db, err := gorm.Open(sqlite.Open(filepath.Join(piholeDir.Path, "gravity.db")), &gorm.Config{})
+				Expect(err).Should(Succeed())
+
+				sqlDB, err := db.DB()
+				Expect(err).Should(Succeed())
+				DeferCleanup(sqlDB.Close)
+
+				for _, stmt := range []string{
+					`CREATE TABLE adlist (id INTEGER PRIMARY KEY, address TEXT, enabled BOOLEAN)`,
+					`CREATE TABLE domainlist (id INTEGER PRIMARY KEY, type INTEGER, domain TEXT, enabled BOOLEAN)`,
+					`CREATE TABLE "group" (id INTEGER PRIMARY KEY, name TEXT)`,
+					`INSERT INTO adlist (address, enabled) VALUES ('https://example.com/a.txt', 1)`,
+					`INSERT INTO adlist (address, enabled) VALUES ('https://example.com/disabled.txt', 0)`,
+					`INSERT INTO domainlist (type, domain, enabled) VALUES (0, 'allowed.com', 1)`,
+					`INSERT INTO domainlist (type, domain, enabled) VALUES (3, '^tracker', 1)`,
+					`INSERT INTO "group" (id, name) VALUES (0, 'Default')`,
+				} {
+					Expect(db.Exec(stmt).Error).Should(Succeed())
+				}
+			})
+
+			It("should read lists and domains from the database", func() {
+				Expect(runMigrate("pihole", piholeDir.Path)).Should(Succeed())
+
+				text, cfg := readOutput()
+
+				Expect(text).Should(ContainSubstring("1 disabled adlists and domains skipped"))
+				Expect(text).ShouldNot(ContainSubstring("groups are not migrated"))
+
+				Expect(cfg.Blocking.BlackLists[migratedGroupName]).Should(HaveLen(2))
+				Expect(cfg.Blocking.BlackLists[migratedGroupName][1].From).Should(Equal("/^tracker/\n"))
+				Expect(cfg.Blocking.WhiteLists[migratedGroupName][0].From).Should(Equal("allowed.com\n"))
+			})
+		})
+
+		When("the directory contains no Pi-hole data", func() {
+			It("should fail", func() {
+				empty := tmpDir.CreateSubFolder("empty")
+
+				Expect(runMigrate("pihole", empty.Path)).Should(MatchError(ContainSubstring("no Pi-hole data found")))
+			})
+		})
+	})
+
+	Describe("from adguardhome", func() {
+		var cfgFile string
+
+		BeforeEach(func() {
+			cfgFile = tmpDir.CreateStringFile("AdGuardHome.yaml",
+				"dns:",
+				"  upstream_dns:",
+				"    - https://dns10.quad9.net/dns-query",
+				"    - tls://1.1.1.1",
+				"    - quic://dns.adguard.com",
+				"    - '[/lan/home/]192.168.1.1'",
+				"  rewrites:",
+				"    - domain: nas.lan",
+				"      answer: 192.168.1.10",
+				"    - domain: alias.lan",
+				"      answer: nas.lan",
+				"filters:",
+				"  - enabled: true",
+				"    url: https://example.com/filter.txt",
+				"    name: filter",
+				"  - enabled: false",
+				"    url: https://example.com/off.txt",
+				"    name: off",
+				"user_rules:",
+				"  - '! comment'",
+				"  - '||blocked.com^'",
+				"  - '@@||allowed.com^'",
+				"  - '/ads[0-9]+/'",
+				"  - '||complex.com^$client=1.2.3.4'",
+				"  - '192.168.1.20 printer.lan'",
+			).Path
+		})
+
+		It("should create a valid config fragment", func() {
+			Expect(runMigrate("adguardhome", cfgFile)).Should(Succeed())
+
+			text, cfg := readOutput()
+
+			Expect(text).Should(ContainSubstring("quic://dns.adguard.com"))
+			Expect(text).Should(ContainSubstring("alias.lan"))
+			Expect(text).Should(ContainSubstring("complex.com"))
+			Expect(text).Should(ContainSubstring("https://example.com/off.txt"))
+
+			Expect(cfg.Upstreams.Groups["default"]).Should(HaveLen(2))
+			Expect(cfg.Upstreams.Groups["default"][1].Net).Should(Equal(config.NetProtocolTcpTls))
+
+			Expect(cfg.Conditional.Mapping.Upstreams).Should(HaveKey("lan"))
+			Expect(cfg.Conditional.Mapping.Upstreams).Should(HaveKey("home"))
+
+			Expect(cfg.CustomDNS.Mapping.HostIPs).Should(HaveKey("nas.lan"))
+			Expect(cfg.CustomDNS.Mapping.HostIPs).Should(HaveKey("printer.lan"))
+			Expect(cfg.CustomDNS.Mapping.HostIPs).ShouldNot(HaveKey("alias.lan"))
+
+			Expect(cfg.Blocking.BlackLists[migratedGroupName]).Should(ConsistOf(
+				config.BytesSource{Type: config.BytesSourceTypeHttp, From: "https://example.com/filter.txt"},
+				config.BytesSource{Type: config.BytesSourceTypeText, From: "blocked.com\n/ads[0-9]+/\n"},
+			))
+			Expect(cfg.Blocking.WhiteLists[migratedGroupName]).Should(ConsistOf(
+				config.BytesSource{Type: config.BytesSourceTypeText, From: "allowed.com\n"},
+			))
+		})
+
+		When("the output file already exists", func() {
+			It("should not overwrite it", func() {
+				Expect(os.WriteFile(output, []byte("existing"), 0o600)).Should(Succeed())
+
+				Expect(runMigrate("adguardhome", cfgFile)).Should(MatchError(ContainSubstring("already exists")))
+
+				data, err := os.ReadFile(output)
+				Expect(err).Should(Succeed())
+				Expect(string(data)).Should(Equal("existing"))
+			})
+		})
+	})
+
+	When("the source is unknown", func() {
+		It("should fail", func() {
+			Expect(runMigrate("dnsmasq", tmpDir.Path)).Should(MatchError(ContainSubstring("unknown migration source")))
+		})
+	})
+})
@@ -11,6 +11,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// exit codes of the query command for the error codes of the API
+const (
+	exitCodeInvalidQuery        = 2
+	exitCodeUpstreamTimeout     = 3
+	exitCodeUpstreamUnreachable = 4
+)
+
 // NewQueryCommand creates new command instance
 func NewQueryCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -48,6 +55,10 @@ func query(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("can't execute %w", err)
 	}
 
+	if apiErr := firstNonNil(resp.JSON400, resp.JSON502, resp.JSON504); apiErr != nil {
+		return queryError(apiErr)
+	}
+
 	if resp.StatusCode() != http.StatusOK {
 		return fmt.Errorf("response NOK, %s %s", resp.Status(), string(resp.Body))
 	}
@@ -60,3 +71,33 @@ func query(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// queryError returns the API error with an exit code per error code, so scripts can branch on it
+func queryError(apiErr *api.ApiError) error {
+	err := fmt.Errorf("query failed (%s): %s", apiErr.Code, apiErr.Message)
+
+	if apiErr.Details != nil {
+		err = fmt.Errorf("%w, details: %s", err, *apiErr.Details)
+	}
+
+	switch apiErr.Code {
+	case api.INVALIDQUERY:
+		return &exitCodeError{err: err, code: exitCodeInvalidQuery}
+	case api.UPSTREAMTIMEOUT:
+		return &exitCodeError{err: err, code: exitCodeUpstreamTimeout}
+	case api.UPSTREAMUNREACHABLE:
+		return &exitCodeError{err: err, code: exitCodeUpstreamUnreachable}
+	}
+
+	return err
+}
+
+func firstNonNil[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+
+	return nil
+}
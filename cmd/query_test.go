@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 
@@ -82,6 +83,31 @@ var _ = Describe("Blocking command", func() {
 				Expect(err.Error()).Should(ContainSubstring("500 Internal Server Error"))
 			})
 		})
+		When("Server returns an API error", func() {
+			BeforeEach(func() {
+				mockFn = func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Add("Content-Type", "application/json")
+					w.WriteHeader(http.StatusGatewayTimeout)
+
+					response, err := json.Marshal(api.ApiError{
+						Code:    api.UPSTREAMTIMEOUT,
+						Message: "upstream DNS servers didn't answer in time",
+					})
+					Expect(err).Should(Succeed())
+
+					_, err = w.Write(response)
+					Expect(err).Should(Succeed())
+				}
+			})
+			It("should end with the error code", func() {
+				err := query(NewQueryCommand(), []string{"google.de"})
+				Expect(err).Should(MatchError("query failed (UPSTREAM_TIMEOUT): upstream DNS servers didn't answer in time"))
+
+				var exitErr *exitCodeError
+				Expect(errors.As(err, &exitErr)).Should(BeTrue())
+				Expect(exitErr.code).Should(Equal(exitCodeUpstreamTimeout))
+			})
+		})
 		When("Type is wrong", func() {
 			It("should end with error", func() {
 				command := NewQueryCommand()
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -54,7 +55,9 @@ Complete documentation is available at https://github.com/0xERR0R/blocky`,
 		newServeCommand(),
 		newBlockingCommand(),
 		NewListsCommand(),
-		NewHealthcheckCommand())
+		NewHealthcheckCommand(),
+		NewMigrateCommand(),
+		newServiceCommand())
 
 	return c
 }
@@ -106,9 +109,28 @@ func initConfig() {
 	}
 }
 
+// exitCodeError is an error which ends the process with a specific exit code
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
 // Execute starts the command
 func Execute() {
 	if err := NewRootCommand().Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+
 		os.Exit(1)
 	}
 }
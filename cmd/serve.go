@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/evt"
@@ -31,29 +32,47 @@ func newServeCommand() *cobra.Command {
 }
 
 func startServer(_ *cobra.Command, _ []string) error {
+	if isWindowsService() {
+		// the service control manager stops blocky instead of signals
+		return runService(runServer)
+	}
+
+	signals := make(chan os.Signal, 1)
+
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	return runServer(signals)
+}
+
+// runServer runs blocky until a signal is received or the server fails
+func runServer(signals <-chan os.Signal) error {
 	printBanner()
 
+	configStart := time.Now()
+
 	cfg, err := config.LoadConfig(configPath, isConfigMandatory)
 	if err != nil {
 		return fmt.Errorf("unable to load configuration: %w", err)
 	}
 
-	log.ConfigureLogger(&cfg.Log)
-
-	signals := make(chan os.Signal, 1)
+	configDuration := time.Since(configStart)
 
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	log.ConfigureLogger(&cfg.Log)
 
 	srv, err := server.NewServer(cfg)
 	if err != nil {
 		return fmt.Errorf("can't start server: %w", err)
 	}
 
+	srv.AddStartupPhase("config load", configStart, configDuration)
+
 	const errChanSize = 10
 	errChan := make(chan error, errChanSize)
 
 	srv.Start(errChan)
 
+	var srvErr error
+
 	go func() {
 		select {
 		case <-signals:
@@ -62,7 +81,8 @@ func startServer(_ *cobra.Command, _ []string) error {
 			done <- true
 
 		case err := <-errChan:
-			log.Log().Error("server start failed: ", err)
+			log.Log().Error("server failed: ", err)
+			srvErr = err
 			done <- true
 		}
 	}()
@@ -70,7 +90,8 @@ func startServer(_ *cobra.Command, _ []string) error {
 	evt.Bus().Publish(evt.ApplicationStarted, util.Version, util.BuildTime)
 	<-done
 
-	return nil
+	// a non-zero exit code lets a supervisor restart blocky
+	return srvErr
 }
 
 func printBanner() {
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"github.com/0xERR0R/blocky/config"
-
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -10,16 +8,6 @@ import (
 var _ = Describe("Serve command", func() {
 	When("Serve command is called", func() {
 		It("should start DNS server", func() {
-			config.GetConfig().BootstrapDNS = []config.BootstrappedUpstreamConfig{
-				{
-					Upstream: config.Upstream{
-						Net:  config.NetProtocolTcpTls,
-						Host: "1.1.1.1",
-						Port: 53,
-					},
-				},
-			}
-
 			isConfigMandatory = false
 
 			grClosure := make(chan interface{})
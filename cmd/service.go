@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+const serviceName = "blocky"
+
+var errServiceNotSupported = errors.New("blocky can only run as service on Windows")
+
+func newServiceCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "service",
+		Short: "Manage the Windows service of blocky",
+	}
+
+	c.AddCommand(&cobra.Command{
+		Use:   "install",
+		Args:  cobra.NoArgs,
+		Short: "Installs the service, which serves with the current config path and starts automatically",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			// the service runs in the system directory, so a relative path wouldn't be found
+			path, err := filepath.Abs(configPath)
+			if err != nil {
+				return fmt.Errorf("can't resolve config path: %w", err)
+			}
+
+			return installService(path)
+		},
+	}, &cobra.Command{
+		Use:   "uninstall",
+		Args:  cobra.NoArgs,
+		Short: "Uninstalls the service",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return uninstallService()
+		},
+	}, &cobra.Command{
+		Use:   "start",
+		Args:  cobra.NoArgs,
+		Short: "Starts the service",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return startService()
+		},
+	}, &cobra.Command{
+		Use:   "stop",
+		Args:  cobra.NoArgs,
+		Short: "Stops the service, running queries are answered before",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return stopService()
+		},
+	}, &cobra.Command{
+		Use:   "print-config",
+		Args:  cobra.NoArgs,
+		Short: "Lets the running service print its configuration and statistics to the log (like SIGUSR1)",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return triggerPrintConfiguration()
+		},
+	})
+
+	return c
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+)
+
+func isWindowsService() bool {
+	return false
+}
+
+func runService(_ func(signals <-chan os.Signal) error) error {
+	return errServiceNotSupported
+}
+
+func installService(_ string) error {
+	return errServiceNotSupported
+}
+
+func uninstallService() error {
+	return errServiceNotSupported
+}
+
+func startService() error {
+	return errServiceNotSupported
+}
+
+func stopService() error {
+	return errServiceNotSupported
+}
+
+func triggerPrintConfiguration() error {
+	return errServiceNotSupported
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service command", func() {
+	When("a service command is called on other systems than Windows", func() {
+		It("should fail", func() {
+			for _, sub := range []string{"install", "uninstall", "start", "stop", "print-config"} {
+				c := newServiceCommand()
+				c.SetArgs([]string{sub})
+				c.SilenceErrors = true
+				c.SilenceUsage = true
+
+				Expect(c.Execute()).Should(MatchError(errServiceNotSupported), sub)
+			}
+		})
+	})
+
+	It("should not run as service", func() {
+		Expect(isWindowsService()).Should(BeFalse())
+		Expect(runService(runServer)).Should(MatchError(errServiceNotSupported))
+	})
+})
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/server"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	serviceStopTimeout  = 30 * time.Second
+	serviceStatusPeriod = 300 * time.Millisecond
+	// serviceExitCode is reported to the service control manager if the server failed
+	serviceExitCode = 1
+)
+
+func isWindowsService() bool {
+	isService, err := svc.IsWindowsService()
+
+	return err == nil && isService
+}
+
+// blockyService handles the requests of the service control manager
+type blockyService struct {
+	run func(signals <-chan os.Signal) error
+}
+
+// Execute implements `svc.Handler`.
+func (s *blockyService) Execute(_ []string, requests <-chan svc.ChangeRequest,
+	status chan<- svc.Status,
+) (svcSpecificEC bool, exitCode uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	started := make(chan struct{})
+
+	_ = evt.Bus().SubscribeOnce(evt.ApplicationStarted, func(_ ...string) {
+		close(started)
+	})
+
+	signals := make(chan os.Signal, 1)
+	result := make(chan error, 1)
+
+	go func() {
+		result <- s.run(signals)
+	}()
+
+	for {
+		select {
+		case <-started:
+			status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+			started = nil
+
+		case request := <-requests:
+			switch request.Cmd { //nolint:exhaustive
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+
+				// the server stops like on SIGTERM: the DNS servers answer the running queries before
+				signals <- syscall.SIGTERM
+
+			default:
+				log.Log().Warnf("unexpected service control request %d", request.Cmd)
+			}
+
+		case err := <-result:
+			if err != nil {
+				return true, serviceExitCode
+			}
+
+			return false, 0
+		}
+	}
+}
+
+func runService(run func(signals <-chan os.Signal) error) error {
+	return svc.Run(serviceName, &blockyService{run: run})
+}
+
+func installService(configPath string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("can't determine path of blocky: %w", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("can't connect to service manager: %w", err)
+	}
+	defer m.Disconnect() //nolint:errcheck
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+
+		return fmt.Errorf("service %s already exists", serviceName)
+	}
+
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: "blocky",
+		Description: "DNS proxy and ad-blocker",
+		StartType:   mgr.StartAutomatic,
+	}, "serve", "--config", configPath)
+	if err != nil {
+		return fmt.Errorf("can't create service: %w", err)
+	}
+	defer s.Close()
+
+	err = eventlog.InstallAsEventCreate(log.EventLogSource, eventlog.Error|eventlog.Warning|eventlog.Info)
+	if err != nil {
+		_ = s.Delete()
+
+		return fmt.Errorf("can't register event log source: %w", err)
+	}
+
+	log.Log().Infof("service %s installed with config %s", serviceName, configPath)
+
+	return nil
+}
+
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("can't connect to service manager: %w", err)
+	}
+	defer m.Disconnect() //nolint:errcheck
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("can't delete service: %w", err)
+	}
+
+	if err := eventlog.Remove(log.EventLogSource); err != nil {
+		log.Log().Warn("can't remove event log source: ", err)
+	}
+
+	log.Log().Infof("service %s uninstalled", serviceName)
+
+	return nil
+}
+
+func startService() error {
+	return withService(func(s *mgr.Service) error {
+		if err := s.Start(); err != nil {
+			return fmt.Errorf("can't start service: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func stopService() error {
+	return withService(func(s *mgr.Service) error {
+		status, err := s.Control(svc.Stop)
+		if err != nil {
+			return fmt.Errorf("can't stop service: %w", err)
+		}
+
+		timeout := time.Now().Add(serviceStopTimeout)
+
+		for status.State != svc.Stopped {
+			if time.Now().After(timeout) {
+				return fmt.Errorf("service %s didn't stop within %s", serviceName, serviceStopTimeout)
+			}
+
+			time.Sleep(serviceStatusPeriod)
+
+			status, err = s.Query()
+			if err != nil {
+				return fmt.Errorf("can't query service status: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func withService(fn func(s *mgr.Service) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("can't connect to service manager: %w", err)
+	}
+	defer m.Disconnect() //nolint:errcheck
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	return fn(s)
+}
+
+func triggerPrintConfiguration() error {
+	name, err := windows.UTF16PtrFromString(server.PrintConfigurationEvent)
+	if err != nil {
+		return err
+	}
+
+	event, err := windows.OpenEvent(windows.EVENT_MODIFY_STATE, false, name)
+	if errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+		return errors.New("blocky isn't running as service")
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't open event: %w", err)
+	}
+	defer windows.CloseHandle(event) //nolint:errcheck
+
+	return windows.SetEvent(event)
+}
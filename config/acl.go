@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ACLConfig configuration of the clients which may query blocky
+type ACLConfig struct {
+	// AllowedClients are the IPs or CIDRs of the only clients which may query, empty allows all clients
+	AllowedClients []string `yaml:"allowedClients"`
+	// DeniedClients are the IPs or CIDRs of clients which must not query, even if they are allowed
+	DeniedClients []string `yaml:"deniedClients"`
+	// TrustedProxies are the IPs or CIDRs of the reverse proxies whose `ClientIPHeader` is used for DoH queries
+	TrustedProxies []string `yaml:"trustedProxies"`
+	ClientIPHeader string   `yaml:"clientIPHeader" default:"X-Forwarded-For"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ACLConfig) IsEnabled() bool {
+	return len(c.AllowedClients) != 0 || len(c.DeniedClients) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ACLConfig) LogConfig(logger *logrus.Entry) {
+	if len(c.AllowedClients) != 0 {
+		logger.Infof("allowedClients = %s", strings.Join(c.AllowedClients, ", "))
+	} else {
+		logger.Info("allowedClients = all")
+	}
+
+	if len(c.DeniedClients) != 0 {
+		logger.Infof("deniedClients = %s", strings.Join(c.DeniedClients, ", "))
+	}
+
+	if len(c.TrustedProxies) != 0 {
+		logger.Infof("trustedProxies = %s", strings.Join(c.TrustedProxies, ", "))
+		logger.Infof("clientIPHeader = %s", c.ClientIPHeader)
+	} else {
+		logger.Infof("trustedProxies = none, the %s header of DoH queries is ignored", c.ClientIPHeader)
+	}
+}
+
+// AllowedNetworks returns the networks of the allowed clients
+func (c *ACLConfig) AllowedNetworks() ([]*net.IPNet, error) {
+	networks, err := parseClientNetworks(c.AllowedClients)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed client %w", err)
+	}
+
+	return networks, nil
+}
+
+// DeniedNetworks returns the networks of the denied clients
+func (c *ACLConfig) DeniedNetworks() ([]*net.IPNet, error) {
+	networks, err := parseClientNetworks(c.DeniedClients)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denied client %w", err)
+	}
+
+	return networks, nil
+}
+
+// TrustedProxyNetworks returns the networks of the trusted reverse proxies
+func (c *ACLConfig) TrustedProxyNetworks() ([]*net.IPNet, error) {
+	networks, err := parseClientNetworks(c.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy %w", err)
+	}
+
+	return networks, nil
+}
+
+// validate checks that all clients and proxies are IPs or CIDRs
+func (c *ACLConfig) validate() error {
+	for _, parse := range []func() ([]*net.IPNet, error){c.AllowedNetworks, c.DeniedNetworks, c.TrustedProxyNetworks} {
+		if _, err := parse(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
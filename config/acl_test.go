@@ -0,0 +1,72 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ACLConfig", func() {
+	var cfg ACLConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[ACLConfig]()
+		Expect(err).Should(Succeed())
+
+		cfg.AllowedClients = []string{"192.168.178.0/24", "fd00::/64"}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg, err := WithDefaults[ACLConfig]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+			Expect(cfg.ClientIPHeader).Should(Equal("X-Forwarded-For"))
+		})
+
+		It("should be true with allowed or denied clients", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+
+			cfg.AllowedClients = nil
+			cfg.DeniedClients = []string{"192.168.178.66"}
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept IPs and CIDRs", func() {
+			cfg.DeniedClients = []string{"192.168.178.66"}
+			cfg.TrustedProxies = []string{"127.0.0.1"}
+
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should fail for invalid clients", func() {
+			cfg.DeniedClients = []string{"192.168.178.300"}
+
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("invalid denied client '192.168.178.300'")))
+		})
+
+		It("should fail for invalid proxies", func() {
+			cfg.TrustedProxies = []string{"proxy"}
+
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("invalid trusted proxy 'proxy'")))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the clients", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"allowedClients = 192.168.178.0/24, fd00::/64",
+				"trustedProxies = none, the X-Forwarded-For header of DoH queries is ignored",
+			))
+		})
+	})
+})
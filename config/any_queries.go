@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// AnyQueriesConfig configuration for the minimal answers to ANY queries (RFC 8482)
+type AnyQueriesConfig struct {
+	// Enable answers ANY queries with a synthesized HINFO record instead of resolving them
+	Enable bool     `yaml:"enable" default:"true"`
+	TTL    Duration `yaml:"ttl" default:"1h"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *AnyQueriesConfig) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *AnyQueriesConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("ttl = %s", c.TTL)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AnyQueriesConfig", func() {
+	var cfg AnyQueriesConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[AnyQueriesConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be true by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+			Expect(cfg.TTL).Should(Equal(Duration(time.Hour)))
+		})
+
+		When("disabled", func() {
+			It("should be false", func() {
+				cfg.Enable = false
+
+				Expect(cfg.IsEnabled()).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the TTL", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("ttl = 1 hour"))
+		})
+	})
+})
@@ -1,19 +1,37 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+
 	. "github.com/0xERR0R/blocky/config/migration" //nolint:revive,stylecheck
 	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
 )
 
 // BlockingConfig configuration for query blocking
 type BlockingConfig struct {
-	BlackLists        map[string][]BytesSource `yaml:"blackLists"`
-	WhiteLists        map[string][]BytesSource `yaml:"whiteLists"`
-	ClientGroupsBlock map[string][]string      `yaml:"clientGroupsBlock"`
-	BlockType         string                   `yaml:"blockType" default:"ZEROIP"`
-	BlockTTL          Duration                 `yaml:"blockTTL" default:"6h"`
-	Loading           SourceLoadingConfig      `yaml:"loading"`
+	BlackLists        map[string][]BytesSource       `yaml:"blackLists"`
+	WhiteLists        map[string][]BytesSource       `yaml:"whiteLists"`
+	ClientGroupsBlock map[string][]string            `yaml:"clientGroupsBlock"`
+	Groups            map[string]BlockingGroupConfig `yaml:"groups"`
+	BlockType         string                         `yaml:"blockType" default:"ZEROIP"`
+	BlockTTL          Duration                       `yaml:"blockTTL" default:"6h"`
+	Loading           SourceLoadingConfig            `yaml:"loading"`
+	// AuditGroups is a short form of `enforce: false` in Groups
+	AuditGroups []string `yaml:"auditGroups"`
+	// CheckCnames enables blocking of responses with a CNAME target on a blacklist (CNAME cloaking)
+	CheckCnames bool `yaml:"checkCnames" default:"true"`
+	// RuntimeEntriesFile persists the black- and whitelist entries added via API, they are kept in memory only if empty
+	RuntimeEntriesFile string `yaml:"runtimeEntriesFile"`
+	// AnnotateOverrides names the override of a deny match which didn't block the query in the response
+	AnnotateOverrides bool `yaml:"annotateOverrides"`
+	// AnnotateDisabled annotates the reason of queries, which would be blocked by a group with disabled blocking
+	AnnotateDisabled bool `yaml:"annotateDisabled"`
+	// BlockPage serves a page explaining the block to browsers, which are sent to blocky by the block type
+	BlockPage BlockPageConfig `yaml:"blockPage"`
 
 	// Deprecated options
 	Deprecated struct {
@@ -28,6 +46,183 @@ type BlockingConfig struct {
 	} `yaml:",inline"`
 }
 
+// BlockingGroupConfig configures the behavior of a black/whitelist group
+type BlockingGroupConfig struct {
+	// Enforce false only logs and counts the matches of the group without blocking (audit mode)
+	Enforce bool `yaml:"enforce" default:"true"`
+	// BlockType and BlockTTL override the global settings for queries blocked by the group
+	BlockType string    `yaml:"blockType"`
+	BlockTTL  *Duration `yaml:"blockTTL"`
+	// QueryTypes limits the group to queries of these types, it applies to all types if empty
+	QueryTypes QTypeSet `yaml:"queryTypes"`
+	// RefreshPeriod overrides loading.refreshPeriod for the lists of the group
+	RefreshPeriod *Duration `yaml:"refreshPeriod"`
+}
+
+// BlockPageConfig configures the listeners of the block page
+type BlockPageConfig struct {
+	HTTP  ListenConfig `yaml:"http"`
+	HTTPS ListenConfig `yaml:"https"`
+	// Template is a file with a Go HTML template, the built-in page is used if empty
+	Template string `yaml:"template"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *BlockPageConfig) IsEnabled() bool {
+	return len(c.HTTP) > 0 || len(c.HTTPS) > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *BlockPageConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("http = %s", c.HTTP)
+	logger.Infof("https = %s", c.HTTPS)
+
+	if c.Template != "" {
+		logger.Infof("template = %s", c.Template)
+	}
+}
+
+// HasBlockOverride returns true if the group doesn't use the global block type and TTL
+func (c *BlockingGroupConfig) HasBlockOverride() bool {
+	return c.BlockType != "" || c.BlockTTL != nil
+}
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+// Defaults are applied before unmarshalling, since map values are created by the YAML decoder.
+func (c *BlockingGroupConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BlockingGroupConfig
+
+	if err := defaults.Set((*plain)(c)); err != nil {
+		return fmt.Errorf("can't apply blocking group defaults: %w", err)
+	}
+
+	return unmarshal((*plain)(c))
+}
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+// The groups of AuditGroups are added to Groups with `enforce: false`.
+func (c *BlockingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BlockingConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	for _, group := range c.AuditGroups {
+		groupCfg, ok := c.Groups[group]
+		if !ok {
+			if err := defaults.Set(&groupCfg); err != nil {
+				return fmt.Errorf("can't apply blocking group defaults: %w", err)
+			}
+		}
+
+		groupCfg.Enforce = false
+
+		if c.Groups == nil {
+			c.Groups = make(map[string]BlockingGroupConfig, len(c.AuditGroups))
+		}
+
+		c.Groups[group] = groupCfg
+	}
+
+	return nil
+}
+
+// IsEnforced returns false if matches of the group should only be audited, groups are enforced by default
+func (c *BlockingConfig) IsEnforced(group string) bool {
+	groupCfg, ok := c.Groups[group]
+
+	return !ok || groupCfg.Enforce
+}
+
+// AppliesToQueryType returns false if the group is limited to other query types
+func (c *BlockingConfig) AppliesToQueryType(group string, qType dns.Type) bool {
+	groupCfg, ok := c.Groups[group]
+
+	return !ok || len(groupCfg.QueryTypes) == 0 || groupCfg.QueryTypes.Contains(qType)
+}
+
+// GroupBlockType returns the block type and TTL used for queries blocked by the group
+func (c *BlockingConfig) GroupBlockType(group string) (blockType string, blockTTL Duration) {
+	blockType, blockTTL = c.BlockType, c.BlockTTL
+
+	if groupCfg, ok := c.Groups[group]; ok {
+		if groupCfg.BlockType != "" {
+			blockType = groupCfg.BlockType
+		}
+
+		if groupCfg.BlockTTL != nil {
+			blockTTL = *groupCfg.BlockTTL
+		}
+	}
+
+	return blockType, blockTTL
+}
+
+// GroupRefreshPeriod returns the refresh period of the lists of the group
+func (c *BlockingConfig) GroupRefreshPeriod(group string) Duration {
+	if groupCfg, ok := c.Groups[group]; ok && groupCfg.RefreshPeriod != nil {
+		return *groupCfg.RefreshPeriod
+	}
+
+	return c.Loading.RefreshPeriod
+}
+
+// GroupRefreshPeriods returns the refresh period of each black- and whitelist group
+func (c *BlockingConfig) GroupRefreshPeriods() map[string]Duration {
+	periods := make(map[string]Duration, len(c.BlackLists)+len(c.WhiteLists))
+
+	for _, listGroups := range []map[string][]BytesSource{c.BlackLists, c.WhiteLists} {
+		for group := range listGroups {
+			periods[group] = c.GroupRefreshPeriod(group)
+		}
+	}
+
+	return periods
+}
+
+// ValidateGroups returns an error if a group is configured, which has neither a black- nor a whitelist,
+// or if a whitelist source uses the IPs of its entries
+func (c *BlockingConfig) ValidateGroups() error {
+	for group := range c.Groups {
+		_, isBlack := c.BlackLists[group]
+		_, isWhite := c.WhiteLists[group]
+
+		if !isBlack && !isWhite {
+			return fmt.Errorf("unknown group '%s' in groups, it has no black- or whitelist", group)
+		}
+	}
+
+	for group, sources := range c.WhiteLists {
+		for _, source := range sources {
+			if source.UseListIPs {
+				return fmt.Errorf("whitelist group '%s': useListIPs is only supported for blacklists", group)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSources checks the glob and exec sources of the black- and whitelists against the loading options
+func (c *BlockingConfig) validateSources() error {
+	for _, listGroups := range []map[string][]BytesSource{c.BlackLists, c.WhiteLists} {
+		for group, sources := range listGroups {
+			if err := c.Loading.validateSources(sources); err != nil {
+				return fmt.Errorf("group '%s': %w", group, err)
+			}
+
+			for _, source := range sources {
+				if source.TTL != nil {
+					return fmt.Errorf("group '%s': %s: ttl is only supported for hostsFile sources", group, source)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func (c *BlockingConfig) migrate(logger *logrus.Entry) bool {
 	return Migrate(logger, "blocking", c.Deprecated, map[string]Migrator{
 		"downloadTimeout":  Move(To("loading.downloads.timeout", &c.Loading.Downloads)),
@@ -58,6 +253,41 @@ func (c *BlockingConfig) LogConfig(logger *logrus.Entry) {
 		logger.Infof("  %s = %v", key, val)
 	}
 
+	logger.Infof("checkCnames = %t", c.CheckCnames)
+
+	if c.RuntimeEntriesFile != "" {
+		logger.Infof("runtimeEntriesFile = %s", c.RuntimeEntriesFile)
+	}
+
+	if c.AnnotateOverrides {
+		logger.Info("annotateOverrides = true")
+	}
+
+	if c.AnnotateDisabled {
+		logger.Info("annotateDisabled = true")
+	}
+
+	if c.BlockPage.IsEnabled() {
+		logger.Info("blockPage:")
+		log.WithIndent(logger, "  ", c.BlockPage.LogConfig)
+	}
+
+	for group, groupCfg := range c.Groups {
+		if !groupCfg.Enforce {
+			logger.Infof("group %s: audit only, matches are not blocked", group)
+		}
+
+		if groupCfg.HasBlockOverride() {
+			blockType, blockTTL := c.GroupBlockType(group)
+
+			logger.Infof("group %s: blockType = %s, blockTTL = %s", group, blockType, blockTTL)
+		}
+
+		if len(groupCfg.QueryTypes) > 0 {
+			logger.Infof("group %s: queryTypes = %s", group, groupCfg.QueryTypes)
+		}
+	}
+
 	logger.Infof("blockType = %s", c.BlockType)
 
 	if c.BlockType != "NXDOMAIN" {
@@ -80,10 +310,24 @@ func (c *BlockingConfig) LogConfig(logger *logrus.Entry) {
 
 func (c *BlockingConfig) logListGroups(logger *logrus.Entry, listGroups map[string][]BytesSource) {
 	for group, sources := range listGroups {
-		logger.Infof("%s:", group)
+		logger.Infof("%s: refresh = %s", group, describeRefresh(c.GroupRefreshPeriod(group)))
 
 		for _, source := range sources {
-			logger.Infof("   - %s", source)
+			var options []string
+
+			if source.UseListIPs {
+				options = append(options, "useListIPs")
+			}
+
+			if source.RefreshPeriod != nil {
+				options = append(options, "refresh = "+describeRefresh(*source.RefreshPeriod))
+			}
+
+			if len(options) > 0 {
+				logger.Infof("   - %s (%s)", source, strings.Join(options, ", "))
+			} else {
+				logger.Infof("   - %s", source)
+			}
 		}
 	}
 }
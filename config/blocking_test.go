@@ -4,8 +4,10 @@ import (
 	"time"
 
 	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("BlockingConfig", func() {
@@ -32,6 +34,7 @@ var _ = Describe("BlockingConfig", func() {
 			Expect(defaults.Set(&cfg)).Should(Succeed())
 
 			Expect(cfg.IsEnabled()).Should(BeFalse())
+			Expect(cfg.CheckCnames).Should(BeTrue())
 		})
 
 		When("enabled", func() {
@@ -59,5 +62,425 @@ var _ = Describe("BlockingConfig", func() {
 			Expect(hook.Messages[0]).Should(Equal("clientGroupsBlock:"))
 			Expect(hook.Messages).Should(ContainElement(Equal("blockType = ZEROIP")))
 		})
+
+		It("should log the runtime entries file", func() {
+			cfg.RuntimeEntriesFile = "/tmp/entries.json"
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("runtimeEntriesFile = /tmp/entries.json")))
+		})
+
+		It("should log audit groups", func() {
+			cfg.Groups = map[string]BlockingGroupConfig{"gr1": {Enforce: false}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("group gr1: audit only, matches are not blocked")))
+		})
+
+		It("should log block type overrides of groups", func() {
+			ttl := Duration(time.Minute)
+			cfg.Groups = map[string]BlockingGroupConfig{"gr1": {Enforce: true, BlockType: "10.0.0.5", BlockTTL: &ttl}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("group gr1: blockType = 10.0.0.5, blockTTL = 1 minute")))
+		})
+
+		It("should log the query types of groups", func() {
+			cfg.Groups = map[string]BlockingGroupConfig{
+				"gr1": {Enforce: true, QueryTypes: NewQTypeSet(dns.Type(dns.TypeAAAA), dns.Type(dns.TypeA))},
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("group gr1: queryTypes = A, AAAA")))
+		})
+
+		It("should log the refresh period of each group and source", func() {
+			groupPeriod := Duration(10 * time.Minute)
+			sourcePeriod := Duration(24 * time.Hour)
+
+			cfg.Loading.RefreshPeriod = Duration(4 * time.Hour)
+			cfg.Groups = map[string]BlockingGroupConfig{"threats": {Enforce: true, RefreshPeriod: &groupPeriod}}
+			cfg.BlackLists["threats"] = []BytesSource{
+				{Type: BytesSourceTypeHttp, From: "https://example.com/feed.txt"},
+				{Type: BytesSourceTypeHttp, From: "https://example.com/big.txt", RefreshPeriod: &sourcePeriod},
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				Equal("gr1: refresh = every 4 hours"),
+				Equal("threats: refresh = every 10 minutes"),
+				Equal("   - https://example.com/feed.txt"),
+				Equal("   - https://example.com/big.txt (refresh = every 1 day)"),
+			))
+		})
+
+		It("should log the block page", func() {
+			cfg.BlockPage = BlockPageConfig{HTTP: ListenConfig{"80"}, Template: "/etc/blocky/blocked.html"}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				Equal("blockPage:"),
+				Equal("http = [80]"),
+				Equal("template = /etc/blocky/blocked.html"),
+			))
+		})
+	})
+
+	Describe("BlockPage", func() {
+		It("should be disabled by default", func() {
+			Expect(cfg.BlockPage.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled with a listener", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blockPage:
+  https: 443
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.BlockPage.HTTPS).Should(Equal(ListenConfig{"443"}))
+			Expect(cfg.BlockPage.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("Groups", func() {
+		It("should enforce groups by default", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+groups:
+  gr1: {}
+  gr2:
+    enforce: false
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.IsEnforced("gr1")).Should(BeTrue())
+			Expect(cfg.IsEnforced("gr2")).Should(BeFalse())
+			Expect(cfg.IsEnforced("unconfigured")).Should(BeTrue())
+		})
+
+		It("should not enforce audit groups", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+auditGroups: [gr1, gr2]
+groups:
+  gr2:
+    blockType: nxDomain
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.IsEnforced("gr1")).Should(BeFalse())
+			Expect(cfg.IsEnforced("gr2")).Should(BeFalse())
+			Expect(cfg.IsEnforced("unconfigured")).Should(BeTrue())
+
+			By("keeping the other settings of the group", func() {
+				Expect(cfg.Groups["gr2"].BlockType).Should(Equal("nxDomain"))
+				Expect(cfg.Groups["gr1"].BlockType).Should(BeEmpty())
+			})
+		})
+
+		It("should parse block type overrides", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+groups:
+  adult:
+    blockType: 10.0.0.5
+    blockTTL: 1m
+  ads:
+    blockType: nxDomain
+`), &cfg)).Should(Succeed())
+
+			adult := cfg.Groups["adult"]
+			Expect(adult.Enforce).Should(BeTrue())
+			Expect(adult.HasBlockOverride()).Should(BeTrue())
+			Expect(cfg.Groups["ads"].BlockTTL).Should(BeNil())
+		})
+
+		It("should parse the query types of groups", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+groups:
+  ads:
+    queryTypes: [A, AAAA, HTTPS]
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.AppliesToQueryType("ads", dns.Type(dns.TypeHTTPS))).Should(BeTrue())
+			Expect(cfg.AppliesToQueryType("ads", dns.Type(dns.TypeMX))).Should(BeFalse())
+			Expect(cfg.AppliesToQueryType("unconfigured", dns.Type(dns.TypeMX))).Should(BeTrue())
+		})
+
+		It("should fail for unknown query types", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+groups:
+  ads:
+    queryTypes: [UNKNOWN]
+`), &cfg)).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("Sources", func() {
+		It("should parse the source format", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  threats:
+    - https://example.com/hosts.txt
+    - source: https://example.com/threats.rpz
+      format: rpz
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.BlackLists["threats"]).Should(Equal([]BytesSource{
+				{Type: BytesSourceTypeHttp, From: "https://example.com/hosts.txt"},
+				{Type: BytesSourceTypeHttp, From: "https://example.com/threats.rpz", Format: BytesSourceFormatRpz},
+			}))
+		})
+
+		It("should fail for an unknown format", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  threats:
+    - source: https://example.com/threats.rpz
+      format: unknown
+`), &cfg)).ShouldNot(Succeed())
+		})
+
+		It("should fail without source", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  threats:
+    - format: rpz
+`), &cfg)).ShouldNot(Succeed())
+		})
+
+		It("should parse the authentication of HTTP sources", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  internal:
+    - source: https://lists.example.com/internal.txt
+      headers:
+        Authorization: Bearer ${file:/run/secrets/token}
+      headersFile: /run/secrets/headers
+      tls:
+        cert: client.pem
+        key: client-key.pem
+        ca: ca.pem
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.BlackLists["internal"]).Should(Equal([]BytesSource{{
+				Type: BytesSourceTypeHttp,
+				From: "https://lists.example.com/internal.txt",
+				HTTP: &HTTPSourceConfig{
+					Headers:     map[string]string{"Authorization": "Bearer ${file:/run/secrets/token}"},
+					HeadersFile: "/run/secrets/headers",
+					TLS:         TLSFilesConfig{Cert: "client.pem", Key: "client-key.pem", CA: "ca.pem"},
+				},
+			}}))
+		})
+
+		It("should fail for headers of a file source", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  internal:
+    - source: /etc/blocky/internal.txt
+      headers:
+        Authorization: secret
+`), &cfg)).Should(MatchError(ContainSubstring("only supported for HTTP sources")))
+		})
+
+		It("should fail for a client certificate without key", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  internal:
+    - source: https://lists.example.com/internal.txt
+      tls:
+        cert: client.pem
+`), &cfg)).Should(MatchError(ContainSubstring("cert and key must be set together")))
+		})
+
+		It("should parse the use of list IPs", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  sinkhole:
+    - source: https://example.com/sinkhole.txt
+      useListIPs: true
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.BlackLists["sinkhole"]).Should(Equal([]BytesSource{
+				{Type: BytesSourceTypeHttp, From: "https://example.com/sinkhole.txt", UseListIPs: true},
+			}))
+		})
+
+		It("should fail for list IPs of other formats than hosts", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  sinkhole:
+    - source: https://example.com/threats.rpz
+      format: rpz
+      useListIPs: true
+`), &cfg)).Should(MatchError(ContainSubstring("only supported for sources in hosts format")))
+		})
+
+		It("should parse glob patterns and executables", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  local:
+    - /etc/blocky/lists/*.txt
+    - file:///etc/blocky/list-[0-9].txt
+    - exec:///usr/local/bin/gen-list
+    - source: exec:///usr/local/bin/slow-list
+      timeout: 2m
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.BlackLists["local"]).Should(Equal([]BytesSource{
+				{Type: BytesSourceTypeGlob, From: "/etc/blocky/lists/*.txt"},
+				{Type: BytesSourceTypeGlob, From: "/etc/blocky/list-[0-9].txt"},
+				{Type: BytesSourceTypeExec, From: "/usr/local/bin/gen-list"},
+				{Type: BytesSourceTypeExec, From: "/usr/local/bin/slow-list", Timeout: Duration(2 * time.Minute)},
+			}))
+			Expect(cfg.BlackLists["local"][0].String()).Should(Equal("file:///etc/blocky/lists/*.txt"))
+			Expect(cfg.BlackLists["local"][2].String()).Should(Equal("exec:///usr/local/bin/gen-list"))
+		})
+
+		It("should fail for the timeout of a file source", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  local:
+    - source: /etc/blocky/list.txt
+      timeout: 2m
+`), &cfg)).Should(MatchError(ContainSubstring("only supported for exec sources")))
+		})
+
+		Describe("validateSources", func() {
+			It("should require the opt-in for glob patterns", func() {
+				cfg.BlackLists = map[string][]BytesSource{"local": NewBytesSources("/etc/blocky/lists/*.txt")}
+
+				Expect(cfg.validateSources()).Should(MatchError(ContainSubstring("loading.allowGlobs")))
+
+				cfg.Loading.AllowGlobs = true
+
+				Expect(cfg.validateSources()).Should(Succeed())
+			})
+
+			It("should reject the TTL of a source", func() {
+				ttl := Duration(time.Minute)
+				cfg.BlackLists = map[string][]BytesSource{"ads": {{Type: BytesSourceTypeHttp, From: "https://a.b/c", TTL: &ttl}}}
+
+				Expect(cfg.validateSources()).Should(MatchError(ContainSubstring("ttl is only supported for hostsFile sources")))
+			})
+
+			It("should require the opt-in for executables", func() {
+				cfg.WhiteLists = map[string][]BytesSource{"local": NewBytesSources("exec:///usr/local/bin/gen-list")}
+
+				Expect(cfg.validateSources()).Should(MatchError(ContainSubstring("loading.allowExec")))
+
+				cfg.Loading.AllowExec = true
+
+				Expect(cfg.validateSources()).Should(Succeed())
+			})
+
+			It("should fail for an executable with a relative path", func() {
+				cfg.Loading.AllowExec = true
+				cfg.BlackLists = map[string][]BytesSource{"local": NewBytesSources("exec://gen-list")}
+
+				Expect(cfg.validateSources()).Should(MatchError(ContainSubstring("must be an absolute path")))
+			})
+
+			It("should fail for an invalid glob pattern", func() {
+				cfg.Loading.AllowGlobs = true
+				cfg.BlackLists = map[string][]BytesSource{"local": NewBytesSources("/etc/blocky/[a-.txt")}
+
+				Expect(cfg.validateSources()).Should(MatchError(ContainSubstring("invalid glob pattern")))
+			})
+		})
+	})
+
+	Describe("GroupRefreshPeriod", func() {
+		It("should return the period of the group or the loading config", func() {
+			groupPeriod := Duration(10 * time.Minute)
+
+			cfg.Loading.RefreshPeriod = Duration(4 * time.Hour)
+			cfg.Groups = map[string]BlockingGroupConfig{
+				"threats": {Enforce: true, RefreshPeriod: &groupPeriod},
+				"audit":   {Enforce: false},
+			}
+			cfg.WhiteLists = map[string][]BytesSource{"threats": NewBytesSources("/a/whitelist")}
+
+			Expect(cfg.GroupRefreshPeriod("threats")).Should(Equal(groupPeriod))
+			Expect(cfg.GroupRefreshPeriod("audit")).Should(Equal(Duration(4 * time.Hour)))
+			Expect(cfg.GroupRefreshPeriods()).Should(Equal(map[string]Duration{
+				"gr1":     Duration(4 * time.Hour),
+				"threats": groupPeriod,
+			}))
+		})
+
+		It("should parse the refresh periods of groups and sources", func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+blackLists:
+  threats:
+    - https://example.com/feed.txt
+    - source: https://example.com/big.txt
+      refreshPeriod: 24h
+groups:
+  threats:
+    refreshPeriod: 10m
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.GroupRefreshPeriod("threats")).Should(Equal(Duration(10 * time.Minute)))
+			Expect(cfg.BlackLists["threats"][0].RefreshPeriod).Should(BeNil())
+			Expect(*cfg.BlackLists["threats"][1].RefreshPeriod).Should(Equal(Duration(24 * time.Hour)))
+		})
+	})
+
+	Describe("GroupBlockType", func() {
+		BeforeEach(func() {
+			ttl := Duration(time.Minute)
+
+			cfg.Groups = map[string]BlockingGroupConfig{
+				"adult": {Enforce: true, BlockType: "10.0.0.5", BlockTTL: &ttl},
+				"ads":   {Enforce: true, BlockType: "nxDomain"},
+				"audit": {Enforce: false},
+			}
+		})
+
+		It("should return the overrides of the group", func() {
+			blockType, blockTTL := cfg.GroupBlockType("adult")
+			Expect(blockType).Should(Equal("10.0.0.5"))
+			Expect(blockTTL).Should(Equal(Duration(time.Minute)))
+		})
+
+		It("should fall back to the global settings", func() {
+			blockType, blockTTL := cfg.GroupBlockType("ads")
+			Expect(blockType).Should(Equal("nxDomain"))
+			Expect(blockTTL).Should(Equal(cfg.BlockTTL))
+
+			blockType, blockTTL = cfg.GroupBlockType("audit")
+			Expect(blockType).Should(Equal(cfg.BlockType))
+			Expect(blockTTL).Should(Equal(cfg.BlockTTL))
+
+			audit := cfg.Groups["audit"]
+			Expect(audit.HasBlockOverride()).Should(BeFalse())
+		})
+	})
+
+	Describe("ValidateGroups", func() {
+		It("should accept groups with a black- or whitelist", func() {
+			cfg.WhiteLists = map[string][]BytesSource{"wl": NewBytesSources("/a/file/path")}
+			cfg.Groups = map[string]BlockingGroupConfig{"gr1": {}, "wl": {}}
+
+			Expect(cfg.ValidateGroups()).Should(Succeed())
+		})
+
+		It("should fail for unknown groups", func() {
+			cfg.Groups = map[string]BlockingGroupConfig{"adult": {}}
+
+			Expect(cfg.ValidateGroups()).Should(MatchError(ContainSubstring("unknown group 'adult'")))
+		})
+
+		It("should fail for whitelists using list IPs", func() {
+			source := newBytesSource("/a/file/path")
+			source.UseListIPs = true
+
+			cfg.WhiteLists = map[string][]BytesSource{"wl": {source}}
+
+			Expect(cfg.ValidateGroups()).Should(MatchError(ContainSubstring("only supported for blacklists")))
+		})
 	})
 })
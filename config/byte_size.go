@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes, which can be configured with a unit (B, KB, MB, GB).
+// Units are binary: 1 KB = 1024 B
+type ByteSize uint64
+
+const (
+	byteSizeKB ByteSize = 1 << (10 * (iota + 1))
+	byteSizeMB
+	byteSizeGB
+)
+
+//nolint:gochecknoglobals
+var (
+	byteSizePattern = regexp.MustCompile(`^\s*(\d+)\s*([a-zA-Z]*)\s*$`)
+
+	byteSizeUnits = map[string]ByteSize{
+		"":    1,
+		"b":   1,
+		"kb":  byteSizeKB,
+		"kib": byteSizeKB,
+		"mb":  byteSizeMB,
+		"mib": byteSizeMB,
+		"gb":  byteSizeGB,
+		"gib": byteSizeGB,
+	}
+)
+
+func (s ByteSize) IsAboveZero() bool {
+	return s > 0
+}
+
+func (s ByteSize) String() string {
+	switch {
+	case s >= byteSizeGB && s%byteSizeGB == 0:
+		return fmt.Sprintf("%dGB", s/byteSizeGB)
+	case s >= byteSizeMB && s%byteSizeMB == 0:
+		return fmt.Sprintf("%dMB", s/byteSizeMB)
+	case s >= byteSizeKB && s%byteSizeKB == 0:
+		return fmt.Sprintf("%dKB", s/byteSizeKB)
+	default:
+		return fmt.Sprintf("%dB", uint64(s))
+	}
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`.
+func (s *ByteSize) UnmarshalText(data []byte) error {
+	input := string(data)
+
+	parts := byteSizePattern.FindStringSubmatch(input)
+	if parts == nil {
+		return fmt.Errorf("invalid size '%s', expected a number with optional unit (B, KB, MB, GB)", input)
+	}
+
+	unit, ok := byteSizeUnits[strings.ToLower(parts[2])]
+	if !ok {
+		return fmt.Errorf("invalid size unit '%s' in '%s', supported: B, KB, MB, GB", parts[2], input)
+	}
+
+	value, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size '%s': %w", input, err)
+	}
+
+	*s = ByteSize(value) * unit
+
+	return nil
+}
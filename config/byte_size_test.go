@@ -0,0 +1,45 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ByteSize", func() {
+	var s ByteSize
+
+	BeforeEach(func() {
+		s = 0
+	})
+
+	Describe("UnmarshalText", func() {
+		DescribeTable("should parse size",
+			func(input string, expected ByteSize) {
+				Expect(s.UnmarshalText([]byte(input))).Should(Succeed())
+				Expect(s).Should(Equal(expected))
+			},
+			Entry("without unit", "512", ByteSize(512)),
+			Entry("bytes", "512B", ByteSize(512)),
+			Entry("kilobytes", "2KB", ByteSize(2048)),
+			Entry("megabytes", "64MB", ByteSize(64*1024*1024)),
+			Entry("megabytes with space and lower case", "64 mb", ByteSize(64*1024*1024)),
+			Entry("mebibytes", "64MiB", ByteSize(64*1024*1024)),
+			Entry("gigabytes", "1GB", ByteSize(1024*1024*1024)),
+		)
+
+		It("should fail if size is in wrong format", func() {
+			Expect(s.UnmarshalText([]byte("wrong"))).ShouldNot(Succeed())
+			Expect(s.UnmarshalText([]byte("-1MB"))).ShouldNot(Succeed())
+			Expect(s.UnmarshalText([]byte("1TB"))).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("String", func() {
+		It("should use the biggest matching unit", func() {
+			Expect(ByteSize(64 * 1024 * 1024).String()).Should(Equal("64MB"))
+			Expect(ByteSize(2048).String()).Should(Equal("2KB"))
+			Expect(ByteSize(1000).String()).Should(Equal("1000B"))
+			Expect(ByteSize(2 * 1024 * 1024 * 1024).String()).Should(Equal("2GB"))
+		})
+	})
+})
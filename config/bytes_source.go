@@ -2,11 +2,23 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
-const maxTextSourceDisplayLen = 12
+// DefaultExecTimeout is the timeout of exec sources without `timeout`
+const DefaultExecTimeout = 30 * time.Second
+
+const (
+	maxTextSourceDisplayLen = 12
+
+	execSourcePrefix = "exec://"
+	// globMetaChars are the characters which make a file path a glob pattern
+	globMetaChars = "*?["
+)
 
 // var BytesSourceNone = BytesSource{}
 
@@ -14,12 +26,65 @@ const maxTextSourceDisplayLen = 12
 // text=1 // Inline YAML block.
 // http   // HTTP(S).
 // file   // Local file.
+// glob   // Local files matching a glob pattern, expanded at each refresh.
+// exec   // Standard output of a local executable.
 // )
 type BytesSourceType uint16
 
+// BytesSourceFormat format of the content of a BytesSource. ENUM(
+// hosts // Hosts file or host list, optionally with wildcards and regexes.
+// rpz   // Response Policy Zone (RPZ) zone file.
+// abp   // Adblock Plus filter list, only domain rules are used.
+// )
+type BytesSourceFormat uint16
+
 type BytesSource struct {
-	Type BytesSourceType
-	From string
+	Type   BytesSourceType
+	From   string
+	Format BytesSourceFormat
+	// HTTP configures the requests of HTTP sources, nil if not configured
+	HTTP *HTTPSourceConfig
+	// UseListIPs answers blocked queries with the IPs of the hosts file entries instead of the block type
+	UseListIPs bool
+	// Timeout of the executable of an exec source, `DefaultExecTimeout` if 0
+	Timeout Duration
+	// RefreshPeriod overrides the refresh period of the group for this source, nil if not configured
+	RefreshPeriod *Duration
+	// TTL overrides the TTL of the hosts file entries of this source, nil if not configured
+	TTL *Duration
+}
+
+// HTTPSourceConfig authentication of the requests of a HTTP source
+type HTTPSourceConfig struct {
+	// Headers sent with each request, values can reference files with `${file:/path/to/secret}`
+	Headers map[string]string `yaml:"headers"`
+	// HeadersFile contains additional headers, one `Name: value` per line
+	HeadersFile string `yaml:"headersFile"`
+	// TLS client certificate and CA
+	TLS TLSFilesConfig `yaml:"tls"`
+}
+
+// TLSFilesConfig PEM files of a TLS client
+type TLSFilesConfig struct {
+	// Cert client certificate, requires Key
+	Cert string `yaml:"cert"`
+	// Key private key of the client certificate
+	Key string `yaml:"key"`
+	// CA certificates to verify the server with, instead of the system's
+	CA string `yaml:"ca"`
+}
+
+// IsEnabled returns true if any file is configured
+func (c *TLSFilesConfig) IsEnabled() bool {
+	return c.Cert != "" || c.Key != "" || c.CA != ""
+}
+
+func (c *TLSFilesConfig) validate() error {
+	if (c.Cert == "") != (c.Key == "") {
+		return errors.New("tls: cert and key must be set together")
+	}
+
+	return nil
 }
 
 func (s BytesSource) String() string {
@@ -30,9 +95,12 @@ func (s BytesSource) String() string {
 	case BytesSourceTypeHttp:
 		return s.From
 
-	case BytesSourceTypeFile:
+	case BytesSourceTypeFile, BytesSourceTypeGlob:
 		return fmt.Sprintf("file://%s", s.From)
 
+	case BytesSourceTypeExec:
+		return execSourcePrefix + s.From
+
 	default:
 		return fmt.Sprintf("unknown source (%s: %s)", s.Type, s.From)
 	}
@@ -70,9 +138,117 @@ func (s *BytesSource) UnmarshalText(data []byte) error {
 	case strings.HasPrefix(source, "http"):
 		*s = BytesSource{Type: BytesSourceTypeHttp, From: source}
 
-	// Probably path to a local file
+	// Output of an executable
+	case strings.HasPrefix(source, execSourcePrefix):
+		*s = BytesSource{Type: BytesSourceTypeExec, From: strings.TrimPrefix(source, execSourcePrefix)}
+
+	// Probably path to a local file or a glob pattern of local files
 	default:
-		*s = BytesSource{Type: BytesSourceTypeFile, From: strings.TrimPrefix(source, "file://")}
+		path := strings.TrimPrefix(source, "file://")
+
+		if strings.ContainsAny(path, globMetaChars) {
+			*s = BytesSource{Type: BytesSourceTypeGlob, From: path}
+		} else {
+			*s = BytesSource{Type: BytesSourceTypeFile, From: path}
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+// A source is either a plain string, or a mapping with the keys `source` and `format`.
+// HTTP sources accept `headers`, `headersFile` and `tls` to authenticate the download.
+// Sources in hosts format accept `useListIPs`, exec sources accept `timeout`.
+// All sources accept `refreshPeriod`, which is only supported for black- and whitelists,
+// and `ttl`, which is only supported for hosts file sources.
+func (s *BytesSource) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var source string
+
+	if err := unmarshal(&source); err == nil {
+		return s.UnmarshalText([]byte(source))
+	}
+
+	var withOptions struct {
+		Source           string            `yaml:"source"`
+		Format           BytesSourceFormat `yaml:"format"`
+		UseListIPs       bool              `yaml:"useListIPs"`
+		Timeout          Duration          `yaml:"timeout"`
+		RefreshPeriod    *Duration         `yaml:"refreshPeriod"`
+		TTL              *Duration         `yaml:"ttl"`
+		HTTPSourceConfig `yaml:",inline"`
+	}
+
+	if err := unmarshal(&withOptions); err != nil {
+		return err
+	}
+
+	if withOptions.Source == "" {
+		return errors.New("missing source")
+	}
+
+	if err := s.UnmarshalText([]byte(withOptions.Source)); err != nil {
+		return err
+	}
+
+	s.Format = withOptions.Format
+
+	if withOptions.UseListIPs && s.Format != BytesSourceFormatHosts {
+		return fmt.Errorf("%s: useListIPs is only supported for sources in hosts format", s)
+	}
+
+	s.UseListIPs = withOptions.UseListIPs
+
+	if withOptions.Timeout != 0 && s.Type != BytesSourceTypeExec {
+		return fmt.Errorf("%s: timeout is only supported for exec sources", s)
+	}
+
+	s.Timeout = withOptions.Timeout
+	s.RefreshPeriod = withOptions.RefreshPeriod
+	s.TTL = withOptions.TTL
+
+	httpCfg := withOptions.HTTPSourceConfig
+	if len(httpCfg.Headers) == 0 && httpCfg.HeadersFile == "" && !httpCfg.TLS.IsEnabled() {
+		return nil
+	}
+
+	if s.Type != BytesSourceTypeHttp {
+		return fmt.Errorf("%s: headers and tls are only supported for HTTP sources", s)
+	}
+
+	if err := httpCfg.TLS.validate(); err != nil {
+		return fmt.Errorf("%s: %w", s, err)
+	}
+
+	s.HTTP = &httpCfg
+
+	return nil
+}
+
+// validateSources checks that the glob and exec sources are enabled and valid
+func (c *SourceLoadingConfig) validateSources(sources []BytesSource) error {
+	for _, source := range sources {
+		switch source.Type {
+		case BytesSourceTypeGlob:
+			if !c.AllowGlobs {
+				return fmt.Errorf("%s: glob patterns must be enabled with loading.allowGlobs", source)
+			}
+
+			if _, err := filepath.Match(source.From, ""); err != nil {
+				return fmt.Errorf("%s: invalid glob pattern: %w", source, err)
+			}
+
+		case BytesSourceTypeExec:
+			if !c.AllowExec {
+				return fmt.Errorf("%s: executables must be enabled with loading.allowExec", source)
+			}
+
+			if !filepath.IsAbs(source.From) {
+				return fmt.Errorf("%s: the executable must be an absolute path", source)
+			}
+
+		case BytesSourceTypeText, BytesSourceTypeHttp, BytesSourceTypeFile:
+		}
 	}
 
 	return nil
@@ -11,6 +11,95 @@ import (
 	"strings"
 )
 
+const (
+	// BytesSourceFormatHosts is a BytesSourceFormat of type Hosts.
+	// Hosts file or host list, optionally with wildcards and regexes.
+	BytesSourceFormatHosts BytesSourceFormat = iota
+	// BytesSourceFormatRpz is a BytesSourceFormat of type Rpz.
+	// Response Policy Zone (RPZ) zone file.
+	BytesSourceFormatRpz
+	// BytesSourceFormatAbp is a BytesSourceFormat of type Abp.
+	// Adblock Plus filter list, only domain rules are used.
+	BytesSourceFormatAbp
+)
+
+var ErrInvalidBytesSourceFormat = fmt.Errorf("not a valid BytesSourceFormat, try [%s]", strings.Join(_BytesSourceFormatNames, ", "))
+
+const _BytesSourceFormatName = "hostsrpzabp"
+
+var _BytesSourceFormatNames = []string{
+	_BytesSourceFormatName[0:5],
+	_BytesSourceFormatName[5:8],
+	_BytesSourceFormatName[8:11],
+}
+
+// BytesSourceFormatNames returns a list of possible string values of BytesSourceFormat.
+func BytesSourceFormatNames() []string {
+	tmp := make([]string, len(_BytesSourceFormatNames))
+	copy(tmp, _BytesSourceFormatNames)
+	return tmp
+}
+
+// BytesSourceFormatValues returns a list of the values for BytesSourceFormat
+func BytesSourceFormatValues() []BytesSourceFormat {
+	return []BytesSourceFormat{
+		BytesSourceFormatHosts,
+		BytesSourceFormatRpz,
+		BytesSourceFormatAbp,
+	}
+}
+
+var _BytesSourceFormatMap = map[BytesSourceFormat]string{
+	BytesSourceFormatHosts: _BytesSourceFormatName[0:5],
+	BytesSourceFormatRpz:   _BytesSourceFormatName[5:8],
+	BytesSourceFormatAbp:   _BytesSourceFormatName[8:11],
+}
+
+// String implements the Stringer interface.
+func (x BytesSourceFormat) String() string {
+	if str, ok := _BytesSourceFormatMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("BytesSourceFormat(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x BytesSourceFormat) IsValid() bool {
+	_, ok := _BytesSourceFormatMap[x]
+	return ok
+}
+
+var _BytesSourceFormatValue = map[string]BytesSourceFormat{
+	_BytesSourceFormatName[0:5]:  BytesSourceFormatHosts,
+	_BytesSourceFormatName[5:8]:  BytesSourceFormatRpz,
+	_BytesSourceFormatName[8:11]: BytesSourceFormatAbp,
+}
+
+// ParseBytesSourceFormat attempts to convert a string to a BytesSourceFormat.
+func ParseBytesSourceFormat(name string) (BytesSourceFormat, error) {
+	if x, ok := _BytesSourceFormatValue[name]; ok {
+		return x, nil
+	}
+	return BytesSourceFormat(0), fmt.Errorf("%s is %w", name, ErrInvalidBytesSourceFormat)
+}
+
+// MarshalText implements the text marshaller method.
+func (x BytesSourceFormat) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *BytesSourceFormat) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseBytesSourceFormat(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// BytesSourceTypeText is a BytesSourceType of type Text.
 	// Inline YAML block.
@@ -21,16 +110,24 @@ const (
 	// BytesSourceTypeFile is a BytesSourceType of type File.
 	// Local file.
 	BytesSourceTypeFile
+	// BytesSourceTypeGlob is a BytesSourceType of type Glob.
+	// Local files matching a glob pattern, expanded at each refresh.
+	BytesSourceTypeGlob
+	// BytesSourceTypeExec is a BytesSourceType of type Exec.
+	// Standard output of a local executable.
+	BytesSourceTypeExec
 )
 
 var ErrInvalidBytesSourceType = fmt.Errorf("not a valid BytesSourceType, try [%s]", strings.Join(_BytesSourceTypeNames, ", "))
 
-const _BytesSourceTypeName = "texthttpfile"
+const _BytesSourceTypeName = "texthttpfileglobexec"
 
 var _BytesSourceTypeNames = []string{
 	_BytesSourceTypeName[0:4],
 	_BytesSourceTypeName[4:8],
 	_BytesSourceTypeName[8:12],
+	_BytesSourceTypeName[12:16],
+	_BytesSourceTypeName[16:20],
 }
 
 // BytesSourceTypeNames returns a list of possible string values of BytesSourceType.
@@ -46,6 +143,8 @@ func BytesSourceTypeValues() []BytesSourceType {
 		BytesSourceTypeText,
 		BytesSourceTypeHttp,
 		BytesSourceTypeFile,
+		BytesSourceTypeGlob,
+		BytesSourceTypeExec,
 	}
 }
 
@@ -53,6 +152,8 @@ var _BytesSourceTypeMap = map[BytesSourceType]string{
 	BytesSourceTypeText: _BytesSourceTypeName[0:4],
 	BytesSourceTypeHttp: _BytesSourceTypeName[4:8],
 	BytesSourceTypeFile: _BytesSourceTypeName[8:12],
+	BytesSourceTypeGlob: _BytesSourceTypeName[12:16],
+	BytesSourceTypeExec: _BytesSourceTypeName[16:20],
 }
 
 // String implements the Stringer interface.
@@ -71,9 +172,11 @@ func (x BytesSourceType) IsValid() bool {
 }
 
 var _BytesSourceTypeValue = map[string]BytesSourceType{
-	_BytesSourceTypeName[0:4]:  BytesSourceTypeText,
-	_BytesSourceTypeName[4:8]:  BytesSourceTypeHttp,
-	_BytesSourceTypeName[8:12]: BytesSourceTypeFile,
+	_BytesSourceTypeName[0:4]:   BytesSourceTypeText,
+	_BytesSourceTypeName[4:8]:   BytesSourceTypeHttp,
+	_BytesSourceTypeName[8:12]:  BytesSourceTypeFile,
+	_BytesSourceTypeName[12:16]: BytesSourceTypeGlob,
+	_BytesSourceTypeName[16:20]: BytesSourceTypeExec,
 }
 
 // ParseBytesSourceType attempts to convert a string to a BytesSourceType.
@@ -8,14 +8,24 @@ import (
 
 // CachingConfig configuration for domain caching
 type CachingConfig struct {
-	MinCachingTime        Duration `yaml:"minTime"`
-	MaxCachingTime        Duration `yaml:"maxTime"`
-	CacheTimeNegative     Duration `yaml:"cacheTimeNegative" default:"30m"`
-	MaxItemsCount         int      `yaml:"maxItemsCount"`
-	Prefetching           bool     `yaml:"prefetching"`
-	PrefetchExpires       Duration `yaml:"prefetchExpires" default:"2h"`
-	PrefetchThreshold     int      `yaml:"prefetchThreshold" default:"5"`
-	PrefetchMaxItemsCount int      `yaml:"prefetchMaxItemsCount"`
+	MinCachingTime        Duration      `yaml:"minTime"`
+	MaxCachingTime        Duration      `yaml:"maxTime"`
+	CacheTimeNegative     Duration      `yaml:"cacheTimeNegative" default:"30m"`
+	MaxNegativeTime       Duration      `yaml:"maxNegativeTime" default:"30m"`
+	MaxItemsCount         int           `yaml:"maxItemsCount"`
+	MaxSize               ByteSize      `yaml:"maxSize"`
+	Prefetching           bool          `yaml:"prefetching"`
+	PrefetchExpires       Duration      `yaml:"prefetchExpires" default:"2h"`
+	PrefetchThreshold     int           `yaml:"prefetchThreshold" default:"5"`
+	PrefetchMaxItemsCount int           `yaml:"prefetchMaxItemsCount"`
+	PrefetchMaxFailures   int           `yaml:"prefetchMaxFailures" default:"3"`
+	PrefetchExclude       []string      `yaml:"prefetchExclude"`
+	Exclude               []string      `yaml:"exclude"`
+	Shards                int           `yaml:"shards"`
+	PartitionByECS        bool          `yaml:"partitionByECS"`
+	WarmupDomains         []BytesSource `yaml:"warmupDomains"`
+
+	StaleWhileRevalidate RevalidateThreshold `yaml:"staleWhileRevalidate"`
 }
 
 // IsEnabled implements `config.Configurable`.
@@ -28,15 +38,57 @@ func (c *CachingConfig) LogConfig(logger *logrus.Entry) {
 	logger.Infof("minTime = %s", c.MinCachingTime)
 	logger.Infof("maxTime = %s", c.MaxCachingTime)
 	logger.Infof("cacheTimeNegative = %s", c.CacheTimeNegative)
+	logger.Infof("maxNegativeTime = %s", c.MaxNegativeTime)
+
+	if c.MaxSize.IsAboveZero() {
+		logger.Infof("maxSize = %s", c.MaxSize)
+	}
+
+	if c.Shards > 0 {
+		logger.Infof("shards = %d", c.Shards)
+	}
+
+	if c.PartitionByECS {
+		logger.Info("partitionByECS = true")
+	}
 
 	if c.Prefetching {
 		logger.Infof("prefetching:")
-		logger.Infof("  expires   = %s", c.PrefetchExpires)
-		logger.Infof("  threshold = %d", c.PrefetchThreshold)
-		logger.Infof("  maxItems  = %d", c.PrefetchMaxItemsCount)
+		logger.Infof("  expires     = %s", c.PrefetchExpires)
+		logger.Infof("  threshold   = %d", c.PrefetchThreshold)
+		logger.Infof("  maxItems    = %d", c.PrefetchMaxItemsCount)
+		logger.Infof("  maxFailures = %d", c.PrefetchMaxFailures)
+
+		if len(c.PrefetchExclude) > 0 {
+			logger.Info("  exclude:")
+
+			for _, e := range c.PrefetchExclude {
+				logger.Infof("    - %s", e)
+			}
+		}
 	} else {
 		logger.Debug("prefetching: disabled")
 	}
+
+	if c.StaleWhileRevalidate.IsEnabled() {
+		logger.Infof("staleWhileRevalidate = %s", c.StaleWhileRevalidate)
+	}
+
+	if len(c.WarmupDomains) > 0 {
+		logger.Info("warmupDomains:")
+
+		for _, source := range c.WarmupDomains {
+			logger.Infof("  - %s", source)
+		}
+	}
+
+	if len(c.Exclude) > 0 {
+		logger.Info("exclude:")
+
+		for _, e := range c.Exclude {
+			logger.Infof("  - %s", e)
+		}
+	}
 }
 
 func (c *CachingConfig) EnablePrefetch() {
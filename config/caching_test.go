@@ -59,6 +59,62 @@ var _ = Describe("CachingConfig", func() {
 				Expect(hook.Messages).Should(ContainElement(ContainSubstring("prefetching:")))
 			})
 		})
+		When("shards are configured", func() {
+			BeforeEach(func() {
+				cfg = CachingConfig{
+					Shards: 16,
+				}
+			})
+
+			It("should log the shard count", func() {
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("shards = 16")))
+			})
+		})
+		When("exclusions are configured", func() {
+			BeforeEach(func() {
+				cfg = CachingConfig{
+					Exclude: []string{"*.consul", "failover.example.com"},
+				}
+			})
+
+			It("should log exclusions", func() {
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("exclude:")))
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("*.consul")))
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("failover.example.com")))
+			})
+		})
+		When("stale-while-revalidate is configured", func() {
+			BeforeEach(func() {
+				cfg = CachingConfig{
+					StaleWhileRevalidate: RevalidateThreshold{Percent: 10},
+				}
+			})
+
+			It("should log the threshold", func() {
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement("staleWhileRevalidate = 10% of TTL"))
+			})
+		})
+	})
+
+	When("prefetch exclusions are configured", func() {
+		BeforeEach(func() {
+			cfg = CachingConfig{
+				Prefetching:     true,
+				PrefetchExclude: []string{"*.tracker.example"},
+			}
+		})
+
+		It("should log prefetch exclusions", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("*.tracker.example")))
+		})
 	})
 
 	Describe("EnablePrefetch", func() {
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// ChaosQueriesConfig configuration for the answers to CHAOS class queries of the server version and identity
+type ChaosQueriesConfig struct {
+	// Enable answers version.bind, version.server, hostname.bind and id.server, otherwise they're refused
+	Enable bool `yaml:"enable" default:"true"`
+	// Version is the answer to version.bind and version.server, empty answers with the version of blocky
+	Version string `yaml:"version"`
+	// Hostname is the answer to hostname.bind and id.server, empty answers with the hostname
+	Hostname string `yaml:"hostname"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ChaosQueriesConfig) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ChaosQueriesConfig) LogConfig(logger *logrus.Entry) {
+	if c.Version == "" {
+		logger.Info("version = blocky version")
+	} else {
+		logger.Infof("version = %s", c.Version)
+	}
+
+	if c.Hostname == "" {
+		logger.Info("hostname = hostname")
+	} else {
+		logger.Infof("hostname = %s", c.Hostname)
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChaosQueriesConfig", func() {
+	var cfg ChaosQueriesConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[ChaosQueriesConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be true by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		When("disabled", func() {
+			It("should be false", func() {
+				cfg.Enable = false
+
+				Expect(cfg.IsEnabled()).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the defaults", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("version = blocky version", "hostname = hostname"))
+		})
+
+		It("should log the configured answers", func() {
+			cfg.Version = "secret"
+			cfg.Hostname = "dns-1"
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("version = secret", "hostname = dns-1"))
+		})
+	})
+})
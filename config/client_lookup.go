@@ -3,6 +3,7 @@ package config
 import (
 	"net"
 
+	"github.com/0xERR0R/blocky/log"
 	"github.com/sirupsen/logrus"
 )
 
@@ -11,11 +12,25 @@ type ClientLookupConfig struct {
 	ClientnameIPMapping map[string][]net.IP `yaml:"clients"`
 	Upstream            Upstream            `yaml:"upstream"`
 	SingleNameOrder     []uint              `yaml:"singleNameOrder"`
+	EDNS0               ClientLookupEDNS0   `yaml:"edns0"`
+	// LeaseFiles of dnsmasq or ISC dhcpd, the hostnames of active leases take precedence over reverse DNS
+	LeaseFiles []BytesSource       `yaml:"leaseFiles"`
+	Loading    SourceLoadingConfig `yaml:"loading"`
+}
+
+// ClientLookupEDNS0 configuration for the client identification by EDNS0 options, e.g. the MAC added by a router
+type ClientLookupEDNS0 struct {
+	Enable bool `yaml:"enable" default:"false"`
+	// OptionCodes of the EDNS0 options, not limited to the local range (65001-65534) since some vendors
+	// use other codes for their device id. The first option of a query in this order determines the client name.
+	OptionCodes []uint16 `yaml:"optionCodes" default:"[65001]"`
+	// Clients maps the option value (e.g. a MAC) to a client name
+	Clients map[string]string `yaml:"clients"`
 }
 
 // IsEnabled implements `config.Configurable`.
 func (c *ClientLookupConfig) IsEnabled() bool {
-	return !c.Upstream.IsDefault() || len(c.ClientnameIPMapping) != 0
+	return !c.Upstream.IsDefault() || len(c.ClientnameIPMapping) != 0 || c.EDNS0.Enable || len(c.LeaseFiles) != 0
 }
 
 // LogConfig implements `config.Configurable`.
@@ -33,4 +48,23 @@ func (c *ClientLookupConfig) LogConfig(logger *logrus.Entry) {
 			logger.Infof("  %s = %s", k, v)
 		}
 	}
+
+	if c.EDNS0.Enable {
+		logger.Infof("edns0 option codes = %v", c.EDNS0.OptionCodes)
+
+		for k, v := range c.EDNS0.Clients {
+			logger.Infof("  %s = %s", k, v)
+		}
+	}
+
+	if len(c.LeaseFiles) > 0 {
+		logger.Info("lease files:")
+
+		for _, source := range c.LeaseFiles {
+			logger.Infof("  - %s", source)
+		}
+
+		logger.Info("loading:")
+		log.WithIndent(logger, "  ", c.Loading.LogConfig)
+	}
 }
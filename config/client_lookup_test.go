@@ -29,6 +29,7 @@ var _ = Describe("ClientLookupConfig", func() {
 			Expect(defaults.Set(&cfg)).Should(Succeed())
 
 			Expect(cfg.IsEnabled()).Should(BeFalse())
+			Expect(cfg.EDNS0.OptionCodes).Should(Equal([]uint16{65001}))
 		})
 
 		When("enabled", func() {
@@ -51,6 +52,18 @@ var _ = Describe("ClientLookupConfig", func() {
 
 					Expect(cfg.IsEnabled()).Should(BeTrue())
 				})
+
+				By("EDNS0 option", func() {
+					cfg := ClientLookupConfig{EDNS0: ClientLookupEDNS0{Enable: true}}
+
+					Expect(cfg.IsEnabled()).Should(BeTrue())
+				})
+
+				By("lease files", func() {
+					cfg := ClientLookupConfig{LeaseFiles: NewBytesSources("/var/lib/misc/dnsmasq.leases")}
+
+					Expect(cfg.IsEnabled()).Should(BeTrue())
+				})
 			})
 		})
 	})
@@ -62,5 +75,23 @@ var _ = Describe("ClientLookupConfig", func() {
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("client IP mapping:")))
 		})
+
+		It("should log the EDNS0 option", func() {
+			cfg.EDNS0 = ClientLookupEDNS0{
+				Enable: true, OptionCodes: []uint16{65001, 65074}, Clients: map[string]string{"tv-id": "tv"}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("edns0 option codes = [65001 65074]", "  tv-id = tv"))
+		})
+
+		It("should log the lease files", func() {
+			cfg.LeaseFiles = NewBytesSources("/var/lib/misc/dnsmasq.leases")
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("lease files:", "  - file:///var/lib/misc/dnsmasq.leases",
+				"loading:"))
+		})
 	})
 })
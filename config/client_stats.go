@@ -0,0 +1,28 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// ClientStatsConfig configuration for the per client query statistics
+type ClientStatsConfig struct {
+	Enable        bool     `yaml:"enable" default:"false"`
+	MaxClients    uint     `yaml:"maxClients" default:"100"`
+	ResetInterval Duration `yaml:"resetInterval" default:"24h"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ClientStatsConfig) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ClientStatsConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("maxClients = %d", c.MaxClients)
+
+	if c.ResetInterval.IsAboveZero() {
+		logger.Infof("resetInterval = %s", c.ResetInterval)
+	} else {
+		logger.Info("resetInterval = never")
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientStatsConfig", func() {
+	var cfg ClientStatsConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[ClientStatsConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		When("enabled", func() {
+			It("should be true", func() {
+				cfg.Enable = true
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the defaults", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("maxClients = 100"),
+				ContainSubstring("resetInterval = 1 day"),
+			))
+		})
+
+		When("the reset interval is disabled", func() {
+			It("should log never", func() {
+				cfg.ResetInterval = 0
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("resetInterval = never")))
+			})
+		})
+	})
+})
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -16,6 +18,19 @@ type ConditionalUpstreamConfig struct {
 // ConditionalUpstreamMapping mapping for conditional configuration
 type ConditionalUpstreamMapping struct {
 	Upstreams map[string][]Upstream
+	// Fallthrough contains the domains whose queries fall through to the default upstreams
+	Fallthrough map[string]ConditionalFallthrough
+	// Minimize contains the domains whose queries are minimized (RFC 9156)
+	Minimize map[string]bool
+}
+
+// ConditionalFallthrough defines which answers of the conditional upstreams are replaced with the answer of
+// the default upstreams
+type ConditionalFallthrough struct {
+	// OnError falls through if the conditional upstreams fail or answer with SERVFAIL
+	OnError bool
+	// OnNXDomain falls through if the conditional upstreams answer with NXDOMAIN
+	OnNXDomain bool
 }
 
 // IsEnabled implements `config.Configurable`.
@@ -26,20 +41,100 @@ func (c *ConditionalUpstreamConfig) IsEnabled() bool {
 // LogConfig implements `config.Configurable`.
 func (c *ConditionalUpstreamConfig) LogConfig(logger *logrus.Entry) {
 	for key, val := range c.Mapping.Upstreams {
-		logger.Infof("%s = %v", key, val)
+		var options []string
+
+		if ft, ok := c.Mapping.Fallthrough[key]; ok {
+			options = append(options,
+				fmt.Sprintf("fallthroughOnError: %t, fallthroughOnNxdomain: %t", ft.OnError, ft.OnNXDomain))
+		}
+
+		if c.Mapping.Minimize[key] {
+			options = append(options, "minimize: true")
+		}
+
+		if len(options) == 0 {
+			logger.Infof("%s = %v", key, val)
+
+			continue
+		}
+
+		logger.Infof("%s = %v (%s)", key, val, strings.Join(options, ", "))
 	}
 }
 
 // UnmarshalYAML implements `yaml.Unmarshaler`.
+// The value of a domain is either the list of upstreams, or a mapping with the keys `upstreams`,
+// `fallthroughOnError`, `fallthroughOnNxdomain` and `minimize`.
 func (c *ConditionalUpstreamMapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var input map[string]string
-	if err := unmarshal(&input); err != nil {
+	if err := unmarshal(&input); err == nil {
+		return c.parse(input, nil, nil)
+	}
+
+	var withOptions map[string]conditionalMappingEntry
+	if err := unmarshal(&withOptions); err != nil {
 		return err
 	}
 
+	input = make(map[string]string, len(withOptions))
+	fallthroughs := make(map[string]ConditionalFallthrough)
+	minimize := make(map[string]bool)
+
+	for k, v := range withOptions {
+		input[k] = v.Upstreams
+
+		if v.FallthroughOnError || v.FallthroughOnNXDomain {
+			fallthroughs[k] = ConditionalFallthrough{OnError: v.FallthroughOnError, OnNXDomain: v.FallthroughOnNXDomain}
+		}
+
+		if v.Minimize {
+			minimize[k] = true
+		}
+	}
+
+	return c.parse(input, fallthroughs, minimize)
+}
+
+// conditionalMappingEntry is a domain of the mapping with options
+type conditionalMappingEntry struct {
+	Upstreams             string `yaml:"upstreams"`
+	FallthroughOnError    bool   `yaml:"fallthroughOnError"`
+	FallthroughOnNXDomain bool   `yaml:"fallthroughOnNxdomain"`
+	Minimize              bool   `yaml:"minimize"`
+}
+
+// UnmarshalYAML implements `yaml.Unmarshaler`, the entry is either the list of upstreams or a mapping with options.
+func (e *conditionalMappingEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.Upstreams); err == nil {
+		return nil
+	}
+
+	type withOptions conditionalMappingEntry
+
+	if err := unmarshal((*withOptions)(e)); err != nil {
+		return err
+	}
+
+	if e.Upstreams == "" {
+		return errors.New("missing upstreams")
+	}
+
+	return nil
+}
+
+func (c *ConditionalUpstreamMapping) parse(
+	input map[string]string, fallthroughs map[string]ConditionalFallthrough, minimize map[string]bool,
+) error {
 	result := make(map[string][]Upstream, len(input))
 
 	for k, v := range input {
+		// a subnet in CIDR notation is translated to its reverse zones by the resolver
+		if strings.Contains(k, "/") {
+			if _, _, err := net.ParseCIDR(k); err != nil {
+				return fmt.Errorf("invalid CIDR '%s': %w", k, err)
+			}
+		}
+
 		var upstreams []Upstream
 
 		for _, part := range strings.Split(v, ",") {
@@ -55,6 +150,8 @@ func (c *ConditionalUpstreamMapping) UnmarshalYAML(unmarshal func(interface{}) e
 	}
 
 	c.Upstreams = result
+	c.Fallthrough = fallthroughs
+	c.Minimize = minimize
 
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/creasty/defaults"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("ConditionalUpstreamConfig", func() {
@@ -57,6 +58,23 @@ var _ = Describe("ConditionalUpstreamConfig", func() {
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("fritz.box = ")))
 		})
+
+		It("should log fallthrough options", func() {
+			cfg.Mapping.Fallthrough = map[string]ConditionalFallthrough{"fritz.box": {OnNXDomain: true}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(
+				HaveSuffix("(fallthroughOnError: false, fallthroughOnNxdomain: true)")))
+		})
+
+		It("should log the minimize option", func() {
+			cfg.Mapping.Minimize = map[string]bool{"fritz.box": true}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(HaveSuffix("(minimize: true)")))
+		})
 	})
 
 	Describe("UnmarshalYAML", func() {
@@ -75,6 +93,67 @@ var _ = Describe("ConditionalUpstreamConfig", func() {
 			}))
 		})
 
+		It("should parse CIDR keys", func() {
+			c := &ConditionalUpstreamMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+192.168.1.0/24: 192.168.1.1
+fd00::/8: 192.168.1.1
+`), c)).Should(Succeed())
+
+			Expect(c.Upstreams).Should(HaveKey("192.168.1.0/24"))
+			Expect(c.Upstreams).Should(HaveKey("fd00::/8"))
+		})
+
+		It("should parse fallthrough options", func() {
+			c := &ConditionalUpstreamMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+fritz.box: 192.168.178.1
+corp.example.com:
+  upstreams: 10.8.0.1, 10.8.0.2
+  fallthroughOnError: true
+  fallthroughOnNxdomain: true
+vpn.example.com:
+  upstreams: 10.8.0.1
+  fallthroughOnNxdomain: true
+`), c)).Should(Succeed())
+
+			Expect(c.Upstreams).Should(HaveLen(3))
+			Expect(c.Upstreams["corp.example.com"]).Should(HaveLen(2))
+			Expect(c.Fallthrough).Should(Equal(map[string]ConditionalFallthrough{
+				"corp.example.com": {OnError: true, OnNXDomain: true},
+				"vpn.example.com":  {OnNXDomain: true},
+			}))
+		})
+
+		It("should parse the minimize option", func() {
+			c := &ConditionalUpstreamMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+fritz.box: 192.168.178.1
+corp.example.com:
+  upstreams: 10.8.0.1
+  minimize: true
+`), c)).Should(Succeed())
+
+			Expect(c.Upstreams).Should(HaveLen(2))
+			Expect(c.Minimize).Should(Equal(map[string]bool{"corp.example.com": true}))
+			Expect(c.Fallthrough).Should(BeEmpty())
+		})
+
+		It("should fail without upstreams", func() {
+			c := &ConditionalUpstreamMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+corp.example.com:
+  fallthroughOnError: true
+`), c)).Should(MatchError("missing upstreams"))
+		})
+
+		It("should fail for an invalid CIDR", func() {
+			c := &ConditionalUpstreamMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+192.168.1.0/33: 192.168.1.1
+`), c)).Should(MatchError(ContainSubstring("invalid CIDR '192.168.1.0/33'")))
+		})
+
 		It("should fail if wrong YAML format", func() {
 			c := &ConditionalUpstreamMapping{}
 			err := c.UnmarshalYAML(func(i interface{}) error {
@@ -127,6 +127,12 @@ type QueryLogField string
 // ENUM(parallel_best,strict)
 type UpstreamStrategy uint8
 
+// RedisSyncMode how cache entries are synchronized between instances ENUM(
+// pubsub // publish cache entries, instances which are down miss them
+// stream // append cache entries to a stream, instances catch up after a restart
+// )
+type RedisSyncMode uint8
+
 //nolint:gochecknoglobals
 var netDefaultPort = map[NetProtocol]uint16{
 	NetProtocolTcpUdp: udpPort,
@@ -192,6 +198,7 @@ type Config struct {
 	Upstreams           UpstreamsConfig           `yaml:"upstreams"`
 	ConnectIPVersion    IPVersion                 `yaml:"connectIPVersion"`
 	CustomDNS           CustomDNSConfig           `yaml:"customDNS"`
+	StaticResponses     StaticResponsesConfig     `yaml:"staticResponses"`
 	Conditional         ConditionalUpstreamConfig `yaml:"conditional"`
 	Blocking            BlockingConfig            `yaml:"blocking"`
 	ClientLookup        ClientLookupConfig        `yaml:"clientLookup"`
@@ -201,9 +208,13 @@ type Config struct {
 	Redis               RedisConfig               `yaml:"redis"`
 	Log                 log.Config                `yaml:"log"`
 	Ports               PortsConfig               `yaml:"ports"`
+	API                 APIConfig                 `yaml:"api"`
 	DoHUserAgent        string                    `yaml:"dohUserAgent"`
 	MinTLSServeVer      string                    `yaml:"minTlsServeVersion" default:"1.2"`
 	StartVerifyUpstream bool                      `yaml:"startVerifyUpstream" default:"false"`
+	MaxAnswerAge        Duration                  `yaml:"maxAnswerAge" default:"5s"`
+	MinimalResponses    bool                      `yaml:"minimalResponses" default:"false"`
+	EDNSUDPSize         uint16                    `yaml:"ednsUdpSize" default:"1232"`
 	CertFile            string                    `yaml:"certFile"`
 	KeyFile             string                    `yaml:"keyFile"`
 	BootstrapDNS        BootstrapDNSConfig        `yaml:"bootstrapDns"`
@@ -211,7 +222,20 @@ type Config struct {
 	FqdnOnly            FqdnOnlyConfig            `yaml:"fqdnOnly"`
 	Filtering           FilteringConfig           `yaml:"filtering"`
 	Ede                 EdeConfig                 `yaml:"ede"`
+	NSID                NSIDConfig                `yaml:"nsid"`
 	SUDN                SUDNConfig                `yaml:"specialUseDomains"`
+	AnyQueries          AnyQueriesConfig          `yaml:"anyQueries"`
+	ChaosQueries        ChaosQueriesConfig        `yaml:"chaosQueries"`
+	RateLimit           RateLimitConfig           `yaml:"rateLimit"`
+	ACL                 ACLConfig                 `yaml:"acl"`
+	RebindProtection    RebindProtectionConfig    `yaml:"rebindProtection"`
+	ECS                 ECSConfig                 `yaml:"ecs"`
+	Cookies             CookiesConfig             `yaml:"cookies"`
+	DNS64               DNS64Config               `yaml:"dns64"`
+	DNSSEC              DNSSECConfig              `yaml:"dnssec"`
+	Watchdog            WatchdogConfig            `yaml:"watchdog"`
+	ClientStats         ClientStatsConfig         `yaml:"clientStats"`
+	Profiles            ProfilesConfig            `yaml:"profiles"`
 
 	// Deprecated options
 	Deprecated struct {
@@ -243,6 +267,12 @@ func (c *PortsConfig) LogConfig(logger *logrus.Entry) {
 	logger.Infof("HTTPS = %s", c.HTTPS)
 }
 
+// APIConfig configuration of the REST API
+type APIConfig struct {
+	// ErrorDetails adds the internal error, e.g. with upstream addresses, to error responses
+	ErrorDetails bool `yaml:"errorDetails" default:"false"`
+}
+
 // split in two types to avoid infinite recursion. See `BootstrapDNSConfig.UnmarshalYAML`.
 type (
 	BootstrapDNSConfig bootstrapDNSConfig
@@ -260,16 +290,19 @@ type (
 
 // RedisConfig configuration for the redis connection
 type RedisConfig struct {
-	Address            string   `yaml:"address"`
-	Username           string   `yaml:"username" default:""`
-	Password           string   `yaml:"password" default:""`
-	Database           int      `yaml:"database" default:"0"`
-	Required           bool     `yaml:"required" default:"false"`
-	ConnectionAttempts int      `yaml:"connectionAttempts" default:"3"`
-	ConnectionCooldown Duration `yaml:"connectionCooldown" default:"1s"`
-	SentinelUsername   string   `yaml:"sentinelUsername" default:""`
-	SentinelPassword   string   `yaml:"sentinelPassword" default:""`
-	SentinelAddresses  []string `yaml:"sentinelAddresses"`
+	Address            string        `yaml:"address"`
+	Username           string        `yaml:"username" default:""`
+	Password           string        `yaml:"password" default:""`
+	Database           int           `yaml:"database" default:"0"`
+	Required           bool          `yaml:"required" default:"false"`
+	ConnectionAttempts int           `yaml:"connectionAttempts" default:"3"`
+	ConnectionCooldown Duration      `yaml:"connectionCooldown" default:"1s"`
+	SentinelUsername   string        `yaml:"sentinelUsername" default:""`
+	SentinelPassword   string        `yaml:"sentinelPassword" default:""`
+	SentinelAddresses  []string      `yaml:"sentinelAddresses"`
+	SyncMode           RedisSyncMode `yaml:"syncMode" default:"pubsub"`
+	StreamMaxLength    int64         `yaml:"streamMaxLength" default:"10000"`
+	InstanceName       string        `yaml:"instanceName"`
 }
 
 type (
@@ -292,20 +325,33 @@ func (c *toEnable) LogConfig(logger *logrus.Entry) {
 }
 
 type SourceLoadingConfig struct {
-	Concurrency        uint              `yaml:"concurrency" default:"4"`
-	MaxErrorsPerSource int               `yaml:"maxErrorsPerSource" default:"5"`
-	RefreshPeriod      Duration          `yaml:"refreshPeriod" default:"4h"`
-	Strategy           StartStrategyType `yaml:"strategy" default:"blocking"`
-	Downloads          DownloaderConfig  `yaml:"downloads"`
+	Concurrency             uint              `yaml:"concurrency" default:"4"`
+	MaxErrorsPerSource      int               `yaml:"maxErrorsPerSource" default:"5"`
+	MaxRegexesPerGroup      uint              `yaml:"maxRegexesPerGroup" default:"1000"`
+	MaxFailedSourcesPercent uint              `yaml:"maxFailedSourcesPercent" default:"50"`
+	RefreshPeriod           Duration          `yaml:"refreshPeriod" default:"4h"`
+	Strategy                StartStrategyType `yaml:"strategy" default:"blocking"`
+	Downloads               DownloaderConfig  `yaml:"downloads"`
+	// AllowGlobs enables sources with glob patterns, which read all matching local files
+	AllowGlobs bool `yaml:"allowGlobs"`
+	// AllowExec enables sources which run a local executable and read its output
+	AllowExec bool `yaml:"allowExec"`
+	// WatchFiles refreshes local file sources as soon as they change, in addition to the periodic refresh
+	WatchFiles bool `yaml:"watchFiles"`
 }
 
 func (c *SourceLoadingConfig) LogConfig(logger *logrus.Entry) {
 	logger.Infof("concurrency = %d", c.Concurrency)
 	logger.Debugf("maxErrorsPerSource = %d", c.MaxErrorsPerSource)
+	logger.Debugf("maxRegexesPerGroup = %d", c.MaxRegexesPerGroup)
+	logger.Debugf("maxFailedSourcesPercent = %d", c.MaxFailedSourcesPercent)
 	logger.Debugf("strategy = %s", c.Strategy)
+	logger.Debugf("allowGlobs = %t", c.AllowGlobs)
+	logger.Debugf("allowExec = %t", c.AllowExec)
+	logger.Infof("watchFiles = %t", c.WatchFiles)
 
 	if c.RefreshPeriod.IsAboveZero() {
-		logger.Infof("refresh = every %s", c.RefreshPeriod)
+		logger.Infof("refresh = %s", describeRefresh(c.RefreshPeriod))
 	} else {
 		logger.Debug("refresh = disabled")
 	}
@@ -314,35 +360,61 @@ func (c *SourceLoadingConfig) LogConfig(logger *logrus.Entry) {
 	log.WithIndent(logger, "  ", c.Downloads.LogConfig)
 }
 
+// describeRefresh returns the refresh period for the log
+func describeRefresh(period Duration) string {
+	if !period.IsAboveZero() {
+		return "disabled"
+	}
+
+	return fmt.Sprintf("every %s", period)
+}
+
+// StartPeriodicRefresh loads the sources according to the strategy and refreshes them every `RefreshPeriod`
 func (c *SourceLoadingConfig) StartPeriodicRefresh(refresh func(context.Context) error, logErr func(error)) error {
-	refreshAndRecover := func(ctx context.Context) (rerr error) {
-		defer func() {
-			if val := recover(); val != nil {
-				rerr = fmt.Errorf("refresh function panicked: %v", val)
-			}
-		}()
+	return c.StartRefreshes(refresh, logErr, PeriodicRefresh{Period: c.RefreshPeriod, Refresh: refresh})
+}
 
-		return refresh(ctx)
-	}
+// PeriodicRefresh is a refresh with its own period, which is disabled if it is not above zero
+type PeriodicRefresh struct {
+	Period  Duration
+	Refresh func(context.Context) error
+}
 
-	err := c.Strategy.do(func() error { return refreshAndRecover(context.Background()) }, logErr)
+// StartRefreshes loads the sources with `initial` according to the strategy,
+// then each periodic refresh runs independently with its own timer.
+func (c *SourceLoadingConfig) StartRefreshes(
+	initial func(context.Context) error, logErr func(error), periodic ...PeriodicRefresh,
+) error {
+	err := c.Strategy.do(func() error { return refreshAndRecover(context.Background(), initial) }, logErr)
 	if err != nil {
 		return err
 	}
 
-	if c.RefreshPeriod > 0 {
-		go c.periodically(refreshAndRecover, logErr)
+	for _, p := range periodic {
+		if p.Period.IsAboveZero() {
+			go periodically(p, logErr)
+		}
 	}
 
 	return nil
 }
 
-func (c *SourceLoadingConfig) periodically(refresh func(context.Context) error, logErr func(error)) {
-	ticker := time.NewTicker(c.RefreshPeriod.ToDuration())
+func refreshAndRecover(ctx context.Context, refresh func(context.Context) error) (rerr error) {
+	defer func() {
+		if val := recover(); val != nil {
+			rerr = fmt.Errorf("refresh function panicked: %v", val)
+		}
+	}()
+
+	return refresh(ctx)
+}
+
+func periodically(p PeriodicRefresh, logErr func(error)) {
+	ticker := time.NewTicker(p.Period.ToDuration())
 	defer ticker.Stop()
 
 	for range ticker.C {
-		err := refresh(context.Background())
+		err := refreshAndRecover(context.Background(), p.Refresh)
 		if err != nil {
 			logErr(err)
 		}
@@ -350,15 +422,17 @@ func (c *SourceLoadingConfig) periodically(refresh func(context.Context) error,
 }
 
 type DownloaderConfig struct {
-	Timeout  Duration `yaml:"timeout" default:"5s"`
-	Attempts uint     `yaml:"attempts" default:"3"`
-	Cooldown Duration `yaml:"cooldown" default:"500ms"`
+	Timeout     Duration `yaml:"timeout" default:"5s"`
+	Attempts    uint     `yaml:"attempts" default:"3"`
+	Cooldown    Duration `yaml:"cooldown" default:"500ms"`
+	MaxCooldown Duration `yaml:"maxCooldown" default:"10s"`
 }
 
 func (c *DownloaderConfig) LogConfig(logger *logrus.Entry) {
 	logger.Infof("timeout = %s", c.Timeout)
 	logger.Infof("attempts = %d", c.Attempts)
 	logger.Debugf("cooldown = %s", c.Cooldown)
+	logger.Debugf("maxCooldown = %s", c.MaxCooldown)
 }
 
 func WithDefaults[T any]() (T, error) {
@@ -489,9 +563,123 @@ func unmarshalConfig(data []byte, cfg *Config) error {
 		logger.Error("configuration uses deprecated options, see warning logs for details")
 	}
 
+	return cfg.validate()
+}
+
+// validate checks constraints of the configuration, which span multiple options
+func (cfg *Config) validate() error {
+	if err := cfg.Upstreams.ValidateGroups(); err != nil {
+		return fmt.Errorf("invalid upstreams: %w", err)
+	}
+
+	if err := cfg.Blocking.validateSources(); err != nil {
+		return fmt.Errorf("invalid blocking lists: %w", err)
+	}
+
+	if err := cfg.HostsFile.Loading.validateSources(cfg.HostsFile.Sources); err != nil {
+		return fmt.Errorf("invalid hostsFile sources: %w", err)
+	}
+
+	for _, source := range cfg.HostsFile.Sources {
+		if source.RefreshPeriod != nil {
+			return fmt.Errorf("invalid hostsFile sources: %s: refreshPeriod is only supported for black- and whitelists, "+
+				"use hostsFile.loading.refreshPeriod", source)
+		}
+	}
+
+	if err := cfg.CustomDNS.Loading.validateSources(cfg.CustomDNS.ZoneFiles); err != nil {
+		return fmt.Errorf("invalid customDNS zone files: %w", err)
+	}
+
+	for _, source := range cfg.CustomDNS.ZoneFiles {
+		if source.RefreshPeriod != nil {
+			return fmt.Errorf("invalid customDNS zone files: %s: refreshPeriod is only supported for black- and whitelists, "+
+				"use customDNS.loading.refreshPeriod", source)
+		}
+
+		if source.TTL != nil {
+			return fmt.Errorf("invalid customDNS zone files: %s: ttl is only supported for hostsFile sources, "+
+				"use the TTLs of the zone file", source)
+		}
+	}
+
+	if _, err := cfg.CustomDNS.RegexRules(); err != nil {
+		return fmt.Errorf("invalid customDNS rewrite: %w", err)
+	}
+
+	if err := cfg.CustomDNS.validateClientGroups(); err != nil {
+		return fmt.Errorf("invalid customDNS client groups: %w", err)
+	}
+
+	if err := cfg.Filtering.validate(); err != nil {
+		return fmt.Errorf("invalid filtering: %w", err)
+	}
+
+	if _, err := cfg.RateLimit.ExemptNetworks(); err != nil {
+		return fmt.Errorf("invalid rateLimit: %w", err)
+	}
+
+	if err := cfg.ACL.validate(); err != nil {
+		return fmt.Errorf("invalid acl: %w", err)
+	}
+
+	if err := cfg.RebindProtection.validate(); err != nil {
+		return fmt.Errorf("invalid rebindProtection: %w", err)
+	}
+
+	if err := cfg.ECS.validate(); err != nil {
+		return fmt.Errorf("invalid ecs: %w", err)
+	}
+
+	if err := cfg.Cookies.validate(); err != nil {
+		return fmt.Errorf("invalid cookies: %w", err)
+	}
+
+	if err := cfg.DNS64.validate(); err != nil {
+		return fmt.Errorf("invalid dns64: %w", err)
+	}
+
+	if err := cfg.DNSSEC.validate(); err != nil {
+		return fmt.Errorf("invalid dnssec: %w", err)
+	}
+
+	if err := cfg.Watchdog.validate(); err != nil {
+		return fmt.Errorf("invalid watchdog: %w", err)
+	}
+
+	if err := cfg.ClientLookup.Loading.validateSources(cfg.ClientLookup.LeaseFiles); err != nil {
+		return fmt.Errorf("invalid clientLookup lease files: %w", err)
+	}
+
+	for _, source := range cfg.ClientLookup.LeaseFiles {
+		if source.RefreshPeriod != nil || source.TTL != nil {
+			return fmt.Errorf("invalid clientLookup lease files: %s: refreshPeriod and ttl aren't supported, "+
+				"use clientLookup.loading.refreshPeriod", source)
+		}
+	}
+
+	if _, err := cfg.Conditional.RegexRules(); err != nil {
+		return fmt.Errorf("invalid conditional rewrite: %w", err)
+	}
+
+	for name, profile := range cfg.Profiles {
+		if err := profile.Upstreams.ValidateGroups(); err != nil {
+			return fmt.Errorf("invalid upstreams of profile '%s': %w", name, err)
+		}
+
+		if err := profile.Blocking.validateSources(); err != nil {
+			return fmt.Errorf("invalid blocking lists of profile '%s': %w", name, err)
+		}
+	}
+
 	return nil
 }
 
+// UDPSize returns the EDNS UDP size advertised to clients and upstreams, sizes below 512 are treated as 512 (RFC 6891)
+func (cfg *Config) UDPSize() uint16 {
+	return max(cfg.EDNSUDPSize, dns.MinMsgSize)
+}
+
 func (cfg *Config) migrate(logger *logrus.Entry) bool {
 	usesDepredOpts := Migrate(logger, "", cfg.Deprecated, map[string]Migrator{
 		"upstream":        Move(To("upstreams.groups", &cfg.Upstreams)),
@@ -514,10 +702,16 @@ func (cfg *Config) migrate(logger *logrus.Entry) bool {
 	usesDepredOpts = cfg.Blocking.migrate(logger) || usesDepredOpts
 	usesDepredOpts = cfg.HostsFile.migrate(logger) || usesDepredOpts
 
+	for name, profile := range cfg.Profiles {
+		usesDepredOpts = profile.Blocking.migrate(logger) || usesDepredOpts
+		cfg.Profiles[name] = profile
+	}
+
 	return usesDepredOpts
 }
 
-// GetConfig returns the current config
+// GetConfig returns the current config.
+// It's only meant for the composition root: resolvers get their configuration at construction time.
 func GetConfig() *Config {
 	cfgLock.RLock()
 	defer cfgLock.RUnlock()
@@ -388,6 +388,88 @@ func (x *QueryLogType) UnmarshalText(text []byte) error {
 	return nil
 }
 
+const (
+	// RedisSyncModePubsub is a RedisSyncMode of type Pubsub.
+	// publish cache entries, instances which are down miss them
+	RedisSyncModePubsub RedisSyncMode = iota
+	// RedisSyncModeStream is a RedisSyncMode of type Stream.
+	// append cache entries to a stream, instances catch up after a restart
+	RedisSyncModeStream
+)
+
+var ErrInvalidRedisSyncMode = fmt.Errorf("not a valid RedisSyncMode, try [%s]", strings.Join(_RedisSyncModeNames, ", "))
+
+const _RedisSyncModeName = "pubsubstream"
+
+var _RedisSyncModeNames = []string{
+	_RedisSyncModeName[0:6],
+	_RedisSyncModeName[6:12],
+}
+
+// RedisSyncModeNames returns a list of possible string values of RedisSyncMode.
+func RedisSyncModeNames() []string {
+	tmp := make([]string, len(_RedisSyncModeNames))
+	copy(tmp, _RedisSyncModeNames)
+	return tmp
+}
+
+// RedisSyncModeValues returns a list of the values for RedisSyncMode
+func RedisSyncModeValues() []RedisSyncMode {
+	return []RedisSyncMode{
+		RedisSyncModePubsub,
+		RedisSyncModeStream,
+	}
+}
+
+var _RedisSyncModeMap = map[RedisSyncMode]string{
+	RedisSyncModePubsub: _RedisSyncModeName[0:6],
+	RedisSyncModeStream: _RedisSyncModeName[6:12],
+}
+
+// String implements the Stringer interface.
+func (x RedisSyncMode) String() string {
+	if str, ok := _RedisSyncModeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("RedisSyncMode(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x RedisSyncMode) IsValid() bool {
+	_, ok := _RedisSyncModeMap[x]
+	return ok
+}
+
+var _RedisSyncModeValue = map[string]RedisSyncMode{
+	_RedisSyncModeName[0:6]:  RedisSyncModePubsub,
+	_RedisSyncModeName[6:12]: RedisSyncModeStream,
+}
+
+// ParseRedisSyncMode attempts to convert a string to a RedisSyncMode.
+func ParseRedisSyncMode(name string) (RedisSyncMode, error) {
+	if x, ok := _RedisSyncModeValue[name]; ok {
+		return x, nil
+	}
+	return RedisSyncMode(0), fmt.Errorf("%s is %w", name, ErrInvalidRedisSyncMode)
+}
+
+// MarshalText implements the text marshaller method.
+func (x RedisSyncMode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *RedisSyncMode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseRedisSyncMode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// StartStrategyTypeBlocking is a StartStrategyType of type Blocking.
 	// synchronously download blocking lists on startup
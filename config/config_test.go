@@ -240,7 +240,7 @@ blocking:
     multiple.resolvers: 192.168.178.1,wrongprotocol:4.4.4.4:53`
 				err := unmarshalConfig([]byte(data), &cfg)
 				Expect(err).Should(HaveOccurred())
-				Expect(err.Error()).Should(ContainSubstring("wrong host name 'wrongprotocol:4.4.4.4:53'"))
+				Expect(err.Error()).Should(ContainSubstring("position 1: unknown protocol 'wrongprotocol'"))
 			})
 		})
 		When("Wrong upstreams are defined", func() {
@@ -268,6 +268,36 @@ blocking:
 				Expect(err.Error()).Should(ContainSubstring("unknown DNS query type: 'invalidqtype'"))
 			})
 		})
+		When("filtering rules are defined", func() {
+			It("should parse them next to the query types", func() {
+				cfg := Config{}
+				data := `filtering:
+  queryTypes:
+    - MX
+  rules:
+    - queryTypes:
+        - AAAA
+      domains:
+        - "*.corp.example.com"
+`
+				Expect(unmarshalConfig([]byte(data), &cfg)).Should(Succeed())
+				Expect(cfg.Filtering.QueryTypes).Should(Equal(NewQTypeSet(dns.Type(dns.TypeMX))))
+				Expect(cfg.Filtering.Rules).Should(Equal([]FilteringRule{
+					{QueryTypes: NewQTypeSet(dns.Type(dns.TypeAAAA)), Domains: []string{"*.corp.example.com"}},
+				}))
+			})
+
+			It("should fail for an invalid rule", func() {
+				cfg := Config{}
+				data := `filtering:
+  rules:
+    - queryTypes:
+        - AAAA
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid filtering: rule #1: no domains")))
+			})
+		})
 
 		When("bootstrapDns is defined", func() {
 			It("should be backwards compatible to 'single IP syntax'", func() {
@@ -278,6 +308,15 @@ blocking:
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(cfg.BootstrapDNS[0].Upstream.Host).Should(Equal("0.0.0.0"))
 			})
+			It("should accept a link-local IPv6 address with zone", func() {
+				cfg := Config{}
+				data := "bootstrapDns: fe80::1%eth0"
+
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cfg.BootstrapDNS[0].Upstream.Host).Should(Equal("fe80::1"))
+				Expect(cfg.BootstrapDNS[0].Upstream.Zone).Should(Equal("eth0"))
+			})
 			It("should be backwards compatible to 'single item definition'", func() {
 				cfg := Config{}
 				data := `
@@ -311,6 +350,310 @@ bootstrapDns:
 			})
 		})
 
+		When("upstreams with options are defined", func() {
+			It("should enable padding for encrypted upstreams", func() {
+				cfg := Config{}
+				data := `
+upstreams:
+  groups:
+    default:
+      - 1.1.1.1
+      - upstream: tcp-tls:dns.example.com
+        padding: true
+      - upstream: https://dns.example.com/dns-query
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				upstreams := cfg.Upstreams.Groups["default"]
+				Expect(upstreams).Should(HaveLen(3))
+				Expect(upstreams[0].Padding).Should(BeFalse())
+				Expect(upstreams[1].Host).Should(Equal("dns.example.com"))
+				Expect(upstreams[1].Padding).Should(BeTrue())
+				Expect(upstreams[2].Net).Should(Equal(NetProtocolHttps))
+				Expect(upstreams[2].Padding).Should(BeFalse())
+			})
+
+			It("should ignore padding for unencrypted upstreams", func() {
+				cfg := Config{}
+				data := `
+upstreams:
+  groups:
+    default:
+      - upstream: 1.1.1.1
+        padding: true
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cfg.Upstreams.Groups["default"][0].Padding).Should(BeFalse())
+			})
+
+			It("should fail for unknown options", func() {
+				cfg := Config{}
+				data := `
+upstreams:
+  groups:
+    default:
+      - upstream: tcp-tls:dns.example.com
+        unknown: true
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		When("an upstream group requires encryption", func() {
+			It("should accept encrypted upstreams", func() {
+				cfg := Config{}
+				data := `
+upstreams:
+  groups:
+    personal:
+      - tcp-tls:dns.example.com
+      - https://dns.example.com/dns-query
+  groupOptions:
+    personal:
+      requireEncryption: true
+`
+				Expect(unmarshalConfig([]byte(data), &cfg)).Should(Succeed())
+				Expect(cfg.Upstreams.RequiresEncryption("personal")).Should(BeTrue())
+			})
+
+			It("should reject unencrypted upstreams", func() {
+				cfg := Config{}
+				data := `
+upstreams:
+  groups:
+    personal:
+      - tcp-tls:dns.example.com
+      - 1.1.1.1
+  groupOptions:
+    personal:
+      requireEncryption: true
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring(
+					"upstream 'tcp+udp:1.1.1.1' in group 'personal' is not encrypted")))
+			})
+
+			It("should reject unencrypted upstreams of profiles", func() {
+				cfg := Config{}
+				data := `
+profiles:
+  kids:
+    ports:
+      dns: 5353
+    upstreams:
+      groups:
+        default:
+          - 1.1.1.1
+      groupOptions:
+        default:
+          requireEncryption: true
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("profile 'kids'")))
+			})
+		})
+
+		When("local sources are used", func() {
+			It("should require the opt-in of the resolver", func() {
+				cfg := Config{}
+				data := `
+blocking:
+  blackLists:
+    local:
+      - exec:///usr/local/bin/gen-list
+hostsFile:
+  loading:
+    allowExec: true
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid blocking lists: group 'local'")))
+			})
+
+			It("should check the hosts file sources", func() {
+				cfg := Config{}
+				data := `
+hostsFile:
+  sources:
+    - /etc/hosts.d/*
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid hostsFile sources")))
+
+				cfg = Config{}
+				data += `
+  loading:
+    allowGlobs: true
+`
+				Expect(unmarshalConfig([]byte(data), &cfg)).Should(Succeed())
+			})
+		})
+
+		When("a hosts file source has a TTL", func() {
+			It("should use it", func() {
+				cfg := Config{}
+				data := `
+hostsFile:
+  sources:
+    - /etc/hosts
+    - source: http://router.lan/hosts
+      ttl: 30s
+`
+				Expect(unmarshalConfig([]byte(data), &cfg)).Should(Succeed())
+				Expect(cfg.HostsFile.Sources[0].TTL).Should(BeNil())
+				Expect(*cfg.HostsFile.Sources[1].TTL).Should(Equal(Duration(30 * time.Second)))
+			})
+		})
+
+		When("a hosts file source has a refresh period", func() {
+			It("should fail", func() {
+				cfg := Config{}
+				data := `
+hostsFile:
+  sources:
+    - source: /etc/hosts
+      refreshPeriod: 1m
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("refreshPeriod is only supported for black- and whitelists")))
+			})
+		})
+
+		When("customDNS zone files are used", func() {
+			It("should check the sources", func() {
+				cfg := Config{}
+				data := `
+customDNS:
+  zoneFiles:
+    - /etc/blocky/zones/*
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid customDNS zone files")))
+
+				cfg = Config{}
+				data += `
+  loading:
+    allowGlobs: true
+`
+				Expect(unmarshalConfig([]byte(data), &cfg)).Should(Succeed())
+				Expect(cfg.CustomDNS.IsEnabled()).Should(BeTrue())
+			})
+
+			It("should reject a refresh period per source", func() {
+				cfg := Config{}
+				data := `
+customDNS:
+  zoneFiles:
+    - source: /etc/blocky/home.zone
+      refreshPeriod: 1m
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("use customDNS.loading.refreshPeriod")))
+			})
+
+			It("should reject a TTL per source", func() {
+				cfg := Config{}
+				data := `
+customDNS:
+  zoneFiles:
+    - source: /etc/blocky/home.zone
+      ttl: 1m
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("use the TTLs of the zone file")))
+			})
+		})
+
+		When("customDNS client groups overlap", func() {
+			It("should fail", func() {
+				cfg := Config{}
+				data := `
+customDNS:
+  clientGroups:
+    10.6.0.0/24:
+      mapping:
+        nas.home: 10.6.0.10
+    10.6.0.1/24:
+      mapping:
+        nas.home: 10.6.0.11
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid customDNS client groups")))
+			})
+		})
+
+		When("clientLookup lease files are used", func() {
+			It("should check the sources", func() {
+				cfg := Config{}
+				data := `
+clientLookup:
+  leaseFiles:
+    - /var/lib/dhcp/*.leases
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid clientLookup lease files")))
+
+				cfg = Config{}
+				data += `
+  loading:
+    allowGlobs: true
+`
+				Expect(unmarshalConfig([]byte(data), &cfg)).Should(Succeed())
+				Expect(cfg.ClientLookup.IsEnabled()).Should(BeTrue())
+			})
+
+			It("should reject a refresh period per source", func() {
+				cfg := Config{}
+				data := `
+clientLookup:
+  leaseFiles:
+    - source: /var/lib/misc/dnsmasq.leases
+      refreshPeriod: 1m
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("use clientLookup.loading.refreshPeriod")))
+			})
+		})
+
+		When("a rewrite regex is invalid", func() {
+			It("should fail for custom DNS", func() {
+				cfg := Config{}
+				data := `
+customDNS:
+  rewrite:
+    /^(.*\.old\.lan$/: $1.new.lan
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid customDNS rewrite")))
+			})
+
+			It("should fail for conditional", func() {
+				cfg := Config{}
+				data := `
+conditional:
+  rewrite:
+    /^(.*)\.old\.lan$/: ""
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid conditional rewrite")))
+			})
+		})
+
+		When("the watchdog interval is 0", func() {
+			It("should return error", func() {
+				cfg := Config{}
+				data := `
+watchdog:
+  enable: true
+  interval: 0
+`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(MatchError(ContainSubstring("invalid watchdog: interval must be greater than 0")))
+			})
+		})
+
 		When("config is not YAML", func() {
 			It("should return error", func() {
 				cfg := Config{}
@@ -479,6 +822,99 @@ bootstrapDns:
 			"[2620:fe::9]:55",
 			Upstream{Net: NetProtocolTcpUdp, Host: "2620:fe::9", Port: 55},
 			false),
+		Entry("link-local IPv6 with zone",
+			"fe80::1%eth0",
+			Upstream{Net: NetProtocolTcpUdp, Host: "fe80::1", Zone: "eth0", Port: 53},
+			false),
+		Entry("link-local IPv6 with zone and port",
+			"tcp+udp:[fe80::1%eth0]:5353",
+			Upstream{Net: NetProtocolTcpUdp, Host: "fe80::1", Zone: "eth0", Port: 5353},
+			false),
+		Entry("tcp-tls link-local IPv6 with zone",
+			"tcp-tls:[fe80::1%br-lan]#router.lan",
+			Upstream{Net: NetProtocolTcpTls, Host: "fe80::1", Zone: "br-lan", Port: 853, CommonName: "router.lan"},
+			false),
+		Entry("https with zone",
+			"https://[fe80::1%eth0]/dns-query",
+			nil,
+			true),
+	)
+
+	DescribeTable("Upstream notations of other tools",
+		func(in, canonical string) {
+			upstream, err := ParseUpstream(in)
+			Expect(err).Should(Succeed(), in)
+			Expect(upstream.String()).Should(Equal(canonical), in)
+
+			roundTripped, err := ParseUpstream(canonical)
+			Expect(err).Should(Succeed())
+			Expect(roundTripped).Should(Equal(upstream))
+		},
+		Entry("plain udp", "udp://8.8.8.8", "tcp+udp:8.8.8.8"),
+		Entry("plain tcp with port", "tcp://1.1.1.1:5353", "tcp+udp:1.1.1.1:5353"),
+		Entry("tcp+udp scheme", "tcp+udp://1.1.1.1", "tcp+udp:1.1.1.1"),
+		Entry("DoT", "tls://dns.quad9.net", "tcp-tls:dns.quad9.net"),
+		Entry("DoT with default port", "tls://1.1.1.1:853", "tcp-tls:1.1.1.1"),
+		Entry("DoT with upper case scheme", "TLS://1.1.1.1", "tcp-tls:1.1.1.1"),
+		Entry("DoT IPv6", "tls://[2606:4700:4700::1111]", "tcp-tls:[2606:4700:4700::1111]"),
+		Entry("tcp-tls scheme", "tcp-tls://1.1.1.1:8853", "tcp-tls:1.1.1.1:8853"),
+		Entry("DoT without protocol", "1.1.1.1:853", "tcp-tls:1.1.1.1"),
+		Entry("DoT IPv6 without protocol", "[2606:4700:4700::1111]:853", "tcp-tls:[2606:4700:4700::1111]"),
+		Entry("DoH", "https://dns.google/dns-query", "https://dns.google/dns-query"),
+		Entry("DoH with port", "https://dns.google:8443/dns-query", "https://dns.google:8443/dns-query"),
+		Entry("DoH with port after the path", "https://dns.google/dns-query:8443", "https://dns.google:8443/dns-query"),
+		Entry("DoH with default port after the path", "https://dns.google/dns-query:443", "https://dns.google/dns-query"),
+		Entry("surrounding spaces", " 9.9.9.9 ", "tcp+udp:9.9.9.9"),
+		Entry("plain udp with zone", "udp://[fe80::1%eth0]:53", "tcp+udp:[fe80::1%eth0]"),
+	)
+
+	DescribeTable("Upstream parsing errors",
+		func(in, wantErr string) {
+			_, err := ParseUpstream(in)
+			Expect(err).Should(MatchError(wantErr), in)
+		},
+		Entry("empty", "", "position 1: empty upstream"),
+		Entry("DoQ", "quic://dns.adguard-dns.com", "position 1: DNS-over-QUIC isn't supported"),
+		Entry("DoH3", "h3://dns.google/dns-query", "position 1: DNS-over-HTTP/3 isn't supported"),
+		Entry("DNS stamp", "sdns://AgcAAAAAAAAABzEuMC4wLjE", "position 1: DNS stamps aren't supported"),
+		Entry("unknown scheme", "doh://dns.google",
+			"position 1: unknown protocol 'doh', supported are tcp+udp, tcp-tls, https and URL style schemes like tls://"),
+		Entry("unknown protocol", "udp:1.1.1.1:53",
+			"position 1: unknown protocol 'udp', supported are tcp+udp, tcp-tls, https and URL style schemes like tls://"),
+		Entry("missing host", "tls://", "position 7: missing host"),
+		Entry("not numeric port", "tcp-tls:1.1.1.1:A636", "position 17: invalid port 'A636', must be a number between 1 and 65535"),
+		Entry("port out of range", "1.1.1.1:65536", "position 9: invalid port '65536', must be a number between 1 and 65535"),
+		Entry("port 0", "1.1.1.1:0", "position 9: invalid port '0', must be a number between 1 and 65535"),
+		Entry("missing port", "1.1.1.1:", "position 9: missing port after ':'"),
+		Entry("path without https", "tcp-tls:1.1.1.1/dns-query", "position 16: a path is only supported for https"),
+		Entry("invalid host", "tls://host$name", "position 7: invalid host name 'host$name'"),
+		Entry("unclosed IPv6", "[2620:fe::9:53", "position 1: missing ']' after IPv6 address"),
+		Entry("IPv6 without brackets but port", "tcp+udp:2620:fe::9/x", "position 19: a path is only supported for https"),
+		Entry("garbage after IPv6", "[2620:fe::9]53", "position 13: unexpected '53' after IPv6 address"),
+		Entry("empty common name", "tcp-tls:1.1.1.1#", "position 16: empty common name after '#'"),
+		Entry("empty zone", "[fe80::1%]:53", "position 9: empty zone after '%'"),
+		Entry("zone for IPv4", "1.1.1.1%eth0", "position 8: a zone is only supported for IPv6 addresses"),
+		Entry("zone for host name", "tls://dns.quad9.net%eth0", "position 20: a zone is only supported for IPv6 addresses"),
+		Entry("zone for https", "https://[fe80::1%eth0]/dns-query", "position 17: a zone isn't supported for https"),
+		Entry("zone with port without brackets", "fe80::1%eth0:53",
+			"position 8: invalid zone 'eth0:53', IPv6 addresses with port must be enclosed in []"),
+	)
+
+	DescribeTable("Upstream corrections",
+		func(in, wantCorrection string) {
+			_, corrections, err := parseUpstream(in)
+			Expect(err).Should(Succeed())
+
+			if wantCorrection == "" {
+				Expect(corrections).Should(BeEmpty())
+			} else {
+				Expect(corrections).Should(ConsistOf(wantCorrection))
+			}
+		},
+		Entry("valid", "tls://1.1.1.1", ""),
+		Entry("port after path", "https://dns.google/dns-query:443", "the port must be placed before the path"),
+		Entry("DoT port without protocol", "1.1.1.1:853", "port 853 is used for DNS-over-TLS"),
+		Entry("DoT port with explicit protocol", "tcp+udp:1.1.1.1:853", ""),
 	)
 
 	DescribeTable("Upstream string representation",
@@ -541,6 +977,10 @@ bootstrapDns:
 			Upstream{Net: NetProtocolTcpTls, Host: "fd00::6cd4:d7e0:d99d:2952", Port: 853},
 			"tcp-tls:[fd00::6cd4:d7e0:d99d:2952]",
 		),
+		Entry("tcp+udp IPv6 with zone",
+			Upstream{Net: NetProtocolTcpUdp, Host: "fe80::1", Zone: "eth0", Port: 53},
+			"tcp+udp:[fe80::1%eth0]",
+		),
 	)
 
 	Describe("SourceLoadingConfig", func() {
@@ -560,6 +1000,7 @@ bootstrapDns:
 				Expect(hook.Calls).ShouldNot(BeEmpty())
 				Expect(hook.Messages[0]).Should(Equal("concurrency = 12"))
 				Expect(hook.Messages).Should(ContainElement(ContainSubstring("refresh = every 1 hour")))
+				Expect(hook.Messages).Should(ContainElement(Equal("watchFiles = false")))
 			})
 			When("refresh is disabled", func() {
 				BeforeEach(func() {
@@ -736,6 +1177,49 @@ bootstrapDns:
 			Eventually(calls, "50ms").Should(Receive(Equal(int32(2))))
 			Eventually(calls, "50ms").Should(Receive(Equal(int32(3))))
 		})
+
+		It("runs each periodic refresh with its own period", func() {
+			sut := SourceLoadingConfig{
+				Strategy:      StartStrategyTypeBlocking,
+				RefreshPeriod: Duration(time.Hour),
+			}
+
+			var initial, fast, disabled atomic.Int32
+
+			count := func(counter *atomic.Int32) func(context.Context) error {
+				return func(context.Context) error {
+					counter.Add(1)
+
+					return nil
+				}
+			}
+
+			err := sut.StartRefreshes(count(&initial), func(err error) {
+				Fail(err.Error())
+			},
+				PeriodicRefresh{Period: Duration(5 * time.Millisecond), Refresh: count(&fast)},
+				PeriodicRefresh{Period: Duration(-1), Refresh: count(&disabled)},
+			)
+
+			Expect(err).Should(Succeed())
+			Expect(initial.Load()).Should(Equal(int32(1)))
+			Eventually(fast.Load, "50ms").Should(BeNumerically(">=", 2))
+			Consistently(initial.Load, "20ms").Should(Equal(int32(1)))
+			Expect(disabled.Load()).Should(BeZero())
+		})
+	})
+
+	Describe("UDPSize", func() {
+		It("should default to 1232", func() {
+			cfg, err := WithDefaults[Config]()
+			Expect(err).Should(Succeed())
+			Expect(cfg.UDPSize()).Should(BeNumerically("==", 1232))
+		})
+
+		It("should treat sizes below 512 as 512", func() {
+			cfg := Config{EDNSUDPSize: 100}
+			Expect(cfg.UDPSize()).Should(BeNumerically("==", dns.MinMsgSize))
+		})
 	})
 
 	Describe("WithDefaults", func() {
@@ -791,6 +1275,7 @@ func defaultTestFileConfig() {
 	Expect(config.DoHUserAgent).Should(Equal("testBlocky"))
 	Expect(config.MinTLSServeVer).Should(Equal("1.3"))
 	Expect(config.StartVerifyUpstream).Should(BeFalse())
+	Expect(config.MaxAnswerAge).Should(Equal(Duration(5 * time.Second)))
 
 	Expect(GetConfig()).Should(Not(BeNil()))
 }
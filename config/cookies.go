@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cookieMinSecretRotation is the lifetime of server cookies (RFC 9018), they must stay valid after a rotation
+const cookieMinSecretRotation = time.Hour
+
+// CookiesConfig configuration of the DNS cookies (RFC 7873)
+type CookiesConfig struct {
+	// Upstream sends a client cookie with the UDP queries to the upstreams and echoes their server cookie
+	Upstream bool `yaml:"upstream" default:"true"`
+	// Clients answers the cookies of the clients with a server cookie,
+	// clients with a valid server cookie are exempt from the rate limiting
+	Clients bool `yaml:"clients" default:"false"`
+	// SecretRotation is the interval to replace the secrets the cookies are derived from
+	SecretRotation Duration `yaml:"secretRotation" default:"24h"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *CookiesConfig) IsEnabled() bool {
+	return c.Upstream || c.Clients
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *CookiesConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("upstream = %t", c.Upstream)
+	logger.Infof("clients = %t", c.Clients)
+	logger.Infof("secretRotation = %s", c.SecretRotation)
+}
+
+// validate checks that the server cookies stay valid after a rotation of the secret
+func (c *CookiesConfig) validate() error {
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	if c.SecretRotation.ToDuration() < cookieMinSecretRotation {
+		return fmt.Errorf("secretRotation %s must be at least %s", c.SecretRotation, Duration(cookieMinSecretRotation))
+	}
+
+	return nil
+}
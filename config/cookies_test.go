@@ -0,0 +1,69 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CookiesConfig", func() {
+	var cfg CookiesConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[CookiesConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should send cookies to the upstreams by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+			Expect(cfg.Upstream).Should(BeTrue())
+			Expect(cfg.Clients).Should(BeFalse())
+			Expect(cfg.SecretRotation).Should(Equal(Duration(24 * time.Hour)))
+		})
+
+		When("nothing is configured", func() {
+			It("should be false", func() {
+				cfg.Upstream = false
+
+				Expect(cfg.IsEnabled()).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept the defaults", func() {
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should fail for short rotation intervals", func() {
+			cfg.SecretRotation = Duration(time.Minute)
+
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("secretRotation 1 minute")))
+		})
+
+		It("should ignore the rotation interval, if disabled", func() {
+			cfg.Upstream = false
+			cfg.SecretRotation = 0
+
+			Expect(cfg.validate()).Should(Succeed())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"upstream = true",
+				"clients = false",
+				"secretRotation = 1 day",
+			))
+		})
+	})
+})
@@ -1,44 +1,216 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/trie"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
 )
 
 // CustomDNSConfig custom DNS configuration
 type CustomDNSConfig struct {
 	RewriterConfig      `yaml:",inline"`
-	CustomTTL           Duration         `yaml:"customTTL" default:"1h"`
-	Mapping             CustomDNSMapping `yaml:"mapping"`
-	FilterUnmappedTypes bool             `yaml:"filterUnmappedTypes" default:"true"`
+	CustomTTL           Duration            `yaml:"customTTL" default:"1h"`
+	Mapping             CustomDNSMapping    `yaml:"mapping"`
+	FilterUnmappedTypes bool                `yaml:"filterUnmappedTypes" default:"true"`
+	CreatePTR           bool                `yaml:"createPTR" default:"true"`
+	ZoneFiles           []BytesSource       `yaml:"zoneFiles"`
+	Loading             SourceLoadingConfig `yaml:"loading"`
+	// RuntimeRecordsFile persists the records set via API, they are kept in memory only if empty
+	RuntimeRecordsFile string `yaml:"runtimeRecordsFile"`
+	// ClientGroups maps a client identifier (name with wildcards, IP or CIDR) to the mapping for these clients
+	ClientGroups map[string]CustomDNSClientGroup `yaml:"clientGroups"`
+}
+
+// CustomDNSClientGroup is the mapping which answers the clients of a group instead of the default mapping
+type CustomDNSClientGroup struct {
+	Mapping CustomDNSMapping `yaml:"mapping"`
 }
 
 // CustomDNSMapping mapping for the custom DNS configuration
 type CustomDNSMapping struct {
 	HostIPs map[string][]net.IP `yaml:"hostIPs"`
+	// CNAMEs maps a domain to the target of its CNAME record (lower case and without trailing dot)
+	CNAMEs map[string]string `yaml:"cnames"`
+	// Records maps a domain to records of any type, they only answer queries for exactly this domain
+	Records map[string][]dns.RR `yaml:"records"`
+	// TTLs overrides the customTTL for the domain (lower case and without trailing dot)
+	TTLs map[string]Duration `yaml:"ttls"`
 }
 
+// ttlOption is appended to a value of the mapping to set the TTL of the domain: `192.168.178.3?ttl=30s`
+const ttlOption = "?ttl="
+
+// hoursPerDay allows TTLs in days, which time.ParseDuration doesn't support
+const hoursPerDay = 24
+
 // IsEnabled implements `config.Configurable`.
 func (c *CustomDNSConfig) IsEnabled() bool {
-	return len(c.Mapping.HostIPs) != 0
+	return len(c.Mapping.HostIPs) != 0 || len(c.Mapping.CNAMEs) != 0 || len(c.Mapping.Records) != 0 ||
+		len(c.ZoneFiles) != 0 || c.RuntimeRecordsFile != "" || len(c.ClientGroups) != 0
 }
 
 // LogConfig implements `config.Configurable`.
 func (c *CustomDNSConfig) LogConfig(logger *logrus.Entry) {
 	logger.Debugf("TTL = %s", c.CustomTTL)
 	logger.Debugf("filterUnmappedTypes = %t", c.FilterUnmappedTypes)
+	logger.Debugf("createPTR = %t", c.CreatePTR)
+
+	if c.RuntimeRecordsFile != "" {
+		logger.Infof("runtimeRecordsFile = %s", c.RuntimeRecordsFile)
+	}
 
 	logger.Info("mapping:")
+	c.logMapping(logger, &c.Mapping, "  ")
+
+	if len(c.ClientGroups) != 0 {
+		logger.Info("client groups:")
+
+		for _, client := range c.sortedClientGroups() {
+			logger.Infof("  %s:", client)
+
+			mapping := c.ClientGroups[client].Mapping
+			c.logMapping(logger, &mapping, "    ")
+		}
+	}
+
+	if len(c.ZoneFiles) != 0 {
+		logger.Info("zone files:")
+
+		for _, source := range c.ZoneFiles {
+			logger.Infof("  - %s", source)
+		}
+
+		logger.Info("loading:")
+		log.WithIndent(logger, "  ", c.Loading.LogConfig)
+	}
+}
+
+func (c *CustomDNSConfig) logMapping(logger *logrus.Entry, mapping *CustomDNSMapping, indent string) {
+	for key, val := range mapping.HostIPs {
+		logger.Infof("%s%s = %s%s", indent, key, val, c.describeTTL(mapping, key))
+	}
+
+	for key, val := range mapping.CNAMEs {
+		logger.Infof("%s%s = CNAME %s%s", indent, key, val, c.describeTTL(mapping, key))
+	}
+
+	for key, records := range mapping.Records {
+		for _, rr := range records {
+			logger.Infof("%s%s = %s%s", indent, key, RecordData(rr), c.describeTTL(mapping, key))
+		}
+	}
+}
+
+// TTL returns the TTL of a domain of the mapping, the customTTL if it has no own TTL
+func (c *CustomDNSConfig) TTL(domain string) Duration {
+	return c.MappingTTL(&c.Mapping, domain)
+}
+
+// MappingTTL returns the TTL of a domain of the mapping or of a client group mapping,
+// the customTTL if it has no own TTL
+func (c *CustomDNSConfig) MappingTTL(mapping *CustomDNSMapping, domain string) Duration {
+	if ttl, found := mapping.TTLs[util.NormalizeDomain(domain)]; found {
+		return ttl
+	}
+
+	return c.CustomTTL
+}
+
+// describeTTL returns the TTL of the domain for the log, if it differs from the customTTL
+func (c *CustomDNSConfig) describeTTL(mapping *CustomDNSMapping, domain string) string {
+	ttl := c.MappingTTL(mapping, domain)
+	if ttl == c.CustomTTL {
+		return ""
+	}
+
+	return fmt.Sprintf(" (TTL %s)", ttl)
+}
+
+// sortedClientGroups returns the client identifiers of the client groups in alphabetical order
+func (c *CustomDNSConfig) sortedClientGroups() []string {
+	clients := make([]string, 0, len(c.ClientGroups))
+
+	for client := range c.ClientGroups {
+		clients = append(clients, client)
+	}
+
+	sort.Strings(clients)
+
+	return clients
+}
+
+// validateClientGroups checks the client identifiers of the client groups. Identifiers which always match the same
+// clients, e.g. names differing only in case or two notations of the same network, are rejected: neither of them
+// would be more specific.
+func (c *CustomDNSConfig) validateClientGroups() error {
+	normalized := make(map[string]string, len(c.ClientGroups))
+
+	for _, client := range c.sortedClientGroups() {
+		key, err := normalizeClientIdentifier(client)
+		if err != nil {
+			return err
+		}
+
+		if other, found := normalized[key]; found {
+			return fmt.Errorf("client groups '%s' and '%s' match the same clients", other, client)
+		}
+
+		normalized[key] = client
+	}
+
+	return nil
+}
+
+// normalizeClientIdentifier returns the IP, network or lower case name which the client identifier matches
+func normalizeClientIdentifier(client string) (string, error) {
+	client = strings.TrimSpace(client)
+
+	if client == "" {
+		return "", errors.New("empty client group")
+	}
+
+	if strings.Contains(client, "/") {
+		_, network, err := net.ParseCIDR(client)
+		if err != nil {
+			return "", fmt.Errorf("invalid client group '%s': %w", client, err)
+		}
+
+		if ones, bits := network.Mask.Size(); ones == bits {
+			// a single IP
+			return network.IP.String(), nil
+		}
+
+		return network.String(), nil
+	}
 
-	for key, val := range c.Mapping.HostIPs {
-		logger.Infof("  %s = %s", key, val)
+	if ip := net.ParseIP(client); ip != nil {
+		return ip.String(), nil
 	}
+
+	name := strings.ToLower(client)
+
+	if _, err := filepath.Match(name, ""); err != nil {
+		return "", fmt.Errorf("invalid client group '%s': %w", client, err)
+	}
+
+	return name, nil
 }
 
 // UnmarshalYAML implements `yaml.Unmarshaler`.
+// A value which is a single domain name instead of IP addresses is the target of a CNAME record.
+// A value starting with a record type contains records in zone file syntax without owner, one per line.
+// Each value can end with `?ttl=<duration>` to override the customTTL of the domain.
 func (c *CustomDNSMapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var input map[string]string
 	if err := unmarshal(&input); err != nil {
@@ -46,8 +218,45 @@ func (c *CustomDNSMapping) UnmarshalYAML(unmarshal func(interface{}) error) erro
 	}
 
 	result := make(map[string][]net.IP, len(input))
+	cnames := make(map[string]string)
+	records := make(map[string][]dns.RR)
+	ttls := make(map[string]Duration)
+
+	for k, value := range input {
+		if err := validateWildcard(k); err != nil {
+			return err
+		}
+
+		v, ttl, err := cutTTL(value)
+		if err != nil {
+			return fmt.Errorf("invalid TTL of %s: %w", k, err)
+		}
+
+		if ttl != nil {
+			ttls[util.NormalizeDomain(k)] = *ttl
+		}
+
+		if isTypedRecords(v) {
+			if trie.IsWildcard(k) {
+				return fmt.Errorf("invalid wildcard '%s': only supported for IP addresses and CNAME targets", k)
+			}
+
+			rrs, err := parseTypedRecords(util.NormalizeDomain(k), v)
+			if err != nil {
+				return err
+			}
+
+			records[util.NormalizeDomain(k)] = rrs
+
+			continue
+		}
+
+		if target, ok := parseCNAMETarget(v); ok {
+			cnames[util.NormalizeDomain(k)] = target
+
+			continue
+		}
 
-	for k, v := range input {
 		var ips []net.IP
 
 		for _, part := range strings.Split(v, ",") {
@@ -62,7 +271,194 @@ func (c *CustomDNSMapping) UnmarshalYAML(unmarshal func(interface{}) error) erro
 		result[k] = ips
 	}
 
-	c.HostIPs = result
+	mapping := CustomDNSMapping{HostIPs: result, CNAMEs: cnames, Records: records, TTLs: ttls}
+
+	if err := mapping.validateCNAMEs(); err != nil {
+		return err
+	}
+
+	*c = mapping
+
+	return nil
+}
+
+// cutTTL removes the TTL option from the end of the value and returns the TTL, nil if the value has none
+func cutTTL(value string) (string, *Duration, error) {
+	idx := strings.LastIndex(value, ttlOption)
+	if idx == -1 {
+		return value, nil, nil
+	}
+
+	ttl, err := parseTTL(strings.TrimSpace(value[idx+len(ttlOption):]))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return strings.TrimSpace(value[:idx]), &ttl, nil
+}
+
+// parseTTL parses a duration with unit, `d` can be used for days
+func parseTTL(value string) (Duration, error) {
+	var (
+		duration time.Duration
+		err      error
+	)
+
+	if days, found := strings.CutSuffix(value, "d"); found {
+		var n int
+
+		n, err = strconv.Atoi(days)
+		duration = time.Duration(n) * hoursPerDay * time.Hour
+	} else {
+		duration, err = time.ParseDuration(value)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a duration with unit like 30s or 1d", value)
+	}
+
+	if duration < 0 {
+		return 0, fmt.Errorf("'%s' is negative", value)
+	}
+
+	return Duration(duration), nil
+}
+
+// validateWildcard checks that a wildcard is only used as the first label of a domain
+func validateWildcard(domain string) error {
+	if !strings.Contains(domain, trie.WildcardLabel) {
+		return nil
+	}
+
+	rest := strings.TrimPrefix(domain, trie.WildcardPrefix)
+	if rest == domain || rest == "" || strings.Contains(rest, trie.WildcardLabel) {
+		return fmt.Errorf("invalid wildcard '%s': only '*.' is supported as prefix", domain)
+	}
+
+	return nil
+}
+
+// isTypedRecords returns true if the value starts with a record type followed by its data
+func isTypedRecords(value string) bool {
+	fields := strings.Fields(value)
+	if len(fields) < 2 { //nolint:gomnd
+		return false
+	}
+
+	_, ok := dns.StringToType[strings.ToUpper(fields[0])]
+
+	return ok
+}
+
+// parseTypedRecords parses one record per line, the domain is prepended as owner
+func parseTypedRecords(domain, value string) ([]dns.RR, error) {
+	var res []dns.RR
+
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		rr, err := dns.NewRR(dns.Fqdn(domain) + " " + line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record '%s' for %s: %w", line, domain, err)
+		}
+
+		if rr.Header().Rrtype == dns.TypeCNAME {
+			return nil, fmt.Errorf("invalid record '%s' for %s: use the target domain as value for a CNAME", line, domain)
+		}
+
+		res = append(res, rr)
+	}
+
+	return res, nil
+}
+
+// RecordData returns the record in zone file syntax without owner, TTL and class
+func RecordData(rr dns.RR) string {
+	hdr := rr.Header()
+
+	return fmt.Sprintf("%s %s", dns.TypeToString[hdr.Rrtype], strings.TrimPrefix(rr.String(), hdr.String()))
+}
+
+// parseCNAMETarget returns the normalized target if the value is a domain name.
+// Values which look like (mistyped) IP addresses aren't domain names: they contain a colon or start with a number.
+// Wildcards aren't targets.
+func parseCNAMETarget(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+
+	if strings.ContainsAny(value, ",:"+trie.WildcardLabel) {
+		return "", false
+	}
+
+	firstLabel, _, _ := strings.Cut(value, ".")
+	if strings.Trim(firstLabel, "0123456789") == "" {
+		return "", false
+	}
+
+	if _, ok := dns.IsDomainName(value); !ok {
+		return "", false
+	}
+
+	return util.NormalizeDomain(value), true
+}
+
+// validateCNAMEs follows the CNAME chain of each mapping and rejects chains which lead back to a mapped domain
+// already part of the chain.
+func (c *CustomDNSMapping) validateCNAMEs() error {
+	// CNAME mappings have their key as value, IP mappings an empty value
+	entries := trie.NewValueTrie[string](trie.SplitTLD)
+
+	for name := range c.HostIPs {
+		entries.Insert(util.NormalizeDomain(name), "")
+	}
+
+	for name := range c.CNAMEs {
+		entries.Insert(name, name)
+	}
+
+	// sorted, to report the same loop on each load
+	names := make([]string, 0, len(c.CNAMEs))
+	for name := range c.CNAMEs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := c.CNAMEs[name]
+		visited := map[string]bool{name: true}
+		chain := []string{name}
+
+		for {
+			chain = append(chain, target)
+
+			key, found := c.cnameKey(entries, target)
+			if !found {
+				break
+			}
+
+			if visited[key] {
+				return fmt.Errorf("CNAME loop: %s", strings.Join(chain, " -> "))
+			}
+
+			visited[key] = true
+			target = c.CNAMEs[key]
+		}
+	}
 
 	return nil
 }
+
+// cnameKey returns the CNAME mapping which answers the domain, the same way the resolver matches subdomains
+// and wildcards
+func (c *CustomDNSMapping) cnameKey(entries *trie.ValueTrie[string], domain string) (string, bool) {
+	if _, found := c.Records[domain]; found {
+		return "", false
+	}
+
+	key, found := entries.Find(domain)
+
+	return key, found && key != ""
+}
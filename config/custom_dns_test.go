@@ -2,11 +2,15 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"time"
 
 	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("CustomDNSConfig", func() {
@@ -51,6 +55,14 @@ var _ = Describe("CustomDNSConfig", func() {
 				Expect(cfg.IsEnabled()).Should(BeFalse())
 			})
 		})
+
+		When("only the runtime records are persisted", func() {
+			It("should be true", func() {
+				cfg := CustomDNSConfig{RuntimeRecordsFile: "/tmp/records.json"}
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
 	})
 
 	Describe("LogConfig", func() {
@@ -60,9 +72,113 @@ var _ = Describe("CustomDNSConfig", func() {
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("custom.domain = ")))
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("multiple.ips = ")))
+			Expect(hook.Messages).Should(ContainElement(Equal("createPTR = false")))
+		})
+
+		It("should log the runtime records file", func() {
+			cfg.RuntimeRecordsFile = "/tmp/records.json"
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("runtimeRecordsFile = /tmp/records.json")))
+		})
+
+		It("should log CNAMEs", func() {
+			cfg.Mapping.CNAMEs = map[string]string{"grafana.home": "nas.home"}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("  grafana.home = CNAME nas.home")))
+		})
+
+		It("should log zone files", func() {
+			cfg.ZoneFiles = NewBytesSources("/etc/blocky/home.zone")
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				Equal("zone files:"),
+				Equal("  - file:///etc/blocky/home.zone"),
+				Equal("loading:"),
+			))
+		})
+
+		It("should log records", func() {
+			rr, err := dns.NewRR(`lab. TXT "v=spf1 -all"`)
+			Expect(err).Should(Succeed())
+
+			cfg.Mapping.Records = map[string][]dns.RR{"lab": {rr}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal(`  lab = TXT "v=spf1 -all"`)))
+		})
+
+		It("should log TTLs which differ from the customTTL", func() {
+			cfg.CustomTTL = Duration(time.Hour)
+			cfg.Mapping.CNAMEs = map[string]string{"grafana.home": "nas.home"}
+			cfg.Mapping.TTLs = map[string]Duration{
+				"custom.domain": Duration(30 * time.Second),
+				"grafana.home":  Duration(time.Hour),
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				Equal("  custom.domain = [192.168.143.123] (TTL 30 seconds)"),
+				Equal("  grafana.home = CNAME nas.home"),
+			))
+		})
+
+		It("should log client groups", func() {
+			cfg.ClientGroups = map[string]CustomDNSClientGroup{
+				"10.6.0.0/24": {Mapping: CustomDNSMapping{
+					HostIPs: map[string][]net.IP{"nas.home": {net.ParseIP("10.6.0.10")}},
+					TTLs:    map[string]Duration{"nas.home": Duration(time.Minute)},
+				}},
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				Equal("client groups:"),
+				Equal("  10.6.0.0/24:"),
+				Equal("    nas.home = [10.6.0.10] (TTL 1 minute)"),
+			))
 		})
 	})
 
+	Describe("validateClientGroups", func() {
+		group := CustomDNSClientGroup{}
+
+		It("should accept distinct clients", func() {
+			cfg.ClientGroups = map[string]CustomDNSClientGroup{
+				"10.6.0.0/24": group, "10.6.0.0/16": group, "10.6.0.1": group, "laptop": group, "laptop-*": group,
+			}
+
+			Expect(cfg.validateClientGroups()).Should(Succeed())
+		})
+
+		DescribeTable("should reject clients which overlap or are invalid",
+			func(clients []string, reason string) {
+				cfg.ClientGroups = make(map[string]CustomDNSClientGroup)
+
+				for _, client := range clients {
+					cfg.ClientGroups[client] = group
+				}
+
+				Expect(cfg.validateClientGroups()).Should(MatchError(ContainSubstring(reason)))
+			},
+			Entry("same name", []string{"Laptop", "laptop"}, "client groups 'Laptop' and 'laptop' match the same clients"),
+			Entry("same network", []string{"10.6.0.0/24", "10.6.0.1/24"}, "match the same clients"),
+			Entry("IP as network", []string{"10.6.0.1", "10.6.0.1/32"}, "match the same clients"),
+			Entry("same IPv6", []string{"2001:db8::1", "2001:db8:0::1"}, "match the same clients"),
+			Entry("invalid network", []string{"10.6.0.0/33"}, "invalid client group '10.6.0.0/33'"),
+			Entry("invalid pattern", []string{"laptop-["}, "invalid client group 'laptop-['"),
+			Entry("empty", []string{" "}, "empty client group"),
+		)
+	})
+
 	Describe("UnmarshalYAML", func() {
 		It("Should parse config as map", func() {
 			c := &CustomDNSMapping{}
@@ -77,6 +193,198 @@ var _ = Describe("CustomDNSConfig", func() {
 			Expect(c.HostIPs["key"][0]).Should(Equal(net.ParseIP("1.2.3.4")))
 		})
 
+		It("should parse a domain name as CNAME target", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+nas.home: 192.168.178.20
+Grafana.home: nas.home.
+`), c)).Should(Succeed())
+
+			Expect(c.HostIPs).Should(HaveKey("nas.home"))
+			Expect(c.HostIPs).ShouldNot(HaveKey("grafana.home"))
+			Expect(c.CNAMEs).Should(Equal(map[string]string{"grafana.home": "nas.home"}))
+		})
+
+		It("should fail for an invalid IP address", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+nas.home: 192.168.178.300
+`), c)).Should(MatchError("invalid IP address '192.168.178.300'"))
+
+			Expect(yaml.UnmarshalStrict([]byte(`
+nas.home: 192.168.178.20, nas.lan
+`), c)).Should(MatchError("invalid IP address ' nas.lan'"))
+		})
+
+		It("should reject CNAME loops", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+a.home: b.home
+b.home: c.home
+c.home: a.home
+`), c)).Should(MatchError(SatisfyAll(
+				ContainSubstring("CNAME loop: "),
+				ContainSubstring("a.home -> b.home -> c.home -> a.home"),
+			)))
+		})
+
+		It("should reject CNAME loops through subdomains", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+home: www.home
+`), c)).Should(MatchError("CNAME loop: home -> www.home"))
+		})
+
+		It("should accept chains ending with IPs or an unmapped domain", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+a.home: b.home
+b.home: nas.home
+nas.home: 192.168.178.20
+www.home: example.com
+`), c)).Should(Succeed())
+
+			Expect(c.CNAMEs).Should(HaveLen(3))
+		})
+
+		It("should parse wildcards", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+"*.apps.home": 192.168.178.30
+"*.svc.home": apps.home
+`), c)).Should(Succeed())
+
+			Expect(c.HostIPs).Should(HaveKey("*.apps.home"))
+			Expect(c.CNAMEs).Should(Equal(map[string]string{"*.svc.home": "apps.home"}))
+		})
+
+		It("should reject wildcards which aren't a prefix", func() {
+			for _, key := range []string{"a.*.home", "*apps.home", "*", "*.*.home"} {
+				c := &CustomDNSMapping{}
+				Expect(yaml.UnmarshalStrict([]byte(`"`+key+`": 192.168.178.30`), c)).
+					Should(MatchError(fmt.Sprintf("invalid wildcard '%s': only '*.' is supported as prefix", key)))
+			}
+		})
+
+		It("should reject wildcards for typed records and as CNAME target", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+"*.lab": TXT "v=spf1 -all"
+`), c)).Should(MatchError(ContainSubstring("only supported for IP addresses and CNAME targets")))
+
+			Expect(yaml.UnmarshalStrict([]byte(`
+www.home: "*.apps.home"
+`), c)).Should(MatchError(ContainSubstring("invalid IP address")))
+		})
+
+		It("should reject CNAME loops through wildcards", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+"*.apps.home": www.apps.home
+`), c)).Should(MatchError("CNAME loop: *.apps.home -> www.apps.home"))
+
+			Expect(yaml.UnmarshalStrict([]byte(`
+"*.apps.home": www.apps.home
+www.apps.home: 192.168.178.30
+`), c)).Should(Succeed())
+		})
+
+		It("should parse typed records", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+_ldap._tcp.Lab: SRV 0 0 389 dc1.lab
+lab: |
+  TXT "v=spf1 -all"
+  mx 10 mail.lab
+`), c)).Should(Succeed())
+
+			Expect(c.HostIPs).Should(BeEmpty())
+			Expect(c.CNAMEs).Should(BeEmpty())
+			Expect(c.Records).Should(HaveLen(2))
+
+			Expect(c.Records["_ldap._tcp.lab"]).Should(HaveLen(1))
+			Expect(c.Records["_ldap._tcp.lab"][0].String()).Should(Equal("_ldap._tcp.lab.\t3600\tIN\tSRV\t0 0 389 dc1.lab."))
+
+			Expect(c.Records["lab"]).Should(HaveLen(2))
+			Expect(c.Records["lab"][0].(*dns.TXT).Txt).Should(Equal([]string{"v=spf1 -all"}))
+			Expect(c.Records["lab"][1].(*dns.MX).Mx).Should(Equal("mail.lab."))
+		})
+
+		It("should report the invalid record", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+lab: |
+  TXT "v=spf1 -all"
+  MX ten mail.lab
+`), c)).Should(MatchError(ContainSubstring("invalid record 'MX ten mail.lab' for lab: ")))
+		})
+
+		It("should reject typed CNAME records", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+grafana.lab: CNAME nas.lab
+`), c)).Should(MatchError(ContainSubstring("use the target domain as value for a CNAME")))
+		})
+
+		It("should parse TTLs", func() {
+			c := &CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+vip.home: 192.168.178.100?ttl=30s
+printer.Home.: 192.168.178.3, 192.168.178.4 ?ttl=1d
+grafana.home: vip.home?ttl=5m
+lab: |
+  TXT "v=spf1 -all"
+  MX 10 mail.lab
+  ?ttl=2h
+nas.home: 192.168.178.20
+`), c)).Should(Succeed())
+
+			Expect(c.HostIPs["printer.Home."]).Should(HaveLen(2))
+			Expect(c.CNAMEs).Should(HaveKeyWithValue("grafana.home", "vip.home"))
+			Expect(c.Records["lab"]).Should(HaveLen(2))
+			Expect(c.TTLs).Should(Equal(map[string]Duration{
+				"vip.home":     Duration(30 * time.Second),
+				"printer.home": Duration(24 * time.Hour),
+				"grafana.home": Duration(5 * time.Minute),
+				"lab":          Duration(2 * time.Hour),
+			}))
+
+			cfg := CustomDNSConfig{CustomTTL: Duration(time.Hour), Mapping: *c}
+			Expect(cfg.TTL("Vip.home.")).Should(Equal(Duration(30 * time.Second)))
+			Expect(cfg.TTL("nas.home")).Should(Equal(Duration(time.Hour)))
+		})
+
+		It("should parse client groups", func() {
+			data := `
+mapping:
+  nas.home: 192.168.1.10
+clientGroups:
+  10.6.0.0/24:
+    mapping:
+      nas.home: 10.6.0.10?ttl=5m
+`
+			var c CustomDNSConfig
+			Expect(yaml.UnmarshalStrict([]byte(data), &c)).Should(Succeed())
+
+			Expect(c.ClientGroups).Should(HaveKey("10.6.0.0/24"))
+
+			mapping := c.ClientGroups["10.6.0.0/24"].Mapping
+			Expect(mapping.HostIPs).Should(HaveKeyWithValue("nas.home", []net.IP{net.ParseIP("10.6.0.10")}))
+			Expect(c.MappingTTL(&mapping, "nas.home")).Should(Equal(Duration(5 * time.Minute)))
+			Expect(c.TTL("nas.home")).Should(Equal(c.CustomTTL))
+		})
+
+		DescribeTable("should reject invalid TTLs",
+			func(value, reason string) {
+				c := &CustomDNSMapping{}
+				Expect(yaml.UnmarshalStrict([]byte("vip.home: "+value), c)).
+					Should(MatchError(SatisfyAll(ContainSubstring("invalid TTL of vip.home"), ContainSubstring(reason))))
+			},
+			Entry("without unit", "192.168.178.100?ttl=30", "is not a duration with unit"),
+			Entry("invalid days", "192.168.178.100?ttl=xd", "is not a duration with unit"),
+			Entry("negative", "192.168.178.100?ttl=-1s", "is negative"),
+		)
+
 		It("should fail if wrong YAML format", func() {
 			c := &CustomDNSMapping{}
 			err := c.UnmarshalYAML(func(i interface{}) error {
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dns64UOctet is the byte of the prefix, which is reserved for future use
+const dns64UOctet = 8
+
+// DNS64Config configuration of the synthesis of AAAA records from A records for NAT64 (RFC 6147)
+type DNS64Config struct {
+	Enable bool `yaml:"enable" default:"false"`
+	// Prefix is the NAT64 prefix the IPv4 addresses are embedded into (RFC 6052)
+	Prefix string `yaml:"prefix" default:"64:ff9b::/96"`
+	// ExcludedDomains are never synthesized, e.g. `*.example.com`
+	ExcludedDomains []string `yaml:"excludedDomains"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *DNS64Config) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *DNS64Config) LogConfig(logger *logrus.Entry) {
+	logger.Infof("prefix = %s", c.Prefix)
+
+	if len(c.ExcludedDomains) != 0 {
+		logger.Infof("excludedDomains = %s", strings.Join(c.ExcludedDomains, ", "))
+	}
+}
+
+// PrefixNetwork returns the NAT64 prefix, its length must be one of RFC 6052: 32, 40, 48, 56, 64 or 96
+func (c *DNS64Config) PrefixNetwork() (*net.IPNet, error) {
+	_, prefix, err := net.ParseCIDR(c.Prefix)
+	if err != nil || prefix.IP.To4() != nil {
+		return nil, fmt.Errorf("prefix '%s' must be an IPv6 CIDR", c.Prefix)
+	}
+
+	ones, _ := prefix.Mask.Size()
+	if !slices.Contains([]int{32, 40, 48, 56, 64, 96}, ones) { //nolint:gomnd
+		return nil, fmt.Errorf("prefix '%s' must have a length of 32, 40, 48, 56, 64 or 96", c.Prefix)
+	}
+
+	if prefix.IP[dns64UOctet] != 0 {
+		return nil, errors.New("bits 64 to 71 of the prefix must be zero")
+	}
+
+	return prefix, nil
+}
+
+// validate checks the prefix and the excluded domains
+func (c *DNS64Config) validate() error {
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	if _, err := c.PrefixNetwork(); err != nil {
+		return err
+	}
+
+	for _, domain := range c.ExcludedDomains {
+		if err := validateWildcardDomain(domain); err != nil {
+			return fmt.Errorf("invalid excluded domain '%s': %w", domain, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DNS64Config", func() {
+	var cfg DNS64Config
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[DNS64Config]()
+		Expect(err).Should(Succeed())
+
+		cfg.Enable = true
+		cfg.ExcludedDomains = []string{"*.ipv4only.example", "legacy.example.com"}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg, err := WithDefaults[DNS64Config]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+			Expect(cfg.Prefix).Should(Equal("64:ff9b::/96"))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept the default prefix", func() {
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should not validate if disabled", func() {
+			cfg.Enable = false
+			cfg.Prefix = "invalid"
+
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		DescribeTable("should accept the prefix lengths of RFC 6052",
+			func(prefix string) {
+				cfg.Prefix = prefix
+
+				Expect(cfg.validate()).Should(Succeed())
+			},
+			Entry("/32", "2001:db8::/32"),
+			Entry("/40", "2001:db8:100::/40"),
+			Entry("/48", "2001:db8:122::/48"),
+			Entry("/56", "2001:db8:122:300::/56"),
+			Entry("/64", "2001:db8:122:344::/64"),
+			Entry("/96", "64:ff9b:1::/96"),
+		)
+
+		DescribeTable("should fail for invalid prefixes",
+			func(prefix, message string) {
+				cfg.Prefix = prefix
+
+				Expect(cfg.validate()).Should(MatchError(ContainSubstring(message)))
+			},
+			Entry("no CIDR", "64:ff9b::", "must be an IPv6 CIDR"),
+			Entry("IPv4", "192.0.2.0/24", "must be an IPv6 CIDR"),
+			Entry("invalid length", "64:ff9b::/80", "must have a length of"),
+			Entry("reserved bits", "64:ff9b:0:0:100::/96", "bits 64 to 71"),
+		)
+
+		It("should fail for invalid domains", func() {
+			cfg.ExcludedDomains = []string{"legacy.*"}
+
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("invalid excluded domain 'legacy.*'")))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"prefix = 64:ff9b::/96",
+				"excludedDomains = *.ipv4only.example, legacy.example.com",
+			))
+		})
+	})
+})
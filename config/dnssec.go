@@ -0,0 +1,108 @@
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal --names --values
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// dnssecRootKSK2017 and dnssecRootKSK2024 are the DS records of the root zone's key signing keys, published by IANA
+	dnssecRootKSK2017 = ". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"
+	dnssecRootKSK2024 = ". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16"
+)
+
+// DNSSECMode how the result of the DNSSEC validation is used ENUM(
+// log     // only log answers, which fail the validation
+// enforce // answer with SERVFAIL, if the validation fails
+// )
+type DNSSECMode uint8
+
+// DNSSECConfig configuration of the DNSSEC validation of upstream answers
+type DNSSECConfig struct {
+	Enable bool       `yaml:"enable" default:"false"`
+	Mode   DNSSECMode `yaml:"mode" default:"enforce"`
+	// TrustAnchors are DS or DNSKEY records of the keys the chains of trust start with, the root keys if empty
+	TrustAnchors []string `yaml:"trustAnchors"`
+	// InsecureDomains aren't validated (negative trust anchors), e.g. `*.corp.example.com`
+	InsecureDomains []string `yaml:"insecureDomains"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *DNSSECConfig) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *DNSSECConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("mode = %s", c.Mode)
+
+	if len(c.TrustAnchors) == 0 {
+		logger.Info("trustAnchors = root KSKs")
+	} else {
+		logger.Info("trustAnchors:")
+
+		for _, anchor := range c.TrustAnchors {
+			logger.Infof("  %s", anchor)
+		}
+	}
+
+	if len(c.InsecureDomains) != 0 {
+		logger.Infof("insecureDomains = %s", strings.Join(c.InsecureDomains, ", "))
+	}
+}
+
+// Anchors returns the trust anchors as DS records, DNSKEY records are converted
+func (c *DNSSECConfig) Anchors() ([]*dns.DS, error) {
+	anchors := c.TrustAnchors
+	if len(anchors) == 0 {
+		anchors = []string{dnssecRootKSK2017, dnssecRootKSK2024}
+	}
+
+	result := make([]*dns.DS, 0, len(anchors))
+
+	for _, anchor := range anchors {
+		rr, err := dns.NewRR(anchor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust anchor '%s': %w", anchor, err)
+		}
+
+		switch v := rr.(type) {
+		case *dns.DS:
+			result = append(result, v)
+		case *dns.DNSKEY:
+			ds := v.ToDS(dns.SHA256)
+			if ds == nil {
+				return nil, fmt.Errorf("invalid trust anchor '%s': unsupported key", anchor)
+			}
+
+			result = append(result, ds)
+		default:
+			return nil, fmt.Errorf("invalid trust anchor '%s': must be a DS or DNSKEY record", anchor)
+		}
+	}
+
+	return result, nil
+}
+
+// validate checks the trust anchors and the insecure domains
+func (c *DNSSECConfig) validate() error {
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	if _, err := c.Anchors(); err != nil {
+		return err
+	}
+
+	for _, domain := range c.InsecureDomains {
+		if err := validateWildcardDomain(domain); err != nil {
+			return fmt.Errorf("invalid insecure domain '%s': %w", domain, err)
+		}
+	}
+
+	return nil
+}
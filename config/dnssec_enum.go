@@ -0,0 +1,94 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DNSSECModeLog is a DNSSECMode of type Log.
+	// only log answers, which fail the validation
+	DNSSECModeLog DNSSECMode = iota
+	// DNSSECModeEnforce is a DNSSECMode of type Enforce.
+	// answer with SERVFAIL, if the validation fails
+	DNSSECModeEnforce
+)
+
+var ErrInvalidDNSSECMode = fmt.Errorf("not a valid DNSSECMode, try [%s]", strings.Join(_DNSSECModeNames, ", "))
+
+const _DNSSECModeName = "logenforce"
+
+var _DNSSECModeNames = []string{
+	_DNSSECModeName[0:3],
+	_DNSSECModeName[3:10],
+}
+
+// DNSSECModeNames returns a list of possible string values of DNSSECMode.
+func DNSSECModeNames() []string {
+	tmp := make([]string, len(_DNSSECModeNames))
+	copy(tmp, _DNSSECModeNames)
+	return tmp
+}
+
+// DNSSECModeValues returns a list of the values for DNSSECMode
+func DNSSECModeValues() []DNSSECMode {
+	return []DNSSECMode{
+		DNSSECModeLog,
+		DNSSECModeEnforce,
+	}
+}
+
+var _DNSSECModeMap = map[DNSSECMode]string{
+	DNSSECModeLog:     _DNSSECModeName[0:3],
+	DNSSECModeEnforce: _DNSSECModeName[3:10],
+}
+
+// String implements the Stringer interface.
+func (x DNSSECMode) String() string {
+	if str, ok := _DNSSECModeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("DNSSECMode(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x DNSSECMode) IsValid() bool {
+	_, ok := _DNSSECModeMap[x]
+	return ok
+}
+
+var _DNSSECModeValue = map[string]DNSSECMode{
+	_DNSSECModeName[0:3]:  DNSSECModeLog,
+	_DNSSECModeName[3:10]: DNSSECModeEnforce,
+}
+
+// ParseDNSSECMode attempts to convert a string to a DNSSECMode.
+func ParseDNSSECMode(name string) (DNSSECMode, error) {
+	if x, ok := _DNSSECModeValue[name]; ok {
+		return x, nil
+	}
+	return DNSSECMode(0), fmt.Errorf("%s is %w", name, ErrInvalidDNSSECMode)
+}
+
+// MarshalText implements the text marshaller method.
+func (x DNSSECMode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *DNSSECMode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseDNSSECMode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DNSSECConfig", func() {
+	var cfg DNSSECConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[DNSSECConfig]()
+		Expect(err).Should(Succeed())
+
+		cfg.Enable = true
+		cfg.InsecureDomains = []string{"*.corp.example.com", "broken.example.org"}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg, err := WithDefaults[DNSSECConfig]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+			Expect(cfg.Mode).Should(Equal(DNSSECModeEnforce))
+		})
+	})
+
+	Describe("Anchors", func() {
+		It("should return the root KSKs by default", func() {
+			anchors, err := cfg.Anchors()
+			Expect(err).Should(Succeed())
+
+			Expect(anchors).Should(HaveLen(2))
+			Expect(anchors).Should(HaveEach(HaveField("Hdr.Name", ".")))
+			Expect(anchors).Should(ContainElement(HaveField("KeyTag", uint16(20326))))
+			Expect(anchors).Should(ContainElement(HaveField("KeyTag", uint16(38696))))
+		})
+
+		It("should convert DNSKEY records", func() {
+			key := &dns.DNSKEY{
+				Hdr:       dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+				Flags:     dns.ZONE | dns.SEP,
+				Protocol:  3,
+				Algorithm: dns.ECDSAP256SHA256,
+			}
+			_, err := key.Generate(256)
+			Expect(err).Should(Succeed())
+
+			cfg.TrustAnchors = []string{key.String()}
+
+			anchors, err := cfg.Anchors()
+			Expect(err).Should(Succeed())
+
+			Expect(anchors).Should(HaveLen(1))
+			Expect(anchors[0].KeyTag).Should(Equal(key.KeyTag()))
+			Expect(anchors[0].DigestType).Should(Equal(dns.SHA256))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept the defaults", func() {
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should not validate if disabled", func() {
+			cfg.Enable = false
+			cfg.TrustAnchors = []string{"invalid"}
+
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		DescribeTable("should fail for invalid trust anchors",
+			func(anchor, message string) {
+				cfg.TrustAnchors = []string{anchor}
+
+				Expect(cfg.validate()).Should(MatchError(ContainSubstring(message)))
+			},
+			Entry("no record", "invalid", "invalid trust anchor 'invalid'"),
+			Entry("empty", "", "must be a DS or DNSKEY record"),
+			Entry("other type", "example.com. IN A 192.0.2.1", "must be a DS or DNSKEY record"),
+		)
+
+		It("should fail for invalid domains", func() {
+			cfg.InsecureDomains = []string{"corp.*"}
+
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("invalid insecure domain 'corp.*'")))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"mode = enforce",
+				"trustAnchors = root KSKs",
+				"insecureDomains = *.corp.example.com, broken.example.org",
+			))
+		})
+
+		It("should log the trust anchors", func() {
+			cfg.TrustAnchors = []string{dnssecRootKSK2024}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("trustAnchors:", "  "+dnssecRootKSK2024))
+		})
+	})
+})
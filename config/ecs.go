@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ecsMaxIPv4Mask = 32
+	ecsMaxIPv6Mask = 128
+)
+
+// ECSConfig configuration of the EDNS Client Subnet (RFC 7871) sent to the upstreams
+type ECSConfig struct {
+	// Forward passes the ECS option of the client, its source prefix is truncated to the masks
+	Forward bool `yaml:"forward" default:"false"`
+	// Add synthesizes an ECS option from the client address, if the client sent none
+	Add bool `yaml:"add" default:"false"`
+	// Strip removes the ECS option of the client, unless it is forwarded
+	Strip    bool  `yaml:"strip" default:"true"`
+	IPv4Mask uint8 `yaml:"ipv4Mask" default:"24"`
+	IPv6Mask uint8 `yaml:"ipv6Mask" default:"56"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ECSConfig) IsEnabled() bool {
+	return c.Forward || c.Add || c.Strip
+}
+
+// SendsClientSubnet returns true if the upstreams get a client subnet, so the answers depend on it
+func (c *ECSConfig) SendsClientSubnet() bool {
+	return c.Forward || c.Add
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ECSConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("forward = %t", c.Forward)
+	logger.Infof("add = %t", c.Add)
+	logger.Infof("strip = %t", c.Strip)
+
+	if c.SendsClientSubnet() {
+		logger.Infof("ipv4Mask = %d", c.IPv4Mask)
+		logger.Infof("ipv6Mask = %d", c.IPv6Mask)
+	}
+}
+
+// validate checks that the masks are valid prefix lengths, if a client subnet is sent
+func (c *ECSConfig) validate() error {
+	if !c.SendsClientSubnet() {
+		return nil
+	}
+
+	if c.IPv4Mask == 0 || c.IPv4Mask > ecsMaxIPv4Mask {
+		return fmt.Errorf("ipv4Mask %d must be between 1 and %d", c.IPv4Mask, ecsMaxIPv4Mask)
+	}
+
+	if c.IPv6Mask == 0 || c.IPv6Mask > ecsMaxIPv6Mask {
+		return fmt.Errorf("ipv6Mask %d must be between 1 and %d", c.IPv6Mask, ecsMaxIPv6Mask)
+	}
+
+	return nil
+}
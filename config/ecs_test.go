@@ -0,0 +1,81 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ECSConfig", func() {
+	var cfg ECSConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[ECSConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should strip by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+			Expect(cfg.Strip).Should(BeTrue())
+			Expect(cfg.SendsClientSubnet()).Should(BeFalse())
+		})
+
+		When("nothing is configured", func() {
+			It("should be false", func() {
+				cfg.Strip = false
+
+				Expect(cfg.IsEnabled()).Should(BeFalse())
+			})
+		})
+
+		When("the client subnet is added", func() {
+			It("should send the client subnet", func() {
+				cfg.Add = true
+
+				Expect(cfg.SendsClientSubnet()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept the defaults", func() {
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should ignore the masks, if no client subnet is sent", func() {
+			cfg.IPv4Mask = 0
+
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should fail for invalid masks", func() {
+			cfg.Add = true
+
+			cfg.IPv4Mask = 33
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("ipv4Mask 33")))
+
+			cfg.IPv4Mask = 24
+			cfg.IPv6Mask = 0
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("ipv6Mask 0")))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the configuration", func() {
+			cfg.Forward = true
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"forward = true",
+				"strip = true",
+				"ipv4Mask = 24",
+				"ipv6Mask = 56",
+			))
+		})
+	})
+})
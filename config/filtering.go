@@ -1,16 +1,31 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/0xERR0R/blocky/trie"
+	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
 )
 
 type FilteringConfig struct {
 	QueryTypes QTypeSet `yaml:"queryTypes"`
+	// Rules filter query types only for some domains
+	Rules []FilteringRule `yaml:"rules"`
+}
+
+// FilteringRule filters the query types for the domains.
+// A domain matches exactly, a wildcard like `*.example.com` matches all domains below it.
+type FilteringRule struct {
+	QueryTypes QTypeSet `yaml:"queryTypes"`
+	Domains    []string `yaml:"domains"`
 }
 
 // IsEnabled implements `config.Configurable`.
 func (c *FilteringConfig) IsEnabled() bool {
-	return len(c.QueryTypes) != 0
+	return len(c.QueryTypes) != 0 || len(c.Rules) != 0
 }
 
 // LogConfig implements `config.Configurable`.
@@ -20,4 +35,48 @@ func (c *FilteringConfig) LogConfig(logger *logrus.Entry) {
 	for qType := range c.QueryTypes {
 		logger.Infof("  - %s", qType)
 	}
+
+	if len(c.Rules) != 0 {
+		logger.Info("rules:")
+
+		for _, rule := range c.Rules {
+			logger.Infof("  - %s for %s", rule.QueryTypes, strings.Join(rule.Domains, ", "))
+		}
+	}
+}
+
+// validate checks that each rule has query types and valid domains
+func (c *FilteringConfig) validate() error {
+	for i, rule := range c.Rules {
+		if len(rule.QueryTypes) == 0 {
+			return fmt.Errorf("rule #%d: no query types", i+1)
+		}
+
+		if len(rule.Domains) == 0 {
+			return fmt.Errorf("rule #%d: no domains", i+1)
+		}
+
+		for _, domain := range rule.Domains {
+			if err := validateWildcardDomain(domain); err != nil {
+				return fmt.Errorf("rule #%d: invalid domain '%s': %w", i+1, domain, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateWildcardDomain checks that the domain is a domain name, optionally with `*.` as first label
+func validateWildcardDomain(domain string) error {
+	name := strings.TrimPrefix(domain, trie.WildcardPrefix)
+
+	if strings.Contains(name, trie.WildcardLabel) {
+		return errors.New("only `*.` as first label is supported as wildcard")
+	}
+
+	if _, ok := dns.IsDomainName(name); !ok || name == "" || name == "." {
+		return errors.New("not a domain name")
+	}
+
+	return nil
 }
@@ -20,6 +20,12 @@ var _ = Describe("FilteringConfig", func() {
 	})
 
 	Describe("IsEnabled", func() {
+		It("should be true with rules only", func() {
+			cfg := FilteringConfig{Rules: []FilteringRule{{QueryTypes: NewQTypeSet(AAAA), Domains: []string{"a.com"}}}}
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
 		It("should be false by default", func() {
 			cfg := FilteringConfig{}
 			Expect(defaults.Set(&cfg)).Should(Succeed())
@@ -51,5 +57,41 @@ var _ = Describe("FilteringConfig", func() {
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("  - AAAA")))
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("  - MX")))
 		})
+
+		It("should log the rules", func() {
+			cfg.Rules = []FilteringRule{
+				{QueryTypes: NewQTypeSet(AAAA, MX), Domains: []string{"*.corp.example.com", "example.com"}},
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("rules:", "  - AAAA, MX for *.corp.example.com, example.com"))
+		})
+	})
+
+	Describe("validate", func() {
+		DescribeTable("should check the rules",
+			func(rule FilteringRule, expectedErr string) {
+				cfg.Rules = []FilteringRule{rule}
+
+				err := cfg.validate()
+
+				if expectedErr == "" {
+					Expect(err).Should(Succeed())
+				} else {
+					Expect(err).Should(MatchError(ContainSubstring(expectedErr)))
+				}
+			},
+			Entry("valid domains",
+				FilteringRule{QueryTypes: NewQTypeSet(AAAA), Domains: []string{"example.com", "*.corp.example.com"}}, ""),
+			Entry("no query types",
+				FilteringRule{Domains: []string{"example.com"}}, "no query types"),
+			Entry("no domains",
+				FilteringRule{QueryTypes: NewQTypeSet(AAAA)}, "no domains"),
+			Entry("wildcard in the middle",
+				FilteringRule{QueryTypes: NewQTypeSet(AAAA), Domains: []string{"corp.*.com"}}, "invalid domain 'corp.*.com'"),
+			Entry("only a wildcard",
+				FilteringRule{QueryTypes: NewQTypeSet(AAAA), Domains: []string{"*"}}, "invalid domain '*'"),
+		)
 	})
 })
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetConfig", func() {
+	// packages get their configuration at construction time, only the composition root may use `config.GetConfig`
+	DescribeTable("must not be used outside of the composition root",
+		func(dir string) {
+			Expect(globalConfigUsages(dir)).Should(BeEmpty())
+		},
+		Entry("resolver", "../resolver"),
+		Entry("server", "../server"),
+		Entry("util", "../util"),
+		Entry("lists", "../lists"),
+		Entry("api", "../api"),
+	)
+})
+
+// globalConfigUsages returns the positions of all `config.GetConfig` calls in the non-test files of the directory
+func globalConfigUsages(dir string) []string {
+	GinkgoHelper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	Expect(err).Should(Succeed())
+	Expect(files).ShouldNot(BeEmpty())
+
+	var usages []string
+
+	fset := token.NewFileSet()
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		Expect(err).Should(Succeed())
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "GetConfig" {
+				return true
+			}
+
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "config" {
+				usages = append(usages, fset.Position(sel.Pos()).String())
+			}
+
+			return true
+		})
+	}
+
+	return usages
+}
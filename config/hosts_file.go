@@ -44,6 +44,12 @@ func (c *HostsFileConfig) LogConfig(logger *logrus.Entry) {
 	logger.Info("sources:")
 
 	for _, source := range c.Sources {
+		if source.TTL != nil && *source.TTL != c.HostsTTL {
+			logger.Infof("  - %s (TTL %s)", source, *source.TTL)
+
+			continue
+		}
+
 		logger.Infof("  - %s", source)
 	}
 }
@@ -56,6 +56,15 @@ var _ = Describe("HostsFileConfig", func() {
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("- file:///a/file/path")))
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("- 127.0.0.1 lo...")))
 		})
+
+		It("should log the TTL of sources which differs from hostsTTL", func() {
+			ttl := Duration(30 * time.Second)
+			cfg.Sources[0].TTL = &ttl
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("  - file:///a/file/path (TTL 30 seconds)")))
+		})
 	})
 
 	Describe("migrate", func() {
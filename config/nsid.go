@@ -0,0 +1,27 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// NSIDConfig configuration for the EDNS name server identifier (RFC 5001)
+type NSIDConfig struct {
+	Enable     bool   `yaml:"enable" default:"false"`
+	Identifier string `yaml:"identifier"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *NSIDConfig) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *NSIDConfig) LogConfig(logger *logrus.Entry) {
+	if c.Identifier == "" {
+		logger.Info("identifier = hostname")
+
+		return
+	}
+
+	logger.Infof("identifier = %s", c.Identifier)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NSIDConfig", func() {
+	var cfg NSIDConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[NSIDConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		When("enabled", func() {
+			It("should be true", func() {
+				cfg.Enable = true
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the hostname by default", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("identifier = hostname")))
+		})
+
+		When("an identifier is configured", func() {
+			It("should log the identifier", func() {
+				cfg.Identifier = "blocky-1"
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("identifier = blocky-1")))
+			})
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultProfileName is the name of the profile defined by the main configuration
+const DefaultProfileName = "default"
+
+//nolint:gochecknoglobals
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ProfilesConfig maps the profile name to its configuration
+type ProfilesConfig map[string]ProfileConfig
+
+// ProfileConfig configures an additional, independent resolver chain with its own DNS listeners.
+// All other sections (e.g. customDNS, queryLog) are shared with the main configuration.
+type ProfileConfig struct {
+	Ports     ProfilePortsConfig `yaml:"ports"`
+	Upstreams UpstreamsConfig    `yaml:"upstreams"`
+	Blocking  BlockingConfig     `yaml:"blocking"`
+	Caching   CachingConfig      `yaml:"caching"`
+}
+
+// ProfilePortsConfig DNS listeners of a profile, HTTP(S) listeners are shared
+type ProfilePortsConfig struct {
+	DNS ListenConfig `yaml:"dns"`
+	TLS ListenConfig `yaml:"tls"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ProfilesConfig) IsEnabled() bool {
+	return len(*c) > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ProfilesConfig) LogConfig(logger *logrus.Entry) {
+	for name, profile := range *c {
+		logger.Infof("%s:", name)
+		logger.Infof("  DNS = %s", profile.Ports.DNS)
+		logger.Infof("  TLS = %s", profile.Ports.TLS)
+	}
+}
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+func (c *ProfilesConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var input map[string]profileConfig
+	if err := unmarshal(&input); err != nil {
+		return err
+	}
+
+	result := make(ProfilesConfig, len(input))
+
+	for name, profile := range input {
+		if name == DefaultProfileName || !profileNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid profile name '%s'", name)
+		}
+
+		if len(profile.Ports.DNS) == 0 && len(profile.Ports.TLS) == 0 {
+			return fmt.Errorf("profile '%s' has no DNS or TLS listener", name)
+		}
+
+		result[name] = ProfileConfig(profile)
+	}
+
+	*c = result
+
+	return nil
+}
+
+// profileConfig is used to avoid infinite recursion and to apply defaults before unmarshalling,
+// since map values are created by the YAML decoder.
+type profileConfig ProfileConfig
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+func (c *profileConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain profileConfig
+
+	if err := defaults.Set((*plain)(c)); err != nil {
+		return fmt.Errorf("can't apply profile defaults: %w", err)
+	}
+
+	return unmarshal((*plain)(c))
+}
+
+// ForProfile returns the configuration of the profile: a copy of the main configuration
+// with the listeners, upstreams, blocking and caching of the profile.
+// If the profile has no upstreams, the upstreams of the main configuration are used.
+func (cfg *Config) ForProfile(name string) (*Config, error) {
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile '%s'", name)
+	}
+
+	result := *cfg
+
+	result.Profiles = nil
+	result.Ports = PortsConfig{DNS: profile.Ports.DNS, TLS: profile.Ports.TLS}
+	result.Blocking = profile.Blocking
+	result.Caching = profile.Caching
+
+	if profile.Upstreams.IsEnabled() {
+		result.Upstreams = profile.Upstreams
+	}
+
+	// the watchdog monitors the default profile only
+	result.Watchdog = WatchdogConfig{}
+
+	return &result, nil
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProfilesConfig", func() {
+	var cfg Config
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[Config]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(cfg.Profiles.IsEnabled()).Should(BeFalse())
+		})
+
+		When("a profile is defined", func() {
+			It("should be true", func() {
+				cfg.Profiles = ProfilesConfig{"kids": {}}
+
+				Expect(cfg.Profiles.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the listeners of each profile", func() {
+			cfg.Profiles = ProfilesConfig{
+				"kids": {Ports: ProfilePortsConfig{DNS: ListenConfig{"5353"}}},
+			}
+
+			cfg.Profiles.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("kids:")))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("DNS = [5353]")))
+		})
+	})
+
+	Describe("UnmarshalYAML", func() {
+		It("should apply the defaults of the profile sections", func() {
+			data := `
+profiles:
+  kids:
+    ports:
+      dns: 5353
+    blocking:
+      clientGroupsBlock:
+        default:
+          - ads`
+			Expect(unmarshalConfig([]byte(data), &cfg)).Should(Succeed())
+
+			Expect(cfg.Profiles).Should(HaveKey("kids"))
+
+			profile := cfg.Profiles["kids"]
+			Expect(profile.Ports.DNS).Should(Equal(ListenConfig{"5353"}))
+			Expect(profile.Blocking.ClientGroupsBlock).Should(HaveKeyWithValue("default", []string{"ads"}))
+			Expect(profile.Blocking.BlockType).Should(Equal("ZEROIP"))
+			Expect(profile.Upstreams.Timeout).Should(Equal(Duration(2 * time.Second)))
+		})
+
+		When("the profile has no DNS or TLS listener", func() {
+			It("should return error", func() {
+				data := `
+profiles:
+  kids:
+    blocking:
+      blockType: nxDomain`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(HaveOccurred())
+				Expect(err.Error()).Should(ContainSubstring("profile 'kids' has no DNS or TLS listener"))
+			})
+		})
+
+		When("the profile name is invalid", func() {
+			It("should return error", func() {
+				data := `
+profiles:
+  kids/lab:
+    ports:
+      dns: 5353`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(HaveOccurred())
+				Expect(err.Error()).Should(ContainSubstring("invalid profile name 'kids/lab'"))
+			})
+		})
+
+		When("the profile is called like the default profile", func() {
+			It("should return error", func() {
+				data := `
+profiles:
+  default:
+    ports:
+      dns: 5353`
+				err := unmarshalConfig([]byte(data), &cfg)
+				Expect(err).Should(HaveOccurred())
+				Expect(err.Error()).Should(ContainSubstring("invalid profile name 'default'"))
+			})
+		})
+	})
+
+	Describe("ForProfile", func() {
+		BeforeEach(func() {
+			cfg.Ports.DNS = ListenConfig{"53"}
+			cfg.Ports.HTTP = ListenConfig{"4000"}
+			cfg.Upstreams.Groups = UpstreamGroups{
+				UpstreamDefaultCfgName: {Upstream{Net: NetProtocolTcpUdp, Host: "1.1.1.1", Port: 53}},
+			}
+			cfg.Watchdog.Enable = true
+
+			cfg.Profiles = ProfilesConfig{
+				"kids": {
+					Ports: ProfilePortsConfig{DNS: ListenConfig{"5353"}},
+					Blocking: BlockingConfig{
+						ClientGroupsBlock: map[string][]string{"default": {"ads"}},
+					},
+				},
+			}
+		})
+
+		It("should use the listeners, blocking and caching of the profile", func() {
+			profileCfg, err := cfg.ForProfile("kids")
+			Expect(err).Should(Succeed())
+
+			Expect(profileCfg.Ports).Should(Equal(PortsConfig{DNS: ListenConfig{"5353"}}))
+			Expect(profileCfg.Blocking.ClientGroupsBlock).Should(HaveKey("default"))
+			Expect(profileCfg.Profiles).Should(BeEmpty())
+			Expect(profileCfg.Watchdog.IsEnabled()).Should(BeFalse())
+		})
+
+		When("the profile has no upstreams", func() {
+			It("should use the upstreams of the main configuration", func() {
+				profileCfg, err := cfg.ForProfile("kids")
+				Expect(err).Should(Succeed())
+
+				Expect(profileCfg.Upstreams.Groups).Should(Equal(cfg.Upstreams.Groups))
+			})
+		})
+
+		When("the profile has upstreams", func() {
+			It("should use the upstreams of the profile", func() {
+				profile := cfg.Profiles["kids"]
+				profile.Upstreams.Groups = UpstreamGroups{
+					UpstreamDefaultCfgName: {Upstream{Net: NetProtocolTcpUdp, Host: "9.9.9.9", Port: 53}},
+				}
+				cfg.Profiles["kids"] = profile
+
+				profileCfg, err := cfg.ForProfile("kids")
+				Expect(err).Should(Succeed())
+
+				Expect(profileCfg.Upstreams.Groups[UpstreamDefaultCfgName][0].Host).Should(Equal("9.9.9.9"))
+			})
+		})
+
+		When("the profile is unknown", func() {
+			It("should return error", func() {
+				_, err := cfg.ForProfile("unknown")
+				Expect(err).Should(MatchError("unknown profile 'unknown'"))
+			})
+		})
+	})
+})
@@ -27,6 +27,19 @@ func (s QTypeSet) Contains(qType dns.Type) bool {
 	return found
 }
 
+// String returns the sorted, comma separated query types
+func (s QTypeSet) String() string {
+	types := make([]string, 0, len(s))
+
+	for qType := range s {
+		types = append(types, qType.String())
+	}
+
+	sort.Strings(types)
+
+	return strings.Join(types, ", ")
+}
+
 func (s *QTypeSet) Insert(qType dns.Type) {
 	if *s == nil {
 		*s = make(QTypeSet, 1)
@@ -13,6 +13,19 @@ type QueryLogConfig struct {
 	CreationCooldown Duration        `yaml:"creationCooldown" default:"2s"`
 	Fields           []QueryLogField `yaml:"fields"`
 	FlushInterval    Duration        `yaml:"flushInterval" default:"30s"`
+	Answer           QueryLogAnswer  `yaml:"answer"`
+}
+
+// QueryLogAnswer configures the rendering of the answer field
+type QueryLogAnswer struct {
+	// max number of logged records, the others are replaced with "…+N more". 0 means unlimited
+	MaxRecords uint `yaml:"maxRecords" default:"0"`
+	// max length of a single record, longer records are cut. 0 means unlimited
+	MaxLength uint `yaml:"maxLength" default:"0"`
+	// log the size of TXT and NULL records instead of their content
+	OmitPayloads bool `yaml:"omitPayloads" default:"false"`
+	// log only the first record of each type
+	Compact bool `yaml:"compact" default:"false"`
 }
 
 // SetDefaults implements `defaults.Setter`.
@@ -40,4 +53,9 @@ func (c *QueryLogConfig) LogConfig(logger *logrus.Entry) {
 	logger.Debugf("creationCooldown: %s", c.CreationCooldown)
 	logger.Infof("flushInterval: %s", c.FlushInterval)
 	logger.Infof("fields: %s", c.Fields)
+	logger.Info("answer:")
+	logger.Infof("  maxRecords   = %d", c.Answer.MaxRecords)
+	logger.Infof("  maxLength    = %d", c.Answer.MaxLength)
+	logger.Infof("  omitPayloads = %t", c.Answer.OmitPayloads)
+	logger.Infof("  compact      = %t", c.Answer.Compact)
 }
@@ -0,0 +1,92 @@
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal --names --values
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitAction action taken for queries over the rate limit ENUM(
+// refuse // answer with REFUSED
+// drop   // don't answer at all
+// )
+type RateLimitAction uint8
+
+// RateLimitConfig configuration for the per client query rate limiting
+type RateLimitConfig struct {
+	// Rate is the number of queries per second a client may send, 0 disables the rate limiting
+	Rate uint `yaml:"rate" default:"0"`
+	// Burst is the number of queries a client may send at once, 0 uses the rate
+	Burst             uint            `yaml:"burst" default:"0"`
+	Action            RateLimitAction `yaml:"action" default:"refuse"`
+	Exempt            []string        `yaml:"exempt"`
+	ExemptLocalhost   bool            `yaml:"exemptLocalhost" default:"true"`
+	MaxMetricsClients uint            `yaml:"maxMetricsClients" default:"10"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *RateLimitConfig) IsEnabled() bool {
+	return c.Rate > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *RateLimitConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("rate = %d queries/s", c.Rate)
+	logger.Infof("burst = %d queries", c.BurstSize())
+	logger.Infof("action = %s", c.Action)
+
+	if len(c.Exempt) != 0 {
+		logger.Infof("exempt = %s", strings.Join(c.Exempt, ", "))
+	}
+
+	logger.Infof("exemptLocalhost = %t", c.ExemptLocalhost)
+	logger.Infof("maxMetricsClients = %d", c.MaxMetricsClients)
+}
+
+// BurstSize returns the number of queries a client may send at once
+func (c *RateLimitConfig) BurstSize() uint {
+	if c.Burst == 0 {
+		return c.Rate
+	}
+
+	return c.Burst
+}
+
+// ExemptNetworks returns the networks of the exempt clients, a single IP is a network of one address
+func (c *RateLimitConfig) ExemptNetworks() ([]*net.IPNet, error) {
+	networks, err := parseClientNetworks(c.Exempt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exempt client %w", err)
+	}
+
+	return networks, nil
+}
+
+// parseClientNetworks parses IPs and CIDRs of clients, a single IP is a network of one address
+func parseClientNetworks(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+
+	for _, entry := range entries {
+		cidr := entry
+
+		if ip := net.ParseIP(entry); ip != nil {
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("'%s', must be an IP or CIDR", entry)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
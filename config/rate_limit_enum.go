@@ -0,0 +1,94 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// RateLimitActionRefuse is a RateLimitAction of type Refuse.
+	// answer with REFUSED
+	RateLimitActionRefuse RateLimitAction = iota
+	// RateLimitActionDrop is a RateLimitAction of type Drop.
+	// don't answer at all
+	RateLimitActionDrop
+)
+
+var ErrInvalidRateLimitAction = fmt.Errorf("not a valid RateLimitAction, try [%s]", strings.Join(_RateLimitActionNames, ", "))
+
+const _RateLimitActionName = "refusedrop"
+
+var _RateLimitActionNames = []string{
+	_RateLimitActionName[0:6],
+	_RateLimitActionName[6:10],
+}
+
+// RateLimitActionNames returns a list of possible string values of RateLimitAction.
+func RateLimitActionNames() []string {
+	tmp := make([]string, len(_RateLimitActionNames))
+	copy(tmp, _RateLimitActionNames)
+	return tmp
+}
+
+// RateLimitActionValues returns a list of the values for RateLimitAction
+func RateLimitActionValues() []RateLimitAction {
+	return []RateLimitAction{
+		RateLimitActionRefuse,
+		RateLimitActionDrop,
+	}
+}
+
+var _RateLimitActionMap = map[RateLimitAction]string{
+	RateLimitActionRefuse: _RateLimitActionName[0:6],
+	RateLimitActionDrop:   _RateLimitActionName[6:10],
+}
+
+// String implements the Stringer interface.
+func (x RateLimitAction) String() string {
+	if str, ok := _RateLimitActionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("RateLimitAction(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x RateLimitAction) IsValid() bool {
+	_, ok := _RateLimitActionMap[x]
+	return ok
+}
+
+var _RateLimitActionValue = map[string]RateLimitAction{
+	_RateLimitActionName[0:6]:  RateLimitActionRefuse,
+	_RateLimitActionName[6:10]: RateLimitActionDrop,
+}
+
+// ParseRateLimitAction attempts to convert a string to a RateLimitAction.
+func ParseRateLimitAction(name string) (RateLimitAction, error) {
+	if x, ok := _RateLimitActionValue[name]; ok {
+		return x, nil
+	}
+	return RateLimitAction(0), fmt.Errorf("%s is %w", name, ErrInvalidRateLimitAction)
+}
+
+// MarshalText implements the text marshaller method.
+func (x RateLimitAction) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *RateLimitAction) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseRateLimitAction(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimitConfig", func() {
+	var cfg RateLimitConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[RateLimitConfig]()
+		Expect(err).Should(Succeed())
+
+		cfg.Rate = 20
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg, err := WithDefaults[RateLimitConfig]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+			Expect(cfg.Action).Should(Equal(RateLimitActionRefuse))
+			Expect(cfg.ExemptLocalhost).Should(BeTrue())
+		})
+
+		When("a rate is set", func() {
+			It("should be true", func() {
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("BurstSize", func() {
+		It("should default to the rate", func() {
+			Expect(cfg.BurstSize()).Should(BeNumerically("==", 20))
+
+			cfg.Burst = 50
+
+			Expect(cfg.BurstSize()).Should(BeNumerically("==", 50))
+		})
+	})
+
+	Describe("ExemptNetworks", func() {
+		It("should accept IPs and CIDRs", func() {
+			cfg.Exempt = []string{"192.168.178.10", "10.0.0.0/8", "fd00::1"}
+
+			networks, err := cfg.ExemptNetworks()
+			Expect(err).Should(Succeed())
+			Expect(networks).Should(HaveLen(3))
+
+			Expect(networks[0].String()).Should(Equal("192.168.178.10/32"))
+			Expect(networks[1].Contains(net.ParseIP("10.1.2.3"))).Should(BeTrue())
+			Expect(networks[2].String()).Should(Equal("fd00::1/128"))
+		})
+
+		It("should fail for invalid entries", func() {
+			cfg.Exempt = []string{"my-laptop"}
+
+			_, err := cfg.ExemptNetworks()
+			Expect(err).Should(MatchError(ContainSubstring("invalid exempt client 'my-laptop'")))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the configuration", func() {
+			cfg.Exempt = []string{"10.0.0.0/8"}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+			Expect(hook.Messages).Should(ContainElements(
+				"rate = 20 queries/s",
+				"burst = 20 queries",
+				"action = refuse",
+				"exempt = 10.0.0.0/8",
+			))
+		})
+	})
+})
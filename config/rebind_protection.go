@@ -0,0 +1,51 @@
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal --names --values
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RebindProtectionAction action taken for upstream answers with private addresses ENUM(
+// nxdomain // answer with NXDOMAIN
+// strip    // remove the private addresses from the answer
+// )
+type RebindProtectionAction uint8
+
+// RebindProtectionConfig configuration of the DNS rebinding protection
+type RebindProtectionConfig struct {
+	Enable bool                   `yaml:"enable" default:"false"`
+	Action RebindProtectionAction `yaml:"action" default:"nxdomain"`
+	// AllowedDomains may resolve to private addresses, e.g. `*.plex.direct`
+	AllowedDomains    []string `yaml:"allowedDomains"`
+	MaxMetricsDomains uint     `yaml:"maxMetricsDomains" default:"10"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *RebindProtectionConfig) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *RebindProtectionConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("action = %s", c.Action)
+
+	if len(c.AllowedDomains) != 0 {
+		logger.Infof("allowedDomains = %s", strings.Join(c.AllowedDomains, ", "))
+	}
+
+	logger.Infof("maxMetricsDomains = %d", c.MaxMetricsDomains)
+}
+
+// validate checks that the allowed domains are valid
+func (c *RebindProtectionConfig) validate() error {
+	for _, domain := range c.AllowedDomains {
+		if err := validateWildcardDomain(domain); err != nil {
+			return fmt.Errorf("invalid allowed domain '%s': %w", domain, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// RebindProtectionActionNxdomain is a RebindProtectionAction of type Nxdomain.
+	// answer with NXDOMAIN
+	RebindProtectionActionNxdomain RebindProtectionAction = iota
+	// RebindProtectionActionStrip is a RebindProtectionAction of type Strip.
+	// remove the private addresses from the answer
+	RebindProtectionActionStrip
+)
+
+var ErrInvalidRebindProtectionAction = fmt.Errorf("not a valid RebindProtectionAction, try [%s]", strings.Join(_RebindProtectionActionNames, ", "))
+
+const _RebindProtectionActionName = "nxdomainstrip"
+
+var _RebindProtectionActionNames = []string{
+	_RebindProtectionActionName[0:8],
+	_RebindProtectionActionName[8:13],
+}
+
+// RebindProtectionActionNames returns a list of possible string values of RebindProtectionAction.
+func RebindProtectionActionNames() []string {
+	tmp := make([]string, len(_RebindProtectionActionNames))
+	copy(tmp, _RebindProtectionActionNames)
+	return tmp
+}
+
+// RebindProtectionActionValues returns a list of the values for RebindProtectionAction
+func RebindProtectionActionValues() []RebindProtectionAction {
+	return []RebindProtectionAction{
+		RebindProtectionActionNxdomain,
+		RebindProtectionActionStrip,
+	}
+}
+
+var _RebindProtectionActionMap = map[RebindProtectionAction]string{
+	RebindProtectionActionNxdomain: _RebindProtectionActionName[0:8],
+	RebindProtectionActionStrip:    _RebindProtectionActionName[8:13],
+}
+
+// String implements the Stringer interface.
+func (x RebindProtectionAction) String() string {
+	if str, ok := _RebindProtectionActionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("RebindProtectionAction(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x RebindProtectionAction) IsValid() bool {
+	_, ok := _RebindProtectionActionMap[x]
+	return ok
+}
+
+var _RebindProtectionActionValue = map[string]RebindProtectionAction{
+	_RebindProtectionActionName[0:8]:  RebindProtectionActionNxdomain,
+	_RebindProtectionActionName[8:13]: RebindProtectionActionStrip,
+}
+
+// ParseRebindProtectionAction attempts to convert a string to a RebindProtectionAction.
+func ParseRebindProtectionAction(name string) (RebindProtectionAction, error) {
+	if x, ok := _RebindProtectionActionValue[name]; ok {
+		return x, nil
+	}
+	return RebindProtectionAction(0), fmt.Errorf("%s is %w", name, ErrInvalidRebindProtectionAction)
+}
+
+// MarshalText implements the text marshaller method.
+func (x RebindProtectionAction) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *RebindProtectionAction) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseRebindProtectionAction(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RebindProtectionConfig", func() {
+	var cfg RebindProtectionConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[RebindProtectionConfig]()
+		Expect(err).Should(Succeed())
+
+		cfg.Enable = true
+		cfg.AllowedDomains = []string{"*.plex.direct", "router.example.com"}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg, err := WithDefaults[RebindProtectionConfig]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+			Expect(cfg.Action).Should(Equal(RebindProtectionActionNxdomain))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept domains and wildcards", func() {
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should fail for invalid domains", func() {
+			cfg.AllowedDomains = []string{"plex.*"}
+
+			Expect(cfg.validate()).Should(MatchError(ContainSubstring("invalid allowed domain 'plex.*'")))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"action = nxdomain",
+				"allowedDomains = *.plex.direct, router.example.com",
+			))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxPercent = 100
+
+// RevalidateThreshold is the remaining TTL of a cache entry below which it's refreshed in the background.
+// It's configured either as duration (e.g. 30s) or as percentage of the entry's TTL (e.g. 10%)
+type RevalidateThreshold struct {
+	Duration Duration
+	Percent  uint
+}
+
+// IsEnabled returns true if a threshold is configured
+func (t RevalidateThreshold) IsEnabled() bool {
+	return t.Duration.IsAboveZero() || t.Percent > 0
+}
+
+// IsReached returns true if the remaining TTL of an entry with the original TTL is below the threshold
+func (t RevalidateThreshold) IsReached(remaining, ttl time.Duration) bool {
+	if t.Percent > 0 {
+		return remaining <= ttl*time.Duration(t.Percent)/maxPercent
+	}
+
+	return t.Duration.IsAboveZero() && remaining <= t.Duration.ToDuration()
+}
+
+func (t RevalidateThreshold) String() string {
+	if t.Percent > 0 {
+		return fmt.Sprintf("%d%% of TTL", t.Percent)
+	}
+
+	return t.Duration.String()
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`.
+func (t *RevalidateThreshold) UnmarshalText(data []byte) error {
+	input := strings.TrimSpace(string(data))
+
+	if value, found := strings.CutSuffix(input, "%"); found {
+		percent, err := strconv.ParseUint(strings.TrimSpace(value), 10, 8)
+		if err != nil || percent == 0 || percent > maxPercent {
+			return fmt.Errorf("invalid percentage '%s', expected a number between 1 and 100", input)
+		}
+
+		*t = RevalidateThreshold{Percent: uint(percent)}
+
+		return nil
+	}
+
+	var d Duration
+	if err := d.UnmarshalText([]byte(input)); err != nil {
+		return fmt.Errorf("invalid threshold '%s', expected a duration or percentage: %w", input, err)
+	}
+
+	if d < 0 {
+		return fmt.Errorf("invalid threshold '%s', must not be negative", input)
+	}
+
+	*t = RevalidateThreshold{Duration: d}
+
+	return nil
+}
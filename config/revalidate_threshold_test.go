@@ -0,0 +1,75 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("RevalidateThreshold", func() {
+	var t RevalidateThreshold
+
+	BeforeEach(func() {
+		t = RevalidateThreshold{}
+	})
+
+	Describe("UnmarshalText", func() {
+		DescribeTable("should parse threshold",
+			func(input string, expected RevalidateThreshold) {
+				Expect(t.UnmarshalText([]byte(input))).Should(Succeed())
+				Expect(t).Should(Equal(expected))
+			},
+			Entry("duration", "30s", RevalidateThreshold{Duration: Duration(30 * time.Second)}),
+			Entry("percentage", "10%", RevalidateThreshold{Percent: 10}),
+			Entry("percentage with space", " 25 % ", RevalidateThreshold{Percent: 25}),
+		)
+
+		DescribeTable("should fail for invalid threshold",
+			func(input string) {
+				Expect(t.UnmarshalText([]byte(input))).ShouldNot(Succeed())
+			},
+			Entry("no number", "wrong"),
+			Entry("zero percent", "0%"),
+			Entry("more than 100 percent", "101%"),
+			Entry("negative duration", "-5s"),
+		)
+
+		It("should be used by the caching config", func() {
+			var cfg CachingConfig
+
+			Expect(yaml.UnmarshalStrict([]byte("staleWhileRevalidate: 20%"), &cfg)).Should(Succeed())
+			Expect(cfg.StaleWhileRevalidate).Should(Equal(RevalidateThreshold{Percent: 20}))
+		})
+	})
+
+	Describe("IsReached", func() {
+		It("should compare the remaining TTL with the duration", func() {
+			t = RevalidateThreshold{Duration: Duration(30 * time.Second)}
+
+			Expect(t.IsEnabled()).Should(BeTrue())
+			Expect(t.IsReached(20*time.Second, time.Hour)).Should(BeTrue())
+			Expect(t.IsReached(time.Minute, time.Hour)).Should(BeFalse())
+		})
+
+		It("should compare the remaining TTL with the percentage of the TTL", func() {
+			t = RevalidateThreshold{Percent: 10}
+
+			Expect(t.IsReached(5*time.Minute, time.Hour)).Should(BeTrue())
+			Expect(t.IsReached(10*time.Minute, time.Hour)).Should(BeFalse())
+		})
+
+		It("should never be reached if disabled", func() {
+			Expect(t.IsEnabled()).Should(BeFalse())
+			Expect(t.IsReached(0, time.Hour)).Should(BeFalse())
+		})
+	})
+
+	Describe("String", func() {
+		It("should return the threshold", func() {
+			Expect(RevalidateThreshold{Percent: 10}.String()).Should(Equal("10% of TTL"))
+			Expect(RevalidateThreshold{Duration: Duration(30 * time.Second)}.String()).Should(Equal("30 seconds"))
+		})
+	})
+})
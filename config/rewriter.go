@@ -1,6 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -10,6 +15,13 @@ type RewriterConfig struct {
 	FallbackUpstream bool              `yaml:"fallbackUpstream" default:"false"`
 }
 
+// RewriteRegexRule rewrites domains matching Pattern to Replacement, which may reference capture groups (`$1`)
+type RewriteRegexRule struct {
+	Key         string
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
 // IsEnabled implements `config.Configurable`.
 func (c *RewriterConfig) IsEnabled() bool {
 	return len(c.Rewrite) != 0
@@ -25,3 +37,36 @@ func (c *RewriterConfig) LogConfig(logger *logrus.Entry) {
 		logger.Infof("  %s = %s", key, val)
 	}
 }
+
+// IsRewriteRegex returns true if the rewrite key is a regex: a non-empty pattern wrapped in slashes
+func IsRewriteRegex(key string) bool {
+	return len(key) > 2 && strings.HasPrefix(key, "/") && strings.HasSuffix(key, "/")
+}
+
+// RegexRules compiles the regex rewrite rules, sorted by key
+func (c *RewriterConfig) RegexRules() ([]RewriteRegexRule, error) {
+	var rules []RewriteRegexRule
+
+	for key, replacement := range c.Rewrite {
+		if !IsRewriteRegex(key) {
+			continue
+		}
+
+		pattern, err := regexp.Compile(strings.TrimSuffix(strings.TrimPrefix(key, "/"), "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite regex '%s': %w", key, err)
+		}
+
+		if len(replacement) == 0 {
+			return nil, fmt.Errorf("invalid rewrite regex '%s': empty replacement", key)
+		}
+
+		rules = append(rules, RewriteRegexRule{Key: key, Pattern: pattern, Replacement: replacement})
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Key < rules[j].Key
+	})
+
+	return rules, nil
+}
@@ -52,4 +52,37 @@ var _ = Describe("RewriterConfig", func() {
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("original2 =")))
 		})
 	})
+	Describe("RegexRules", func() {
+		It("should only return regex rules, sorted by key", func() {
+			cfg.Rewrite[`/^b\.(.*)$/`] = "$1"
+			cfg.Rewrite[`/^a\.(.*)$/`] = "x.$1"
+
+			rules, err := cfg.RegexRules()
+			Expect(err).Should(Succeed())
+			Expect(rules).Should(HaveLen(2))
+			Expect(rules[0].Key).Should(Equal(`/^a\.(.*)$/`))
+			Expect(rules[0].Pattern.ReplaceAllString("a.lan", rules[0].Replacement)).Should(Equal("x.lan"))
+			Expect(rules[1].Key).Should(Equal(`/^b\.(.*)$/`))
+		})
+
+		It("should not treat slashes alone as regex", func() {
+			cfg.Rewrite["//"] = "x"
+
+			Expect(cfg.RegexRules()).Should(BeEmpty())
+		})
+
+		It("should fail on invalid patterns", func() {
+			cfg.Rewrite[`/^(a$/`] = "b"
+
+			_, err := cfg.RegexRules()
+			Expect(err).Should(MatchError(ContainSubstring("invalid rewrite regex '/^(a$/'")))
+		})
+
+		It("should fail on empty replacements", func() {
+			cfg.Rewrite[`/^a$/`] = ""
+
+			_, err := cfg.RegexRules()
+			Expect(err).Should(MatchError(ContainSubstring("empty replacement")))
+		})
+	})
 })
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/0xERR0R/blocky/trie"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// StaticResponsesConfig rules which answer matching queries with a fixed response
+type StaticResponsesConfig struct {
+	Rules []StaticResponseRule `yaml:"rules"`
+}
+
+// StaticResponseRule matches queries by name, type and client and defines the complete response.
+// The resource records are parsed on load, so the resolver only copies them.
+type StaticResponseRule struct {
+	// Name matches only this domain
+	Name string
+	// Suffix matches the domain and all its subdomains
+	Suffix string
+	// suffix contains Suffix for the matching
+	suffix *trie.Trie
+	// QueryTypes limits the rule to queries of these types, it applies to all types if empty
+	QueryTypes QTypeSet
+	// Clients limits the rule to these clients (names with wildcards, IPs or CIDRs), it applies to all if empty
+	Clients []string
+
+	Rcode      int
+	Answer     []dns.RR
+	Authority  []dns.RR
+	Additional []dns.RR
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *StaticResponsesConfig) IsEnabled() bool {
+	return len(c.Rules) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *StaticResponsesConfig) LogConfig(logger *logrus.Entry) {
+	logger.Info("rules:")
+
+	for i := range c.Rules {
+		logger.Infof("  %s", &c.Rules[i])
+	}
+}
+
+// Matches returns true if the rule applies to the domain (lower case and without trailing dot) and query type
+func (r *StaticResponseRule) Matches(domain string, qType dns.Type) bool {
+	if len(r.QueryTypes) != 0 && !r.QueryTypes.Contains(qType) {
+		return false
+	}
+
+	if r.Name != "" {
+		return domain == r.Name
+	}
+
+	return r.suffix.HasParentOf(domain)
+}
+
+func (r *StaticResponseRule) String() string {
+	var sb strings.Builder
+
+	if r.Name != "" {
+		sb.WriteString(r.Name)
+	} else {
+		fmt.Fprintf(&sb, "*.%s", r.Suffix)
+	}
+
+	if len(r.QueryTypes) != 0 {
+		fmt.Fprintf(&sb, " [%s]", r.QueryTypes)
+	}
+
+	if len(r.Clients) != 0 {
+		fmt.Fprintf(&sb, " clients: %s", strings.Join(r.Clients, ", "))
+	}
+
+	fmt.Fprintf(&sb, " = %s", dns.RcodeToString[r.Rcode])
+
+	if count := len(r.Answer) + len(r.Authority) + len(r.Additional); count != 0 {
+		fmt.Fprintf(&sb, ", %d records", count)
+	}
+
+	return sb.String()
+}
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+// The records are multi-line strings in zone file syntax, relative names are relative to the root.
+func (r *StaticResponseRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var input struct {
+		Name       string   `yaml:"name"`
+		Suffix     string   `yaml:"suffix"`
+		QueryTypes QTypeSet `yaml:"queryTypes"`
+		Clients    []string `yaml:"clients"`
+		Rcode      string   `yaml:"rcode"`
+		Answer     string   `yaml:"answer"`
+		Authority  string   `yaml:"authority"`
+		Additional string   `yaml:"additional"`
+	}
+
+	if err := unmarshal(&input); err != nil {
+		return err
+	}
+
+	if (input.Name == "") == (input.Suffix == "") {
+		return errors.New("static response: either name or suffix must be set")
+	}
+
+	rule := StaticResponseRule{
+		Name:       util.NormalizeDomain(input.Name),
+		Suffix:     util.NormalizeDomain(input.Suffix),
+		QueryTypes: input.QueryTypes,
+		Clients:    input.Clients,
+	}
+
+	if rule.Suffix != "" {
+		rule.suffix = trie.NewTrie(trie.SplitTLD)
+		rule.suffix.Insert(rule.Suffix)
+	}
+
+	if input.Rcode == "" && input.Answer == "" && input.Authority == "" && input.Additional == "" {
+		return fmt.Errorf("static response %s: rcode or records must be set", &rule)
+	}
+
+	if input.Rcode != "" {
+		rcode, ok := dns.StringToRcode[strings.ToUpper(input.Rcode)]
+		if !ok {
+			return fmt.Errorf("static response %s: unknown rcode '%s'", &rule, input.Rcode)
+		}
+
+		rule.Rcode = rcode
+	}
+
+	var err error
+
+	sections := []struct {
+		name   string
+		input  string
+		target *[]dns.RR
+	}{
+		{"answer", input.Answer, &rule.Answer},
+		{"authority", input.Authority, &rule.Authority},
+		{"additional", input.Additional, &rule.Additional},
+	}
+
+	for _, section := range sections {
+		*section.target, err = parseRRs(section.input)
+		if err != nil {
+			return fmt.Errorf("static response %s: %s: %w", &rule, section.name, err)
+		}
+	}
+
+	*r = rule
+
+	return nil
+}
+
+// parseRRs parses resource records in zone file syntax, errors contain the line of the record
+func parseRRs(records string) ([]dns.RR, error) {
+	var res []dns.RR
+
+	zp := dns.NewZoneParser(strings.NewReader(records), ".", "")
+
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		res = append(res, rr)
+	}
+
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("StaticResponsesConfig", func() {
+	var cfg StaticResponsesConfig
+
+	suiteBeforeEach()
+
+	parse := func(data string) error {
+		cfg = StaticResponsesConfig{}
+
+		return yaml.UnmarshalStrict([]byte(data), &cfg)
+	}
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(defaults.Set(&cfg)).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with rules", func() {
+			Expect(parse(`
+rules:
+  - name: blocked.home.lan
+    rcode: REFUSED
+`)).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("UnmarshalYAML", func() {
+		It("should parse the rules", func() {
+			Expect(parse(`
+rules:
+  - name: _dmarc.Home.lan.
+    queryTypes: [TXT]
+    answer: |
+      _dmarc.home.lan. 300 IN TXT "v=DMARC1; p=reject"
+  - suffix: example.com
+    queryTypes: [NS]
+    clients: [lab-*, 10.0.42.0/24]
+    answer: example.com. 3600 IN NS ns.lab.lan.
+    additional: |
+      ns.lab.lan. 3600 IN A 10.0.42.53
+  - name: nx.home.lan
+    rcode: nxdomain
+    authority: home.lan. 60 IN SOA ns.home.lan. admin.home.lan. 1 3600 600 86400 60
+`)).Should(Succeed())
+
+			Expect(cfg.Rules).Should(HaveLen(3))
+
+			dmarc := cfg.Rules[0]
+			Expect(dmarc.Name).Should(Equal("_dmarc.home.lan"))
+			Expect(dmarc.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(dmarc.Answer).Should(HaveLen(1))
+			Expect(dmarc.Answer[0].String()).Should(Equal("_dmarc.home.lan.\t300\tIN\tTXT\t\"v=DMARC1; p=reject\""))
+
+			lab := cfg.Rules[1]
+			Expect(lab.Suffix).Should(Equal("example.com"))
+			Expect(lab.Clients).Should(Equal([]string{"lab-*", "10.0.42.0/24"}))
+			Expect(lab.Answer).Should(HaveLen(1))
+			Expect(lab.Additional).Should(HaveLen(1))
+
+			nx := cfg.Rules[2]
+			Expect(nx.Rcode).Should(Equal(dns.RcodeNameError))
+			Expect(nx.Authority).Should(HaveLen(1))
+		})
+
+		It("should fail without name and suffix", func() {
+			Expect(parse(`
+rules:
+  - rcode: REFUSED
+`)).Should(MatchError(ContainSubstring("either name or suffix must be set")))
+		})
+
+		It("should fail with name and suffix", func() {
+			Expect(parse(`
+rules:
+  - name: example.com
+    suffix: example.com
+    rcode: REFUSED
+`)).Should(MatchError(ContainSubstring("either name or suffix must be set")))
+		})
+
+		It("should fail without response", func() {
+			Expect(parse(`
+rules:
+  - name: example.com
+`)).Should(MatchError(ContainSubstring("rcode or records must be set")))
+		})
+
+		It("should fail for an unknown rcode", func() {
+			Expect(parse(`
+rules:
+  - name: example.com
+    rcode: GONE
+`)).Should(MatchError(ContainSubstring("unknown rcode 'GONE'")))
+		})
+
+		It("should report the line of an invalid record", func() {
+			Expect(parse(`
+rules:
+  - name: example.com
+    answer: |
+      example.com. 300 IN A 192.0.2.1
+      example.com. 300 IN A 192.0.2.300
+`)).Should(MatchError(SatisfyAll(
+				ContainSubstring("static response example.com = NOERROR: answer"),
+				ContainSubstring("line: 2"),
+			)))
+		})
+	})
+
+	Describe("Matches", func() {
+		BeforeEach(func() {
+			Expect(parse(`
+rules:
+  - name: exact.example.com
+    rcode: REFUSED
+  - suffix: example.org
+    queryTypes: [A, AAAA]
+    rcode: REFUSED
+`)).Should(Succeed())
+		})
+
+		It("should match the name exactly", func() {
+			rule := cfg.Rules[0]
+
+			Expect(rule.Matches("exact.example.com", dns.Type(dns.TypeTXT))).Should(BeTrue())
+			Expect(rule.Matches("sub.exact.example.com", dns.Type(dns.TypeTXT))).Should(BeFalse())
+		})
+
+		It("should match the suffix and the query types", func() {
+			rule := cfg.Rules[1]
+
+			Expect(rule.Matches("example.org", dns.Type(dns.TypeA))).Should(BeTrue())
+			Expect(rule.Matches("www.example.org", dns.Type(dns.TypeAAAA))).Should(BeTrue())
+			Expect(rule.Matches("www.example.org", dns.Type(dns.TypeMX))).Should(BeFalse())
+			Expect(rule.Matches("notexample.org", dns.Type(dns.TypeA))).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the rules", func() {
+			Expect(parse(`
+rules:
+  - suffix: example.com
+    queryTypes: [NS]
+    clients: [lab-*]
+    answer: example.com. 3600 IN NS ns.lab.lan.
+`)).Should(Succeed())
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("  *.example.com [NS] clients: lab-* = NOERROR, 1 records")))
+		})
+	})
+})
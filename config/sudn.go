@@ -4,22 +4,39 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// SUDNConfig configuration for Special Use Domain Names
+// SUDNConfig configuration for Special Use Domain Names.
+// Each kind of domain can be disabled, e.g. to forward `.local` to an mDNS gateway.
 type SUDNConfig struct {
 	// These are "recommended for private use" but not mandatory.
 	// If a user wishes to use one, it will most likely be via conditional
 	// upstream or custom DNS, which come before SUDN in the resolver chain.
 	// Thus defaulting to `true` and returning NXDOMAIN here should not conflict.
 	RFC6762AppendixG bool `yaml:"rfc6762-appendixG" default:"true"`
+	// PrivateReverseZones answers the reverse zones of the RFC 1918 private networks
+	PrivateReverseZones bool `yaml:"privateReverseZones" default:"true"`
+	Test                bool `yaml:"test" default:"true"`
+	Localhost           bool `yaml:"localhost" default:"true"`
+	Invalid             bool `yaml:"invalid" default:"true"`
+	// Local answers `.local` and the link-local reverse zones, which are resolved by mDNS
+	Local    bool `yaml:"local" default:"true"`
+	Onion    bool `yaml:"onion" default:"true"`
+	HomeArpa bool `yaml:"homeArpa" default:"true"`
 }
 
 // IsEnabled implements `config.Configurable`.
 func (c *SUDNConfig) IsEnabled() bool {
-	// The Special Use RFCs are always active
-	return true
+	return c.RFC6762AppendixG || c.PrivateReverseZones || c.Test || c.Localhost || c.Invalid || c.Local ||
+		c.Onion || c.HomeArpa
 }
 
 // LogConfig implements `config.Configurable`.
 func (c *SUDNConfig) LogConfig(logger *logrus.Entry) {
 	logger.Debugf("rfc6762-appendixG = %v", c.RFC6762AppendixG)
+	logger.Debugf("privateReverseZones = %v", c.PrivateReverseZones)
+	logger.Debugf("test = %v", c.Test)
+	logger.Debugf("localhost = %v", c.Localhost)
+	logger.Debugf("invalid = %v", c.Invalid)
+	logger.Debugf("local = %v", c.Local)
+	logger.Debugf("onion = %v", c.Onion)
+	logger.Debugf("homeArpa = %v", c.HomeArpa)
 }
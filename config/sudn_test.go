@@ -21,6 +21,12 @@ var _ = Describe("SUDNConfig", func() {
 		It("is true", func() {
 			Expect(cfg.IsEnabled()).Should(BeTrue())
 		})
+
+		It("is false if all domain kinds are disabled", func() {
+			cfg = SUDNConfig{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
 	})
 
 	Describe("LogConfig", func() {
@@ -28,7 +34,10 @@ var _ = Describe("SUDNConfig", func() {
 			cfg.LogConfig(logger)
 
 			Expect(hook.Calls).ShouldNot(BeEmpty())
-			Expect(hook.Messages).Should(ContainElement(ContainSubstring("rfc6762-appendixG = true")))
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("rfc6762-appendixG = true"),
+				ContainSubstring("local = true"),
+			))
 		})
 	})
 })
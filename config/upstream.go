@@ -5,6 +5,8 @@ import (
 	"net"
 	"regexp"
 	"strings"
+
+	"github.com/0xERR0R/blocky/log"
 )
 
 var validDomain = regexp.MustCompile(
@@ -14,9 +16,11 @@ var validDomain = regexp.MustCompile(
 type Upstream struct {
 	Net        NetProtocol
 	Host       string
+	Zone       string // IPv6 zone, e.g. the interface of a link-local address; optional
 	Port       uint16
 	Path       string
 	CommonName string // Common Name to use for certificate verification; optional. "" uses .Host
+	Padding    bool   // pad queries with EDNS0 padding (RFC 7830/8467); only for encrypted protocols
 }
 
 // IsDefault returns true if u is the default value
@@ -24,6 +28,11 @@ func (u *Upstream) IsDefault() bool {
 	return *u == Upstream{}
 }
 
+// IsEncrypted returns true if the protocol of u encrypts the queries
+func (u *Upstream) IsEncrypted() bool {
+	return u.Net != NetProtocolTcpUdp
+}
+
 // String returns the string representation of u
 func (u Upstream) String() string {
 	if u.IsDefault() {
@@ -43,6 +52,12 @@ func (u Upstream) String() string {
 	if isIPv6 {
 		sb.WriteRune('[')
 		sb.WriteString(u.Host)
+
+		if u.Zone != "" {
+			sb.WriteRune('%')
+			sb.WriteString(u.Zone)
+		}
+
 		sb.WriteRune(']')
 	} else {
 		sb.WriteString(u.Host)
@@ -74,91 +89,292 @@ func (u *Upstream) UnmarshalText(data []byte) error {
 	return nil
 }
 
-// ParseUpstream creates new Upstream from passed string in format [net]:host[:port][/path][#commonname]
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+// An upstream is either a string, or a mapping with the keys `upstream` and `padding`.
+func (u *Upstream) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var upstream string
+	if err := unmarshal(&upstream); err == nil {
+		return u.UnmarshalText([]byte(upstream))
+	}
+
+	// reject unknown keys, so mappings of other types with an `upstream` key don't match by accident
+	var keys map[string]interface{}
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+
+	for key := range keys {
+		if key != "upstream" && key != "padding" {
+			return fmt.Errorf("unknown upstream option '%s'", key)
+		}
+	}
+
+	var withOptions struct {
+		Upstream string `yaml:"upstream"`
+		Padding  bool   `yaml:"padding"`
+	}
+
+	if err := unmarshal(&withOptions); err != nil {
+		return err
+	}
+
+	if err := u.UnmarshalText([]byte(withOptions.Upstream)); err != nil {
+		return err
+	}
+
+	if withOptions.Padding && u.Net == NetProtocolTcpUdp {
+		log.Log().Warnf("upstream '%s': padding is only used for encrypted protocols (tcp-tls, https), ignoring it", u)
+
+		return nil
+	}
+
+	u.Padding = withOptions.Padding
+
+	return nil
+}
+
+// ParseUpstream creates new Upstream from passed string in format [net:]host[:port][/path][#commonname].
+// URL style schemes like `tls://` are accepted as well. Obvious mistakes are corrected with a warning.
 func ParseUpstream(upstream string) (Upstream, error) {
+	result, corrections, err := parseUpstream(strings.TrimSpace(upstream))
+	if err != nil {
+		return Upstream{}, err
+	}
+
+	for _, correction := range corrections {
+		log.Log().Warnf("upstream '%s': %s, using '%s'", upstream, correction, result)
+	}
+
+	return result, nil
+}
+
+// upstreamError describes what is wrong with an upstream definition and where
+type upstreamError struct {
+	pos     int // 1-based
+	problem string
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.pos, e.problem)
+}
+
+func errAt(pos int, format string, args ...any) error {
+	return &upstreamError{pos: pos, problem: fmt.Sprintf(format, args...)}
+}
+
+//nolint:gochecknoglobals
+var (
+	// upstreamSchemes maps URL style schemes, as used by other tools, to the protocol
+	upstreamSchemes = map[string]NetProtocol{
+		"udp":     NetProtocolTcpUdp,
+		"tcp":     NetProtocolTcpUdp,
+		"tcp+udp": NetProtocolTcpUdp,
+		"tls":     NetProtocolTcpTls,
+		"tcp-tls": NetProtocolTcpTls,
+		"https":   NetProtocolHttps,
+	}
+
+	unsupportedSchemes = map[string]string{
+		"quic": "DNS-over-QUIC isn't supported",
+		"h3":   "DNS-over-HTTP/3 isn't supported",
+		"sdns": "DNS stamps aren't supported",
+	}
+
+	portAfterPath = regexp.MustCompile(`^(.*):(\d+)$`)
+)
+
+const tlsPortNumber = 853
+
+//nolint:funlen
+func parseUpstream(in string) (result Upstream, corrections []string, err error) {
+	if len(in) == 0 {
+		return Upstream{}, nil, errAt(1, "empty upstream")
+	}
+
+	rest, commonName, hasCommonName := strings.Cut(in, "#")
+	if hasCommonName && len(commonName) == 0 {
+		return Upstream{}, nil, errAt(len(rest)+1, "empty common name after '#'")
+	}
+
+	n, explicitNet, offset, err := parseNet(rest)
+	if err != nil {
+		return Upstream{}, nil, err
+	}
+
+	rest = rest[offset:]
+
 	var path string
 
-	var port uint16
+	if idx := strings.IndexByte(rest, '/'); idx >= 0 {
+		if n != NetProtocolHttps {
+			return Upstream{}, nil, errAt(offset+idx+1, "a path is only supported for https")
+		}
 
-	commonName, upstream := extractCommonName(upstream)
+		path, rest = rest[idx:], rest[:idx]
+	}
 
-	n, upstream := extractNet(upstream)
+	if len(rest) == 0 {
+		return Upstream{}, nil, errAt(offset+1, "missing host")
+	}
 
-	path, upstream = extractPath(upstream)
+	host, portString, portPos, err := splitUpstreamHostPort(rest, offset, explicitNet)
+	if err != nil {
+		return Upstream{}, nil, err
+	}
+
+	host, zone, err := splitUpstreamZone(host, n, offset+strings.IndexByte(rest, '%')+1)
+	if err != nil {
+		return Upstream{}, nil, err
+	}
 
-	host, portString, err := net.SplitHostPort(upstream)
+	port := netDefaultPort[n]
 
-	// string contains host:port
-	if err == nil {
-		p, err := ConvertPort(portString)
+	if portPos >= 0 {
+		port, err = parseUpstreamPort(portString, portPos)
 		if err != nil {
-			err = fmt.Errorf("can't convert port to number (1 - 65535) %w", err)
+			return Upstream{}, nil, err
+		}
 
-			return Upstream{}, err
+		if !explicitNet && port == tlsPortNumber {
+			n = NetProtocolTcpTls
+
+			corrections = append(corrections, "port 853 is used for DNS-over-TLS")
+		}
+	} else if match := portAfterPath.FindStringSubmatch(path); match != nil {
+		port, err = parseUpstreamPort(match[2], offset+len(rest)+len(match[1])+2)
+		if err != nil {
+			return Upstream{}, nil, err
 		}
 
-		port = p
-	} else {
-		// only host, use default port
-		host = upstream
-		port = netDefaultPort[n]
+		path = match[1]
 
-		// trim any IPv6 brackets
-		host = strings.TrimPrefix(host, "[")
-		host = strings.TrimSuffix(host, "]")
+		corrections = append(corrections, "the port must be placed before the path")
 	}
 
 	// validate hostname or ip
-	if ip := net.ParseIP(host); ip == nil {
-		// is not IP
-		if !validDomain.MatchString(host) {
-			return Upstream{}, fmt.Errorf("wrong host name '%s'", host)
-		}
+	if ip := net.ParseIP(host); ip == nil && !validDomain.MatchString(host) {
+		return Upstream{}, nil, errAt(offset+1, "invalid host name '%s'", host)
 	}
 
 	return Upstream{
 		Net:        n,
 		Host:       host,
+		Zone:       zone,
 		Port:       port,
 		Path:       path,
 		CommonName: commonName,
-	}, nil
+	}, corrections, nil
 }
 
-func extractCommonName(in string) (string, string) {
-	upstream, cn, _ := strings.Cut(in, "#")
+// parseNet returns the protocol, if it was given and the length of its prefix
+func parseNet(in string) (n NetProtocol, explicit bool, prefixLen int, err error) {
+	if scheme, _, ok := strings.Cut(in, "://"); ok && !strings.ContainsAny(scheme, ".:[/") {
+		scheme = strings.ToLower(scheme)
+
+		if problem, ok := unsupportedSchemes[scheme]; ok {
+			return 0, false, 0, errAt(1, problem)
+		}
+
+		n, ok := upstreamSchemes[scheme]
+		if !ok {
+			return 0, false, 0, unknownNetError(scheme)
+		}
+
+		return n, true, len(scheme) + len("://"), nil
+	}
+
+	for _, n := range NetProtocolValues() {
+		prefix := n.String() + ":"
+		if strings.HasPrefix(in, prefix) {
+			return n, true, len(prefix), nil
+		}
+	}
 
-	return cn, upstream
+	return NetProtocolTcpUdp, false, 0, nil
 }
 
-func extractPath(in string) (path, upstream string) {
-	slashIdx := strings.Index(in, "/")
+func unknownNetError(name string) error {
+	return errAt(1, "unknown protocol '%s', supported are %s and URL style schemes like tls://",
+		name, strings.Join(NetProtocolNames(), ", "))
+}
 
-	if slashIdx >= 0 {
-		path = in[slashIdx:]
-		upstream = in[:slashIdx]
-	} else {
-		upstream = in
+// splitUpstreamHostPort splits host and optional port. The returned 1-based port position is -1 if there is no port
+func splitUpstreamHostPort(in string, offset int, explicitNet bool) (host, port string, portPos int, err error) {
+	if strings.HasPrefix(in, "[") {
+		end := strings.IndexByte(in, ']')
+		if end < 0 {
+			return "", "", 0, errAt(offset+1, "missing ']' after IPv6 address")
+		}
+
+		host, rest := in[1:end], in[end+1:]
+
+		switch {
+		case len(rest) == 0:
+			return host, "", -1, nil
+		case rest[0] == ':':
+			return host, rest[1:], offset + end + 3, nil
+		default:
+			return "", "", 0, errAt(offset+end+2, "unexpected '%s' after IPv6 address", rest)
+		}
+	}
+
+	switch strings.Count(in, ":") {
+	case 0:
+		return in, "", -1, nil
+	case 1:
+		host, port, _ := strings.Cut(in, ":")
+
+		return host, port, offset + len(host) + 2, nil
+	}
+
+	if ip, _, _ := strings.Cut(in, "%"); net.ParseIP(ip) != nil {
+		// IPv6 without brackets and port
+		return in, "", -1, nil
 	}
 
-	return
+	if first, _, _ := strings.Cut(in, ":"); !explicitNet && net.ParseIP(first) == nil && validDomain.MatchString(first) {
+		return "", "", 0, unknownNetError(first)
+	}
+
+	return "", "", 0, errAt(offset+1, "invalid address '%s', IPv6 addresses with port must be enclosed in []", in)
 }
 
-func extractNet(upstream string) (NetProtocol, string) {
-	tcpUDPPrefix := NetProtocolTcpUdp.String() + ":"
-	if strings.HasPrefix(upstream, tcpUDPPrefix) {
-		return NetProtocolTcpUdp, upstream[len(tcpUDPPrefix):]
+// splitUpstreamZone splits the zone from an IPv6 address, e.g. "fe80::1%eth0".
+// Zones are only supported for plain DNS and DoT, an https upstream can't be a link-local address.
+func splitUpstreamZone(host string, n NetProtocol, pos int) (ip, zone string, err error) {
+	ip, zone, hasZone := strings.Cut(host, "%")
+	if !hasZone {
+		return host, "", nil
+	}
+
+	if len(zone) == 0 {
+		return "", "", errAt(pos, "empty zone after '%%'")
+	}
+
+	if parsed := net.ParseIP(ip); parsed == nil || parsed.To4() != nil {
+		return "", "", errAt(pos, "a zone is only supported for IPv6 addresses")
 	}
 
-	tcpTLSPrefix := NetProtocolTcpTls.String() + ":"
-	if strings.HasPrefix(upstream, tcpTLSPrefix) {
-		return NetProtocolTcpTls, upstream[len(tcpTLSPrefix):]
+	if strings.ContainsRune(zone, ':') {
+		return "", "", errAt(pos, "invalid zone '%s', IPv6 addresses with port must be enclosed in []", zone)
+	}
+
+	if n == NetProtocolHttps {
+		return "", "", errAt(pos, "a zone isn't supported for https")
+	}
+
+	return ip, zone, nil
+}
+
+func parseUpstreamPort(in string, pos int) (uint16, error) {
+	if len(in) == 0 {
+		return 0, errAt(pos, "missing port after ':'")
 	}
 
-	httpsPrefix := NetProtocolHttps.String() + ":"
-	if strings.HasPrefix(upstream, httpsPrefix) {
-		return NetProtocolHttps, strings.TrimPrefix(upstream[len(httpsPrefix):], "//")
+	port, err := ConvertPort(in)
+	if err != nil || port == 0 {
+		return 0, errAt(pos, "invalid port '%s', must be a number between 1 and 65535", in)
 	}
 
-	return NetProtocolTcpUdp, upstream
+	return port, nil
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -11,10 +13,92 @@ type UpstreamsConfig struct {
 	Timeout  Duration         `yaml:"timeout" default:"2s"`
 	Groups   UpstreamGroups   `yaml:"groups"`
 	Strategy UpstreamStrategy `yaml:"strategy" default:"parallel_best"`
+
+	// options of the upstream groups, by group name
+	GroupOptions map[string]UpstreamGroupOptions `yaml:"groupOptions"`
+
+	// max number of upstream queries in flight for the parallel_best strategy,
+	// above the limit each query is sent to a single upstream only. 0 means unlimited
+	MaxParallelQueries uint `yaml:"maxParallelQueries" default:"1000"`
+
+	// max number of CNAMEs in an upstream answer, responses with longer chains or loops are
+	// replaced with SERVFAIL. 0 means unlimited, loops are always rejected
+	MaxCNAMEChainLength uint `yaml:"maxCNAMEChainLength" default:"10"`
+
+	// if true, queries to encrypted upstreams (DoT and DoH) are padded with EDNS0 padding (RFC 7830/8467).
+	// Upstreams with the `padding` option are padded regardless
+	Padding bool `yaml:"padding" default:"true"`
+
+	ResponseQuality UpstreamResponseQuality `yaml:"responseQuality"`
+
+	// if true, blocky starts even if no upstream of a group passes the verification on start (`startVerifyUpstream`)
+	AllowEmptyOnStart bool `yaml:"allowEmptyOnStart" default:"false"`
+
+	VerifyRetry UpstreamVerifyRetry `yaml:"verifyRetry"`
+}
+
+// UpstreamVerifyRetry configures the background retry of upstreams which failed the verification on start
+type UpstreamVerifyRetry struct {
+	// the interval doubles after each failed attempt, up to the max interval
+	Interval    Duration `yaml:"interval" default:"5s"`
+	MaxInterval Duration `yaml:"maxInterval" default:"5m"`
+	// number of attempts before the upstream is marked as failed. 0 means unlimited
+	Attempts uint `yaml:"attempts" default:"0"`
+}
+
+// UpstreamResponseQuality configures how SERVFAIL and REFUSED responses affect the upstream selection
+type UpstreamResponseQuality struct {
+	// rates above the thresholds reduce the weight of an upstream, values <= 0 disable the check
+	ServFailThreshold float64  `yaml:"servFailThreshold" default:"0.02"`
+	RefusedThreshold  float64  `yaml:"refusedThreshold" default:"0.02"`
+	MinQueries        uint     `yaml:"minQueries" default:"20"`
+	Window            Duration `yaml:"window" default:"10m"`
+	ServFailWait      Duration `yaml:"servFailWait" default:"100ms"`
 }
 
 type UpstreamGroups map[string][]Upstream
 
+// UpstreamGroupOptions configures an upstream group
+type UpstreamGroupOptions struct {
+	// if true, the group may only contain encrypted upstreams (DoT and DoH)
+	RequireEncryption bool `yaml:"requireEncryption" default:"false"`
+}
+
+// RequiresEncryption returns true if the group may only contain encrypted upstreams
+func (c *UpstreamsConfig) RequiresEncryption(group string) bool {
+	return c.GroupOptions[group].RequireEncryption
+}
+
+// ValidateEncryption returns an error if a group, which requires encryption, contains an unencrypted upstream
+func (c *UpstreamsConfig) ValidateEncryption() error {
+	for group, upstreams := range c.Groups {
+		if !c.RequiresEncryption(group) {
+			continue
+		}
+
+		for _, upstream := range upstreams {
+			if !upstream.IsEncrypted() {
+				return fmt.Errorf("upstream '%s' in group '%s' is not encrypted, but the group requires encryption",
+					upstream, group)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateGroups returns an error if options are configured for an unknown group
+// or a group, which requires encryption, contains an unencrypted upstream
+func (c *UpstreamsConfig) ValidateGroups() error {
+	for group := range c.GroupOptions {
+		if _, ok := c.Groups[group]; !ok {
+			return fmt.Errorf("unknown upstream group '%s' in groupOptions", group)
+		}
+	}
+
+	return c.ValidateEncryption()
+}
+
 // IsEnabled implements `config.Configurable`.
 func (c *UpstreamsConfig) IsEnabled() bool {
 	return len(c.Groups) != 0
@@ -24,10 +108,28 @@ func (c *UpstreamsConfig) IsEnabled() bool {
 func (c *UpstreamsConfig) LogConfig(logger *logrus.Entry) {
 	logger.Info("timeout: ", c.Timeout)
 	logger.Info("strategy: ", c.Strategy)
+	logger.Info("maxParallelQueries: ", c.MaxParallelQueries)
+	logger.Info("maxCNAMEChainLength: ", c.MaxCNAMEChainLength)
+	logger.Info("padding: ", c.Padding)
+	logger.Info("responseQuality:")
+	logger.Infof("  servFailThreshold = %g", c.ResponseQuality.ServFailThreshold)
+	logger.Infof("  refusedThreshold  = %g", c.ResponseQuality.RefusedThreshold)
+	logger.Infof("  minQueries        = %d", c.ResponseQuality.MinQueries)
+	logger.Infof("  window            = %s", c.ResponseQuality.Window)
+	logger.Infof("  servFailWait      = %s", c.ResponseQuality.ServFailWait)
+	logger.Info("allowEmptyOnStart: ", c.AllowEmptyOnStart)
+	logger.Info("verifyRetry:")
+	logger.Infof("  interval    = %s", c.VerifyRetry.Interval)
+	logger.Infof("  maxInterval = %s", c.VerifyRetry.MaxInterval)
+	logger.Infof("  attempts    = %d", c.VerifyRetry.Attempts)
 	logger.Info("groups:")
 
 	for name, upstreams := range c.Groups {
-		logger.Infof("  %s:", name)
+		if c.RequiresEncryption(name) {
+			logger.Infof("  %s (encryption required):", name)
+		} else {
+			logger.Infof("  %s:", name)
+		}
 
 		for _, upstream := range upstreams {
 			logger.Infof("    - %s", upstream)
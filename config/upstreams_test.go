@@ -56,6 +56,70 @@ var _ = Describe("ParallelBestConfig", func() {
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("timeout:")))
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("groups:")))
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring(":host2:")))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("allowEmptyOnStart:")))
+		})
+
+		It("should flag groups which require encryption", func() {
+			cfg.GroupOptions = map[string]UpstreamGroupOptions{UpstreamDefaultCfgName: {RequireEncryption: true}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("  default (encryption required):")))
+		})
+	})
+
+	Describe("ValidateGroups", func() {
+		BeforeEach(func() {
+			cfg.Groups["personal"] = []Upstream{
+				{Net: NetProtocolTcpTls, Host: "dns.example.com", Port: 853},
+				{Net: NetProtocolHttps, Host: "dns.example.com", Port: 443, Path: "/dns-query"},
+			}
+			cfg.GroupOptions = map[string]UpstreamGroupOptions{"personal": {RequireEncryption: true}}
+		})
+
+		It("should accept encrypted upstreams", func() {
+			Expect(cfg.ValidateGroups()).Should(Succeed())
+		})
+
+		It("should not check groups without the option", func() {
+			cfg.GroupOptions = nil
+
+			cfg.Groups["personal"] = append(cfg.Groups["personal"], Upstream{Net: NetProtocolTcpUdp, Host: "1.1.1.1"})
+
+			Expect(cfg.ValidateGroups()).Should(Succeed())
+		})
+
+		It("should name the unencrypted upstream and its group", func() {
+			cfg.Groups["personal"] = append(cfg.Groups["personal"],
+				Upstream{Net: NetProtocolTcpUdp, Host: "1.1.1.1", Port: 53})
+
+			Expect(cfg.ValidateGroups()).Should(MatchError(
+				"upstream 'tcp+udp:1.1.1.1' in group 'personal' is not encrypted, but the group requires encryption"))
+		})
+
+		It("should fail for unknown groups", func() {
+			cfg.GroupOptions["unknown"] = UpstreamGroupOptions{RequireEncryption: true}
+
+			Expect(cfg.ValidateGroups()).Should(MatchError(ContainSubstring("unknown upstream group 'unknown'")))
+		})
+	})
+
+	Describe("defaults", func() {
+		It("should retry the verification with backoff", func() {
+			cfg, err := WithDefaults[UpstreamsConfig]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.AllowEmptyOnStart).Should(BeFalse())
+			Expect(cfg.VerifyRetry.Interval).Should(Equal(Duration(5 * time.Second)))
+			Expect(cfg.VerifyRetry.MaxInterval).Should(Equal(Duration(5 * time.Minute)))
+			Expect(cfg.VerifyRetry.Attempts).Should(BeZero())
+		})
+
+		It("should pad the queries of encrypted upstreams", func() {
+			cfg, err := WithDefaults[UpstreamsConfig]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.Padding).Should(BeTrue())
 		})
 	})
 })
@@ -0,0 +1,76 @@
+//go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal --names --values
+package config
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WatchdogMitigation action taken when the watchdog detects an internal stall ENUM(
+// log   // only log the stall and update the metrics
+// reset // reset the upstream connections and forget the resolved upstream IPs
+// exit  // stop blocky with a non-zero exit code, so a supervisor can restart it
+// )
+type WatchdogMitigation uint8
+
+// WatchdogConfig configuration for the self-query watchdog
+type WatchdogConfig struct {
+	Enable           bool               `yaml:"enable" default:"false"`
+	Interval         Duration           `yaml:"interval" default:"30s"`
+	Timeout          Duration           `yaml:"timeout" default:"5s"`
+	Canary           string             `yaml:"canary" default:"example.com"`
+	LocalCanary      string             `yaml:"localCanary"`
+	FailureThreshold uint               `yaml:"failureThreshold" default:"3"`
+	Mitigation       WatchdogMitigation `yaml:"mitigation" default:"log"`
+	Address          string             `yaml:"address"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *WatchdogConfig) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *WatchdogConfig) LogConfig(logger *logrus.Entry) {
+	logger.Infof("interval = %s", c.Interval)
+	logger.Infof("timeout = %s", c.Timeout)
+	logger.Infof("canary = %s", c.Canary)
+
+	if c.LocalCanary != "" {
+		logger.Infof("localCanary = %s", c.LocalCanary)
+	} else {
+		logger.Info("localCanary = none, upstream outages can't be told apart from internal stalls")
+	}
+
+	logger.Infof("failureThreshold = %d", c.FailureThreshold)
+	logger.Infof("mitigation = %s", c.Mitigation)
+
+	if c.Address != "" {
+		logger.Infof("address = %s", c.Address)
+	} else {
+		logger.Debug("address = in-process")
+	}
+}
+
+func (c *WatchdogConfig) validate() error {
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	if !c.Interval.IsAboveZero() {
+		return errors.New("interval must be greater than 0")
+	}
+
+	return nil
+}
+
+// CheckTimeout returns the time a single check may take, which is never longer than the interval.
+func (c *WatchdogConfig) CheckTimeout() time.Duration {
+	if c.Timeout.IsAboveZero() && c.Timeout < c.Interval {
+		return c.Timeout.ToDuration()
+	}
+
+	return c.Interval.ToDuration()
+}
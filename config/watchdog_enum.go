@@ -0,0 +1,101 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// WatchdogMitigationLog is a WatchdogMitigation of type Log.
+	// only log the stall and update the metrics
+	WatchdogMitigationLog WatchdogMitigation = iota
+	// WatchdogMitigationReset is a WatchdogMitigation of type Reset.
+	// reset the upstream connections and forget the resolved upstream IPs
+	WatchdogMitigationReset
+	// WatchdogMitigationExit is a WatchdogMitigation of type Exit.
+	// stop blocky with a non-zero exit code, so a supervisor can restart it
+	WatchdogMitigationExit
+)
+
+var ErrInvalidWatchdogMitigation = fmt.Errorf("not a valid WatchdogMitigation, try [%s]", strings.Join(_WatchdogMitigationNames, ", "))
+
+const _WatchdogMitigationName = "logresetexit"
+
+var _WatchdogMitigationNames = []string{
+	_WatchdogMitigationName[0:3],
+	_WatchdogMitigationName[3:8],
+	_WatchdogMitigationName[8:12],
+}
+
+// WatchdogMitigationNames returns a list of possible string values of WatchdogMitigation.
+func WatchdogMitigationNames() []string {
+	tmp := make([]string, len(_WatchdogMitigationNames))
+	copy(tmp, _WatchdogMitigationNames)
+	return tmp
+}
+
+// WatchdogMitigationValues returns a list of the values for WatchdogMitigation
+func WatchdogMitigationValues() []WatchdogMitigation {
+	return []WatchdogMitigation{
+		WatchdogMitigationLog,
+		WatchdogMitigationReset,
+		WatchdogMitigationExit,
+	}
+}
+
+var _WatchdogMitigationMap = map[WatchdogMitigation]string{
+	WatchdogMitigationLog:   _WatchdogMitigationName[0:3],
+	WatchdogMitigationReset: _WatchdogMitigationName[3:8],
+	WatchdogMitigationExit:  _WatchdogMitigationName[8:12],
+}
+
+// String implements the Stringer interface.
+func (x WatchdogMitigation) String() string {
+	if str, ok := _WatchdogMitigationMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("WatchdogMitigation(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x WatchdogMitigation) IsValid() bool {
+	_, ok := _WatchdogMitigationMap[x]
+	return ok
+}
+
+var _WatchdogMitigationValue = map[string]WatchdogMitigation{
+	_WatchdogMitigationName[0:3]:  WatchdogMitigationLog,
+	_WatchdogMitigationName[3:8]:  WatchdogMitigationReset,
+	_WatchdogMitigationName[8:12]: WatchdogMitigationExit,
+}
+
+// ParseWatchdogMitigation attempts to convert a string to a WatchdogMitigation.
+func ParseWatchdogMitigation(name string) (WatchdogMitigation, error) {
+	if x, ok := _WatchdogMitigationValue[name]; ok {
+		return x, nil
+	}
+	return WatchdogMitigation(0), fmt.Errorf("%s is %w", name, ErrInvalidWatchdogMitigation)
+}
+
+// MarshalText implements the text marshaller method.
+func (x WatchdogMitigation) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *WatchdogMitigation) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseWatchdogMitigation(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WatchdogConfig", func() {
+	var cfg WatchdogConfig
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[WatchdogConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		When("enabled", func() {
+			It("should be true", func() {
+				cfg.Enable = true
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("mitigation = log")))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("localCanary = none")))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept the defaults", func() {
+			cfg.Enable = true
+
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		It("should not validate if disabled", func() {
+			cfg.Interval = 0
+
+			Expect(cfg.validate()).Should(Succeed())
+		})
+
+		DescribeTable("should fail if the interval isn't positive",
+			func(interval Duration) {
+				cfg.Enable = true
+				cfg.Interval = interval
+
+				Expect(cfg.validate()).Should(MatchError("interval must be greater than 0"))
+			},
+			Entry("zero", Duration(0)),
+			Entry("negative", Duration(-time.Second)),
+		)
+	})
+
+	Describe("CheckTimeout", func() {
+		It("should use the timeout", func() {
+			Expect(cfg.CheckTimeout()).Should(Equal(5 * time.Second))
+		})
+
+		When("the timeout is longer than the interval", func() {
+			It("should use the interval", func() {
+				cfg.Timeout = Duration(time.Minute)
+
+				Expect(cfg.CheckTimeout()).Should(Equal(30 * time.Second))
+			})
+		})
+	})
+})
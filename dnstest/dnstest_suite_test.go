@@ -0,0 +1,15 @@
+package dnstest
+
+import (
+	"testing"
+
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDNSTest(t *testing.T) {
+	log.Silence()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DNSTest Suite")
+}
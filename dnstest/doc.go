@@ -0,0 +1,9 @@
+// Package dnstest provides utilities to test against blocky and DNS servers in general:
+// a mock upstream DNS server and, in package matchers, gomega matchers for DNS messages and blocky responses.
+//
+// Unlike the other packages of blocky, the exported API of this package and its subpackages is meant to be used
+// by other projects.
+// It follows semantic versioning of blocky: exported identifiers are only removed or changed incompatibly
+// with a new major version, minor versions only add functionality.
+// The package is used by the tests of blocky itself, so it is kept working.
+package dnstest
@@ -0,0 +1,163 @@
+// Package matchers provides gomega matchers for DNS messages and blocky responses.
+//
+// It is part of the public test utilities and has the same stability guarantees as package dnstest.
+package matchers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/gcustom"
+	"github.com/onsi/gomega/types"
+)
+
+// ToAnswer returns the answer section of the response, to be used with gomega.WithTransform
+func ToAnswer(m *model.Response) []dns.RR {
+	return m.Res.Answer
+}
+
+// ToExtra returns the additional section of the response, to be used with gomega.WithTransform
+func ToExtra(m *model.Response) []dns.RR {
+	return m.Res.Extra
+}
+
+// HaveNoAnswer succeeds if the answer section of the response is empty
+func HaveNoAnswer() types.GomegaMatcher {
+	return gomega.WithTransform(ToAnswer, gomega.BeEmpty())
+}
+
+// HaveReason succeeds if blocky answered the response with the reason
+func HaveReason(reason string) types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(m *model.Response) (bool, error) {
+		return m.Reason == reason, nil
+	}).WithTemplate(
+		"Expected:\n{{.Actual}}\n{{.To}} have reason:\n{{format .Data 1}}",
+		reason,
+	)
+}
+
+// HaveResponseType succeeds if blocky answered the response with the response type
+func HaveResponseType(c model.ResponseType) types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(m *model.Response) (bool, error) {
+		return m.RType == c, nil
+	}).WithTemplate(
+		"Expected:\n{{.Actual}}\n{{.To}} have ResponseType:\n{{format .Data 1}}",
+		c.String(),
+	)
+}
+
+// HaveReturnCode succeeds if the response has the return code
+func HaveReturnCode(code int) types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(m *model.Response) (bool, error) {
+		return m.Res.Rcode == code, nil
+	}).WithTemplate(
+		"Expected:\n{{.Actual}}\n{{.To}} have RCode:\n{{format .Data 1}}",
+		fmt.Sprintf("%d (%s)", code, dns.RcodeToString[code]),
+	)
+}
+
+func toFirstRR(actual interface{}) (dns.RR, error) {
+	switch i := actual.(type) {
+	case *model.Response:
+		return toFirstRR(i.Res)
+	case *dns.Msg:
+		return toFirstRR(i.Answer)
+
+	case []dns.RR:
+		if len(i) == 0 {
+			return nil, fmt.Errorf("answer must not be empty")
+		}
+
+		if len(i) == 1 {
+			return toFirstRR(i[0])
+		}
+
+		return nil, fmt.Errorf("supports only single RR in answer")
+	case dns.RR:
+		return i, nil
+	default:
+		return nil, fmt.Errorf("not supported type")
+	}
+}
+
+// HaveTTL succeeds if the TTL of the single answer record matches.
+// It accepts a *model.Response, *dns.Msg, []dns.RR or dns.RR.
+func HaveTTL(matcher types.GomegaMatcher) types.GomegaMatcher {
+	return gomega.WithTransform(func(actual interface{}) (uint32, error) {
+		rr, err := toFirstRR(actual)
+		if err != nil {
+			return 0, err
+		}
+
+		return rr.Header().Ttl, nil
+	}, matcher)
+}
+
+// BeDNSRecord succeeds if the single answer record has the domain, type and answer.
+// The answer is compared with the address of A and AAAA records and the target or text of
+// PTR, CNAME, MX, NS and TXT records. It accepts a *model.Response, *dns.Msg, []dns.RR or dns.RR.
+func BeDNSRecord(domain string, dnsType dns.Type, answer string) types.GomegaMatcher {
+	return &dnsRecordMatcher{
+		domain:  domain,
+		dnsType: dnsType,
+		answer:  answer,
+	}
+}
+
+type dnsRecordMatcher struct {
+	domain  string
+	dnsType dns.Type
+	answer  string
+}
+
+func (matcher *dnsRecordMatcher) matchSingle(rr dns.RR) (success bool, err error) {
+	if (rr.Header().Name != matcher.domain) ||
+		(dns.Type(rr.Header().Rrtype) != matcher.dnsType) {
+		return false, nil
+	}
+
+	switch v := rr.(type) {
+	case *dns.A:
+		return v.A.String() == matcher.answer, nil
+	case *dns.AAAA:
+		return v.AAAA.String() == matcher.answer, nil
+	case *dns.PTR:
+		return v.Ptr == matcher.answer, nil
+	case *dns.CNAME:
+		return v.Target == matcher.answer, nil
+	case *dns.MX:
+		return v.Mx == matcher.answer, nil
+	case *dns.NS:
+		return v.Ns == matcher.answer, nil
+	case *dns.TXT:
+		return strings.Join(v.Txt, "") == matcher.answer, nil
+	}
+
+	return false, nil
+}
+
+// Match checks the DNS record
+func (matcher *dnsRecordMatcher) Match(actual interface{}) (success bool, err error) {
+	rr, err := toFirstRR(actual)
+	if err != nil {
+		return false, err
+	}
+
+	return matcher.matchSingle(rr)
+}
+
+// FailureMessage generates a failure message
+func (matcher *dnsRecordMatcher) FailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected\n\t%s\n to contain\n\t domain '%s', type '%s', answer '%s'",
+		actual, matcher.domain, dns.TypeToString[uint16(matcher.dnsType)], matcher.answer)
+}
+
+// NegatedFailureMessage creates negated message
+func (matcher *dnsRecordMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected\n\t%s\n not to contain\n\t domain '%s', type '%s', answer '%s'",
+		actual, matcher.domain, dns.TypeToString[uint16(matcher.dnsType)], matcher.answer)
+}
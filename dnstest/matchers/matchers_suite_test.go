@@ -0,0 +1,15 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMatchers(t *testing.T) {
+	log.Silence()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Matchers Suite")
+}
@@ -0,0 +1,69 @@
+package matchers
+
+import (
+	"net"
+
+	"github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Matchers", func() {
+	var response *model.Response
+
+	BeforeEach(func() {
+		msg := new(dns.Msg)
+		msg.SetQuestion("example.com.", dns.TypeA)
+		msg.Rcode = dns.RcodeSuccess
+		msg.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP("192.0.2.1"),
+		}}
+
+		response = &model.Response{Res: msg, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}
+	})
+
+	Describe("BeDNSRecord", func() {
+		It("should match the record of a response, message, record slice or record", func() {
+			matcher := BeDNSRecord("example.com.", dns.Type(dns.TypeA), "192.0.2.1")
+
+			Expect(response).Should(matcher)
+			Expect(response.Res).Should(matcher)
+			Expect(response.Res.Answer).Should(matcher)
+			Expect(response.Res.Answer[0]).Should(matcher)
+		})
+
+		It("should not match other records", func() {
+			Expect(response).ShouldNot(BeDNSRecord("example.com.", dns.Type(dns.TypeA), "192.0.2.2"))
+			Expect(response).ShouldNot(BeDNSRecord("example.org.", dns.Type(dns.TypeA), "192.0.2.1"))
+			Expect(response).ShouldNot(BeDNSRecord("example.com.", dns.Type(dns.TypeAAAA), "192.0.2.1"))
+		})
+
+		It("should fail for multiple records", func() {
+			response.Res.Answer = append(response.Res.Answer, response.Res.Answer[0])
+
+			success, err := BeDNSRecord("example.com.", dns.Type(dns.TypeA), "192.0.2.1").Match(response)
+			Expect(success).Should(BeFalse())
+			Expect(err).Should(MatchError("supports only single RR in answer"))
+		})
+	})
+
+	Describe("Response matchers", func() {
+		It("should match the fields of the response", func() {
+			Expect(response).Should(SatisfyAll(
+				HaveTTL(BeNumerically("==", 300)),
+				HaveReturnCode(dns.RcodeSuccess),
+				HaveResponseType(model.ResponseTypeCUSTOMDNS),
+				HaveReason("CUSTOM DNS"),
+				Not(HaveNoAnswer()),
+			))
+		})
+
+		It("should match an empty answer", func() {
+			response.Res.Answer = nil
+
+			Expect(response).Should(HaveNoAnswer())
+		})
+	})
+})
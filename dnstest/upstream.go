@@ -0,0 +1,360 @@
+package dnstest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/miekg/dns"
+)
+
+const (
+	// listenAttempts limits the tries to find a port which is free for UDP and TCP
+	listenAttempts = 10
+
+	// closeTimeout limits the wait for answers in progress on Close
+	closeTimeout = 100 * time.Millisecond
+
+	// clientCookieHexLen is the length of a DNS client cookie in hex presentation
+	clientCookieHexLen = 16
+	// serverCookieLen is the length of the server cookies of the mock server
+	serverCookieLen = 8
+)
+
+// MockUpstreamServer is a DNS server for tests, which answers UDP and TCP queries on the same port of 127.0.0.1.
+//
+// The answer is defined with one of the With* methods before calling Start.
+// Without an answer, the server responds with an empty NOERROR message.
+// UDP answers are truncated to the size advertised by the query, like real servers do.
+// All methods are safe for concurrent use.
+type MockUpstreamServer struct {
+	mu       sync.Mutex
+	answerFn func(request *dns.Msg) (response *dns.Msg)
+	latency  time.Duration
+	cookies  bool
+	requests []*dns.Msg
+
+	addr    string
+	servers []*dns.Server
+}
+
+// NewMockUpstreamServer creates a new server, it must be started with Start
+func NewMockUpstreamServer() *MockUpstreamServer {
+	return &MockUpstreamServer{}
+}
+
+// WithAnswerRR answers all queries with the records in zone file syntax.
+// It panics if a record can't be parsed.
+func (t *MockUpstreamServer) WithAnswerRR(answers ...string) *MockUpstreamServer {
+	rrs := make([]dns.RR, 0, len(answers))
+
+	for _, a := range answers {
+		rr, err := dns.NewRR(a)
+		if err != nil {
+			panic(fmt.Sprintf("dnstest: can't create RR from '%s': %s", a, err))
+		}
+
+		rrs = append(rrs, rr)
+	}
+
+	return t.WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+		msg := new(dns.Msg)
+
+		for _, rr := range rrs {
+			msg.Answer = append(msg.Answer, dns.Copy(rr))
+		}
+
+		return msg
+	})
+}
+
+// WithAnswerMsg answers all queries with a copy of the message
+func (t *MockUpstreamServer) WithAnswerMsg(answer *dns.Msg) *MockUpstreamServer {
+	return t.WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+		return answer.Copy()
+	})
+}
+
+// WithAnswerError answers all queries with the return code and without records
+func (t *MockUpstreamServer) WithAnswerError(errorCode int) *MockUpstreamServer {
+	return t.WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.Rcode = errorCode
+
+		return msg
+	})
+}
+
+// WithAnswerFn answers each query with the result of the function.
+// The ID, question and flags of the request are set on the response, the return code is kept.
+// A nil response sends an invalid message, which simulates a broken upstream.
+func (t *MockUpstreamServer) WithAnswerFn(fn func(request *dns.Msg) (response *dns.Msg)) *MockUpstreamServer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.answerFn = fn
+
+	return t
+}
+
+// WithLatency delays each answer
+func (t *MockUpstreamServer) WithLatency(latency time.Duration) *MockUpstreamServer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.latency = latency
+
+	return t
+}
+
+// WithCookies requires DNS cookies (RFC 7873) for UDP queries: queries without cookie are refused and queries
+// without a valid server cookie are answered with BADCOOKIE and a new server cookie.
+// Valid cookies are echoed with the answer, TCP queries are answered without cookie check.
+func (t *MockUpstreamServer) WithCookies() *MockUpstreamServer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cookies = true
+
+	return t
+}
+
+// GetCallCount returns the number of received queries
+func (t *MockUpstreamServer) GetCallCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.requests)
+}
+
+// Requests returns copies of the received queries in the order of arrival
+func (t *MockUpstreamServer) Requests() []*dns.Msg {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := make([]*dns.Msg, 0, len(t.requests))
+
+	for _, request := range t.requests {
+		res = append(res, request.Copy())
+	}
+
+	return res
+}
+
+// LastRequest returns a copy of the last received query or nil if there was none
+func (t *MockUpstreamServer) LastRequest() *dns.Msg {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.requests) == 0 {
+		return nil
+	}
+
+	return t.requests[len(t.requests)-1].Copy()
+}
+
+// Addr returns the address (host:port) the server listens on, it is empty until the server is started
+func (t *MockUpstreamServer) Addr() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.addr
+}
+
+// Start starts the server and returns its address as blocky upstream.
+// It panics if no port is free.
+func (t *MockUpstreamServer) Start() config.Upstream {
+	udpConn, tcpListener, err := listen()
+	if err != nil {
+		panic(fmt.Sprintf("dnstest: can't start mock upstream server: %s", err))
+	}
+
+	handler := dns.HandlerFunc(t.handle)
+
+	var started sync.WaitGroup
+
+	servers := []*dns.Server{
+		{PacketConn: udpConn, Handler: handler, NotifyStartedFunc: started.Done},
+		{Listener: tcpListener, Handler: handler, NotifyStartedFunc: started.Done},
+	}
+
+	started.Add(len(servers))
+
+	for _, server := range servers {
+		go func(server *dns.Server) {
+			_ = server.ActivateAndServe()
+		}(server)
+	}
+
+	// Shutdown fails for servers which aren't started yet
+	started.Wait()
+
+	addr := udpConn.LocalAddr().(*net.UDPAddr)
+
+	t.mu.Lock()
+	t.addr = addr.String()
+	t.servers = servers
+	t.mu.Unlock()
+
+	return config.Upstream{Net: config.NetProtocolTcpUdp, Host: addr.IP.String(), Port: uint16(addr.Port)}
+}
+
+// Close stops the server, answers which take longer than a short grace period aren't waited for
+func (t *MockUpstreamServer) Close() {
+	t.mu.Lock()
+	servers := t.servers
+	t.servers = nil
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	for _, server := range servers {
+		_ = server.ShutdownContext(ctx)
+	}
+}
+
+func (t *MockUpstreamServer) handle(w dns.ResponseWriter, request *dns.Msg) {
+	t.mu.Lock()
+	t.requests = append(t.requests, request.Copy())
+	answerFn := t.answerFn
+	latency := t.latency
+	cookies := t.cookies
+	t.mu.Unlock()
+
+	_, isUDP := w.LocalAddr().(*net.UDPAddr)
+
+	var cookie string
+
+	if cookies && isUDP {
+		var rCode int
+
+		cookie, rCode = checkCookie(request)
+		if rCode != dns.RcodeSuccess {
+			response := new(dns.Msg)
+			response.SetRcode(request, rCode)
+
+			if cookie != "" {
+				setCookie(response, cookie)
+			}
+
+			_ = w.WriteMsg(response)
+
+			return
+		}
+	}
+
+	response := new(dns.Msg)
+	if answerFn != nil {
+		response = answerFn(request)
+	}
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if response == nil {
+		_, _ = w.Write([]byte("dummy"))
+
+		return
+	}
+
+	rCode := response.Rcode
+	response.SetReply(request)
+
+	if rCode != dns.RcodeSuccess {
+		response.Rcode = rCode
+	}
+
+	if cookie != "" {
+		setCookie(response, cookie)
+	}
+
+	if isUDP {
+		response.Truncate(udpSize(request))
+	}
+
+	_ = w.WriteMsg(response)
+}
+
+// udpSize returns the max size of an UDP answer to the request
+func udpSize(request *dns.Msg) int {
+	if opt := request.IsEdns0(); opt != nil {
+		return int(opt.UDPSize())
+	}
+
+	return dns.MinMsgSize
+}
+
+// checkCookie checks the cookie of the query and returns the cookie for the answer.
+// The return code is not NOERROR, if the query must not be answered
+func checkCookie(request *dns.Msg) (cookie string, rCode int) {
+	var sent string
+
+	if opt := request.IsEdns0(); opt != nil {
+		for _, o := range opt.Option {
+			if c, ok := o.(*dns.EDNS0_COOKIE); ok {
+				sent = c.Cookie
+			}
+		}
+	}
+
+	if sent == "" {
+		return "", dns.RcodeRefused
+	}
+
+	if len(sent) < clientCookieHexLen {
+		return "", dns.RcodeFormatError
+	}
+
+	client := sent[:clientCookieHexLen]
+	serverCookie := sha256.Sum256([]byte(client))
+	cookie = client + hex.EncodeToString(serverCookie[:serverCookieLen])
+
+	if sent != cookie {
+		return cookie, dns.RcodeBadCookie
+	}
+
+	return cookie, dns.RcodeSuccess
+}
+
+// setCookie adds the cookie to the EDNS record of the response
+func setCookie(response *dns.Msg, cookie string) {
+	opt := response.IsEdns0()
+	if opt == nil {
+		opt = response.SetEdns0(dns.DefaultMsgSize, false).IsEdns0()
+	}
+
+	opt.Option = append(opt.Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+}
+
+// listen opens UDP and TCP on the same random port of 127.0.0.1
+func listen() (*net.UDPConn, net.Listener, error) {
+	var lastErr error
+
+	for i := 0; i < listenAttempts; i++ {
+		udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		port := udpConn.LocalAddr().(*net.UDPAddr).Port
+
+		tcpListener, err := net.Listen("tcp4", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+		if err == nil {
+			return udpConn, tcpListener, nil
+		}
+
+		// the port is used by TCP, try another one
+		_ = udpConn.Close()
+		lastErr = err
+	}
+
+	return nil, nil, lastErr
+}
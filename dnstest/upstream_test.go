@@ -0,0 +1,266 @@
+package dnstest
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/0xERR0R/blocky/dnstest/matchers"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MockUpstreamServer", func() {
+	var sut *MockUpstreamServer
+
+	BeforeEach(func() {
+		sut = NewMockUpstreamServer()
+
+		DeferCleanup(sut.Close)
+	})
+
+	exchange := func(network, name string, qType uint16) (*dns.Msg, time.Duration, error) {
+		client := dns.Client{Net: network, Timeout: time.Second}
+
+		msg := new(dns.Msg)
+		msg.SetQuestion(name, qType)
+
+		return client.Exchange(msg, sut.Addr())
+	}
+
+	Describe("Start", func() {
+		It("should return the address as upstream", func() {
+			upstream := sut.Start()
+
+			Expect(upstream.Host).Should(Equal("127.0.0.1"))
+			Expect(sut.Addr()).Should(Equal(net.JoinHostPort(upstream.Host, strconv.Itoa(int(upstream.Port)))))
+		})
+
+		It("should answer with an empty response without configured answer", func() {
+			sut.Start()
+
+			resp, _, err := exchange("udp", "example.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(resp.Answer).Should(BeEmpty())
+		})
+	})
+
+	Describe("WithAnswerRR", func() {
+		It("should answer with the records via UDP and TCP", func() {
+			sut.WithAnswerRR("example.com 123 IN A 192.0.2.1").Start()
+
+			for _, network := range []string{"udp", "tcp"} {
+				resp, _, err := exchange(network, "example.com.", dns.TypeA)
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(SatisfyAll(
+					BeDNSRecord("example.com.", dns.Type(dns.TypeA), "192.0.2.1"),
+					HaveTTL(BeNumerically("==", 123)),
+				))
+				Expect(resp.Question[0].Name).Should(Equal("example.com."))
+			}
+
+			Expect(sut.GetCallCount()).Should(Equal(2))
+		})
+
+		It("should panic for invalid records", func() {
+			Expect(func() { sut.WithAnswerRR("example.com 123 IN A 192.0.2.300") }).
+				Should(PanicWith(ContainSubstring("can't create RR")))
+		})
+	})
+
+	Describe("WithAnswerMsg", func() {
+		It("should answer with a copy of the message", func() {
+			answer := new(dns.Msg)
+			answer.Answer = []dns.RR{&dns.TXT{
+				Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+				Txt: []string{"hello"},
+			}}
+
+			sut.WithAnswerMsg(answer).Start()
+
+			resp, _, err := exchange("udp", "example.com.", dns.TypeTXT)
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(BeDNSRecord("example.com.", dns.Type(dns.TypeTXT), "hello"))
+
+			Expect(answer.Question).Should(BeEmpty())
+		})
+	})
+
+	Describe("UDP answers", func() {
+		It("should truncate answers larger than the advertised size", func() {
+			txt := make([]string, 0, 10)
+			for i := 0; i < 10; i++ {
+				txt = append(txt, fmt.Sprintf(`example.com 60 IN TXT "%d %s"`, i, strings.Repeat("x", 100)))
+			}
+
+			sut.WithAnswerRR(txt...).Start()
+
+			resp, _, err := exchange("udp", "example.com.", dns.TypeTXT)
+			Expect(err).Should(Succeed())
+			Expect(resp.Truncated).Should(BeTrue())
+
+			// the answer is sent compressed
+			resp.Compress = true
+			Expect(resp.Len()).Should(BeNumerically("<=", dns.MinMsgSize))
+
+			resp, _, err = exchange("tcp", "example.com.", dns.TypeTXT)
+			Expect(err).Should(Succeed())
+			Expect(resp.Truncated).Should(BeFalse())
+			Expect(resp.Answer).Should(HaveLen(10))
+		})
+	})
+
+	Describe("WithAnswerError", func() {
+		It("should answer with the return code", func() {
+			sut.WithAnswerError(dns.RcodeServerFailure).Start()
+
+			resp, _, err := exchange("udp", "example.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeServerFailure))
+			Expect(resp.Answer).Should(BeEmpty())
+		})
+	})
+
+	Describe("WithAnswerFn", func() {
+		It("should answer with the result of the function", func() {
+			sut.WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+				msg := new(dns.Msg)
+				msg.Rcode = dns.RcodeNameError
+
+				if request.Question[0].Name == "found.com." {
+					msg.Rcode = dns.RcodeSuccess
+				}
+
+				return msg
+			}).Start()
+
+			resp, _, err := exchange("udp", "found.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeSuccess))
+
+			resp, _, err = exchange("udp", "other.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeNameError))
+		})
+
+		It("should send an invalid message for a nil response", func() {
+			sut.WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+				return nil
+			}).Start()
+
+			_, _, err := exchange("udp", "example.com.", dns.TypeA)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("WithCookies", func() {
+		exchangeWithCookie := func(network, cookie string) *dns.Msg {
+			client := dns.Client{Net: network, Timeout: time.Second}
+
+			msg := new(dns.Msg)
+			msg.SetQuestion("example.com.", dns.TypeA)
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+
+			opt := msg.IsEdns0()
+			opt.Option = append(opt.Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+
+			resp, _, err := client.Exchange(msg, sut.Addr())
+			Expect(err).Should(Succeed())
+
+			return resp
+		}
+
+		cookieOf := func(msg *dns.Msg) string {
+			for _, o := range msg.IsEdns0().Option {
+				if cookie, ok := o.(*dns.EDNS0_COOKIE); ok {
+					return cookie.Cookie
+				}
+			}
+
+			return ""
+		}
+
+		BeforeEach(func() {
+			sut.WithAnswerRR("example.com 123 IN A 123.124.122.122").WithCookies().Start()
+		})
+
+		It("should refuse UDP queries without cookie", func() {
+			resp, _, err := exchange("udp", "example.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeRefused))
+		})
+
+		It("should answer with a server cookie, which is accepted afterwards", func() {
+			resp := exchangeWithCookie("udp", "0102030405060708")
+			Expect(resp.Rcode).Should(Equal(dns.RcodeBadCookie))
+			Expect(resp.Answer).Should(BeEmpty())
+
+			cookie := cookieOf(resp)
+			Expect(cookie).Should(HavePrefix("0102030405060708"))
+			Expect(cookie).Should(HaveLen(32))
+
+			resp = exchangeWithCookie("udp", cookie)
+			Expect(resp.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(resp.Answer).Should(HaveLen(1))
+			Expect(cookieOf(resp)).Should(Equal(cookie))
+		})
+
+		It("should answer TCP queries without cookie", func() {
+			resp, _, err := exchange("tcp", "example.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(resp.Answer).Should(HaveLen(1))
+		})
+	})
+
+	Describe("WithLatency", func() {
+		It("should delay the answer", func() {
+			const latency = 50 * time.Millisecond
+
+			sut.WithLatency(latency).Start()
+
+			_, rtt, err := exchange("udp", "example.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+			Expect(rtt).Should(BeNumerically(">=", latency))
+		})
+	})
+
+	Describe("Requests", func() {
+		It("should capture the received queries", func() {
+			Expect(sut.LastRequest()).Should(BeNil())
+
+			sut.Start()
+
+			_, _, err := exchange("udp", "a.com.", dns.TypeA)
+			Expect(err).Should(Succeed())
+
+			_, _, err = exchange("tcp", "b.com.", dns.TypeAAAA)
+			Expect(err).Should(Succeed())
+
+			requests := sut.Requests()
+			Expect(requests).Should(HaveLen(2))
+			Expect(requests[0].Question[0].Name).Should(Equal("a.com."))
+			Expect(requests[1].Question[0].Qtype).Should(Equal(dns.TypeAAAA))
+
+			Expect(sut.LastRequest().Question[0].Name).Should(Equal("b.com."))
+		})
+	})
+
+	Describe("Close", func() {
+		It("should stop the server", func() {
+			sut.Start()
+			sut.Close()
+
+			_, _, err := exchange("tcp", "example.com.", dns.TypeA)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("should do nothing if not started", func() {
+			Expect(sut.Close).ShouldNot(Panic())
+		})
+	})
+})
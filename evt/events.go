@@ -5,35 +5,130 @@ import (
 )
 
 const (
-	// BlockingEnabledEvent fires if blocking status will be changed. Parameter: boolean (enabled = true)
+	// BlockingEnabledEvent fires if blocking status will be changed. Parameter: profile, boolean (enabled = true)
 	BlockingEnabledEvent = "blocking:enabled"
 
-	// BlockingCacheGroupChanged fires, if a list group is changed. Parameter: list type, group name, element count
+	// BlockingCacheGroupChanged fires, if a list group is changed,
+	// Parameter: profile, list type, group name, element count
 	BlockingCacheGroupChanged = "blocking:cachingGroupChanged"
 
-	// CachingDomainPrefetched fires if a domain will be prefetched, Parameter: domain name
+	// BlockingListSourceChanged fires if a list source was loaded with changes,
+	// Parameter: profile, list type, group name, source, time of the change, entry count
+	BlockingListSourceChanged = "blocking:listSourceChanged"
+
+	// BlockingListSourceRefreshed fires after each refresh of a list source,
+	// Parameter: profile, list type, group name, source, error (nil if successful),
+	// entries of a previous refresh are used
+	BlockingListSourceRefreshed = "blocking:listSourceRefreshed"
+
+	// BlockingAuditMatch fires if a query matched a group which isn't enforced, Parameter: profile, group name
+	BlockingAuditMatch = "blocking:auditMatch"
+
+	// BlockingDisabledMatch fires if a query was resolved, which would be blocked by a group with disabled blocking,
+	// Parameter: profile, group name
+	BlockingDisabledMatch = "blocking:disabledMatch"
+
+	// CachingDomainPrefetched fires if a domain will be prefetched, Parameter: profile, domain name
 	CachingDomainPrefetched = "caching:prefetched"
 
-	// CachingResultCacheChanged fires if a result cache was changed, Parameter: new cache size
+	// CachingResultCacheChanged fires if a result cache was changed, Parameter: profile, new cache size
 	CachingResultCacheChanged = "caching:resultCacheChanged"
 
-	// CachingPrefetchCacheHit fires if a query result was found in the prefetch cache, Parameter: domain name
+	// CachingResultCacheSizeChanged fires if the size of the result cache was changed,
+	// Parameter: profile, new size in bytes
+	CachingResultCacheSizeChanged = "caching:resultCacheSizeChanged"
+
+	// CachingResultCacheEvicted fires if an entry was evicted from the result cache because of its max size,
+	// Parameter: profile, cache key
+	CachingResultCacheEvicted = "caching:resultCacheEvicted"
+
+	// CachingPrefetchCacheHit fires if a query result was found in the prefetch cache, Parameter: profile, domain name
 	CachingPrefetchCacheHit = "caching:prefetchHit"
 
-	// CachingResultCacheHit fires, if a query result was found in the cache, Parameter: domain name
+	// CachingResultCacheHit fires, if a query result was found in the cache, Parameter: profile, domain name
 	CachingResultCacheHit = "caching:cacheHit"
 
-	// CachingResultCacheMiss fires, if a query result was not found in the cache, Parameter: domain name
+	// CachingRevalidateHit fires, if a query result was found in the cache and is refreshed in the background
+	// (stale-while-revalidate), Parameter: profile, domain name
+	CachingRevalidateHit = "caching:revalidateHit"
+
+	// CachingRevalidateFailed fires, if the background refresh of a cache entry failed, Parameter: profile, domain name
+	CachingRevalidateFailed = "caching:revalidateFailed"
+
+	// CachingResultCacheMiss fires, if a query result was not found in the cache, Parameter: profile, domain name
 	CachingResultCacheMiss = "caching:cacheMiss"
 
-	// CachingDomainsToPrefetchCountChanged fires, if a number of domains being prefetched changed, Parameter: new count
+	// CachingResultCacheExcluded fires, if a query bypassed the cache because the domain is excluded,
+	// Parameter: profile, domain name
+	CachingResultCacheExcluded = "caching:cacheExcluded"
+
+	// CachingDomainsToPrefetchCountChanged fires, if a number of domains being prefetched changed,
+	// Parameter: profile, new count
 	CachingDomainsToPrefetchCountChanged = "caching:domainsToPrefetchCountChanged"
 
+	// CachingPrefetchDomainEvicted fires if a domain was removed from the prefetch tracking
+	// to respect the max items count, Parameter: profile, domain name
+	CachingPrefetchDomainEvicted = "caching:prefetchDomainEvicted"
+
+	// CachingPrefetchFailedDomainEvicted fires if a domain was removed from the prefetch tracking
+	// because its refreshes failed repeatedly, Parameter: profile, domain name
+	CachingPrefetchFailedDomainEvicted = "caching:prefetchFailedDomainEvicted"
+
 	// CachingFailedDownloadChanged fires, if a download of a blocking list or hosts file fails
 	CachingFailedDownloadChanged = "caching:failedDownload"
 
+	// UpstreamParallelLimitReached fires if a query is sent to a single upstream only,
+	// since the max number of parallel upstream queries is reached
+	UpstreamParallelLimitReached = "upstream:parallelLimitReached"
+
+	// UpstreamResponseMismatch fires if a response doesn't match the query and was dropped (possible spoofing),
+	// Parameter: upstream
+	UpstreamResponseMismatch = "upstream:responseMismatch"
+
+	// ServerProtocolMismatch fires if a client speaks the wrong protocol on a listener, e.g. HTTPS on the DoT port,
+	// Parameter: listener, detected protocol
+	ServerProtocolMismatch = "server:protocolMismatch"
+
+	// ServerMessageSize fires for each DNS request received and response sent by the server,
+	// Parameter: transport (udp, tcp, dot or doh), direction (request or response), size in bytes
+	ServerMessageSize = "server:messageSize"
+
+	// ServerTruncatedResponse fires if a truncated response is sent over UDP, no parameters
+	ServerTruncatedResponse = "server:truncatedResponse"
+
+	// ServerTCPFallback fires if a client retries a query over TCP after a truncated UDP response (best-effort),
+	// no parameters
+	ServerTCPFallback = "server:tcpFallback"
+
+	// ServerLateAnswerDropped fires if a UDP answer isn't sent, since it is older than `maxAnswerAge`, no parameters
+	ServerLateAnswerDropped = "server:lateAnswerDropped"
+
+	// ServerACLRefused fires if a query is refused, since the client isn't allowed by the ACL,
+	// Parameter: transport (udp, tcp, dot or doh)
+	ServerACLRefused = "server:aclRefused"
+
+	// RebindProtectionFiltered fires if private addresses are filtered from an upstream answer,
+	// Parameter: domain (the domain for the first `maxMetricsDomains` filtered domains, otherwise "other")
+	RebindProtectionFiltered = "rebindProtection:filtered"
+
+	// RateLimitQueryLimited fires if a query of a client is over the rate limit,
+	// Parameter: client (the IP for the first `maxMetricsClients` limited clients, otherwise "other")
+	RateLimitQueryLimited = "rateLimit:queryLimited"
+
+	// WatchdogCheckFailed fires if a watchdog self-query failed, Parameter: failure classification
+	WatchdogCheckFailed = "watchdog:checkFailed"
+
+	// WatchdogMitigationApplied fires if the watchdog applies its mitigation, Parameter: mitigation action
+	WatchdogMitigationApplied = "watchdog:mitigationApplied"
+
 	// ApplicationStarted fires on start of the application. Parameter: version number, build time
 	ApplicationStarted = "application:started"
+
+	// StartupPhaseCompleted fires if a phase of the startup completed, Parameter: phase name, duration
+	StartupPhaseCompleted = "application:startupPhaseCompleted"
+
+	// StartupFinished fires once the server is started, Parameter: total duration, duration per phase name
+	StartupFinished = "application:startupFinished"
 )
 
 //nolint:gochecknoglobals
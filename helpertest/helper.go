@@ -2,26 +2,27 @@ package helpertest
 
 import (
 	"bytes"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 
+	"github.com/0xERR0R/blocky/dnstest/matchers"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
 
 	"github.com/miekg/dns"
-	"github.com/onsi/gomega"
-	"github.com/onsi/gomega/gcustom"
 	"github.com/onsi/gomega/types"
 )
 
 const (
 	A     = dns.Type(dns.TypeA)
 	AAAA  = dns.Type(dns.TypeAAAA)
+	CNAME = dns.Type(dns.TypeCNAME)
 	HTTPS = dns.Type(dns.TypeHTTPS)
 	MX    = dns.Type(dns.TypeMX)
+	NS    = dns.Type(dns.TypeNS)
 	PTR   = dns.Type(dns.TypePTR)
+	SRV   = dns.Type(dns.TypeSRV)
 	TXT   = dns.Type(dns.TypeTXT)
 	DS    = dns.Type(dns.TypeDS)
 )
@@ -65,133 +66,42 @@ func DoGetRequest(url string,
 	return rr, rr.Body
 }
 
+// ToAnswer returns the answer section of the response
 func ToAnswer(m *model.Response) []dns.RR {
-	return m.Res.Answer
+	return matchers.ToAnswer(m)
 }
 
+// ToExtra returns the additional section of the response
 func ToExtra(m *model.Response) []dns.RR {
-	return m.Res.Extra
+	return matchers.ToExtra(m)
 }
 
+// HaveNoAnswer see matchers.HaveNoAnswer
 func HaveNoAnswer() types.GomegaMatcher {
-	return gomega.WithTransform(ToAnswer, gomega.BeEmpty())
+	return matchers.HaveNoAnswer()
 }
 
+// HaveReason see matchers.HaveReason
 func HaveReason(reason string) types.GomegaMatcher {
-	return gcustom.MakeMatcher(func(m *model.Response) (bool, error) {
-		return m.Reason == reason, nil
-	}).WithTemplate(
-		"Expected:\n{{.Actual}}\n{{.To}} have reason:\n{{format .Data 1}}",
-		reason,
-	)
+	return matchers.HaveReason(reason)
 }
 
+// HaveResponseType see matchers.HaveResponseType
 func HaveResponseType(c model.ResponseType) types.GomegaMatcher {
-	return gcustom.MakeMatcher(func(m *model.Response) (bool, error) {
-		return m.RType == c, nil
-	}).WithTemplate(
-		"Expected:\n{{.Actual}}\n{{.To}} have ResponseType:\n{{format .Data 1}}",
-		c.String(),
-	)
+	return matchers.HaveResponseType(c)
 }
 
+// HaveReturnCode see matchers.HaveReturnCode
 func HaveReturnCode(code int) types.GomegaMatcher {
-	return gcustom.MakeMatcher(func(m *model.Response) (bool, error) {
-		return m.Res.Rcode == code, nil
-	}).WithTemplate(
-		"Expected:\n{{.Actual}}\n{{.To}} have RCode:\n{{format .Data 1}}",
-		fmt.Sprintf("%d (%s)", code, dns.RcodeToString[code]),
-	)
-}
-
-func toFirstRR(actual interface{}) (dns.RR, error) {
-	switch i := actual.(type) {
-	case *model.Response:
-		return toFirstRR(i.Res)
-	case *dns.Msg:
-		return toFirstRR(i.Answer)
-
-	case []dns.RR:
-		if len(i) == 0 {
-			return nil, fmt.Errorf("answer must not be empty")
-		}
-
-		if len(i) == 1 {
-			return toFirstRR(i[0])
-		}
-
-		return nil, fmt.Errorf("supports only single RR in answer")
-	case dns.RR:
-		return i, nil
-	default:
-		return nil, fmt.Errorf("not supported type")
-	}
+	return matchers.HaveReturnCode(code)
 }
 
+// HaveTTL see matchers.HaveTTL
 func HaveTTL(matcher types.GomegaMatcher) types.GomegaMatcher {
-	return gomega.WithTransform(func(actual interface{}) (uint32, error) {
-		rr, err := toFirstRR(actual)
-		if err != nil {
-			return 0, err
-		}
-
-		return rr.Header().Ttl, nil
-	}, matcher)
+	return matchers.HaveTTL(matcher)
 }
 
-// BeDNSRecord returns new dns matcher
+// BeDNSRecord see matchers.BeDNSRecord
 func BeDNSRecord(domain string, dnsType dns.Type, answer string) types.GomegaMatcher {
-	return &dnsRecordMatcher{
-		domain:  domain,
-		dnsType: dnsType,
-		answer:  answer,
-	}
-}
-
-type dnsRecordMatcher struct {
-	domain  string
-	dnsType dns.Type
-	answer  string
-}
-
-func (matcher *dnsRecordMatcher) matchSingle(rr dns.RR) (success bool, err error) {
-	if (rr.Header().Name != matcher.domain) ||
-		(dns.Type(rr.Header().Rrtype) != matcher.dnsType) {
-		return false, nil
-	}
-
-	switch v := rr.(type) {
-	case *dns.A:
-		return v.A.String() == matcher.answer, nil
-	case *dns.AAAA:
-		return v.AAAA.String() == matcher.answer, nil
-	case *dns.PTR:
-		return v.Ptr == matcher.answer, nil
-	case *dns.MX:
-		return v.Mx == matcher.answer, nil
-	}
-
-	return false, nil
-}
-
-// Match checks the DNS record
-func (matcher *dnsRecordMatcher) Match(actual interface{}) (success bool, err error) {
-	rr, err := toFirstRR(actual)
-	if err != nil {
-		return false, err
-	}
-
-	return matcher.matchSingle(rr)
-}
-
-// FailureMessage generates a failure message
-func (matcher *dnsRecordMatcher) FailureMessage(actual interface{}) (message string) {
-	return fmt.Sprintf("Expected\n\t%s\n to contain\n\t domain '%s', type '%s', answer '%s'",
-		actual, matcher.domain, dns.TypeToString[uint16(matcher.dnsType)], matcher.answer)
-}
-
-// NegatedFailureMessage creates negated message
-func (matcher *dnsRecordMatcher) NegatedFailureMessage(actual interface{}) (message string) {
-	return fmt.Sprintf("Expected\n\t%s\n not to contain\n\t domain '%s', type '%s', answer '%s'",
-		actual, matcher.domain, dns.TypeToString[uint16(matcher.dnsType)], matcher.answer)
+	return matchers.BeDNSRecord(domain, dnsType, answer)
 }
@@ -1,12 +1,19 @@
 package lists
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/0xERR0R/blocky/chaos"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/evt"
 	"github.com/avast/retry-go/v4"
@@ -25,11 +32,38 @@ func (e *TransientError) Unwrap() error {
 	return e.inner
 }
 
+// ErrNotModified is returned by a conditional download if the file didn't change
+var ErrNotModified = errors.New("not modified")
+
+// headerFileRefRegex matches references to files in header values, e.g. `${file:/run/secrets/token}`
+var headerFileRefRegex = regexp.MustCompile(`\$\{file:([^}]+)\}`)
+
+// CacheValidators identify the version of a downloaded file (HTTP ETag and Last-Modified headers)
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// IsEmpty returns true if the server didn't return any validator
+func (v CacheValidators) IsEmpty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
 // FileDownloader is able to download some text file
 type FileDownloader interface {
 	DownloadFile(link string) (io.ReadCloser, error)
 }
 
+// ConditionalDownloader is able to download a file only if it was modified
+type ConditionalDownloader interface {
+	// DownloadFileIfModified downloads the file if it doesn't match the validators of a previous download,
+	// otherwise ErrNotModified is returned. Returns the validators of the downloaded file.
+	// `httpCfg` authenticates the request, it can be nil
+	DownloadFileIfModified(
+		link string, validators CacheValidators, httpCfg *config.HTTPSourceConfig,
+	) (io.ReadCloser, CacheValidators, error)
+}
+
 // httpDownloader downloads files via HTTP protocol
 type httpDownloader struct {
 	cfg config.DownloaderConfig
@@ -53,14 +87,67 @@ func newDownloader(cfg config.DownloaderConfig, transport http.RoundTripper) *ht
 }
 
 func (d *httpDownloader) DownloadFile(link string) (io.ReadCloser, error) {
-	var body io.ReadCloser
+	body, _, err := d.DownloadFileIfModified(link, CacheValidators{}, nil)
 
-	err := retry.Do(
+	return body, err
+}
+
+func (d *httpDownloader) DownloadFileIfModified(
+	link string, validators CacheValidators, httpCfg *config.HTTPSourceConfig,
+) (io.ReadCloser, CacheValidators, error) {
+	var (
+		body        io.ReadCloser
+		result      CacheValidators
+		notModified bool
+	)
+
+	// files are read on each download, so secrets can be rotated without restart
+	headers, err := requestHeaders(httpCfg)
+	if err != nil {
+		return nil, validators, err
+	}
+
+	client, err := d.clientFor(httpCfg)
+	if err != nil {
+		return nil, validators, err
+	}
+
+	time.Sleep(chaos.ListDownloadDelay())
+
+	err = retry.Do(
 		func() error {
-			resp, httpErr := d.client.Get(link)
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			if err != nil {
+				return err
+			}
+
+			for name, values := range headers {
+				req.Header[name] = values
+			}
+
+			if validators.ETag != "" {
+				req.Header.Set("If-None-Match", validators.ETag)
+			}
+
+			if validators.LastModified != "" {
+				req.Header.Set("If-Modified-Since", validators.LastModified)
+			}
+
+			resp, httpErr := client.Do(req)
 			if httpErr == nil {
-				if resp.StatusCode == http.StatusOK {
+				switch resp.StatusCode {
+				case http.StatusOK:
 					body = resp.Body
+					result = CacheValidators{
+						ETag:         resp.Header.Get("ETag"),
+						LastModified: resp.Header.Get("Last-Modified"),
+					}
+
+					return nil
+
+				case http.StatusNotModified:
+					_ = resp.Body.Close()
+					notModified = true
 
 					return nil
 				}
@@ -77,8 +164,9 @@ func (d *httpDownloader) DownloadFile(link string) (io.ReadCloser, error) {
 			return httpErr
 		},
 		retry.Attempts(d.cfg.Attempts),
-		retry.DelayType(retry.FixedDelay),
+		retry.DelayType(retry.BackOffDelay),
 		retry.Delay(d.cfg.Cooldown.ToDuration()),
+		retry.MaxDelay(d.cfg.MaxCooldown.ToDuration()),
 		retry.LastErrorOnly(true),
 		retry.OnRetry(func(n uint, err error) {
 			var transientErr *TransientError
@@ -101,7 +189,133 @@ func (d *httpDownloader) DownloadFile(link string) (io.ReadCloser, error) {
 			onDownloadError(link)
 		}))
 
-	return body, err
+	if err == nil && notModified {
+		return nil, validators, ErrNotModified
+	}
+
+	return body, result, err
+}
+
+// clientFor returns the client for a source, with a separate transport if the source configures TLS
+func (d *httpDownloader) clientFor(httpCfg *config.HTTPSourceConfig) (*http.Client, error) {
+	if httpCfg == nil || !httpCfg.TLS.IsEnabled() {
+		return &d.client, nil
+	}
+
+	tlsCfg, err := tlsConfig(httpCfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport *http.Transport
+
+	switch t := d.client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("tls options are not supported by transport %T", t)
+	}
+
+	transport.TLSClientConfig = tlsCfg
+	// the transport is only used for one download, idle connections would never be closed
+	transport.DisableKeepAlives = true
+
+	client := d.client
+	client.Transport = transport
+
+	return &client, nil
+}
+
+func tlsConfig(cfg config.TLSFilesConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate: %w", err)
+		}
+
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CA != "" {
+		data, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, fmt.Errorf("can't load CA: %w", err)
+		}
+
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("can't load CA: no certificates found in '%s'", cfg.CA)
+		}
+	}
+
+	return tlsCfg, nil
+}
+
+// requestHeaders returns the configured headers of a source with the referenced files resolved
+func requestHeaders(httpCfg *config.HTTPSourceConfig) (http.Header, error) {
+	headers := make(http.Header)
+
+	if httpCfg == nil {
+		return headers, nil
+	}
+
+	if httpCfg.HeadersFile != "" {
+		data, err := os.ReadFile(httpCfg.HeadersFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read headers file: %w", err)
+		}
+
+		for i, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			name, value, found := strings.Cut(line, ":")
+			if !found || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("invalid header in line %d of '%s'", i+1, httpCfg.HeadersFile)
+			}
+
+			headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+
+	for name, value := range httpCfg.Headers {
+		value, err := resolveFileRefs(value)
+		if err != nil {
+			return nil, fmt.Errorf("header '%s': %w", name, err)
+		}
+
+		headers.Set(name, value)
+	}
+
+	return headers, nil
+}
+
+// resolveFileRefs replaces references like `${file:/run/secrets/token}` with the trimmed content of the file
+func resolveFileRefs(value string) (string, error) {
+	var err error
+
+	resolved := headerFileRefRegex.ReplaceAllStringFunc(value, func(ref string) string {
+		path := headerFileRefRegex.FindStringSubmatch(ref)[1]
+
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			err = errors.Join(err, readErr)
+
+			return ref
+		}
+
+		return strings.TrimSpace(string(data))
+	})
+
+	return resolved, err
 }
 
 func onDownloadError(link string) {
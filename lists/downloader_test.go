@@ -1,8 +1,16 @@
 package lists
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
 	"io"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -92,6 +100,39 @@ var _ = Describe("Downloader", func() {
 				Expect(buf.String()).Should(Equal("line.one\nline.two"))
 			})
 		})
+		When("Server supports conditional requests", func() {
+			BeforeEach(func() {
+				server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					if req.Header.Get("If-None-Match") == `"v1"` {
+						rw.WriteHeader(http.StatusNotModified)
+
+						return
+					}
+
+					rw.Header().Set("ETag", `"v1"`)
+					rw.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+					_, _ = rw.Write([]byte("line.one"))
+				}))
+				DeferCleanup(server.Close)
+			})
+			It("Should return the validators and ErrNotModified for an unchanged file", func() {
+				reader, validators, err := sut.DownloadFileIfModified(server.URL, CacheValidators{}, nil)
+
+				Expect(err).Should(Succeed())
+				DeferCleanup(reader.Close)
+				Expect(validators).Should(Equal(CacheValidators{
+					ETag:         `"v1"`,
+					LastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+				}))
+
+				reader, validators2, err := sut.DownloadFileIfModified(server.URL, validators, nil)
+
+				Expect(err).Should(MatchError(ErrNotModified))
+				Expect(reader).Should(BeNil())
+				Expect(validators2).Should(Equal(validators))
+				Expect(failedDownloadCountEvtChannel).Should(BeEmpty())
+			})
+		})
 		When("Server returns NOT_FOUND (404)", func() {
 			BeforeEach(func() {
 				server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
@@ -111,6 +152,26 @@ var _ = Describe("Downloader", func() {
 				Expect(failedDownloadCountEvtChannel).Should(Receive(Equal(server.URL)))
 			})
 		})
+		When("Download fails repeatedly", func() {
+			BeforeEach(func() {
+				server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					rw.WriteHeader(http.StatusInternalServerError)
+				}))
+				DeferCleanup(server.Close)
+
+				sutConfig.Attempts = 3
+				sutConfig.Cooldown = config.Duration(50 * time.Millisecond)
+			})
+			It("Should double the cooldown after each attempt", func() {
+				start := time.Now()
+
+				_, err := sut.DownloadFile(server.URL)
+
+				Expect(err).Should(HaveOccurred())
+				Expect(time.Since(start)).Should(BeNumerically(">=", 150*time.Millisecond))
+				Expect(failedDownloadCountEvtChannel).Should(HaveLen(3))
+			})
+		})
 		When("Wrong URL is defined", func() {
 			BeforeEach(func() {
 				sutConfig.Attempts = 1
@@ -215,4 +276,174 @@ var _ = Describe("Downloader", func() {
 			})
 		})
 	})
+
+	Describe("Authentication of a download", func() {
+		var (
+			tmpDir  *TmpFolder
+			httpCfg *config.HTTPSourceConfig
+			sutURL  string
+		)
+
+		BeforeEach(func() {
+			tmpDir = NewTmpFolder("downloader")
+			Expect(tmpDir.Error).Should(Succeed())
+			DeferCleanup(tmpDir.Clean)
+
+			sutConfig.Attempts = 1
+		})
+
+		download := func(url string) (string, error) {
+			reader, _, err := sut.DownloadFileIfModified(url, CacheValidators{}, httpCfg)
+			if err != nil {
+				return "", err
+			}
+
+			defer reader.Close()
+
+			data, err := io.ReadAll(reader)
+
+			return string(data), err
+		}
+
+		When("headers are configured", func() {
+			var received http.Header
+
+			BeforeEach(func() {
+				server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					received = req.Header
+					_, _ = rw.Write([]byte("blocked1.com"))
+				}))
+				DeferCleanup(server.Close)
+
+				token := tmpDir.CreateStringFile("token", "secret-token")
+				Expect(token.Error).Should(Succeed())
+
+				headersFile := tmpDir.CreateStringFile("headers", "# comment", "", "X-Api-Key: key1", "X-Team: blue")
+				Expect(headersFile.Error).Should(Succeed())
+
+				httpCfg = &config.HTTPSourceConfig{
+					Headers: map[string]string{
+						"Authorization": "Bearer ${file:" + token.Path + "}",
+						"X-Team":        "red",
+					},
+					HeadersFile: headersFile.Path,
+				}
+
+				DeferCleanup(func() { httpCfg = nil })
+
+				sutURL = server.URL
+			})
+
+			It("should send them with the referenced files resolved", func() {
+				Expect(download(sutURL)).Should(Equal("blocked1.com"))
+
+				Expect(received.Get("Authorization")).Should(Equal("Bearer secret-token"))
+				Expect(received.Get("X-Api-Key")).Should(Equal("key1"))
+				// headers of the config override the headers file
+				Expect(received.Values("X-Team")).Should(Equal([]string{"red"}))
+			})
+
+			It("should fail if a referenced file is missing", func() {
+				httpCfg.Headers["Authorization"] = "Bearer ${file:" + tmpDir.JoinPath("missing") + "}"
+
+				_, err := download(sutURL)
+				Expect(err).Should(MatchError(ContainSubstring("header 'Authorization'")))
+				Expect(failedDownloadCountEvtChannel).Should(BeEmpty())
+			})
+
+			It("should fail for an invalid headers file", func() {
+				invalid := tmpDir.CreateStringFile("invalid", "X-Api-Key key1")
+				Expect(invalid.Error).Should(Succeed())
+
+				httpCfg.HeadersFile = invalid.Path
+
+				_, err := download(sutURL)
+				Expect(err).Should(MatchError(ContainSubstring("invalid header in line 1")))
+			})
+		})
+
+		When("TLS is configured", func() {
+			BeforeEach(func() {
+				certPEM, keyPEM := newTestClientCert()
+
+				clientCAs := x509.NewCertPool()
+				Expect(clientCAs.AppendCertsFromPEM(certPEM)).Should(BeTrue())
+
+				server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					_, _ = rw.Write([]byte("blocked1.com"))
+				}))
+				server.TLS = &tls.Config{
+					ClientAuth: tls.RequireAndVerifyClientCert,
+					ClientCAs:  clientCAs,
+					MinVersion: tls.VersionTLS12,
+				}
+				server.StartTLS()
+				DeferCleanup(server.Close)
+
+				serverCA := tmpDir.CreateStringFile("ca.pem", string(pem.EncodeToMemory(&pem.Block{
+					Type: "CERTIFICATE", Bytes: server.Certificate().Raw,
+				})))
+				Expect(serverCA.Error).Should(Succeed())
+
+				cert := tmpDir.CreateStringFile("client.pem", string(certPEM))
+				Expect(cert.Error).Should(Succeed())
+
+				key := tmpDir.CreateStringFile("client-key.pem", string(keyPEM))
+				Expect(key.Error).Should(Succeed())
+
+				httpCfg = &config.HTTPSourceConfig{
+					TLS: config.TLSFilesConfig{Cert: cert.Path, Key: key.Path, CA: serverCA.Path},
+				}
+
+				DeferCleanup(func() { httpCfg = nil })
+
+				sutURL = server.URL
+			})
+
+			It("should authenticate with the client certificate", func() {
+				Expect(download(sutURL)).Should(Equal("blocked1.com"))
+			})
+
+			It("should fail without client certificate", func() {
+				httpCfg.TLS.Cert = ""
+				httpCfg.TLS.Key = ""
+
+				_, err := download(sutURL)
+				Expect(err).Should(HaveOccurred())
+			})
+
+			It("should fail for an invalid CA file", func() {
+				httpCfg.TLS.CA = httpCfg.TLS.Key
+
+				_, err := download(sutURL)
+				Expect(err).Should(MatchError(ContainSubstring("no certificates found")))
+			})
+		})
+	})
 })
+
+// newTestClientCert returns a self-signed client certificate and its key in PEM format
+func newTestClientCert() (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).Should(Succeed())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "blocky"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).Should(Succeed())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).Should(Succeed())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
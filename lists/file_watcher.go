@@ -0,0 +1,288 @@
+package lists
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchDebounce is the time without further changes, after which changed files are refreshed.
+// Editors often write a file with multiple events (truncate, write, rename), which are handled as one change.
+const WatchDebounce = 500 * time.Millisecond
+
+// watchedSource is a local file or glob source, identified by its index
+type watchedSource struct {
+	index   int
+	pattern string
+	glob    bool
+}
+
+func (s *watchedSource) matches(path string) bool {
+	if !s.glob {
+		return path == s.pattern
+	}
+
+	matched, _ := filepath.Match(s.pattern, path)
+
+	return matched
+}
+
+// WatchSources watches the local file and glob sources and calls refresh with the indexes of the changed sources,
+// once no further change happened for `debounce`. The directories of the files are watched, so files replaced by
+// editors and files matching a glob which are created later are picked up.
+//
+// All calls share one file watcher, since the number of watchers (inotify instances) per user is limited.
+// An error is returned if the sources can't be watched, the periodic refresh is the only refresh then.
+// Watching stops when ctx is done, the shared watcher is closed once no sources are watched anymore.
+func WatchSources(
+	ctx context.Context, sources []config.BytesSource, debounce time.Duration, refresh func(only []int),
+) error {
+	watched, dirs, err := watchedSources(sources)
+	if err != nil {
+		return err
+	}
+
+	if len(watched) == 0 {
+		return nil
+	}
+
+	sub, err := sharedWatcher.subscribe(watched, dirs)
+	if err != nil {
+		return err
+	}
+
+	go sub.run(ctx, debounce, refresh)
+
+	return nil
+}
+
+func watchedSources(sources []config.BytesSource) (watched []watchedSource, dirs []string, err error) {
+	dirSet := make(map[string]struct{})
+
+	for i, source := range sources {
+		if source.Type != config.BytesSourceTypeFile && source.Type != config.BytesSourceTypeGlob {
+			continue
+		}
+
+		path, err := filepath.Abs(source.From)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't watch %s: %w", source, err)
+		}
+
+		dir := filepath.Dir(path)
+		if strings.ContainsAny(dir, "*?[") {
+			return nil, nil, fmt.Errorf("can't watch %s: only the file name may contain glob patterns", source)
+		}
+
+		watched = append(watched, watchedSource{
+			index:   i,
+			pattern: path,
+			glob:    source.Type == config.BytesSourceTypeGlob,
+		})
+
+		if _, found := dirSet[dir]; !found {
+			dirSet[dir] = struct{}{}
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return watched, dirs, nil
+}
+
+// sharedWatcher is the file watcher of all watched sources
+var sharedWatcher = &fileWatcher{}
+
+// fileWatcher routes the events of one fsnotify watcher to the subscriptions of the watched sources.
+// The watcher is created with the first subscription and closed after the last one was removed.
+type fileWatcher struct {
+	lock    sync.Mutex
+	watcher *fsnotify.Watcher
+	// number of subscriptions per watched directory
+	dirs map[string]int
+	subs map[*subscription]struct{}
+}
+
+// subscription collects the changed sources of one WatchSources call
+type subscription struct {
+	watched []watchedSource
+	dirs    []string
+
+	lock    sync.Mutex
+	changed map[int]struct{}
+	// signals a change, doesn't block the event routing while a refresh is running
+	notify chan struct{}
+}
+
+func (w *fileWatcher) subscribe(watched []watchedSource, dirs []string) (*subscription, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return nil, fmt.Errorf("can't create file watcher: %w", err)
+		}
+
+		w.watcher = watcher
+		w.dirs = make(map[string]int)
+		w.subs = make(map[*subscription]struct{})
+
+		go w.route(watcher)
+	}
+
+	sub := &subscription{
+		watched: watched,
+		changed: make(map[int]struct{}),
+		notify:  make(chan struct{}, 1),
+	}
+
+	for _, dir := range dirs {
+		if w.dirs[dir] == 0 {
+			if err := w.watcher.Add(dir); err != nil {
+				w.removeLocked(sub)
+
+				return nil, fmt.Errorf("can't watch directory %s: %w", dir, err)
+			}
+		}
+
+		w.dirs[dir]++
+		sub.dirs = append(sub.dirs, dir)
+	}
+
+	w.subs[sub] = struct{}{}
+
+	return sub, nil
+}
+
+func (w *fileWatcher) unsubscribe(sub *subscription) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.removeLocked(sub)
+}
+
+// removeLocked removes the subscription and its directories, w.lock must be held
+func (w *fileWatcher) removeLocked(sub *subscription) {
+	delete(w.subs, sub)
+
+	for _, dir := range sub.dirs {
+		w.dirs[dir]--
+
+		if w.dirs[dir] == 0 {
+			delete(w.dirs, dir)
+
+			_ = w.watcher.Remove(dir)
+		}
+	}
+
+	if len(w.subs) == 0 {
+		_ = w.watcher.Close()
+
+		w.watcher = nil
+	}
+}
+
+// route passes the events of the watcher to the subscriptions until the watcher is closed
+func (w *fileWatcher) route(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op == fsnotify.Chmod {
+				// the content didn't change
+				continue
+			}
+
+			path := filepath.Clean(event.Name)
+
+			w.lock.Lock()
+
+			for sub := range w.subs {
+				sub.handle(path)
+			}
+
+			w.lock.Unlock()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			logger().WithError(err).Warn("error watching files, changes may only be picked up by the periodic refresh")
+		}
+	}
+}
+
+func (s *subscription) handle(path string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	matched := false
+
+	for _, source := range s.watched {
+		if source.matches(path) {
+			s.changed[source.index] = struct{}{}
+			matched = true
+		}
+	}
+
+	if matched {
+		select {
+		case s.notify <- struct{}{}:
+		default:
+			// a change is already signaled
+		}
+	}
+}
+
+// takeChanged returns the sorted indexes of the changed sources and resets them
+func (s *subscription) takeChanged() []int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	only := make([]int, 0, len(s.changed))
+
+	for index := range s.changed {
+		only = append(only, index)
+	}
+
+	sort.Ints(only)
+
+	s.changed = make(map[int]struct{})
+
+	return only
+}
+
+func (s *subscription) run(ctx context.Context, debounce time.Duration, refresh func(only []int)) {
+	defer sharedWatcher.unsubscribe(s)
+
+	// nil until a change is pending: a nil channel blocks forever
+	var debounced <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-s.notify:
+			debounced = time.After(debounce)
+
+		case <-debounced:
+			debounced = nil
+
+			if only := s.takeChanged(); len(only) > 0 {
+				refresh(only)
+			}
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package lists
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WatchSources", func() {
+	const debounce = 50 * time.Millisecond
+
+	var (
+		tmpDir    *TmpFolder
+		ctx       context.Context
+		cancel    context.CancelFunc
+		refreshes chan []int
+		sources   []config.BytesSource
+	)
+
+	BeforeEach(func() {
+		tmpDir = NewTmpFolder("watcher")
+		Expect(tmpDir.Error).Should(Succeed())
+		DeferCleanup(tmpDir.Clean)
+
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		refreshes = make(chan []int, 10)
+	})
+
+	watch := func() error {
+		return WatchSources(ctx, sources, debounce, func(only []int) {
+			refreshes <- only
+		})
+	}
+
+	write := func(path, content string) {
+		Expect(os.WriteFile(path, []byte(content), 0o600)).Should(Succeed())
+	}
+
+	When("a file source changes", func() {
+		BeforeEach(func() {
+			first := tmpDir.CreateStringFile("first.txt", "first.com")
+			Expect(first.Error).Should(Succeed())
+
+			second := tmpDir.CreateStringFile("second.txt", "second.com")
+			Expect(second.Error).Should(Succeed())
+
+			sources = []config.BytesSource{
+				config.TextBytesSource("inline.com"),
+				config.NewBytesSources(first.Path)[0],
+				config.NewBytesSources(second.Path)[0],
+			}
+
+			Expect(watch()).Should(Succeed())
+		})
+
+		It("should only refresh the changed source", func() {
+			write(tmpDir.JoinPath("second.txt"), "changed.com")
+
+			Eventually(refreshes).Should(Receive(Equal([]int{2})))
+			Consistently(refreshes, 2*debounce).ShouldNot(Receive())
+		})
+
+		It("should refresh once for multiple writes", func() {
+			for i := 0; i < 5; i++ {
+				write(tmpDir.JoinPath("first.txt"), "changed.com")
+			}
+
+			write(tmpDir.JoinPath("second.txt"), "changed.com")
+
+			Eventually(refreshes).Should(Receive(Equal([]int{1, 2})))
+			Consistently(refreshes, 2*debounce).ShouldNot(Receive())
+		})
+
+		It("should refresh a file replaced by renaming", func() {
+			write(tmpDir.JoinPath("first.txt.tmp"), "changed.com")
+			Expect(os.Rename(tmpDir.JoinPath("first.txt.tmp"), tmpDir.JoinPath("first.txt"))).Should(Succeed())
+
+			Eventually(refreshes).Should(Receive(Equal([]int{1})))
+		})
+
+		It("should ignore other files of the directory", func() {
+			write(tmpDir.JoinPath("other.txt"), "other.com")
+
+			Consistently(refreshes, 4*debounce).ShouldNot(Receive())
+		})
+
+		It("should stop when the context is done", func() {
+			cancel()
+
+			write(tmpDir.JoinPath("first.txt"), "changed.com")
+
+			Consistently(refreshes, 4*debounce).ShouldNot(Receive())
+		})
+	})
+
+	When("a glob source is used", func() {
+		BeforeEach(func() {
+			sources = []config.BytesSource{
+				{Type: config.BytesSourceTypeGlob, From: filepath.Join(tmpDir.Path, "*.list")},
+			}
+
+			Expect(watch()).Should(Succeed())
+		})
+
+		It("should refresh for created matching files", func() {
+			write(tmpDir.JoinPath("new.list"), "new.com")
+
+			Eventually(refreshes).Should(Receive(Equal([]int{0})))
+		})
+
+		It("should ignore files which don't match", func() {
+			write(tmpDir.JoinPath("new.txt"), "new.com")
+
+			Consistently(refreshes, 4*debounce).ShouldNot(Receive())
+		})
+	})
+
+	When("multiple sources are watched", func() {
+		var (
+			otherCtx       context.Context
+			otherCancel    context.CancelFunc
+			otherRefreshes chan []int
+		)
+
+		// number of subscriptions which watch the directory
+		dirWatches := func() int {
+			sharedWatcher.lock.Lock()
+			defer sharedWatcher.lock.Unlock()
+
+			return sharedWatcher.dirs[tmpDir.Path]
+		}
+
+		BeforeEach(func() {
+			first := tmpDir.CreateStringFile("first.txt", "first.com")
+			Expect(first.Error).Should(Succeed())
+
+			second := tmpDir.CreateStringFile("second.txt", "second.com")
+			Expect(second.Error).Should(Succeed())
+
+			sources = config.NewBytesSources(first.Path)
+			Expect(watch()).Should(Succeed())
+
+			otherCtx, otherCancel = context.WithCancel(context.Background())
+			DeferCleanup(otherCancel)
+
+			otherRefreshes = make(chan []int, 10)
+
+			Expect(WatchSources(otherCtx, config.NewBytesSources(second.Path), debounce, func(only []int) {
+				otherRefreshes <- only
+			})).Should(Succeed())
+		})
+
+		It("should route the changes to the watching sources", func() {
+			write(tmpDir.JoinPath("second.txt"), "changed.com")
+
+			Eventually(otherRefreshes).Should(Receive(Equal([]int{0})))
+			Consistently(refreshes, 2*debounce).ShouldNot(Receive())
+		})
+
+		It("should stop watching the directory when all contexts are done", func() {
+			Expect(dirWatches()).Should(Equal(2))
+
+			cancel()
+
+			Eventually(dirWatches).Should(Equal(1))
+
+			write(tmpDir.JoinPath("second.txt"), "changed.com")
+
+			Eventually(otherRefreshes).Should(Receive(Equal([]int{0})))
+
+			otherCancel()
+
+			Eventually(dirWatches).Should(Equal(0))
+		})
+	})
+
+	When("the last subscription is removed", func() {
+		It("should close the watcher", func() {
+			sut := &fileWatcher{}
+
+			sub, err := sut.subscribe(nil, []string{tmpDir.Path})
+			Expect(err).Should(Succeed())
+			Expect(sut.watcher).ShouldNot(BeNil())
+
+			other, err := sut.subscribe(nil, []string{tmpDir.Path})
+			Expect(err).Should(Succeed())
+
+			sut.unsubscribe(sub)
+			Expect(sut.watcher).ShouldNot(BeNil())
+
+			sut.unsubscribe(other)
+			Expect(sut.watcher).Should(BeNil())
+			Expect(sut.dirs).Should(BeEmpty())
+		})
+
+		It("should close the watcher if the directory can't be watched", func() {
+			sut := &fileWatcher{}
+
+			_, err := sut.subscribe(nil, []string{tmpDir.Path, "/this/dir/does/not/exist"})
+			Expect(err).Should(MatchError(ContainSubstring("can't watch directory")))
+			Expect(sut.watcher).Should(BeNil())
+			Expect(sut.dirs).Should(BeEmpty())
+		})
+	})
+
+	When("the directory of a glob contains a pattern", func() {
+		It("should fail", func() {
+			sources = []config.BytesSource{
+				{Type: config.BytesSourceTypeGlob, From: filepath.Join(tmpDir.Path, "*", "a.list")},
+			}
+
+			Expect(watch()).Should(MatchError(ContainSubstring("only the file name may contain glob patterns")))
+		})
+	})
+
+	When("the directory doesn't exist", func() {
+		It("should fail", func() {
+			sources = config.NewBytesSources("/this/dir/does/not/exist/hosts")
+
+			Expect(watch()).Should(MatchError(ContainSubstring("can't watch directory")))
+		})
+	})
+
+	When("there are no local files", func() {
+		It("should do nothing", func() {
+			sources = config.NewBytesSources("http://example.com/list")
+
+			Expect(watch()).Should(Succeed())
+		})
+	})
+})
@@ -2,24 +2,43 @@ package lists
 
 //go:generate go run github.com/abice/go-enum -f=$GOFILE --marshal --names
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
 
 	"github.com/0xERR0R/blocky/cache/stringcache"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/lists/parsers"
 	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/util"
 	"github.com/ThinkChaos/parcour"
 	"github.com/ThinkChaos/parcour/jobgroup"
 )
 
 const groupProducersBufferCap = 1000
 
+// ErrTooManyRegexes is returned if a group contains more regex entries than allowed by the configuration
+var ErrTooManyRegexes = errors.New("too many regex entries")
+
+// ErrTooManyFailedSources is returned if more sources of a group failed than allowed by the configuration
+var ErrTooManyFailedSources = errors.New("too many failed sources")
+
 // ListCacheType represents the type of cached list ENUM(
 // blacklist // is a list with blocked domains
 // whitelist // is a list with whitelisted domains / IPs
@@ -30,16 +49,114 @@ type ListCacheType int
 type Matcher interface {
 	// Match matches passed domain name against cached list entries
 	Match(domain string, groupsToCheck []string) (groups []string)
+
+	// Explain returns the list entries which match the domain name
+	Explain(domain string, groupsToCheck []string) []MatchedEntry
 }
 
-// ListCache generic cache of strings divided in groups
+// MatchedEntry is a list entry which matched a domain name
+type MatchedEntry struct {
+	Group  string
+	Source string
+	// Entry as it is stored in the cache, e.g. "*.example.com" for a wildcard entry
+	Entry string
+}
+
+// LookupEntry is a list entry found by `ListCache.Lookup`
+type LookupEntry struct {
+	MatchedEntry
+	Type stringcache.MatchType
+	// Exception is set for exception entries (e.g. `@@` rules of ABP lists)
+	Exception bool
+}
+
+// ListCache generic cache of strings divided in groups.
+// Each source of a group is cached separately, so a match can be tracked back to the source
 type ListCache struct {
 	groupedCache stringcache.GroupedStringCache
 
 	cfg          config.SourceLoadingConfig
 	listType     ListCacheType
 	groupSources map[string][]config.BytesSource
-	downloader   FileDownloader
+	sourceKeys   map[string][]string
+	// refreshPeriods override the refresh period of the loading config by group
+	refreshPeriods map[string]config.Duration
+	// groupLocks serialize the refreshes of each group, since the groups have independent timers
+	groupLocks map[string]*sync.Mutex
+	// exceptionKeys are the keys of the exception entries of each source, see `sourceEntry.exception`
+	exceptionKeys map[string][]string
+	downloader    FileDownloader
+
+	statesLock   sync.RWMutex
+	sourceStates map[string]sourceState
+
+	// listIPs are the IPs of the hosts file entries of the sources with `useListIPs`, by source key and domain
+	listIPsLock sync.RWMutex
+	listIPs     map[string]map[string][]net.IP
+
+	// refreshed is set by the first refresh, which loads the lists on startup
+	refreshed atomic.Bool
+
+	// profile the events are published for
+	profile string
+}
+
+// sourceState is kept for each source across refreshes
+type sourceState struct {
+	validators  CacheValidators
+	regexCount  uint
+	lastChanged time.Time
+	// contentHash is the SHA-256 hash of the content of the last load
+	contentHash []byte
+
+	entries     int
+	lastSuccess time.Time
+	lastErr     error
+	errorCount  uint
+}
+
+// sourceRefresh is the state of a source during the refresh of its group
+type sourceRefresh struct {
+	previous    sourceState
+	validators  CacheValidators
+	regexCount  uint
+	notModified bool
+	contentHash []byte
+	// unchanged is set if the source was read, but has the same content hash as the previous load
+	unchanged bool
+	// skipped is set if the source isn't part of the refresh, since it has another refresh period
+	skipped bool
+	// err is set if the source couldn't be loaded
+	err error
+}
+
+// keepsEntries returns true if the entries of the previous refresh are kept
+func (r *sourceRefresh) keepsEntries() bool {
+	return r.skipped || r.notModified || r.unchanged || r.err != nil
+}
+
+// SourceStatus is the status of a list source
+type SourceStatus struct {
+	Group  string
+	Source string
+	// LastChanged is the time the source was last loaded with changes, zero if it wasn't loaded yet
+	LastChanged time.Time
+	// Entries is the number of entries of the source in the cache
+	Entries int
+	// LastSuccess is the time of the last refresh whose result is used, zero if there was none
+	LastSuccess time.Time
+	// LastErr is the error of the last refresh, nil if it succeeded
+	LastErr error
+	// ErrorCount is the number of failed refreshes
+	ErrorCount uint
+}
+
+// GroupStatus is the status of a list group and its sources
+type GroupStatus struct {
+	Group string
+	// Entries is the number of entries of all sources of the group
+	Entries int
+	Sources []SourceStatus
 }
 
 // LogConfig implements `config.Configurable`.
@@ -47,48 +164,299 @@ func (b *ListCache) LogConfig(logger *logrus.Entry) {
 	var total int
 
 	for group := range b.groupSources {
-		count := b.groupedCache.ElementCount(group)
-		logger.Infof("%s: %d entries", group, count)
+		count := b.elementCount(group)
+		logger.Infof("%s: %d entries, refresh = %s", group, count, b.describeRefresh(group))
 		total += count
 	}
 
 	logger.Infof("TOTAL: %d entries", total)
 }
 
-// NewListCache creates new list instance
+// NewListCache creates new list instance.
+// The refresh period of the loading config can be overridden by group with `refreshPeriods`
+// and by source with `config.BytesSource.RefreshPeriod`, each group and period has its own timer.
+// The events of the list cache are published for `profile`, changed files are watched until ctx is done.
 func NewListCache(
-	t ListCacheType, cfg config.SourceLoadingConfig,
+	ctx context.Context, t ListCacheType, cfg config.SourceLoadingConfig,
 	groupSources map[string][]config.BytesSource, downloader FileDownloader,
+	refreshPeriods map[string]config.Duration, profile string,
 ) (*ListCache, error) {
 	c := &ListCache{
 		groupedCache: stringcache.NewChainedGroupedCache(
 			stringcache.NewInMemoryGroupedStringCache(),
+			stringcache.NewInMemoryGroupedWildcardCache(),
+			stringcache.NewInMemoryGroupedCIDRCache(),
 			stringcache.NewInMemoryGroupedRegexCache(),
 		),
 
-		cfg:          cfg,
-		listType:     t,
-		groupSources: groupSources,
-		downloader:   downloader,
+		cfg:            cfg,
+		listType:       t,
+		groupSources:   groupSources,
+		refreshPeriods: refreshPeriods,
+		groupLocks:     make(map[string]*sync.Mutex, len(groupSources)),
+		sourceKeys:     make(map[string][]string, len(groupSources)),
+		exceptionKeys:  make(map[string][]string, len(groupSources)),
+		downloader:     downloader,
+		sourceStates:   make(map[string]sourceState),
+		listIPs:        make(map[string]map[string][]net.IP),
+		profile:        profile,
 	}
 
-	err := cfg.StartPeriodicRefresh(c.refresh, func(err error) {
+	for group, sources := range groupSources {
+		c.groupLocks[group] = &sync.Mutex{}
+
+		for i := range sources {
+			c.sourceKeys[group] = append(c.sourceKeys[group], sourceKey(group, i))
+			c.exceptionKeys[group] = append(c.exceptionKeys[group], exceptionKey(group, i))
+		}
+	}
+
+	err := cfg.StartRefreshes(c.refresh, func(err error) {
 		logger().WithError(err).Errorf("could not init %s", t)
-	})
+	}, c.periodicRefreshes()...)
 	if err != nil {
 		return nil, err
 	}
 
+	if cfg.WatchFiles {
+		c.watchFiles(ctx)
+	}
+
 	return c, nil
 }
 
+// watchFiles refreshes the local file sources of each group as soon as they change.
+// The periodic refresh is kept, it's the fallback if the files can't be watched.
+func (b *ListCache) watchFiles(ctx context.Context) {
+	for group, sources := range b.groupSources {
+		group := group
+
+		err := WatchSources(ctx, sources, WatchDebounce, func(only []int) {
+			logger().WithField("group", group).Debugf("refreshing changed %s sources %v", b.listType, only)
+
+			if err := b.refreshGroups(context.Background(), map[string][]int{group: only}); err != nil {
+				logger().WithError(err).Errorf("could not refresh changed %s sources", b.listType)
+			}
+		})
+		if err != nil {
+			logger().WithError(err).WithField("group", group).
+				Warnf("can't watch %s files, changes are picked up by the periodic refresh", b.listType)
+		}
+	}
+}
+
 func logger() *logrus.Entry {
 	return log.PrefixedLog("list_cache")
 }
 
+// sourceKey is the key of a source in the grouped cache
+func sourceKey(group string, sourceIdx int) string {
+	return fmt.Sprintf("%s#%d", group, sourceIdx)
+}
+
+// exceptionKey is the key of the exception entries of a source in the grouped cache
+func exceptionKey(group string, sourceIdx int) string {
+	return sourceKey(group, sourceIdx) + "!"
+}
+
+// parseSourceKey returns the group and the index of the source of a key created by sourceKey
+func parseSourceKey(key string) (group string, sourceIdx int) {
+	idx := strings.LastIndexByte(key, '#')
+	sourceIdx, _ = strconv.Atoi(key[idx+1:])
+
+	return key[:idx], sourceIdx
+}
+
+func (b *ListCache) keysOf(groups []string) []string {
+	var keys []string
+
+	for _, group := range groups {
+		keys = append(keys, b.sourceKeys[group]...)
+	}
+
+	return keys
+}
+
+func (b *ListCache) elementCount(group string) int {
+	var count int
+
+	for _, key := range b.sourceKeys[group] {
+		count += b.groupedCache.ElementCount(key)
+	}
+
+	return count
+}
+
+// isException returns true if the domain is exempt from the entries of the group by an exception entry
+func (b *ListCache) isException(domain, group string) bool {
+	return len(b.groupedCache.Contains(domain, b.exceptionKeys[group])) > 0
+}
+
 // Match matches passed domain name against cached list entries
 func (b *ListCache) Match(domain string, groupsToCheck []string) (groups []string) {
-	return b.groupedCache.Contains(domain, groupsToCheck)
+	for _, key := range b.groupedCache.Contains(domain, b.keysOf(groupsToCheck)) {
+		group, _ := parseSourceKey(key)
+
+		if !slices.Contains(groups, group) && !b.isException(domain, group) {
+			groups = append(groups, group)
+		}
+	}
+
+	slices.Sort(groups)
+
+	return groups
+}
+
+// Explain returns the list entries which match the domain name, ordered by group and source
+func (b *ListCache) Explain(domain string, groupsToCheck []string) []MatchedEntry {
+	keys := b.keysOf(groupsToCheck)
+	entries := b.groupedCache.Explain(domain, keys)
+
+	result := make([]MatchedEntry, 0, len(entries))
+
+	for _, key := range keys {
+		entry, found := entries[key]
+		if !found {
+			continue
+		}
+
+		group, sourceIdx := parseSourceKey(key)
+
+		if b.isException(domain, group) {
+			continue
+		}
+
+		result = append(result, MatchedEntry{
+			Group:  group,
+			Source: b.groupSources[group][sourceIdx].String(),
+			Entry:  entry,
+		})
+	}
+
+	return result
+}
+
+// ListIPs returns the IPs of the hosts file entries of the domain in the sources with `useListIPs`.
+// The IPs are ordered by group and source, sinkhole IPs like 0.0.0.0 are not returned
+func (b *ListCache) ListIPs(domain string, groupsToCheck []string) []net.IP {
+	b.listIPsLock.RLock()
+	defer b.listIPsLock.RUnlock()
+
+	if len(b.listIPs) == 0 {
+		return nil
+	}
+
+	domain = strings.ToLower(domain)
+
+	var result []net.IP
+
+	for _, key := range b.keysOf(groupsToCheck) {
+		for _, ip := range b.listIPs[key][domain] {
+			result = appendIP(result, ip)
+		}
+	}
+
+	return result
+}
+
+// appendIP appends the IP, if it isn't already contained
+func appendIP(ips []net.IP, ip net.IP) []net.IP {
+	for _, existing := range ips {
+		if existing.Equal(ip) {
+			return ips
+		}
+	}
+
+	return append(ips, ip)
+}
+
+// isSinkholeIP returns true for the IPs used by hosts files to block a domain, e.g. 0.0.0.0 or 127.0.0.1
+func isSinkholeIP(ip net.IP) bool {
+	return ip.IsUnspecified() || ip.IsLoopback()
+}
+
+// Lookup returns the entries of all groups which match the domain, ordered by group and source.
+// It is meant for diagnostics: exact entries of parent domains are returned although they don't match,
+// and exceptions are returned instead of being applied
+func (b *ListCache) Lookup(domain string) []LookupEntry {
+	groups := maps.Keys(b.groupSources)
+	slices.Sort(groups)
+
+	var keys []string
+	for _, group := range groups {
+		keys = append(keys, b.sourceKeys[group]...)
+		keys = append(keys, b.exceptionKeys[group]...)
+	}
+
+	matches := b.groupedCache.Lookup(domain, keys)
+
+	var result []LookupEntry
+
+	for _, group := range groups {
+		for i, source := range b.groupSources[group] {
+			for _, key := range []string{sourceKey(group, i), exceptionKey(group, i)} {
+				for _, match := range matches[key] {
+					result = append(result, LookupEntry{
+						MatchedEntry: MatchedEntry{Group: group, Source: source.String(), Entry: match.Entry},
+						Type:         match.Type,
+						Exception:    key == exceptionKey(group, i),
+					})
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// Type returns the type of the list
+func (b *ListCache) Type() ListCacheType {
+	return b.listType
+}
+
+// Sources returns the status of the sources, ordered by group
+func (b *ListCache) Sources() []SourceStatus {
+	var result []SourceStatus
+
+	for _, group := range b.Groups() {
+		result = append(result, group.Sources...)
+	}
+
+	return result
+}
+
+// Groups returns the status of the groups and their sources, ordered by group
+func (b *ListCache) Groups() []GroupStatus {
+	b.statesLock.RLock()
+	defer b.statesLock.RUnlock()
+
+	groups := maps.Keys(b.groupSources)
+	slices.Sort(groups)
+
+	result := make([]GroupStatus, 0, len(groups))
+
+	for _, group := range groups {
+		status := GroupStatus{Group: group}
+
+		for i, source := range b.groupSources[group] {
+			state := b.sourceStates[sourceKey(group, i)]
+
+			status.Entries += state.entries
+			status.Sources = append(status.Sources, SourceStatus{
+				Group:       group,
+				Source:      source.String(),
+				LastChanged: state.lastChanged,
+				Entries:     state.entries,
+				LastSuccess: state.lastSuccess,
+				LastErr:     state.lastErr,
+				ErrorCount:  state.errorCount,
+			})
+		}
+
+		result = append(result, status)
+	}
+
+	return result
 }
 
 // Refresh triggers the refresh of a list
@@ -96,20 +464,111 @@ func (b *ListCache) Refresh() error {
 	return b.refresh(context.Background())
 }
 
+// refresh refreshes all sources of all groups
 func (b *ListCache) refresh(ctx context.Context) error {
+	groups := make(map[string][]int, len(b.groupSources))
+
+	for group := range b.groupSources {
+		groups[group] = nil
+	}
+
+	return b.refreshGroups(ctx, groups)
+}
+
+// groupRefreshPeriod returns the refresh period of the group
+func (b *ListCache) groupRefreshPeriod(group string) config.Duration {
+	if period, ok := b.refreshPeriods[group]; ok {
+		return period
+	}
+
+	return b.cfg.RefreshPeriod
+}
+
+// describeRefresh returns the refresh period of the group for the log, including the overrides of its sources
+func (b *ListCache) describeRefresh(group string) string {
+	res := "disabled"
+
+	if period := b.groupRefreshPeriod(group); period.IsAboveZero() {
+		res = fmt.Sprintf("every %s", period)
+	}
+
+	for _, source := range b.groupSources[group] {
+		if source.RefreshPeriod != nil {
+			return res + " (sources with own period)"
+		}
+	}
+
+	return res
+}
+
+// periodicRefreshes returns a refresh for each group and refresh period of its sources
+func (b *ListCache) periodicRefreshes() []config.PeriodicRefresh {
+	var res []config.PeriodicRefresh
+
+	for group, sources := range b.groupSources {
+		groupPeriod := b.groupRefreshPeriod(group)
+		sourcesByPeriod := make(map[config.Duration][]int)
+
+		for i, source := range sources {
+			period := groupPeriod
+			if source.RefreshPeriod != nil {
+				period = *source.RefreshPeriod
+			}
+
+			sourcesByPeriod[period] = append(sourcesByPeriod[period], i)
+		}
+
+		for period, idxs := range sourcesByPeriod {
+			if len(idxs) == len(sources) {
+				// all sources of the group
+				idxs = nil
+			}
+
+			selection := map[string][]int{group: idxs}
+
+			res = append(res, config.PeriodicRefresh{
+				Period: period,
+				Refresh: func(ctx context.Context) error {
+					return b.refreshGroups(ctx, selection)
+				},
+			})
+		}
+	}
+
+	return res
+}
+
+// refreshGroups refreshes the groups concurrently, only the given sources of a group are refreshed if they aren't nil
+func (b *ListCache) refreshGroups(ctx context.Context, groups map[string][]int) error {
 	unlimitedGrp, _ := jobgroup.WithContext(ctx)
 	defer unlimitedGrp.Close()
 
 	producersGrp := jobgroup.WithMaxConcurrency(unlimitedGrp, b.cfg.Concurrency)
 	defer producersGrp.Close()
 
-	for group, sources := range b.groupSources {
-		group, sources := group, sources
+	initial := b.refreshed.CompareAndSwap(false, true)
+
+	for group, only := range groups {
+		group, only := group, only
+		sources := b.groupSources[group]
 
 		unlimitedGrp.Go(func(ctx context.Context) error {
-			err := b.createCacheForGroup(producersGrp, unlimitedGrp, group, sources)
+			lock := b.groupLocks[group]
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			start := time.Now()
+
+			err := b.createCacheForGroup(producersGrp, unlimitedGrp, group, sources, only)
+
+			if initial {
+				evt.Bus().Publish(evt.StartupPhaseCompleted,
+					fmt.Sprintf("list load %s %s", b.listType, group), time.Since(start))
+			}
+
 			if err != nil {
-				count := b.groupedCache.ElementCount(group)
+				count := b.elementCount(group)
 
 				logger := logger().WithFields(logrus.Fields{
 					"group":       group,
@@ -125,9 +584,9 @@ func (b *ListCache) refresh(ctx context.Context) error {
 				return err
 			}
 
-			count := b.groupedCache.ElementCount(group)
+			count := b.elementCount(group)
 
-			evt.Bus().Publish(evt.BlockingCacheGroupChanged, b.listType, group, count)
+			evt.Bus().Publish(evt.BlockingCacheGroupChanged, b.profile, b.listType, group, count)
 
 			logger().WithFields(logrus.Fields{
 				"group":       group,
@@ -141,63 +600,261 @@ func (b *ListCache) refresh(ctx context.Context) error {
 	return unlimitedGrp.Wait()
 }
 
+// createCacheForGroup loads the sources of the group with the indexes in `only`, or all sources if it is nil.
+// The other sources keep their entries.
 func (b *ListCache) createCacheForGroup(
-	producersGrp, consumersGrp jobgroup.JobGroup, group string, sources []config.BytesSource,
+	producersGrp, consumersGrp jobgroup.JobGroup, group string, sources []config.BytesSource, only []int,
 ) error {
-	groupFactory := b.groupedCache.Refresh(group)
+	sourceFactories := make([]stringcache.GroupFactory, len(sources))
+	exceptionFactories := make([]stringcache.GroupFactory, len(sources))
+	sourceIPs := make([]map[string][]net.IP, len(sources))
+	refreshes := make([]sourceRefresh, len(sources))
 
-	producers := parcour.NewProducersWithBuffer[string](producersGrp, consumersGrp, groupProducersBufferCap)
+	b.statesLock.RLock()
+
+	for i := range sources {
+		sourceFactories[i] = b.groupedCache.Refresh(sourceKey(group, i))
+		exceptionFactories[i] = b.groupedCache.Refresh(exceptionKey(group, i))
+		refreshes[i].previous = b.sourceStates[sourceKey(group, i)]
+		refreshes[i].skipped = only != nil && !slices.Contains(only, i)
+	}
+
+	b.statesLock.RUnlock()
+
+	producers := parcour.NewProducersWithBuffer[sourceEntry](producersGrp, consumersGrp, groupProducersBufferCap)
 	defer producers.Close()
 
 	for i, source := range sources {
 		i, source := i, source
 
-		producers.GoProduce(func(ctx context.Context, hostsChan chan<- string) error {
+		if refreshes[i].skipped {
+			continue
+		}
+
+		producers.GoProduce(func(ctx context.Context, hostsChan chan<- sourceEntry) error {
 			locInfo := fmt.Sprintf("item #%d of group %s", i, group)
 
 			opener, err := NewSourceOpener(locInfo, source, b.downloader)
-			if err != nil {
-				return err
+			if err == nil {
+				err = b.parseFile(ctx, opener, source.Format, i, &refreshes[i], hostsChan)
+			}
+
+			if err != nil && !errors.Is(err, context.Canceled) {
+				// don't cancel the other sources, the failed sources are checked once all are loaded
+				refreshes[i].err = err
+
+				return nil
 			}
 
-			return b.parseFile(ctx, opener, hostsChan)
+			return err
 		})
 	}
 
-	hasEntries := false
+	var regexCount uint
+
+	producers.GoConsume(func(ctx context.Context, ch <-chan sourceEntry) error {
+		for entry := range ch {
+			host := entry.host
+
+			if entry.exception {
+				exceptionFactories[entry.source].AddEntry(host)
+
+				continue
+			}
+
+			if entry.ip != nil && sources[entry.source].UseListIPs {
+				if sourceIPs[entry.source] == nil {
+					sourceIPs[entry.source] = make(map[string][]net.IP)
+				}
+
+				domain := strings.ToLower(host)
+				sourceIPs[entry.source][domain] = appendIP(sourceIPs[entry.source][domain], entry.ip)
+			}
+
+			if isRegex(host) {
+				refreshes[entry.source].regexCount++
+				regexCount++
 
-	producers.GoConsume(func(ctx context.Context, ch <-chan string) error {
-		for host := range ch {
-			hasEntries = true
+				if b.cfg.MaxRegexesPerGroup > 0 && regexCount > b.cfg.MaxRegexesPerGroup {
+					// don't compile the remaining ones, the group is rejected anyway
+					continue
+				}
+			}
 
-			groupFactory.AddEntry(host)
+			sourceFactories[entry.source].AddEntry(host)
 		}
 
 		return nil
 	})
 
-	err := producers.Wait()
-	if err != nil {
-		if !hasEntries {
-			// Always fail the group if no entries were parsed
-			return err
+	if err := producers.Wait(); err != nil {
+		return err
+	}
+
+	for _, refresh := range refreshes {
+		if refresh.keepsEntries() {
+			// the entries of the source are kept, so its regexes count as well
+			regexCount += refresh.previous.regexCount
 		}
+	}
 
-		var transientErr *TransientError
+	if b.cfg.MaxRegexesPerGroup > 0 && regexCount > b.cfg.MaxRegexesPerGroup {
+		b.reportSources(group, sources, refreshes, true)
 
-		if errors.As(err, &transientErr) {
-			// Temporary error: fail the whole group to retry later
-			return err
-		}
+		return fmt.Errorf("%w: group %s has %d, the limit is %d (loading.maxRegexesPerGroup)",
+			ErrTooManyRegexes, group, regexCount, b.cfg.MaxRegexesPerGroup)
+	}
+
+	if err := b.checkFailedSources(group, refreshes); err != nil {
+		b.reportSources(group, sources, refreshes, true)
+
+		return err
 	}
 
-	groupFactory.Finish()
+	b.reportSources(group, sources, refreshes, false)
+
+	now := util.Now()
+
+	for i, factory := range sourceFactories {
+		key := sourceKey(group, i)
+
+		if refreshes[i].unchanged {
+			b.updateSourceState(key, func(state *sourceState) {
+				state.validators = refreshes[i].validators
+			})
+		}
+
+		if refreshes[i].keepsEntries() {
+			// keep the existing entries
+			continue
+		}
+
+		factory.Finish()
+		exceptionFactories[i].Finish()
+		b.setListIPs(key, sourceIPs[i])
+
+		entries := b.groupedCache.ElementCount(key)
+
+		b.updateSourceState(key, func(state *sourceState) {
+			state.validators = refreshes[i].validators
+			state.regexCount = refreshes[i].regexCount
+			state.lastChanged = now
+			state.contentHash = refreshes[i].contentHash
+			state.entries = entries
+		})
+
+		evt.Bus().Publish(evt.BlockingListSourceChanged, b.profile, b.listType, group, sources[i].String(), now, entries)
+	}
 
 	return nil
 }
 
+// checkFailedSources returns an error if all or more than the allowed percentage of the sources failed
+func (b *ListCache) checkFailedSources(group string, refreshes []sourceRefresh) error {
+	var (
+		failed   uint
+		firstErr error
+	)
+
+	for _, refresh := range refreshes {
+		if refresh.err != nil {
+			failed++
+
+			if firstErr == nil {
+				firstErr = refresh.err
+			}
+		}
+	}
+
+	total := uint(len(refreshes))
+
+	if failed == 0 || (failed < total && failed*100 <= b.cfg.MaxFailedSourcesPercent*total) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d of %d sources of group %s failed, the limit is %d%% (loading.maxFailedSourcesPercent): %w",
+		ErrTooManyFailedSources, failed, total, group, b.cfg.MaxFailedSourcesPercent, firstErr)
+}
+
+// reportSources logs the failed sources, updates their state and publishes the result of the refresh of each source.
+// If the group is rejected, the entries of all sources are kept.
+func (b *ListCache) reportSources(
+	group string, sources []config.BytesSource, refreshes []sourceRefresh, groupRejected bool,
+) {
+	now := util.Now()
+
+	for i, refresh := range refreshes {
+		if refresh.skipped {
+			continue
+		}
+
+		hasPreviousEntries := !refresh.previous.lastChanged.IsZero()
+		stale := hasPreviousEntries && (groupRejected || refresh.err != nil)
+
+		b.updateSourceState(sourceKey(group, i), func(state *sourceState) {
+			state.lastErr = refresh.err
+
+			switch {
+			case refresh.err != nil:
+				state.errorCount++
+			case !stale:
+				state.lastSuccess = now
+			}
+		})
+
+		if refresh.err != nil {
+			logger := logger().WithFields(logrus.Fields{
+				"group":  group,
+				"source": sources[i].String(),
+			})
+
+			if stale {
+				logger.Warnf("source failed, keeping stale entries: %s", refresh.err)
+			} else {
+				logger.Warnf("source failed, no entries of a previous refresh: %s", refresh.err)
+			}
+		}
+
+		evt.Bus().Publish(evt.BlockingListSourceRefreshed,
+			b.profile, b.listType, group, sources[i].String(), refresh.err, stale)
+	}
+}
+
+func (b *ListCache) setListIPs(key string, ips map[string][]net.IP) {
+	b.listIPsLock.Lock()
+	defer b.listIPsLock.Unlock()
+
+	if len(ips) == 0 {
+		delete(b.listIPs, key)
+	} else {
+		b.listIPs[key] = ips
+	}
+}
+
+func (b *ListCache) updateSourceState(key string, update func(state *sourceState)) {
+	b.statesLock.Lock()
+	defer b.statesLock.Unlock()
+
+	state := b.sourceStates[key]
+	update(&state)
+	b.sourceStates[key] = state
+}
+
+// sourceEntry is an entry of the source with the index in the sources of its group
+type sourceEntry struct {
+	source int
+	host   string
+	// ip of a hosts file entry, nil for other entries and sinkhole IPs
+	ip net.IP
+	// exception entries of a blacklist exempt domains from the blacklist entries of the group (ABP `@@` rules)
+	exception bool
+}
+
 // downloads file (or reads local file) and writes each line in the file to the result channel
-func (b *ListCache) parseFile(ctx context.Context, opener SourceOpener, resultCh chan<- string) error {
+func (b *ListCache) parseFile(
+	ctx context.Context, opener SourceOpener, format config.BytesSourceFormat, sourceIdx int, refresh *sourceRefresh,
+	resultCh chan<- sourceEntry,
+) error {
 	count := 0
 
 	logger := func() *logrus.Entry {
@@ -209,7 +866,15 @@ func (b *ListCache) parseFile(ctx context.Context, opener SourceOpener, resultCh
 
 	logger().Debug("starting processing of source")
 
-	r, err := opener.Open()
+	r, err := openSource(opener, refresh)
+	if errors.Is(err, ErrNotModified) {
+		refresh.notModified = true
+
+		logger().Info("source not modified, keeping existing entries")
+
+		return nil
+	}
+
 	if err != nil {
 		logger().Error("cannot open source: ", err)
 
@@ -217,26 +882,40 @@ func (b *ListCache) parseFile(ctx context.Context, opener SourceOpener, resultCh
 	}
 	defer r.Close()
 
-	p := parsers.AllowErrors(parsers.Hosts(r), b.cfg.MaxErrorsPerSource)
-	p.OnErr(func(err error) {
-		logger().Warnf("parse error: %s, trying to continue", err)
-	})
+	content, err := readIfChanged(r, refresh)
+	if err != nil {
+		logger().Error("cannot read source: ", err)
 
-	err = parsers.ForEach[*parsers.HostsIterator](ctx, p, func(hosts *parsers.HostsIterator) error {
-		return hosts.ForEach(func(host string) error {
-			count++
+		return err
+	}
 
-			// For IPs, we want to ensure the string is the Go representation so that when
-			// we compare responses, a same IP matches, even if it was written differently
-			// in the list.
-			if ip := net.ParseIP(host); ip != nil {
-				host = ip.String()
-			}
+	if refresh.unchanged {
+		logger().Info("source content unchanged, keeping existing entries")
+
+		return nil
+	}
 
-			resultCh <- host
+	br := bufio.NewReader(content)
 
-			return nil
-		})
+	if format == config.BytesSourceFormatHosts && parsers.IsABP(br) {
+		format = config.BytesSourceFormatAbp
+	}
+
+	err = b.forEachHost(ctx, br, format, logger, func(host string, ip net.IP, exception bool) error {
+		count++
+
+		// For IPs, we want to ensure the string is the Go representation so that when
+		// we compare responses, a same IP matches, even if it was written differently
+		// in the list.
+		if ip := net.ParseIP(host); ip != nil {
+			host = ip.String()
+		} else if _, ipNet, err := net.ParseCIDR(host); err == nil {
+			host = ipNet.String()
+		}
+
+		resultCh <- sourceEntry{source: sourceIdx, host: host, ip: ip, exception: exception}
+
+		return nil
 	})
 	if err != nil {
 		// Don't log cancelation: it was caused by another goroutine failing
@@ -258,3 +937,159 @@ func (b *ListCache) parseFile(ctx context.Context, opener SourceOpener, resultCh
 
 	return nil
 }
+
+// hostCallback is called for each entry of a source, exception entries are only passed by blacklists.
+// ip is the IP of a hosts file entry, nil for other entries and sinkhole IPs
+type hostCallback func(host string, ip net.IP, exception bool) error
+
+// forEachHost parses the entries of a source with the given format
+func (b *ListCache) forEachHost(
+	ctx context.Context, r io.Reader, format config.BytesSourceFormat, logger func() *logrus.Entry,
+	callback hostCallback,
+) error {
+	switch format {
+	case config.BytesSourceFormatHosts:
+		return b.forEachHostsListHost(ctx, r, logger, callback)
+	case config.BytesSourceFormatRpz:
+		return b.forEachRPZHost(ctx, r, logger, callback)
+	case config.BytesSourceFormatAbp:
+		return b.forEachABPHost(ctx, r, logger, callback)
+	}
+
+	return fmt.Errorf("unsupported list format: %s", format)
+}
+
+// forEachHostsListHost parses a hosts file or host list
+func (b *ListCache) forEachHostsListHost(
+	ctx context.Context, r io.Reader, logger func() *logrus.Entry, callback hostCallback,
+) error {
+	p := parsers.AllowErrors(parsers.Hosts(r), b.cfg.MaxErrorsPerSource)
+	p.OnErr(func(err error) {
+		logger().Warnf("parse error: %s, trying to continue", err)
+	})
+
+	return parsers.ForEach[*parsers.HostsIterator](ctx, p, func(hosts *parsers.HostsIterator) error {
+		ip := hosts.IP()
+		if ip != nil && isSinkholeIP(ip) {
+			ip = nil
+		}
+
+		return hosts.ForEach(func(host string) error {
+			return callback(host, ip, false)
+		})
+	})
+}
+
+// forEachRPZHost parses a RPZ zone: a whitelist only uses the passthru entries, a blacklist the blocking ones
+func (b *ListCache) forEachRPZHost(
+	ctx context.Context, r io.Reader, logger func() *logrus.Entry, callback hostCallback,
+) error {
+	rpz := parsers.RPZ(r)
+
+	p := parsers.AllowErrors[*parsers.RPZEntry](rpz, b.cfg.MaxErrorsPerSource)
+	p.OnErr(func(err error) {
+		logger().Warnf("parse error: %s, trying to continue", err)
+	})
+
+	defer func() {
+		if ignored := rpz.Ignored(); ignored > 0 {
+			logger().Warnf("ignored %d RPZ records with unsupported triggers or policy actions", ignored)
+		}
+	}()
+
+	isWhitelist := b.listType == ListCacheTypeWhitelist
+
+	return parsers.ForEach[*parsers.RPZEntry](ctx, p, func(entry *parsers.RPZEntry) error {
+		if entry.Action.IsBlock() == isWhitelist {
+			return nil
+		}
+
+		return callback(entry.Name, nil, false)
+	})
+}
+
+// forEachABPHost parses an Adblock Plus filter list: a whitelist only uses the exception rules,
+// a blacklist passes them as exceptions for its group
+func (b *ListCache) forEachABPHost(
+	ctx context.Context, r io.Reader, logger func() *logrus.Entry, callback hostCallback,
+) error {
+	abp := parsers.ABP(r)
+
+	p := parsers.AllowErrors[*parsers.ABPEntry](abp, b.cfg.MaxErrorsPerSource)
+	p.OnErr(func(err error) {
+		logger().Warnf("parse error: %s, trying to continue", err)
+	})
+
+	defer func() {
+		if ignored := abp.Ignored(); ignored > 0 {
+			logger().Warnf("skipped %d ABP rules which can't be applied to DNS queries", ignored)
+		}
+	}()
+
+	isWhitelist := b.listType == ListCacheTypeWhitelist
+
+	return parsers.ForEach[*parsers.ABPEntry](ctx, p, func(entry *parsers.ABPEntry) error {
+		if isWhitelist && !entry.Exception {
+			return nil
+		}
+
+		return entry.ForEach(func(host string) error {
+			return callback(host, nil, entry.Exception && !isWhitelist)
+		})
+	})
+}
+
+// openSource opens the source, sources supporting it are only opened if they were modified since the last refresh
+func openSource(opener SourceOpener, refresh *sourceRefresh) (io.ReadCloser, error) {
+	conditional, ok := opener.(ConditionalOpener)
+	if !ok {
+		return opener.Open()
+	}
+
+	r, validators, err := conditional.OpenIfModified(refresh.previous.validators)
+	refresh.validators = validators
+
+	return r, err
+}
+
+// readIfChanged hashes the content of the source. If the source was loaded before, the content is read completely
+// to compare the hash, so unchanged sources aren't parsed and don't allocate cache entries. Otherwise the content is
+// hashed while it is parsed.
+func readIfChanged(r io.Reader, refresh *sourceRefresh) (io.Reader, error) {
+	hasher := sha256.New()
+	content := io.TeeReader(r, hasher)
+
+	if refresh.previous.contentHash == nil {
+		return &hashingReader{Reader: content, hash: hasher, refresh: refresh}, nil
+	}
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh.contentHash = hasher.Sum(nil)
+	refresh.unchanged = bytes.Equal(refresh.contentHash, refresh.previous.contentHash)
+
+	return bytes.NewReader(data), nil
+}
+
+// hashingReader stores the hash of the content in the refresh, once it was read completely
+type hashingReader struct {
+	io.Reader
+	hash    hash.Hash
+	refresh *sourceRefresh
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.refresh.contentHash = r.hash.Sum(nil)
+	}
+
+	return n, err
+}
+
+func isRegex(host string) bool {
+	return len(host) > 2 && strings.HasPrefix(host, "/") && strings.HasSuffix(host, "/")
+}
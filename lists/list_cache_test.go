@@ -6,10 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/0xERR0R/blocky/cache/stringcache"
 	"github.com/0xERR0R/blocky/config"
 	. "github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/lists/parsers"
@@ -34,12 +38,20 @@ var _ = Describe("ListCache", func() {
 
 		listCacheType  ListCacheType
 		lists          map[string][]config.BytesSource
+		refreshPeriods map[string]config.Duration
 		downloader     FileDownloader
 		mockDownloader *MockDownloader
+		ctx            context.Context
 	)
 
 	BeforeEach(func() {
-		var err error
+		var (
+			err    error
+			cancel context.CancelFunc
+		)
+
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
 
 		listCacheType = ListCacheTypeBlacklist
 
@@ -50,6 +62,7 @@ var _ = Describe("ListCache", func() {
 
 		downloader = NewDownloader(config.DownloaderConfig{}, nil)
 		mockDownloader = nil
+		refreshPeriods = nil
 
 		server1 = TestServer("blocked1.com\nblocked1a.com\n192.168.178.55")
 		DeferCleanup(server1.Close)
@@ -84,7 +97,7 @@ var _ = Describe("ListCache", func() {
 			downloader = mockDownloader
 		}
 
-		sut, err = NewListCache(listCacheType, sutConfig, lists, downloader)
+		sut, err = NewListCache(ctx, listCacheType, sutConfig, lists, downloader, refreshPeriods, "")
 		Expect(err).Should(Succeed())
 	})
 
@@ -216,6 +229,82 @@ var _ = Describe("ListCache", func() {
 				})
 			})
 		})
+		When("a source of a group fails", func() {
+			var refreshed []string
+
+			BeforeEach(func() {
+				mockDownloader = newMockDownloader(func(res chan<- string, err chan<- error) {
+					res <- "blocked1.com"
+					err <- errors.New("boom")
+				})
+
+				lists = map[string][]config.BytesSource{
+					"gr1": {
+						mockDownloader.ListSource(),
+						config.TextBytesSource("blocked2.com"),
+						config.TextBytesSource("blocked3.com"),
+					},
+				}
+
+				refreshed = nil
+
+				fn := func(_ string, _ ListCacheType, _, source string, err error, stale bool) {
+					refreshed = append(refreshed, fmt.Sprintf("%s: %v, stale=%t", source, err, stale))
+				}
+				Expect(Bus().Subscribe(BlockingListSourceRefreshed, fn)).Should(Succeed())
+				DeferCleanup(func() {
+					Expect(Bus().Unsubscribe(BlockingListSourceRefreshed, fn)).Should(Succeed())
+				})
+			})
+
+			It("should keep the stale entries of the failed source", func() {
+				Expect(sut.Refresh()).Should(Succeed())
+
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(refreshed).Should(ContainElement("http://mock-downloader: boom, stale=true"))
+			})
+
+			It("should keep the statistics of the sources", func() {
+				before := sut.Groups()[0].Sources[0]
+
+				Expect(sut.Refresh()).Should(Succeed())
+
+				groups := sut.Groups()
+				Expect(groups).Should(HaveLen(1))
+				Expect(groups[0].Group).Should(Equal("gr1"))
+				Expect(groups[0].Entries).Should(Equal(3))
+
+				failed := groups[0].Sources[0]
+				Expect(failed.Entries).Should(Equal(1))
+				Expect(failed.ErrorCount).Should(BeNumerically("==", 1))
+				Expect(failed.LastErr).Should(MatchError("boom"))
+				Expect(failed.LastSuccess).Should(Equal(before.LastSuccess))
+
+				Expect(groups[0].Sources[1]).Should(SatisfyAll(
+					HaveField("Entries", 1),
+					HaveField("ErrorCount", BeZero()),
+					HaveField("LastErr", BeNil()),
+					HaveField("LastSuccess", BeTemporally(">", before.LastSuccess)),
+				))
+			})
+
+			When("more sources failed than allowed", func() {
+				BeforeEach(func() {
+					sutConfig.MaxFailedSourcesPercent = 30
+				})
+
+				It("should fail the refresh and keep the entries of all sources", func() {
+					Expect(sut.Refresh()).Should(MatchError(ErrTooManyFailedSources))
+
+					Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+					Expect(refreshed).Should(ContainElements(
+						"http://mock-downloader: boom, stale=true",
+						ContainSubstring("blocked2.com: <nil>, stale=true"),
+					))
+				})
+			})
+		})
 		When("Configuration has 3 external working urls", func() {
 			BeforeEach(func() {
 				lists = map[string][]config.BytesSource{
@@ -262,7 +351,7 @@ var _ = Describe("ListCache", func() {
 					"gr1": config.NewBytesSources(server1.URL),
 				}
 
-				_ = Bus().SubscribeOnce(BlockingCacheGroupChanged, func(listType ListCacheType, group string, cnt int) {
+				_ = Bus().SubscribeOnce(BlockingCacheGroupChanged, func(_ string, listType ListCacheType, group string, cnt int) {
 					resultCnt = cnt
 				})
 			})
@@ -282,8 +371,8 @@ var _ = Describe("ListCache", func() {
 			})
 
 			It("should match", func() {
-				Expect(sut.groupedCache.ElementCount("gr1")).Should(Equal(3))
-				Expect(sut.groupedCache.ElementCount("gr2")).Should(Equal(2))
+				Expect(sut.elementCount("gr1")).Should(Equal(3))
+				Expect(sut.elementCount("gr2")).Should(Equal(2))
 
 				group := sut.Match("blocked1.com", []string{"gr1", "gr2"})
 				Expect(group).Should(ContainElement("gr1"))
@@ -295,6 +384,120 @@ var _ = Describe("ListCache", func() {
 				Expect(group).Should(ContainElement("gr2"))
 			})
 		})
+		When("a glob pattern is used", func() {
+			var listsDir *TmpFolder
+
+			BeforeEach(func() {
+				listsDir = tmpDir.CreateSubFolder("lists")
+				Expect(listsDir.Error).Should(Succeed())
+
+				Expect(listsDir.CreateStringFile("a.txt", "blocked1.com", "blocked1a.com").Error).Should(Succeed())
+				Expect(listsDir.CreateStringFile("b.txt", "blocked2.com").Error).Should(Succeed())
+				Expect(listsDir.CreateStringFile("ignored.conf", "ignored.com").Error).Should(Succeed())
+				Expect(listsDir.CreateSubFolder("dir.txt").Error).Should(Succeed())
+
+				lists = map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources(listsDir.JoinPath("*.txt")),
+				}
+			})
+
+			It("should read all matching files", func() {
+				Expect(sut.elementCount("gr1")).Should(Equal(3))
+				Expect(sut.Match("blocked1a.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("ignored.com", []string{"gr1"})).Should(BeEmpty())
+			})
+
+			It("should pick up new files on refresh", func() {
+				Expect(listsDir.CreateStringFile("c.txt", "blocked3.com").Error).Should(Succeed())
+
+				Expect(sut.Refresh()).Should(Succeed())
+
+				Expect(sut.elementCount("gr1")).Should(Equal(4))
+				Expect(sut.Match("blocked3.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			})
+
+			It("should fail if no file matches", func() {
+				lists := map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources(listsDir.JoinPath("*.missing")),
+				}
+
+				sut, err := NewListCache(ctx, ListCacheTypeBlacklist, sutConfig, lists, downloader, nil, "")
+				Expect(err).Should(Succeed())
+
+				Expect(sut.Groups()[0].Sources[0].LastErr).Should(MatchError(ContainSubstring("no file matches")))
+			})
+		})
+		When("files are watched", func() {
+			BeforeEach(func() {
+				sutConfig.WatchFiles = true
+
+				lists = map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources(file1.Path, server2.URL),
+				}
+			})
+
+			It("should refresh a changed file", func() {
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+
+				Expect(os.WriteFile(file1.Path, []byte("changed.com"), 0o600)).Should(Succeed())
+
+				Eventually(func() []string {
+					return sut.Match("changed.com", []string{"gr1"})
+				}, "3s").Should(ConsistOf("gr1"))
+
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			})
+		})
+
+		When("an executable is used", func() {
+			script := func(name string, lines ...string) string {
+				file := tmpDir.CreateStringFile(name, append([]string{"#!/bin/sh"}, lines...)...)
+				Expect(file.Error).Should(Succeed())
+				Expect(os.Chmod(file.Path, 0o700)).Should(Succeed())
+
+				return file.Path
+			}
+
+			BeforeEach(func() {
+				lists = map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources("exec://" + script("gen-list", "echo blocked1.com", "echo blocked2.com")),
+				}
+			})
+
+			It("should read its output", func() {
+				Expect(sut.elementCount("gr1")).Should(Equal(2))
+				Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			})
+
+			It("should fail on a non-zero exit code", func() {
+				lists := map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources("exec://" + script("failing", "echo blocked1.com", "echo oops >&2", "exit 3")),
+				}
+
+				sut, err := NewListCache(ctx, ListCacheTypeBlacklist, sutConfig, lists, downloader, nil, "")
+				Expect(err).Should(Succeed())
+
+				Expect(sut.elementCount("gr1")).Should(BeZero())
+				Expect(sut.Groups()[0].Sources[0].LastErr).Should(MatchError(SatisfyAll(
+					ContainSubstring("exit status 3"),
+					ContainSubstring("oops"),
+				)))
+			})
+
+			It("should fail if it times out", func() {
+				source := config.NewBytesSources("exec://" + script("slow", "sleep 5"))[0]
+				source.Timeout = config.Duration(50 * time.Millisecond)
+
+				lists := map[string][]config.BytesSource{"gr1": {source}}
+
+				sut, err := NewListCache(ctx, ListCacheTypeBlacklist, sutConfig, lists, downloader, nil, "")
+				Expect(err).Should(Succeed())
+
+				Expect(sut.Groups()[0].Sources[0].LastErr).Should(MatchError(ContainSubstring("didn't finish within")))
+			})
+		})
 		When("group with bigger files", func() {
 			It("should match", func() {
 				file1, lines1 := createTestListFile(GinkgoT().TempDir(), 10000)
@@ -304,10 +507,10 @@ var _ = Describe("ListCache", func() {
 					"gr1": config.NewBytesSources(file1, file2, file3),
 				}
 
-				sut, err := NewListCache(ListCacheTypeBlacklist, sutConfig, lists, downloader)
+				sut, err := NewListCache(ctx, ListCacheTypeBlacklist, sutConfig, lists, downloader, nil, "")
 				Expect(err).Should(Succeed())
 
-				Expect(sut.groupedCache.ElementCount("gr1")).Should(Equal(lines1 + lines2 + lines3))
+				Expect(sut.elementCount("gr1")).Should(Equal(lines1 + lines2 + lines3))
 			})
 		})
 		When("inline list content is defined", func() {
@@ -322,7 +525,7 @@ var _ = Describe("ListCache", func() {
 			})
 
 			It("should match", func() {
-				Expect(sut.groupedCache.ElementCount("gr1")).Should(Equal(2))
+				Expect(sut.elementCount("gr1")).Should(Equal(2))
 				group := sut.Match("inlinedomain1.com", []string{"gr1"})
 				Expect(group).Should(ContainElement("gr1"))
 
@@ -359,7 +562,7 @@ var _ = Describe("ListCache", func() {
 					},
 				}
 
-				_, err := NewListCache(ListCacheTypeBlacklist, sutConfig, lists, downloader)
+				_, err := NewListCache(ctx, ListCacheTypeBlacklist, sutConfig, lists, downloader, nil, "")
 				Expect(err).ShouldNot(Succeed())
 				Expect(err).Should(MatchError(parsers.ErrTooManyErrors))
 			})
@@ -391,7 +594,470 @@ var _ = Describe("ListCache", func() {
 				Expect(group).Should(ContainElement("gr1"))
 			})
 		})
+		When("regex and plain entries are defined", func() {
+			BeforeEach(func() {
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("/^ads?[0-9]*\\./", "/invalid regex (/", "tracker.com")},
+					"gr2": {config.TextBytesSource("/^ad[0-9]+\\.example\\./")},
+				}
+			})
+
+			It("should match both and skip invalid regexes", func() {
+				Expect(sut.Match("ad123.example.org", []string{"gr1", "gr2"})).Should(ConsistOf("gr1", "gr2"))
+				Expect(sut.Match("tracker.com", []string{"gr1", "gr2"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("example.org", []string{"gr1", "gr2"})).Should(BeEmpty())
+
+				Expect(sut.elementCount("gr1")).Should(Equal(2))
+			})
+		})
+		When("wildcard entries are defined", func() {
+			BeforeEach(func() {
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("*.ads.example.com", "*.Tracker.org")},
+				}
+			})
+
+			It("should match subdomains", func() {
+				Expect(sut.Match("www.ads.example.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("a.b.tracker.org", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("ads.example.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Match("example.com", []string{"gr1"})).Should(BeEmpty())
+
+				Expect(sut.elementCount("gr1")).Should(Equal(2))
+			})
+		})
+		When("CIDR entries are defined", func() {
+			BeforeEach(func() {
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("10.1.2.3/16", "2001:DB8::/32", "192.168.178.1")},
+				}
+			})
+
+			It("should match the IPs in the networks", func() {
+				Expect(sut.Match("10.1.200.1", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("2001:db8::5", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("192.168.178.1", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("10.2.0.1", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Match("10.1.0.0/16", []string{"gr1"})).Should(BeEmpty())
+
+				Expect(sut.elementCount("gr1")).Should(Equal(3))
+			})
+		})
+		When("ABP sources are defined", func() {
+			BeforeEach(func() {
+				abp := config.TextBytesSource(
+					"! explicit format",
+					"||ads.com^",
+					"@@||good.ads.com^",
+					"tracker.com##.banner",
+				)
+				abp.Format = config.BytesSourceFormatAbp
+
+				lists = map[string][]config.BytesSource{
+					"gr1": {
+						abp,
+						config.TextBytesSource("blocked.com", "allowed.tracker.com"),
+						config.TextBytesSource(
+							"[Adblock Plus 2.0]",
+							"||tracker.com^",
+							"@@||allowed.tracker.com^",
+						),
+					},
+					"gr2": {config.TextBytesSource("good.ads.com")},
+				}
+			})
+
+			It("should block the domains and their subdomains", func() {
+				Expect(sut.Match("ads.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("www.ads.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("tracker.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("blocked.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			})
+
+			It("should apply exceptions within a source", func() {
+				Expect(sut.Match("good.ads.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Match("www.good.ads.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Explain("good.ads.com", []string{"gr1"})).Should(BeEmpty())
+			})
+
+			It("should apply exceptions across the sources of the group", func() {
+				Expect(sut.Match("allowed.tracker.com", []string{"gr1"})).Should(BeEmpty())
+			})
+
+			It("should not apply exceptions to other groups", func() {
+				Expect(sut.Match("good.ads.com", []string{"gr1", "gr2"})).Should(ConsistOf("gr2"))
+			})
+
+			It("should look up the entries and exceptions of all groups", func() {
+				source := lists["gr1"][0].String()
+
+				Expect(sut.Lookup("good.ads.com")).Should(Equal([]LookupEntry{
+					{
+						MatchedEntry: MatchedEntry{Group: "gr1", Source: source, Entry: "ads.com"},
+						Type:         stringcache.MatchTypeParent,
+					},
+					{
+						MatchedEntry: MatchedEntry{Group: "gr1", Source: source, Entry: "*.ads.com"},
+						Type:         stringcache.MatchTypeWildcard,
+					},
+					{
+						MatchedEntry: MatchedEntry{Group: "gr1", Source: source, Entry: "good.ads.com"},
+						Type:         stringcache.MatchTypeExact,
+						Exception:    true,
+					},
+					{
+						MatchedEntry: MatchedEntry{Group: "gr2", Source: "good.ads.com", Entry: "good.ads.com"},
+						Type:         stringcache.MatchTypeExact,
+					},
+				}))
+				Expect(sut.Lookup("example.com")).Should(BeEmpty())
+			})
+
+			When("it's a whitelist", func() {
+				BeforeEach(func() {
+					listCacheType = ListCacheTypeWhitelist
+				})
+
+				It("should match the exception rules", func() {
+					Expect(sut.Match("good.ads.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+					Expect(sut.Match("allowed.tracker.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+					Expect(sut.Match("ads.com", []string{"gr1"})).Should(BeEmpty())
+					Expect(sut.Match("tracker.com", []string{"gr1"})).Should(BeEmpty())
+				})
+			})
+		})
+		When("a RPZ source is defined", func() {
+			BeforeEach(func() {
+				rpz := config.TextBytesSource(
+					"$TTL 300",
+					"@ SOA localhost. root.localhost. 1 43200 3600 86400 120",
+					"blocked.com CNAME .",
+					"*.ads.com CNAME *.",
+					"allowed.com CNAME rpz-passthru.",
+					"local.com A 192.0.2.1",
+				)
+				rpz.Format = config.BytesSourceFormatRpz
+
+				lists = map[string][]config.BytesSource{
+					"gr1": {rpz},
+				}
+			})
+
+			It("should match the blocking entries", func() {
+				Expect(sut.Match("blocked.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("www.ads.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("allowed.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Match("local.com", []string{"gr1"})).Should(BeEmpty())
+
+				Expect(sut.elementCount("gr1")).Should(Equal(2))
+			})
+
+			When("it's a whitelist", func() {
+				BeforeEach(func() {
+					listCacheType = ListCacheTypeWhitelist
+				})
+
+				It("should match the passthru entries", func() {
+					Expect(sut.Match("allowed.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+					Expect(sut.Match("blocked.com", []string{"gr1"})).Should(BeEmpty())
+
+					Expect(sut.elementCount("gr1")).Should(Equal(1))
+				})
+			})
+		})
+		When("a source uses the IPs of the list", func() {
+			BeforeEach(func() {
+				redirect := config.TextBytesSource(
+					"146.112.61.106 phishing.com www.phishing.com",
+					"146.112.61.107 phishing.com",
+					"2001:db8::1 Phishing.com",
+					"0.0.0.0 ads.com",
+					"127.0.0.1 tracker.com",
+					"malware.com",
+				)
+				redirect.UseListIPs = true
+
+				lists = map[string][]config.BytesSource{
+					"gr1": {redirect},
+					"gr2": {config.TextBytesSource("192.0.2.1 other.com")},
+				}
+			})
+
+			It("should match all entries", func() {
+				for _, domain := range []string{"phishing.com", "www.phishing.com", "ads.com", "tracker.com", "malware.com"} {
+					Expect(sut.Match(domain, []string{"gr1"})).Should(ConsistOf("gr1"))
+				}
+			})
+
+			It("should return all IPs of the domain", func() {
+				Expect(sut.ListIPs("phishing.com", []string{"gr1"})).Should(Equal([]net.IP{
+					net.ParseIP("146.112.61.106"), net.ParseIP("146.112.61.107"), net.ParseIP("2001:db8::1"),
+				}))
+				Expect(sut.ListIPs("WWW.phishing.com", []string{"gr1"})).Should(Equal([]net.IP{
+					net.ParseIP("146.112.61.106"),
+				}))
+			})
+
+			It("should not return sinkhole IPs", func() {
+				Expect(sut.ListIPs("ads.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.ListIPs("tracker.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.ListIPs("malware.com", []string{"gr1"})).Should(BeEmpty())
+			})
+
+			It("should only return IPs of sources using them", func() {
+				Expect(sut.Match("other.com", []string{"gr2"})).Should(ConsistOf("gr2"))
+				Expect(sut.ListIPs("other.com", []string{"gr2"})).Should(BeEmpty())
+				Expect(sut.ListIPs("phishing.com", []string{"gr2"})).Should(BeEmpty())
+			})
+		})
+		When("a domain is explained", func() {
+			BeforeEach(func() {
+				lists = map[string][]config.BytesSource{
+					"gr1": {
+						config.TextBytesSource("blocked.com", "*.ads.com"),
+						config.TextBytesSource("/^tracker\\./", "blocked.com"),
+					},
+					"gr2": config.NewBytesSources(file1.Path),
+				}
+			})
+
+			It("should return the matching entries with their source", func() {
+				Expect(sut.Explain("blocked.com", []string{"gr1", "gr2"})).Should(Equal([]MatchedEntry{
+					{Group: "gr1", Source: "blocked.com", Entry: "blocked.com"},
+					{Group: "gr1", Source: "/^tracker\\./", Entry: "blocked.com"},
+				}))
+				Expect(sut.Explain("www.ads.com", []string{"gr1"})).Should(Equal([]MatchedEntry{
+					{Group: "gr1", Source: "blocked.com", Entry: "*.ads.com"},
+				}))
+				Expect(sut.Explain("tracker.com", []string{"gr1"})).Should(Equal([]MatchedEntry{
+					{Group: "gr1", Source: "/^tracker\\./", Entry: "/^tracker\\./"},
+				}))
+				Expect(sut.Explain("blocked1.com", []string{"gr1", "gr2"})).Should(Equal([]MatchedEntry{
+					{Group: "gr2", Source: "file://" + file1.Path, Entry: "blocked1.com"},
+				}))
+				Expect(sut.Explain("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+			})
+		})
+		When("a source wasn't modified since the last refresh", func() {
+			var requests []string
+
+			BeforeEach(func() {
+				requests = nil
+
+				server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					requests = append(requests, req.Header.Get("If-None-Match"))
+
+					if req.Header.Get("If-None-Match") == `"v1"` {
+						rw.WriteHeader(http.StatusNotModified)
+
+						return
+					}
+
+					rw.Header().Set("ETag", `"v1"`)
+					_, _ = rw.Write([]byte("blocked1.com\n/^ads\\./"))
+				}))
+				DeferCleanup(server.Close)
+
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("blocked2.com"), config.NewBytesSources(server.URL)[0]},
+				}
+			})
+
+			It("should keep the existing entries", func() {
+				sources := sut.Sources()
+				Expect(sources).Should(HaveLen(2))
+				Expect(sources[1].LastChanged).ShouldNot(BeZero())
+
+				Expect(sut.Refresh()).Should(Succeed())
+
+				Expect(requests).Should(Equal([]string{"", `"v1"`}))
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("ads.example.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Sources()[1]).Should(SatisfyAll(
+					HaveField("LastChanged", Equal(sources[1].LastChanged)),
+					HaveField("Entries", 2),
+					HaveField("LastSuccess", BeTemporally(">", sources[1].LastSuccess)),
+				))
+				// the content of the text source didn't change either
+				Expect(sut.Sources()[0].LastChanged).Should(Equal(sources[0].LastChanged))
+			})
+		})
+		When("the content of a source didn't change since the last refresh", func() {
+			var (
+				file    *TmpFile
+				changed []string
+			)
+
+			BeforeEach(func() {
+				file = tmpDir.CreateStringFile("unchanged", "blocked1.com", "/^ads\\./")
+
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("blocked2.com"), config.NewBytesSources(file.Path)[0]},
+				}
+
+				fn := func(_ string, _ ListCacheType, _, source string, _ time.Time, _ int) {
+					changed = append(changed, source)
+				}
+				Expect(Bus().Subscribe(BlockingListSourceChanged, fn)).Should(Succeed())
+				DeferCleanup(func() {
+					Expect(Bus().Unsubscribe(BlockingListSourceChanged, fn)).Should(Succeed())
+				})
+			})
+
+			JustBeforeEach(func() {
+				changed = nil
+			})
+
+			It("should keep the existing entries", func() {
+				sources := sut.Sources()
+
+				Expect(sut.Refresh()).Should(Succeed())
+
+				Expect(changed).Should(BeEmpty())
+				Expect(sut.Sources()[0].LastChanged).Should(Equal(sources[0].LastChanged))
+				Expect(sut.Sources()[1]).Should(SatisfyAll(
+					HaveField("LastChanged", Equal(sources[1].LastChanged)),
+					HaveField("Entries", 2),
+				))
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("ads.example.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			})
+
+			It("should only reload the changed source", func() {
+				sources := sut.Sources()
+
+				Expect(os.WriteFile(file.Path, []byte("blocked3.com\n"), 0o600)).Should(Succeed())
+
+				Expect(sut.Refresh()).Should(Succeed())
+
+				Expect(changed).Should(Equal([]string{sources[1].Source}))
+				Expect(sut.Sources()[0].LastChanged).Should(Equal(sources[0].LastChanged))
+				Expect(sut.Sources()[1]).Should(SatisfyAll(
+					HaveField("LastChanged", BeTemporally(">", sources[1].LastChanged)),
+					HaveField("Entries", 1),
+				))
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Match("blocked3.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+				Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			})
+		})
+		When("a group has more regexes than allowed", func() {
+			BeforeEach(func() {
+				sutConfig.MaxRegexesPerGroup = 1
+
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("/^ads\\./", "/^tracker\\./", "blocked.com")},
+					"gr2": {config.TextBytesSource("/^ads\\./")},
+				}
+			})
+
+			It("should reject the group with an error", func() {
+				Expect(sut.Refresh()).Should(MatchError(ErrTooManyRegexes))
+
+				Expect(sut.elementCount("gr1")).Should(BeZero())
+				Expect(sut.Match("ads.example.com", []string{"gr2"})).Should(ConsistOf("gr2"))
+			})
+
+			It("should not limit the regexes if disabled", func() {
+				sutConfig.MaxRegexesPerGroup = 0
+
+				sut, err := NewListCache(ctx, listCacheType, sutConfig, lists, downloader, nil, "")
+				Expect(err).Should(Succeed())
+
+				Expect(sut.Match("tracker.example.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			})
+		})
 	})
+	Describe("Refresh periods", func() {
+		var sourcePeriod config.Duration
+
+		BeforeEach(func() {
+			sourcePeriod = config.Duration(time.Hour)
+
+			slowSource := config.NewBytesSources(file2.Path)[0]
+			slowSource.RefreshPeriod = &sourcePeriod
+
+			lists = map[string][]config.BytesSource{
+				"gr1": {config.NewBytesSources(file1.Path)[0], slowSource},
+				"gr2": config.NewBytesSources(file3.Path),
+			}
+			refreshPeriods = map[string]config.Duration{"gr1": config.Duration(10 * time.Minute)}
+		})
+
+		refreshWithPeriod := func(period config.Duration) func(context.Context) error {
+			for _, refresh := range sut.periodicRefreshes() {
+				if refresh.Period == period {
+					return refresh.Refresh
+				}
+			}
+
+			Fail(fmt.Sprintf("no refresh with period %s", period))
+
+			return nil
+		}
+
+		It("should have a refresh for each group and period", func() {
+			Expect(sut.periodicRefreshes()).Should(ConsistOf(
+				HaveField("Period", config.Duration(10*time.Minute)),
+				HaveField("Period", sourcePeriod),
+				HaveField("Period", config.Duration(-1)),
+			))
+		})
+
+		It("should only refresh the sources with the period", func() {
+			before := sut.Groups()
+
+			Expect(os.WriteFile(file1.Path, []byte("changed1.com"), 0o600)).Should(Succeed())
+			Expect(os.WriteFile(file2.Path, []byte("changed2.com"), 0o600)).Should(Succeed())
+
+			Expect(refreshWithPeriod(sourcePeriod)(context.Background())).Should(Succeed())
+
+			Expect(sut.Match("changed2.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			Expect(sut.Match("blocked2.com", []string{"gr1"})).Should(BeEmpty())
+
+			// the other source keeps its entries and state
+			Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+			Expect(sut.Match("changed1.com", []string{"gr1"})).Should(BeEmpty())
+			Expect(sut.Groups()[0].Sources[0]).Should(Equal(before[0].Sources[0]))
+
+			Expect(refreshWithPeriod(config.Duration(10 * time.Minute))(context.Background())).Should(Succeed())
+
+			Expect(sut.Match("changed1.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+		})
+
+		It("should serialize the refreshes of a group", func() {
+			sut.groupLocks["gr1"].Lock()
+
+			done := make(chan struct{})
+
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+
+				Expect(sut.Refresh()).Should(Succeed())
+			}()
+
+			Consistently(done, "50ms").ShouldNot(BeClosed())
+
+			sut.groupLocks["gr1"].Unlock()
+
+			Eventually(done, "1s").Should(BeClosed())
+		})
+
+		It("should log the refresh period of each group", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("gr1: 3 entries, refresh = every 10 minutes (sources with own period)"),
+				ContainSubstring("gr2: 2 entries, refresh = disabled"),
+			))
+		})
+	})
+
 	Describe("LogConfig", func() {
 		var (
 			logger *logrus.Entry
@@ -408,7 +1074,7 @@ var _ = Describe("ListCache", func() {
 				"gr2": {config.TextBytesSource("inline", "definition")},
 			}
 
-			sut, err := NewListCache(ListCacheTypeBlacklist, sutConfig, lists, downloader)
+			sut, err := NewListCache(ctx, ListCacheTypeBlacklist, sutConfig, lists, downloader, nil, "")
 			Expect(err).Should(Succeed())
 
 			sut.LogConfig(logger)
@@ -430,7 +1096,7 @@ var _ = Describe("ListCache", func() {
 					"gr1": config.NewBytesSources("doesnotexist"),
 				}
 
-				_, err := NewListCache(ListCacheTypeBlacklist, sutConfig, lists, downloader)
+				_, err := NewListCache(ctx, ListCacheTypeBlacklist, sutConfig, lists, downloader, nil, "")
 				Expect(err).Should(Succeed())
 			})
 		})
@@ -0,0 +1,170 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/0xERR0R/blocky/trie"
+	"golang.org/x/net/idna"
+)
+
+const (
+	abpHeader          = "[Adblock"
+	utf8BOM            = "\xEF\xBB\xBF"
+	abpCommentPrefix   = "!"
+	abpHeaderPrefix    = "["
+	abpExceptionPrefix = "@@"
+	abpDomainPrefix    = "||"
+	abpSeparator       = "^"
+	abpEndAnchor       = "|"
+	abpOptionsPrefix   = "$"
+
+	// abpImportantOption only raises the priority of a rule, which doesn't matter for DNS
+	abpImportantOption = "important"
+)
+
+// ABP parses `r` as an Adblock Plus filter list.
+//
+// Only domain rules (`||example.com^`) and their exceptions (`@@||example.com^`) are returned.
+// Other rules like element hiding, paths or rules with options can't be applied to DNS queries,
+// they are skipped and counted by `Ignored`.
+func ABP(r io.Reader) *ABPParser {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanLines)
+
+	return &ABPParser{scanner: scanner}
+}
+
+// IsABP returns true if the content of `r` starts with an Adblock Plus header, e.g. `[Adblock Plus 2.0]`.
+// The content is only peeked and remains available.
+func IsABP(r *bufio.Reader) bool {
+	data, _ := r.Peek(len(abpHeader) + len(utf8BOM))
+
+	data = bytes.TrimPrefix(data, []byte(utf8BOM))
+
+	return bytes.HasPrefix(data, []byte(abpHeader))
+}
+
+// ABPEntry is a domain rule of an Adblock Plus filter list.
+type ABPEntry struct {
+	Domain string
+	// Exception is set for exception rules (`@@`), which allow the domain
+	Exception bool
+}
+
+// ForEach calls `callback` with the domain and a wildcard for its subdomains, which are both matched by the rule
+func (e *ABPEntry) ForEach(callback func(string) error) error {
+	if err := callback(e.Domain); err != nil {
+		return err
+	}
+
+	return callback(trie.WildcardPrefix + e.Domain)
+}
+
+// ABPParser is the `SeriesParser` returned by `ABP`.
+type ABPParser struct {
+	scanner *bufio.Scanner
+	lineNo  uint
+	ignored uint
+}
+
+// Ignored returns the number of rules skipped so far, because they can't be applied to DNS queries
+func (p *ABPParser) Ignored() uint {
+	return p.ignored
+}
+
+func (p *ABPParser) Position() string {
+	return fmt.Sprintf("line %d", p.lineNo)
+}
+
+func (p *ABPParser) Next(ctx context.Context) (*ABPEntry, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, NewNonResumableError(err)
+		}
+
+		if !p.scanner.Scan() {
+			break
+		}
+
+		p.lineNo++
+
+		line := strings.TrimSpace(p.scanner.Text())
+
+		if len(line) == 0 || strings.HasPrefix(line, abpCommentPrefix) || strings.HasPrefix(line, abpHeaderPrefix) {
+			continue
+		}
+
+		entry, ok, err := p.parseRule(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return entry, nil
+		}
+
+		p.ignored++
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return nil, NewNonResumableError(err)
+	}
+
+	return nil, NewNonResumableError(io.EOF)
+}
+
+// parseRule returns false for rules, which can't be applied to DNS queries
+func (p *ABPParser) parseRule(rule string) (*ABPEntry, bool, error) {
+	entry := ABPEntry{}
+
+	if strings.HasPrefix(rule, abpExceptionPrefix) {
+		entry.Exception = true
+		rule = strings.TrimPrefix(rule, abpExceptionPrefix)
+	}
+
+	if idx := strings.Index(rule, abpOptionsPrefix); idx != -1 {
+		if rule[idx+1:] != abpImportantOption {
+			return nil, false, nil
+		}
+
+		rule = rule[:idx]
+	}
+
+	if !strings.HasPrefix(rule, abpDomainPrefix) {
+		// element hiding, path and address part rules
+		return nil, false, nil
+	}
+
+	domain := strings.TrimPrefix(rule, abpDomainPrefix)
+	domain = strings.TrimSuffix(domain, abpEndAnchor)
+
+	if !strings.HasSuffix(domain, abpSeparator) {
+		// without separator, the rule would match any domain starting with it
+		return nil, false, nil
+	}
+
+	domain = strings.TrimSuffix(domain, abpSeparator)
+
+	if strings.ContainsAny(domain, "/*^|") {
+		// paths and patterns
+		return nil, false, nil
+	}
+
+	domain, err := idna.Punycode.ToASCII(strings.ToLower(domain))
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", err, domain)
+	}
+
+	if err := validateDomainName(domain); err != nil {
+		return nil, false, err
+	}
+
+	entry.Domain = domain
+
+	return &entry, true, nil
+}
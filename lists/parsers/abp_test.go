@@ -0,0 +1,122 @@
+package parsers
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ABP", func() {
+	var (
+		sutReader io.Reader
+		sut       *ABPParser
+	)
+
+	JustBeforeEach(func() {
+		sut = ABP(sutReader)
+	})
+
+	collect := func() []ABPEntry {
+		var res []ABPEntry
+
+		Expect(ForEach[*ABPEntry](context.Background(), sut, func(entry *ABPEntry) error {
+			res = append(res, *entry)
+
+			return nil
+		})).Should(Succeed())
+
+		return res
+	}
+
+	When("parsing domain rules", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"[Adblock Plus 2.0]",
+				"! Title: test list",
+				"",
+				"||ads.example.com^",
+				"||Tracker.example.org^|",
+				"@@||cdn.ads.example.com^",
+				"||important.example.com^$important",
+				"||müller.com^",
+			)
+		})
+
+		It("returns the domains and exceptions", func() {
+			Expect(collect()).Should(Equal([]ABPEntry{
+				{Domain: "ads.example.com"},
+				{Domain: "tracker.example.org"},
+				{Domain: "cdn.ads.example.com", Exception: true},
+				{Domain: "important.example.com"},
+				{Domain: "xn--mller-kva.com"},
+			}))
+			Expect(sut.Ignored()).Should(BeZero())
+			Expect(sut.Position()).Should(Equal("line 8"))
+		})
+	})
+
+	When("parsing rules which can't be applied to DNS queries", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"example.com##.banner",
+				"example.com#@#.banner",
+				"/ads/banner*",
+				"|https://example.com/ads",
+				"||example.com/ads/*",
+				"||example.com^$third-party",
+				"||ads.*.example.com^",
+				"||prefix.example",
+				"||blocked.com^",
+			)
+		})
+
+		It("skips and counts them", func() {
+			Expect(collect()).Should(Equal([]ABPEntry{
+				{Domain: "blocked.com"},
+			}))
+			Expect(sut.Ignored()).Should(BeNumerically("==", 8))
+		})
+	})
+
+	When("a domain rule is invalid", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"||invalid!domain^",
+			)
+		})
+
+		It("returns an error", func() {
+			_, err := sut.Next(context.Background())
+			Expect(err).Should(HaveOccurred())
+			Expect(IsNonResumableErr(err)).Should(BeFalse())
+		})
+	})
+
+	Describe("ABPEntry", func() {
+		It("matches the domain and its subdomains", func() {
+			entry := ABPEntry{Domain: "example.com"}
+
+			Expect(iteratorToList(entry.ForEach)).Should(Equal([]string{"example.com", "*.example.com"}))
+		})
+	})
+
+	Describe("IsABP", func() {
+		It("detects the Adblock Plus header", func() {
+			Expect(IsABP(bufio.NewReader(strings.NewReader("[Adblock Plus 2.0]\n||example.com^")))).Should(BeTrue())
+			Expect(IsABP(bufio.NewReader(strings.NewReader("\xEF\xBB\xBF[Adblock Plus 2.0]\n")))).Should(BeTrue())
+			Expect(IsABP(bufio.NewReader(strings.NewReader("example.com\n")))).Should(BeFalse())
+			Expect(IsABP(bufio.NewReader(strings.NewReader("")))).Should(BeFalse())
+		})
+
+		It("keeps the content", func() {
+			r := bufio.NewReader(strings.NewReader("[Adblock Plus 2.0]\n"))
+
+			Expect(IsABP(r)).Should(BeTrue())
+			Expect(io.ReadAll(r)).Should(BeEquivalentTo("[Adblock Plus 2.0]\n"))
+		})
+	})
+})
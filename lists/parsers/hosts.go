@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/0xERR0R/blocky/trie"
 	"github.com/hashicorp/go-multierror"
 	"golang.org/x/net/idna"
 )
@@ -51,6 +52,15 @@ func (h *HostsIterator) ForEach(callback func(string) error) error {
 	return h.hostsIterator.forEachHost(callback)
 }
 
+// IP returns the IP of a hosts file entry, nil for a host list entry
+func (h *HostsIterator) IP() net.IP {
+	if entry, ok := h.hostsIterator.(*HostsFileEntry); ok {
+		return entry.IP
+	}
+
+	return nil
+}
+
 func (h *HostsIterator) UnmarshalText(data []byte) error {
 	var mErr *multierror.Error
 
@@ -234,8 +244,9 @@ func validateDomainName(host string) error {
 	return fmt.Errorf("invalid domain name: %s", host)
 }
 
+// isRegex returns true for non-empty patterns wrapped in slashes, "//" would match everything
 func isRegex(host string) bool {
-	return strings.HasPrefix(host, "/") && strings.HasSuffix(host, "/")
+	return len(host) > 2 && strings.HasPrefix(host, "/") && strings.HasSuffix(host, "/")
 }
 
 func validateHostsListEntry(host string) error {
@@ -243,10 +254,22 @@ func validateHostsListEntry(host string) error {
 		return nil
 	}
 
+	if _, _, err := net.ParseCIDR(host); err == nil {
+		return nil
+	}
+
+	if trie.IsWildcard(host) {
+		return validateDomainName(strings.TrimPrefix(host, trie.WildcardPrefix))
+	}
+
 	if isRegex(host) {
-		_, err := regexp.Compile(host)
+		// compiled the same way as by the regex cache, so invalid patterns are reported with their source
+		_, err := regexp.Compile(strings.TrimSpace(host[1 : len(host)-1]))
+		if err != nil {
+			return fmt.Errorf("invalid regex %s: %w", host, err)
+		}
 
-		return err
+		return nil
 	}
 
 	return validateDomainName(host)
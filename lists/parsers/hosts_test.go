@@ -36,6 +36,7 @@ var _ = Describe("Hosts", func() {
 				`/domain\.(tld|local)/`,
 				`/^(.*\.)?2023\.xn--aptslabs-6fd\.net$/`,
 				`müller.com`,
+				"*.wildcard.tld",
 			)
 		})
 
@@ -43,11 +44,13 @@ var _ = Describe("Hosts", func() {
 			it, err := sut.Next(context.Background())
 			Expect(err).Should(Succeed())
 			Expect(iteratorToList(it.ForEach)).Should(Equal([]string{"localhost"}))
+			Expect(it.IP()).Should(BeNil())
 			Expect(sut.Position()).Should(Equal("line 1"))
 
 			it, err = sut.Next(context.Background())
 			Expect(err).Should(Succeed())
 			Expect(iteratorToList(it.ForEach)).Should(Equal([]string{"domain.tld"}))
+			Expect(it.IP()).Should(Equal(net.ParseIP("127.0.0.1")))
 			Expect(sut.Position()).Should(Equal("line 4"))
 
 			it, err = sut.Next(context.Background())
@@ -70,11 +73,16 @@ var _ = Describe("Hosts", func() {
 			Expect(iteratorToList(it.ForEach)).Should(Equal([]string{`xn--mller-kva.com`}))
 			Expect(sut.Position()).Should(Equal("line 8"))
 
+			it, err = sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(iteratorToList(it.ForEach)).Should(Equal([]string{"*.wildcard.tld"}))
+			Expect(sut.Position()).Should(Equal("line 9"))
+
 			_, err = sut.Next(context.Background())
 			Expect(err).ShouldNot(Succeed())
 			Expect(err).Should(MatchError(io.EOF))
 			Expect(IsNonResumableErr(err)).Should(BeTrue())
-			Expect(sut.Position()).Should(Equal("line 9"))
+			Expect(sut.Position()).Should(Equal("line 10"))
 		})
 	})
 
@@ -85,6 +93,11 @@ var _ = Describe("Hosts", func() {
 				"!notadomain!",
 				"xn---mllerk1va.com",
 				`/invalid regex ??/`,
+				`/invalid regex (/`,
+				"//",
+				"*.",
+				"*.invalid!",
+				"127.0.0.1 *.wildcard.tld",
 			}
 
 			for _, line := range lines {
@@ -324,6 +337,10 @@ var _ = Describe("HostList", func() {
 				// invalid domain names we want to support
 				"-start-with-a-hyphen.com",
 				"end-with-a-hyphen-.com",
+
+				// networks
+				"10.0.0.0/8",
+				"2001:db8::/32",
 			)
 		})
 
@@ -368,11 +385,21 @@ var _ = Describe("HostList", func() {
 			Expect(entry.String()).Should(Equal("end-with-a-hyphen-.com"))
 			Expect(sut.Position()).Should(Equal("line 10"))
 
+			entry, err = sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(entry.String()).Should(Equal("10.0.0.0/8"))
+			Expect(sut.Position()).Should(Equal("line 11"))
+
+			entry, err = sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(entry.String()).Should(Equal("2001:db8::/32"))
+			Expect(sut.Position()).Should(Equal("line 12"))
+
 			_, err = sut.Next(context.Background())
 			Expect(err).ShouldNot(Succeed())
 			Expect(err).Should(MatchError(io.EOF))
 			Expect(IsNonResumableErr(err)).Should(BeTrue())
-			Expect(sut.Position()).Should(Equal("line 11"))
+			Expect(sut.Position()).Should(Equal("line 13"))
 		})
 	})
 
@@ -382,6 +409,7 @@ var _ = Describe("HostList", func() {
 				"127.0.0.1 localhost",
 				"localhost localhost",
 				`/invalid regex ??/`,
+				"10.0.0.0/33",
 				"toolong" + strings.Repeat("a", maxDomainNameLength),
 			}
 
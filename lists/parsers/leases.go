@@ -0,0 +1,222 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// dnsmasqLeaseFields are the fields of a dnsmasq lease: expiry, MAC (or IAID), IP, hostname and client ID
+	dnsmasqLeaseFields = 5
+
+	// iscTimeLayout is the time format of ISC dhcpd, after the weekday: `ends 4 2024/01/04 22:00:00;`
+	iscTimeLayout = "2006/01/02 15:04:05"
+
+	// unknownHostname is written by dnsmasq for clients which didn't send a hostname
+	unknownHostname = "*"
+)
+
+// Lease is an active DHCP lease
+type Lease struct {
+	IP net.IP
+	// MAC is nil for DHCPv6 leases of dnsmasq, which have an IAID instead
+	MAC net.HardwareAddr
+	// Hostname sent by the client, empty if it didn't send one
+	Hostname string
+	// Expires is zero for infinite leases
+	Expires time.Time
+}
+
+// IsExpired returns true if the lease expired before `now`
+func (l *Lease) IsExpired(now time.Time) bool {
+	return !l.Expires.IsZero() && !now.Before(l.Expires)
+}
+
+// Leases parses `r` as dnsmasq or ISC dhcpd lease file, the format is detected for each entry.
+//
+// Leases of ISC dhcpd which aren't active (e.g. free or released) are skipped. Later leases of the same IP
+// supersede earlier ones, like ISC dhcpd appends changed leases to the file.
+func Leases(r io.Reader) SeriesParser[*Lease] {
+	return &leases{lines: Lines(r)}
+}
+
+type leases struct {
+	lines SeriesParser[string]
+}
+
+func (l *leases) Position() string {
+	return l.lines.Position()
+}
+
+func (l *leases) Next(ctx context.Context) (*Lease, error) {
+	for {
+		line, err := l.lines.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+
+		switch {
+		case fields[0] == "lease" && strings.HasSuffix(line, "{"):
+			lease, err := l.parseISCLease(ctx, fields)
+			if err != nil || lease != nil {
+				return lease, err
+			}
+
+		case strings.HasSuffix(line, "{"):
+			// other ISC blocks like failover states or DHCPv6 leases
+			if err := l.skipBlock(ctx); err != nil {
+				return nil, err
+			}
+
+		case fields[0] == "duid":
+			// the server DUID of dnsmasq, before the DHCPv6 leases
+
+		case isISCStatement(line):
+			// ISC declarations outside of blocks, e.g. `authoring-byte-order little-endian;`
+
+		default:
+			return parseDnsmasqLease(fields)
+		}
+	}
+}
+
+// parseDnsmasqLease parses a line like `1704405600 aa:bb:cc:dd:ee:ff 192.168.178.20 laptop 01:aa:bb:cc:dd:ee:ff`
+func parseDnsmasqLease(fields []string) (*Lease, error) {
+	if len(fields) != dnsmasqLeaseFields {
+		return nil, fmt.Errorf("invalid lease, expected %d fields: %s", dnsmasqLeaseFields, strings.Join(fields, " "))
+	}
+
+	expiry, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lease expiry '%s': %w", fields[0], err)
+	}
+
+	lease := Lease{IP: net.ParseIP(fields[2])}
+
+	if lease.IP == nil {
+		return nil, fmt.Errorf("invalid lease IP '%s'", fields[2])
+	}
+
+	if expiry != 0 {
+		lease.Expires = time.Unix(expiry, 0)
+	}
+
+	// DHCPv6 leases have an IAID instead
+	lease.MAC, _ = net.ParseMAC(fields[1])
+
+	if fields[3] != unknownHostname {
+		lease.Hostname = fields[3]
+	}
+
+	return &lease, nil
+}
+
+// parseISCLease parses the statements of a lease block until its end. Nil is returned for leases which aren't active.
+func (l *leases) parseISCLease(ctx context.Context, fields []string) (*Lease, error) {
+	lease := Lease{IP: net.ParseIP(fields[1])}
+
+	if lease.IP == nil {
+		_ = l.skipBlock(ctx)
+
+		return nil, fmt.Errorf("invalid lease IP '%s'", fields[1])
+	}
+
+	active := true
+
+	for {
+		line, err := l.lines.Next(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, NewNonResumableError(fmt.Errorf("lease %s isn't closed", lease.IP))
+			}
+
+			return nil, err
+		}
+
+		if line == "}" {
+			break
+		}
+
+		statement := strings.Fields(strings.TrimSuffix(line, ";"))
+
+		switch {
+		case len(statement) >= 2 && statement[0] == "ends":
+			lease.Expires, err = parseISCTime(statement[1:])
+			if err != nil {
+				_ = l.skipBlock(ctx)
+
+				return nil, fmt.Errorf("invalid expiry of lease %s: %w", lease.IP, err)
+			}
+
+		case len(statement) == 3 && statement[0] == "binding" && statement[1] == "state":
+			active = statement[2] == "active"
+
+		case len(statement) == 3 && statement[0] == "hardware":
+			lease.MAC, _ = net.ParseMAC(statement[2])
+
+		case len(statement) == 2 && statement[0] == "client-hostname":
+			lease.Hostname = strings.Trim(statement[1], `"`)
+		}
+	}
+
+	if !active {
+		return nil, nil //nolint:nilnil // nil lease means the lease is skipped
+	}
+
+	return &lease, nil
+}
+
+// parseISCTime parses `never`, `epoch 1704405600` or `4 2024/01/04 22:00:00` (weekday, date and time in UTC)
+func parseISCTime(fields []string) (time.Time, error) {
+	const weekdayDateTime = 3
+
+	switch {
+	case len(fields) == 1 && fields[0] == "never":
+		return time.Time{}, nil
+
+	case len(fields) == 2 && fields[0] == "epoch":
+		epoch, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(epoch, 0), nil
+
+	case len(fields) == weekdayDateTime:
+		return time.Parse(iscTimeLayout, fields[1]+" "+fields[2])
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time '%s'", strings.Join(fields, " "))
+}
+
+// skipBlock skips the lines until the end of the current block, including nested blocks
+func (l *leases) skipBlock(ctx context.Context) error {
+	for depth := 1; depth > 0; {
+		line, err := l.lines.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasSuffix(line, "{"):
+			depth++
+		case line == "}":
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// isISCStatement returns true for a statement which doesn't start with a number, unlike dnsmasq leases
+func isISCStatement(line string) bool {
+	return strings.HasSuffix(line, ";") && (line[0] < '0' || line[0] > '9')
+}
@@ -0,0 +1,199 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Leases", func() {
+	var (
+		sutReader io.Reader
+		sut       SeriesParser[*Lease]
+	)
+
+	BeforeEach(func() {
+		sutReader = nil
+	})
+
+	JustBeforeEach(func() {
+		sut = Leases(sutReader)
+	})
+
+	mac := func(s string) net.HardwareAddr {
+		m, err := net.ParseMAC(s)
+		Expect(err).Should(Succeed())
+
+		return m
+	}
+
+	When("parsing a dnsmasq lease file", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"1704405600 aa:bb:cc:dd:ee:01 192.168.178.20 laptop 01:aa:bb:cc:dd:ee:01",
+				"0 aa:bb:cc:dd:ee:02 192.168.178.21 * *",
+				"duid 00:01:00:01:2c:5e:9a:1b:aa:bb:cc:dd:ee:ff",
+				"1704405600 12345678 fd00::20 phone 00:01:00:01:2c:5e:9a:1b:aa:bb:cc:dd:ee:03",
+			)
+		})
+
+		It("succeeds", func() {
+			lease, err := sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(lease).Should(Equal(&Lease{
+				IP:       net.ParseIP("192.168.178.20"),
+				MAC:      mac("aa:bb:cc:dd:ee:01"),
+				Hostname: "laptop",
+				Expires:  time.Unix(1704405600, 0),
+			}))
+			Expect(sut.Position()).Should(Equal("line 1"))
+
+			lease, err = sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(lease.Hostname).Should(BeEmpty())
+			Expect(lease.Expires).Should(BeZero())
+			Expect(lease.IsExpired(time.Now())).Should(BeFalse())
+			Expect(sut.Position()).Should(Equal("line 2"))
+
+			lease, err = sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(lease.IP).Should(Equal(net.ParseIP("fd00::20")))
+			Expect(lease.MAC).Should(BeNil())
+			Expect(lease.Hostname).Should(Equal("phone"))
+			Expect(sut.Position()).Should(Equal("line 4"))
+
+			_, err = sut.Next(context.Background())
+			Expect(err).ShouldNot(Succeed())
+			Expect(err).Should(MatchError(io.EOF))
+			Expect(IsNonResumableErr(err)).Should(BeTrue())
+		})
+	})
+
+	When("parsing an ISC dhcpd lease file", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"# The format of this file is documented in the dhcpd.leases(5) manual page.",
+				"authoring-byte-order little-endian;",
+				"server-duid \"\\000\\001\\000\\001\";",
+				"",
+				"lease 192.168.1.20 {",
+				"  starts 4 2024/01/04 10:00:00;",
+				"  ends 4 2024/01/04 22:00:00;",
+				"  binding state active;",
+				"  next binding state free;",
+				"  hardware ethernet aa:bb:cc:dd:ee:01;",
+				"  uid \"\\001\\252\\273\\314\\335\\356\\001\";",
+				"  client-hostname \"laptop\";",
+				"}",
+				"lease 192.168.1.21 {",
+				"  ends never;",
+				"  binding state free;",
+				"  hardware ethernet aa:bb:cc:dd:ee:02;",
+				"}",
+				"failover peer \"peer\" state {",
+				"  my state normal at 4 2024/01/04 10:00:00;",
+				"}",
+				"ia-na \"\\000\\001\" {",
+				"  iaaddr fd00::20 {",
+				"    binding state active;",
+				"  }",
+				"}",
+				"lease 192.168.1.22 {",
+				"  ends epoch 1704405600;",
+				"  binding state active;",
+				"  hardware ethernet aa:bb:cc:dd:ee:03;",
+				"}",
+			)
+		})
+
+		It("succeeds", func() {
+			lease, err := sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(lease).Should(Equal(&Lease{
+				IP:       net.ParseIP("192.168.1.20"),
+				MAC:      mac("aa:bb:cc:dd:ee:01"),
+				Hostname: "laptop",
+				Expires:  time.Date(2024, 1, 4, 22, 0, 0, 0, time.UTC),
+			}))
+			Expect(sut.Position()).Should(Equal("line 13"))
+
+			lease, err = sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(lease).Should(Equal(&Lease{
+				IP:      net.ParseIP("192.168.1.22"),
+				MAC:     mac("aa:bb:cc:dd:ee:03"),
+				Expires: time.Unix(1704405600, 0),
+			}))
+			Expect(lease.IsExpired(time.Unix(1704405600, 0))).Should(BeTrue())
+
+			_, err = sut.Next(context.Background())
+			Expect(err).Should(MatchError(io.EOF))
+		})
+	})
+
+	When("parsing invalid leases", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"1704405600 aa:bb:cc:dd:ee:01 192.168.178.20",
+				"soon aa:bb:cc:dd:ee:01 192.168.178.20 laptop *",
+				"1704405600 aa:bb:cc:dd:ee:01 invalid laptop *",
+				"lease invalid {",
+				"  binding state active;",
+				"}",
+				"lease 192.168.1.20 {",
+				"  ends sometime;",
+				"}",
+				"1704405600 aa:bb:cc:dd:ee:01 192.168.178.20 laptop *",
+			)
+		})
+
+		It("should fail for each lease and continue", func() {
+			for _, expected := range []string{
+				"expected 5 fields", "invalid lease expiry", "invalid lease IP 'invalid'", "invalid lease IP 'invalid'",
+				"invalid expiry of lease 192.168.1.20",
+			} {
+				_, err := sut.Next(context.Background())
+				Expect(err).Should(MatchError(ContainSubstring(expected)))
+				Expect(IsNonResumableErr(err)).Should(BeFalse())
+			}
+
+			lease, err := sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(lease.Hostname).Should(Equal("laptop"))
+			Expect(sut.Position()).Should(Equal("line 10"))
+		})
+	})
+
+	When("a lease isn't closed", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"lease 192.168.1.20 {",
+				"  binding state active;",
+			)
+		})
+
+		It("should fail", func() {
+			_, err := sut.Next(context.Background())
+			Expect(err).Should(MatchError(ContainSubstring("lease 192.168.1.20 isn't closed")))
+			Expect(IsNonResumableErr(err)).Should(BeTrue())
+		})
+	})
+
+	When("the context is cancelled", func() {
+		It("should fail", func() {
+			sutReader = linesReader("0 aa:bb:cc:dd:ee:01 192.168.178.20 laptop *")
+			sut = Leases(sutReader)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := sut.Next(ctx)
+			Expect(errors.Is(err, context.Canceled)).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,164 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/0xERR0R/blocky/trie"
+	"github.com/miekg/dns"
+)
+
+const (
+	rpzPassthru = "rpz-passthru."
+
+	// rpzTriggerPrefix starts the labels of the triggers other than the query name (rpz-ip, rpz-nsdname, ...)
+	rpzTriggerPrefix = "rpz-"
+)
+
+// RPZAction is the policy action of a RPZ entry supported by blocky
+type RPZAction int
+
+const (
+	// RPZActionNXDomain blocks the domain: `CNAME .`
+	RPZActionNXDomain RPZAction = iota
+	// RPZActionNoData blocks the domain: `CNAME *.`
+	RPZActionNoData
+	// RPZActionPassthru exempts the domain from blocking: `CNAME rpz-passthru.`
+	RPZActionPassthru
+)
+
+// IsBlock returns true if the action blocks the domain
+func (a RPZAction) IsBlock() bool {
+	return a != RPZActionPassthru
+}
+
+// RPZEntry is a query name trigger of a Response Policy Zone with a supported action.
+type RPZEntry struct {
+	// Name is relative to the zone, wildcard triggers use the `*.` prefix like blocky's wildcards
+	Name   string
+	Action RPZAction
+}
+
+// RPZ parses `r` as a Response Policy Zone (RPZ) zone file.
+//
+// Only query name triggers with the actions `CNAME .`, `CNAME *.` and `CNAME rpz-passthru.` are returned.
+// Other triggers and actions can't be expressed as a list entry, they are skipped and counted by `Ignored`.
+func RPZ(r io.Reader) *RPZParser {
+	zp := dns.NewZoneParser(r, ".", "")
+	zp.SetIncludeAllowed(false)
+
+	return &RPZParser{zp: zp, apex: "."}
+}
+
+// RPZParser is the `SeriesParser` returned by `RPZ`.
+type RPZParser struct {
+	zp      *dns.ZoneParser
+	apex    string
+	records uint
+	ignored uint
+}
+
+// Ignored returns the number of records skipped so far, because their trigger or action isn't supported
+func (p *RPZParser) Ignored() uint {
+	return p.ignored
+}
+
+func (p *RPZParser) Position() string {
+	return fmt.Sprintf("record %d", p.records)
+}
+
+func (p *RPZParser) Next(ctx context.Context) (*RPZEntry, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rr, ok := p.zp.Next()
+		if !ok {
+			if err := p.zp.Err(); err != nil {
+				return nil, NewNonResumableError(err)
+			}
+
+			return nil, io.EOF
+		}
+
+		p.records++
+
+		entry, ok, err := p.parseRecord(rr)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return entry, nil
+		}
+	}
+}
+
+// parseRecord returns false for records, which are skipped
+func (p *RPZParser) parseRecord(rr dns.RR) (*RPZEntry, bool, error) {
+	switch rr := rr.(type) {
+	case *dns.SOA:
+		p.apex = rr.Hdr.Name
+
+		return nil, false, nil
+
+	case *dns.NS:
+		// zone meta data
+		return nil, false, nil
+
+	case *dns.CNAME:
+		return p.parseCNAME(rr)
+
+	default:
+		// local data action, the record would be returned instead of the query's answer
+		p.ignored++
+
+		return nil, false, nil
+	}
+}
+
+func (p *RPZParser) parseCNAME(rr *dns.CNAME) (*RPZEntry, bool, error) {
+	var action RPZAction
+
+	switch strings.ToLower(p.relative(rr.Target)) {
+	case ".":
+		action = RPZActionNXDomain
+	case "*.":
+		action = RPZActionNoData
+	case rpzPassthru:
+		action = RPZActionPassthru
+	default:
+		// rpz-drop, rpz-tcp-only and local data rewrites
+		p.ignored++
+
+		return nil, false, nil
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(p.relative(rr.Hdr.Name), "."))
+
+	for _, label := range dns.SplitDomainName(name) {
+		if strings.HasPrefix(label, rpzTriggerPrefix) {
+			p.ignored++
+
+			return nil, false, nil
+		}
+	}
+
+	if err := validateDomainName(strings.TrimPrefix(name, trie.WildcardPrefix)); err != nil {
+		return nil, false, err
+	}
+
+	return &RPZEntry{Name: name, Action: action}, true, nil
+}
+
+// relative strips the zone apex from `name`, names outside the zone are kept
+func (p *RPZParser) relative(name string) string {
+	if p.apex == "." || !dns.IsSubDomain(p.apex, name) || dns.CountLabel(name) == dns.CountLabel(p.apex) {
+		return name
+	}
+
+	return name[:len(name)-len(p.apex)]
+}
@@ -0,0 +1,127 @@
+package parsers
+
+import (
+	"context"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RPZ", func() {
+	var (
+		sutReader io.Reader
+		sut       *RPZParser
+	)
+
+	JustBeforeEach(func() {
+		sut = RPZ(sutReader)
+	})
+
+	collect := func() []RPZEntry {
+		var res []RPZEntry
+
+		Expect(ForEach[*RPZEntry](context.Background(), sut, func(entry *RPZEntry) error {
+			res = append(res, *entry)
+
+			return nil
+		})).Should(Succeed())
+
+		return res
+	}
+
+	When("parsing a zone with supported actions", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"$TTL 300",
+				"@ SOA localhost. root.localhost. 1 43200 3600 86400 120",
+				"  NS localhost.",
+				"; comment",
+				"blocked.com CNAME .",
+				"*.Wildcard.com CNAME .",
+				"nodata.com CNAME *.",
+				"allowed.com CNAME rpz-passthru.",
+			)
+		})
+
+		It("returns the entries relative to the zone", func() {
+			Expect(collect()).Should(Equal([]RPZEntry{
+				{Name: "blocked.com", Action: RPZActionNXDomain},
+				{Name: "*.wildcard.com", Action: RPZActionNXDomain},
+				{Name: "nodata.com", Action: RPZActionNoData},
+				{Name: "allowed.com", Action: RPZActionPassthru},
+			}))
+			Expect(sut.Ignored()).Should(BeZero())
+			Expect(sut.Position()).Should(Equal("record 6"))
+		})
+	})
+
+	When("the zone has an origin", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"$ORIGIN rpz.example.",
+				"$TTL 300",
+				"@ SOA ns.example. root.example. 1 43200 3600 86400 120",
+				"blocked.com CNAME .",
+				"allowed.com CNAME rpz-passthru",
+			)
+		})
+
+		It("strips the origin", func() {
+			Expect(collect()).Should(Equal([]RPZEntry{
+				{Name: "blocked.com", Action: RPZActionNXDomain},
+				{Name: "allowed.com", Action: RPZActionPassthru},
+			}))
+		})
+	})
+
+	When("the zone has unsupported triggers and actions", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"$TTL 300",
+				"@ SOA localhost. root.localhost. 1 43200 3600 86400 120",
+				"dropped.com CNAME rpz-drop.",
+				"tcp.com CNAME rpz-tcp-only.",
+				"rewritten.com CNAME other.com.",
+				"local.com A 192.0.2.1",
+				"32.1.2.0.192.rpz-ip CNAME .",
+				"ns.example.rpz-nsdname CNAME .",
+				"blocked.com CNAME .",
+			)
+		})
+
+		It("ignores and counts them", func() {
+			Expect(collect()).Should(Equal([]RPZEntry{
+				{Name: "blocked.com", Action: RPZActionNXDomain},
+			}))
+			Expect(sut.Ignored()).Should(BeNumerically("==", 6))
+		})
+	})
+
+	When("the zone is invalid", func() {
+		BeforeEach(func() {
+			sutReader = linesReader(
+				"$TTL 300",
+				"blocked.com CNAME .",
+				"invalid.com IN INVALID .",
+			)
+		})
+
+		It("fails with a non resumable error", func() {
+			_, err := sut.Next(context.Background())
+			Expect(err).Should(Succeed())
+
+			_, err = sut.Next(context.Background())
+			Expect(err).Should(HaveOccurred())
+			Expect(IsNonResumableErr(err)).Should(BeTrue())
+		})
+	})
+
+	Describe("RPZAction", func() {
+		It("blocks for all actions but passthru", func() {
+			Expect(RPZActionNXDomain.IsBlock()).Should(BeTrue())
+			Expect(RPZActionNoData.IsBlock()).Should(BeTrue())
+			Expect(RPZActionPassthru.IsBlock()).Should(BeFalse())
+		})
+	})
+})
@@ -1,20 +1,38 @@
 package lists
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/0xERR0R/blocky/config"
 )
 
+// execWaitDelay limits the wait for the output of an executable after it was killed,
+// since its child processes might still hold the output open
+const execWaitDelay = time.Second
+
 type SourceOpener interface {
 	fmt.Stringer
 
 	Open() (io.ReadCloser, error)
 }
 
+// ConditionalOpener is a SourceOpener which can skip sources which didn't change since the last open
+type ConditionalOpener interface {
+	SourceOpener
+
+	// OpenIfModified returns ErrNotModified if the source matches the validators of the previous open
+	OpenIfModified(validators CacheValidators) (io.ReadCloser, CacheValidators, error)
+}
+
 func NewSourceOpener(txtLocInfo string, source config.BytesSource, downloader FileDownloader) (SourceOpener, error) {
 	switch source.Type {
 	case config.BytesSourceTypeText:
@@ -25,6 +43,12 @@ func NewSourceOpener(txtLocInfo string, source config.BytesSource, downloader Fi
 
 	case config.BytesSourceTypeFile:
 		return &fileOpener{source: source}, nil
+
+	case config.BytesSourceTypeGlob:
+		return &globOpener{source: source}, nil
+
+	case config.BytesSourceTypeExec:
+		return &execOpener{source: source}, nil
 	}
 
 	return nil, fmt.Errorf("cannot open %s", source)
@@ -49,9 +73,25 @@ type httpOpener struct {
 }
 
 func (o *httpOpener) Open() (io.ReadCloser, error) {
+	if downloader, ok := o.downloader.(ConditionalDownloader); ok {
+		r, _, err := downloader.DownloadFileIfModified(o.source.From, CacheValidators{}, o.source.HTTP)
+
+		return r, err
+	}
+
 	return o.downloader.DownloadFile(o.source.From)
 }
 
+func (o *httpOpener) OpenIfModified(validators CacheValidators) (io.ReadCloser, CacheValidators, error) {
+	if downloader, ok := o.downloader.(ConditionalDownloader); ok {
+		return downloader.DownloadFileIfModified(o.source.From, validators, o.source.HTTP)
+	}
+
+	r, err := o.Open()
+
+	return r, CacheValidators{}, err
+}
+
 func (o *httpOpener) String() string {
 	return o.source.String()
 }
@@ -67,3 +107,112 @@ func (o *fileOpener) Open() (io.ReadCloser, error) {
 func (o *fileOpener) String() string {
 	return o.source.String()
 }
+
+// globOpener reads all files matching the pattern of the source, as if they were one file.
+// The pattern is expanded on each open, so added and removed files are picked up by the next refresh.
+type globOpener struct {
+	source config.BytesSource
+}
+
+func (o *globOpener) Open() (io.ReadCloser, error) {
+	paths, err := filepath.Glob(o.source.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files   []io.Closer
+		readers []io.Reader
+	)
+
+	for _, path := range paths {
+		if stat, err := os.Stat(path); err != nil || !stat.Mode().IsRegular() {
+			// skip directories and special files
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			_ = closeAll(files)
+
+			return nil, err
+		}
+
+		files = append(files, file)
+		// the last line of a file might have no line ending
+		readers = append(readers, file, strings.NewReader("\n"))
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file matches %s", o.source.From)
+	}
+
+	return &multiReadCloser{Reader: io.MultiReader(readers...), closers: files}, nil
+}
+
+func (o *globOpener) String() string {
+	return o.source.String()
+}
+
+type multiReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *multiReadCloser) Close() error {
+	return closeAll(r.closers)
+}
+
+func closeAll(closers []io.Closer) error {
+	var errs []error
+
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// execOpener runs the executable of the source and reads its standard output
+type execOpener struct {
+	source config.BytesSource
+}
+
+func (o *execOpener) Open() (io.ReadCloser, error) {
+	timeout := o.source.Timeout.ToDuration()
+	if timeout <= 0 {
+		timeout = config.DefaultExecTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, o.source.From) //nolint:gosec // opt-in with loading.allowExec
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = execWaitDelay
+
+	err := cmd.Run()
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("%s didn't finish within %s", o.source.From, timeout)
+
+	case err != nil:
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", o.source.From, err, msg)
+		}
+
+		return nil, fmt.Errorf("%s failed: %w", o.source.From, err)
+	}
+
+	return io.NopCloser(&stdout), nil
+}
+
+func (o *execOpener) String() string {
+	return o.source.String()
+}
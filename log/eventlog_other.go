@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+func configureEventLog(enabled bool) {
+	if enabled {
+		logger.Warn("eventLog is only supported on Windows")
+	}
+}
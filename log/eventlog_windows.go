@@ -0,0 +1,67 @@
+package log
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// EventLogSource is the source of blocky's entries in the Windows event log, it is registered by the service install
+const EventLogSource = "blocky"
+
+// event IDs of the entries, the message of the generic source is the text of the entry
+const (
+	eventIDInfo    = 1
+	eventIDWarning = 2
+	eventIDError   = 3
+)
+
+//nolint:gochecknoglobals
+var eventLogHookOnce sync.Once
+
+// eventLogHook writes the entries with level info and above to the event log
+type eventLogHook struct {
+	log *eventlog.Log
+}
+
+func configureEventLog(enabled bool) {
+	if !enabled {
+		return
+	}
+
+	// the logger is configured several times on start
+	eventLogHookOnce.Do(func() {
+		l, err := eventlog.Open(EventLogSource)
+		if err != nil {
+			logger.Warn("can't open event log: ", err)
+
+			return
+		}
+
+		logger.AddHook(&eventLogHook{log: l})
+	})
+}
+
+// Levels implements `logrus.Hook`.
+func (h *eventLogHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel}
+}
+
+// Fire implements `logrus.Hook`.
+func (h *eventLogHook) Fire(entry *logrus.Entry) error {
+	msg := entry.Message
+
+	if prefix, ok := entry.Data[prefixField].(string); ok {
+		msg = prefix + ": " + msg
+	}
+
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return h.log.Error(eventIDError, msg)
+	case logrus.WarnLevel:
+		return h.log.Warning(eventIDWarning, msg)
+	default:
+		return h.log.Info(eventIDInfo, msg)
+	}
+}
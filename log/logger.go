@@ -7,6 +7,7 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
@@ -20,6 +21,11 @@ const prefixField = "prefix"
 //nolint:gochecknoglobals
 var logger *logrus.Logger
 
+// privacy is true if user sensitive data must be obfuscated in the log output
+//
+//nolint:gochecknoglobals
+var privacy atomic.Bool
+
 // FormatType format for logging ENUM(
 // text // logging as text
 // json // JSON format
@@ -42,6 +48,8 @@ type Config struct {
 	Format    FormatType `yaml:"format" default:"text"`
 	Privacy   bool       `yaml:"privacy" default:"false"`
 	Timestamp bool       `yaml:"timestamp" default:"true"`
+	// EventLog writes the log additionally to the Windows event log
+	EventLog bool `yaml:"eventLog" default:"false"`
 }
 
 //nolint:gochecknoinits
@@ -93,6 +101,8 @@ func ConfigureLogger(cfg *Config) {
 		logger.SetLevel(level)
 	}
 
+	privacy.Store(cfg.Privacy)
+
 	switch cfg.Format {
 	case FormatTypeText:
 		logFormatter := &prefixed.TextFormatter{
@@ -114,6 +124,13 @@ func ConfigureLogger(cfg *Config) {
 	case FormatTypeJson:
 		logger.SetFormatter(&logrus.JSONFormatter{})
 	}
+
+	configureEventLog(cfg.EventLog)
+}
+
+// IsPrivacyEnabled returns true if user sensitive data must be obfuscated in the log output
+func IsPrivacyEnabled() bool {
+	return privacy.Load()
 }
 
 // Silence disables the logger output
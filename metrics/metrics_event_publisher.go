@@ -16,6 +16,11 @@ func RegisterEventListeners() {
 	registerBlockingEventListeners()
 	registerCachingEventListeners()
 	registerApplicationEventListeners()
+	registerWatchdogEventListeners()
+	registerUpstreamEventListeners()
+	registerServerEventListeners()
+	registerRateLimitEventListeners()
+	registerRebindProtectionEventListeners()
 }
 
 func registerApplicationEventListeners() {
@@ -25,6 +30,36 @@ func registerApplicationEventListeners() {
 	subscribe(evt.ApplicationStarted, func(version, buildTime string) {
 		v.WithLabelValues(version, buildTime).Set(1)
 	})
+
+	startupDuration := startupDurationGauge()
+	startupPhaseDuration := startupPhaseDurationGauge()
+
+	RegisterMetric(startupDuration)
+	RegisterMetric(startupPhaseDuration)
+
+	subscribe(evt.StartupFinished, func(duration time.Duration, phases map[string]time.Duration) {
+		startupDuration.Set(duration.Seconds())
+
+		for phase, phaseDuration := range phases {
+			startupPhaseDuration.WithLabelValues(phase).Set(phaseDuration.Seconds())
+		}
+	})
+}
+
+func startupDurationGauge() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "blocky_startup_duration_seconds",
+		Help: "Duration of the startup",
+	})
+}
+
+func startupPhaseDurationGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_startup_phase_duration_seconds",
+			Help: "Duration of the phases of the startup",
+		}, []string{"phase"},
+	)
 }
 
 func versionNumberGauge() *prometheus.GaugeVec {
@@ -43,12 +78,8 @@ func registerBlockingEventListeners() {
 
 	RegisterMetric(enabledGauge)
 
-	subscribe(evt.BlockingEnabledEvent, func(enabled bool) {
-		if enabled {
-			enabledGauge.Set(1)
-		} else {
-			enabledGauge.Set(0)
-		}
+	subscribe(evt.BlockingEnabledEvent, func(profile string, enabled bool) {
+		enabledGauge.WithLabelValues(profile).Set(boolToFloat(enabled))
 	})
 
 	blacklistCnt := blacklistGauge()
@@ -61,25 +92,153 @@ func registerBlockingEventListeners() {
 	RegisterMetric(whitelistCnt)
 	RegisterMetric(lastListGroupRefresh)
 
-	subscribe(evt.BlockingCacheGroupChanged, func(listType lists.ListCacheType, groupName string, cnt int) {
-		lastListGroupRefresh.Set(float64(time.Now().Unix()))
-		switch listType {
-		case lists.ListCacheTypeBlacklist:
-			blacklistCnt.WithLabelValues(groupName).Set(float64(cnt))
-		case lists.ListCacheTypeWhitelist:
-			whitelistCnt.WithLabelValues(groupName).Set(float64(cnt))
-		}
+	subscribe(evt.BlockingCacheGroupChanged,
+		func(profile string, listType lists.ListCacheType, groupName string, cnt int) {
+			lastListGroupRefresh.WithLabelValues(profile).Set(float64(time.Now().Unix()))
+			switch listType {
+			case lists.ListCacheTypeBlacklist:
+				blacklistCnt.WithLabelValues(profile, groupName).Set(float64(cnt))
+			case lists.ListCacheTypeWhitelist:
+				whitelistCnt.WithLabelValues(profile, groupName).Set(float64(cnt))
+			}
+		})
+
+	sourceLastChanged := listSourceLastChanged()
+	sourceEntries := listSourceEntries()
+
+	RegisterMetric(sourceLastChanged)
+	RegisterMetric(sourceEntries)
+
+	subscribe(evt.BlockingListSourceChanged,
+		func(profile string, listType lists.ListCacheType, groupName, source string, changed time.Time, entries int) {
+			labels := []string{profile, listType.String(), groupName, source}
+
+			sourceLastChanged.WithLabelValues(labels...).Set(float64(changed.Unix()))
+			sourceEntries.WithLabelValues(labels...).Set(float64(entries))
+		})
+
+	sourceFailed := listSourceFailed()
+	sourceStale := listSourceStale()
+	sourceLastSuccess := listSourceLastSuccess()
+	sourceErrorCnt := listSourceErrorCount()
+
+	RegisterMetric(sourceFailed)
+	RegisterMetric(sourceStale)
+	RegisterMetric(sourceLastSuccess)
+	RegisterMetric(sourceErrorCnt)
+
+	subscribe(evt.BlockingListSourceRefreshed,
+		func(profile string, listType lists.ListCacheType, groupName, source string, err error, stale bool) {
+			labels := []string{profile, listType.String(), groupName, source}
+
+			sourceFailed.WithLabelValues(labels...).Set(boolToFloat(err != nil))
+			sourceStale.WithLabelValues(labels...).Set(boolToFloat(stale))
+
+			switch {
+			case err != nil:
+				sourceErrorCnt.WithLabelValues(labels...).Inc()
+			case !stale:
+				// the result of the refresh is used, it's only stale if the group was rejected
+				sourceLastSuccess.WithLabelValues(labels...).Set(float64(time.Now().Unix()))
+			}
+		})
+
+	auditMatchCnt := auditMatchCount()
+
+	RegisterMetric(auditMatchCnt)
+
+	subscribe(evt.BlockingAuditMatch, func(profile, groupName string) {
+		auditMatchCnt.WithLabelValues(profile, groupName).Inc()
 	})
-}
 
-func enabledGauge() prometheus.Gauge {
-	enabledGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "blocky_blocking_enabled",
-		Help: "Blocking status",
+	disabledResolvedCnt := disabledResolvedCount()
+
+	RegisterMetric(disabledResolvedCnt)
+
+	subscribe(evt.BlockingDisabledMatch, func(profile, groupName string) {
+		disabledResolvedCnt.WithLabelValues(profile, groupName).Inc()
 	})
-	enabledGauge.Set(1)
+}
+
+func listSourceLastChanged() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_list_source_last_changed",
+			Help: "Timestamp of the last refresh which changed the list source",
+		}, []string{"profile", "type", "group", "source"},
+	)
+}
+
+func listSourceEntries() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_list_source_entries",
+			Help: "Number of entries of the list source",
+		}, []string{"profile", "type", "group", "source"},
+	)
+}
+
+func listSourceLastSuccess() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_list_source_last_success",
+			Help: "Timestamp of the last successful refresh of the list source, changed or not",
+		}, []string{"profile", "type", "group", "source"},
+	)
+}
+
+func listSourceErrorCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_list_source_error_count",
+			Help: "Number of failed refreshes of the list source",
+		}, []string{"profile", "type", "group", "source"},
+	)
+}
+
+func listSourceFailed() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_list_source_failed",
+			Help: "1 if the last refresh of the list source failed, 0 otherwise",
+		}, []string{"profile", "type", "group", "source"},
+	)
+}
+
+func listSourceStale() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_list_source_stale",
+			Help: "1 if the entries of a previous refresh of the list source are used, since the last refresh failed",
+		}, []string{"profile", "type", "group", "source"},
+	)
+}
+
+func auditMatchCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_blocking_audit_match_count",
+			Help: "Number of queries which would have been blocked by a group which isn't enforced",
+		}, []string{"profile", "group"},
+	)
+}
+
+func disabledResolvedCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_blocking_disabled_resolved_count",
+			Help: "Number of queries which were resolved, since blocking of the group was disabled",
+		}, []string{"profile", "group"},
+	)
+}
 
-	return enabledGauge
+func enabledGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_blocking_enabled",
+			Help: "Blocking status",
+		}, []string{"profile"},
+	)
 }
 
 func blacklistGauge() *prometheus.GaugeVec {
@@ -87,7 +246,7 @@ func blacklistGauge() *prometheus.GaugeVec {
 		prometheus.GaugeOpts{
 			Name: "blocky_blacklist_cache",
 			Help: "Number of entries in the blacklist cache",
-		}, []string{"group"},
+		}, []string{"profile", "group"},
 	)
 
 	return blacklistCnt
@@ -98,60 +257,102 @@ func whitelistGauge() *prometheus.GaugeVec {
 		prometheus.GaugeOpts{
 			Name: "blocky_whitelist_cache",
 			Help: "Number of entries in the whitelist cache",
-		}, []string{"group"},
+		}, []string{"profile", "group"},
 	)
 
 	return whitelistCnt
 }
 
-func lastListGroupRefresh() prometheus.Gauge {
-	return prometheus.NewGauge(
+func lastListGroupRefresh() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "blocky_last_list_group_refresh",
 			Help: "Timestamp of last list refresh",
-		},
+		}, []string{"profile"},
 	)
 }
 
 func registerCachingEventListeners() {
 	entryCount := cacheEntryCount()
+	sizeBytes := cacheSizeBytes()
+	evictionCount := cacheEvictionCount()
 	prefetchDomainCount := prefetchDomainCacheCount()
+	prefetchDomainEvictionCount := prefetchDomainCacheEvictionCount()
+	prefetchFailedEvictionCount := prefetchFailedDomainEvictionCount()
 	hitCount := cacheHitCount()
 	missCount := cacheMissCount()
+	excludedCount := cacheExcludedCount()
 	prefetchCount := domainPrefetchCount()
 	prefetchHitCount := domainPrefetchHitCount()
+	revalidateHitCount := cacheRevalidateHitCount()
+	revalidateFailedCount := cacheRevalidateFailedCount()
 	failedDownloadCount := failedDownloadCount()
 
 	RegisterMetric(entryCount)
+	RegisterMetric(sizeBytes)
+	RegisterMetric(evictionCount)
 	RegisterMetric(prefetchDomainCount)
+	RegisterMetric(prefetchDomainEvictionCount)
+	RegisterMetric(prefetchFailedEvictionCount)
 	RegisterMetric(hitCount)
 	RegisterMetric(missCount)
+	RegisterMetric(excludedCount)
 	RegisterMetric(prefetchCount)
 	RegisterMetric(prefetchHitCount)
+	RegisterMetric(revalidateHitCount)
+	RegisterMetric(revalidateFailedCount)
 	RegisterMetric(failedDownloadCount)
 
-	subscribe(evt.CachingDomainsToPrefetchCountChanged, func(cnt int) {
-		prefetchDomainCount.Set(float64(cnt))
+	subscribe(evt.CachingDomainsToPrefetchCountChanged, func(profile string, cnt int) {
+		prefetchDomainCount.WithLabelValues(profile).Set(float64(cnt))
+	})
+
+	subscribe(evt.CachingPrefetchDomainEvicted, func(profile, _ string) {
+		prefetchDomainEvictionCount.WithLabelValues(profile).Inc()
+	})
+
+	subscribe(evt.CachingPrefetchFailedDomainEvicted, func(profile, _ string) {
+		prefetchFailedEvictionCount.WithLabelValues(profile).Inc()
+	})
+
+	subscribe(evt.CachingResultCacheMiss, func(profile, _ string) {
+		missCount.WithLabelValues(profile).Inc()
+	})
+
+	subscribe(evt.CachingResultCacheHit, func(profile, _ string) {
+		hitCount.WithLabelValues(profile).Inc()
+	})
+
+	subscribe(evt.CachingResultCacheExcluded, func(profile, _ string) {
+		excludedCount.WithLabelValues(profile).Inc()
 	})
 
-	subscribe(evt.CachingResultCacheMiss, func(_ string) {
-		missCount.Inc()
+	subscribe(evt.CachingDomainPrefetched, func(profile, _ string) {
+		prefetchCount.WithLabelValues(profile).Inc()
 	})
 
-	subscribe(evt.CachingResultCacheHit, func(_ string) {
-		hitCount.Inc()
+	subscribe(evt.CachingPrefetchCacheHit, func(profile, _ string) {
+		prefetchHitCount.WithLabelValues(profile).Inc()
 	})
 
-	subscribe(evt.CachingDomainPrefetched, func(_ string) {
-		prefetchCount.Inc()
+	subscribe(evt.CachingRevalidateHit, func(profile, _ string) {
+		revalidateHitCount.WithLabelValues(profile).Inc()
 	})
 
-	subscribe(evt.CachingPrefetchCacheHit, func(_ string) {
-		prefetchHitCount.Inc()
+	subscribe(evt.CachingRevalidateFailed, func(profile, _ string) {
+		revalidateFailedCount.WithLabelValues(profile).Inc()
 	})
 
-	subscribe(evt.CachingResultCacheChanged, func(cnt int) {
-		entryCount.Set(float64(cnt))
+	subscribe(evt.CachingResultCacheChanged, func(profile string, cnt int) {
+		entryCount.WithLabelValues(profile).Set(float64(cnt))
+	})
+
+	subscribe(evt.CachingResultCacheSizeChanged, func(profile string, size int64) {
+		sizeBytes.WithLabelValues(profile).Set(float64(size))
+	})
+
+	subscribe(evt.CachingResultCacheEvicted, func(profile, _ string) {
+		evictionCount.WithLabelValues(profile).Inc()
 	})
 
 	subscribe(evt.CachingFailedDownloadChanged, func(_ string) {
@@ -166,60 +367,336 @@ func failedDownloadCount() prometheus.Counter {
 	})
 }
 
-func cacheHitCount() prometheus.Counter {
-	return prometheus.NewCounter(
+func cacheHitCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blocky_cache_hit_count",
 			Help: "Cache hit counter",
-		},
+		}, []string{"profile"},
 	)
 }
 
-func cacheMissCount() prometheus.Counter {
-	return prometheus.NewCounter(
+func cacheMissCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blocky_cache_miss_count",
 			Help: "Cache miss counter",
-		},
+		}, []string{"profile"},
 	)
 }
 
-func domainPrefetchCount() prometheus.Counter {
-	return prometheus.NewCounter(
+func cacheExcludedCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_cache_excluded_count",
+			Help: "Counter of queries which bypassed the cache because the domain is excluded",
+		}, []string{"profile"},
+	)
+}
+
+func domainPrefetchCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blocky_prefetch_count",
 			Help: "Prefetch counter",
-		},
+		}, []string{"profile"},
 	)
 }
 
-func domainPrefetchHitCount() prometheus.Counter {
-	return prometheus.NewCounter(
+func domainPrefetchHitCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blocky_prefetch_hit_count",
 			Help: "Prefetch hit counter",
-		},
+		}, []string{"profile"},
+	)
+}
+
+func cacheRevalidateHitCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_cache_revalidate_hit_count",
+			Help: "Number of cache hits which triggered a background refresh of the entry (stale-while-revalidate)",
+		}, []string{"profile"},
 	)
 }
 
-func cacheEntryCount() prometheus.Gauge {
-	return prometheus.NewGauge(
+func cacheRevalidateFailedCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_cache_revalidate_failed_count",
+			Help: "Number of failed background refreshes of cache entries (stale-while-revalidate)",
+		}, []string{"profile"},
+	)
+}
+
+func cacheEntryCount() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "blocky_cache_entry_count",
 			Help: "Number of entries in cache",
-		},
+		}, []string{"profile"},
 	)
 }
 
-func prefetchDomainCacheCount() prometheus.Gauge {
-	return prometheus.NewGauge(
+func cacheSizeBytes() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_cache_size_bytes",
+			Help: "Approximate size of all entries in cache in bytes (only tracked if max size is set)",
+		}, []string{"profile"},
+	)
+}
+
+func cacheEvictionCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_cache_eviction_count",
+			Help: "Number of entries evicted from cache because of its max size",
+		}, []string{"profile"},
+	)
+}
+
+func prefetchDomainCacheCount() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "blocky_prefetch_domain_name_cache_count",
 			Help: "Number of entries in domain cache",
+		}, []string{"profile"},
+	)
+}
+
+func prefetchDomainCacheEvictionCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_prefetch_domain_name_cache_eviction_count",
+			Help: "Number of domains evicted from domain cache because of its max size",
+		}, []string{"profile"},
+	)
+}
+
+func prefetchFailedDomainEvictionCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_prefetch_failed_domain_eviction_count",
+			Help: "Number of domains evicted from domain cache because their prefetching failed repeatedly",
+		}, []string{"profile"},
+	)
+}
+
+func registerWatchdogEventListeners() {
+	checkFailedCount := watchdogCheckFailedCount()
+	mitigationCount := watchdogMitigationCount()
+
+	RegisterMetric(checkFailedCount)
+	RegisterMetric(mitigationCount)
+
+	subscribe(evt.WatchdogCheckFailed, func(reason string) {
+		checkFailedCount.WithLabelValues(reason).Inc()
+	})
+
+	subscribe(evt.WatchdogMitigationApplied, func(action string) {
+		mitigationCount.WithLabelValues(action).Inc()
+	})
+}
+
+func watchdogCheckFailedCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_watchdog_check_failed_count",
+			Help: "Failed watchdog self-queries",
+		}, []string{"reason"},
+	)
+}
+
+func watchdogMitigationCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_watchdog_mitigation_count",
+			Help: "Mitigations applied by the watchdog",
+		}, []string{"action"},
+	)
+}
+
+func registerUpstreamEventListeners() {
+	parallelLimitedCount := upstreamParallelLimitedCount()
+	responseMismatchCount := upstreamResponseMismatchCount()
+
+	RegisterMetric(parallelLimitedCount)
+	RegisterMetric(responseMismatchCount)
+
+	subscribe(evt.UpstreamParallelLimitReached, func() {
+		parallelLimitedCount.Inc()
+	})
+
+	subscribe(evt.UpstreamResponseMismatch, func(upstream string) {
+		responseMismatchCount.WithLabelValues(upstream).Inc()
+	})
+}
+
+func upstreamParallelLimitedCount() prometheus.Counter {
+	return prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "blocky_upstream_parallel_limited_count",
+			Help: "Queries sent to a single upstream since the max number of parallel upstream queries was reached",
 		},
 	)
 }
 
+func upstreamResponseMismatchCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_upstream_response_mismatch_count",
+			Help: "Upstream responses dropped since they didn't match the query (possible spoofing)",
+		}, []string{"upstream"},
+	)
+}
+
+func registerServerEventListeners() {
+	mismatchCount := protocolMismatchCount()
+
+	RegisterMetric(mismatchCount)
+
+	subscribe(evt.ServerProtocolMismatch, func(listener, protocol string) {
+		mismatchCount.WithLabelValues(listener, protocol).Inc()
+	})
+
+	messageSize := messageSizeHistogram()
+	truncatedCount := truncatedResponseCount()
+	tcpFallbackCount := tcpFallbackCount()
+	lateAnswerCount := lateAnswerDroppedCount()
+	aclRefusedCount := aclRefusedQueryCount()
+
+	RegisterMetric(messageSize)
+	RegisterMetric(truncatedCount)
+	RegisterMetric(tcpFallbackCount)
+	RegisterMetric(lateAnswerCount)
+	RegisterMetric(aclRefusedCount)
+
+	subscribe(evt.ServerMessageSize, func(transport, direction string, size int) {
+		messageSize.WithLabelValues(transport, direction).Observe(float64(size))
+	})
+
+	subscribe(evt.ServerTruncatedResponse, func() {
+		truncatedCount.Inc()
+	})
+
+	subscribe(evt.ServerTCPFallback, func() {
+		tcpFallbackCount.Inc()
+	})
+
+	subscribe(evt.ServerLateAnswerDropped, func() {
+		lateAnswerCount.Inc()
+	})
+
+	subscribe(evt.ServerACLRefused, func(transport string) {
+		aclRefusedCount.WithLabelValues(transport).Inc()
+	})
+}
+
+func messageSizeHistogram() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "blocky_message_size_bytes",
+			Help: "Size distribution of the DNS requests received and responses sent by transport and direction",
+			// classic UDP limit, common EDNS buffer sizes and the usual MTU
+			Buckets:                     []float64{64, 128, 256, 512, 1232, 1452, 4096, 16384, 65535},
+			NativeHistogramBucketFactor: 1.1,
+		}, []string{"transport", "direction"},
+	)
+}
+
+func truncatedResponseCount() prometheus.Counter {
+	return prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "blocky_truncated_response_count",
+			Help: "Number of truncated responses sent over UDP",
+		},
+	)
+}
+
+func tcpFallbackCount() prometheus.Counter {
+	return prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "blocky_tcp_fallback_count",
+			Help: "Number of queries retried over TCP after a truncated UDP response (best-effort)",
+		},
+	)
+}
+
+func lateAnswerDroppedCount() prometheus.Counter {
+	return prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "blocky_late_answer_dropped_count",
+			Help: "Number of UDP answers not sent, since they were older than maxAnswerAge",
+		},
+	)
+}
+
+func protocolMismatchCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_protocol_mismatch_count",
+			Help: "Connections closed since the client spoke the wrong protocol, e.g. HTTPS on the DoT port",
+		}, []string{"listener", "protocol"},
+	)
+}
+
+func aclRefusedQueryCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_acl_refused_query_count",
+			Help: "Number of queries refused since the client isn't allowed by the ACL, by transport",
+		}, []string{"transport"},
+	)
+}
+
+func registerRateLimitEventListeners() {
+	limitedCount := rateLimitedQueryCount()
+
+	RegisterMetric(limitedCount)
+
+	subscribe(evt.RateLimitQueryLimited, func(client string) {
+		limitedCount.WithLabelValues(client).Inc()
+	})
+}
+
+func rateLimitedQueryCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_rate_limited_query_count",
+			Help: "Number of queries over the rate limit by client",
+		}, []string{"client"},
+	)
+}
+
+func registerRebindProtectionEventListeners() {
+	filteredCount := rebindProtectionFilteredCount()
+
+	RegisterMetric(filteredCount)
+
+	subscribe(evt.RebindProtectionFiltered, func(domain string) {
+		filteredCount.WithLabelValues(domain).Inc()
+	})
+}
+
+func rebindProtectionFilteredCount() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_rebind_protection_filtered_count",
+			Help: "Number of upstream answers with private addresses filtered by the rebind protection, by domain",
+		}, []string{"domain"},
+	)
+}
+
 func subscribe(topic string, fn interface{}) {
 	util.FatalOnError(fmt.Sprintf("can't subscribe topic '%s'", topic), evt.Bus().Subscribe(topic, fn))
 }
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -52,6 +52,8 @@ type Response struct {
 	Res    *dns.Msg
 	Reason string
 	RType  ResponseType
+	// BlockOverride names why a deny match of the domain didn't block the query, empty if there was none
+	BlockOverride string
 }
 
 // RequestProtocol represents the server protocol ENUM(
@@ -69,4 +71,7 @@ type Request struct {
 	Req             *dns.Msg
 	Log             *logrus.Entry
 	RequestTS       time.Time
+	// ValidCookie is true if the client sent a valid server cookie (RFC 7873),
+	// so it receives the responses to its address
+	ValidCookie bool
 }
@@ -21,18 +21,21 @@ import (
 )
 
 type logEntry struct {
-	RequestTS     *time.Time `gorm:"index"`
-	ClientIP      string
-	ClientName    string `gorm:"index"`
-	DurationMs    int64
-	Reason        string
-	ResponseType  string `gorm:"index"`
-	QuestionType  string
-	QuestionName  string
-	EffectiveTLDP string
-	Answer        string
-	ResponseCode  string
-	Hostname      string
+	RequestTS    *time.Time `gorm:"index"`
+	ClientIP     string
+	ClientName   string `gorm:"index"`
+	DurationMs   int64
+	Reason       string
+	ResponseType string `gorm:"index"`
+	QuestionType string
+	QuestionName string
+	// raw (punycode) question name, QuestionName contains the unicode form
+	QuestionNamePunycode string
+	EffectiveTLDP        string
+	Answer               string
+	ResponseCode         string
+	Hostname             string
+	BlockOverride        string
 }
 
 type DatabaseWriter struct {
@@ -132,22 +135,25 @@ func (d *DatabaseWriter) periodicFlush() {
 }
 
 func (d *DatabaseWriter) Write(entry *LogEntry) {
-	domain := util.ExtractDomainOnly(entry.QuestionName)
+	domain := util.ExtractDomainOnly(entry.QuestionNamePunycode)
+	// public suffix list operates on the punycode form
 	eTLD, _ := publicsuffix.EffectiveTLDPlusOne(domain)
 
 	e := &logEntry{
-		RequestTS:     &entry.Start,
-		ClientIP:      entry.ClientIP,
-		ClientName:    strings.Join(entry.ClientNames, "; "),
-		DurationMs:    entry.DurationMs,
-		Reason:        entry.ResponseReason,
-		ResponseType:  entry.ResponseType,
-		QuestionType:  entry.QuestionType,
-		QuestionName:  domain,
-		EffectiveTLDP: eTLD,
-		Answer:        entry.Answer,
-		ResponseCode:  entry.ResponseCode,
-		Hostname:      util.HostnameString(),
+		RequestTS:            &entry.Start,
+		ClientIP:             entry.ClientIP,
+		ClientName:           strings.Join(entry.ClientNames, "; "),
+		DurationMs:           entry.DurationMs,
+		Reason:               entry.ResponseReason,
+		ResponseType:         entry.ResponseType,
+		QuestionType:         entry.QuestionType,
+		QuestionName:         util.ToUnicode(domain),
+		QuestionNamePunycode: domain,
+		EffectiveTLDP:        util.ToUnicode(eTLD),
+		Answer:               entry.Answer,
+		ResponseCode:         entry.ResponseCode,
+		Hostname:             util.HostnameString(),
+		BlockOverride:        entry.BlockOverride,
 	}
 
 	d.lock.Lock()
@@ -76,6 +76,30 @@ var _ = Describe("DatabaseWriter", func() {
 			})
 		})
 
+		When("New log entry with IDN was created", func() {
+			BeforeEach(func() {
+				writer, err = newDatabaseWriter(sqliteDB, 7, time.Millisecond)
+				Expect(err).Should(Succeed())
+			})
+
+			It("should persist unicode and punycode question name", func() {
+				writer.Write(&LogEntry{
+					Start:                time.Now(),
+					QuestionName:         "www.müller.de.",
+					QuestionNamePunycode: "www.xn--mller-kva.de.",
+				})
+
+				Expect(writer.doDBWrite()).Should(Succeed())
+
+				var entry logEntry
+				Expect(writer.db.First(&entry).Error).Should(Succeed())
+
+				Expect(entry.QuestionName).Should(Equal("www.müller.de"))
+				Expect(entry.QuestionNamePunycode).Should(Equal("www.xn--mller-kva.de"))
+				Expect(entry.EffectiveTLDP).Should(Equal("müller.de"))
+			})
+		})
+
 		When("> 10000 Entries were created", func() {
 			BeforeEach(func() {
 				writer, err = newDatabaseWriter(sqliteDB, 7, time.Millisecond)
@@ -116,6 +116,8 @@ func createQueryLogRow(logEntry *LogEntry) []string {
 		logEntry.ResponseType,
 		logEntry.QuestionType,
 		util.HostnameString(),
+		logEntry.QuestionNamePunycode,
+		logEntry.BlockOverride,
 	}
 }
 
@@ -19,20 +19,30 @@ func NewLoggerWriter() *LoggerWriter {
 }
 
 func (d *LoggerWriter) Write(entry *LogEntry) {
-	d.logger.WithFields(
-		logrus.Fields{
-			"client_ip":       entry.ClientIP,
-			"client_names":    strings.Join(entry.ClientNames, "; "),
-			"response_reason": entry.ResponseReason,
-			"response_type":   entry.ResponseType,
-			"response_code":   entry.ResponseCode,
-			"question_name":   entry.QuestionName,
-			"question_type":   entry.QuestionType,
-			"answer":          entry.Answer,
-			"duration_ms":     entry.DurationMs,
-			"hostname":        util.HostnameString(),
-		},
-	).Infof("query resolved")
+	fields := logrus.Fields{
+		"client_ip":              entry.ClientIP,
+		"client_names":           strings.Join(entry.ClientNames, "; "),
+		"response_reason":        entry.ResponseReason,
+		"response_type":          entry.ResponseType,
+		"response_code":          entry.ResponseCode,
+		"question_name":          entry.QuestionName,
+		"question_name_punycode": entry.QuestionNamePunycode,
+		"question_type":          entry.QuestionType,
+		"answer":                 entry.Answer,
+		"duration_ms":            entry.DurationMs,
+		"hostname":               util.HostnameString(),
+	}
+
+	if entry.BlockOverride != "" {
+		fields["block_override"] = entry.BlockOverride
+	}
+
+	// the JSON output is meant for machines: keep the complete answer
+	if _, isJSON := d.logger.Logger.Formatter.(*logrus.JSONFormatter); isJSON && entry.FullAnswer != "" {
+		fields["answer_full"] = entry.FullAnswer
+	}
+
+	d.logger.WithFields(fields).Infof("query resolved")
 }
 
 func (d *LoggerWriter) CleanUp() {
@@ -3,6 +3,7 @@ package querylog
 import (
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 
 	. "github.com/onsi/gomega"
@@ -25,6 +26,54 @@ var _ = Describe("LoggerWriter", func() {
 
 				Expect(hook.Entries).Should(HaveLen(1))
 				Expect(hook.LastEntry().Message).Should(Equal("query resolved"))
+				Expect(hook.LastEntry().Data).ShouldNot(HaveKey("block_override"))
+			})
+		})
+		When("a deny match was overridden", func() {
+			It("should log the override", func() {
+				writer := NewLoggerWriter()
+				logger, hook := test.NewNullLogger()
+				writer.logger = logger.WithField("k", "v")
+
+				writer.Write(&LogEntry{BlockOverride: "WHITELIST (ads: example.com)"})
+
+				Expect(hook.LastEntry().Data).Should(HaveKeyWithValue("block_override", "WHITELIST (ads: example.com)"))
+			})
+		})
+		When("the answer is shortened", func() {
+			var (
+				writer *LoggerWriter
+				logger *logrus.Logger
+				hook   *test.Hook
+			)
+
+			BeforeEach(func() {
+				writer = NewLoggerWriter()
+				logger, hook = test.NewNullLogger()
+				writer.logger = logger.WithField("k", "v")
+			})
+
+			write := func() {
+				writer.Write(&LogEntry{
+					Answer:     "A (1.1.1.1), …+1 more",
+					FullAnswer: "A (1.1.1.1), A (1.0.0.1)",
+				})
+			}
+
+			It("should log the shortened answer", func() {
+				write()
+
+				Expect(hook.LastEntry().Data).Should(HaveKeyWithValue("answer", "A (1.1.1.1), …+1 more"))
+				Expect(hook.LastEntry().Data).ShouldNot(HaveKey("answer_full"))
+			})
+
+			It("should add the complete answer to the JSON output", func() {
+				logger.SetFormatter(&logrus.JSONFormatter{})
+
+				write()
+
+				Expect(hook.LastEntry().Data).Should(HaveKeyWithValue("answer", "A (1.1.1.1), …+1 more"))
+				Expect(hook.LastEntry().Data).Should(HaveKeyWithValue("answer_full", "A (1.1.1.1), A (1.0.0.1)"))
 			})
 		})
 		When("Cleanup is called", func() {
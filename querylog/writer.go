@@ -14,7 +14,13 @@ type LogEntry struct {
 	ResponseCode   string
 	QuestionType   string
 	QuestionName   string
-	Answer         string
+	// QuestionNamePunycode contains the raw (punycode) question name, QuestionName its unicode form
+	QuestionNamePunycode string
+	Answer               string
+	// FullAnswer contains the complete answer, if Answer is shortened
+	FullAnswer string
+	// BlockOverride names why a deny match didn't block the query, see `model.Response`
+	BlockOverride string
 }
 
 type Writer interface {
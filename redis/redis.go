@@ -11,6 +11,7 @@ import (
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/miekg/dns"
@@ -51,6 +52,7 @@ type EnabledMessage struct {
 	State    bool          `json:"s"`
 	Duration time.Duration `json:"d,omitempty"`
 	Groups   []string      `json:"g,omitempty"`
+	Client   string        `json:"c,omitempty"`
 }
 
 // Client for redis communication
@@ -60,6 +62,8 @@ type Client struct {
 	l              *logrus.Entry
 	ctx            context.Context
 	id             []byte
+	streamGroup    string
+	streamConsumer string
 	sendBuffer     chan *bufferMessage
 	CacheChannel   chan *CacheMessage
 	EnabledChannel chan *EnabledMessage
@@ -116,6 +120,16 @@ func New(cfg *config.RedisConfig) (*Client, error) {
 				EnabledChannel: make(chan *EnabledMessage, chanCap),
 			}
 
+			if cfg.SyncMode == config.RedisSyncModeStream {
+				instance := cfg.InstanceName
+				if len(instance) == 0 {
+					instance = util.HostnameString()
+				}
+
+				res.streamGroup = streamGroupPrefix + instance
+				res.streamConsumer = instance
+			}
+
 			// start channel handling go routine
 			err = res.startup()
 
@@ -175,6 +189,13 @@ func (c *Client) startup() error {
 	ps := c.client.Subscribe(c.ctx, SyncChannelName)
 
 	_, err := ps.Receive(c.ctx)
+	if err == nil && c.config.SyncMode == config.RedisSyncModeStream {
+		err = c.createStreamGroup()
+		if err == nil {
+			go c.consumeStream()
+		}
+	}
+
 	if err == nil {
 		go func() {
 			for {
@@ -204,6 +225,14 @@ func (c *Client) publishMessageFromBuffer(s *bufferMessage) {
 	binRes, pErr := origRes.Pack()
 
 	if pErr == nil {
+		if c.config.SyncMode == config.RedisSyncModeStream {
+			// other instances read the entry from the store, so it must be set first
+			c.storeResponse(s.Key, binRes, origRes)
+			c.appendToStream(s.Key)
+
+			return
+		}
+
 		binMsg, mErr := json.Marshal(redisMessage{
 			Key:     s.Key,
 			Type:    messageTypeCache,
@@ -215,13 +244,17 @@ func (c *Client) publishMessageFromBuffer(s *bufferMessage) {
 			c.client.Publish(c.ctx, SyncChannelName, binMsg)
 		}
 
-		c.client.Set(c.ctx,
-			prefixKey(s.Key),
-			binRes,
-			c.getTTL(origRes))
+		c.storeResponse(s.Key, binRes, origRes)
 	}
 }
 
+func (c *Client) storeResponse(key string, binRes []byte, res *dns.Msg) {
+	c.client.Set(c.ctx,
+		prefixKey(key),
+		binRes,
+		c.getTTL(res))
+}
+
 func (c *Client) processReceivedMessage(msg *redis.Message) {
 	var rm redisMessage
 
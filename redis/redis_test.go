@@ -8,6 +8,7 @@ import (
 	"github.com/0xERR0R/blocky/util"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/creasty/defaults"
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
@@ -309,4 +310,136 @@ var _ = Describe("Redis client", func() {
 			})
 		})
 	})
+
+	Describe("Stream synchronization", func() {
+		var streamConfig config.RedisConfig
+
+		newStreamClient := func(instance string) *Client {
+			cfg := streamConfig
+			cfg.InstanceName = instance
+
+			client, err := New(&cfg)
+			Expect(err).Should(Succeed())
+			Expect(client).ShouldNot(BeNil())
+
+			return client
+		}
+
+		publish := func(client *Client, domain string) {
+			res, err := util.NewMsgWithAnswer(domain+".", 123, dns.Type(dns.TypeA), "123.124.122.123")
+			Expect(err).Should(Succeed())
+
+			client.PublishCache(domain, res)
+		}
+
+		streamLen := func() int {
+			entries, err := redisServer.Stream(SyncStreamName)
+			if err != nil {
+				return 0
+			}
+
+			return len(entries)
+		}
+
+		BeforeEach(func() {
+			streamConfig = *redisConfig
+			streamConfig.SyncMode = config.RedisSyncModeStream
+			streamConfig.ConnectionCooldown = config.Duration(100 * time.Millisecond)
+		})
+
+		It("should store the entry and append its key to the stream", func() {
+			sender := newStreamClient("a")
+
+			publish(sender, "example.com")
+
+			Eventually(streamLen).Should(Equal(1))
+			Expect(redisServer.DB(redisConfig.Database).Exists(CacheStorePrefix + "example.com")).Should(BeTrue())
+		})
+
+		It("should propagate the entry to other instances", func() {
+			sender := newStreamClient("a")
+			receiver := newStreamClient("b")
+
+			publish(sender, "example.com")
+
+			Eventually(receiver.CacheChannel).Should(Receive(HaveField("Key", "example.com")))
+			Consistently(sender.CacheChannel).ShouldNot(Receive())
+		})
+
+		It("should replay the entries which were added while the instance was disconnected", func() {
+			sender := newStreamClient("a")
+
+			// the instance "b" was running before
+			Expect(sender.client.XGroupCreateMkStream(sender.ctx, SyncStreamName, streamGroupPrefix+"b", "$").Err()).
+				Should(Succeed())
+
+			publish(sender, "example.com")
+			Eventually(streamLen).Should(Equal(1))
+
+			receiver := newStreamClient("b")
+
+			Eventually(receiver.CacheChannel).Should(Receive(HaveField("Key", "example.com")))
+		})
+
+		It("should not replay the entries for a new instance", func() {
+			sender := newStreamClient("a")
+
+			publish(sender, "example.com")
+			Eventually(streamLen).Should(Equal(1))
+
+			receiver := newStreamClient("b")
+
+			Consistently(receiver.CacheChannel).ShouldNot(Receive())
+		})
+
+		It("should process the entries which were delivered but not acknowledged before a restart", func() {
+			sender := newStreamClient("a")
+
+			Expect(sender.client.XGroupCreateMkStream(sender.ctx, SyncStreamName, streamGroupPrefix+"b", "$").Err()).
+				Should(Succeed())
+
+			publish(sender, "example.com")
+			Eventually(streamLen).Should(Equal(1))
+
+			// deliver the entry to "b" without acknowledging it
+			Expect(sender.client.XReadGroup(sender.ctx, &redis.XReadGroupArgs{
+				Group:    streamGroupPrefix + "b",
+				Consumer: "b",
+				Streams:  []string{SyncStreamName, streamNewID},
+				Block:    -1,
+			}).Result()).Should(HaveLen(1))
+
+			receiver := newStreamClient("b")
+
+			Eventually(receiver.CacheChannel).Should(Receive(HaveField("Key", "example.com")))
+		})
+
+		It("should skip entries which are expired", func() {
+			receiver := newStreamClient("b")
+
+			_, err := redisServer.XAdd(SyncStreamName, "*", []string{streamFieldKey, "expired.com"})
+			Expect(err).Should(Succeed())
+
+			Consistently(receiver.CacheChannel).ShouldNot(Receive())
+		})
+
+		When("the stream length is limited", func() {
+			BeforeEach(func() {
+				streamConfig.StreamMaxLength = 2
+			})
+
+			It("should trim the stream", func() {
+				sender := newStreamClient("a")
+
+				for _, domain := range []string{"a.com", "b.com", "c.com", "d.com"} {
+					publish(sender, domain)
+				}
+
+				Eventually(func() []string {
+					return redisServer.DB(redisConfig.Database).Keys()
+				}).Should(HaveLen(5))
+				Expect(streamLen()).Should(BeNumerically("<=", 2))
+			})
+		})
+	})
 })
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	SyncStreamName    = "blocky_sync_stream"
+	streamGroupPrefix = "blocky:"
+	streamBlockTime   = 5 * time.Second
+	streamReadCount   = 100
+	streamFieldKey    = "k"
+	streamFieldClient = "c"
+	streamPendingID   = "0"
+	streamNewID       = ">"
+)
+
+// createStreamGroup creates the consumer group of this instance, an existing group resumes after
+// the last acknowledged entry
+func (c *Client) createStreamGroup() error {
+	err := c.client.XGroupCreateMkStream(c.ctx, SyncStreamName, c.streamGroup, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+
+	return err
+}
+
+// appendToStream notifies the other instances about a new cache entry
+func (c *Client) appendToStream(key string) {
+	c.client.XAdd(c.ctx, &redis.XAddArgs{
+		Stream: SyncStreamName,
+		MaxLen: c.config.StreamMaxLength,
+		Approx: true,
+		Values: map[string]interface{}{
+			streamFieldKey:    key,
+			streamFieldClient: c.id,
+		},
+	})
+}
+
+// consumeStream reads the stream until the context is done
+func (c *Client) consumeStream() {
+	// entries which were delivered but not acknowledged before a restart are read first
+	lastID := streamPendingID
+
+	for c.ctx.Err() == nil {
+		streams, err := c.client.XReadGroup(c.ctx, &redis.XReadGroupArgs{
+			Group:    c.streamGroup,
+			Consumer: c.streamConsumer,
+			Streams:  []string{SyncStreamName, lastID},
+			Count:    streamReadCount,
+			Block:    streamBlockTime,
+		}).Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				c.handleStreamError(err)
+			}
+
+			continue
+		}
+
+		for _, stream := range streams {
+			if lastID != streamNewID {
+				if len(stream.Messages) == 0 {
+					lastID = streamNewID
+				} else {
+					lastID = stream.Messages[len(stream.Messages)-1].ID
+				}
+			}
+
+			for _, msg := range stream.Messages {
+				c.processStreamMessage(msg)
+			}
+		}
+	}
+}
+
+func (c *Client) handleStreamError(err error) {
+	c.l.Warn("Reading the sync stream failed: ", err)
+
+	time.Sleep(c.config.ConnectionCooldown.ToDuration())
+
+	if strings.HasPrefix(err.Error(), "NOGROUP") {
+		// the stream was deleted, e.g. by a restart of redis without persistence
+		if gErr := c.createStreamGroup(); gErr != nil {
+			c.l.Warn("Can't create the sync stream group: ", gErr)
+		}
+	}
+}
+
+func (c *Client) processStreamMessage(msg redis.XMessage) {
+	defer c.client.XAck(c.ctx, SyncStreamName, c.streamGroup, msg.ID)
+
+	key, _ := msg.Values[streamFieldKey].(string)
+	client, _ := msg.Values[streamFieldClient].(string)
+
+	if len(key) == 0 || client == string(c.id) {
+		return
+	}
+
+	// the entry is read from the store to get its remaining TTL, expired entries are skipped
+	cm, err := c.getResponse(prefixKey(key))
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			c.l.Error("Processing error: ", err)
+		}
+
+		return
+	}
+
+	c.CacheChannel <- cm
+}
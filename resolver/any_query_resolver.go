@@ -0,0 +1,60 @@
+package resolver
+
+import (
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// rfc8482HINFOCPU is the CPU of the synthesized HINFO record, as recommended by RFC 8482
+const rfc8482HINFOCPU = "RFC8482"
+
+// AnyQueryResolver answers ANY queries with a synthesized HINFO record (RFC 8482) instead of resolving them:
+// they are mostly used for amplification attacks and the answer of a resolver is incomplete anyway.
+type AnyQueryResolver struct {
+	configurable[*config.AnyQueriesConfig]
+	NextResolver
+	typed
+
+	// customDNS answers authoritatively for its names, may be nil
+	customDNS *CustomDNSResolver
+}
+
+// NewAnyQueryResolver creates new resolver instance, the answers for the names of customDNS are authoritative
+func NewAnyQueryResolver(cfg config.AnyQueriesConfig, customDNS *CustomDNSResolver) *AnyQueryResolver {
+	return &AnyQueryResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("any_query"),
+
+		customDNS: customDNS,
+	}
+}
+
+func (r *AnyQueryResolver) Resolve(request *model.Request) (*model.Response, error) {
+	question := request.Req.Question[0]
+
+	if !r.IsEnabled() || question.Qtype != dns.TypeANY {
+		return r.next.Resolve(request)
+	}
+
+	response := newResponse(request, dns.RcodeSuccess, model.ResponseTypeSPECIAL, "RFC 8482")
+	response.Res.Authoritative = r.customDNS != nil && r.customDNS.isCustomName(request)
+	response.Res.Answer = []dns.RR{&dns.HINFO{
+		Hdr: dns.RR_Header{
+			Name:   question.Name,
+			Rrtype: dns.TypeHINFO,
+			Class:  question.Qclass,
+			Ttl:    r.cfg.TTL.SecondsU32(),
+		},
+		Cpu: rfc8482HINFOCPU,
+	}}
+
+	r.log().WithFields(logrus.Fields{
+		"domain":        util.ExtractDomain(question),
+		"authoritative": response.Res.Authoritative,
+	}).Debug("answering ANY query with HINFO")
+
+	return response, nil
+}
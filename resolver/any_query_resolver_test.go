@@ -0,0 +1,151 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("AnyQueryResolver", func() {
+	var (
+		sut       *AnyQueryResolver
+		sutConfig config.AnyQueriesConfig
+		customDNS *CustomDNSResolver
+		m         *mockResolver
+		sutCtx    context.Context
+	)
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+
+		sutCtx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
+	ANY := dns.Type(dns.TypeANY)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.AnyQueriesConfig]()
+		Expect(err).Should(Succeed())
+
+		customDNS, err = NewCustomDNSResolver(sutCtx, config.CustomDNSConfig{
+			Mapping: config.CustomDNSMapping{HostIPs: map[string][]net.IP{"nas.lan": {net.ParseIP("192.168.178.10")}}},
+		}, systemResolverBootstrap)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg), RType: ResponseTypeRESOLVED}, nil)
+	})
+
+	JustBeforeEach(func() {
+		sut = NewAnyQueryResolver(sutConfig, customDNS)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true by default", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("an ANY query is received", func() {
+		It("should answer with HINFO without calling the next resolver", func() {
+			resp, err := sut.Resolve(newRequest("example.com.", ANY))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeSPECIAL),
+				HaveReason("RFC 8482"),
+				HaveReturnCode(dns.RcodeSuccess),
+				HaveTTL(BeNumerically("==", time.Hour.Seconds())),
+			))
+			Expect(resp.Res.Authoritative).Should(BeFalse())
+			Expect(resp.Res.Answer).Should(HaveLen(1))
+
+			hinfo, ok := resp.Res.Answer[0].(*dns.HINFO)
+			Expect(ok).Should(BeTrue())
+			Expect(hinfo.Hdr.Name).Should(Equal("example.com."))
+			Expect(hinfo.Cpu).Should(Equal("RFC8482"))
+			Expect(hinfo.Os).Should(BeEmpty())
+
+			Expect(m.Calls).Should(BeEmpty())
+		})
+
+		It("should answer authoritatively for custom DNS names", func() {
+			resp, err := sut.Resolve(newRequest("NAS.lan.", ANY))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveResponseType(ResponseTypeSPECIAL))
+			Expect(resp.Res.Authoritative).Should(BeTrue())
+		})
+
+		When("the TTL is configured", func() {
+			BeforeEach(func() {
+				sutConfig.TTL = config.Duration(5 * time.Minute)
+			})
+
+			It("should use it", func() {
+				Expect(sut.Resolve(newRequest("example.com.", ANY))).Should(HaveTTL(BeNumerically("==", 300)))
+			})
+		})
+
+		When("there is no custom DNS resolver", func() {
+			BeforeEach(func() {
+				customDNS = nil
+			})
+
+			It("should not answer authoritatively", func() {
+				resp, err := sut.Resolve(newRequest("nas.lan.", ANY))
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Res.Authoritative).Should(BeFalse())
+			})
+		})
+
+		When("it is disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("should call the next resolver", func() {
+				Expect(sut.Resolve(newRequest("example.com.", ANY))).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+		})
+	})
+
+	When("another query type is received", func() {
+		It("should call the next resolver", func() {
+			Expect(sut.Resolve(newRequest("example.com.", A))).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,409 @@
+package resolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/util"
+)
+
+// runtimeEntriesSource is the source of matched runtime entries
+const runtimeEntriesSource = "runtime entries"
+
+var errBlockingEntryNotFound = errors.New("entry not found")
+
+// runtimeEntries contains the black- and whitelist entries added via API.
+// They are kept apart from the lists, so a list refresh doesn't remove them.
+// Temporary entries expire after their TTL and are removed automatically.
+type runtimeEntries struct {
+	lock sync.RWMutex
+	// expiry of the domains per entry type and group, zero for permanent entries
+	entries map[api.BlockingEntryType]map[string]map[string]time.Time
+	// file to persist the entries, not persisted if empty
+	file string
+	// timer to purge the next expiring entry
+	purgeTimer *time.Timer
+}
+
+// persistedEntry is the JSON representation of an entry in the file
+type persistedEntry struct {
+	Type      api.BlockingEntryType `json:"type"`
+	Group     string                `json:"group"`
+	Domain    string                `json:"domain"`
+	ExpiresAt *time.Time            `json:"expiresAt,omitempty"`
+}
+
+// newRuntimeEntries creates the entries and loads them from the file, if it exists
+func newRuntimeEntries(file string) (*runtimeEntries, error) {
+	e := &runtimeEntries{
+		entries: make(map[api.BlockingEntryType]map[string]map[string]time.Time),
+		file:    file,
+	}
+
+	if file == "" {
+		return e, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return e, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can't read runtime entries: %w", err)
+	}
+
+	var persisted []persistedEntry
+
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, fmt.Errorf("can't parse runtime entries file '%s': %w", file, err)
+	}
+
+	for _, p := range persisted {
+		var expiresAt time.Time
+
+		if p.ExpiresAt != nil {
+			expiresAt = *p.ExpiresAt
+		}
+
+		e.domains(p.Type, p.Group)[p.Domain] = expiresAt
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// entries which expired while blocky was stopped are removed from the file
+	if err := e.purgeExpired(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// matcher returns the matcher of the entries with the type
+func (e *runtimeEntries) matcher(entryType api.BlockingEntryType) lists.Matcher {
+	return runtimeEntriesMatcher{entries: e, entryType: entryType}
+}
+
+// domains returns the domains of the group, the caller must hold the write lock
+func (e *runtimeEntries) domains(entryType api.BlockingEntryType, group string) map[string]time.Time {
+	groups, ok := e.entries[entryType]
+	if !ok {
+		groups = make(map[string]map[string]time.Time)
+		e.entries[entryType] = groups
+	}
+
+	domains, ok := groups[group]
+	if !ok {
+		domains = make(map[string]time.Time)
+		groups[group] = domains
+	}
+
+	return domains
+}
+
+// add adds the entry, an existing entry gets the TTL of the new one
+func (e *runtimeEntries) add(entry api.BlockingEntry) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var expiresAt time.Time
+
+	if entry.TTL > 0 {
+		expiresAt = util.Now().Add(entry.TTL)
+	}
+
+	domains := e.domains(entry.Type, entry.Group)
+
+	previous, found := domains[entry.Domain]
+	if found && previous.IsZero() && expiresAt.IsZero() {
+		return nil
+	}
+
+	domains[entry.Domain] = expiresAt
+
+	if err := e.save(); err != nil {
+		if found {
+			domains[entry.Domain] = previous
+		} else {
+			delete(domains, entry.Domain)
+		}
+
+		return err
+	}
+
+	e.schedulePurge()
+
+	return nil
+}
+
+func (e *runtimeEntries) remove(entry api.BlockingEntry) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	domains := e.entries[entry.Type][entry.Group]
+
+	expiresAt, found := domains[entry.Domain]
+	if !found || isExpired(expiresAt, util.Now()) {
+		return fmt.Errorf("%w: %s %s in group '%s'", errBlockingEntryNotFound, entry.Type, entry.Domain, entry.Group)
+	}
+
+	delete(domains, entry.Domain)
+
+	if err := e.save(); err != nil {
+		domains[entry.Domain] = expiresAt
+
+		return err
+	}
+
+	return nil
+}
+
+// list returns the active entries, temporary entries with their remaining TTL
+func (e *runtimeEntries) list() []api.BlockingEntry {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	var result []api.BlockingEntry
+
+	now := util.Now()
+
+	for entryType, groups := range e.entries {
+		for group, domains := range groups {
+			for domain, expiresAt := range domains {
+				if isExpired(expiresAt, now) {
+					continue
+				}
+
+				entry := api.BlockingEntry{Type: entryType, Group: group, Domain: domain}
+
+				if !expiresAt.IsZero() {
+					entry.TTL = expiresAt.Sub(now)
+				}
+
+				result = append(result, entry)
+			}
+		}
+	}
+
+	return result
+}
+
+// isExpired returns true if the entry with the expiry is expired, permanent entries never expire
+func isExpired(expiresAt, now time.Time) bool {
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// purgeExpired removes the expired entries and saves the remaining ones, the caller must hold the write lock
+func (e *runtimeEntries) purgeExpired() error {
+	now := util.Now()
+	purged := false
+
+	for entryType, groups := range e.entries {
+		for group, domains := range groups {
+			for domain, expiresAt := range domains {
+				if isExpired(expiresAt, now) {
+					delete(domains, domain)
+
+					purged = true
+
+					log.Log().Infof("%s entry '%s' in group '%s' expired", entryType, log.EscapeInput(domain), group)
+				}
+			}
+		}
+	}
+
+	if purged {
+		if err := e.save(); err != nil {
+			return err
+		}
+	}
+
+	e.schedulePurge()
+
+	return nil
+}
+
+// schedulePurge starts the timer to purge the next expiring entry, the caller must hold the write lock
+func (e *runtimeEntries) schedulePurge() {
+	var next time.Time
+
+	for _, groups := range e.entries {
+		for _, domains := range groups {
+			for _, expiresAt := range domains {
+				if !expiresAt.IsZero() && (next.IsZero() || expiresAt.Before(next)) {
+					next = expiresAt
+				}
+			}
+		}
+	}
+
+	if e.purgeTimer != nil {
+		e.purgeTimer.Stop()
+	}
+
+	if next.IsZero() {
+		return
+	}
+
+	e.purgeTimer = time.AfterFunc(next.Sub(util.Now()), func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
+		if err := e.purgeExpired(); err != nil {
+			log.Log().Error("can't purge expired runtime entries: ", err)
+		}
+	})
+}
+
+// save writes the entries to the file, the caller must hold the write lock
+func (e *runtimeEntries) save() error {
+	if e.file == "" {
+		return nil
+	}
+
+	persisted := make([]persistedEntry, 0)
+
+	for entryType, groups := range e.entries {
+		for group, domains := range groups {
+			for domain, expiresAt := range domains {
+				entry := persistedEntry{Type: entryType, Group: group, Domain: domain}
+
+				if !expiresAt.IsZero() {
+					expiresAt := expiresAt
+					entry.ExpiresAt = &expiresAt
+				}
+
+				persisted = append(persisted, entry)
+			}
+		}
+	}
+
+	// stable file content
+	sort.Slice(persisted, func(i, j int) bool {
+		a, b := persisted[i], persisted[j]
+
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+
+		return a.Domain < b.Domain
+	})
+
+	data, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomically(e.file, data); err != nil {
+		return fmt.Errorf("can't write runtime entries: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomically writes to a temporary file first and renames it, so a failed write doesn't corrupt the
+// existing file
+func writeFileAtomically(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+type runtimeEntriesMatcher struct {
+	entries   *runtimeEntries
+	entryType api.BlockingEntryType
+}
+
+// Match implements `lists.Matcher`.
+func (m runtimeEntriesMatcher) Match(domain string, groupsToCheck []string) (groups []string) {
+	m.entries.lock.RLock()
+	defer m.entries.lock.RUnlock()
+
+	domain = strings.ToLower(domain)
+	now := util.Now()
+
+	for _, group := range groupsToCheck {
+		// expired entries are ignored until they are purged
+		if expiresAt, found := m.entries.entries[m.entryType][group][domain]; found && !isExpired(expiresAt, now) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// Explain implements `lists.Matcher`.
+func (m runtimeEntriesMatcher) Explain(domain string, groupsToCheck []string) []lists.MatchedEntry {
+	var result []lists.MatchedEntry
+
+	for _, group := range m.Match(domain, groupsToCheck) {
+		result = append(result, lists.MatchedEntry{
+			Group:  group,
+			Source: runtimeEntriesSource,
+			Entry:  strings.ToLower(domain),
+		})
+	}
+
+	return result
+}
+
+// combinedMatcher matches a domain against several matchers, e.g. the lists and the runtime entries
+type combinedMatcher []lists.Matcher
+
+// Match implements `lists.Matcher`.
+func (m combinedMatcher) Match(domain string, groupsToCheck []string) []string {
+	var result []string
+
+	for _, matcher := range m {
+		for _, group := range matcher.Match(domain, groupsToCheck) {
+			if !slices.Contains(result, group) {
+				result = append(result, group)
+			}
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// Explain implements `lists.Matcher`.
+func (m combinedMatcher) Explain(domain string, groupsToCheck []string) []lists.MatchedEntry {
+	var result []lists.MatchedEntry
+
+	for _, matcher := range m {
+		result = append(result, matcher.Explain(domain, groupsToCheck)...)
+	}
+
+	return result
+}
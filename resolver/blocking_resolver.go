@@ -1,8 +1,10 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -24,25 +26,32 @@ import (
 
 	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
 )
 
 const defaultBlockingCleanUpInterval = 5 * time.Second
 
-func createBlockHandler(cfg config.BlockingConfig) (blockHandler, error) {
-	cfgBlockType := cfg.BlockType
+func createBlockHandler(cfgBlockType string, blockTTL config.Duration) (blockHandler, error) {
+	blockTime := blockTTL.SecondsU32()
 
 	if strings.EqualFold(cfgBlockType, "NXDOMAIN") {
-		return nxDomainBlockHandler{}, nil
+		return nxDomainBlockHandler{
+			BlockTimeSec: blockTime,
+		}, nil
 	}
 
-	blockTime := cfg.BlockTTL.SecondsU32()
-
 	if strings.EqualFold(cfgBlockType, "ZEROIP") {
 		return zeroIPBlockHandler{
 			BlockTimeSec: blockTime,
 		}, nil
 	}
 
+	if strings.EqualFold(cfgBlockType, "SELF") {
+		return selfBlockHandler{
+			BlockTimeSec: blockTime,
+		}, nil
+	}
+
 	var ips []net.IP
 
 	for _, part := range strings.Split(cfgBlockType, ",") {
@@ -62,18 +71,67 @@ func createBlockHandler(cfg config.BlockingConfig) (blockHandler, error) {
 	}
 
 	return nil,
-		fmt.Errorf("unknown blockType '%s', please use one of: ZeroIP, NxDomain or specify destination IP address(es)",
+		fmt.Errorf("unknown blockType '%s', please use one of: ZeroIP, NxDomain, Self or specify destination IP address(es)",
 			cfgBlockType)
 }
 
+// createGroupBlockHandlers creates the block handlers of the groups which override the global block type or TTL
+func createGroupBlockHandlers(cfg *config.BlockingConfig) (map[string]blockHandler, error) {
+	if err := cfg.ValidateGroups(); err != nil {
+		return nil, err
+	}
+
+	handlers := make(map[string]blockHandler, len(cfg.Groups))
+
+	for group, groupCfg := range cfg.Groups {
+		if !groupCfg.HasBlockOverride() {
+			continue
+		}
+
+		handler, err := createBlockHandler(cfg.GroupBlockType(group))
+		if err != nil {
+			return nil, fmt.Errorf("group '%s': %w", group, err)
+		}
+
+		handlers[group] = handler
+	}
+
+	return handlers, nil
+}
+
 type status struct {
 	// true: blocking of all groups is enabled
 	// false: blocking is disabled. Either all groups or only particular
 	enabled        bool
 	disabledGroups []string
 	enableTimer    *time.Timer
-	disableEnd     time.Time
-	lock           sync.RWMutex
+	disableEnd     util.MonotonicTime
+	// clients (IP or lower case client name) with disabled blocking
+	disabledClients map[string]*clientStatus
+	lock            sync.RWMutex
+}
+
+// clientStatus blocking status of a client with disabled blocking
+type clientStatus struct {
+	disabledGroups []string
+	// nil if blocking is disabled until it's enabled again via API
+	enableTimer *time.Timer
+	disableEnd  util.MonotonicTime
+}
+
+func (c *clientStatus) stopTimer() {
+	if c.enableTimer != nil {
+		c.enableTimer.Stop()
+	}
+}
+
+// clientKey normalizes the client (IP or client name) to the key of `status.disabledClients`
+func clientKey(client string) string {
+	if ip := net.ParseIP(client); ip != nil {
+		return ip.String()
+	}
+
+	return strings.ToLower(client)
 }
 
 // BlockingResolver checks request's question (domain name) against black and white lists
@@ -84,30 +142,46 @@ type BlockingResolver struct {
 
 	blacklistMatcher    *lists.ListCache
 	whitelistMatcher    *lists.ListCache
+	runtimeEntries      *runtimeEntries
+	blacklist           lists.Matcher
+	whitelist           lists.Matcher
 	blockHandler        blockHandler
+	groupBlockHandlers  map[string]blockHandler
 	whitelistOnlyGroups map[string]bool
 	status              *status
 	clientGroupsBlock   map[string][]string
 	redisClient         *redis.Client
 	fqdnIPCache         expirationcache.ExpiringCache[[]net.IP]
+	profile             string
 }
 
-// NewBlockingResolver returns a new configured instance of the resolver
+// NewBlockingResolver returns a new configured instance of the resolver, its events are published for the profile.
+// Changed list files are watched until ctx is done.
 func NewBlockingResolver(
-	cfg config.BlockingConfig, redis *redis.Client, bootstrap *Bootstrap,
+	ctx context.Context, cfg config.BlockingConfig, redis *redis.Client, bootstrap *Bootstrap, profile string,
 ) (r *BlockingResolver, err error) {
-	blockHandler, err := createBlockHandler(cfg)
+	blockHandler, err := createBlockHandler(cfg.BlockType, cfg.BlockTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	groupBlockHandlers, err := createGroupBlockHandlers(&cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	downloader := lists.NewDownloader(cfg.Loading.Downloads, bootstrap.NewHTTPTransport())
 
-	blacklistMatcher, blErr := lists.NewListCache(lists.ListCacheTypeBlacklist, cfg.Loading, cfg.BlackLists, downloader)
-	whitelistMatcher, wlErr := lists.NewListCache(lists.ListCacheTypeWhitelist, cfg.Loading, cfg.WhiteLists, downloader)
+	refreshPeriods := cfg.GroupRefreshPeriods()
+
+	blacklistMatcher, blErr := lists.NewListCache(
+		ctx, lists.ListCacheTypeBlacklist, cfg.Loading, cfg.BlackLists, downloader, refreshPeriods, profile)
+	whitelistMatcher, wlErr := lists.NewListCache(
+		ctx, lists.ListCacheTypeWhitelist, cfg.Loading, cfg.WhiteLists, downloader, refreshPeriods, profile)
 	whitelistOnlyGroups := determineWhitelistOnlyGroups(&cfg)
+	runtimeEntries, reErr := newRuntimeEntries(cfg.RuntimeEntriesFile)
 
-	err = multierror.Append(err, blErr, wlErr).ErrorOrNil()
+	err = multierror.Append(err, blErr, wlErr, reErr).ErrorOrNil()
 	if err != nil {
 		return nil, err
 	}
@@ -130,8 +204,12 @@ func NewBlockingResolver(
 		typed:        withType("blocking"),
 
 		blockHandler:        blockHandler,
+		groupBlockHandlers:  groupBlockHandlers,
 		blacklistMatcher:    blacklistMatcher,
 		whitelistMatcher:    whitelistMatcher,
+		runtimeEntries:      runtimeEntries,
+		blacklist:           combinedMatcher{blacklistMatcher, runtimeEntries.matcher(api.BlockingEntryDeny)},
+		whitelist:           combinedMatcher{whitelistMatcher, runtimeEntries.matcher(api.BlockingEntryAllow)},
 		whitelistOnlyGroups: whitelistOnlyGroups,
 		status: &status{
 			enabled:     true,
@@ -139,12 +217,16 @@ func NewBlockingResolver(
 		},
 		clientGroupsBlock: cgb,
 		redisClient:       redis,
+		profile:           profile,
 	}
 
 	if res.redisClient != nil {
 		setupRedisEnabledSubscriber(res)
 	}
 
+	// blocking is enabled on start
+	evt.Bus().Publish(evt.BlockingEnabledEvent, profile, true)
+
 	_ = evt.Bus().Subscribe(evt.ApplicationStarted, func(_ ...string) {
 		go res.initFQDNIPCache()
 	})
@@ -159,9 +241,9 @@ func setupRedisEnabledSubscriber(c *BlockingResolver) {
 				c.log().Debug("Received state from redis: ", em)
 
 				if em.State {
-					c.internalEnableBlocking()
+					c.internalEnableBlocking(em.Client)
 				} else {
-					err := c.internalDisableBlocking(em.Duration, em.Groups)
+					err := c.internalDisableBlocking(em.Duration, em.Groups, em.Client)
 					if err != nil {
 						c.log().Warn("Blocking couldn't be disabled:", err)
 					}
@@ -181,6 +263,250 @@ func (r *BlockingResolver) RefreshLists() error {
 	return err.ErrorOrNil()
 }
 
+// ListSources returns the sources of the black- and whitelists
+func (r *BlockingResolver) ListSources() []api.ListSource {
+	var result []api.ListSource
+
+	for _, list := range []*lists.ListCache{r.blacklistMatcher, r.whitelistMatcher} {
+		for _, source := range list.Sources() {
+			result = append(result, api.ListSource{
+				Type:        list.Type().String(),
+				Group:       source.Group,
+				Source:      source.Source,
+				LastChanged: source.LastChanged,
+			})
+		}
+	}
+
+	return result
+}
+
+// ListGroups returns the black- and whitelist groups with the statistics of their sources
+func (r *BlockingResolver) ListGroups() []api.ListGroup {
+	var result []api.ListGroup
+
+	for _, list := range []*lists.ListCache{r.blacklistMatcher, r.whitelistMatcher} {
+		for _, group := range list.Groups() {
+			sources := make([]api.ListGroupSource, 0, len(group.Sources))
+
+			for _, source := range group.Sources {
+				sources = append(sources, api.ListGroupSource{
+					Source:      source.Source,
+					Entries:     source.Entries,
+					LastChanged: source.LastChanged,
+					LastSuccess: source.LastSuccess,
+					LastError:   source.LastErr,
+					ErrorCount:  source.ErrorCount,
+				})
+			}
+
+			result = append(result, api.ListGroup{
+				Type:    list.Type().String(),
+				Group:   group.Group,
+				Entries: group.Entries,
+				Sources: sources,
+			})
+		}
+	}
+
+	return result
+}
+
+// LookupLists returns the entries of all black- and whitelist groups which match the domain
+func (r *BlockingResolver) LookupLists(domain string) (api.ListLookup, error) {
+	domain, err := validateDomain(domain)
+	if err != nil {
+		return api.ListLookup{}, err
+	}
+
+	result := api.ListLookup{Domain: domain}
+
+	for _, list := range []*lists.ListCache{r.blacklistMatcher, r.whitelistMatcher} {
+		for _, entry := range list.Lookup(domain) {
+			result.Matches = append(result.Matches, api.ListLookupMatch{
+				Type:      list.Type().String(),
+				Group:     entry.Group,
+				Source:    entry.Source,
+				Entry:     entry.Entry,
+				Match:     string(entry.Type),
+				Exception: entry.Exception,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// AddBlockingEntry adds a black- or whitelist entry, which is kept on list refresh.
+// Entries with a TTL are removed automatically after they expire
+func (r *BlockingResolver) AddBlockingEntry(entry api.BlockingEntry) error {
+	entry, err := r.validateBlockingEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	err = r.runtimeEntries.add(entry)
+	if err != nil {
+		return err
+	}
+
+	if entry.TTL > 0 {
+		log.Log().Infof("added %s entry '%s' to group '%s' for %s",
+			entry.Type, log.EscapeInput(entry.Domain), entry.Group, entry.TTL)
+	} else {
+		log.Log().Infof("added %s entry '%s' to group '%s'", entry.Type, log.EscapeInput(entry.Domain), entry.Group)
+	}
+
+	return nil
+}
+
+// RemoveBlockingEntry removes a black- or whitelist entry, which was added with `AddBlockingEntry`
+func (r *BlockingResolver) RemoveBlockingEntry(entry api.BlockingEntry) error {
+	entry, err := r.validateBlockingEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	err = r.runtimeEntries.remove(entry)
+	if err != nil {
+		return err
+	}
+
+	log.Log().Infof("removed %s entry '%s' from group '%s'", entry.Type, log.EscapeInput(entry.Domain), entry.Group)
+
+	return nil
+}
+
+// BlockingEntries returns the black- and whitelist entries added with `AddBlockingEntry`
+func (r *BlockingResolver) BlockingEntries() []api.BlockingEntry {
+	return r.runtimeEntries.list()
+}
+
+// validateBlockingEntry checks the type and group of the entry and returns it with the normalized domain
+func (r *BlockingResolver) validateBlockingEntry(entry api.BlockingEntry) (api.BlockingEntry, error) {
+	if entry.Type != api.BlockingEntryDeny && entry.Type != api.BlockingEntryAllow {
+		return entry, fmt.Errorf("unknown entry type '%s'", entry.Type)
+	}
+
+	if !r.isKnownGroup(entry.Group) {
+		return entry, fmt.Errorf("group '%s' is unknown", entry.Group)
+	}
+
+	domain, err := validateDomain(entry.Domain)
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Domain = domain
+
+	return entry, nil
+}
+
+// validateDomain returns the normalized domain or an error if it's empty or contains white space
+func validateDomain(domain string) (string, error) {
+	domain = util.NormalizeDomain(domain)
+
+	if domain == "" || strings.ContainsAny(domain, " \t") {
+		return domain, fmt.Errorf("invalid domain '%s'", domain)
+	}
+
+	return domain, nil
+}
+
+// CheckBlocking checks if the domain would be blocked for the client (name or IP) and which entries match,
+// without resolving the domain. Answers of the upstream (CNAMEs and IPs) are not checked
+func (r *BlockingResolver) CheckBlocking(domain, client string) (api.BlockingCheck, error) {
+	domain, err := validateDomain(domain)
+	if err != nil {
+		return api.BlockingCheck{}, err
+	}
+
+	request := &model.Request{}
+
+	if ip := net.ParseIP(client); ip != nil {
+		request.ClientIP = ip
+	} else if client != "" {
+		request.ClientNames = []string{client}
+	}
+
+	groupsToCheck := r.groupsToCheckForClient(request)
+
+	result := api.BlockingCheck{
+		Domain:       domain,
+		Groups:       groupsToCheck,
+		DenyMatches:  blockingCheckMatches(r.blacklist.Explain(domain, groupsToCheck)),
+		AllowMatches: blockingCheckMatches(r.whitelist.Explain(domain, groupsToCheck)),
+	}
+
+	whitelistOnly := r.whiteListOnlyGroups(groupsToCheck)
+	denied := r.matches(groupsToCheck, r.blacklist, domain)
+
+	if len(result.AllowMatches) > 0 {
+		result.AllowOverride = len(whitelistOnly) > 0 || len(denied) > 0
+
+		return result, nil
+	}
+
+	whitelistOnlyEnforced, whitelistOnlyAudited := r.splitEnforced(whitelistOnly)
+	deniedEnforced, deniedAudited := r.splitEnforced(denied)
+
+	var annotations []string
+
+	switch {
+	case len(whitelistOnlyEnforced) > 0:
+		result.Blocked = true
+		result.Reason = "BLOCKED (WHITELIST ONLY)"
+	case len(deniedEnforced) > 0:
+		result.Blocked = true
+		result.Reason = fmt.Sprintf("BLOCKED (%s)", strings.Join(deniedEnforced, ","))
+	default:
+		if len(whitelistOnlyAudited) > 0 {
+			annotations = append(annotations, "WOULD_BLOCK (WHITELIST ONLY)")
+		}
+
+		if len(deniedAudited) > 0 {
+			annotations = append(annotations, fmt.Sprintf("WOULD_BLOCK (%s)", strings.Join(deniedAudited, ",")))
+		}
+
+		result.Reason = strings.Join(annotations, ", ")
+	}
+
+	return result, nil
+}
+
+func blockingCheckMatches(entries []lists.MatchedEntry) []api.BlockingCheckMatch {
+	result := make([]api.BlockingCheckMatch, 0, len(entries))
+
+	for _, e := range entries {
+		result = append(result, api.BlockingCheckMatch{
+			Group:  e.Group,
+			Source: e.Source,
+			Entry:  e.Entry,
+		})
+	}
+
+	return result
+}
+
+// isKnownGroup returns true if the group has a black- or whitelist or is assigned to a client
+func (r *BlockingResolver) isKnownGroup(group string) bool {
+	if _, ok := r.cfg.BlackLists[group]; ok {
+		return true
+	}
+
+	if _, ok := r.cfg.WhiteLists[group]; ok {
+		return true
+	}
+
+	for _, groups := range r.cfg.ClientGroupsBlock {
+		if slices.Contains(groups, group) {
+			return true
+		}
+	}
+
+	return false
+}
+
 //nolint:prealloc
 func (r *BlockingResolver) retrieveAllBlockingGroups() []string {
 	groups := make(map[string]bool, len(r.cfg.BlackLists))
@@ -200,64 +526,81 @@ func (r *BlockingResolver) retrieveAllBlockingGroups() []string {
 	return result
 }
 
-// EnableBlocking enables the blocking against the blacklists
-func (r *BlockingResolver) EnableBlocking() {
-	r.internalEnableBlocking()
+// EnableBlocking enables the blocking against the blacklists.
+// If client is set, only the blocking of this client, which was disabled before, is enabled again
+func (r *BlockingResolver) EnableBlocking(client string) {
+	r.internalEnableBlocking(client)
 
 	if r.redisClient != nil {
-		r.redisClient.PublishEnabled(&redis.EnabledMessage{State: true})
+		r.redisClient.PublishEnabled(&redis.EnabledMessage{State: true, Client: client})
 	}
 }
 
-func (r *BlockingResolver) internalEnableBlocking() {
+func (r *BlockingResolver) internalEnableBlocking(client string) {
 	s := r.status
 	s.lock.Lock()
 	defer s.lock.Unlock()
+
+	if len(client) > 0 {
+		key := clientKey(client)
+
+		if cs, found := s.disabledClients[key]; found {
+			cs.stopTimer()
+			delete(s.disabledClients, key)
+
+			log.Log().Infof("enable blocking for client '%s'", log.EscapeInput(client))
+		}
+
+		return
+	}
+
 	s.enableTimer.Stop()
 	s.enabled = true
 	s.disabledGroups = []string{}
 
-	evt.Bus().Publish(evt.BlockingEnabledEvent, true)
+	evt.Bus().Publish(evt.BlockingEnabledEvent, r.profile, true)
 }
 
 // DisableBlocking deactivates the blocking for a particular duration (or forever if 0).
-func (r *BlockingResolver) DisableBlocking(duration time.Duration, disableGroups []string) error {
-	err := r.internalDisableBlocking(duration, disableGroups)
+// If client (IP or client name) is set, the blocking is only disabled for this client
+func (r *BlockingResolver) DisableBlocking(duration time.Duration, disableGroups []string, client string) error {
+	err := r.internalDisableBlocking(duration, disableGroups, client)
 	if err == nil && r.redisClient != nil {
 		r.redisClient.PublishEnabled(&redis.EnabledMessage{
 			State:    false,
 			Duration: duration,
 			Groups:   disableGroups,
+			Client:   client,
 		})
 	}
 
 	return err
 }
 
-func (r *BlockingResolver) internalDisableBlocking(duration time.Duration, disableGroups []string) error {
+func (r *BlockingResolver) internalDisableBlocking(
+	duration time.Duration, disableGroups []string, client string,
+) error {
 	s := r.status
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.enableTimer.Stop()
 
-	allBlockingGroups := r.retrieveAllBlockingGroups()
+	if len(client) > 0 {
+		return r.disableBlockingForClient(duration, disableGroups, client)
+	}
 
-	if len(disableGroups) == 0 {
-		s.disabledGroups = allBlockingGroups
-	} else {
-		for _, g := range disableGroups {
-			i := sort.SearchStrings(allBlockingGroups, g)
-			if !(i < len(allBlockingGroups) && allBlockingGroups[i] == g) {
-				return fmt.Errorf("group '%s' is unknown", g)
-			}
-		}
-		s.disabledGroups = disableGroups
+	s.enableTimer.Stop()
+
+	groups, err := r.groupsToDisable(disableGroups)
+	if err != nil {
+		return err
 	}
 
+	s.disabledGroups = groups
+
 	s.enabled = false
-	evt.Bus().Publish(evt.BlockingEnabledEvent, false)
+	evt.Bus().Publish(evt.BlockingEnabledEvent, r.profile, false)
 
-	s.disableEnd = time.Now().Add(duration)
+	s.disableEnd = util.MonotonicNow().Add(duration)
 
 	if duration == 0 {
 		log.Log().Infof("disable blocking for group(s) '%s'", log.EscapeInput(strings.Join(s.disabledGroups, "; ")))
@@ -265,7 +608,7 @@ func (r *BlockingResolver) internalDisableBlocking(duration time.Duration, disab
 		log.Log().Infof("disable blocking for %s for group(s) '%s'", duration,
 			log.EscapeInput(strings.Join(s.disabledGroups, "; ")))
 		s.enableTimer = time.AfterFunc(duration, func() {
-			r.EnableBlocking()
+			r.EnableBlocking("")
 			log.Log().Info("blocking enabled again")
 		})
 	}
@@ -273,6 +616,66 @@ func (r *BlockingResolver) internalDisableBlocking(duration time.Duration, disab
 	return nil
 }
 
+// disableBlockingForClient must be called with the status lock held
+func (r *BlockingResolver) disableBlockingForClient(
+	duration time.Duration, disableGroups []string, client string,
+) error {
+	s := r.status
+
+	groups, err := r.groupsToDisable(disableGroups)
+	if err != nil {
+		return err
+	}
+
+	key := clientKey(client)
+
+	if previous, found := s.disabledClients[key]; found {
+		previous.stopTimer()
+	}
+
+	cs := &clientStatus{disabledGroups: groups}
+
+	if duration == 0 {
+		log.Log().Infof("disable blocking for client '%s' for group(s) '%s'", log.EscapeInput(client),
+			log.EscapeInput(strings.Join(groups, "; ")))
+	} else {
+		log.Log().Infof("disable blocking for %s for client '%s' for group(s) '%s'", duration, log.EscapeInput(client),
+			log.EscapeInput(strings.Join(groups, "; ")))
+
+		cs.disableEnd = util.MonotonicNow().Add(duration)
+		cs.enableTimer = time.AfterFunc(duration, func() {
+			r.EnableBlocking(client)
+			log.Log().Infof("blocking enabled again for client '%s'", log.EscapeInput(client))
+		})
+	}
+
+	if s.disabledClients == nil {
+		s.disabledClients = make(map[string]*clientStatus)
+	}
+
+	s.disabledClients[key] = cs
+
+	return nil
+}
+
+// groupsToDisable validates the groups to disable, all blocking groups if empty
+func (r *BlockingResolver) groupsToDisable(disableGroups []string) ([]string, error) {
+	allBlockingGroups := r.retrieveAllBlockingGroups()
+
+	if len(disableGroups) == 0 {
+		return allBlockingGroups, nil
+	}
+
+	for _, g := range disableGroups {
+		i := sort.SearchStrings(allBlockingGroups, g)
+		if !(i < len(allBlockingGroups) && allBlockingGroups[i] == g) {
+			return nil, fmt.Errorf("group '%s' is unknown", g)
+		}
+	}
+
+	return disableGroups, nil
+}
+
 // BlockingStatus returns the current blocking status
 func (r *BlockingResolver) BlockingStatus() api.BlockingStatus {
 	var autoEnableDuration time.Duration
@@ -280,17 +683,47 @@ func (r *BlockingResolver) BlockingStatus() api.BlockingStatus {
 	r.status.lock.RLock()
 	defer r.status.lock.RUnlock()
 
-	if !r.status.enabled && r.status.disableEnd.After(time.Now()) {
-		autoEnableDuration = time.Until(r.status.disableEnd)
+	if remaining := util.MonotonicUntil(r.status.disableEnd); !r.status.enabled && remaining > 0 {
+		autoEnableDuration = remaining
 	}
 
 	return api.BlockingStatus{
 		Enabled:         r.status.enabled,
 		DisabledGroups:  r.status.disabledGroups,
 		AutoEnableInSec: int(autoEnableDuration.Seconds()),
+		DisabledClients: r.disabledClients(),
 	}
 }
 
+// disabledClients must be called with the status lock held
+func (r *BlockingResolver) disabledClients() []api.DisabledClient {
+	if len(r.status.disabledClients) == 0 {
+		return nil
+	}
+
+	result := make([]api.DisabledClient, 0, len(r.status.disabledClients))
+
+	for client, cs := range r.status.disabledClients {
+		var autoEnableDuration time.Duration
+
+		if remaining := util.MonotonicUntil(cs.disableEnd); cs.enableTimer != nil && remaining > 0 {
+			autoEnableDuration = remaining
+		}
+
+		result = append(result, api.DisabledClient{
+			Client:          client,
+			DisabledGroups:  cs.disabledGroups,
+			AutoEnableInSec: int(autoEnableDuration.Seconds()),
+		})
+	}
+
+	slices.SortFunc(result, func(a, b api.DisabledClient) int {
+		return strings.Compare(a.Client, b.Client)
+	})
+
+	return result
+}
+
 // returns groups, which have only whitelist entries
 func determineWhitelistOnlyGroups(cfg *config.BlockingConfig) (result map[string]bool) {
 	result = make(map[string]bool, len(cfg.WhiteLists))
@@ -308,18 +741,92 @@ func determineWhitelistOnlyGroups(cfg *config.BlockingConfig) (result map[string
 
 // sets answer and/or return code for DNS response, if request should be blocked
 func (r *BlockingResolver) handleBlocked(logger *logrus.Entry,
-	request *model.Request, question dns.Question, reason string,
+	request *model.Request, question dns.Question, handler blockHandler, reason string,
 ) (*model.Response, error) {
 	response := new(dns.Msg)
 	response.SetReply(request.Req)
 
-	r.blockHandler.handleBlock(question, response)
+	if self, ok := handler.(selfBlockHandler); ok {
+		handler = self.forClient(request.ClientIP)
+	}
+
+	handler.handleBlock(question, response)
 
 	logger.Debugf("blocking request '%s'", reason)
 
 	return &model.Response{Res: response, RType: model.ResponseTypeBLOCKED, Reason: reason}, nil
 }
 
+// blockGroupFor returns the group whose block type is used for the blocking groups:
+// if several groups override the global block type, the alphabetically first one is used. Empty if none does
+func (r *BlockingResolver) blockGroupFor(groups []string) string {
+	var name string
+
+	for _, group := range groups {
+		if _, ok := r.groupBlockHandlers[group]; ok && (name == "" || group < name) {
+			name = group
+		}
+	}
+
+	return name
+}
+
+// blockHandlerFor returns the block handler of the blocking groups, see `blockGroupFor`
+func (r *BlockingResolver) blockHandlerFor(groups []string) blockHandler {
+	if handler, ok := r.groupBlockHandlers[r.blockGroupFor(groups)]; ok {
+		return handler
+	}
+
+	return r.blockHandler
+}
+
+// redirectHandler returns a block handler which answers with the IPs of the domain's entries in the sources with
+// `useListIPs`, and the IPs it answers with. The handler is nil if there are no IPs for the type of the question
+func (r *BlockingResolver) redirectHandler(
+	question dns.Question, domain string, groups []string,
+) (blockHandler, []net.IP) {
+	var ips []net.IP
+
+	for _, ip := range r.blacklistMatcher.ListIPs(domain, groups) {
+		if (question.Qtype == dns.TypeA && ip.To4() != nil) || (question.Qtype == dns.TypeAAAA && ip.To4() == nil) {
+			ips = append(ips, ip)
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, nil
+	}
+
+	_, blockTTL := r.cfg.GroupBlockType(r.blockGroupFor(groups))
+
+	return ipBlockHandler{
+		destinations:    ips,
+		fallbackHandler: r.blockHandlerFor(groups),
+		BlockTimeSec:    blockTTL.SecondsU32(),
+	}, ips
+}
+
+// handleBlockedDomain blocks the request of a domain on the blacklists of the groups,
+// the IPs of sources with `useListIPs` take precedence over the block type
+func (r *BlockingResolver) handleBlockedDomain(logger *logrus.Entry,
+	request *model.Request, question dns.Question, domain string, groups []string,
+) (*model.Response, error) {
+	reason := fmt.Sprintf("BLOCKED (%s)", strings.Join(groups, ","))
+
+	handler, ips := r.redirectHandler(question, domain, groups)
+	if handler == nil {
+		return r.handleBlocked(logger, request, question, r.blockHandlerFor(groups), reason)
+	}
+
+	targets := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		targets = append(targets, ip.String())
+	}
+
+	return r.handleBlocked(logger, request, question, handler,
+		fmt.Sprintf("%s -> %s", reason, strings.Join(targets, ",")))
+}
+
 // LogConfig implements `config.Configurable`.
 func (r *BlockingResolver) LogConfig(logger *logrus.Entry) {
 	r.cfg.LogConfig(logger)
@@ -331,57 +838,129 @@ func (r *BlockingResolver) LogConfig(logger *logrus.Entry) {
 	log.WithIndent(logger, "  ", r.whitelistMatcher.LogConfig)
 }
 
-func (r *BlockingResolver) hasWhiteListOnlyAllowed(groupsToCheck []string) bool {
+func (r *BlockingResolver) whiteListOnlyGroups(groupsToCheck []string) (groups []string) {
 	for _, group := range groupsToCheck {
 		if _, found := r.whitelistOnlyGroups[group]; found {
-			return true
+			groups = append(groups, group)
 		}
 	}
 
-	return false
+	return groups
+}
+
+// splitEnforced splits matched groups into the enforced ones and the ones which are only audited
+func (r *BlockingResolver) splitEnforced(groups []string) (enforced, audited []string) {
+	for _, group := range groups {
+		if r.cfg.IsEnforced(group) {
+			enforced = append(enforced, group)
+		} else {
+			audited = append(audited, group)
+		}
+	}
+
+	return enforced, audited
+}
+
+// wouldBlock counts a match of audited groups and adds the annotation for the response reason,
+// e.g. several answer IPs matching the same groups are counted once
+func (r *BlockingResolver) wouldBlock(
+	logger *logrus.Entry, annotations []string, groups []string, annotation string,
+) []string {
+	if slices.Contains(annotations, annotation) {
+		return annotations
+	}
+
+	for _, group := range groups {
+		evt.Bus().Publish(evt.BlockingAuditMatch, r.profile, group)
+	}
+
+	logger.WithField("groups", groups).Debugf("not blocking request '%s', groups are not enforced", annotation)
+
+	return append(annotations, annotation)
 }
 
 func (r *BlockingResolver) handleBlacklist(groupsToCheck []string,
 	request *model.Request, logger *logrus.Entry,
-) (bool, *model.Response, error) {
+) (handled bool, resp *model.Response, annotations []string, err error) {
 	logger.WithField("groupsToCheck", strings.Join(groupsToCheck, "; ")).Debug("checking groups for request")
-	whitelistOnlyAllowed := r.hasWhiteListOnlyAllowed(groupsToCheck)
+	whitelistOnlyEnforced, whitelistOnlyAudited := r.splitEnforced(r.whiteListOnlyGroups(groupsToCheck))
 
 	for _, question := range request.Req.Question {
 		domain := util.ExtractDomain(question)
 		logger := logger.WithField("domain", domain)
 
-		if groups := r.matches(groupsToCheck, r.whitelistMatcher, domain); len(groups) > 0 {
+		if groups := r.matches(groupsToCheck, r.whitelist, domain); len(groups) > 0 {
 			logger.WithField("groups", groups).Debugf("domain is whitelisted")
 
 			resp, err := r.next.Resolve(request)
 
-			return true, resp, err
+			return true, resp, nil, err
 		}
 
-		if whitelistOnlyAllowed {
-			resp, err := r.handleBlocked(logger, request, question, "BLOCKED (WHITELIST ONLY)")
+		if len(whitelistOnlyEnforced) > 0 {
+			resp, err := r.handleBlocked(logger, request, question, r.blockHandlerFor(whitelistOnlyEnforced),
+				"BLOCKED (WHITELIST ONLY)")
+
+			return true, resp, nil, err
+		}
 
-			return true, resp, err
+		if len(whitelistOnlyAudited) > 0 {
+			annotations = r.wouldBlock(logger, annotations, whitelistOnlyAudited, "WOULD_BLOCK (WHITELIST ONLY)")
 		}
 
-		if groups := r.matches(groupsToCheck, r.blacklistMatcher, domain); len(groups) > 0 {
-			resp, err := r.handleBlocked(logger, request, question, fmt.Sprintf("BLOCKED (%s)", strings.Join(groups, ",")))
+		if groups := r.matches(groupsToCheck, r.blacklist, domain); len(groups) > 0 {
+			enforced, audited := r.splitEnforced(groups)
 
-			return true, resp, err
+			if len(enforced) > 0 {
+				resp, err := r.handleBlockedDomain(logger, request, question, domain, enforced)
+
+				return true, resp, nil, err
+			}
+
+			annotations = r.wouldBlock(logger, annotations, audited,
+				fmt.Sprintf("WOULD_BLOCK (%s)", strings.Join(audited, ",")))
 		}
 	}
 
-	return false, nil, nil
+	return false, nil, annotations, nil
 }
 
 // Resolve checks the query against the blacklist and delegates to next resolver if domain is not blocked
 func (r *BlockingResolver) Resolve(request *model.Request) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, "blacklist_resolver")
-	groupsToCheck := r.groupsToCheckForClient(request)
+	groupsToCheck := r.groupsForQueryType(r.groupsToCheckForClient(request), request)
+
+	resp, err := r.resolve(request, groupsToCheck, logger)
+
+	if err == nil && r.cfg.AnnotateDisabled && resp.RType != model.ResponseTypeBLOCKED {
+		resp = r.annotateDisabled(logger, request, groupsToCheck, resp)
+	}
+
+	if err == nil && r.cfg.AnnotateOverrides && resp.RType != model.ResponseTypeBLOCKED {
+		if override := r.blockOverride(request, groupsToCheck); override != "" {
+			annotated := *resp
+			annotated.BlockOverride = override
+
+			return &annotated, nil
+		}
+	}
+
+	return resp, err
+}
+
+func (r *BlockingResolver) resolve(request *model.Request, groupsToCheck []string,
+	logger *logrus.Entry,
+) (*model.Response, error) {
+	var annotations []string
 
 	if len(groupsToCheck) > 0 {
-		handled, resp, err := r.handleBlacklist(groupsToCheck, request, logger)
+		var (
+			handled bool
+			resp    *model.Response
+			err     error
+		)
+
+		handled, resp, annotations, err = r.handleBlacklist(groupsToCheck, request, logger)
 		if handled {
 			return resp, err
 		}
@@ -392,22 +971,129 @@ func (r *BlockingResolver) Resolve(request *model.Request) (*model.Response, err
 	if err == nil && len(groupsToCheck) > 0 && respFromNext.Res != nil {
 		for _, rr := range respFromNext.Res.Answer {
 			entryToCheck, tName := extractEntryToCheckFromResponse(rr)
+			if tName == "CNAME" && !r.cfg.CheckCnames {
+				continue
+			}
+
 			if len(entryToCheck) > 0 {
 				logger := logger.WithField("response_entry", entryToCheck)
 
-				if groups := r.matches(groupsToCheck, r.whitelistMatcher, entryToCheck); len(groups) > 0 {
+				if groups := r.matches(groupsToCheck, r.whitelist, entryToCheck); len(groups) > 0 {
 					logger.WithField("groups", groups).Debugf("%s is whitelisted", tName)
-				} else if groups := r.matches(groupsToCheck, r.blacklistMatcher, entryToCheck); len(groups) > 0 {
-					return r.handleBlocked(logger, request, request.Req.Question[0], fmt.Sprintf("BLOCKED %s (%s)", tName,
-						strings.Join(groups, ",")))
+				} else if groups := r.matches(groupsToCheck, r.blacklist, entryToCheck); len(groups) > 0 {
+					enforced, audited := r.splitEnforced(groups)
+
+					if len(enforced) > 0 {
+						return r.handleBlocked(logger, request, request.Req.Question[0], r.blockHandlerFor(enforced),
+							fmt.Sprintf("BLOCKED %s (%s)", tName, strings.Join(enforced, ",")))
+					}
+
+					annotations = r.wouldBlock(logger, annotations, audited,
+						fmt.Sprintf("WOULD_BLOCK %s (%s)", tName, strings.Join(audited, ",")))
 				}
 			}
 		}
+
+		respFromNext, annotations = r.stripBlockedHints(logger, groupsToCheck, respFromNext, annotations)
+	}
+
+	if err == nil && len(annotations) > 0 {
+		annotated := *respFromNext
+		annotated.Reason = fmt.Sprintf("%s, %s", respFromNext.Reason, strings.Join(annotations, ", "))
+
+		return &annotated, nil
 	}
 
 	return respFromNext, err
 }
 
+// annotateDisabled annotates the reason of a response, which would be blocked by a group with disabled blocking.
+// The disabled groups are checked the same way as groups in audit mode, after the query was resolved
+func (r *BlockingResolver) annotateDisabled(logger *logrus.Entry,
+	request *model.Request, groupsToCheck []string, resp *model.Response,
+) *model.Response {
+	disabled := r.disabledGroupsOf(request, groupsToCheck)
+	if len(disabled) == 0 {
+		return resp
+	}
+
+	annotation, groups := r.disabledMatch(request, groupsToCheck, disabled, resp)
+	if annotation == "" {
+		return resp
+	}
+
+	for _, group := range groups {
+		evt.Bus().Publish(evt.BlockingDisabledMatch, r.profile, group)
+	}
+
+	logger.WithField("groups", groups).Debugf("not blocking request '%s', blocking is disabled", annotation)
+
+	annotated := *resp
+	annotated.Reason = fmt.Sprintf("%s, %s", resp.Reason, annotation)
+
+	return &annotated
+}
+
+// disabledGroupsOf returns the enforced groups of the request with disabled blocking
+func (r *BlockingResolver) disabledGroupsOf(request *model.Request, groupsToCheck []string) []string {
+	var result []string
+
+	for _, group := range r.groupsForQueryType(r.assignedGroups(request), request) {
+		if r.cfg.IsEnforced(group) && !slices.Contains(groupsToCheck, group) && !slices.Contains(result, group) {
+			result = append(result, group)
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// disabledMatch returns the annotation and the disabled groups, if the query would be blocked by them.
+// The whitelists of all groups of the request apply, since they would also if blocking was enabled
+func (r *BlockingResolver) disabledMatch(
+	request *model.Request, groupsToCheck, disabled []string, resp *model.Response,
+) (annotation string, groups []string) {
+	allGroups := append(slices.Clone(groupsToCheck), disabled...)
+
+	for _, question := range request.Req.Question {
+		domain := util.ExtractDomain(question)
+
+		if len(r.matches(allGroups, r.whitelist, domain)) > 0 {
+			return "", nil
+		}
+
+		if groups := r.whiteListOnlyGroups(disabled); len(groups) > 0 {
+			return "BLOCKING_DISABLED (WHITELIST ONLY)", groups
+		}
+
+		if groups := r.matches(disabled, r.blacklist, domain); len(groups) > 0 {
+			return fmt.Sprintf("BLOCKING_DISABLED (%s)", strings.Join(groups, ",")), groups
+		}
+	}
+
+	if resp.Res == nil {
+		return "", nil
+	}
+
+	for _, rr := range resp.Res.Answer {
+		entryToCheck, tName := extractEntryToCheckFromResponse(rr)
+		if len(entryToCheck) == 0 || (tName == "CNAME" && !r.cfg.CheckCnames) {
+			continue
+		}
+
+		if len(r.matches(allGroups, r.whitelist, entryToCheck)) > 0 {
+			continue
+		}
+
+		if groups := r.matches(disabled, r.blacklist, entryToCheck); len(groups) > 0 {
+			return fmt.Sprintf("BLOCKING_DISABLED %s (%s)", tName, strings.Join(groups, ",")), groups
+		}
+	}
+
+	return "", nil
+}
+
 func extractEntryToCheckFromResponse(rr dns.RR) (entryToCheck, tName string) {
 	switch v := rr.(type) {
 	case *dns.A:
@@ -424,6 +1110,124 @@ func extractEntryToCheckFromResponse(rr dns.RR) (entryToCheck, tName string) {
 	return
 }
 
+// stripBlockedHints removes the IP hints of HTTPS and SVCB records, if their target or a hint IP is blocked:
+// clients could connect to the hint IPs without resolving the blocked A and AAAA records
+func (r *BlockingResolver) stripBlockedHints(logger *logrus.Entry, groupsToCheck []string,
+	resp *model.Response, annotations []string,
+) (*model.Response, []string) {
+	var msg *dns.Msg
+
+	for i, rr := range resp.Res.Answer {
+		svcb := svcbOf(rr)
+		if svcb == nil {
+			continue
+		}
+
+		groups := r.blockedHintGroups(groupsToCheck, svcb)
+		if len(groups) == 0 {
+			continue
+		}
+
+		enforced, audited := r.splitEnforced(groups)
+
+		if len(enforced) == 0 {
+			annotations = r.wouldBlock(logger, annotations, audited,
+				fmt.Sprintf("WOULD_STRIP HINTS (%s)", strings.Join(audited, ",")))
+
+			continue
+		}
+
+		if msg == nil {
+			// the response can be shared, e.g. by the cache
+			msg = resp.Res.Copy()
+		}
+
+		stripHints(svcbOf(msg.Answer[i]))
+
+		logger.WithField("groups", enforced).Debugf("stripped IP hints of %s", rr.Header().Name)
+
+		annotation := fmt.Sprintf("STRIPPED HINTS (%s)", strings.Join(enforced, ","))
+		if !slices.Contains(annotations, annotation) {
+			annotations = append(annotations, annotation)
+		}
+	}
+
+	if msg == nil {
+		return resp, annotations
+	}
+
+	stripped := *resp
+	stripped.Res = msg
+
+	return &stripped, annotations
+}
+
+// blockedHintGroups returns the groups blocking the target or a hint IP of a SVCB record with IP hints
+func (r *BlockingResolver) blockedHintGroups(groupsToCheck []string, svcb *dns.SVCB) []string {
+	var entries []string
+
+	for _, kv := range svcb.Value {
+		switch v := kv.(type) {
+		case *dns.SVCBIPv4Hint:
+			for _, ip := range v.Hint {
+				entries = append(entries, ip.String())
+			}
+		case *dns.SVCBIPv6Hint:
+			for _, ip := range v.Hint {
+				entries = append(entries, strings.ToLower(ip.String()))
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// "." is the owner name, which is the queried domain
+	if target := util.ExtractDomainOnly(svcb.Target); target != "" {
+		entries = append(entries, target)
+	}
+
+	var result []string
+
+	for _, entry := range entries {
+		if len(r.matches(groupsToCheck, r.whitelist, entry)) > 0 {
+			continue
+		}
+
+		for _, group := range r.matches(groupsToCheck, r.blacklist, entry) {
+			if !slices.Contains(result, group) {
+				result = append(result, group)
+			}
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// svcbOf returns the SVCB record of HTTPS and SVCB records, nil for other records
+func svcbOf(rr dns.RR) *dns.SVCB {
+	switch v := rr.(type) {
+	case *dns.SVCB:
+		return v
+	case *dns.HTTPS:
+		return &v.SVCB
+	}
+
+	return nil
+}
+
+// stripHints removes the ipv4hint and ipv6hint parameters of the record
+func stripHints(svcb *dns.SVCB) {
+	svcb.Value = slices.DeleteFunc(svcb.Value, func(kv dns.SVCBKeyValue) bool {
+		key := kv.Key()
+
+		return key == dns.SVCB_IPV4HINT || key == dns.SVCB_IPV6HINT
+	})
+}
+
 func (r *BlockingResolver) isGroupDisabled(group string) bool {
 	r.status.lock.RLock()
 	defer r.status.lock.RUnlock()
@@ -437,11 +1241,75 @@ func (r *BlockingResolver) isGroupDisabled(group string) bool {
 	return false
 }
 
+// groupsForQueryType removes the groups which are limited to other query types than the one of the request
+func (r *BlockingResolver) groupsForQueryType(groups []string, request *model.Request) []string {
+	if len(request.Req.Question) == 0 {
+		return groups
+	}
+
+	qType := dns.Type(request.Req.Question[0].Qtype)
+
+	return slices.DeleteFunc(groups, func(group string) bool {
+		return !r.cfg.AppliesToQueryType(group, qType)
+	})
+}
+
+// isGroupDisabledForClient checks if the blocking of the group is disabled globally or for the client of the request
+func (r *BlockingResolver) isGroupDisabledForClient(group string, request *model.Request) bool {
+	r.status.lock.RLock()
+	defer r.status.lock.RUnlock()
+
+	return slices.Contains(r.status.disabledGroups, group) || slices.Contains(r.clientDisabledGroups(request), group)
+}
+
+// clientDisabledGroups returns the groups with disabled blocking for the client of the request.
+// Must be called with the status lock held
+func (r *BlockingResolver) clientDisabledGroups(request *model.Request) []string {
+	if len(r.status.disabledClients) == 0 {
+		return nil
+	}
+
+	var result []string
+
+	if request.ClientIP != nil {
+		if cs, found := r.status.disabledClients[request.ClientIP.String()]; found {
+			result = append(result, cs.disabledGroups...)
+		}
+	}
+
+	for _, name := range request.ClientNames {
+		if cs, found := r.status.disabledClients[strings.ToLower(name)]; found {
+			result = append(result, cs.disabledGroups...)
+		}
+	}
+
+	return result
+}
+
 // returns groups which should be checked for client's request
 func (r *BlockingResolver) groupsToCheckForClient(request *model.Request) []string {
+	groups := r.assignedGroups(request)
+
 	r.status.lock.RLock()
 	defer r.status.lock.RUnlock()
 
+	clientDisabledGroups := r.clientDisabledGroups(request)
+
+	var result []string
+
+	for _, g := range groups {
+		if !r.isGroupDisabled(g) && !slices.Contains(clientDisabledGroups, g) {
+			result = append(result, g)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// assignedGroups returns the groups assigned to the client of the request by `clientGroupsBlock`
+func (r *BlockingResolver) assignedGroups(request *model.Request) []string {
 	var groups []string
 	// try client names
 	for _, cName := range request.ClientNames {
@@ -480,14 +1348,85 @@ func (r *BlockingResolver) groupsToCheckForClient(request *model.Request) []stri
 		groups = r.clientGroupsBlock["default"]
 	}
 
-	var result []string
+	return groups
+}
 
-	for _, g := range groups {
-		if !r.isGroupDisabled(g) {
-			result = append(result, g)
+// blockOverride names why the deny matches of the requested domain didn't block the query, empty if there are none.
+// groupsToCheck are the groups the request was checked against
+func (r *BlockingResolver) blockOverride(request *model.Request, groupsToCheck []string) string {
+	if len(request.Req.Question) == 0 {
+		return ""
+	}
+
+	question := request.Req.Question[0]
+	domain := util.ExtractDomain(question)
+
+	denied := r.blacklist.Match(domain, r.denyGroups())
+	if len(denied) == 0 {
+		return ""
+	}
+
+	assigned := r.assignedGroups(request)
+	qType := dns.Type(question.Qtype)
+
+	var unassigned, disabled, otherQueryType, audited, checked []string
+
+	for _, group := range denied {
+		switch {
+		case !slices.Contains(assigned, group):
+			unassigned = append(unassigned, group)
+		case r.isGroupDisabledForClient(group, request):
+			disabled = append(disabled, group)
+		case !r.cfg.AppliesToQueryType(group, qType):
+			otherQueryType = append(otherQueryType, group)
+		case !r.cfg.IsEnforced(group):
+			audited = append(audited, group)
+		default:
+			checked = append(checked, group)
+		}
+	}
+
+	var overrides []string
+
+	if len(checked) > 0 {
+		// the deny match of a checked group can only be overridden by a whitelist
+		for _, match := range r.whitelist.Explain(domain, groupsToCheck) {
+			overrides = append(overrides, fmt.Sprintf("WHITELIST (%s: %s)", match.Group, match.Entry))
+		}
+	}
+
+	for _, o := range []struct {
+		name   string
+		groups []string
+	}{
+		{"AUDIT", audited},
+		{"DISABLED", disabled},
+		{"QUERY TYPE", otherQueryType},
+		{"NOT ASSIGNED", unassigned},
+	} {
+		if len(o.groups) > 0 {
+			overrides = append(overrides, fmt.Sprintf("%s (%s)", o.name, strings.Join(o.groups, ",")))
 		}
 	}
 
+	return strings.Join(overrides, ", ")
+}
+
+// denyGroups returns all groups, which can have deny entries
+func (r *BlockingResolver) denyGroups() []string {
+	groups := make(map[string]struct{}, len(r.cfg.BlackLists))
+
+	for group := range r.cfg.BlackLists {
+		groups[group] = struct{}{}
+	}
+
+	for _, assigned := range r.cfg.ClientGroupsBlock {
+		for _, group := range assigned {
+			groups[group] = struct{}{}
+		}
+	}
+
+	result := maps.Keys(groups)
 	sort.Strings(result)
 
 	return result
@@ -511,7 +1450,9 @@ type zeroIPBlockHandler struct {
 	BlockTimeSec uint32
 }
 
-type nxDomainBlockHandler struct{}
+type nxDomainBlockHandler struct {
+	BlockTimeSec uint32
+}
 
 type ipBlockHandler struct {
 	destinations    []net.IP
@@ -519,6 +1460,11 @@ type ipBlockHandler struct {
 	BlockTimeSec    uint32
 }
 
+// selfBlockHandler answers with the IP of blocky, which is used to reach the client
+type selfBlockHandler struct {
+	BlockTimeSec uint32
+}
+
 func (b zeroIPBlockHandler) handleBlock(question dns.Question, response *dns.Msg) {
 	var zeroIP net.IP
 
@@ -538,8 +1484,69 @@ func (b zeroIPBlockHandler) handleBlock(question dns.Question, response *dns.Msg
 	response.Answer = append(response.Answer, rr)
 }
 
-func (b nxDomainBlockHandler) handleBlock(_ dns.Question, response *dns.Msg) {
+// handleBlock answers with an authoritative NXDOMAIN, the SOA record lets clients cache it for the block TTL
+// (RFC 2308: the negative TTL is the minimum of the SOA TTL and its MINIMUM field)
+func (b nxDomainBlockHandler) handleBlock(question dns.Question, response *dns.Msg) {
 	response.Rcode = dns.RcodeNameError
+	response.Authoritative = true
+	response.Ns = []dns.RR{&dns.SOA{
+		Hdr: dns.RR_Header{
+			Name:   question.Name,
+			Rrtype: dns.TypeSOA,
+			Class:  dns.ClassINET,
+			Ttl:    b.BlockTimeSec,
+		},
+		Ns:      "blocky.",
+		Mbox:    "blocky.",
+		Serial:  1,
+		Refresh: b.BlockTimeSec,
+		Retry:   b.BlockTimeSec,
+		Expire:  b.BlockTimeSec,
+		Minttl:  b.BlockTimeSec,
+	}}
+}
+
+// handleBlock is only used without client, `forClient` returns the handler with blocky's IP
+func (b selfBlockHandler) handleBlock(question dns.Question, response *dns.Msg) {
+	zeroIPBlockHandler(b).handleBlock(question, response)
+}
+
+// forClient returns a handler which answers with the local IP of the route to the client.
+// It falls back to zero IPs, if the IP is unknown or of the other IP version than the query
+func (b selfBlockHandler) forClient(clientIP net.IP) blockHandler {
+	fallback := zeroIPBlockHandler(b)
+
+	ip := localIPFor(clientIP)
+	if ip == nil {
+		return fallback
+	}
+
+	return ipBlockHandler{
+		destinations:    []net.IP{ip},
+		BlockTimeSec:    b.BlockTimeSec,
+		fallbackHandler: fallback,
+	}
+}
+
+// localIPFor returns the source IP of the route to the client, nil if there is none.
+// Connecting a UDP socket only selects the route, no packet is sent
+func localIPFor(clientIP net.IP) net.IP {
+	if clientIP == nil {
+		return nil
+	}
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: clientIP, Port: 53})
+	if err != nil {
+		return nil
+	}
+
+	defer conn.Close()
+
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+		return addr.IP
+	}
+
+	return nil
 }
 
 func (b ipBlockHandler) handleBlock(question dns.Question, response *dns.Msg) {
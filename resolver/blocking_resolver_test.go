@@ -1,9 +1,13 @@
 package resolver
 
 import (
+	"context"
+	"os"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
 	. "github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/lists"
@@ -50,8 +54,16 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 		sutConfig  config.BlockingConfig
 		m          *mockResolver
 		mockAnswer *dns.Msg
+		sutCtx     context.Context
 	)
 
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+
+		sutCtx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
 	Describe("Type", func() {
 		It("follows conventions", func() {
 			expectValidResolverType(sut)
@@ -72,7 +84,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 
 		m = &mockResolver{}
 		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
-		sut, err = NewBlockingResolver(sutConfig, nil, systemResolverBootstrap)
+		sut, err = NewBlockingResolver(sutCtx, sutConfig, nil, systemResolverBootstrap, "")
 		Expect(err).Should(Succeed())
 		sut.Next(m)
 	})
@@ -107,16 +119,20 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 		When("List is refreshed", func() {
 			It("event should be fired", func() {
 				groupCnt := make(map[string]int)
-				err := Bus().Subscribe(BlockingCacheGroupChanged, func(listType lists.ListCacheType, group string, cnt int) {
-					groupCnt[group] = cnt
-				})
+				fn := func(profile string, _ lists.ListCacheType, group string, cnt int) {
+					groupCnt[profile+"/"+group] = cnt
+				}
+				err := Bus().Subscribe(BlockingCacheGroupChanged, fn)
 				Expect(err).Should(Succeed())
+				DeferCleanup(func() {
+					Expect(Bus().Unsubscribe(BlockingCacheGroupChanged, fn)).Should(Succeed())
+				})
 
 				// recreate to trigger a reload
-				sut, err = NewBlockingResolver(sutConfig, nil, systemResolverBootstrap)
+				sut, err = NewBlockingResolver(sutCtx, sutConfig, nil, systemResolverBootstrap, "lab")
 				Expect(err).Should(Succeed())
 
-				Eventually(groupCnt, "1s").Should(HaveLen(2))
+				Eventually(groupCnt, "1s").Should(SatisfyAll(HaveKey("lab/gr1"), HaveKey("lab/gr2")))
 			})
 		})
 	})
@@ -209,7 +225,8 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 					"wildcard[0-9]*":  {"gr1"},
 					"default":         {"defaultGroup"},
 				},
-				BlockType: "ZeroIP",
+				BlockType:   "ZeroIP",
+				CheckCnames: true,
 			}
 		})
 
@@ -420,6 +437,31 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 							HaveReason("BLOCKED (defaultGroup)"),
 						))
 			})
+
+			DescribeTable("should return an authoritative NXDOMAIN with the block TTL for all query types",
+				func(qType dns.Type) {
+					resp, err := sut.Resolve(newRequestWithClient("blocked3.com.", qType, "1.2.1.2", "unknown"))
+					Expect(err).Should(Succeed())
+
+					Expect(resp).Should(SatisfyAll(
+						HaveNoAnswer(),
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReturnCode(dns.RcodeNameError),
+						HaveReason("BLOCKED (defaultGroup)"),
+					))
+					Expect(resp.Res.Authoritative).Should(BeTrue())
+					Expect(resp.Res.Ns).Should(HaveLen(1))
+
+					soa, ok := resp.Res.Ns[0].(*dns.SOA)
+					Expect(ok).Should(BeTrue())
+					Expect(soa.Hdr.Name).Should(Equal("blocked3.com."))
+					Expect(soa.Hdr.Ttl).Should(BeNumerically("==", 60))
+					Expect(soa.Minttl).Should(BeNumerically("==", 60))
+				},
+				Entry("A", A),
+				Entry("AAAA", AAAA),
+				Entry("HTTPS", HTTPS),
+			)
 		})
 
 		When("BlockTTL is set", func() {
@@ -533,6 +575,50 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 			})
 		})
 
+		When("BlockType is SELF", func() {
+			BeforeEach(func() {
+				sutConfig = config.BlockingConfig{
+					BlackLists: map[string][]config.BytesSource{
+						"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+					},
+					ClientGroupsBlock: map[string][]string{
+						"default": {"defaultGroup"},
+					},
+					BlockType: "self",
+					BlockTTL:  config.Duration(time.Minute),
+				}
+			})
+
+			It("should return the IP of the route to the client", func() {
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "127.0.0.1", "unknown"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("blocked3.com.", A, "127.0.0.1"),
+							HaveTTL(BeNumerically("==", 60)),
+							HaveResponseType(ResponseTypeBLOCKED),
+							HaveReason("BLOCKED (defaultGroup)"),
+						))
+			})
+
+			It("should use fallback for the other IP version and return zero ip", func() {
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", AAAA, "127.0.0.1", "unknown"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("blocked3.com.", AAAA, "::"),
+							HaveResponseType(ResponseTypeBLOCKED),
+						))
+			})
+
+			It("should use fallback without client IP", func() {
+				Expect(sut.Resolve(newRequest("blocked3.com.", A))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("blocked3.com.", A, "0.0.0.0"),
+							HaveResponseType(ResponseTypeBLOCKED),
+						))
+			})
+		})
+
 		When("Blacklist contains IP", func() {
 			When("IP4", func() {
 				BeforeEach(func() {
@@ -573,25 +659,702 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 			})
 		})
 
-		When("blacklist contains domain which is CNAME in response", func() {
+		When("Blacklist contains network", func() {
+			BeforeEach(func() {
+				networkFile := tmpDir.CreateStringFile("networkFile", "198.51.100.0/24", "2001:db8:1::/48")
+				Expect(networkFile.Error).Should(Succeed())
+
+				sutConfig.BlackLists["defaultGroup"] = config.NewBytesSources(networkFile.Path)
+				sutConfig.WhiteLists = map[string][]config.BytesSource{
+					"defaultGroup": {config.TextBytesSource("allowed.com")},
+				}
+			})
+
+			When("the lookup result contains an IP of the network", func() {
+				BeforeEach(func() {
+					mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "198.51.100.17")
+				})
+
+				It("should block query", func() {
+					Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								BeDNSRecord("example.com.", A, "0.0.0.0"),
+								HaveResponseType(ResponseTypeBLOCKED),
+								HaveReason("BLOCKED IP (defaultGroup)"),
+							))
+				})
+			})
+
+			When("the lookup result contains an IPv6 of the network", func() {
+				BeforeEach(func() {
+					mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, AAAA, "2001:db8:1:2::1")
+				})
+
+				It("should block query", func() {
+					Expect(sut.Resolve(newRequestWithClient("example.com.", AAAA, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								BeDNSRecord("example.com.", AAAA, "::"),
+								HaveResponseType(ResponseTypeBLOCKED),
+								HaveReason("BLOCKED IP (defaultGroup)"),
+							))
+				})
+			})
+
+			When("the lookup result is outside of the network", func() {
+				BeforeEach(func() {
+					mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "198.51.101.17")
+				})
+
+				It("should not block query", func() {
+					Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+						Should(HaveResponseType(ResponseTypeRESOLVED))
+				})
+			})
+
+			When("the domain is whitelisted", func() {
+				BeforeEach(func() {
+					mockAnswer, _ = util.NewMsgWithAnswer("allowed.com.", 300, A, "198.51.100.17")
+				})
+
+				It("should not block query", func() {
+					Expect(sut.Resolve(newRequestWithClient("allowed.com.", A, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								BeDNSRecord("allowed.com.", A, "198.51.100.17"),
+								HaveResponseType(ResponseTypeRESOLVED),
+							))
+				})
+			})
+
+			When("the lookup result contains a HTTPS record with a hint of the network", func() {
+				BeforeEach(func() {
+					rr, err := dns.NewRR(
+						"example.com. 300 IN HTTPS 1 . alpn=h2 ipv4hint=198.51.101.1,198.51.100.17 ipv6hint=2001:db8:2::1 port=8443")
+					Expect(err).Should(Succeed())
+
+					mockAnswer = new(dns.Msg)
+					mockAnswer.Answer = []dns.RR{rr}
+				})
+
+				It("should strip the hints of the record", func() {
+					resp, err := sut.Resolve(newRequestWithClient("example.com.", HTTPS, "1.2.1.2", "unknown"))
+					Expect(err).Should(Succeed())
+
+					Expect(resp).Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReturnCode(dns.RcodeSuccess),
+						HaveReason(", STRIPPED HINTS (defaultGroup)"),
+					))
+					Expect(resp.Res.Answer).Should(HaveLen(1))
+					Expect(resp.Res.Answer[0].String()).Should(HaveSuffix("1 . alpn=\"h2\" port=\"8443\""))
+
+					By("not modifying the response of the next resolver", func() {
+						Expect(mockAnswer.Answer[0].(*dns.HTTPS).Value).Should(HaveLen(4))
+					})
+				})
+			})
+
+			When("the lookup result contains a SVCB record with a blocked target", func() {
+				BeforeEach(func() {
+					sutConfig.BlackLists["defaultGroup"] = append(sutConfig.BlackLists["defaultGroup"],
+						config.TextBytesSource("badcnamedomain.com"))
+
+					rr, err := dns.NewRR("_dns.example.com. 300 IN SVCB 1 badcnamedomain.com. alpn=dot ipv4hint=192.0.2.1")
+					Expect(err).Should(Succeed())
+
+					mockAnswer = new(dns.Msg)
+					mockAnswer.Answer = []dns.RR{rr}
+				})
+
+				It("should strip the hints of the record", func() {
+					resp, err := sut.Resolve(
+						newRequestWithClient("_dns.example.com.", dns.Type(dns.TypeSVCB), "1.2.1.2", "unknown"))
+					Expect(err).Should(Succeed())
+
+					Expect(resp).Should(HaveReason(", STRIPPED HINTS (defaultGroup)"))
+					Expect(resp.Res.Answer[0].(*dns.SVCB).Value).Should(HaveLen(1))
+				})
+			})
+
+			When("the lookup result contains a HTTPS record without blocked hints", func() {
+				BeforeEach(func() {
+					rr, err := dns.NewRR("example.com. 300 IN HTTPS 1 . alpn=h2 ipv4hint=198.51.101.1")
+					Expect(err).Should(Succeed())
+
+					mockAnswer = new(dns.Msg)
+					mockAnswer.Answer = []dns.RR{rr}
+				})
+
+				It("should keep the hints", func() {
+					resp, err := sut.Resolve(newRequestWithClient("example.com.", HTTPS, "1.2.1.2", "unknown"))
+					Expect(err).Should(Succeed())
+
+					Expect(resp).Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(""),
+					))
+					Expect(resp.Res.Answer[0].(*dns.HTTPS).Value).Should(HaveLen(2))
+				})
+			})
+		})
+
+		When("blacklist contains domain which is CNAME in response", func() {
+			BeforeEach(func() {
+				// reconfigure mock, to return CNAMEs
+				rr1, _ := dns.NewRR("example.com 300 IN CNAME domain.com")
+				rr2, _ := dns.NewRR("domain.com 300 IN CNAME badcnamedomain.com")
+				rr3, _ := dns.NewRR("badcnamedomain.com 300 IN A 125.125.125.125")
+				mockAnswer = new(dns.Msg)
+				mockAnswer.Answer = []dns.RR{rr1, rr2, rr3}
+			})
+			It("should block the query, if response contains a CNAME with domain on a blacklist", func() {
+				Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("example.com.", A, "0.0.0.0"),
+							HaveTTL(BeNumerically("==", 21600)),
+							HaveResponseType(ResponseTypeBLOCKED),
+							HaveReturnCode(dns.RcodeSuccess),
+							HaveReason("BLOCKED CNAME (defaultGroup)"),
+						))
+			})
+
+			When("the CNAME check is disabled", func() {
+				BeforeEach(func() {
+					sutConfig.CheckCnames = false
+				})
+
+				It("should not block the query", func() {
+					Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								HaveResponseType(ResponseTypeRESOLVED),
+								HaveReturnCode(dns.RcodeSuccess),
+							))
+					Expect(m.Calls).Should(HaveLen(1))
+				})
+			})
+
+			When("the queried domain is whitelisted", func() {
+				BeforeEach(func() {
+					exampleFile := tmpDir.CreateStringFile("exampleFile", "example.com")
+					sutConfig.WhiteLists = map[string][]config.BytesSource{
+						"defaultGroup": config.NewBytesSources(exampleFile.Path),
+					}
+				})
+
+				It("should not block the query", func() {
+					Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								HaveResponseType(ResponseTypeRESOLVED),
+								HaveReturnCode(dns.RcodeSuccess),
+							))
+					Expect(m.Calls).Should(HaveLen(1))
+				})
+			})
+		})
+	})
+
+	Describe("Audit groups", func() {
+		var auditMatches chan string
+
+		BeforeEach(func() {
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"gr1":          config.NewBytesSources(group1File.Path),
+					"gr2":          config.NewBytesSources(group2File.Path),
+					"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1", "gr2"},
+					"client1": {"gr1", "defaultGroup"},
+				},
+				Groups: map[string]config.BlockingGroupConfig{
+					"gr1":          {Enforce: false},
+					"gr2":          {Enforce: true},
+					"defaultGroup": {Enforce: false},
+				},
+			}
+
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.145.123.145")
+
+			auditMatches = make(chan string, 10)
+			Expect(Bus().SubscribeOnce(BlockingAuditMatch, func(_, group string) {
+				auditMatches <- group
+			})).Should(Succeed())
+		})
+
+		When("the domain is only on the list of an audit group", func() {
+			It("should resolve and annotate the reason", func() {
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						BeDNSRecord("example.com.", A, "123.145.123.145"),
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(", WOULD_BLOCK (gr1)"),
+					))
+
+				Expect(m.Calls).Should(HaveLen(1))
+				Expect(auditMatches).Should(Receive(Equal("gr1")))
+			})
+		})
+
+		When("the domain is on the list of an enforced group", func() {
+			It("should block it", func() {
+				Expect(sut.Resolve(newRequestWithClient("blocked2.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						BeDNSRecord("blocked2.com.", A, "0.0.0.0"),
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReason("BLOCKED (gr2)"),
+					))
+
+				Expect(m.Calls).Should(BeEmpty())
+				Expect(auditMatches).ShouldNot(Receive())
+			})
+		})
+
+		When("the answer contains an IP on the list of an audit group", func() {
+			It("should return the answer and annotate the reason", func() {
+				Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "client1"))).
+					Should(SatisfyAll(
+						BeDNSRecord("example.com.", A, "123.145.123.145"),
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(", WOULD_BLOCK IP (defaultGroup)"),
+					))
+
+				Expect(auditMatches).Should(Receive(Equal("defaultGroup")))
+			})
+		})
+
+		When("an audit group is whitelist only", func() {
+			BeforeEach(func() {
+				sutConfig.BlackLists = nil
+				sutConfig.WhiteLists = map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources(group1File.Path),
+				}
+				sutConfig.ClientGroupsBlock = map[string][]string{"default": {"gr1"}}
+				sutConfig.Groups = map[string]config.BlockingGroupConfig{"gr1": {Enforce: false}}
+			})
+
+			It("should only annotate domains which aren't whitelisted", func() {
+				Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(", WOULD_BLOCK (WHITELIST ONLY)"),
+					))
+
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(""),
+					))
+			})
+		})
+	})
+
+	Describe("Per group block type", func() {
+		BeforeEach(func() {
+			bothGroupsFile := tmpDir.CreateStringFile("bothGroupsFile", "blocked2.com")
+			Expect(bothGroupsFile.Error).Should(Succeed())
+
+			groupTTL := config.Duration(2 * time.Minute)
+
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"gr1":          config.NewBytesSources(group1File.Path, bothGroupsFile.Path),
+					"gr2":          config.NewBytesSources(group2File.Path),
+					"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1", "gr2", "defaultGroup"},
+					"client1": {"gr2"},
+				},
+				Groups: map[string]config.BlockingGroupConfig{
+					"gr1":          {Enforce: true, BlockType: "10.0.0.5", BlockTTL: &groupTTL},
+					"gr2":          {Enforce: true, BlockType: "nxDomain"},
+					"defaultGroup": {Enforce: true},
+				},
+			}
+		})
+
+		When("the domain is on the list of a group with a custom IP", func() {
+			It("should return the IP with the TTL of the group", func() {
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						BeDNSRecord("domain1.com.", A, "10.0.0.5"),
+						HaveTTL(BeNumerically("==", 120)),
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReason("BLOCKED (gr1)"),
+					))
+			})
+		})
+
+		When("the domain is on the list of a group with NXDOMAIN", func() {
+			It("should return NXDOMAIN with the global TTL", func() {
+				resp, err := sut.Resolve(newRequestWithClient("blocked2.com.", A, "1.2.1.2", "client1"))
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(SatisfyAll(
+					HaveNoAnswer(),
+					HaveReturnCode(dns.RcodeNameError),
+					HaveResponseType(ResponseTypeBLOCKED),
+					HaveReason("BLOCKED (gr2)"),
+				))
+				Expect(resp.Res.Ns).Should(ConsistOf(
+					WithTransform(func(rr dns.RR) uint32 { return rr.(*dns.SOA).Minttl }, BeNumerically("==", 60)),
+				))
+			})
+		})
+
+		When("the domain is on the list of a group without override", func() {
+			It("should use the global block type", func() {
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						BeDNSRecord("blocked3.com.", A, "0.0.0.0"),
+						HaveTTL(BeNumerically("==", 60)),
+						HaveReason("BLOCKED (defaultGroup)"),
+					))
+			})
+		})
+
+		When("the domain is on the lists of several groups with overrides", func() {
+			It("should use the alphabetically first group", func() {
+				Expect(sut.Resolve(newRequestWithClient("blocked2.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						BeDNSRecord("blocked2.com.", A, "10.0.0.5"),
+						HaveResponseType(ResponseTypeBLOCKED),
+					))
+			})
+		})
+	})
+
+	Describe("Blocking with the IPs of the list", func() {
+		BeforeEach(func() {
+			redirect := config.TextBytesSource(
+				"146.112.61.106 phishing.com",
+				"146.112.61.107 phishing.com",
+				"2001:db8::1 phishing6.com",
+				"0.0.0.0 ads.com",
+				"malware.com",
+			)
+			redirect.UseListIPs = true
+
+			groupTTL := config.Duration(2 * time.Minute)
+
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"sinkhole": {redirect},
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"sinkhole"},
+				},
+				Groups: map[string]config.BlockingGroupConfig{
+					"sinkhole": {Enforce: true, BlockType: "nxDomain", BlockTTL: &groupTTL},
+				},
+			}
+		})
+
+		When("the domain has IPs in the list", func() {
+			It("should answer with the IPs of the query type", func() {
+				resp, err := sut.Resolve(newRequestWithClient("phishing.com.", A, "1.2.1.2", "unknown"))
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(SatisfyAll(
+					HaveResponseType(ResponseTypeBLOCKED),
+					HaveReturnCode(dns.RcodeSuccess),
+					HaveReason("BLOCKED (sinkhole) -> 146.112.61.106,146.112.61.107"),
+				))
+				Expect(resp.Res.Answer).Should(HaveExactElements(
+					SatisfyAll(
+						WithTransform(func(rr dns.RR) string { return rr.(*dns.A).A.String() }, Equal("146.112.61.106")),
+						WithTransform(func(rr dns.RR) uint32 { return rr.Header().Ttl }, BeNumerically("==", 120)),
+					),
+					WithTransform(func(rr dns.RR) string { return rr.(*dns.A).A.String() }, Equal("146.112.61.107")),
+				))
+
+				Expect(sut.Resolve(newRequestWithClient("phishing6.com.", AAAA, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						BeDNSRecord("phishing6.com.", AAAA, "2001:db8::1"),
+						HaveReason("BLOCKED (sinkhole) -> 2001:db8::1"),
+					))
+			})
+		})
+
+		When("the domain has no IPs of the query type", func() {
+			It("should use the block type", func() {
+				Expect(sut.Resolve(newRequestWithClient("phishing.com.", AAAA, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveNoAnswer(),
+						HaveReturnCode(dns.RcodeNameError),
+						HaveReason("BLOCKED (sinkhole)"),
+					))
+			})
+		})
+
+		When("the domain has a sinkhole IP or none in the list", func() {
+			It("should use the block type", func() {
+				for _, domain := range []string{"ads.com.", "malware.com."} {
+					Expect(sut.Resolve(newRequestWithClient(domain, A, "1.2.1.2", "unknown"))).
+						Should(SatisfyAll(
+							HaveNoAnswer(),
+							HaveReturnCode(dns.RcodeNameError),
+							HaveReason("BLOCKED (sinkhole)"),
+						))
+				}
+			})
+		})
+	})
+
+	Describe("Per group query types", func() {
+		BeforeEach(func() {
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"gr1":          config.NewBytesSources(group1File.Path),
+					"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1", "defaultGroup"},
+				},
+				Groups: map[string]config.BlockingGroupConfig{
+					"gr1": {Enforce: true, QueryTypes: config.NewQTypeSet(A, AAAA, HTTPS)},
+				},
+			}
+		})
+
+		When("the query type is one of the group", func() {
+			It("should block the query", func() {
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						BeDNSRecord("domain1.com.", A, "0.0.0.0"),
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReason("BLOCKED (gr1)"),
+					))
+
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", HTTPS, "1.2.1.2", "unknown"))).
+					Should(HaveResponseType(ResponseTypeBLOCKED))
+			})
+		})
+
+		When("the query type is not one of the group", func() {
+			It("should delegate the query to the next resolver", func() {
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", MX, "1.2.1.2", "unknown"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+			})
+		})
+
+		When("the group isn't limited to query types", func() {
+			It("should block all query types", func() {
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", TXT, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReason("BLOCKED (defaultGroup)"),
+					))
+			})
+		})
+	})
+
+	Describe("Annotation of disabled blocking", func() {
+		var disabledMatches chan string
+
+		BeforeEach(func() {
+			sutConfig = config.BlockingConfig{
+				BlockType:   "ZEROIP",
+				BlockTTL:    config.Duration(time.Minute),
+				CheckCnames: true,
+				BlackLists: map[string][]config.BytesSource{
+					"gr1":          config.NewBytesSources(group1File.Path),
+					"gr2":          config.NewBytesSources(group2File.Path),
+					"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+				},
+				WhiteLists: map[string][]config.BytesSource{
+					"gr2": {config.TextBytesSource("domain1.com")},
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1", "gr2", "defaultGroup"},
+				},
+				AnnotateDisabled: true,
+			}
+
+			disabledMatches = make(chan string, 10)
+			Expect(Bus().SubscribeOnce(BlockingDisabledMatch, func(_, group string) {
+				disabledMatches <- group
+			})).Should(Succeed())
+		})
+
+		When("blocking of the matching group is disabled", func() {
+			It("should resolve and annotate the reason", func() {
+				Expect(sut.DisableBlocking(0, []string{"defaultGroup"}, "")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(", BLOCKING_DISABLED (defaultGroup)"),
+					))
+
+				Expect(disabledMatches).Should(Receive(Equal("defaultGroup")))
+			})
+		})
+
+		When("blocking is disabled for the client", func() {
+			It("should resolve and annotate the reason", func() {
+				Expect(sut.DisableBlocking(0, nil, "1.2.1.2")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("blocked2.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(", BLOCKING_DISABLED (gr2)"),
+					))
+			})
+		})
+
+		When("the response contains a CNAME on a list of a disabled group", func() {
+			BeforeEach(func() {
+				rr1, _ := dns.NewRR("example.com 300 IN CNAME badcnamedomain.com")
+				rr2, _ := dns.NewRR("badcnamedomain.com 300 IN A 125.125.125.125")
+				mockAnswer = new(dns.Msg)
+				mockAnswer.Answer = []dns.RR{rr1, rr2}
+			})
+
+			It("should annotate the reason", func() {
+				Expect(sut.DisableBlocking(0, nil, "")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+					Should(HaveReason(", BLOCKING_DISABLED CNAME (defaultGroup)"))
+			})
+		})
+
+		When("the domain is whitelisted by another group", func() {
+			It("should not annotate the reason", func() {
+				Expect(sut.DisableBlocking(0, []string{"gr1"}, "")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+					Should(HaveReason(""))
+
+				Expect(disabledMatches).ShouldNot(Receive())
+			})
+		})
+
+		When("the domain isn't on a list of a disabled group", func() {
+			It("should not annotate the reason", func() {
+				Expect(sut.DisableBlocking(0, []string{"gr1"}, "")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReason("BLOCKED (defaultGroup)"),
+					))
+			})
+		})
+
+		When("the annotation is disabled", func() {
+			BeforeEach(func() {
+				sutConfig.AnnotateDisabled = false
+			})
+
+			It("should not annotate the reason", func() {
+				Expect(sut.DisableBlocking(0, nil, "")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						HaveReason(""),
+					))
+			})
+		})
+	})
+
+	Describe("Block overrides", func() {
+		BeforeEach(func() {
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"gr1":          config.NewBytesSources(group1File.Path),
+					"gr2":          config.NewBytesSources(group2File.Path),
+					"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+				},
+				WhiteLists: map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("domain1.com")},
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1", "defaultGroup"},
+				},
+				Groups: map[string]config.BlockingGroupConfig{
+					"defaultGroup": {Enforce: true, QueryTypes: config.NewQTypeSet(A)},
+				},
+				AnnotateOverrides: true,
+			}
+		})
+
+		It("should name the whitelist entry", func() {
+			Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+				Should(SatisfyAll(
+					HaveResponseType(ResponseTypeRESOLVED),
+					WithTransform(func(r *Response) string { return r.BlockOverride },
+						Equal("WHITELIST (gr1: domain1.com)")),
+				))
+		})
+
+		It("should name the groups which aren't assigned to the client", func() {
+			resp, err := sut.Resolve(newRequestWithClient("blocked2.com.", A, "1.2.1.2", "unknown"))
+			Expect(err).Should(Succeed())
+			Expect(resp.BlockOverride).Should(Equal("NOT ASSIGNED (gr2)"))
+		})
+
+		It("should name the groups limited to other query types", func() {
+			resp, err := sut.Resolve(newRequestWithClient("blocked3.com.", TXT, "1.2.1.2", "unknown"))
+			Expect(err).Should(Succeed())
+			Expect(resp.BlockOverride).Should(Equal("QUERY TYPE (defaultGroup)"))
+		})
+
+		It("should name the disabled groups", func() {
+			Expect(sut.DisableBlocking(0, []string{"defaultGroup"}, "unknown")).Should(Succeed())
+
+			resp, err := sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))
+			Expect(err).Should(Succeed())
+			Expect(resp.BlockOverride).Should(Equal("DISABLED (defaultGroup)"))
+		})
+
+		It("should name audited groups", func() {
+			sut.cfg.Groups["gr1"] = config.BlockingGroupConfig{Enforce: false}
+
+			resp, err := sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))
+			Expect(err).Should(Succeed())
+			Expect(resp.BlockOverride).Should(Equal("AUDIT (gr1)"))
+		})
+
+		It("should not annotate blocked queries and queries without deny match", func() {
+			resp, err := sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))
+			Expect(err).Should(Succeed())
+			Expect(resp.RType).Should(Equal(ResponseTypeBLOCKED))
+			Expect(resp.BlockOverride).Should(BeEmpty())
+
+			resp, err = sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))
+			Expect(err).Should(Succeed())
+			Expect(resp.BlockOverride).Should(BeEmpty())
+		})
+
+		When("annotations are disabled", func() {
 			BeforeEach(func() {
-				// reconfigure mock, to return CNAMEs
-				rr1, _ := dns.NewRR("example.com 300 IN CNAME domain.com")
-				rr2, _ := dns.NewRR("domain.com 300 IN CNAME badcnamedomain.com")
-				rr3, _ := dns.NewRR("badcnamedomain.com 300 IN A 125.125.125.125")
-				mockAnswer = new(dns.Msg)
-				mockAnswer.Answer = []dns.RR{rr1, rr2, rr3}
+				sutConfig.AnnotateOverrides = false
 			})
-			It("should block the query, if response contains a CNAME with domain on a blacklist", func() {
-				Expect(sut.Resolve(newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
-					Should(
-						SatisfyAll(
-							BeDNSRecord("example.com.", A, "0.0.0.0"),
-							HaveTTL(BeNumerically("==", 21600)),
-							HaveResponseType(ResponseTypeBLOCKED),
-							HaveReturnCode(dns.RcodeSuccess),
-							HaveReason("BLOCKED CNAME (defaultGroup)"),
-						))
+
+			It("should not annotate the response", func() {
+				resp, err := sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))
+				Expect(err).Should(Succeed())
+				Expect(resp.BlockOverride).Should(BeEmpty())
 			})
 		})
 	})
@@ -834,7 +1597,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 				})
 
 				By("Calling Rest API to deactivate all groups", func() {
-					err := sut.DisableBlocking(0, []string{})
+					err := sut.DisableBlocking(0, []string{}, "")
 					Expect(err).Should(Succeed())
 				})
 
@@ -867,7 +1630,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 				})
 
 				By("Calling Rest API to deactivate only defaultGroup", func() {
-					err := sut.DisableBlocking(0, []string{"defaultGroup"})
+					err := sut.DisableBlocking(0, []string{"defaultGroup"}, "")
 					Expect(err).Should(Succeed())
 				})
 
@@ -923,11 +1686,11 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 
 				By("Calling Rest API to deactivate blocking for 0.5 sec", func() {
 					enabled := make(chan bool, 1)
-					err := Bus().SubscribeOnce(BlockingEnabledEvent, func(state bool) {
+					err := Bus().SubscribeOnce(BlockingEnabledEvent, func(_ string, state bool) {
 						enabled <- state
 					})
 					Expect(err).Should(Succeed())
-					err = sut.DisableBlocking(500*time.Millisecond, []string{})
+					err = sut.DisableBlocking(500*time.Millisecond, []string{}, "")
 					Expect(err).Should(Succeed())
 					Eventually(enabled, "1s").Should(Receive(BeFalse()))
 				})
@@ -961,7 +1724,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 
 				By("Wait 1 sec and perform the same query again, should be blocked now", func() {
 					enabled := make(chan bool, 1)
-					_ = Bus().SubscribeOnce(BlockingEnabledEvent, func(state bool) {
+					_ = Bus().SubscribeOnce(BlockingEnabledEvent, func(_ string, state bool) {
 						enabled <- state
 					})
 					// wait 1 sec
@@ -1013,11 +1776,11 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 
 				By("Calling Rest API to deactivate blocking for one group for 0.5 sec", func() {
 					enabled := make(chan bool, 1)
-					err := Bus().SubscribeOnce(BlockingEnabledEvent, func(state bool) {
+					err := Bus().SubscribeOnce(BlockingEnabledEvent, func(_ string, state bool) {
 						enabled <- false
 					})
 					Expect(err).Should(Succeed())
-					err = sut.DisableBlocking(500*time.Millisecond, []string{"group1"})
+					err = sut.DisableBlocking(500*time.Millisecond, []string{"group1"}, "")
 					Expect(err).Should(Succeed())
 					Eventually(enabled, "1s").Should(Receive(BeFalse()))
 				})
@@ -1049,7 +1812,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 
 				By("Wait 1 sec and perform the same query again, should be blocked now", func() {
 					enabled := make(chan bool, 1)
-					_ = Bus().SubscribeOnce(BlockingEnabledEvent, func(state bool) {
+					_ = Bus().SubscribeOnce(BlockingEnabledEvent, func(_ string, state bool) {
 						enabled <- state
 					})
 					// wait 1 sec
@@ -1078,15 +1841,79 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 
 		When("Disable blocking is called with wrong group name", func() {
 			It("should fail", func() {
-				err := sut.DisableBlocking(500*time.Millisecond, []string{"unknownGroupName"})
+				err := sut.DisableBlocking(500*time.Millisecond, []string{"unknownGroupName"}, "")
 				Expect(err).Should(HaveOccurred())
 			})
 		})
 
+		When("Disable blocking is called for a client", func() {
+			It("should only disable blocking for this client until the duration expires", func() {
+				Expect(sut.DisableBlocking(500*time.Millisecond, []string{}, "Laptop")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "laptop"))).
+					Should(
+						SatisfyAll(
+							HaveNoAnswer(),
+							HaveResponseType(ResponseTypeRESOLVED),
+						))
+
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.3", "tablet"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("blocked3.com.", A, "0.0.0.0"),
+							HaveResponseType(ResponseTypeBLOCKED),
+						))
+
+				status := sut.BlockingStatus()
+				Expect(status.Enabled).Should(BeTrue())
+				Expect(status.DisabledClients).Should(HaveLen(1))
+				Expect(status.DisabledClients[0].Client).Should(Equal("laptop"))
+				Expect(status.DisabledClients[0].DisabledGroups).Should(ContainElements("defaultGroup", "group1"))
+
+				Eventually(func() []api.DisabledClient {
+					return sut.BlockingStatus().DisabledClients
+				}, "1s").Should(BeEmpty())
+
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "laptop"))).
+					Should(HaveResponseType(ResponseTypeBLOCKED))
+			})
+
+			It("should disable the passed groups for the client IP", func() {
+				Expect(sut.DisableBlocking(time.Hour, []string{"group1"}, "1.2.1.2")).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "laptop"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				Expect(sut.Resolve(newRequestWithClient("blocked3.com.", A, "1.2.1.2", "laptop"))).
+					Should(
+						SatisfyAll(
+							HaveResponseType(ResponseTypeBLOCKED),
+							HaveReason("BLOCKED (defaultGroup)"),
+						))
+
+				status := sut.BlockingStatus()
+				Expect(status.DisabledClients).Should(HaveLen(1))
+				Expect(status.DisabledClients[0].AutoEnableInSec).Should(BeNumerically("~", 3600, 1))
+
+				By("enable blocking for the client again", func() {
+					sut.EnableBlocking("1.2.1.2")
+
+					Expect(sut.BlockingStatus().DisabledClients).Should(BeEmpty())
+					Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "laptop"))).
+						Should(HaveResponseType(ResponseTypeBLOCKED))
+				})
+			})
+
+			It("should fail for an unknown group", func() {
+				Expect(sut.DisableBlocking(0, []string{"unknownGroupName"}, "laptop")).ShouldNot(Succeed())
+				Expect(sut.BlockingStatus().DisabledClients).Should(BeEmpty())
+			})
+		})
+
 		When("Blocking status is called", func() {
 			It("should return correct status", func() {
 				By("enable blocking via API", func() {
-					sut.EnableBlocking()
+					sut.EnableBlocking("")
 				})
 
 				By("Query blocking status via API should return 'enabled'", func() {
@@ -1095,7 +1922,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 				})
 
 				By("disable blocking via API", func() {
-					err := sut.DisableBlocking(500*time.Millisecond, []string{})
+					err := sut.DisableBlocking(500*time.Millisecond, []string{}, "")
 					Expect(err).Should(Succeed())
 				})
 
@@ -1106,27 +1933,275 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 				})
 			})
 		})
+
+		When("the wall clock jumps while blocking is disabled", func() {
+			It("should enable blocking again after the elapsed duration", func() {
+				clock := util.NewFakeClock()
+				DeferCleanup(util.SetClock(clock))
+
+				Expect(sut.DisableBlocking(2*time.Second, []string{}, "")).Should(Succeed())
+
+				clock.JumpWallClock(5 * time.Hour)
+
+				result := sut.BlockingStatus()
+				Expect(result.Enabled).Should(BeFalse())
+				Expect(result.AutoEnableInSec).Should(BeNumerically("~", 1, 1))
+
+				Consistently(func() bool {
+					return sut.BlockingStatus().Enabled
+				}, "1s").Should(BeFalse())
+				Eventually(func() bool {
+					return sut.BlockingStatus().Enabled
+				}, "2s").Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("Runtime entries", func() {
+		var entriesFile string
+
+		deny := api.BlockingEntry{Type: api.BlockingEntryDeny, Group: "manual", Domain: "Evil.example.com."}
+		allow := api.BlockingEntry{Type: api.BlockingEntryAllow, Group: "gr1", Domain: "domain1.com"}
+
+		BeforeEach(func() {
+			entriesFile = ""
+
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources(group1File.Path),
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1", "manual"},
+				},
+			}
+
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.145.123.145")
+		})
+
+		JustBeforeEach(func() {
+			if entriesFile != "" {
+				// recreate the resolver with the file
+				var err error
+
+				sutConfig.RuntimeEntriesFile = entriesFile
+				sut, err = NewBlockingResolver(sutCtx, sutConfig, nil, systemResolverBootstrap, "")
+				Expect(err).Should(Succeed())
+				sut.Next(m)
+			}
+		})
+
+		It("should block and unblock a domain immediately", func() {
+			request := func() *Request {
+				return newRequestWithClient("evil.example.com.", A, "1.2.1.2", "unknown")
+			}
+
+			Expect(sut.Resolve(request())).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(sut.AddBlockingEntry(deny)).Should(Succeed())
+
+			Expect(sut.Resolve(request())).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeBLOCKED),
+				HaveReason("BLOCKED (manual)"),
+			))
+			Expect(sut.BlockingEntries()).Should(ConsistOf(
+				api.BlockingEntry{Type: api.BlockingEntryDeny, Group: "manual", Domain: "evil.example.com"},
+			))
+
+			Expect(sut.RemoveBlockingEntry(deny)).Should(Succeed())
+
+			Expect(sut.Resolve(request())).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(sut.BlockingEntries()).Should(BeEmpty())
+		})
+
+		It("should whitelist a blocked domain", func() {
+			request := newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown")
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeBLOCKED))
+
+			Expect(sut.AddBlockingEntry(allow)).Should(Succeed())
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		It("should keep the entries on list refresh", func() {
+			Expect(sut.AddBlockingEntry(deny)).Should(Succeed())
+
+			Expect(sut.RefreshLists()).Should(Succeed())
+
+			Expect(sut.Resolve(newRequestWithClient("evil.example.com.", A, "1.2.1.2", "unknown"))).
+				Should(HaveResponseType(ResponseTypeBLOCKED))
+		})
+
+		It("should reject invalid entries", func() {
+			Expect(sut.AddBlockingEntry(api.BlockingEntry{Type: api.BlockingEntryDeny, Group: "unknown", Domain: "a.com"})).
+				Should(MatchError("group 'unknown' is unknown"))
+			Expect(sut.AddBlockingEntry(api.BlockingEntry{Type: "other", Group: "gr1", Domain: "a.com"})).
+				Should(MatchError("unknown entry type 'other'"))
+			Expect(sut.AddBlockingEntry(api.BlockingEntry{Type: api.BlockingEntryDeny, Group: "gr1", Domain: " "})).
+				Should(MatchError("invalid domain ''"))
+			Expect(sut.RemoveBlockingEntry(deny)).Should(MatchError(errBlockingEntryNotFound))
+		})
+
+		When("an entry has a TTL", func() {
+			var clock *util.FakeClock
+
+			temporary := api.BlockingEntry{Type: api.BlockingEntryAllow, Group: "gr1", Domain: "domain1.com", TTL: time.Hour}
+
+			entriesTTL := func() []time.Duration {
+				var result []time.Duration
+
+				for _, e := range sut.BlockingEntries() {
+					result = append(result, e.TTL)
+				}
+
+				return result
+			}
+
+			BeforeEach(func() {
+				clock = util.NewFakeClock()
+				DeferCleanup(util.SetClock(clock))
+			})
+
+			It("should remove the entry after it expires", func() {
+				request := newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown")
+
+				Expect(sut.AddBlockingEntry(temporary)).Should(Succeed())
+
+				Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(entriesTTL()).Should(HaveExactElements(BeNumerically("~", time.Hour, time.Second)))
+
+				clock.Advance(40 * time.Minute)
+
+				Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(entriesTTL()).Should(HaveExactElements(BeNumerically("~", 20*time.Minute, time.Second)))
+
+				clock.Advance(20 * time.Minute)
+
+				Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeBLOCKED))
+				Expect(sut.BlockingEntries()).Should(BeEmpty())
+				Expect(sut.RemoveBlockingEntry(temporary)).Should(MatchError(errBlockingEntryNotFound))
+			})
+
+			It("should replace the TTL if the entry is added again", func() {
+				Expect(sut.AddBlockingEntry(temporary)).Should(Succeed())
+
+				longer := temporary
+				longer.TTL = 2 * time.Hour
+
+				Expect(sut.AddBlockingEntry(longer)).Should(Succeed())
+				Expect(entriesTTL()).Should(HaveExactElements(BeNumerically("~", 2*time.Hour, time.Second)))
+
+				Expect(sut.AddBlockingEntry(allow)).Should(Succeed())
+				Expect(entriesTTL()).Should(HaveExactElements(time.Duration(0)))
+			})
+
+			It("should remove the entry before it expires", func() {
+				Expect(sut.AddBlockingEntry(temporary)).Should(Succeed())
+				Expect(sut.RemoveBlockingEntry(allow)).Should(Succeed())
+
+				Expect(sut.Resolve(newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+					Should(HaveResponseType(ResponseTypeBLOCKED))
+			})
+
+			When("a file is configured", func() {
+				BeforeEach(func() {
+					entriesFile = tmpDir.JoinPath("runtimeEntries.json")
+					DeferCleanup(os.Remove, entriesFile)
+				})
+
+				It("should persist the expiry and purge expired entries on start", func() {
+					Expect(sut.AddBlockingEntry(temporary)).Should(Succeed())
+					Expect(sut.AddBlockingEntry(deny)).Should(Succeed())
+
+					restarted, err := NewBlockingResolver(sutCtx, sutConfig, nil, systemResolverBootstrap, "")
+					Expect(err).Should(Succeed())
+					Expect(restarted.BlockingEntries()).Should(HaveLen(2))
+					Expect(os.ReadFile(entriesFile)).Should(ContainSubstring("expiresAt"))
+
+					clock.Advance(time.Hour)
+
+					restarted, err = NewBlockingResolver(sutCtx, sutConfig, nil, systemResolverBootstrap, "")
+					Expect(err).Should(Succeed())
+					Expect(restarted.BlockingEntries()).Should(ConsistOf(
+						api.BlockingEntry{Type: api.BlockingEntryDeny, Group: "manual", Domain: "evil.example.com"},
+					))
+					Expect(os.ReadFile(entriesFile)).ShouldNot(ContainSubstring("domain1.com"))
+				})
+			})
+		})
+
+		When("a file is configured", func() {
+			BeforeEach(func() {
+				entriesFile = tmpDir.JoinPath("runtimeEntries.json")
+				DeferCleanup(os.Remove, entriesFile)
+			})
+
+			It("should persist the entries", func() {
+				Expect(sut.AddBlockingEntry(deny)).Should(Succeed())
+				Expect(sut.AddBlockingEntry(allow)).Should(Succeed())
+				Expect(sut.RemoveBlockingEntry(allow)).Should(Succeed())
+
+				restarted, err := NewBlockingResolver(sutCtx, sutConfig, nil, systemResolverBootstrap, "")
+				Expect(err).Should(Succeed())
+
+				Expect(restarted.BlockingEntries()).Should(ConsistOf(
+					api.BlockingEntry{Type: api.BlockingEntryDeny, Group: "manual", Domain: "evil.example.com"},
+				))
+			})
+
+			It("should fail to start if the file is invalid", func() {
+				Expect(os.WriteFile(entriesFile, []byte("invalid"), 0o600)).Should(Succeed())
+
+				_, err := NewBlockingResolver(sutCtx, sutConfig, nil, systemResolverBootstrap, "")
+				Expect(err).Should(MatchError(ContainSubstring("can't parse runtime entries file")))
+			})
+		})
 	})
 
 	Describe("Create resolver with wrong parameter", func() {
 		When("Wrong blockType is used", func() {
 			It("should return error", func() {
-				_, err := NewBlockingResolver(config.BlockingConfig{
+				_, err := NewBlockingResolver(sutCtx, config.BlockingConfig{
 					BlockType: "wrong",
-				}, nil, systemResolverBootstrap)
+				}, nil, systemResolverBootstrap, "")
+
+				Expect(err).Should(MatchError(
+					"unknown blockType 'wrong', please use one of: ZeroIP, NxDomain, Self or specify destination IP address(es)",
+				))
+			})
+		})
+		When("Wrong blockType is used for a group", func() {
+			It("should return error", func() {
+				_, err := NewBlockingResolver(sutCtx, config.BlockingConfig{
+					BlockType:  "zeroIp",
+					BlackLists: map[string][]config.BytesSource{"gr1": config.NewBytesSources(group1File.Path)},
+					Groups:     map[string]config.BlockingGroupConfig{"gr1": {Enforce: true, BlockType: "wrong"}},
+				}, nil, systemResolverBootstrap, "")
+
+				Expect(err).Should(MatchError(ContainSubstring("group 'gr1': unknown blockType 'wrong'")))
+			})
+		})
+		When("an unknown group is configured", func() {
+			It("should return error", func() {
+				_, err := NewBlockingResolver(sutCtx, config.BlockingConfig{
+					BlockType:  "zeroIp",
+					BlackLists: map[string][]config.BytesSource{"gr1": config.NewBytesSources(group1File.Path)},
+					Groups:     map[string]config.BlockingGroupConfig{"adult": {Enforce: true, BlockType: "nxDomain"}},
+				}, nil, systemResolverBootstrap, "")
 
-				Expect(err).Should(
-					MatchError("unknown blockType 'wrong', please use one of: ZeroIP, NxDomain or specify destination IP address(es)"))
+				Expect(err).Should(MatchError(ContainSubstring("unknown group 'adult'")))
 			})
 		})
 		When("strategy is failOnError", func() {
 			It("should fail if lists can't be downloaded", func() {
-				_, err := NewBlockingResolver(config.BlockingConfig{
+				_, err := NewBlockingResolver(sutCtx, config.BlockingConfig{
 					BlackLists: map[string][]config.BytesSource{"gr1": config.NewBytesSources("wrongPath")},
 					WhiteLists: map[string][]config.BytesSource{"whitelist": config.NewBytesSources("wrongPath")},
 					Loading:    config.SourceLoadingConfig{Strategy: config.StartStrategyTypeFailOnError},
 					BlockType:  "zeroIp",
-				}, nil, systemResolverBootstrap)
+				}, nil, systemResolverBootstrap, "")
 				Expect(err).Should(HaveOccurred())
 			})
 		})
@@ -1155,7 +2230,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 				BlockTTL:  config.Duration(time.Minute),
 			}
 
-			sut, err = NewBlockingResolver(sutConfig, redisClient, systemResolverBootstrap)
+			sut, err = NewBlockingResolver(sutCtx, sutConfig, redisClient, systemResolverBootstrap, "")
 			Expect(err).Should(Succeed())
 		})
 		JustAfterEach(func() {
@@ -1163,7 +2238,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 		})
 		When("disable", func() {
 			It("should return disable", func() {
-				sut.EnableBlocking()
+				sut.EnableBlocking("")
 
 				redisMockMsg := &redis.EnabledMessage{
 					State: false,
@@ -1177,7 +2252,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 		})
 		When("disable", func() {
 			It("should return disable", func() {
-				sut.EnableBlocking()
+				sut.EnableBlocking("")
 				redisMockMsg := &redis.EnabledMessage{
 					State:  false,
 					Groups: []string{"unknown"},
@@ -1191,7 +2266,7 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 		})
 		When("enable", func() {
 			It("should return enable", func() {
-				err = sut.DisableBlocking(time.Hour, []string{})
+				err = sut.DisableBlocking(time.Hour, []string{}, "")
 				Expect(err).Should(Succeed())
 
 				redisMockMsg := &redis.EnabledMessage{
@@ -1205,4 +2280,215 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 			})
 		})
 	})
+
+	Describe("Blocking check", func() {
+		BeforeEach(func() {
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"gr1":   config.NewBytesSources(group1File.Path),
+					"gr2":   config.NewBytesSources(group2File.Path),
+					"audit": {config.TextBytesSource("*.com")},
+				},
+				WhiteLists: map[string][]config.BytesSource{
+					"gr2": {config.TextBytesSource("domain1.com")},
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1"},
+					"kids":    {"gr1", "gr2"},
+					"1.2.1.2": {"audit"},
+				},
+				Groups: map[string]config.BlockingGroupConfig{
+					"audit": {Enforce: false},
+				},
+			}
+		})
+
+		It("should explain the blocking of the domain", func() {
+			Expect(sut.CheckBlocking("Domain1.com.", "")).Should(Equal(api.BlockingCheck{
+				Domain:  "domain1.com",
+				Groups:  []string{"gr1"},
+				Blocked: true,
+				Reason:  "BLOCKED (gr1)",
+				DenyMatches: []api.BlockingCheckMatch{
+					{Group: "gr1", Source: "file://" + group1File.Path, Entry: "domain1.com"},
+				},
+				AllowMatches: []api.BlockingCheckMatch{},
+			}))
+		})
+
+		It("should report the whitelist entry overriding the blacklist", func() {
+			Expect(sut.CheckBlocking("domain1.com", "kids")).Should(SatisfyAll(
+				HaveField("Groups", Equal([]string{"gr1", "gr2"})),
+				HaveField("Blocked", BeFalse()),
+				HaveField("AllowOverride", BeTrue()),
+				HaveField("DenyMatches", HaveLen(1)),
+				HaveField("AllowMatches", Equal([]api.BlockingCheckMatch{
+					{Group: "gr2", Source: "domain1.com", Entry: "domain1.com"},
+				})),
+			))
+		})
+
+		It("should report matches of audited groups", func() {
+			Expect(sut.CheckBlocking("www.example.com", "1.2.1.2")).Should(SatisfyAll(
+				HaveField("Blocked", BeFalse()),
+				HaveField("Reason", "WOULD_BLOCK (audit)"),
+				HaveField("DenyMatches", Equal([]api.BlockingCheckMatch{
+					{Group: "audit", Source: "*.com", Entry: "*.com"},
+				})),
+			))
+		})
+
+		It("should report runtime entries", func() {
+			Expect(sut.AddBlockingEntry(api.BlockingEntry{
+				Type: api.BlockingEntryDeny, Group: "gr1", Domain: "evil.example.com",
+			})).Should(Succeed())
+
+			Expect(sut.CheckBlocking("evil.example.com", "")).Should(SatisfyAll(
+				HaveField("Blocked", BeTrue()),
+				HaveField("DenyMatches", Equal([]api.BlockingCheckMatch{
+					{Group: "gr1", Source: "runtime entries", Entry: "evil.example.com"},
+				})),
+			))
+		})
+
+		It("should not block a domain without matches", func() {
+			Expect(sut.CheckBlocking("example.com", "")).Should(SatisfyAll(
+				HaveField("Blocked", BeFalse()),
+				HaveField("Reason", BeEmpty()),
+				HaveField("DenyMatches", BeEmpty()),
+			))
+		})
+
+		It("should fail on an invalid domain", func() {
+			_, err := sut.CheckBlocking(" ", "")
+			Expect(err).Should(MatchError(ContainSubstring("invalid domain")))
+		})
+
+		Describe("List groups", func() {
+			It("should return the groups of both list types", func() {
+				groups := sut.ListGroups()
+
+				Expect(groups).Should(HaveLen(4))
+				Expect(groups[0]).Should(SatisfyAll(
+					HaveField("Type", "blacklist"),
+					HaveField("Group", "audit"),
+					HaveField("Entries", 1),
+					HaveField("Sources", ConsistOf(SatisfyAll(
+						HaveField("Source", "*.com"),
+						HaveField("Entries", 1),
+						HaveField("LastSuccess", Not(BeZero())),
+						HaveField("LastError", BeNil()),
+					))),
+				))
+				Expect(groups[3]).Should(SatisfyAll(
+					HaveField("Type", "whitelist"),
+					HaveField("Group", "gr2"),
+				))
+			})
+		})
+
+		Describe("List lookup", func() {
+			It("should return the entries of all groups", func() {
+				Expect(sut.LookupLists("Domain1.com.")).Should(Equal(api.ListLookup{
+					Domain: "domain1.com",
+					Matches: []api.ListLookupMatch{
+						{Type: "blacklist", Group: "audit", Source: "*.com", Entry: "*.com", Match: "wildcard"},
+						{
+							Type: "blacklist", Group: "gr1", Source: "file://" + group1File.Path,
+							Entry: "domain1.com", Match: "exact",
+						},
+						{Type: "whitelist", Group: "gr2", Source: "domain1.com", Entry: "domain1.com", Match: "exact"},
+					},
+				}))
+			})
+
+			It("should report entries of parent domains", func() {
+				Expect(sut.LookupLists("www.domain1.com")).Should(HaveField("Matches", ContainElement(
+					api.ListLookupMatch{Type: "whitelist", Group: "gr2", Source: "domain1.com", Entry: "domain1.com", Match: "parent"},
+				)))
+			})
+
+			It("should fail on an invalid domain", func() {
+				_, err := sut.LookupLists(" ")
+				Expect(err).Should(MatchError(ContainSubstring("invalid domain")))
+			})
+		})
+	})
+
+	Describe("DNSSEC records", func() {
+		var mockUpstream *dnstest.MockUpstreamServer
+
+		BeforeEach(func() {
+			sutConfig = config.BlockingConfig{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				BlackLists: map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources(group1File.Path),
+				},
+				ClientGroupsBlock: map[string][]string{
+					"default": {"gr1"},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			zone := newDNSSECTestZone("example.")
+
+			mockUpstream = dnstest.NewMockUpstreamServer().WithAnswerFn(zone.answer)
+			DeferCleanup(mockUpstream.Close)
+
+			caching := NewCachingResolver(sutCtx, config.CachingConfig{}, nil, "")
+			caching.Next(newUpstreamResolverUnchecked(mockUpstream.Start(), nil))
+
+			sut.Next(caching)
+		})
+
+		It("should keep the DNSSEC records and flags of answers, which aren't blocked", func() {
+			for _, rType := range []ResponseType{ResponseTypeRESOLVED, ResponseTypeCACHED} {
+				request := newRequest("www.example.", A)
+				request.Req.SetEdns0(dns.DefaultMsgSize, true)
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(HaveResponseType(rType))
+				Expect(resp.Res.Answer).Should(HaveLen(2))
+				Expect(resp.Res.Answer[1]).Should(BeAssignableToTypeOf(&dns.RRSIG{}))
+				Expect(resp.Res.Ns).Should(ContainElements(
+					BeAssignableToTypeOf(&dns.NSEC{}), BeAssignableToTypeOf(&dns.RRSIG{})))
+				Expect(resp.Res.IsEdns0().Do()).Should(BeTrue())
+			}
+
+			Expect(mockUpstream.GetCallCount()).Should(Equal(1))
+		})
+
+		It("should pass the CD bit", func() {
+			request := newRequest("www.example.", A)
+			request.Req.SetEdns0(dns.DefaultMsgSize, true)
+			request.Req.CheckingDisabled = true
+
+			resp, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.CheckingDisabled).Should(BeTrue())
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+			Expect(mockUpstream.LastRequest().CheckingDisabled).Should(BeTrue())
+		})
+
+		It("should still block", func() {
+			request := newRequest("domain1.com.", A)
+			request.Req.SetEdns0(dns.DefaultMsgSize, true)
+
+			resp, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(SatisfyAll(
+				BeDNSRecord("domain1.com.", A, "0.0.0.0"),
+				HaveResponseType(ResponseTypeBLOCKED),
+			))
+			Expect(mockUpstream.GetCallCount()).Should(BeZero())
+		})
+	})
 })
@@ -27,6 +27,13 @@ type Bootstrap struct {
 	bootstraped bootstrapedResolvers
 
 	connectIPVersion config.IPVersion
+	upstreamTimeout  config.Duration
+	dohUserAgent     string
+
+	maxCNAMEChainLength uint
+	padEncrypted        bool
+	ednsUDPSize         uint16
+	upstreamCookies     *upstreamCookies
 
 	// To allow replacing during tests
 	systemResolver *net.Resolver
@@ -46,11 +53,21 @@ func NewBootstrap(cfg *config.Config) (b *Bootstrap, err error) {
 	b = &Bootstrap{
 		log:              log,
 		connectIPVersion: cfg.ConnectIPVersion,
+		upstreamTimeout:  cfg.Upstreams.Timeout,
+		dohUserAgent:     cfg.DoHUserAgent,
+
+		maxCNAMEChainLength: cfg.Upstreams.MaxCNAMEChainLength,
+		padEncrypted:        cfg.Upstreams.Padding,
+		ednsUDPSize:         cfg.UDPSize(),
 
 		systemResolver: net.DefaultResolver,
 		dialer:         &net.Dialer{},
 	}
 
+	if cfg.Cookies.Upstream {
+		b.upstreamCookies = newUpstreamCookies(cfg.Cookies.SecretRotation.ToDuration())
+	}
+
 	bootstraped, err := newBootstrapedResolvers(b, cfg.BootstrapDNS)
 	if err != nil {
 		return nil, err
@@ -84,7 +101,9 @@ func NewBootstrap(cfg *config.Config) (b *Bootstrap, err error) {
 
 	b.resolver = Chain(
 		NewFilteringResolver(cfg.Filtering),
-		newCachingResolver(cachingCfg, nil, false), // false: no metrics, to not overwrite the main blocking resolver ones
+		// the bootstrap resolver is used for the whole runtime, so its caches are never stopped
+		// false: no metrics, to not overwrite the main blocking resolver ones
+		newCachingResolver(context.Background(), cachingCfg, nil, "", false),
 		parallelResolver,
 	)
 
@@ -109,18 +128,16 @@ func (b *Bootstrap) UpstreamIPs(r *UpstreamResolver) (*IPSet, error) {
 func (b *Bootstrap) resolveUpstream(r Resolver, host string) ([]net.IP, error) {
 	// Use system resolver if no bootstrap is configured
 	if b.resolver == nil {
-		cfg := config.GetConfig()
 		ctx := context.Background()
 
-		timeout := cfg.Upstreams.Timeout
-		if timeout.IsAboveZero() {
+		if b.upstreamTimeout.IsAboveZero() {
 			var cancel context.CancelFunc
 
-			ctx, cancel = context.WithTimeout(ctx, timeout.ToDuration())
+			ctx, cancel = context.WithTimeout(ctx, b.upstreamTimeout.ToDuration())
 			defer cancel()
 		}
 
-		return b.systemResolver.LookupIP(ctx, cfg.ConnectIPVersion.Net(), host)
+		return b.systemResolver.LookupIP(ctx, b.connectIPVersion.Net(), host)
 	}
 
 	if ips, ok := b.bootstraped[r]; ok {
@@ -131,6 +148,69 @@ func (b *Bootstrap) resolveUpstream(r Resolver, host string) ([]net.IP, error) {
 	return b.resolve(host, b.connectIPVersion.QTypes())
 }
 
+// upstreamClientConfig returns the timeout and DoH user agent for upstream clients, nil-safe for tests
+func (b *Bootstrap) upstreamClientConfig() (timeout time.Duration, dohUserAgent string) {
+	if b == nil {
+		return 0, ""
+	}
+
+	return b.upstreamTimeout.ToDuration(), b.dohUserAgent
+}
+
+func (b *Bootstrap) cnameChainLimit() uint {
+	if b == nil {
+		return 0
+	}
+
+	return b.maxCNAMEChainLength
+}
+
+// udpSize returns the EDNS UDP size advertised to the upstreams, 0 keeps the size of the client
+func (b *Bootstrap) udpSize() uint16 {
+	if b == nil {
+		return 0
+	}
+
+	return b.ednsUDPSize
+}
+
+// cookies returns the DNS cookies of the upstream, nil if no cookies are sent to it.
+// Only UDP queries carry cookies, so they're only sent to tcp+udp upstreams
+func (b *Bootstrap) cookies(upstream config.Upstream) *upstreamCookies {
+	if b == nil || upstream.Net != config.NetProtocolTcpUdp {
+		return nil
+	}
+
+	return b.upstreamCookies
+}
+
+// padding returns true if the queries to the upstream are padded: encrypted upstreams are padded by default,
+// others only with the padding option of the upstream
+func (b *Bootstrap) padding(upstream config.Upstream) bool {
+	if upstream.Padding {
+		return true
+	}
+
+	return b != nil && b.padEncrypted && upstream.IsEncrypted()
+}
+
+// ResetConnections forgets the resolved upstream IPs and resets the connections to the bootstrap upstreams.
+func (b *Bootstrap) ResetConnections() {
+	if b.resolver == nil {
+		return
+	}
+
+	ForEach(b.resolver, func(res Resolver) {
+		if caching, ok := res.(*CachingResolver); ok {
+			caching.resultCache.Clear()
+		}
+	})
+
+	for resolver := range b.bootstraped {
+		ResetConnections(resolver)
+	}
+}
+
 // NewHTTPTransport returns a new http.Transport that uses b to resolve hostnames
 func (b *Bootstrap) NewHTTPTransport() *http.Transport {
 	if b.resolver == nil {
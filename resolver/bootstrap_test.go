@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"time"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/util"
@@ -209,6 +211,28 @@ var _ = Describe("Bootstrap", Label("bootstrap"), func() {
 				})
 			})
 		})
+
+		Describe("cookies", func() {
+			It("should send cookies only to tcp+udp upstreams", func() {
+				Expect(sut.cookies(config.Upstream{Net: config.NetProtocolTcpUdp})).Should(BeNil())
+
+				sutConfig.Cookies.Upstream = true
+				sutConfig.Cookies.SecretRotation = config.Duration(time.Hour)
+
+				sut, err = NewBootstrap(sutConfig)
+				Expect(err).Should(Succeed())
+
+				Expect(sut.cookies(config.Upstream{Net: config.NetProtocolTcpUdp})).ShouldNot(BeNil())
+				Expect(sut.cookies(config.Upstream{Net: config.NetProtocolTcpTls})).Should(BeNil())
+				Expect(sut.cookies(config.Upstream{Net: config.NetProtocolHttps})).Should(BeNil())
+			})
+
+			It("should be nil-safe", func() {
+				var b *Bootstrap
+
+				Expect(b.cookies(config.Upstream{Net: config.NetProtocolTcpUdp})).Should(BeNil())
+			})
+		})
 	})
 
 	Describe("resolving", func() {
@@ -307,7 +331,7 @@ var _ = Describe("Bootstrap", Label("bootstrap"), func() {
 					Log: logrus.NewEntry(log.Log()),
 				}
 
-				mockUpstreamServer := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+				mockUpstreamServer := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 				DeferCleanup(mockUpstreamServer.Close)
 				upstream := mockUpstreamServer.Start()
 
@@ -560,15 +584,15 @@ var _ = Describe("Bootstrap", Label("bootstrap"), func() {
 
 	Describe("multiple upstreams", func() {
 		var (
-			mockUpstream1 *MockUDPUpstreamServer
-			mockUpstream2 *MockUDPUpstreamServer
+			mockUpstream1 *dnstest.MockUpstreamServer
+			mockUpstream2 *dnstest.MockUpstreamServer
 		)
 
 		BeforeEach(func() {
-			mockUpstream1 = NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+			mockUpstream1 = dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 			DeferCleanup(mockUpstream1.Close)
 
-			mockUpstream2 = NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+			mockUpstream2 = dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 			DeferCleanup(mockUpstream1.Close)
 
 			sutConfig.BootstrapDNS = []config.BootstrappedUpstreamConfig{
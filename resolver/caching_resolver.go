@@ -1,13 +1,23 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/0xERR0R/blocky/cache/expirationcache"
+	"github.com/0xERR0R/blocky/cache/stringcache"
+	"github.com/0xERR0R/blocky/chaos"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/lists/parsers"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/redis"
@@ -17,7 +27,24 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const defaultCachingCleanUpInterval = 5 * time.Second
+const (
+	defaultCachingCleanUpInterval = 5 * time.Second
+	defaultShardsPerCPU           = 4
+
+	// revalidateFailureCooldown is how long an entry isn't revalidated again after a failed refresh,
+	// so an unhealthy upstream isn't queried for every answer served from the cache
+	revalidateFailureCooldown = 30 * time.Second
+
+	excludeGroup         = "exclude"
+	prefetchExcludeGroup = "prefetchExclude"
+)
+
+//nolint:gochecknoglobals
+var (
+	// groups of the exclusion checks, declared once since they are checked for each query
+	excludeGroups         = []string{excludeGroup}
+	prefetchExcludeGroups = []string{prefetchExcludeGroup}
+)
 
 // CachingResolver caches answers from dns queries with their TTL time,
 // to avoid external resolver calls for recurrent queries
@@ -27,33 +54,59 @@ type CachingResolver struct {
 	typed
 
 	emitMetricEvents bool // disabled by Bootstrap
+	profile          string
 
 	resultCache          expirationcache.ExpiringCache[cacheValue]
 	prefetchingNameCache expirationcache.ExpiringCache[int]
+	prefetchFailures     expirationcache.ExpiringCache[int] // consecutive failed refreshes per cache key
 	redisClient          *redis.Client
+
+	// cache keys with a running background refresh (stale-while-revalidate)
+	revalidating sync.Map
+	// cache keys which aren't revalidated until the cooldown after a failed refresh expires
+	revalidateFailures expirationcache.ExpiringCache[struct{}]
+
+	// domains which are never cached
+	excludes stringcache.GroupedStringCache
 }
 
-// cacheValue includes query answer and prefetch flag
+// cacheValue includes query answer, prefetch flag and the TTL the answer was cached with
 type cacheValue struct {
 	resultMsg *dns.Msg
 	prefetch  bool
+	ttl       time.Duration
 }
 
-// NewCachingResolver creates a new resolver instance
-func NewCachingResolver(cfg config.CachingConfig, redis *redis.Client) *CachingResolver {
-	return newCachingResolver(cfg, redis, true)
+// NewCachingResolver creates a new resolver instance, its metric events are published for the profile.
+// The caches are cleaned up until ctx is done.
+func NewCachingResolver(
+	ctx context.Context, cfg config.CachingConfig, redis *redis.Client, profile string,
+) *CachingResolver {
+	c := newCachingResolver(ctx, cfg, redis, profile, true)
+
+	if len(cfg.WarmupDomains) > 0 && cfg.MaxCachingTime >= 0 {
+		_ = evt.Bus().SubscribeOnce(evt.ApplicationStarted, func(_ ...string) {
+			go c.warmUp(ctx)
+		})
+	}
+
+	return c
 }
 
-func newCachingResolver(cfg config.CachingConfig, redis *redis.Client, emitMetricEvents bool) *CachingResolver {
+func newCachingResolver(
+	ctx context.Context, cfg config.CachingConfig, redis *redis.Client, profile string, emitMetricEvents bool,
+) *CachingResolver {
 	c := &CachingResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("caching"),
 
 		redisClient:      redis,
 		emitMetricEvents: emitMetricEvents,
+		profile:          profile,
 	}
 
-	configureCaches(c, &cfg)
+	configureCaches(ctx, c, &cfg)
+	configureExcludes(c, &cfg)
 
 	if c.redisClient != nil {
 		setupRedisCacheSubscriber(c)
@@ -63,23 +116,85 @@ func newCachingResolver(cfg config.CachingConfig, redis *redis.Client, emitMetri
 	return c
 }
 
-func configureCaches(c *CachingResolver, cfg *config.CachingConfig) {
+func configureCaches(ctx context.Context, c *CachingResolver, cfg *config.CachingConfig) {
+	shards := cacheShardCount(cfg)
 	cleanupOption := expirationcache.WithCleanUpInterval[cacheValue](defaultCachingCleanUpInterval)
 	maxSizeOption := expirationcache.WithMaxSize[cacheValue](uint(cfg.MaxItemsCount))
+	maxBytesOption := expirationcache.WithMaxBytes(uint64(cfg.MaxSize), func(val *cacheValue) int {
+		return val.resultMsg.Len()
+	})
+	onEvictedOption := expirationcache.WithOnEvictedFn[cacheValue](func(key string) {
+		publishMetricsIfEnabled(c, evt.CachingResultCacheEvicted, key)
+	})
+
+	if cfg.StaleWhileRevalidate.IsEnabled() {
+		c.revalidateFailures = expirationcache.NewShardedCache(
+			ctx, shards,
+			expirationcache.WithCleanUpInterval[struct{}](time.Minute),
+		)
+	}
 
 	if cfg.Prefetching {
-		c.prefetchingNameCache = expirationcache.NewCache(
+		c.prefetchingNameCache = expirationcache.NewShardedCache(
+			ctx, shards,
 			expirationcache.WithCleanUpInterval[int](time.Minute),
 			expirationcache.WithMaxSize[int](uint(cfg.PrefetchMaxItemsCount)),
+			// least recently queried domains are evicted first
+			expirationcache.WithOnEvictedFn[int](func(key string) {
+				_, domain := util.ExtractCacheKey(key)
+
+				publishMetricsIfEnabled(c, evt.CachingPrefetchDomainEvicted, domain)
+			}),
 		)
 
-		c.resultCache = expirationcache.NewCache(
+		c.prefetchFailures = expirationcache.NewShardedCache(
+			ctx, shards,
+			expirationcache.WithCleanUpInterval[int](time.Minute),
+		)
+
+		c.resultCache = expirationcache.NewShardedCache(
+			ctx, shards,
 			cleanupOption,
 			maxSizeOption,
+			maxBytesOption,
+			onEvictedOption,
 			expirationcache.WithOnExpiredFn(c.onExpired),
 		)
 	} else {
-		c.resultCache = expirationcache.NewCache(cleanupOption, maxSizeOption)
+		c.resultCache = expirationcache.NewShardedCache(
+			ctx, shards, cleanupOption, maxSizeOption, maxBytesOption, onEvictedOption,
+		)
+	}
+}
+
+// cacheShardCount returns the configured number of cache shards or a default based on the available CPUs
+func cacheShardCount(cfg *config.CachingConfig) uint {
+	if cfg.Shards > 0 {
+		return uint(cfg.Shards)
+	}
+
+	return uint(runtime.GOMAXPROCS(0) * defaultShardsPerCPU)
+}
+
+func configureExcludes(c *CachingResolver, cfg *config.CachingConfig) {
+	// same pattern matching as for blocking lists, extended by wildcards
+	c.excludes = stringcache.NewChainedGroupedCache(
+		stringcache.NewInMemoryGroupedStringCache(),
+		stringcache.NewInMemoryGroupedRegexCache(),
+		stringcache.NewInMemoryGroupedWildcardCache(),
+	)
+
+	for group, entries := range map[string][]string{
+		excludeGroup:         cfg.Exclude,
+		prefetchExcludeGroup: cfg.PrefetchExclude,
+	} {
+		factory := c.excludes.Refresh(group)
+
+		for _, entry := range entries {
+			factory.AddEntry(entry)
+		}
+
+		factory.Finish()
 	}
 }
 
@@ -87,6 +202,10 @@ func setupRedisCacheSubscriber(c *CachingResolver) {
 	go func() {
 		for rc := range c.redisClient.CacheChannel {
 			if rc != nil {
+				if _, domain := util.ExtractCacheKey(rc.Key); c.isExcluded(domain) {
+					continue
+				}
+
 				c.log().Debug("Received key from redis: ", rc.Key)
 				c.putInCache(rc.Key, rc.Response, false, false)
 			}
@@ -96,6 +215,10 @@ func setupRedisCacheSubscriber(c *CachingResolver) {
 
 // check if domain was queried > threshold in the time window
 func (r *CachingResolver) shouldPrefetch(cacheKey string) bool {
+	if r.isPrefetchEvicted(cacheKey) {
+		return false
+	}
+
 	if r.cfg.PrefetchThreshold == 0 {
 		return true
 	}
@@ -106,7 +229,13 @@ func (r *CachingResolver) shouldPrefetch(cacheKey string) bool {
 }
 
 func (r *CachingResolver) onExpired(cacheKey string) (val *cacheValue, ttl time.Duration) {
-	qType, domainName := util.ExtractCacheKey(cacheKey)
+	qType, domainName, subnet := util.ExtractCacheKeyWithSubnet(cacheKey)
+
+	if r.isPrefetchExcluded(domainName) {
+		r.log().Debugf("domain '%s' is excluded from prefetching", util.Obfuscate(domainName))
+
+		return nil, 0
+	}
 
 	if r.shouldPrefetch(cacheKey) {
 		logger := r.log()
@@ -114,22 +243,211 @@ func (r *CachingResolver) onExpired(cacheKey string) (val *cacheValue, ttl time.
 		logger.Debugf("prefetching '%s' (%s)", util.Obfuscate(domainName), qType)
 
 		req := newRequest(fmt.Sprintf("%s.", domainName), qType, logger)
+		util.SetEdns0Do(req.Req)
+
+		if subnet != nil {
+			// refresh with the same client subnet as the entry was populated with
+			util.SetClientSubnet(req.Req, subnet)
+		}
+
 		response, err := r.next.Resolve(req)
 
 		if err == nil {
-			if response.Res.Rcode == dns.RcodeSuccess {
-				r.publishMetricsIfEnabled(evt.CachingDomainPrefetched, domainName)
+			if response.Res.Rcode == dns.RcodeSuccess && r.isCacheable(req.Req, response.Res, logger) {
+				r.prefetchFailures.Delete(cacheKey)
+				publishMetricsIfEnabled(r, evt.CachingDomainPrefetched, domainName)
+
+				ttl := r.cacheTTL(response.Res)
 
-				return &cacheValue{response.Res, true}, r.adjustTTLs(response.Res.Answer)
+				return &cacheValue{resultMsg: response.Res, prefetch: true, ttl: ttl}, ttl
 			}
 		} else {
 			util.LogOnError(fmt.Sprintf("can't prefetch '%s' ", domainName), err)
 		}
+
+		r.onPrefetchFailed(cacheKey, domainName, logger)
 	}
 
 	return nil, 0
 }
 
+// shouldRevalidate checks if the remaining TTL of the entry reached the stale-while-revalidate threshold
+// and no refresh is already running
+func (r *CachingResolver) shouldRevalidate(cacheKey, domain string, val *cacheValue, remaining time.Duration) bool {
+	if !r.cfg.StaleWhileRevalidate.IsReached(remaining, val.ttl) {
+		return false
+	}
+
+	if r.isPrefetchExcluded(domain) {
+		return false
+	}
+
+	if x, _ := r.revalidateFailures.Get(cacheKey); x != nil {
+		// the last refresh failed recently
+		return false
+	}
+
+	_, running := r.revalidating.LoadOrStore(cacheKey, struct{}{})
+
+	return !running
+}
+
+// revalidate refreshes the cache entry via the next resolver, the entry is kept if the refresh fails
+func (r *CachingResolver) revalidate(cacheKey string, prefetch bool) {
+	defer r.revalidating.Delete(cacheKey)
+
+	qType, domainName, subnet := util.ExtractCacheKeyWithSubnet(cacheKey)
+	logger := r.log()
+
+	logger.Debugf("revalidating '%s' (%s)", util.Obfuscate(domainName), qType)
+
+	req := newRequest(fmt.Sprintf("%s.", domainName), qType, logger)
+	util.SetEdns0Do(req.Req)
+
+	if subnet != nil {
+		// refresh with the same client subnet as the entry was populated with
+		util.SetClientSubnet(req.Req, subnet)
+	}
+
+	response, err := r.next.Resolve(req)
+	if err == nil && r.cacheTTL(response.Res) > 0 && r.isCacheable(req.Req, response.Res, logger) {
+		r.putInCache(cacheKey, response, prefetch, true)
+
+		return
+	}
+
+	if err != nil {
+		logger.Debugf("can't revalidate '%s': %s", util.Obfuscate(domainName), err)
+	}
+
+	publishMetricsIfEnabled(r, evt.CachingRevalidateFailed, domainName)
+	r.revalidateFailures.Put(cacheKey, &struct{}{}, revalidateFailureCooldown)
+}
+
+// onPrefetchFailed counts the consecutive failed or negative refreshes, a domain is evicted from the
+// prefetch tracking if they reach the max failures
+func (r *CachingResolver) onPrefetchFailed(cacheKey, domainName string, logger *logrus.Entry) {
+	if r.cfg.PrefetchMaxFailures <= 0 {
+		return
+	}
+
+	var failures int
+	if x, _ := r.prefetchFailures.Get(cacheKey); x != nil {
+		failures = *x
+	}
+
+	failures++
+	r.prefetchFailures.Put(cacheKey, &failures, r.cfg.PrefetchExpires.ToDuration())
+
+	if failures < r.cfg.PrefetchMaxFailures {
+		return
+	}
+
+	logger.Debugf("prefetching of '%s' failed %d times in a row, evicting it", util.Obfuscate(domainName), failures)
+
+	r.prefetchingNameCache.Delete(cacheKey)
+
+	publishMetricsIfEnabled(r, evt.CachingPrefetchFailedDomainEvicted, domainName)
+	publishMetricsIfEnabled(r, evt.CachingDomainsToPrefetchCountChanged, r.prefetchingNameCache.TotalCount())
+}
+
+// isPrefetchEvicted checks if the domain was evicted because of failed refreshes
+func (r *CachingResolver) isPrefetchEvicted(cacheKey string) bool {
+	if r.cfg.PrefetchMaxFailures <= 0 {
+		return false
+	}
+
+	failures, _ := r.prefetchFailures.Get(cacheKey)
+
+	return failures != nil && *failures >= r.cfg.PrefetchMaxFailures
+}
+
+// warmUp resolves the configured warm-up domains via the next resolver to populate the cache
+func (r *CachingResolver) warmUp(ctx context.Context) {
+	logger := r.log()
+
+	domains := r.loadWarmupDomains(ctx, logger)
+
+	logger.Debugf("warming up cache with %d domains", len(domains))
+
+	for _, domain := range domains {
+		if r.isExcluded(domain) {
+			continue
+		}
+
+		for _, qType := range []dns.Type{dns.Type(dns.TypeA), dns.Type(dns.TypeAAAA)} {
+			req := newRequest(dns.Fqdn(domain), qType, logger)
+			util.SetEdns0Do(req.Req)
+
+			response, err := r.next.Resolve(req)
+			if err != nil {
+				logger.Debugf("can't warm up '%s' (%s): %s", util.Obfuscate(domain), qType, err)
+
+				continue
+			}
+
+			if r.isCacheable(req.Req, response.Res, logger) {
+				r.putInCache(util.GenerateCacheKey(qType, domain), response, false, false)
+			}
+		}
+	}
+}
+
+// loadWarmupDomains reads the domains of all warm-up sources, invalid entries are skipped
+func (r *CachingResolver) loadWarmupDomains(ctx context.Context, logger *logrus.Entry) []string {
+	var domains []string
+
+	for i, source := range r.cfg.WarmupDomains {
+		if source.Type != config.BytesSourceTypeText && source.Type != config.BytesSourceTypeFile {
+			logger.Warnf("warm-up source %s is not supported, only inline lists and files can be used", source)
+
+			continue
+		}
+
+		opener, err := lists.NewSourceOpener(fmt.Sprintf("item #%d", i), source, nil)
+		if err != nil {
+			logger.Warnf("can't open warm-up source: %s", err)
+
+			continue
+		}
+
+		err = r.parseWarmupSource(ctx, opener, func(domain string) {
+			domains = append(domains, domain)
+		})
+		if err != nil {
+			logger.Warnf("can't read warm-up source %s: %s", opener, err)
+		}
+	}
+
+	return domains
+}
+
+func (r *CachingResolver) parseWarmupSource(ctx context.Context, opener lists.SourceOpener, add func(string)) error {
+	reader, err := opener.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	p := parsers.AllowErrors(parsers.HostList(reader), parsers.NoErrorLimit)
+	p.OnErr(func(err error) {
+		r.log().Debugf("error parsing %s: %s, skipping entry", opener, err)
+	})
+
+	return parsers.ForEach[*parsers.HostListEntry](ctx, p, func(entry *parsers.HostListEntry) error {
+		host := entry.String()
+
+		// regexes, wildcards and IPs can't be resolved
+		if strings.HasPrefix(host, "/") || strings.Contains(host, "*") || net.ParseIP(host) != nil {
+			return nil
+		}
+
+		add(util.ExtractDomainOnly(host))
+
+		return nil
+	})
+}
+
 // LogConfig implements `config.Configurable`.
 func (r *CachingResolver) LogConfig(logger *logrus.Entry) {
 	r.cfg.LogConfig(logger)
@@ -140,42 +458,75 @@ func (r *CachingResolver) LogConfig(logger *logrus.Entry) {
 // Resolve checks if the current query result is already in the cache and returns it
 // or delegates to the next resolver
 func (r *CachingResolver) Resolve(request *model.Request) (response *model.Response, err error) {
-	logger := log.WithPrefix(request.Log, "caching_resolver")
-
 	if r.cfg.MaxCachingTime < 0 {
-		logger.Debug("skip cache")
+		r.requestLogger(request, "").Debug("skip cache")
 
 		return r.next.Resolve(request)
 	}
 
+	debug := request.Log.Logger.IsLevelEnabled(logrus.DebugLevel)
+
 	for _, question := range request.Req.Question {
 		domain := util.ExtractDomain(question)
-		cacheKey := util.GenerateCacheKey(dns.Type(question.Qtype), domain)
-		logger := logger.WithField("domain", util.Obfuscate(domain))
+		cacheKey := r.cacheKey(dns.Type(question.Qtype), domain, request.Req)
+
+		if r.isExcluded(domain) {
+			r.requestLogger(request, domain).Debug("domain is excluded from caching")
+
+			publishMetricsIfEnabled(r, evt.CachingResultCacheExcluded, domain)
 
-		r.trackQueryDomainNameCount(domain, cacheKey, logger)
+			response, err = r.next.Resolve(request)
+
+			continue
+		}
+
+		r.trackQueryDomainNameCount(request, domain, cacheKey, debug)
 
 		val, ttl := r.resultCache.Get(cacheKey)
 
 		if val != nil {
-			logger.Debug("domain is cached")
+			if debug {
+				r.requestLogger(request, domain).Debug("domain is cached")
+			}
 
-			r.publishMetricsIfEnabled(evt.CachingResultCacheHit, domain)
+			publishMetricsIfEnabled(r, evt.CachingResultCacheHit, domain)
 
 			if val.prefetch {
 				// Hit from prefetch cache
-				r.publishMetricsIfEnabled(evt.CachingPrefetchCacheHit, domain)
+				publishMetricsIfEnabled(r, evt.CachingPrefetchCacheHit, domain)
+			}
+
+			if r.shouldRevalidate(cacheKey, domain, val, ttl) {
+				// answer from cache, the entry is refreshed in the background
+				publishMetricsIfEnabled(r, evt.CachingRevalidateHit, domain)
+
+				go r.revalidate(cacheKey, val.prefetch)
 			}
 
 			resp := val.resultMsg.Copy()
 			resp.SetReply(request.Req)
 			resp.Rcode = val.resultMsg.Rcode
 
+			chaos.CorruptCacheEntry(resp)
+
+			if request.Req.IsEdns0() == nil {
+				removeEdns0(resp)
+			}
+
 			// Adjust TTL
 			for _, rr := range resp.Answer {
 				rr.Header().Ttl = uint32(ttl.Seconds())
 			}
 
+			if len(resp.Answer) == 0 {
+				// negative response: the SOA record's TTL is the remaining negative cache time
+				for _, rr := range resp.Ns {
+					if soa, ok := rr.(*dns.SOA); ok {
+						soa.Hdr.Ttl = uint32(ttl.Seconds())
+					}
+				}
+			}
+
 			if resp.Rcode == dns.RcodeSuccess {
 				return &model.Response{Res: resp, RType: model.ResponseTypeCACHED, Reason: "CACHED"}, nil
 			}
@@ -183,47 +534,149 @@ func (r *CachingResolver) Resolve(request *model.Request) (response *model.Respo
 			return &model.Response{Res: resp, RType: model.ResponseTypeCACHED, Reason: "CACHED NEGATIVE"}, nil
 		}
 
-		r.publishMetricsIfEnabled(evt.CachingResultCacheMiss, domain)
+		publishMetricsIfEnabled(r, evt.CachingResultCacheMiss, domain)
+
+		logger := r.requestLogger(request, domain)
 
 		logger.WithField("next_resolver", Name(r.next)).Debug("not in cache: go to next resolver")
-		response, err = r.next.Resolve(request)
+		response, err = r.next.Resolve(withDNSSECOK(request))
 
-		if err == nil {
+		if err != nil {
+			continue
+		}
+
+		if r.isCacheable(request.Req, response.Res, logger) {
 			r.putInCache(cacheKey, response, false, true)
 		}
+
+		if request.Req.IsEdns0() == nil && response.Res.IsEdns0() != nil {
+			// the response is shared with the cache
+			response.Res = response.Res.Copy()
+			removeEdns0(response.Res)
+		}
 	}
 
 	return response, err
 }
 
-func (r *CachingResolver) trackQueryDomainNameCount(domain, cacheKey string, logger *logrus.Entry) {
+// withDNSSECOK returns the request with the DO bit: the cache always stores the response with the DNSSEC records,
+// so it can answer clients, which validate themselves. The records are removed for other clients by the server
+func withDNSSECOK(request *model.Request) *model.Request {
+	if opt := request.Req.IsEdns0(); opt != nil && opt.Do() {
+		return request
+	}
+
+	req := request.Req.Copy()
+	util.SetEdns0Do(req)
+
+	result := *request
+	result.Req = req
+
+	return &result
+}
+
+// removeEdns0 removes the EDNS record of the response to a request without EDNS (RFC 6891, 7)
+func removeEdns0(msg *dns.Msg) {
+	msg.Extra = slices.DeleteFunc(msg.Extra, func(rr dns.RR) bool {
+		return rr.Header().Rrtype == dns.TypeOPT
+	})
+}
+
+// requestLogger returns the logger of the request with the domain, if not empty.
+// It's only created when needed, since the fields allocate on the fast path of cache hits
+func (r *CachingResolver) requestLogger(request *model.Request, domain string) *logrus.Entry {
+	logger := log.WithPrefix(request.Log, "caching_resolver")
+
+	if domain == "" {
+		return logger
+	}
+
+	return logger.WithField("domain", util.Obfuscate(domain))
+}
+
+// cacheKey returns the cache key of the question, partitioned by the EDNS client subnet if enabled
+func (r *CachingResolver) cacheKey(qType dns.Type, domain string, req *dns.Msg) string {
+	if r.cfg.PartitionByECS {
+		return util.GenerateCacheKeyWithSubnet(qType, domain, util.ClientSubnet(req))
+	}
+
+	return util.GenerateCacheKey(qType, domain)
+}
+
+// isCacheable checks that the response answers the request, so a misbehaving resolver can't poison the cache
+func (r *CachingResolver) isCacheable(req, resp *dns.Msg, logger *logrus.Entry) bool {
+	if req.CheckingDisabled {
+		// the answer wasn't validated by DNSSEC, it's only for the client, which validates it itself
+		return false
+	}
+
+	if err := util.ValidateResponse(req, resp); err != nil {
+		logger.Warnf("response is not cached: %s", err)
+
+		return false
+	}
+
+	if len(req.Question) > 0 {
+		// loops are rejected independently of the upstream configuration, the length was checked there
+		if err := util.CheckCNAMEChain(req.Question[0].Name, resp.Answer, 0); err != nil {
+			logger.Warnf("response is not cached: %s", err)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// isExcluded checks if the domain matches one of the configured exclusions
+func (r *CachingResolver) isExcluded(domain string) bool {
+	return len(r.excludes.Contains(domain, excludeGroups)) > 0
+}
+
+// isPrefetchExcluded checks if the domain matches one of the configured prefetching exclusions
+func (r *CachingResolver) isPrefetchExcluded(domain string) bool {
+	return len(r.excludes.Contains(domain, prefetchExcludeGroups)) > 0
+}
+
+// trackQueryDomainNameCount counts the query for prefetching, debug enables the logging of the count
+func (r *CachingResolver) trackQueryDomainNameCount(request *model.Request, domain, cacheKey string, debug bool) {
 	if r.prefetchingNameCache != nil {
+		if r.isPrefetchExcluded(domain) {
+			r.requestLogger(request, domain).Debug("domain is excluded from prefetching, not tracked")
+
+			return
+		}
+
 		var domainCount int
 		if x, _ := r.prefetchingNameCache.Get(cacheKey); x != nil {
 			domainCount = *x
 		}
 		domainCount++
 		r.prefetchingNameCache.Put(cacheKey, &domainCount, r.cfg.PrefetchExpires.ToDuration())
+
+		if domainCount > r.cfg.PrefetchThreshold && r.isPrefetchEvicted(cacheKey) {
+			// queried often enough again since the eviction
+			r.prefetchFailures.Delete(cacheKey)
+		}
 		totalCount := r.prefetchingNameCache.TotalCount()
 
-		logger.Debugf("domain '%s' was requested %d times, "+
-			"total cache size: %d", util.Obfuscate(domain), domainCount, totalCount)
-		r.publishMetricsIfEnabled(evt.CachingDomainsToPrefetchCountChanged, totalCount)
+		if debug {
+			r.requestLogger(request, domain).Debugf("domain was requested %d times, total cache size: %d",
+				domainCount, totalCount)
+		}
+
+		publishMetricsIfEnabled(r, evt.CachingDomainsToPrefetchCountChanged, totalCount)
 	}
 }
 
 func (r *CachingResolver) putInCache(cacheKey string, response *model.Response, prefetch, publish bool) {
-	if response.Res.Rcode == dns.RcodeSuccess {
-		// put value into cache
-		r.resultCache.Put(cacheKey, &cacheValue{response.Res, prefetch}, r.adjustTTLs(response.Res.Answer))
-	} else if response.Res.Rcode == dns.RcodeNameError {
-		if r.cfg.CacheTimeNegative.IsAboveZero() {
-			// put negative cache if result code is NXDOMAIN
-			r.resultCache.Put(cacheKey, &cacheValue{response.Res, prefetch}, r.cfg.CacheTimeNegative.ToDuration())
-		}
+	// only NOERROR and NXDOMAIN are cached, SERVFAIL and other errors never are
+	if ttl := r.cacheTTL(response.Res); ttl > 0 {
+		r.resultCache.Put(cacheKey, &cacheValue{resultMsg: response.Res, prefetch: prefetch, ttl: ttl}, ttl)
 	}
 
-	r.publishMetricsIfEnabled(evt.CachingResultCacheChanged, r.resultCache.TotalCount())
+	publishMetricsIfEnabled(r, evt.CachingResultCacheChanged, r.resultCache.TotalCount())
+	publishMetricsIfEnabled(r, evt.CachingResultCacheSizeChanged, r.resultCache.TotalSize())
 
 	if publish && r.redisClient != nil {
 		res := *response.Res
@@ -232,16 +685,73 @@ func (r *CachingResolver) putInCache(cacheKey string, response *model.Response,
 	}
 }
 
+// cacheTTL returns how long the response can be cached, 0 means it must not be cached
+func (r *CachingResolver) cacheTTL(msg *dns.Msg) time.Duration {
+	switch msg.Rcode {
+	case dns.RcodeSuccess:
+		if len(msg.Answer) == 0 {
+			// NODATA
+			return r.adjustNegativeTTL(msg)
+		}
+
+		return r.adjustTTLs(msg.Answer)
+
+	case dns.RcodeNameError:
+		return r.adjustNegativeTTL(msg)
+
+	default:
+		return 0
+	}
+}
+
+// adjustNegativeTTL calculates the TTL of a negative response (NXDOMAIN or NODATA) as described in RFC 2308:
+// the minimum of the SOA record's TTL and its MINIMUM field, bounded by the min cache time and max negative time.
+// The SOA record's TTL is adjusted accordingly.
+// Without SOA record, the negative cache time is used.
+func (r *CachingResolver) adjustNegativeTTL(msg *dns.Msg) time.Duration {
+	if !r.cfg.CacheTimeNegative.IsAboveZero() {
+		// negative caching is disabled
+		return 0
+	}
+
+	var soa *dns.SOA
+
+	for _, rr := range msg.Ns {
+		if s, ok := rr.(*dns.SOA); ok {
+			soa = s
+
+			break
+		}
+	}
+
+	if soa == nil {
+		return r.cfg.CacheTimeNegative.ToDuration()
+	}
+
+	ttl := atomic.LoadUint32(&soa.Hdr.Ttl)
+	if soa.Minttl < ttl {
+		ttl = soa.Minttl
+	}
+
+	if r.cfg.MinCachingTime.IsAboveZero() && ttl < r.cfg.MinCachingTime.SecondsU32() {
+		ttl = r.cfg.MinCachingTime.SecondsU32()
+	}
+
+	if r.cfg.MaxNegativeTime.IsAboveZero() && ttl > r.cfg.MaxNegativeTime.SecondsU32() {
+		ttl = r.cfg.MaxNegativeTime.SecondsU32()
+	}
+
+	atomic.StoreUint32(&soa.Hdr.Ttl, ttl)
+
+	return time.Duration(ttl) * time.Second
+}
+
 // adjustTTLs calculates and returns the max TTL (considers also the min and max cache time)
-// for all records from answer or a negative cache time for empty answer
+// for all records from answer
 // adjust the TTL in the answer header accordingly
 func (r *CachingResolver) adjustTTLs(answer []dns.RR) (maxTTL time.Duration) {
 	var max uint32
 
-	if len(answer) == 0 {
-		return r.cfg.CacheTimeNegative.ToDuration()
-	}
-
 	for _, a := range answer {
 		// if TTL < mitTTL -> adjust the value, set minTTL
 		if r.cfg.MinCachingTime.IsAboveZero() {
@@ -265,8 +775,10 @@ func (r *CachingResolver) adjustTTLs(answer []dns.RR) (maxTTL time.Duration) {
 	return time.Duration(max) * time.Second
 }
 
-func (r *CachingResolver) publishMetricsIfEnabled(event string, val interface{}) {
+// publishMetricsIfEnabled publishes the event with the profile and the value. It's generic, so the value is only
+// converted to an interface (which allocates) if the events are enabled
+func publishMetricsIfEnabled[T any](r *CachingResolver, event string, val T) {
 	if r.emitMetricEvents {
-		evt.Bus().Publish(event, val)
+		evt.Bus().Publish(event, r.profile, val)
 	}
 }
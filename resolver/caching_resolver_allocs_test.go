@@ -0,0 +1,78 @@
+//go:build !race && !chaos
+
+package resolver
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Limits of the allocations of a cache hit of a single record answer without debug logging and metric events.
+// The race detector and the fault injection allocate as well, so the limits are only checked without them.
+const (
+	// copy of the message (4), reply question, response and cache key
+	maxCacheHitAllocs = 7
+
+	// the count of the query for prefetching is updated in its cache
+	maxPrefetchCacheHitAllocs = maxCacheHitAllocs + 3
+)
+
+var _ = Describe("CachingResolver allocations", func() {
+	var sutConfig config.CachingConfig
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.CachingConfig]()
+		Expect(err).Should(Succeed())
+	})
+
+	cacheHitAllocs := func() float64 {
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		sut := newCachingResolver(ctx, sutConfig, nil, "", false)
+
+		msg, err := util.NewMsgWithAnswer("example.com.", 3600, A, "123.122.121.120")
+		Expect(err).Should(Succeed())
+
+		sut.resultCache.Put(util.GenerateCacheKey(A, "example.com"), &cacheValue{resultMsg: msg}, time.Hour)
+
+		logger := logrus.New()
+		logger.Out = io.Discard
+		logger.Level = logrus.InfoLevel
+
+		request := newRequest("example.com.", A, logrus.NewEntry(logger))
+
+		Expect(sut.Resolve(request)).Should(HaveResponseType(model.ResponseTypeCACHED))
+
+		return testing.AllocsPerRun(100, func() {
+			_, _ = sut.Resolve(request)
+		})
+	}
+
+	It("should not exceed the allocations of a cache hit", func() {
+		Expect(cacheHitAllocs()).Should(BeNumerically("<=", maxCacheHitAllocs))
+	})
+
+	When("prefetching is enabled", func() {
+		BeforeEach(func() {
+			sutConfig.Prefetching = true
+		})
+
+		It("should not exceed the allocations of a cache hit", func() {
+			Expect(cacheHitAllocs()).Should(BeNumerically("<=", maxPrefetchCacheHitAllocs))
+		})
+	})
+})
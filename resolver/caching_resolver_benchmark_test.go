@@ -0,0 +1,104 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+)
+
+const benchmarkCachedDomains = 10_000
+
+func BenchmarkCachingResolverParallel(b *testing.B) {
+	b.Run("1 shard", func(b *testing.B) {
+		benchmarkCachingResolverParallel(b, 1)
+	})
+
+	b.Run("default shards", func(b *testing.B) {
+		benchmarkCachingResolverParallel(b, 0)
+	})
+}
+
+func benchmarkCachingResolverParallel(b *testing.B, shards int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sut := newCachingResolver(ctx, config.CachingConfig{
+		MaxCachingTime:    config.Duration(time.Hour),
+		Prefetching:       true,
+		PrefetchExpires:   config.Duration(time.Hour),
+		PrefetchThreshold: 5,
+		Shards:            shards,
+	}, nil, "", false)
+
+	qType := dns.Type(dns.TypeA)
+	requests := make([]*model.Request, benchmarkCachedDomains)
+
+	for i := range requests {
+		domain := fmt.Sprintf("domain%d.example.com.", i)
+
+		msg, err := util.NewMsgWithAnswer(domain, 3600, qType, "123.122.121.120")
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		requests[i] = newRequest(domain, qType)
+
+		sut.resultCache.Put(util.GenerateCacheKey(qType, util.ExtractDomain(requests[i].Req.Question[0])),
+			&cacheValue{resultMsg: msg}, time.Hour)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+
+		for pb.Next() {
+			resp, err := sut.Resolve(requests[i%len(requests)])
+			if err != nil || resp.RType != model.ResponseTypeCACHED {
+				b.Errorf("expected cached response, got %v (%v)", resp, err)
+
+				return
+			}
+
+			i++
+		}
+	})
+}
+
+// BenchmarkCachingResolverHit measures the fast path of a cache hit, its allocations are limited by
+// `maxCacheHitAllocs`
+func BenchmarkCachingResolverHit(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sut := newCachingResolver(ctx, config.CachingConfig{MaxCachingTime: config.Duration(time.Hour)}, nil, "", false)
+
+	qType := dns.Type(dns.TypeA)
+
+	msg, err := util.NewMsgWithAnswer("example.com.", 3600, qType, "123.122.121.120")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	sut.resultCache.Put(util.GenerateCacheKey(qType, "example.com"), &cacheValue{resultMsg: msg}, time.Hour)
+
+	request := newRequest("example.com.", qType)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		resp, err := sut.Resolve(request)
+		if err != nil || resp.RType != model.ResponseTypeCACHED {
+			b.Fatalf("expected cached response, got %v (%v)", resp, err)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package resolver
 
 import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xERR0R/blocky/cache/expirationcache"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
 	. "github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
@@ -24,8 +29,12 @@ var _ = Describe("CachingResolver", func() {
 	var (
 		sut        *CachingResolver
 		sutConfig  config.CachingConfig
+		sutProfile string
+		sutCtx     context.Context
 		m          *mockResolver
 		mockAnswer *dns.Msg
+		// upstreamAnswer is the answer of the mock, set from `mockAnswer` for each spec
+		upstreamAnswer *atomic.Pointer[dns.Msg]
 	)
 
 	Describe("Type", func() {
@@ -39,13 +48,35 @@ var _ = Describe("CachingResolver", func() {
 		if err := defaults.Set(&sutConfig); err != nil {
 			panic(err)
 		}
+		sutProfile = ""
 		mockAnswer = new(dns.Msg)
+
+		var cancel context.CancelFunc
+
+		sutCtx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
 	})
 
 	JustBeforeEach(func() {
-		sut = NewCachingResolver(sutConfig, nil)
-		m = &mockResolver{}
-		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
+		sut = NewCachingResolver(sutCtx, sutConfig, nil, sutProfile)
+
+		// the cache goroutines may call the mock after the spec, when `mockAnswer` is already reassigned
+		answer := new(atomic.Pointer[dns.Msg])
+		answer.Store(mockAnswer.Copy())
+		upstreamAnswer = answer
+
+		m = &mockResolver{
+			ResponseFn: func(req *dns.Msg) *dns.Msg {
+				// answer like an upstream: with the ID and question of the request
+				msg := answer.Load()
+				resp := msg.Copy()
+				resp.SetReply(req)
+				resp.Rcode = msg.Rcode
+
+				return resp
+			},
+		}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: answer.Load()}, nil)
 		sut.Next(m)
 	})
 
@@ -65,6 +96,25 @@ var _ = Describe("CachingResolver", func() {
 		})
 	})
 
+	Describe("Events", func() {
+		BeforeEach(func() {
+			sutProfile = "lab"
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 600, A, "123.122.121.120")
+		})
+
+		It("should publish them for the profile", func() {
+			profile := make(chan string, 1)
+			Expect(Bus().SubscribeOnce(CachingResultCacheMiss, func(p, _ string) {
+				profile <- p
+			})).Should(Succeed())
+
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(profile).Should(Receive(Equal("lab")))
+		})
+	})
+
 	Describe("Caching responses", func() {
 		When("prefetching is enabled", func() {
 			BeforeEach(func() {
@@ -79,7 +129,7 @@ var _ = Describe("CachingResolver", func() {
 			It("should prefetch domain if query count > threshold", func() {
 				// prepare resolver, set smaller caching times for testing
 				prefetchThreshold := 5
-				configureCaches(sut, &sutConfig)
+				configureCaches(sutCtx, sut, &sutConfig)
 				sut.resultCache = expirationcache.NewCache(
 					expirationcache.WithCleanUpInterval[cacheValue](100*time.Millisecond),
 					expirationcache.WithOnExpiredFn(sut.onExpired))
@@ -87,14 +137,14 @@ var _ = Describe("CachingResolver", func() {
 				domainPrefetched := make(chan string, 1)
 				prefetchHitDomain := make(chan string, 1)
 				prefetchedCnt := make(chan int, 1)
-				Expect(Bus().SubscribeOnce(CachingPrefetchCacheHit, func(domain string) {
+				Expect(Bus().SubscribeOnce(CachingPrefetchCacheHit, func(_, domain string) {
 					prefetchHitDomain <- domain
 				})).Should(Succeed())
-				Expect(Bus().SubscribeOnce(CachingDomainPrefetched, func(domain string) {
+				Expect(Bus().SubscribeOnce(CachingDomainPrefetched, func(_, domain string) {
 					domainPrefetched <- domain
 				})).Should(Succeed())
 
-				Expect(Bus().SubscribeOnce(CachingDomainsToPrefetchCountChanged, func(cnt int) {
+				Expect(Bus().SubscribeOnce(CachingDomainsToPrefetchCountChanged, func(_ string, cnt int) {
 					prefetchedCnt <- cnt
 				})).Should(Succeed())
 
@@ -126,6 +176,26 @@ var _ = Describe("CachingResolver", func() {
 							HaveTTL(BeNumerically("<=", 2))))
 				Eventually(prefetchHitDomain, "4s").Should(Receive(Equal("example.com")))
 			})
+			When("max items count of prefetch domains is reached", func() {
+				BeforeEach(func() {
+					sutConfig.PrefetchMaxItemsCount = 2
+				})
+
+				It("should evict the least recently queried domain", func() {
+					evicted := make(chan string, 10)
+					_ = Bus().SubscribeOnce(CachingPrefetchDomainEvicted, func(_, domain string) {
+						evicted <- domain
+					})
+
+					for _, domain := range []string{"example1.com.", "example2.com.", "example1.com.", "example3.com."} {
+						_, err := sut.Resolve(newRequest(domain, A))
+						Expect(err).Should(Succeed())
+					}
+
+					Expect(sut.prefetchingNameCache.TotalCount()).Should(Equal(2))
+					Expect(evicted).Should(Receive(Equal("example2.com")))
+				})
+			})
 			When("threshold is 0", func() {
 				BeforeEach(func() {
 					sutConfig.PrefetchThreshold = 0
@@ -135,6 +205,226 @@ var _ = Describe("CachingResolver", func() {
 					Expect(sut.shouldPrefetch("domain.tld")).Should(BeTrue())
 				})
 			})
+			When("domains are excluded from prefetching", func() {
+				BeforeEach(func() {
+					sutConfig.PrefetchThreshold = 0
+					sutConfig.PrefetchExclude = []string{"example.com", "*.tracker.example"}
+				})
+
+				It("should neither track nor prefetch them, but cache them", func() {
+					_, err := sut.Resolve(newRequest("example.com.", A))
+					Expect(err).Should(Succeed())
+
+					Expect(sut.prefetchingNameCache.TotalCount()).Should(BeZero())
+
+					val, _ := sut.onExpired(util.GenerateCacheKey(A, "example.com"))
+					Expect(val).Should(BeNil())
+					m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+
+					resp, err := sut.Resolve(newRequest("example.com.", A))
+					Expect(err).Should(Succeed())
+					Expect(resp.RType).Should(Equal(ResponseTypeCACHED))
+				})
+
+				It("should track other domains", func() {
+					_, err := sut.Resolve(newRequest("example.org.", A))
+					Expect(err).Should(Succeed())
+
+					Expect(sut.prefetchingNameCache.TotalCount()).Should(Equal(1))
+				})
+			})
+			When("refreshes fail repeatedly", func() {
+				cacheKey := util.GenerateCacheKey(A, "example.com")
+
+				queryTwice := func() {
+					for i := 0; i < 2; i++ {
+						_, err := sut.Resolve(newRequest("example.com.", A))
+						Expect(err).Should(Succeed())
+					}
+				}
+
+				BeforeEach(func() {
+					sutConfig.PrefetchThreshold = 1
+					sutConfig.PrefetchMaxFailures = 2
+
+					mockAnswer = new(dns.Msg)
+					mockAnswer.Rcode = dns.RcodeNameError
+				})
+
+				It("should evict the domain and re-admit it if it's queried again", func() {
+					evicted := make(chan string, 1)
+					_ = Bus().SubscribeOnce(CachingPrefetchFailedDomainEvicted, func(_, domain string) {
+						evicted <- domain
+					})
+
+					queryTwice()
+					Expect(sut.shouldPrefetch(cacheKey)).Should(BeTrue())
+
+					val, _ := sut.onExpired(cacheKey)
+					Expect(val).Should(BeNil())
+					Expect(sut.shouldPrefetch(cacheKey)).Should(BeTrue())
+					Expect(evicted).ShouldNot(Receive())
+
+					_, _ = sut.onExpired(cacheKey)
+					Expect(evicted).Should(Receive(Equal("example.com")))
+					Expect(sut.shouldPrefetch(cacheKey)).Should(BeFalse())
+
+					// an evicted domain isn't refreshed anymore
+					calls := len(m.Calls)
+					_, _ = sut.onExpired(cacheKey)
+					Expect(m.Calls).Should(HaveLen(calls))
+
+					By("querying it often enough again", func() {
+						queryTwice()
+						Expect(sut.shouldPrefetch(cacheKey)).Should(BeTrue())
+					})
+				})
+
+				It("should reset the failures after a successful refresh", func() {
+					queryTwice()
+
+					_, _ = sut.onExpired(cacheKey)
+
+					answer, _ := util.NewMsgWithAnswer("example.com.", 2, A, "123.122.121.120")
+					upstreamAnswer.Store(answer)
+					val, _ := sut.onExpired(cacheKey)
+					Expect(val).ShouldNot(BeNil())
+
+					failure := new(dns.Msg)
+					failure.Rcode = dns.RcodeServerFailure
+					upstreamAnswer.Store(failure)
+					_, _ = sut.onExpired(cacheKey)
+
+					Expect(sut.shouldPrefetch(cacheKey)).Should(BeTrue())
+				})
+
+				When("max failures is 0", func() {
+					BeforeEach(func() {
+						sutConfig.PrefetchMaxFailures = 0
+					})
+
+					It("should never evict the domain", func() {
+						queryTwice()
+
+						for i := 0; i < 5; i++ {
+							_, _ = sut.onExpired(cacheKey)
+						}
+
+						Expect(sut.shouldPrefetch(cacheKey)).Should(BeTrue())
+					})
+				})
+			})
+		})
+		When("stale-while-revalidate is enabled", func() {
+			var clock *util.FakeClock
+
+			BeforeEach(func() {
+				sutConfig.StaleWhileRevalidate = config.RevalidateThreshold{Percent: 50}
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 10, A, "123.122.121.120")
+
+				clock = util.NewFakeClock()
+				DeferCleanup(util.SetClock(clock))
+			})
+
+			It("should answer from cache and refresh the entry in the background", func() {
+				revalidateHit := make(chan string, 1)
+				Expect(Bus().SubscribeOnce(CachingRevalidateHit, func(_, domain string) {
+					revalidateHit <- domain
+				})).Should(Succeed())
+
+				_, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				By("querying it before the threshold is reached", func() {
+					Expect(sut.Resolve(newRequest("example.com.", A))).
+						Should(HaveResponseType(ResponseTypeCACHED))
+					Expect(revalidateHit).ShouldNot(Receive())
+					m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+				})
+
+				clock.Advance(6 * time.Second)
+
+				By("querying it after the threshold is reached", func() {
+					Expect(sut.Resolve(newRequest("example.com.", A))).
+						Should(
+							SatisfyAll(
+								HaveResponseType(ResponseTypeCACHED),
+								BeDNSRecord("example.com.", A, "123.122.121.120"),
+								HaveTTL(BeNumerically("<=", 4)),
+							))
+					Expect(revalidateHit).Should(Receive(Equal("example.com")))
+				})
+
+				Eventually(func(g Gomega) {
+					resp, err := sut.Resolve(newRequest("example.com.", A))
+					g.Expect(err).Should(Succeed())
+					g.Expect(resp).Should(HaveTTL(BeNumerically(">", 5)))
+				}, "1s").Should(Succeed())
+
+				m.AssertNumberOfCalls(GinkgoT(), "Resolve", 2)
+			})
+
+			It("should start only one refresh per entry", func() {
+				cacheKey := util.GenerateCacheKey(A, "example.com")
+				val := &cacheValue{ttl: 10 * time.Second}
+
+				Expect(sut.shouldRevalidate(cacheKey, "example.com", val, 6*time.Second)).Should(BeFalse())
+				Expect(sut.shouldRevalidate(cacheKey, "example.com", val, 4*time.Second)).Should(BeTrue())
+				Expect(sut.shouldRevalidate(cacheKey, "example.com", val, 4*time.Second)).Should(BeFalse())
+			})
+
+			It("should keep the entry and pause refreshes if the refresh fails", func() {
+				failed := make(chan string, 1)
+				Expect(Bus().SubscribeOnce(CachingRevalidateFailed, func(_, domain string) {
+					failed <- domain
+				})).Should(Succeed())
+
+				_, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				failure := new(dns.Msg)
+				failure.Rcode = dns.RcodeServerFailure
+				upstreamAnswer.Store(failure)
+
+				clock.Advance(6 * time.Second)
+
+				_, err = sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+				Eventually(failed).Should(Receive(Equal("example.com")))
+
+				Eventually(func() bool {
+					_, running := sut.revalidating.Load(util.GenerateCacheKey(A, "example.com"))
+
+					return running
+				}).Should(BeFalse())
+
+				Expect(sut.Resolve(newRequest("example.com.", A))).
+					Should(
+						SatisfyAll(
+							HaveResponseType(ResponseTypeCACHED),
+							BeDNSRecord("example.com.", A, "123.122.121.120"),
+						))
+				m.AssertNumberOfCalls(GinkgoT(), "Resolve", 2)
+			})
+
+			When("the domain is excluded from prefetching", func() {
+				BeforeEach(func() {
+					sutConfig.PrefetchExclude = []string{"example.com"}
+				})
+
+				It("should not refresh it in the background", func() {
+					_, err := sut.Resolve(newRequest("example.com.", A))
+					Expect(err).Should(Succeed())
+
+					clock.Advance(6 * time.Second)
+
+					Expect(sut.Resolve(newRequest("example.com.", A))).
+						Should(HaveResponseType(ResponseTypeCACHED))
+					Consistently(func() int {
+						return len(m.Calls)
+					}, "100ms").Should(Equal(1))
+				})
+			})
 		})
 		When("min caching time is defined", func() {
 			BeforeEach(func() {
@@ -150,12 +440,12 @@ var _ = Describe("CachingResolver", func() {
 				It("should cache response and use response's TTL", func() {
 					By("first request", func() {
 						domain := make(chan string, 1)
-						_ = Bus().SubscribeOnce(CachingResultCacheMiss, func(d string) {
+						_ = Bus().SubscribeOnce(CachingResultCacheMiss, func(_, d string) {
 							domain <- d
 						})
 
 						totalCacheCount := make(chan int, 1)
-						_ = Bus().SubscribeOnce(CachingResultCacheChanged, func(d int) {
+						_ = Bus().SubscribeOnce(CachingResultCacheChanged, func(_ string, d int) {
 							totalCacheCount <- d
 						})
 						Expect(sut.Resolve(newRequest("example.com.", A))).
@@ -175,7 +465,7 @@ var _ = Describe("CachingResolver", func() {
 					By("second request", func() {
 						Eventually(func(g Gomega) {
 							domain := make(chan string, 1)
-							_ = Bus().SubscribeOnce(CachingResultCacheHit, func(d string) {
+							_ = Bus().SubscribeOnce(CachingResultCacheHit, func(_, d string) {
 								domain <- d
 							})
 
@@ -494,6 +784,651 @@ var _ = Describe("CachingResolver", func() {
 				})
 			})
 		})
+		Context("Negative TTL is derived from SOA record (RFC 2308)", func() {
+			var soa dns.RR
+
+			BeforeEach(func() {
+				// TTL 3600, MINIMUM 300
+				soa, _ = dns.NewRR("example.com. 3600 IN SOA ns.example.com. admin.example.com. 1 7200 3600 1209600 300")
+			})
+
+			When("Upstream resolver returns NXDOMAIN with SOA record", func() {
+				BeforeEach(func() {
+					mockAnswer.Rcode = dns.RcodeNameError
+					mockAnswer.Ns = []dns.RR{soa}
+				})
+
+				It("should cache the response for the SOA minimum", func() {
+					By("first request", func() {
+						resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+						Expect(err).Should(Succeed())
+						Expect(resp).Should(SatisfyAll(
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReturnCode(dns.RcodeNameError),
+							HaveNoAnswer(),
+						))
+						Expect(resp.Res.Ns).Should(HaveTTL(BeNumerically("==", 300)))
+
+						_, ttl := sut.resultCache.Get(util.GenerateCacheKey(AAAA, "example.com"))
+						Expect(ttl).Should(BeNumerically("~", 300*time.Second, time.Second))
+					})
+
+					By("second request", func() {
+						resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+						Expect(err).Should(Succeed())
+						Expect(resp).Should(SatisfyAll(
+							HaveResponseType(ResponseTypeCACHED),
+							HaveReason("CACHED NEGATIVE"),
+							HaveReturnCode(dns.RcodeNameError),
+							HaveNoAnswer(),
+						))
+						Expect(resp.Res.Ns).Should(HaveTTL(BeNumerically("<=", 300)))
+
+						// still one call to resolver
+						Expect(m.Calls).Should(HaveLen(1))
+					})
+				})
+			})
+
+			When("Upstream resolver returns NODATA with SOA record", func() {
+				BeforeEach(func() {
+					mockAnswer.Rcode = dns.RcodeSuccess
+					mockAnswer.Ns = []dns.RR{soa}
+				})
+
+				It("should cache the response for the SOA minimum", func() {
+					By("first request", func() {
+						resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+						Expect(err).Should(Succeed())
+						Expect(resp).Should(SatisfyAll(
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReturnCode(dns.RcodeSuccess),
+							HaveNoAnswer(),
+						))
+						Expect(resp.Res.Ns).Should(HaveTTL(BeNumerically("==", 300)))
+					})
+
+					By("second request", func() {
+						resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+						Expect(err).Should(Succeed())
+						Expect(resp).Should(SatisfyAll(
+							HaveResponseType(ResponseTypeCACHED),
+							HaveReason("CACHED"),
+							HaveReturnCode(dns.RcodeSuccess),
+							HaveNoAnswer(),
+						))
+						Expect(resp.Res.Ns).Should(HaveTTL(BeNumerically("<=", 300)))
+
+						// still one call to resolver
+						Expect(m.Calls).Should(HaveLen(1))
+					})
+				})
+			})
+
+			When("SOA minimum is greater than max negative time", func() {
+				BeforeEach(func() {
+					sutConfig.MaxNegativeTime = config.Duration(time.Minute)
+					mockAnswer.Rcode = dns.RcodeNameError
+					mockAnswer.Ns = []dns.RR{soa}
+				})
+
+				It("should cache the response for max negative time", func() {
+					resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+					Expect(err).Should(Succeed())
+					Expect(resp.Res.Ns).Should(HaveTTL(BeNumerically("==", 60)))
+
+					_, ttl := sut.resultCache.Get(util.GenerateCacheKey(AAAA, "example.com"))
+					Expect(ttl).Should(BeNumerically("~", time.Minute, time.Second))
+				})
+			})
+
+			When("SOA minimum is smaller than min caching time", func() {
+				BeforeEach(func() {
+					sutConfig.MinCachingTime = config.Duration(10 * time.Minute)
+					mockAnswer.Rcode = dns.RcodeNameError
+					mockAnswer.Ns = []dns.RR{soa}
+				})
+
+				It("should cache the response for min caching time", func() {
+					resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+					Expect(err).Should(Succeed())
+					Expect(resp.Res.Ns).Should(HaveTTL(BeNumerically("==", 600)))
+				})
+			})
+
+			When("Upstream resolver returns NXDOMAIN without SOA record", func() {
+				BeforeEach(func() {
+					mockAnswer.Rcode = dns.RcodeNameError
+				})
+
+				It("should cache the response for the negative cache time", func() {
+					_, err := sut.Resolve(newRequest("example.com.", AAAA))
+					Expect(err).Should(Succeed())
+
+					_, ttl := sut.resultCache.Get(util.GenerateCacheKey(AAAA, "example.com"))
+					Expect(ttl).Should(BeNumerically("~", sutConfig.CacheTimeNegative.ToDuration(), time.Second))
+				})
+			})
+		})
+		Context("Upstream resolver returns SERVFAIL", func() {
+			BeforeEach(func() {
+				mockAnswer.Rcode = dns.RcodeServerFailure
+				mockAnswer.Ns = []dns.RR{}
+			})
+
+			It("response shouldn't be cached", func() {
+				By("first request", func() {
+					Expect(sut.Resolve(newRequest("example.com.", AAAA))).
+						Should(SatisfyAll(
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReturnCode(dns.RcodeServerFailure),
+						))
+
+					Expect(m.Calls).Should(HaveLen(1))
+				})
+
+				By("second request", func() {
+					Expect(sut.Resolve(newRequest("example.com.", AAAA))).
+						Should(SatisfyAll(
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReturnCode(dns.RcodeServerFailure),
+						))
+
+					// one more call to upstream
+					Expect(m.Calls).Should(HaveLen(2))
+				})
+			})
+		})
+	})
+
+	Describe("Max cache size in bytes", func() {
+		answerFor := func(req *dns.Msg) *dns.Msg {
+			answer, err := util.NewMsgWithAnswer(req.Question[0].Name, 600, A, "1.1.1.1")
+			Expect(err).Should(Succeed())
+
+			answer.SetReply(req)
+
+			return answer
+		}
+
+		BeforeEach(func() {
+			// enough space for 2 entries
+			sutConfig.MaxSize = config.ByteSize(2 * answerFor(util.NewMsgWithQuestion("example1.com.", A)).Len())
+			sutConfig.Shards = 16
+		})
+
+		JustBeforeEach(func() {
+			m.ResponseFn = answerFor
+		})
+
+		It("should evict least recently used entries", func() {
+			evicted := make(chan string, 10)
+			_ = Bus().SubscribeOnce(CachingResultCacheEvicted, func(_, key string) {
+				evicted <- key
+			})
+
+			for _, domain := range []string{"example1.com.", "example2.com.", "example3.com."} {
+				_, err := sut.Resolve(newRequest(domain, A))
+				Expect(err).Should(Succeed())
+			}
+
+			Expect(sut.resultCache.TotalSize()).Should(BeNumerically("<=", sutConfig.MaxSize))
+			Expect(sut.resultCache.TotalCount()).Should(Equal(2))
+			Expect(evicted).Should(Receive(Equal(util.GenerateCacheKey(A, "example1.com"))))
+		})
+
+		When("an entry has max size", func() {
+			BeforeEach(func() {
+				sutConfig.MaxSize = config.ByteSize(answerFor(util.NewMsgWithQuestion("example1.com.", A)).Len())
+				sutConfig.Shards = 0
+			})
+
+			It("should stay cached", func() {
+				Expect(sut.Resolve(newRequest("example1.com.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(sut.Resolve(newRequest("example1.com.", A))).
+					Should(HaveResponseType(ResponseTypeCACHED))
+
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Excluded domains", func() {
+		BeforeEach(func() {
+			sutConfig.Exclude = []string{"failover.example.com", "*.consul", "/^svc[0-9]+\\.example\\.com$/"}
+		})
+
+		DescribeTable("excluded domain should not be cached",
+			func(domain string) {
+				mockAnswer, _ = util.NewMsgWithAnswer(domain, 600, A, "1.1.1.1")
+
+				By("first request", func() {
+					Expect(sut.Resolve(newRequest(domain, A))).
+						Should(HaveResponseType(ResponseTypeRESOLVED))
+
+					Expect(m.Calls).Should(HaveLen(1))
+				})
+
+				By("second request", func() {
+					Expect(sut.Resolve(newRequest(domain, A))).
+						Should(HaveResponseType(ResponseTypeRESOLVED))
+
+					// one more call to upstream
+					Expect(m.Calls).Should(HaveLen(2))
+					Expect(sut.resultCache.TotalCount()).Should(BeZero())
+				})
+			},
+			Entry("exact", "failover.example.com."),
+			Entry("wildcard", "web.service.consul."),
+			Entry("regex", "svc42.example.com."),
+		)
+
+		It("should cache domains which are not excluded", func() {
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 600, A, "1.1.1.1")
+
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeCACHED))
+
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+
+		It("should publish excluded metric", func() {
+			domain := make(chan string, 1)
+			_ = Bus().SubscribeOnce(CachingResultCacheExcluded, func(_, d string) {
+				domain <- d
+			})
+
+			_, err := sut.Resolve(newRequest("failover.example.com.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(domain).Should(Receive(Equal("failover.example.com")))
+		})
+	})
+
+	Describe("Response validation", func() {
+		JustBeforeEach(func() {
+			m.ResponseFn = func(req *dns.Msg) *dns.Msg {
+				resp, err := util.NewMsgWithAnswer("evil.com.", 600, A, "6.6.6.6")
+				Expect(err).Should(Succeed())
+
+				resp.SetReply(req)
+				resp.Question[0].Name = "evil.com."
+
+				return resp
+			}
+		})
+
+		It("should not cache a response for another question", func() {
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(sut.resultCache.TotalCount()).Should(BeZero())
+
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(m.Calls).Should(HaveLen(2))
+		})
+	})
+
+	Describe("Checking disabled", func() {
+		It("should not cache responses to queries with the CD bit", func() {
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 600, A, "1.1.1.1")
+
+			request := newRequest("example.com.", A)
+			request.Req.CheckingDisabled = true
+
+			Expect(sut.Resolve(request)).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(sut.resultCache.TotalCount()).Should(BeZero())
+
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(m.Calls).Should(HaveLen(2))
+		})
+	})
+
+	Describe("DNSSEC records", func() {
+		var mockUpstream *dnstest.MockUpstreamServer
+
+		JustBeforeEach(func() {
+			zone := newDNSSECTestZone("example.")
+
+			mockUpstream = dnstest.NewMockUpstreamServer().WithAnswerFn(zone.answer)
+			DeferCleanup(mockUpstream.Close)
+
+			sut.Next(newUpstreamResolverUnchecked(mockUpstream.Start(), nil))
+		})
+
+		doRequest := func() *Request {
+			request := newRequest("www.example.", A)
+			request.Req.SetEdns0(dns.DefaultMsgSize, true)
+
+			return request
+		}
+
+		It("should cache the response with the DNSSEC records for all clients", func() {
+			resp, err := sut.Resolve(newRequest("www.example.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(mockUpstream.LastRequest().IsEdns0().Do()).Should(BeTrue())
+
+			By("not returning EDNS to a client without it", func() {
+				Expect(resp.Res.IsEdns0()).Should(BeNil())
+			})
+
+			resp, err = sut.Resolve(doRequest())
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveResponseType(ResponseTypeCACHED))
+			Expect(resp.Res.Answer).Should(ContainElement(BeAssignableToTypeOf(&dns.RRSIG{})))
+			Expect(resp.Res.Ns).Should(ContainElements(
+				BeAssignableToTypeOf(&dns.NSEC{}), BeAssignableToTypeOf(&dns.RRSIG{})))
+			Expect(resp.Res.IsEdns0().Do()).Should(BeTrue())
+			Expect(mockUpstream.GetCallCount()).Should(Equal(1))
+
+			By("not returning EDNS from the cache to a client without it", func() {
+				resp, err = sut.Resolve(newRequest("www.example.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(HaveResponseType(ResponseTypeCACHED))
+				Expect(resp.Res.IsEdns0()).Should(BeNil())
+			})
+		})
+
+		It("should keep the DNSSEC records of clients with the DO bit", func() {
+			resp, err := sut.Resolve(doRequest())
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+			Expect(resp.Res.Ns).Should(HaveLen(2))
+		})
+
+		It("should pass the CD bit to the upstream and the client", func() {
+			for i := 0; i < 2; i++ {
+				request := doRequest()
+				request.Req.CheckingDisabled = true
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(resp.Res.CheckingDisabled).Should(BeTrue())
+				Expect(mockUpstream.LastRequest().CheckingDisabled).Should(BeTrue())
+			}
+
+			Expect(mockUpstream.GetCallCount()).Should(Equal(2))
+		})
+	})
+
+	Describe("CNAME chain validation", func() {
+		When("the answer contains a CNAME loop", func() {
+			JustBeforeEach(func() {
+				m.ResponseFn = func(req *dns.Msg) *dns.Msg {
+					resp := new(dns.Msg)
+					resp.SetReply(req)
+
+					for _, record := range []string{
+						"example.com. 600 IN CNAME a.example.net.",
+						"a.example.net. 600 IN CNAME example.com.",
+					} {
+						rr, err := dns.NewRR(record)
+						Expect(err).Should(Succeed())
+
+						resp.Answer = append(resp.Answer, rr)
+					}
+
+					return resp
+				}
+			})
+
+			It("should not cache the response", func() {
+				Expect(sut.Resolve(newRequest("example.com.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				Expect(sut.resultCache.TotalCount()).Should(BeZero())
+
+				Expect(sut.Resolve(newRequest("example.com.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(m.Calls).Should(HaveLen(2))
+			})
+		})
+
+		When("the upstream resolver replaced the answer with SERVFAIL", func() {
+			var mockUpstream *dnstest.MockUpstreamServer
+
+			JustBeforeEach(func() {
+				mockUpstream = dnstest.NewMockUpstreamServer().WithAnswerRR(
+					"example.com 600 IN CNAME a.example.net",
+					"a.example.net 600 IN CNAME example.com",
+				)
+				DeferCleanup(mockUpstream.Close)
+
+				sut.Next(newUpstreamResolverUnchecked(mockUpstream.Start(), nil))
+			})
+
+			It("should neither cache nor serve the loop", func() {
+				for i := 0; i < 2; i++ {
+					Expect(sut.Resolve(newRequest("example.com.", A))).
+						Should(SatisfyAll(
+							HaveNoAnswer(),
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReturnCode(dns.RcodeServerFailure),
+						))
+				}
+
+				Expect(sut.resultCache.TotalCount()).Should(BeZero())
+				Expect(mockUpstream.GetCallCount()).Should(Equal(2))
+			})
+		})
+	})
+
+	Describe("Cache warm-up", func() {
+		BeforeEach(func() {
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 600, A, "1.1.1.1")
+
+			sutConfig.WarmupDomains = []config.BytesSource{
+				config.TextBytesSource(
+					"# household domains",
+					"example.com",
+					"/^regex\\.com$/",
+					"*.wildcard.com",
+					"1.2.3.4",
+				),
+			}
+		})
+
+		It("should resolve the domains at startup and cache the answers", func() {
+			Bus().Publish(ApplicationStarted, "")
+
+			Eventually(sut.resultCache.TotalCount).Should(Equal(2))
+
+			Expect(m.Calls).Should(HaveLen(2))
+			Expect(m.Calls[0].Arguments[0].(*Request).Req.Question[0].Qtype).Should(Equal(dns.TypeA))
+			Expect(m.Calls[1].Arguments[0].(*Request).Req.Question[0].Qtype).Should(Equal(dns.TypeAAAA))
+
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeCACHED))
+			Expect(m.Calls).Should(HaveLen(2))
+		})
+
+		When("the domains are read from a file", func() {
+			BeforeEach(func() {
+				tmpDir := NewTmpFolder("CachingResolver")
+				Expect(tmpDir.Error).Should(Succeed())
+				DeferCleanup(tmpDir.Clean)
+
+				file := tmpDir.CreateStringFile("warmup.txt", "example.com", "example.org")
+				Expect(file.Error).Should(Succeed())
+
+				sutConfig.WarmupDomains = config.NewBytesSources(file.Path)
+			})
+
+			JustBeforeEach(func() {
+				m.ResponseFn = func(req *dns.Msg) *dns.Msg {
+					resp, err := util.NewMsgWithAnswer(req.Question[0].Name, 600, A, "1.1.1.1")
+					Expect(err).Should(Succeed())
+
+					resp.SetReply(req)
+
+					return resp
+				}
+			})
+
+			It("should resolve all domains of the file", func() {
+				Bus().Publish(ApplicationStarted, "")
+
+				Eventually(sut.resultCache.TotalCount).Should(Equal(4))
+			})
+		})
+
+		When("the next resolver returns an error", func() {
+			var resolved chan dns.Type
+
+			JustBeforeEach(func() {
+				resolved = make(chan dns.Type, 2)
+
+				m = &mockResolver{}
+				m.On("Resolve", mock.Anything).Run(func(args mock.Arguments) {
+					resolved <- dns.Type(args.Get(0).(*Request).Req.Question[0].Qtype)
+				}).Return(nil, errors.New("upstream error"))
+				sut.Next(m)
+			})
+
+			It("should not cache anything", func() {
+				Bus().Publish(ApplicationStarted, "")
+
+				Eventually(resolved).Should(Receive(Equal(A)))
+				Eventually(resolved).Should(Receive(Equal(AAAA)))
+				Consistently(sut.resultCache.TotalCount).Should(BeZero())
+			})
+		})
+	})
+
+	Describe("EDNS client subnet partitioning", func() {
+		newECSRequest := func(domain, subnet string) *Request {
+			req := newRequest(domain, A)
+
+			if subnet != "" {
+				_, ipNet, err := net.ParseCIDR(subnet)
+				Expect(err).Should(Succeed())
+
+				util.SetClientSubnet(req.Req, ipNet)
+			}
+
+			return req
+		}
+
+		JustBeforeEach(func() {
+			// answer depends on the client subnet of the query
+			m.ResolveFn = func(req *Request) (*Response, error) {
+				ip := "1.1.1.1"
+
+				if subnet := util.ClientSubnet(req.Req); subnet != nil {
+					ip = subnet.IP.String()
+				}
+
+				answer, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 600, A, ip)
+				Expect(err).Should(Succeed())
+
+				answer.SetReply(req.Req)
+
+				return &Response{Res: answer, RType: ResponseTypeRESOLVED}, nil
+			}
+		})
+
+		When("partitioning is enabled", func() {
+			BeforeEach(func() {
+				sutConfig.PartitionByECS = true
+			})
+
+			It("should cache answers for different subnets independently", func() {
+				By("first subnet", func() {
+					Expect(sut.Resolve(newECSRequest("example.com.", "10.1.0.0/24"))).
+						Should(SatisfyAll(
+							HaveResponseType(ResponseTypeRESOLVED),
+							BeDNSRecord("example.com.", A, "10.1.0.0"),
+						))
+				})
+
+				By("second subnet", func() {
+					Expect(sut.Resolve(newECSRequest("example.com.", "10.2.0.0/24"))).
+						Should(SatisfyAll(
+							HaveResponseType(ResponseTypeRESOLVED),
+							BeDNSRecord("example.com.", A, "10.2.0.0"),
+						))
+				})
+
+				By("both subnets are cached", func() {
+					Expect(sut.Resolve(newECSRequest("example.com.", "10.1.0.0/24"))).
+						Should(SatisfyAll(
+							HaveResponseType(ResponseTypeCACHED),
+							BeDNSRecord("example.com.", A, "10.1.0.0"),
+						))
+					Expect(sut.Resolve(newECSRequest("example.com.", "10.2.0.0/24"))).
+						Should(SatisfyAll(
+							HaveResponseType(ResponseTypeCACHED),
+							BeDNSRecord("example.com.", A, "10.2.0.0"),
+						))
+
+					Expect(m.Calls).Should(HaveLen(2))
+					Expect(sut.resultCache.TotalCount()).Should(Equal(2))
+				})
+			})
+
+			It("should not mix queries without ECS with ECS tagged entries", func() {
+				Expect(sut.Resolve(newECSRequest("example.com.", "10.1.0.0/24"))).
+					Should(BeDNSRecord("example.com.", A, "10.1.0.0"))
+
+				Expect(sut.Resolve(newECSRequest("example.com.", ""))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeRESOLVED),
+						BeDNSRecord("example.com.", A, "1.1.1.1"),
+					))
+
+				Expect(m.Calls).Should(HaveLen(2))
+			})
+
+			When("prefetching is enabled", func() {
+				BeforeEach(func() {
+					sutConfig.Prefetching = true
+					sutConfig.PrefetchThreshold = 0
+				})
+
+				It("should prefetch with the subnet which populated the entry", func() {
+					_, ipNet, err := net.ParseCIDR("10.1.0.0/24")
+					Expect(err).Should(Succeed())
+
+					val, ttl := sut.onExpired(util.GenerateCacheKeyWithSubnet(A, "example.com", ipNet))
+					Expect(val).ShouldNot(BeNil())
+					Expect(ttl).Should(BeNumerically(">", 0))
+
+					Expect(m.Calls).Should(HaveLen(1))
+					req := m.Calls[0].Arguments.Get(0).(*Request)
+					Expect(util.ClientSubnet(req.Req)).Should(Equal(ipNet))
+					Expect(val.resultMsg.Answer[0].(*dns.A).A.String()).Should(Equal("10.1.0.0"))
+				})
+			})
+		})
+
+		When("partitioning is disabled", func() {
+			It("should share the cache entry between subnets", func() {
+				Expect(sut.Resolve(newECSRequest("example.com.", "10.1.0.0/24"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				Expect(sut.Resolve(newECSRequest("example.com.", "10.2.0.0/24"))).
+					Should(SatisfyAll(
+						HaveResponseType(ResponseTypeCACHED),
+						BeDNSRecord("example.com.", A, "10.1.0.0"),
+					))
+
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+		})
 	})
 
 	Describe("Not A / AAAA queries should also be cached", func() {
@@ -565,9 +1500,18 @@ var _ = Describe("CachingResolver", func() {
 				}
 				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 1000, A, "1.1.1.1")
 
-				sut = NewCachingResolver(sutConfig, redisClient)
-				m = &mockResolver{}
-				m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
+				sut = NewCachingResolver(sutCtx, sutConfig, redisClient, "")
+
+				answer := mockAnswer.Copy()
+				m = &mockResolver{
+					ResponseFn: func(req *dns.Msg) *dns.Msg {
+						resp := answer.Copy()
+						resp.SetReply(req)
+
+						return resp
+					},
+				}
+				m.On("Resolve", mock.Anything).Return(&Response{Res: answer}, nil)
 				sut.Next(m)
 			})
 
@@ -589,7 +1533,8 @@ var _ = Describe("CachingResolver", func() {
 					Response: &Response{
 						RType:  ResponseTypeCACHED,
 						Reason: "MOCK_REDIS",
-						Res:    mockAnswer,
+						// the cache adjusts the TTLs of the message, it mustn't be shared with the mock
+						Res: mockAnswer.Copy(),
 					},
 				}
 				redisClient.CacheChannel <- redisMockMsg
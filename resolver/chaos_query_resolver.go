@@ -0,0 +1,78 @@
+package resolver
+
+import (
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// ChaosQueryResolver answers the CHAOS class queries of the server version and identity, like
+// `dig CH TXT version.bind`. All other CHAOS class queries are refused: upstreams would answer with their own identity.
+type ChaosQueryResolver struct {
+	configurable[*config.ChaosQueriesConfig]
+	NextResolver
+	typed
+
+	answers map[string]string
+}
+
+// NewChaosQueryResolver creates new resolver instance
+func NewChaosQueryResolver(cfg config.ChaosQueriesConfig) *ChaosQueryResolver {
+	version := cfg.Version
+	if version == "" {
+		version = "blocky " + util.Version
+	}
+
+	hostname := cfg.Hostname
+	if hostname == "" {
+		hostname = util.HostnameString()
+	}
+
+	return &ChaosQueryResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("chaos_query"),
+
+		answers: map[string]string{
+			"version.bind.":   version,
+			"version.server.": version,
+			"hostname.bind.":  hostname,
+			"id.server.":      hostname,
+		},
+	}
+}
+
+func (r *ChaosQueryResolver) Resolve(request *model.Request) (*model.Response, error) {
+	question := request.Req.Question[0]
+
+	if question.Qclass != dns.ClassCHAOS {
+		return r.next.Resolve(request)
+	}
+
+	answer, ok := r.answers[strings.ToLower(question.Name)]
+
+	logger := r.log().WithField("domain", util.ExtractDomain(question))
+
+	if !r.IsEnabled() || !ok || answer == "" || question.Qtype != dns.TypeTXT {
+		logger.Debug("refusing CHAOS query")
+
+		return newResponse(request, dns.RcodeRefused, model.ResponseTypeSPECIAL, "CHAOS"), nil
+	}
+
+	response := newResponse(request, dns.RcodeSuccess, model.ResponseTypeSPECIAL, "CHAOS")
+	response.Res.Authoritative = true
+	response.Res.Answer = []dns.RR{&dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   question.Name,
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassCHAOS,
+		},
+		Txt: []string{answer},
+	}}
+
+	logger.WithField("answer", answer).Debug("answering CHAOS query")
+
+	return response, nil
+}
@@ -0,0 +1,154 @@
+package resolver
+
+import (
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("ChaosQueryResolver", func() {
+	var (
+		sut       *ChaosQueryResolver
+		sutConfig config.ChaosQueriesConfig
+		m         *mockResolver
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.ChaosQueriesConfig]()
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg), RType: ResponseTypeRESOLVED}, nil)
+	})
+
+	JustBeforeEach(func() {
+		sut = NewChaosQueryResolver(sutConfig)
+		sut.Next(m)
+	})
+
+	newChaosRequest := func(name string, qType dns.Type) *Request {
+		request := newRequest(name, qType)
+		request.Req.Question[0].Qclass = dns.ClassCHAOS
+
+		return request
+	}
+
+	// txtOf returns the text of the single TXT answer of the response
+	txtOf := func(resp *Response) string {
+		GinkgoHelper()
+
+		Expect(resp.Res.Answer).Should(HaveLen(1))
+
+		txt, ok := resp.Res.Answer[0].(*dns.TXT)
+		Expect(ok).Should(BeTrue())
+		Expect(txt.Hdr.Class).Should(BeEquivalentTo(dns.ClassCHAOS))
+
+		return txt.Txt[0]
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true by default", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	DescribeTable("should answer the server version and identity",
+		func(name, expected string) {
+			resp, err := sut.Resolve(newChaosRequest(name, TXT))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(SatisfyAll(
+				HaveReturnCode(dns.RcodeSuccess),
+				HaveResponseType(ResponseTypeSPECIAL),
+				HaveReason("CHAOS"),
+			))
+			Expect(resp.Res.Authoritative).Should(BeTrue())
+			Expect(txtOf(resp)).Should(Equal(expected))
+			Expect(m.Calls).Should(BeEmpty())
+		},
+		Entry("version.bind", "version.bind.", "blocky "+util.Version),
+		Entry("version.server", "VERSION.server.", "blocky "+util.Version),
+		Entry("hostname.bind", "hostname.bind.", util.HostnameString()),
+		Entry("id.server", "id.server.", util.HostnameString()),
+	)
+
+	When("the answers are configured", func() {
+		BeforeEach(func() {
+			sutConfig.Version = "secret"
+			sutConfig.Hostname = "dns-1"
+		})
+
+		It("should answer with the configured strings", func() {
+			resp, err := sut.Resolve(newChaosRequest("version.bind.", TXT))
+			Expect(err).Should(Succeed())
+			Expect(txtOf(resp)).Should(Equal("secret"))
+
+			resp, err = sut.Resolve(newChaosRequest("id.server.", TXT))
+			Expect(err).Should(Succeed())
+			Expect(txtOf(resp)).Should(Equal("dns-1"))
+		})
+	})
+
+	When("disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Enable = false
+		})
+
+		It("should refuse the queries of the server version and identity", func() {
+			for _, name := range []string{"version.bind.", "version.server.", "hostname.bind.", "id.server."} {
+				resp, err := sut.Resolve(newChaosRequest(name, TXT))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(HaveReturnCode(dns.RcodeRefused), name)
+			}
+
+			Expect(m.Calls).Should(BeEmpty())
+		})
+	})
+
+	It("should refuse other CHAOS queries", func() {
+		resp, err := sut.Resolve(newChaosRequest("authors.bind.", TXT))
+		Expect(err).Should(Succeed())
+		Expect(resp).Should(SatisfyAll(
+			HaveReturnCode(dns.RcodeRefused),
+			HaveResponseType(ResponseTypeSPECIAL),
+		))
+
+		resp, err = sut.Resolve(newChaosRequest("version.bind.", A))
+		Expect(err).Should(Succeed())
+		Expect(resp).Should(HaveReturnCode(dns.RcodeRefused))
+
+		Expect(m.Calls).Should(BeEmpty())
+	})
+
+	It("should pass queries of other classes", func() {
+		_, err := sut.Resolve(newRequest("version.bind.", TXT))
+		Expect(err).Should(Succeed())
+
+		m.AssertExpectations(GinkgoT())
+	})
+})
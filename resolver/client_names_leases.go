@@ -0,0 +1,130 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/lists/parsers"
+)
+
+// loadLeaseFiles parses all lease files and replaces the leases of the previous load
+func (r *ClientNamesResolver) loadLeaseFiles(ctx context.Context) error {
+	return r.refreshLeaseFiles(ctx, nil)
+}
+
+// refreshLeaseFiles parses the lease files with the indexes in `only`, or all lease files if it is nil.
+// A failed file is reported and keeps the leases of its last successful load, an error is only returned if all
+// loaded files failed. Leases of later files supersede the leases of earlier files with the same IP.
+func (r *ClientNamesResolver) refreshLeaseFiles(ctx context.Context, only []int) error {
+	r.refreshLock.Lock()
+	defer r.refreshLock.Unlock()
+
+	r.log().Debug("loading lease files")
+
+	fileLeases := slices.Clone(r.fileLeases)
+	sourceErrs := make([]error, len(r.cfg.LeaseFiles))
+	loaded, failed := 0, 0
+
+	for i, source := range r.cfg.LeaseFiles {
+		if only != nil && !slices.Contains(only, i) {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		loaded++
+
+		opener, err := lists.NewSourceOpener(fmt.Sprintf("lease file #%d", i), source, r.downloader)
+		if err != nil {
+			return err
+		}
+
+		leases, err := r.parseLeaseFile(ctx, opener)
+		if err != nil {
+			failed++
+			sourceErrs[i] = fmt.Errorf("error parsing %s: %w", opener, err)
+
+			r.log().WithError(sourceErrs[i]).
+				Warnf("could not load lease file, keeping %d leases of the last successful load", len(fileLeases[i]))
+
+			continue
+		}
+
+		fileLeases[i] = leases
+	}
+
+	if loaded > 0 && failed == loaded {
+		return fmt.Errorf("all %d loaded lease files failed, first error: %w", failed, firstError(sourceErrs))
+	}
+
+	leases := make(map[string]*parsers.Lease)
+
+	for _, fileLeases := range fileLeases {
+		for _, lease := range fileLeases {
+			leases[lease.IP.String()] = lease
+		}
+	}
+
+	r.fileLeases = fileLeases
+
+	r.leasesLock.Lock()
+	r.leases = leases
+	r.leasesLock.Unlock()
+
+	// the cached names may be outdated
+	r.cache.Clear()
+
+	return nil
+}
+
+func (r *ClientNamesResolver) parseLeaseFile(ctx context.Context, opener lists.SourceOpener) ([]*parsers.Lease, error) {
+	reader, err := opener.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	p := parsers.AllowErrors(parsers.Leases(reader), r.cfg.Loading.MaxErrorsPerSource)
+	p.OnErr(func(err error) {
+		r.log().Warnf("error parsing %s: %s, trying to continue", opener, err)
+	})
+
+	var leases []*parsers.Lease
+
+	err = parsers.ForEach[*parsers.Lease](ctx, p, func(lease *parsers.Lease) error {
+		leases = append(leases, lease)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// getNameFromLeases returns the hostname of the unexpired lease of the IP and how long it may be cached:
+// until the lease expires, but at most `maxTTL`
+func (r *ClientNamesResolver) getNameFromLeases(ip net.IP, maxTTL time.Duration) (string, time.Duration) {
+	r.leasesLock.RLock()
+	lease := r.leases[ip.String()]
+	r.leasesLock.RUnlock()
+
+	now := time.Now()
+
+	if lease == nil || lease.Hostname == "" || lease.IsExpired(now) {
+		return "", 0
+	}
+
+	if lease.Expires.IsZero() {
+		return lease.Hostname, maxTTL
+	}
+
+	return lease.Hostname, min(maxTTL, lease.Expires.Sub(now))
+}
@@ -1,12 +1,19 @@
 package resolver
 
 import (
+	"context"
+	"encoding/hex"
 	"net"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/0xERR0R/blocky/cache/expirationcache"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/lists/parsers"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/util"
@@ -15,6 +22,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// clientNamesCacheTTL is how long resolved client names are cached
+const clientNamesCacheTTL = time.Hour
+
 // ClientNamesResolver tries to determine client name by asking responsible DNS server via rDNS (reverse lookup)
 type ClientNamesResolver struct {
 	configurable[*config.ClientLookupConfig]
@@ -23,11 +33,22 @@ type ClientNamesResolver struct {
 
 	cache            expirationcache.ExpiringCache[[]string]
 	externalResolver Resolver
+	// edns0Clients maps the lower case EDNS0 option value to the client name
+	edns0Clients map[string]string
+
+	downloader lists.FileDownloader
+	leasesLock sync.RWMutex
+	// leases of all lease files by IP
+	leases map[string]*parsers.Lease
+
+	// leases of each lease file, so a single file can be refreshed
+	fileLeases  [][]*parsers.Lease
+	refreshLock sync.Mutex
 }
 
-// NewClientNamesResolver creates new resolver instance
+// NewClientNamesResolver creates new resolver instance, changed lease files are watched until ctx is done
 func NewClientNamesResolver(
-	cfg config.ClientLookupConfig, bootstrap *Bootstrap, shouldVerifyUpstreams bool,
+	ctx context.Context, cfg config.ClientLookupConfig, bootstrap *Bootstrap, shouldVerifyUpstreams bool,
 ) (cr *ClientNamesResolver, err error) {
 	var r Resolver
 	if !cfg.Upstream.IsDefault() {
@@ -43,9 +64,39 @@ func NewClientNamesResolver(
 
 		cache:            expirationcache.NewCache(expirationcache.WithCleanUpInterval[[]string](time.Hour)),
 		externalResolver: r,
+		edns0Clients:     make(map[string]string, len(cfg.EDNS0.Clients)),
 	}
 
-	return
+	for value, name := range cfg.EDNS0.Clients {
+		cr.edns0Clients[strings.ToLower(value)] = name
+	}
+
+	if len(cfg.LeaseFiles) == 0 {
+		return cr, nil
+	}
+
+	cr.downloader = lists.NewDownloader(cfg.Loading.Downloads, bootstrap.NewHTTPTransport())
+	cr.fileLeases = make([][]*parsers.Lease, len(cfg.LeaseFiles))
+
+	err = cfg.Loading.StartPeriodicRefresh(cr.loadLeaseFiles, func(err error) {
+		cr.log().WithError(err).Errorf("could not load lease files")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Loading.WatchFiles {
+		err := lists.WatchSources(ctx, cfg.LeaseFiles, lists.WatchDebounce, func(only []int) {
+			if err := cr.refreshLeaseFiles(context.Background(), only); err != nil {
+				cr.log().WithError(err).Errorf("could not refresh changed lease files")
+			}
+		})
+		if err != nil {
+			cr.log().WithError(err).Warn("can't watch lease files, changes are picked up by the periodic refresh")
+		}
+	}
+
+	return cr, nil
 }
 
 // LogConfig implements `config.Configurable`.
@@ -53,6 +104,13 @@ func (r *ClientNamesResolver) LogConfig(logger *logrus.Entry) {
 	r.cfg.LogConfig(logger)
 
 	logger.Infof("cache entries = %d", r.cache.TotalCount())
+
+	if len(r.cfg.LeaseFiles) != 0 {
+		r.leasesLock.RLock()
+		defer r.leasesLock.RUnlock()
+
+		logger.Infof("leases = %d", len(r.leases))
+	}
 }
 
 // Resolve tries to resolve the client name from the ip address
@@ -65,12 +123,32 @@ func (r *ClientNamesResolver) Resolve(request *model.Request) (*model.Response,
 	return r.next.Resolve(request)
 }
 
+// prefixes of the client identifiers of EDNS0 option values, which can be used as client group keys
+const (
+	edns0MACIdentifierPrefix = "mac:"
+	edns0IDIdentifierPrefix  = "id:"
+)
+
+// edns0MACLength is the length of option values which are MACs
+const edns0MACLength = 6
+
 // returns names of client
 func (r *ClientNamesResolver) getClientNames(request *model.Request) []string {
+	var edns0Options [][]byte
+
+	if r.cfg.EDNS0.Enable {
+		// always remove the options, they must not be forwarded upstream
+		edns0Options = r.removeEdns0Options(request.Req)
+	}
+
 	if request.RequestClientID != "" {
 		return []string{request.RequestClientID}
 	}
 
+	if len(edns0Options) > 0 {
+		return r.edns0ClientNames(edns0Options)
+	}
+
 	ip := request.ClientIP
 	if ip == nil {
 		return []string{}
@@ -85,13 +163,77 @@ func (r *ClientNamesResolver) getClientNames(request *model.Request) []string {
 		return cpy
 	}
 
-	names := r.resolveClientNames(ip, log.WithPrefix(request.Log, "client_names_resolver"))
+	names, ttl := r.resolveClientNames(ip, log.WithPrefix(request.Log, "client_names_resolver"))
 
-	r.cache.Put(ip.String(), &names, time.Hour)
+	r.cache.Put(ip.String(), &names, ttl)
 
 	return names
 }
 
+// removeEdns0Options removes the configured EDNS0 options from the message.
+// Returns the non-empty option values in the order of the configured codes.
+func (r *ClientNamesResolver) removeEdns0Options(msg *dns.Msg) [][]byte {
+	var options [][]byte
+
+	for _, code := range r.cfg.EDNS0.OptionCodes {
+		if data := util.RemoveEdns0LocalOption(msg, code); len(data) > 0 {
+			options = append(options, data)
+		}
+	}
+
+	return options
+}
+
+// edns0ClientNames returns the client name of the first option, the mapped name or the value itself,
+// followed by the client identifiers of all options
+func (r *ClientNamesResolver) edns0ClientNames(options [][]byte) []string {
+	first := formatEdns0ClientID(options[0])
+
+	name, ok := r.edns0Clients[first]
+	if !ok {
+		name = first
+	}
+
+	names := []string{name}
+
+	for _, data := range options {
+		if identifier := edns0ClientIdentifier(data); !slices.Contains(names, identifier) {
+			names = append(names, identifier)
+		}
+	}
+
+	return names
+}
+
+// edns0ClientIdentifier returns the option value prefixed with its kind, e.g. `mac:aa:bb:cc:dd:ee:ff`
+func edns0ClientIdentifier(data []byte) string {
+	if len(data) == edns0MACLength {
+		return edns0MACIdentifierPrefix + formatEdns0ClientID(data)
+	}
+
+	return edns0IDIdentifierPrefix + formatEdns0ClientID(data)
+}
+
+// formatEdns0ClientID returns the value of the EDNS0 option as lower case string:
+// 6 bytes as MAC, printable values as text and hex otherwise
+func formatEdns0ClientID(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	if len(data) == edns0MACLength {
+		return net.HardwareAddr(data).String()
+	}
+
+	for _, b := range data {
+		if b > unicode.MaxASCII || !unicode.IsPrint(rune(b)) {
+			return hex.EncodeToString(data)
+		}
+	}
+
+	return strings.ToLower(string(data))
+}
+
 func extractClientNamesFromAnswer(answer []dns.RR, fallbackIP net.IP) (clientNames []string) {
 	for _, answer := range answer {
 		if t, ok := answer.(*dns.PTR); ok {
@@ -107,14 +249,25 @@ func extractClientNamesFromAnswer(answer []dns.RR, fallbackIP net.IP) (clientNam
 	return
 }
 
-// tries to resolve client name from mapping, performs reverse DNS lookup otherwise
-func (r *ClientNamesResolver) resolveClientNames(ip net.IP, logger *logrus.Entry) (result []string) {
+// tries to resolve client name from mapping, then from the DHCP leases, performs reverse DNS lookup otherwise.
+// Returns the names and how long they may be cached.
+func (r *ClientNamesResolver) resolveClientNames(ip net.IP, logger *logrus.Entry) ([]string, time.Duration) {
 	// try client mapping first
-	result = r.getNameFromIPMapping(ip, result)
-	if len(result) > 0 {
-		return result
+	if result := r.getNameFromIPMapping(ip, nil); len(result) > 0 {
+		return result, clientNamesCacheTTL
 	}
 
+	if name, ttl := r.getNameFromLeases(ip, clientNamesCacheTTL); name != "" {
+		logger.WithField("client_names", name).Debug("resolved client name from DHCP lease")
+
+		return []string{name}, ttl
+	}
+
+	return r.resolveClientNamesViaRDNS(ip, logger), clientNamesCacheTTL
+}
+
+// performs reverse DNS lookup with the external resolver, returns the IP if there is none
+func (r *ClientNamesResolver) resolveClientNamesViaRDNS(ip net.IP, logger *logrus.Entry) (result []string) {
 	if r.externalResolver == nil {
 		return []string{ip.String()}
 	}
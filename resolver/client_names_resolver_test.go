@@ -1,10 +1,15 @@
 package resolver
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net"
+	"os"
+	"time"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
 	"github.com/0xERR0R/blocky/log"
 
 	. "github.com/0xERR0R/blocky/helpertest"
@@ -21,8 +26,16 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 		sut       *ClientNamesResolver
 		sutConfig config.ClientLookupConfig
 		m         *mockResolver
+		sutCtx    context.Context
 	)
 
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+
+		sutCtx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
 	Describe("Type", func() {
 		It("follows conventions", func() {
 			expectValidResolverType(sut)
@@ -32,7 +45,7 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 	JustBeforeEach(func() {
 		var err error
 
-		sut, err = NewClientNamesResolver(sutConfig, nil, false)
+		sut, err = NewClientNamesResolver(sutCtx, sutConfig, systemResolverBootstrap, false)
 		Expect(err).Should(Succeed())
 		m = &mockResolver{}
 		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
@@ -87,6 +100,126 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 			Expect(request.ClientNames).Should(ConsistOf("1.2.3.4"))
 		})
 	})
+	Describe("Resolve client name from EDNS0 option", func() {
+		withOptions := func(request *Request, options ...*dns.EDNS0_LOCAL) *Request {
+			request.Req.SetEdns0(dns.DefaultMsgSize, false)
+			opt := request.Req.IsEdns0()
+
+			for _, option := range options {
+				opt.Option = append(opt.Option, option)
+			}
+
+			opt.Option = append(opt.Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"})
+
+			return request
+		}
+
+		withOption := func(request *Request, data []byte) *Request {
+			return withOptions(request, &dns.EDNS0_LOCAL{Code: 65001, Data: data})
+		}
+
+		BeforeEach(func() {
+			sutConfig = config.ClientLookupConfig{
+				ClientnameIPMapping: map[string][]net.IP{
+					"router": {net.ParseIP("192.168.178.1")},
+				},
+				EDNS0: config.ClientLookupEDNS0{
+					Enable:      true,
+					OptionCodes: []uint16{65001, 65074},
+					Clients:     map[string]string{"AA:BB:CC:DD:EE:FF": "laptop", "tv-living-room": "tv"},
+				},
+			}
+		})
+		AfterEach(func() {
+			// next resolver will be called
+			m.AssertExpectations(GinkgoT())
+		})
+
+		It("should map a MAC to the client name and strip the option", func() {
+			request := withOption(newRequestWithClient("google.de.", A, "192.168.178.1"),
+				[]byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff})
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientNames).Should(Equal([]string{"laptop", "mac:aa:bb:cc:dd:ee:ff"}))
+			Expect(request.Req.IsEdns0().Option).Should(ConsistOf(BeAssignableToTypeOf(&dns.EDNS0_COOKIE{})))
+		})
+
+		It("should map a text value to the client name", func() {
+			request := withOption(newRequestWithClient("google.de.", A, "192.168.178.1"), []byte("TV-Living-Room"))
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientNames).Should(Equal([]string{"tv", "id:tv-living-room"}))
+		})
+
+		It("should use the value if it isn't mapped", func() {
+			request := withOption(newRequestWithClient("google.de.", A, "192.168.178.1"),
+				[]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55})
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientNames).Should(Equal([]string{"00:11:22:33:44:55", "mac:00:11:22:33:44:55"}))
+		})
+
+		It("should use the first configured option for the name and all options as identifiers", func() {
+			request := withOptions(newRequestWithClient("google.de.", A, "192.168.178.1"),
+				&dns.EDNS0_LOCAL{Code: 65074, Data: []byte("tv-living-room")},
+				&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")},
+				&dns.EDNS0_LOCAL{Code: 65001, Data: []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}},
+			)
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientNames).Should(Equal([]string{"laptop", "mac:aa:bb:cc:dd:ee:ff", "id:tv-living-room"}))
+			Expect(request.Req.IsEdns0().Option).Should(HaveLen(2))
+		})
+
+		It("should ignore empty options", func() {
+			request := withOptions(newRequestWithClient("google.de.", A, "192.168.178.1"),
+				&dns.EDNS0_LOCAL{Code: 65001, Data: []byte{}},
+				&dns.EDNS0_LOCAL{Code: 65074, Data: []byte("tv-living-room")},
+			)
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientNames).Should(Equal([]string{"tv", "id:tv-living-room"}))
+			Expect(request.Req.IsEdns0().Option).Should(HaveLen(1))
+		})
+
+		It("should use the IP if the option is missing", func() {
+			request := newRequestWithClient("google.de.", A, "192.168.178.1")
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientNames).Should(ConsistOf("router"))
+		})
+
+		It("should prefer the clientID of the request, but strip the option", func() {
+			request := withOption(newRequestWithClientID("google.de.", A, "192.168.178.1", "client123"), []byte("tv"))
+
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientNames).Should(ConsistOf("client123"))
+			Expect(request.Req.IsEdns0().Option).Should(HaveLen(1))
+		})
+
+		When("the option isn't enabled", func() {
+			BeforeEach(func() {
+				sutConfig.EDNS0.Enable = false
+			})
+
+			It("should neither use nor strip the option", func() {
+				request := withOption(newRequestWithClient("google.de.", A, "192.168.178.1"), []byte("tv-living-room"))
+
+				Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				Expect(request.ClientNames).Should(ConsistOf("router"))
+				Expect(request.Req.IsEdns0().Option).Should(HaveLen(2))
+			})
+		})
+	})
+
 	Describe("Resolve client name with custom name mapping", Label("XXX"), func() {
 		BeforeEach(func() {
 			sutConfig = config.ClientLookupConfig{
@@ -141,8 +274,139 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 		})
 	})
 
+	Describe("Resolve client name from DHCP leases", func() {
+		var (
+			tmpDir       *TmpFolder
+			testUpstream *dnstest.MockUpstreamServer
+			expires      time.Time
+		)
+
+		BeforeEach(func() {
+			tmpDir = NewTmpFolder("leases")
+			Expect(tmpDir.Error).Should(Succeed())
+			DeferCleanup(tmpDir.Clean)
+
+			testUpstream = dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+				response := new(dns.Msg)
+				response.SetReply(request)
+
+				ptr, err := dns.NewRR(request.Question[0].Name + " 600 IN PTR rdns")
+				Expect(err).Should(Succeed())
+
+				response.Answer = []dns.RR{ptr}
+
+				return response
+			})
+			DeferCleanup(testUpstream.Close)
+
+			expires = time.Now().Add(2 * time.Hour).Truncate(time.Second)
+
+			dnsmasq := tmpDir.CreateStringFile("dnsmasq.leases",
+				fmt.Sprintf("%d aa:bb:cc:dd:ee:01 192.168.178.20 laptop *", expires.Unix()),
+				fmt.Sprintf("%d aa:bb:cc:dd:ee:02 192.168.178.21 old *", time.Now().Add(-time.Hour).Unix()),
+				"0 aa:bb:cc:dd:ee:03 192.168.178.22 * *",
+				"0 aa:bb:cc:dd:ee:04 192.168.178.23 nas *",
+			)
+			Expect(dnsmasq.Error).Should(Succeed())
+
+			isc := tmpDir.CreateStringFile("dhcpd.leases",
+				"lease 192.168.1.20 {",
+				fmt.Sprintf("  ends epoch %d;", time.Now().Add(30*time.Minute).Unix()),
+				"  binding state active;",
+				"  hardware ethernet aa:bb:cc:dd:ee:05;",
+				`  client-hostname "desktop";`,
+				"}",
+				"lease 192.168.1.21 {",
+				"  ends never;",
+				"  binding state free;",
+				`  client-hostname "gone";`,
+				"}",
+			)
+			Expect(isc.Error).Should(Succeed())
+
+			sutConfig = config.ClientLookupConfig{
+				Upstream:   testUpstream.Start(),
+				LeaseFiles: config.NewBytesSources(dnsmasq.Path, isc.Path),
+				Loading:    config.SourceLoadingConfig{MaxErrorsPerSource: 5},
+				ClientnameIPMapping: map[string][]net.IP{
+					"mapped": {net.ParseIP("192.168.178.23")},
+				},
+			}
+		})
+
+		resolveNames := func(ip string) []string {
+			request := newRequestWithClient("google.de.", dns.Type(dns.TypeA), ip)
+			Expect(sut.Resolve(request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			return request.ClientNames
+		}
+
+		DescribeTable("should resolve the client name",
+			func(ip string, expected string) {
+				Expect(resolveNames(ip)).Should(ConsistOf(expected))
+			},
+			Entry("of a dnsmasq lease before rDNS", "192.168.178.20", "laptop"),
+			Entry("of an ISC dhcpd lease", "192.168.1.20", "desktop"),
+			Entry("via rDNS for an expired lease", "192.168.178.21", "rdns"),
+			Entry("via rDNS for a lease without hostname", "192.168.178.22", "rdns"),
+			Entry("via rDNS for an ISC dhcpd lease which isn't active", "192.168.1.21", "rdns"),
+			Entry("from the mapping before the leases", "192.168.178.23", "mapped"),
+		)
+
+		It("should cache the name until the lease expires", func() {
+			Expect(resolveNames("192.168.1.20")).Should(ConsistOf("desktop"))
+
+			_, ttl := sut.cache.Get("192.168.1.20")
+			Expect(ttl).Should(BeNumerically("<=", 30*time.Minute))
+
+			Expect(resolveNames("192.168.178.20")).Should(ConsistOf("laptop"))
+
+			_, ttl = sut.cache.Get("192.168.178.20")
+			Expect(ttl).Should(BeNumerically("~", time.Hour, time.Minute))
+		})
+
+		It("should log the leases", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("leases = 5"))
+		})
+
+		When("a lease file changes", func() {
+			BeforeEach(func() {
+				sutConfig.Loading.WatchFiles = true
+			})
+
+			It("should use the new leases", func() {
+				Expect(resolveNames("192.168.178.20")).Should(ConsistOf("laptop"))
+
+				Expect(os.WriteFile(tmpDir.JoinPath("dnsmasq.leases"),
+					[]byte(fmt.Sprintf("%d aa:bb:cc:dd:ee:01 192.168.178.20 renamed *\n", expires.Unix())), 0o600),
+				).Should(Succeed())
+
+				Eventually(func() []string {
+					return resolveNames("192.168.178.20")
+				}).Should(ConsistOf("renamed"))
+
+				By("keeping the leases of the other file", func() {
+					Expect(resolveNames("192.168.1.20")).Should(ConsistOf("desktop"))
+				})
+			})
+		})
+
+		When("a lease file can't be loaded anymore", func() {
+			It("should keep its leases", func() {
+				Expect(os.Remove(tmpDir.JoinPath("dnsmasq.leases"))).Should(Succeed())
+
+				Expect(sut.loadLeaseFiles(context.Background())).Should(Succeed())
+				Expect(resolveNames("192.168.178.20")).Should(ConsistOf("laptop"))
+			})
+		})
+	})
+
 	Describe("Resolve client name via rDNS lookup", func() {
-		var testUpstream *MockUDPUpstreamServer
+		var testUpstream *dnstest.MockUpstreamServer
 
 		AfterEach(func() {
 			// next resolver will be called
@@ -152,7 +416,7 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 		Context("Without order", func() {
 			When("Client has one name", func() {
 				BeforeEach(func() {
-					testUpstream = NewMockUDPUpstreamServer().
+					testUpstream = dnstest.NewMockUpstreamServer().
 						WithAnswerRR("25.178.168.192.in-addr.arpa. 600 IN PTR host1")
 					DeferCleanup(testUpstream.Close)
 					sutConfig = config.ClientLookupConfig{
@@ -208,7 +472,7 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 
 			When("Client has multiple names", func() {
 				BeforeEach(func() {
-					testUpstream = NewMockUDPUpstreamServer().
+					testUpstream = dnstest.NewMockUpstreamServer().
 						WithAnswerRR("25.178.168.192.in-addr.arpa. 600 IN PTR myhost1", "25.178.168.192.in-addr.arpa. 600 IN PTR myhost2")
 					DeferCleanup(testUpstream.Close)
 					sutConfig = config.ClientLookupConfig{
@@ -238,7 +502,7 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 			})
 			When("Client has one name", func() {
 				BeforeEach(func() {
-					testUpstream = NewMockUDPUpstreamServer().
+					testUpstream = dnstest.NewMockUpstreamServer().
 						WithAnswerRR("25.178.168.192.in-addr.arpa. 600 IN PTR host1")
 					DeferCleanup(testUpstream.Close)
 					sutConfig.Upstream = testUpstream.Start()
@@ -259,7 +523,7 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 			})
 			When("Client has multiple names", func() {
 				BeforeEach(func() {
-					testUpstream = NewMockUDPUpstreamServer().
+					testUpstream = dnstest.NewMockUpstreamServer().
 						WithAnswerRR("25.178.168.192.in-addr.arpa. 600 IN PTR myhost1", "25.178.168.192.in-addr.arpa. 600 IN PTR myhost2")
 					DeferCleanup(testUpstream.Close)
 					sutConfig.Upstream = testUpstream.Start()
@@ -283,7 +547,7 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 		Context("Error cases", func() {
 			When("Upstream can't resolve client name via rDNS", func() {
 				BeforeEach(func() {
-					testUpstream = NewMockUDPUpstreamServer().
+					testUpstream = dnstest.NewMockUpstreamServer().
 						WithAnswerError(dns.RcodeNameError)
 					DeferCleanup(testUpstream.Close)
 					sutConfig = config.ClientLookupConfig{
@@ -363,7 +627,7 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 			It("errors during construction", func() {
 				b := newTestBootstrap(&dns.Msg{MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure}})
 
-				r, err := NewClientNamesResolver(config.ClientLookupConfig{
+				r, err := NewClientNamesResolver(sutCtx, config.ClientLookupConfig{
 					Upstream: config.Upstream{Host: "example.com"},
 				}, b, true)
 
@@ -0,0 +1,193 @@
+package resolver
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+)
+
+const clientStatsErrorResponseType = "ERROR"
+
+// ErrClientStatsDisabled is returned by the client statistics API if the statistics are disabled
+var ErrClientStatsDisabled = errors.New("client statistics are disabled")
+
+// ClientStatsResolver counts the queries per client, query type and response type.
+//
+// Only the clients with the most queries are tracked: if the limit is reached, the client with the fewest queries is
+// replaced by the new one, which inherits its count as error bound (space-saving algorithm).
+// Frequent clients are therefore kept, even if many clients only send a few queries.
+type ClientStatsResolver struct {
+	configurable[*config.ClientStatsConfig]
+	NextResolver
+	typed
+
+	lock    sync.Mutex
+	since   time.Time
+	clients map[string]*clientStats
+}
+
+type clientStats struct {
+	total      int
+	blocked    int
+	errorBound int
+
+	queryTypes    map[dns.Type]int
+	responseTypes map[string]int
+}
+
+// NewClientStatsResolver creates a new instance of the ClientStatsResolver type
+func NewClientStatsResolver(cfg config.ClientStatsConfig) *ClientStatsResolver {
+	return &ClientStatsResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("client_stats"),
+
+		since:   time.Now(),
+		clients: make(map[string]*clientStats),
+	}
+}
+
+// Resolve counts the query of the client and passes it to the next resolver
+func (r *ClientStatsResolver) Resolve(request *model.Request) (*model.Response, error) {
+	response, err := r.next.Resolve(request)
+
+	if r.cfg.Enable {
+		responseType := clientStatsErrorResponseType
+		if err == nil {
+			responseType = response.RType.String()
+		}
+
+		r.record(clientStatsKey(request), dns.Type(request.Req.Question[0].Qtype), responseType)
+	}
+
+	return response, err
+}
+
+// ClientStats implements `api.ClientStatsProvider`
+func (r *ClientStatsResolver) ClientStats() (api.ClientStats, error) {
+	if !r.cfg.Enable {
+		return api.ClientStats{}, ErrClientStatsDisabled
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.resetIfExpired()
+
+	result := api.ClientStats{
+		Since:   r.since,
+		Clients: make([]api.ClientStatsEntry, 0, len(r.clients)),
+	}
+
+	for name, stats := range r.clients {
+		entry := api.ClientStatsEntry{
+			Client:        name,
+			Total:         stats.total,
+			Blocked:       stats.blocked,
+			ErrorBound:    stats.errorBound,
+			QueryTypes:    make(map[string]int, len(stats.queryTypes)),
+			ResponseTypes: make(map[string]int, len(stats.responseTypes)),
+		}
+
+		for qType, count := range stats.queryTypes {
+			entry.QueryTypes[qType.String()] = count
+		}
+
+		for rType, count := range stats.responseTypes {
+			entry.ResponseTypes[rType] = count
+		}
+
+		result.Clients = append(result.Clients, entry)
+	}
+
+	return result, nil
+}
+
+// ResetClientStats implements `api.ClientStatsProvider`
+func (r *ClientStatsResolver) ResetClientStats() error {
+	if !r.cfg.Enable {
+		return ErrClientStatsDisabled
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.reset()
+
+	return nil
+}
+
+func (r *ClientStatsResolver) record(client string, qType dns.Type, responseType string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.resetIfExpired()
+
+	stats, found := r.clients[client]
+	if !found {
+		stats = r.track(client)
+	}
+
+	stats.total++
+	stats.queryTypes[qType]++
+	stats.responseTypes[responseType]++
+
+	if responseType == model.ResponseTypeBLOCKED.String() {
+		stats.blocked++
+	}
+}
+
+// track adds a new client, replacing the client with the fewest queries if the limit is reached
+func (r *ClientStatsResolver) track(client string) *clientStats {
+	stats := &clientStats{
+		queryTypes:    make(map[dns.Type]int),
+		responseTypes: make(map[string]int),
+	}
+
+	if r.cfg.MaxClients > 0 && uint(len(r.clients)) >= r.cfg.MaxClients {
+		var (
+			minName  string
+			minStats *clientStats
+		)
+
+		for name, s := range r.clients {
+			if minStats == nil || s.total+s.errorBound < minStats.total+minStats.errorBound {
+				minName, minStats = name, s
+			}
+		}
+
+		delete(r.clients, minName)
+
+		stats.errorBound = minStats.total + minStats.errorBound
+	}
+
+	r.clients[client] = stats
+
+	return stats
+}
+
+func (r *ClientStatsResolver) resetIfExpired() {
+	if r.cfg.ResetInterval.IsAboveZero() && time.Since(r.since) >= r.cfg.ResetInterval.ToDuration() {
+		r.reset()
+	}
+}
+
+func (r *ClientStatsResolver) reset() {
+	r.since = time.Now()
+	r.clients = make(map[string]*clientStats)
+}
+
+// clientStatsKey returns the effective client name, or the IP if no name is known
+func clientStatsKey(request *model.Request) string {
+	if len(request.ClientNames) > 0 {
+		return strings.Join(request.ClientNames, ",")
+	}
+
+	return request.ClientIP.String()
+}
@@ -0,0 +1,220 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("ClientStatsResolver", func() {
+	var (
+		sut       *ClientStatsResolver
+		sutConfig config.ClientStatsConfig
+		m         *mockResolver
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.ClientStatsConfig]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Enable = true
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{
+			Res:    new(dns.Msg),
+			RType:  ResponseTypeRESOLVED,
+			Reason: "Test",
+		}, nil)
+	})
+
+	JustBeforeEach(func() {
+		sut = NewClientStatsResolver(sutConfig)
+		sut.Next(m)
+	})
+
+	resolve := func(qType dns.Type, clientNames ...string) {
+		_, err := sut.Resolve(newRequestWithClient("example.com.", qType, "192.168.178.25", clientNames...))
+		Expect(err).Should(Succeed())
+	}
+
+	clientStats := func() map[string]api.ClientStatsEntry {
+		stats, err := sut.ClientStats()
+		Expect(err).Should(Succeed())
+
+		result := make(map[string]api.ClientStatsEntry, len(stats.Clients))
+		for _, c := range stats.Clients {
+			result[c.Client] = c
+		}
+
+		return result
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("queries are resolved", func() {
+		It("should count them per client, query type and response type", func() {
+			resolve(A, "laptop")
+			resolve(PTR, "laptop")
+			resolve(PTR, "laptop")
+			resolve(AAAA, "phone")
+
+			m.ResolveFn = func(*Request) (*Response, error) {
+				return &Response{Res: new(dns.Msg), RType: ResponseTypeBLOCKED, Reason: "BLOCKED (ads)"}, nil
+			}
+
+			resolve(A, "laptop")
+
+			stats := clientStats()
+			Expect(stats).Should(HaveLen(2))
+			Expect(stats["laptop"]).Should(Equal(api.ClientStatsEntry{
+				Client:        "laptop",
+				Total:         4,
+				Blocked:       1,
+				QueryTypes:    map[string]int{"A": 2, "PTR": 2},
+				ResponseTypes: map[string]int{"RESOLVED": 3, "BLOCKED": 1},
+			}))
+			Expect(stats["phone"].Total).Should(Equal(1))
+			Expect(stats["phone"].QueryTypes).Should(Equal(map[string]int{"AAAA": 1}))
+		})
+
+		It("should count errors", func() {
+			m.ResolveFn = func(*Request) (*Response, error) {
+				return nil, errors.New("upstream error")
+			}
+
+			_, err := sut.Resolve(newRequestWithClient("example.com.", A, "192.168.178.25", "laptop"))
+			Expect(err).Should(HaveOccurred())
+
+			Expect(clientStats()["laptop"].ResponseTypes).Should(Equal(map[string]int{"ERROR": 1}))
+		})
+
+		It("should use the client IP if the name is unknown", func() {
+			resolve(A)
+
+			Expect(clientStats()).Should(HaveKey("192.168.178.25"))
+		})
+
+		It("should join multiple client names", func() {
+			resolve(A, "laptop", "laptop.lan")
+
+			Expect(clientStats()).Should(HaveKey("laptop,laptop.lan"))
+		})
+	})
+
+	When("the client limit is reached", func() {
+		BeforeEach(func() {
+			sutConfig.MaxClients = 2
+		})
+
+		It("should replace the client with the fewest queries", func() {
+			for i := 0; i < 5; i++ {
+				resolve(PTR, "tv")
+			}
+
+			resolve(A, "laptop")
+			resolve(A, "laptop")
+			resolve(A, "phone")
+
+			stats := clientStats()
+			Expect(stats).Should(HaveLen(2))
+			Expect(stats).Should(HaveKey("tv"))
+			Expect(stats["tv"].ErrorBound).Should(BeZero())
+			Expect(stats["phone"].Total).Should(Equal(1))
+			Expect(stats["phone"].ErrorBound).Should(Equal(2))
+		})
+
+		It("should keep a frequent client if many clients send a few queries", func() {
+			for i := 0; i < 10; i++ {
+				resolve(PTR, "tv")
+				resolve(A, fmt.Sprintf("client-%d", i))
+			}
+
+			stats := clientStats()
+			Expect(stats).Should(HaveLen(2))
+			Expect(stats["tv"].Total).Should(Equal(10))
+		})
+	})
+
+	When("the statistics are reset", func() {
+		It("should remove all clients", func() {
+			resolve(A, "laptop")
+
+			before, err := sut.ClientStats()
+			Expect(err).Should(Succeed())
+
+			Expect(sut.ResetClientStats()).Should(Succeed())
+
+			after, err := sut.ClientStats()
+			Expect(err).Should(Succeed())
+			Expect(after.Clients).Should(BeEmpty())
+			Expect(after.Since).Should(BeTemporally(">=", before.Since))
+		})
+	})
+
+	When("the reset interval is expired", func() {
+		BeforeEach(func() {
+			sutConfig.ResetInterval = config.Duration(50 * time.Millisecond)
+		})
+
+		It("should reset the statistics", func() {
+			resolve(A, "laptop")
+
+			Expect(clientStats()).Should(HaveKey("laptop"))
+
+			Eventually(clientStats).Should(BeEmpty())
+		})
+	})
+
+	When("client statistics are disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Enable = false
+		})
+
+		It("should not count queries", func() {
+			resolve(A, "laptop")
+
+			Expect(sut.clients).Should(BeEmpty())
+			m.AssertExpectations(GinkgoT())
+		})
+
+		It("should return an error", func() {
+			_, err := sut.ClientStats()
+			Expect(err).Should(MatchError(ErrClientStatsDisabled))
+
+			Expect(sut.ResetClientStats()).Should(MatchError(ErrClientStatsDisabled))
+		})
+	})
+})
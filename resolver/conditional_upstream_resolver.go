@@ -1,6 +1,8 @@
 package resolver
 
 import (
+	"fmt"
+	"net"
 	"strings"
 
 	"github.com/0xERR0R/blocky/config"
@@ -12,20 +14,45 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// ipv4LabelBits are the bits of an IPv4 address per label of a reverse zone
+	ipv4LabelBits = 8
+	// ipv6LabelBits are the bits of an IPv6 address per label of a reverse zone
+	ipv6LabelBits = 4
+
+	// minimizeMaxQueries limits the queries of a minimized resolution (RFC 9156, MAX_MINIMISE_COUNT)
+	minimizeMaxQueries = 10
+	// minimizeOneLabelQueries are the first queries, which add only one label (RFC 9156, MINIMISE_ONE_LAB)
+	minimizeOneLabelQueries = 4
+)
+
 // ConditionalUpstreamResolver delegates DNS question to other DNS resolver dependent on domain name in question
 type ConditionalUpstreamResolver struct {
 	configurable[*config.ConditionalUpstreamConfig]
 	NextResolver
 	typed
 
-	mapping map[string]Resolver
+	mapping         map[string]conditionalUpstream
+	defaultUpstream Resolver
 }
 
-// NewConditionalUpstreamResolver returns new resolver instance
+// conditionalUpstream resolves the queries of a domain of the mapping
+type conditionalUpstream struct {
+	resolver    Resolver
+	fallThrough config.ConditionalFallthrough
+	minimize    bool
+}
+
+// NewConditionalUpstreamResolver returns new resolver instance.
+// CIDR keys of the mapping are translated to their reverse zones: a more specific prefix takes precedence and a
+// reverse zone defined as domain takes precedence over all prefixes.
+// Queries of domains which fall through are resolved with defaultUpstream, which is only required for them.
+// The answers of defaultUpstream aren't passed to the rest of the chain, it has to check them itself.
 func NewConditionalUpstreamResolver(
-	cfg config.ConditionalUpstreamConfig, bootstrap *Bootstrap, shouldVerifyUpstreams bool,
+	cfg config.ConditionalUpstreamConfig, defaultUpstream Resolver, bootstrap *Bootstrap, shouldVerifyUpstreams bool,
 ) (*ConditionalUpstreamResolver, error) {
-	m := make(map[string]Resolver, len(cfg.Mapping.Upstreams))
+	m := make(map[string]conditionalUpstream, len(cfg.Mapping.Upstreams))
+	subnets := make(map[*net.IPNet]conditionalUpstream)
 
 	for domain, upstream := range cfg.Mapping.Upstreams {
 		pbCfg := config.UpstreamsConfig{
@@ -39,19 +66,124 @@ func NewConditionalUpstreamResolver(
 			return nil, err
 		}
 
-		m[strings.ToLower(domain)] = r
+		upstream := conditionalUpstream{
+			resolver:    r,
+			fallThrough: cfg.Mapping.Fallthrough[domain],
+			minimize:    cfg.Mapping.Minimize[domain],
+		}
+
+		if upstream.fallThrough != (config.ConditionalFallthrough{}) && defaultUpstream == nil {
+			return nil, fmt.Errorf("%s: no default upstream to fall through to", domain)
+		}
+
+		if _, subnet, err := net.ParseCIDR(domain); err == nil {
+			subnets[subnet] = upstream
+
+			continue
+		}
+
+		m[strings.ToLower(domain)] = upstream
 	}
 
+	addReverseZones(m, subnets)
+
 	r := ConditionalUpstreamResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("conditional_upstream"),
 
-		mapping: m,
+		mapping:         m,
+		defaultUpstream: defaultUpstream,
 	}
 
 	return &r, nil
 }
 
+// addReverseZones adds the reverse zones of the subnets which aren't already in the mapping
+func addReverseZones(mapping map[string]conditionalUpstream, subnets map[*net.IPNet]conditionalUpstream) {
+	zones := make(map[string]conditionalUpstream)
+	prefixLengths := make(map[string]int)
+
+	for subnet, r := range subnets {
+		ones, _ := subnet.Mask.Size()
+
+		for _, zone := range reverseZones(subnet) {
+			if length, found := prefixLengths[zone]; !found || ones > length {
+				zones[zone] = r
+				prefixLengths[zone] = ones
+			}
+		}
+	}
+
+	for zone, r := range zones {
+		if _, found := mapping[zone]; !found {
+			mapping[zone] = r
+		}
+	}
+}
+
+// reverseZones returns the reverse zones (in-addr.arpa or ip6.arpa) which contain exactly the addresses of the
+// subnet. A prefix which doesn't end on a label boundary (8 bits for IPv4, 4 bits for IPv6) is split into
+// the zones of the next longer prefix which does, e.g. 192.168.0.0/23 into 0.168.192.in-addr.arpa and
+// 1.168.192.in-addr.arpa.
+func reverseZones(subnet *net.IPNet) []string {
+	labelBits, format, suffix := ipv4LabelBits, "%d.", "in-addr.arpa"
+
+	// the mask tells the address family, an IPv4-mapped IPv6 subnet is an IPv6 subnet
+	ones, bits := subnet.Mask.Size()
+
+	ip := subnet.IP.To4()
+	if bits != 8*net.IPv4len {
+		ip = subnet.IP.To16()
+		labelBits, format, suffix = ipv6LabelBits, "%x.", "ip6.arpa"
+	}
+
+	// labels of the zone and the bits of its last label, which aren't part of the prefix
+	count := (ones + labelBits - 1) / labelBits
+	freeBits := count*labelBits - ones
+
+	labels := make([]int, count)
+
+	for i := range labels {
+		labels[i] = ipLabel(ip, i, labelBits)
+	}
+
+	zones := make([]string, 0, 1<<freeBits)
+
+	for i := 0; i < 1<<freeBits; i++ {
+		var sb strings.Builder
+
+		for j := count - 1; j >= 0; j-- {
+			label := labels[j]
+			if j == count-1 {
+				// the free bits of the subnet address are 0
+				label |= i
+			}
+
+			fmt.Fprintf(&sb, format, label)
+		}
+
+		sb.WriteString(suffix)
+
+		zones = append(zones, sb.String())
+	}
+
+	return zones
+}
+
+// ipLabel returns the value of the i-th label of the IP: a byte for IPv4 or a nibble for IPv6
+func ipLabel(ip net.IP, i, labelBits int) int {
+	if labelBits == ipv4LabelBits {
+		return int(ip[i])
+	}
+
+	b := ip[i/2]
+	if i%2 == 0 {
+		return int(b >> ipv6LabelBits)
+	}
+
+	return int(b & 0x0f) //nolint:gomnd
+}
+
 func (r *ConditionalUpstreamResolver) processRequest(request *model.Request) (bool, *model.Response, error) {
 	domainFromQuestion := util.ExtractDomain(request.Req.Question[0])
 	domain := domainFromQuestion
@@ -59,8 +191,8 @@ func (r *ConditionalUpstreamResolver) processRequest(request *model.Request) (bo
 	if strings.Contains(domainFromQuestion, ".") {
 		// try with domain with and without sub-domains
 		for len(domain) > 0 {
-			if resolver, found := r.mapping[domain]; found {
-				resp, err := r.internalResolve(resolver, domainFromQuestion, domain, request)
+			if upstream, found := r.mapping[domain]; found {
+				resp, err := r.internalResolve(upstream, domainFromQuestion, domain, request)
 
 				return true, resp, err
 			}
@@ -71,8 +203,8 @@ func (r *ConditionalUpstreamResolver) processRequest(request *model.Request) (bo
 				break
 			}
 		}
-	} else if resolver, found := r.mapping["."]; found {
-		resp, err := r.internalResolve(resolver, domainFromQuestion, domain, request)
+	} else if upstream, found := r.mapping["."]; found {
+		resp, err := r.internalResolve(upstream, domainFromQuestion, domain, request)
 
 		return true, resp, err
 	}
@@ -80,6 +212,27 @@ func (r *ConditionalUpstreamResolver) processRequest(request *model.Request) (bo
 	return false, nil, nil
 }
 
+// fallthroughCause returns the reason to fall through to the default upstream, if the response or error require it
+func fallthroughCause(ft config.ConditionalFallthrough, response *model.Response, err error) (string, bool) {
+	switch {
+	case err != nil:
+		return "ERROR", ft.OnError
+	case response.Res.Rcode == dns.RcodeServerFailure:
+		return "SERVFAIL", ft.OnError
+	case response.Res.Rcode == dns.RcodeNameError:
+		return "NXDOMAIN", ft.OnNXDomain
+	}
+
+	return "", false
+}
+
+// resetConnections implements `connectionResetter`.
+func (r *ConditionalUpstreamResolver) resetConnections() {
+	for _, upstream := range r.mapping {
+		ResetConnections(upstream.resolver)
+	}
+}
+
 // Resolve uses the conditional resolver to resolve the query
 func (r *ConditionalUpstreamResolver) Resolve(request *model.Request) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, "conditional_resolver")
@@ -96,16 +249,36 @@ func (r *ConditionalUpstreamResolver) Resolve(request *model.Request) (*model.Re
 	return r.next.Resolve(request)
 }
 
-func (r *ConditionalUpstreamResolver) internalResolve(reso Resolver, doFQ, do string,
+func (r *ConditionalUpstreamResolver) internalResolve(upstream conditionalUpstream, doFQ, do string,
 	req *model.Request,
 ) (*model.Response, error) {
 	// internal request resolution
 	logger := log.WithPrefix(req.Log, "conditional_resolver")
+	reso := upstream.resolver
 
 	req.Req.Question[0].Name = dns.Fqdn(doFQ)
-	response, err := reso.Resolve(req)
 
-	if err == nil {
+	var (
+		response *model.Response
+		err      error
+	)
+
+	if upstream.minimize {
+		response, err = resolveMinimized(reso, do, req)
+	} else {
+		response, err = reso.Resolve(req)
+	}
+
+	if cause, ok := fallthroughCause(upstream.fallThrough, response, err); ok {
+		logger.WithField("domain", do).WithError(err).Debugf("falling through to default upstream on %s", cause)
+
+		response, err = r.defaultUpstream.Resolve(req)
+		if err == nil {
+			response.Reason = fmt.Sprintf("CONDITIONAL FALLTHROUGH (%s): %s", cause, response.Reason)
+		}
+
+		reso = r.defaultUpstream
+	} else if err == nil {
 		response.Reason = "CONDITIONAL"
 		response.RType = model.ResponseTypeCONDITIONAL
 		response.Res.Question[0].Name = req.Req.Question[0].Name
@@ -124,3 +297,59 @@ func (r *ConditionalUpstreamResolver) internalResolve(reso Resolver, doFQ, do st
 
 	return response, err
 }
+
+// resolveMinimized resolves the query with QNAME minimization (RFC 9156): the names between the zone and the name
+// of the query are queried first with type A, so the upstream only gets the full name, if they exist.
+// NXDOMAIN of a name is the answer for all names below it (RFC 8020). If a minimized query fails,
+// the full name is queried
+func resolveMinimized(reso Resolver, zone string, request *model.Request) (*model.Response, error) {
+	logger := log.WithPrefix(request.Log, "conditional_resolver")
+
+	for _, name := range minimizedNames(request.Req.Question[0].Name, zone) {
+		minimized := *request
+		minimized.Req = util.NewMsgWithQuestion(name, dns.Type(dns.TypeA))
+		minimized.Req.RecursionDesired = request.Req.RecursionDesired
+
+		response, err := reso.Resolve(&minimized)
+		if err != nil || (response.Res.Rcode != dns.RcodeSuccess && response.Res.Rcode != dns.RcodeNameError) {
+			logger.WithField("domain", util.Obfuscate(name)).WithError(err).
+				Debug("minimized query failed, querying the full name")
+
+			break
+		}
+
+		if response.Res.Rcode == dns.RcodeNameError {
+			res := new(dns.Msg)
+			res.SetRcode(request.Req, dns.RcodeNameError)
+			res.Ns = response.Res.Ns
+
+			return &model.Response{Res: res, RType: response.RType, Reason: response.Reason}, nil
+		}
+	}
+
+	return reso.Resolve(request)
+}
+
+// minimizedNames returns the names of the minimized queries of the name below the zone (RFC 9156, 2.3): the first
+// queries add one label to the zone, the remaining labels are split evenly between the remaining queries.
+// The name itself isn't included, it's the last query
+func minimizedNames(name, zone string) []string {
+	labels := dns.CountLabel(name)
+
+	var names []string
+
+	for n := dns.CountLabel(zone) + 1; n < labels; {
+		names = append(names, lastLabels(name, n))
+
+		step := 1
+
+		if len(names) >= minimizeOneLabelQueries {
+			remaining := minimizeMaxQueries - len(names)
+			step = (labels - n + remaining - 1) / remaining
+		}
+
+		n += step
+	}
+
+	return names
+}
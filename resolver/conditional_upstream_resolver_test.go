@@ -1,7 +1,12 @@
 package resolver
 
 import (
+	"net"
+	"strings"
+	"sync"
+
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
@@ -26,21 +31,21 @@ var _ = Describe("ConditionalUpstreamResolver", Label("conditionalResolver"), fu
 	})
 
 	BeforeEach(func() {
-		fbTestUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+		fbTestUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 			response, _ = util.NewMsgWithAnswer(request.Question[0].Name, 123, A, "123.124.122.122")
 
 			return response
 		})
 		DeferCleanup(fbTestUpstream.Close)
 
-		otherTestUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+		otherTestUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 			response, _ = util.NewMsgWithAnswer(request.Question[0].Name, 250, A, "192.192.192.192")
 
 			return response
 		})
 		DeferCleanup(otherTestUpstream.Close)
 
-		dotTestUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+		dotTestUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 			response, _ = util.NewMsgWithAnswer(request.Question[0].Name, 223, A, "168.168.168.168")
 
 			return response
@@ -55,7 +60,7 @@ var _ = Describe("ConditionalUpstreamResolver", Label("conditionalResolver"), fu
 					".":         {dotTestUpstream.Start()},
 				},
 			},
-		}, nil, false)
+		}, nil, nil, false)
 		m = &mockResolver{}
 		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
 		sut.Next(m)
@@ -156,6 +161,342 @@ var _ = Describe("ConditionalUpstreamResolver", Label("conditionalResolver"), fu
 		})
 	})
 
+	Describe("CIDR mapping", func() {
+		newPTRUpstream := func(ptr string) config.Upstream {
+			upstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+				response, _ = util.NewMsgWithAnswer(request.Question[0].Name, 60, PTR, ptr)
+
+				return response
+			})
+			DeferCleanup(upstream.Close)
+
+			return upstream.Start()
+		}
+
+		BeforeEach(func() {
+			router := newPTRUpstream("host.router.lan.")
+			other := newPTRUpstream("host.other.lan.")
+
+			var err error
+
+			sut, err = NewConditionalUpstreamResolver(config.ConditionalUpstreamConfig{
+				Mapping: config.ConditionalUpstreamMapping{
+					Upstreams: map[string][]config.Upstream{
+						"192.168.1.0/24":         {router},
+						"192.168.0.0/16":         {other},
+						"10.0.0.0/23":            {router},
+						"10.0.1.0/24":            {other},
+						"192.168.2.0/24":         {router},
+						"2.168.192.in-addr.arpa": {other},
+						"2001:db8:1234::/48":     {router},
+						"fd00:0:0:10::/62":       {router},
+					},
+				},
+			}, nil, nil, false)
+			Expect(err).Should(Succeed())
+
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+			sut.Next(m)
+		})
+
+		It("should forward queries of the subnets to their upstream", func() {
+			for name, ptr := range map[string]string{
+				"5.1.168.192.in-addr.arpa.": "host.router.lan.",
+				"5.9.168.192.in-addr.arpa.": "host.other.lan.",
+				"5.0.0.10.in-addr.arpa.":    "host.router.lan.",
+				"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.4.3.2.1.8.b.d.0.1.0.0.2.ip6.arpa.": "host.router.lan.",
+				"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.3.1.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa.": "host.router.lan.",
+			} {
+				Expect(sut.Resolve(newRequest(name, PTR))).Should(SatisfyAll(
+					BeDNSRecord(name, PTR, ptr),
+					HaveResponseType(ResponseTypeCONDITIONAL),
+				), name)
+			}
+
+			Expect(m.Calls).Should(BeEmpty())
+		})
+
+		It("should prefer the more specific subnet for the same zone", func() {
+			Expect(sut.Resolve(newRequest("5.1.0.10.in-addr.arpa.", PTR))).
+				Should(BeDNSRecord("5.1.0.10.in-addr.arpa.", PTR, "host.other.lan."))
+		})
+
+		It("should prefer the reverse zone defined as domain", func() {
+			Expect(sut.Resolve(newRequest("5.2.168.192.in-addr.arpa.", PTR))).
+				Should(BeDNSRecord("5.2.168.192.in-addr.arpa.", PTR, "host.other.lan."))
+		})
+
+		It("should pass queries outside of the subnets to the next resolver", func() {
+			for _, name := range []string{
+				"5.2.169.192.in-addr.arpa.",
+				"5.2.0.10.in-addr.arpa.",
+				"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.5.3.2.1.8.b.d.0.1.0.0.2.ip6.arpa.",
+				"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.4.1.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa.",
+			} {
+				Expect(sut.Resolve(newRequest(name, PTR))).Should(HaveResponseType(ResponseTypeRESOLVED), name)
+			}
+
+			m.AssertNumberOfCalls(GinkgoT(), "Resolve", 4)
+		})
+	})
+
+	Describe("Fallthrough to default upstream", func() {
+		var defaultUpstream *mockResolver
+
+		newSut := func(domain string, ft config.ConditionalFallthrough, upstream config.Upstream) {
+			var err error
+
+			sut, err = NewConditionalUpstreamResolver(config.ConditionalUpstreamConfig{
+				Mapping: config.ConditionalUpstreamMapping{
+					Upstreams:   map[string][]config.Upstream{domain: {upstream}},
+					Fallthrough: map[string]config.ConditionalFallthrough{domain: ft},
+				},
+			}, defaultUpstream, nil, false)
+			Expect(err).Should(Succeed())
+
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+			sut.Next(m)
+		}
+
+		nxdomainUpstream := func() config.Upstream {
+			upstream := dnstest.NewMockUpstreamServer().WithAnswerError(dns.RcodeNameError)
+			DeferCleanup(upstream.Close)
+
+			return upstream.Start()
+		}
+
+		BeforeEach(func() {
+			defaultUpstream = &mockResolver{}
+			defaultUpstream.On("Resolve", mock.Anything).Return(nil, nil)
+			defaultUpstream.ResolveFn = func(req *Request) (*Response, error) {
+				msg, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 300, A, "192.0.2.1")
+
+				return &Response{Res: msg, RType: ResponseTypeRESOLVED, Reason: "RESOLVED (default)"}, err
+			}
+		})
+
+		When("the conditional upstream fails", func() {
+			var failing config.Upstream
+
+			BeforeEach(func() {
+				upstream := dnstest.NewMockUpstreamServer()
+				failing = upstream.Start()
+				upstream.Close()
+			})
+
+			It("should resolve with the default upstream if enabled", func() {
+				newSut("corp.example.com", config.ConditionalFallthrough{OnError: true}, failing)
+
+				Expect(sut.Resolve(newRequest("host.corp.example.com.", A))).Should(SatisfyAll(
+					BeDNSRecord("host.corp.example.com.", A, "192.0.2.1"),
+					HaveResponseType(ResponseTypeRESOLVED),
+					HaveReason("CONDITIONAL FALLTHROUGH (ERROR): RESOLVED (default)"),
+				))
+
+				Expect(defaultUpstream.Calls).Should(HaveLen(1))
+				Expect(m.Calls).Should(BeEmpty())
+			})
+
+			It("should return the error if only NXDOMAIN falls through", func() {
+				newSut("corp.example.com", config.ConditionalFallthrough{OnNXDomain: true}, failing)
+
+				_, err := sut.Resolve(newRequest("host.corp.example.com.", A))
+				Expect(err).Should(HaveOccurred())
+
+				Expect(defaultUpstream.Calls).Should(BeEmpty())
+			})
+		})
+
+		When("the conditional upstream answers NXDOMAIN", func() {
+			It("should resolve with the default upstream if enabled", func() {
+				newSut("corp.example.com", config.ConditionalFallthrough{OnNXDomain: true}, nxdomainUpstream())
+
+				Expect(sut.Resolve(newRequest("host.corp.example.com.", A))).Should(SatisfyAll(
+					BeDNSRecord("host.corp.example.com.", A, "192.0.2.1"),
+					HaveReason("CONDITIONAL FALLTHROUGH (NXDOMAIN): RESOLVED (default)"),
+				))
+			})
+
+			It("should return NXDOMAIN if only errors fall through", func() {
+				newSut("corp.example.com", config.ConditionalFallthrough{OnError: true}, nxdomainUpstream())
+
+				Expect(sut.Resolve(newRequest("host.corp.example.com.", A))).Should(SatisfyAll(
+					HaveReturnCode(dns.RcodeNameError),
+					HaveResponseType(ResponseTypeCONDITIONAL),
+					HaveReason("CONDITIONAL"),
+				))
+
+				Expect(defaultUpstream.Calls).Should(BeEmpty())
+			})
+		})
+
+		It("should require a default upstream", func() {
+			_, err := NewConditionalUpstreamResolver(config.ConditionalUpstreamConfig{
+				Mapping: config.ConditionalUpstreamMapping{
+					Upstreams: map[string][]config.Upstream{"corp.example.com": {nxdomainUpstream()}},
+					Fallthrough: map[string]config.ConditionalFallthrough{
+						"corp.example.com": {OnError: true},
+					},
+				},
+			}, nil, nil, false)
+			Expect(err).Should(MatchError(ContainSubstring("no default upstream")))
+		})
+	})
+
+	Describe("QNAME minimization", func() {
+		var (
+			mu       sync.Mutex
+			qnames   []string
+			upstream config.Upstream
+			minimize map[string]bool
+		)
+
+		// sent returns the names of the queries, which the upstream received
+		sent := func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return qnames
+		}
+
+		BeforeEach(func() {
+			qnames = nil
+			minimize = map[string]bool{"corp.example.com": true}
+
+			server := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+				name := request.Question[0].Name
+
+				mu.Lock()
+				qnames = append(qnames, name)
+				mu.Unlock()
+
+				if strings.HasSuffix(name, "missing.corp.example.com.") {
+					response := new(dns.Msg)
+					response.SetRcode(request, dns.RcodeNameError)
+
+					return response
+				}
+
+				response, _ := util.NewMsgWithAnswer(name, 300, A, "192.0.2.1")
+
+				return response
+			})
+			DeferCleanup(server.Close)
+
+			upstream = server.Start()
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			sut, err = NewConditionalUpstreamResolver(config.ConditionalUpstreamConfig{
+				Mapping: config.ConditionalUpstreamMapping{
+					Upstreams: map[string][]config.Upstream{"corp.example.com": {upstream}},
+					Minimize:  minimize,
+				},
+			}, nil, nil, false)
+			Expect(err).Should(Succeed())
+		})
+
+		It("should query the upstream one label at a time", func() {
+			Expect(sut.Resolve(newRequest("a.b.c.corp.example.com.", A))).Should(SatisfyAll(
+				BeDNSRecord("a.b.c.corp.example.com.", A, "192.0.2.1"),
+				HaveResponseType(ResponseTypeCONDITIONAL),
+				HaveReason("CONDITIONAL"),
+			))
+
+			Expect(sent()).Should(Equal([]string{
+				"c.corp.example.com.",
+				"b.c.corp.example.com.",
+				"a.b.c.corp.example.com.",
+			}))
+		})
+
+		It("should only send the full name for the zone and its children", func() {
+			_, err := sut.Resolve(newRequest("host.corp.example.com.", AAAA))
+			Expect(err).Should(Succeed())
+
+			Expect(sent()).Should(Equal([]string{"host.corp.example.com."}))
+		})
+
+		It("should stop at NXDOMAIN", func() {
+			Expect(sut.Resolve(newRequest("a.b.missing.corp.example.com.", A))).Should(SatisfyAll(
+				HaveNoAnswer(),
+				HaveReturnCode(dns.RcodeNameError),
+				HaveResponseType(ResponseTypeCONDITIONAL),
+			))
+
+			Expect(sent()).Should(Equal([]string{"missing.corp.example.com."}))
+		})
+
+		When("minimize is not set", func() {
+			BeforeEach(func() {
+				minimize = nil
+			})
+
+			It("should send the full name", func() {
+				_, err := sut.Resolve(newRequest("a.b.c.corp.example.com.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(sent()).Should(Equal([]string{"a.b.c.corp.example.com."}))
+			})
+		})
+
+		DescribeTable("minimizedNames",
+			func(name, zone string, expected []string) {
+				Expect(minimizedNames(name, zone)).Should(Equal(expected))
+			},
+			Entry("the zone", "corp.example.com.", "corp.example.com", nil),
+			Entry("a child of the zone", "host.corp.example.com.", "corp.example.com", nil),
+			Entry("the root zone", "a.b.c.", ".", []string{"c.", "b.c."}),
+			Entry("more labels than queries",
+				"1.2.3.4.5.6.7.8.9.10.11.12.13.14.15.16.example.", "example",
+				[]string{
+					"16.example.",
+					"15.16.example.",
+					"14.15.16.example.",
+					"13.14.15.16.example.",
+					"11.12.13.14.15.16.example.",
+					"9.10.11.12.13.14.15.16.example.",
+					"7.8.9.10.11.12.13.14.15.16.example.",
+					"5.6.7.8.9.10.11.12.13.14.15.16.example.",
+					"3.4.5.6.7.8.9.10.11.12.13.14.15.16.example.",
+				}),
+		)
+	})
+
+	Describe("reverseZones", func() {
+		DescribeTable("should contain exactly the addresses of the subnet",
+			func(cidr string, zones ...string) {
+				_, subnet, err := net.ParseCIDR(cidr)
+				Expect(err).Should(Succeed())
+
+				Expect(reverseZones(subnet)).Should(ConsistOf(zones))
+			},
+			Entry("IPv4 /24", "192.168.1.0/24", "1.168.192.in-addr.arpa"),
+			Entry("IPv4 /16", "192.168.0.0/16", "168.192.in-addr.arpa"),
+			Entry("IPv4 /23", "192.168.2.0/23", "2.168.192.in-addr.arpa", "3.168.192.in-addr.arpa"),
+			Entry("IPv4 /30", "192.168.1.4/30",
+				"4.1.168.192.in-addr.arpa", "5.1.168.192.in-addr.arpa",
+				"6.1.168.192.in-addr.arpa", "7.1.168.192.in-addr.arpa"),
+			Entry("IPv4 /32", "192.168.1.4/32", "4.1.168.192.in-addr.arpa"),
+			Entry("IPv4 /0", "0.0.0.0/0", "in-addr.arpa"),
+			Entry("IPv6 /48", "2001:db8:abcd::/48", "d.c.b.a.8.b.d.0.1.0.0.2.ip6.arpa"),
+			Entry("IPv6 /63", "fd00:0:0:1e::/63",
+				"e.1.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa", "f.1.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa"),
+			Entry("IPv6 /0", "::/0", "ip6.arpa"),
+		)
+
+		It("should create the zones of the prefix length", func() {
+			_, subnet, err := net.ParseCIDR("10.0.0.0/9")
+			Expect(err).Should(Succeed())
+
+			Expect(reverseZones(subnet)).Should(HaveLen(128))
+		})
+	})
+
 	When("upstream is invalid", func() {
 		It("errors during construction", func() {
 			b := newTestBootstrap(&dns.Msg{MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure}})
@@ -166,7 +507,7 @@ var _ = Describe("ConditionalUpstreamResolver", Label("conditionalResolver"), fu
 						".": {config.Upstream{Host: "example.com"}},
 					},
 				},
-			}, b, true)
+			}, nil, b, true)
 
 			Expect(err).ShouldNot(Succeed())
 			Expect(r).Should(BeNil())
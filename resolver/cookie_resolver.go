@@ -0,0 +1,70 @@
+package resolver
+
+import (
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// CookieResolver answers the DNS cookies (RFC 7873) of the clients with a server cookie.
+// A client with a valid server cookie receives the responses to its address, the request is marked for the
+// rate limiting. It must come first in the chain, since the rate limiting is the first resolver.
+type CookieResolver struct {
+	configurable[*config.CookiesConfig]
+	NextResolver
+	typed
+
+	cookies *serverCookies
+}
+
+// NewCookieResolver creates a new instance of the CookieResolver type
+func NewCookieResolver(cfg config.CookiesConfig) *CookieResolver {
+	return &CookieResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("cookie"),
+
+		cookies: newServerCookies(cfg.SecretRotation.ToDuration()),
+	}
+}
+
+// Resolve checks the cookie of the client and adds a new server cookie to the response
+func (r *CookieResolver) Resolve(request *model.Request) (*model.Response, error) {
+	if !r.cfg.Clients {
+		return r.next.Resolve(request)
+	}
+
+	// the cookie is answered by blocky: upstreams shouldn't see it and the server cookie mustn't be cached
+	cookie := util.RemoveEdns0Cookie(request.Req)
+	if cookie == nil {
+		return r.next.Resolve(request)
+	}
+
+	client, valid, err := r.cookies.check(cookie.Cookie, request.ClientIP)
+	if err != nil {
+		log.WithPrefix(request.Log, "cookie_resolver").Debugf("invalid cookie '%s': %s", cookie.Cookie, err)
+
+		return newResponse(request, dns.RcodeFormatError, model.ResponseTypeSPECIAL, "MALFORMED COOKIE"), nil
+	}
+
+	request.ValidCookie = valid
+
+	resp, err := r.next.Resolve(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// the message may be shared with the cache
+	resp.Res = resp.Res.Copy()
+
+	if resp.Res.IsEdns0() == nil {
+		reqOpt := request.Req.IsEdns0()
+
+		resp.Res.SetEdns0(reqOpt.UDPSize(), reqOpt.Do())
+	}
+
+	util.SetEdns0Cookie(resp.Res, r.cookies.create(client, request.ClientIP))
+
+	return resp, nil
+}
@@ -0,0 +1,144 @@
+package resolver
+
+import (
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("CookieResolver", func() {
+	var (
+		sut        *CookieResolver
+		sutConfig  config.CookiesConfig
+		m          *mockResolver
+		mockAnswer *dns.Msg
+
+		received *Request
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.CookiesConfig]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Clients = true
+
+		mockAnswer = new(dns.Msg)
+		received = nil
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(request *Request) (*Response, error) {
+			received = request
+
+			return &Response{Res: mockAnswer, RType: ResponseTypeRESOLVED, Reason: "Test"}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewCookieResolver(sutConfig)
+		sut.Next(m)
+	})
+
+	newCookieRequest := func(cookie string) *Request {
+		request := newRequestWithClient("example.com.", A, "192.168.178.25")
+		util.SetEdns0Cookie(request.Req, cookie)
+
+		return request
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("client cookies are disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Clients = false
+		})
+
+		It("should pass the cookie", func() {
+			_, err := sut.Resolve(newCookieRequest("0102030405060708"))
+			Expect(err).Should(Succeed())
+
+			Expect(util.Edns0Cookie(received.Req)).ShouldNot(BeNil())
+		})
+	})
+
+	It("should answer a client cookie with a server cookie", func() {
+		resp, err := sut.Resolve(newCookieRequest("0102030405060708"))
+		Expect(err).Should(Succeed())
+
+		By("not passing the cookie to the next resolver", func() {
+			Expect(util.Edns0Cookie(received.Req)).Should(BeNil())
+			Expect(received.ValidCookie).Should(BeFalse())
+		})
+
+		cookie := util.Edns0Cookie(resp.Res)
+		Expect(cookie).ShouldNot(BeNil())
+		Expect(cookie.Cookie).Should(HavePrefix("0102030405060708"))
+		Expect(cookie.Cookie).Should(HaveLen(2 * (cookieClientLen + serverCookieLen)))
+
+		By("not modifying the message of the next resolver", func() {
+			Expect(mockAnswer.IsEdns0()).Should(BeNil())
+		})
+
+		By("accepting the server cookie", func() {
+			_, err := sut.Resolve(newCookieRequest(cookie.Cookie))
+			Expect(err).Should(Succeed())
+
+			Expect(received.ValidCookie).Should(BeTrue())
+		})
+	})
+
+	It("should not mark requests with an invalid server cookie", func() {
+		_, err := sut.Resolve(newCookieRequest("0102030405060708" + "01000000000000000102030405060708"))
+		Expect(err).Should(Succeed())
+
+		Expect(received.ValidCookie).Should(BeFalse())
+	})
+
+	It("should answer malformed cookies with FORMERR", func() {
+		resp, err := sut.Resolve(newCookieRequest("01020304"))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(SatisfyAll(
+			HaveReturnCode(dns.RcodeFormatError),
+			HaveResponseType(ResponseTypeSPECIAL),
+			HaveReason("MALFORMED COOKIE"),
+		))
+		Expect(m.Calls).Should(BeEmpty())
+	})
+
+	It("should pass requests without cookie", func() {
+		resp, err := sut.Resolve(newRequestWithClient("example.com.", A, "192.168.178.25"))
+		Expect(err).Should(Succeed())
+
+		Expect(resp.Res.IsEdns0()).Should(BeNil())
+	})
+})
@@ -0,0 +1,229 @@
+package resolver
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+const (
+	// cookieClientLen is the length of a client cookie in bytes
+	cookieClientLen = 8
+	// cookieServerMinLen and cookieServerMaxLen limit the length of a server cookie in bytes
+	cookieServerMinLen = 8
+	cookieServerMaxLen = 32
+	// cookieHashLen is the length of the hash in client and server cookies
+	cookieHashLen = 8
+	// cookieSecretLen is the length of the secrets the cookies are derived from
+	cookieSecretLen = 16
+
+	// serverCookieVersion, serverCookieLen and the time limits define the server cookies of RFC 9018
+	serverCookieVersion   = 1
+	serverCookieLen       = 16
+	serverCookieHeaderLen = 8
+	serverCookieTSOffset  = 4
+	serverCookieLifetime  = time.Hour
+	serverCookieClockSkew = 5 * time.Minute
+)
+
+var (
+	errCookieMismatch  = errors.New("response doesn't echo the client cookie")
+	errMalformedCookie = errors.New("malformed cookie")
+)
+
+// cookieSecret is the secret the cookies are derived from, it is replaced after the rotation interval.
+// The previous secret is kept, so cookies derived from it are accepted until the next rotation
+type cookieSecret struct {
+	interval          time.Duration
+	current, previous []byte
+	rotated           util.MonotonicTime
+}
+
+func newCookieSecret(interval time.Duration) cookieSecret {
+	return cookieSecret{
+		interval: interval,
+		current:  newRandomCookieSecret(),
+		rotated:  util.MonotonicNow(),
+	}
+}
+
+// rotate replaces the secret, if it is older than the interval, and returns true if it did
+func (s *cookieSecret) rotate() bool {
+	if util.MonotonicSince(s.rotated) < s.interval {
+		return false
+	}
+
+	s.previous, s.current = s.current, newRandomCookieSecret()
+	s.rotated = util.MonotonicNow()
+
+	return true
+}
+
+func newRandomCookieSecret() []byte {
+	secret := make([]byte, cookieSecretLen)
+
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("can't create cookie secret: %s", err))
+	}
+
+	return secret
+}
+
+// cookieHash returns the keyed hash of the cookie fields and the IP
+func cookieHash(secret []byte, ip net.IP, fields ...[]byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+
+	for _, field := range fields {
+		mac.Write(field)
+	}
+
+	// the same IPv4 address is hashed the same in 4 and 16 byte representation
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip = ipv4
+	}
+
+	mac.Write(ip)
+
+	return mac.Sum(nil)[:cookieHashLen]
+}
+
+// upstreamCookies keeps the DNS cookies (RFC 7873) sent to the upstreams.
+// The client cookie is derived from the upstream IP and a secret, which is rotated, so upstreams can't track blocky
+// over a long time. The server cookie is the last one received from the upstream IP. Server cookies are bound to
+// the client cookie, so they're forgotten with the rotation.
+type upstreamCookies struct {
+	lock    sync.Mutex
+	secret  cookieSecret
+	servers map[string]string
+}
+
+func newUpstreamCookies(rotation time.Duration) *upstreamCookies {
+	return &upstreamCookies{
+		secret:  newCookieSecret(rotation),
+		servers: make(map[string]string),
+	}
+}
+
+// cookie returns the cookie for a query to the upstream IP in the hex presentation of `dns.EDNS0_COOKIE`
+func (c *upstreamCookies) cookie(ip net.IP) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.secret.rotate() {
+		clear(c.servers)
+	}
+
+	return c.clientCookie(ip) + c.servers[ip.String()]
+}
+
+// update checks the cookie of the upstream's response and remembers its server cookie.
+// Responses without cookie are accepted: the upstream doesn't support cookies
+func (c *upstreamCookies) update(ip net.IP, resp *dns.Msg) error {
+	cookie := util.Edns0Cookie(resp)
+	if cookie == nil {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	client := c.clientCookie(ip)
+	if !strings.HasPrefix(cookie.Cookie, client) {
+		return errCookieMismatch
+	}
+
+	server := cookie.Cookie[len(client):]
+	if len(server) >= 2*cookieServerMinLen && len(server) <= 2*cookieServerMaxLen {
+		c.servers[ip.String()] = server
+	}
+
+	return nil
+}
+
+// clientCookie returns the client cookie for the upstream IP, must be called with the lock held
+func (c *upstreamCookies) clientCookie(ip net.IP) string {
+	return hex.EncodeToString(cookieHash(c.secret.current, ip))
+}
+
+// serverCookies creates and checks the server cookies for the clients in the interoperable format of RFC 9018:
+// version, reserved bytes, timestamp and a hash of the client cookie, these fields and the client IP.
+// The hash is a HMAC-SHA256 instead of SipHash-2-4, so the cookies are only valid for this instance
+type serverCookies struct {
+	lock   sync.Mutex
+	secret cookieSecret
+}
+
+func newServerCookies(rotation time.Duration) *serverCookies {
+	return &serverCookies{secret: newCookieSecret(rotation)}
+}
+
+// check checks the cookie sent by the client: returns the client cookie and if the server cookie is valid.
+// An error is returned for malformed cookies, which are answered with FORMERR
+func (c *serverCookies) check(cookie string, ip net.IP) (client []byte, valid bool, err error) {
+	data, err := hex.DecodeString(cookie)
+	if err != nil || !isValidCookieLen(len(data)) {
+		return nil, false, errMalformedCookie
+	}
+
+	client, server := data[:cookieClientLen], data[cookieClientLen:]
+
+	if len(server) != serverCookieLen || server[0] != serverCookieVersion {
+		return client, false, nil
+	}
+
+	ts := time.Unix(int64(binary.BigEndian.Uint32(server[serverCookieTSOffset:serverCookieHeaderLen])), 0)
+	now := util.Now()
+
+	if ts.Before(now.Add(-serverCookieLifetime)) || ts.After(now.Add(serverCookieClockSkew)) {
+		return client, false, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.secret.rotate()
+
+	for _, secret := range [][]byte{c.secret.current, c.secret.previous} {
+		if secret == nil {
+			continue
+		}
+
+		if hmac.Equal(cookieHash(secret, ip, client, server[:serverCookieHeaderLen]), server[serverCookieHeaderLen:]) {
+			return client, true, nil
+		}
+	}
+
+	return client, false, nil
+}
+
+// create returns the cookie for the response to the client in the hex presentation of `dns.EDNS0_COOKIE`
+func (c *serverCookies) create(client []byte, ip net.IP) string {
+	header := make([]byte, serverCookieHeaderLen)
+	header[0] = serverCookieVersion
+	binary.BigEndian.PutUint32(header[serverCookieTSOffset:], uint32(util.Now().Unix()))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.secret.rotate()
+
+	return hex.EncodeToString(client) + hex.EncodeToString(header) +
+		hex.EncodeToString(cookieHash(c.secret.current, ip, client, header))
+}
+
+// isValidCookieLen returns true for the lengths of a client cookie with or without server cookie (RFC 7873, 5.2.2)
+func isValidCookieLen(length int) bool {
+	return length == cookieClientLen ||
+		(length >= cookieClientLen+cookieServerMinLen && length <= cookieClientLen+cookieServerMaxLen)
+}
@@ -0,0 +1,179 @@
+package resolver
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cookies", func() {
+	var clock *util.FakeClock
+
+	BeforeEach(func() {
+		clock = util.NewFakeClock()
+		DeferCleanup(util.SetClock(clock))
+	})
+
+	Describe("upstreamCookies", func() {
+		var (
+			sut *upstreamCookies
+			ip  net.IP
+		)
+
+		responseWithCookie := func(cookie string) *dns.Msg {
+			msg := new(dns.Msg)
+			util.SetEdns0Cookie(msg, cookie)
+
+			return msg
+		}
+
+		BeforeEach(func() {
+			sut = newUpstreamCookies(time.Hour)
+			ip = net.ParseIP("192.0.2.1")
+		})
+
+		It("should send the same client cookie to an upstream IP", func() {
+			cookie := sut.cookie(ip)
+
+			Expect(cookie).Should(HaveLen(2 * cookieClientLen))
+			Expect(sut.cookie(ip)).Should(Equal(cookie))
+			Expect(sut.cookie(net.ParseIP("192.0.2.1").To4())).Should(Equal(cookie))
+			Expect(sut.cookie(net.ParseIP("192.0.2.2"))).ShouldNot(Equal(cookie))
+		})
+
+		It("should echo the server cookie of the upstream IP", func() {
+			client := sut.cookie(ip)
+
+			Expect(sut.update(ip, responseWithCookie(client+"0102030405060708"))).Should(Succeed())
+
+			Expect(sut.cookie(ip)).Should(Equal(client + "0102030405060708"))
+			Expect(sut.cookie(net.ParseIP("192.0.2.2"))).Should(HaveLen(2 * cookieClientLen))
+		})
+
+		It("should ignore server cookies with invalid length", func() {
+			client := sut.cookie(ip)
+
+			Expect(sut.update(ip, responseWithCookie(client+"0102"))).Should(Succeed())
+
+			Expect(sut.cookie(ip)).Should(Equal(client))
+		})
+
+		It("should accept responses without cookie", func() {
+			Expect(sut.update(ip, new(dns.Msg))).Should(Succeed())
+		})
+
+		It("should fail if the response doesn't echo the client cookie", func() {
+			Expect(sut.update(ip, responseWithCookie("01020304050607080102030405060708"))).
+				Should(MatchError(errCookieMismatch))
+		})
+
+		It("should rotate the client cookie and forget the server cookies", func() {
+			client := sut.cookie(ip)
+			Expect(sut.update(ip, responseWithCookie(client+"0102030405060708"))).Should(Succeed())
+
+			clock.Advance(time.Hour)
+
+			cookie := sut.cookie(ip)
+			Expect(cookie).Should(HaveLen(2 * cookieClientLen))
+			Expect(cookie).ShouldNot(Equal(client))
+		})
+	})
+
+	Describe("serverCookies", func() {
+		var (
+			sut    *serverCookies
+			ip     net.IP
+			client []byte
+		)
+
+		BeforeEach(func() {
+			sut = newServerCookies(time.Hour)
+			ip = net.ParseIP("192.168.178.25")
+			client = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+		})
+
+		It("should create a cookie in the format of RFC 9018", func() {
+			cookie := sut.create(client, ip)
+
+			Expect(cookie).Should(HaveLen(2 * (cookieClientLen + serverCookieLen)))
+			Expect(cookie).Should(HavePrefix("0102030405060708" + "01000000"))
+		})
+
+		It("should accept its own cookies of the same client", func() {
+			cookie := sut.create(client, ip)
+
+			checked, valid, err := sut.check(cookie, ip)
+			Expect(err).Should(Succeed())
+			Expect(valid).Should(BeTrue())
+			Expect(checked).Should(Equal(client))
+
+			_, valid, err = sut.check(cookie, net.ParseIP("192.168.178.26"))
+			Expect(err).Should(Succeed())
+			Expect(valid).Should(BeFalse())
+		})
+
+		It("should accept client cookies without server cookie", func() {
+			checked, valid, err := sut.check("0102030405060708", ip)
+			Expect(err).Should(Succeed())
+			Expect(valid).Should(BeFalse())
+			Expect(checked).Should(Equal(client))
+		})
+
+		It("should not accept changed cookies", func() {
+			cookie := sut.create(client, ip)
+
+			changed := cookie[:len(cookie)-1] + "0"
+			if changed == cookie {
+				changed = cookie[:len(cookie)-1] + "1"
+			}
+
+			_, valid, err := sut.check(changed, ip)
+			Expect(err).Should(Succeed())
+			Expect(valid).Should(BeFalse())
+		})
+
+		It("should not accept expired cookies", func() {
+			cookie := sut.create(client, ip)
+
+			clock.JumpWallClock(serverCookieLifetime + time.Second)
+
+			_, valid, err := sut.check(cookie, ip)
+			Expect(err).Should(Succeed())
+			Expect(valid).Should(BeFalse())
+		})
+
+		It("should accept cookies of the previous secret", func() {
+			sut = newServerCookies(10 * time.Minute)
+			cookie := sut.create(client, ip)
+
+			clock.Advance(10 * time.Minute)
+
+			_, valid, err := sut.check(cookie, ip)
+			Expect(err).Should(Succeed())
+			Expect(valid).Should(BeTrue())
+
+			clock.Advance(10 * time.Minute)
+
+			_, valid, err = sut.check(cookie, ip)
+			Expect(err).Should(Succeed())
+			Expect(valid).Should(BeFalse())
+		})
+
+		DescribeTable("should fail for malformed cookies",
+			func(cookie string) {
+				_, _, err := sut.check(cookie, ip)
+				Expect(err).Should(MatchError(errMalformedCookie))
+			},
+			Entry("too short client cookie", "01020304"),
+			Entry("too short server cookie", "0102030405060708"+"0102"),
+			Entry("too long server cookie", "0102030405060708"+strings.Repeat("01", cookieServerMaxLen+1)),
+			Entry("no hex", "010203040506070x"),
+		)
+	})
+})
@@ -0,0 +1,155 @@
+package resolver
+
+import (
+	"cmp"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/trie"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+)
+
+// customDNSMapping is the resolvable form of the default mapping or of the mapping of a client group
+type customDNSMapping struct {
+	cfg     *config.CustomDNSMapping
+	entries *trie.ValueTrie[customDNSEntry]
+	records map[string][]dns.RR
+}
+
+func newCustomDNSMapping(cfg *config.CustomDNSConfig, mapping *config.CustomDNSMapping) customDNSMapping {
+	entries := trie.NewValueTrie[customDNSEntry](trie.SplitTLD)
+
+	for url, ips := range mapping.HostIPs {
+		entries.Insert(strings.ToLower(url), customDNSEntry{ips: ips, ttl: cfg.MappingTTL(mapping, url).SecondsU32()})
+	}
+
+	for url, target := range mapping.CNAMEs {
+		entries.Insert(strings.ToLower(url), customDNSEntry{
+			cname: strings.ToLower(target),
+			ttl:   cfg.MappingTTL(mapping, url).SecondsU32(),
+		})
+	}
+
+	records := make(map[string][]dns.RR, len(mapping.Records))
+
+	for url, rrs := range mapping.Records {
+		records[strings.ToLower(url)] = rrs
+	}
+
+	return customDNSMapping{cfg: mapping, entries: entries, records: records}
+}
+
+// answers returns true if the mapping defines the domain, a wildcard or a parent domain of it
+func (m *customDNSMapping) answers(domain string) bool {
+	if _, found := m.records[domain]; found {
+		return true
+	}
+
+	_, found := m.entries.Find(domain)
+
+	return found
+}
+
+// kinds of client identifiers, in the order of their precedence
+const (
+	clientKindIP = iota
+	clientKindName
+	clientKindWildcardName
+	clientKindNetwork
+)
+
+// customDNSClientGroup is the mapping for the clients matching the identifier of the group
+type customDNSClientGroup struct {
+	client  string
+	kind    int
+	ip      net.IP
+	network *net.IPNet
+	mapping customDNSMapping
+}
+
+// newCustomDNSClientGroups creates the client groups ordered by precedence, the most specific group first:
+// IPs, names, names with wildcards (more literal characters first) and networks (longer prefix first).
+// The identifiers are validated with the config.
+func newCustomDNSClientGroups(cfg *config.CustomDNSConfig) []customDNSClientGroup {
+	groups := make([]customDNSClientGroup, 0, len(cfg.ClientGroups))
+
+	for client, group := range cfg.ClientGroups {
+		group := group
+
+		g := customDNSClientGroup{
+			client:  client,
+			kind:    clientKindName,
+			mapping: newCustomDNSMapping(cfg, &group.Mapping),
+		}
+
+		if _, network, err := net.ParseCIDR(client); err == nil {
+			g.kind = clientKindNetwork
+			g.network = network
+		} else if ip := net.ParseIP(client); ip != nil {
+			g.kind = clientKindIP
+			g.ip = ip
+		} else if strings.ContainsAny(client, "*?[") {
+			g.kind = clientKindWildcardName
+		}
+
+		groups = append(groups, g)
+	}
+
+	slices.SortFunc(groups, func(a, b customDNSClientGroup) int {
+		if c := cmp.Compare(a.kind, b.kind); c != 0 {
+			return c
+		}
+
+		// more specific first
+		if c := cmp.Compare(b.specificity(), a.specificity()); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.client, b.client)
+	})
+
+	return groups
+}
+
+// specificity orders groups of the same kind: the prefix length of networks, the literal characters of names
+func (g *customDNSClientGroup) specificity() int {
+	switch g.kind {
+	case clientKindNetwork:
+		ones, _ := g.network.Mask.Size()
+
+		return ones
+	case clientKindWildcardName:
+		return len(strings.Map(func(r rune) rune {
+			if strings.ContainsRune("*?[]", r) {
+				return -1
+			}
+
+			return r
+		}, g.client))
+	default:
+		return 0
+	}
+}
+
+// matches returns true if the client IP or one of the client names of the request matches the group
+func (g *customDNSClientGroup) matches(request *model.Request) bool {
+	switch g.kind {
+	case clientKindIP:
+		return g.ip.Equal(request.ClientIP)
+	case clientKindNetwork:
+		return request.ClientIP != nil && g.network.Contains(request.ClientIP)
+	default:
+		for _, name := range request.ClientNames {
+			if util.ClientNameMatchesGroupName(g.client, name) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,398 @@
+package resolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/trie"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+)
+
+// sources of the custom DNS records listed via API
+const (
+	customDNSSourceRuntime  = "runtime"
+	customDNSSourceMapping  = "mapping"
+	customDNSSourceZoneFile = "zone file"
+	// followed by the client identifier of the group
+	customDNSSourceClientGroup = "client group"
+)
+
+var errCustomDNSRecordsNotFound = errors.New("no runtime records")
+
+// runtimeRecords contains the custom DNS records set via API.
+// They are kept apart from the zone files, so a zone file refresh doesn't remove them.
+type runtimeRecords struct {
+	lock sync.RWMutex
+	// records per name with the TTL already applied
+	records map[string]runtimeRecord
+	// TTL of records set without TTL
+	customTTL uint32
+	// file to persist the records, not persisted if empty
+	file string
+}
+
+// runtimeRecord are the records of a name, ttl is the TTL set via API or nil for the customTTL
+type runtimeRecord struct {
+	rrs []dns.RR
+	ttl *time.Duration
+}
+
+// persistedRecords is the JSON representation of the records of a name in the file
+type persistedRecords struct {
+	Name    string   `json:"name"`
+	Records []string `json:"records"`
+	TTL     string   `json:"ttl,omitempty"`
+}
+
+// newRuntimeRecords creates the records and loads them from the file, if it exists
+func newRuntimeRecords(file string, customTTL config.Duration) (*runtimeRecords, error) {
+	r := &runtimeRecords{
+		records:   make(map[string]runtimeRecord),
+		customTTL: customTTL.SecondsU32(),
+		file:      file,
+	}
+
+	if file == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can't read runtime records: %w", err)
+	}
+
+	var persisted []persistedRecords
+
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, fmt.Errorf("can't parse runtime records file '%s': %w", file, err)
+	}
+
+	for _, p := range persisted {
+		var ttl *time.Duration
+
+		if p.TTL != "" {
+			d, err := time.ParseDuration(p.TTL)
+			if err != nil {
+				return nil, fmt.Errorf("can't parse runtime records file '%s': %w", file, err)
+			}
+
+			ttl = &d
+		}
+
+		name, rrs, err := r.parse(p.Name, p.Records, ttl)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse runtime records file '%s': %w", file, err)
+		}
+
+		r.records[name] = runtimeRecord{rrs: rrs, ttl: ttl}
+	}
+
+	return r, nil
+}
+
+// parse validates the name and parses its records, which get the TTL or the customTTL
+func (r *runtimeRecords) parse(name string, records []string, ttl *time.Duration) (string, []dns.RR, error) {
+	name = util.ExtractDomainOnly(strings.TrimSpace(name))
+
+	if _, ok := dns.IsDomainName(name); !ok || name == "" {
+		return "", nil, fmt.Errorf("invalid name '%s'", name)
+	}
+
+	if strings.Contains(name, trie.WildcardLabel) {
+		return "", nil, fmt.Errorf("invalid name '%s': wildcards aren't supported, records only answer exactly the name",
+			name)
+	}
+
+	if len(records) == 0 {
+		return "", nil, fmt.Errorf("no records for %s, remove the records instead", name)
+	}
+
+	rrTTL := r.customTTL
+	if ttl != nil {
+		rrTTL = config.Duration(*ttl).SecondsU32()
+	}
+
+	rrs := make([]dns.RR, 0, len(records))
+
+	for _, record := range records {
+		rr, err := dns.NewRR(dns.Fqdn(name) + " " + strings.TrimSpace(record))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid record '%s' for %s: %w", record, name, err)
+		}
+
+		if rr == nil {
+			return "", nil, fmt.Errorf("invalid record '%s' for %s: empty record", record, name)
+		}
+
+		if rr.Header().Rrtype == dns.TypeCNAME && len(records) > 1 {
+			return "", nil, fmt.Errorf("invalid record '%s' for %s: a CNAME must be the only record", record, name)
+		}
+
+		rr.Header().Ttl = rrTTL
+
+		rrs = append(rrs, rr)
+	}
+
+	return name, rrs, nil
+}
+
+// set replaces the records of the name
+func (r *runtimeRecords) set(name string, records []string, ttl *time.Duration) (string, error) {
+	name, rrs, err := r.parse(name, records, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	previous, found := r.records[name]
+
+	r.records[name] = runtimeRecord{rrs: rrs, ttl: ttl}
+
+	if err := r.save(); err != nil {
+		if found {
+			r.records[name] = previous
+		} else {
+			delete(r.records, name)
+		}
+
+		return "", err
+	}
+
+	return name, nil
+}
+
+func (r *runtimeRecords) remove(name string) (string, error) {
+	name = util.ExtractDomainOnly(strings.TrimSpace(name))
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	previous, found := r.records[name]
+	if !found {
+		return "", fmt.Errorf("%w: %s", errCustomDNSRecordsNotFound, name)
+	}
+
+	delete(r.records, name)
+
+	if err := r.save(); err != nil {
+		r.records[name] = previous
+
+		return "", err
+	}
+
+	return name, nil
+}
+
+// get returns the records of the name, nil if none were set
+func (r *runtimeRecords) get(name string) []dns.RR {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.records[name].rrs
+}
+
+// list returns the records of all names
+func (r *runtimeRecords) list() []api.CustomDNSRecords {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	result := make([]api.CustomDNSRecords, 0, len(r.records))
+
+	for name, record := range r.records {
+		result = append(result, api.CustomDNSRecords{
+			Name:    name,
+			Source:  customDNSSourceRuntime,
+			Records: recordsData(record.rrs),
+			TTL:     ttlDuration(record.rrs[0].Header().Ttl),
+		})
+	}
+
+	return result
+}
+
+// save writes the records to the file, the caller must hold the write lock
+func (r *runtimeRecords) save() error {
+	if r.file == "" {
+		return nil
+	}
+
+	persisted := make([]persistedRecords, 0, len(r.records))
+
+	for name, record := range r.records {
+		p := persistedRecords{Name: name, Records: recordsData(record.rrs)}
+
+		if record.ttl != nil {
+			p.TTL = record.ttl.String()
+		}
+
+		persisted = append(persisted, p)
+	}
+
+	// stable file content
+	sort.Slice(persisted, func(i, j int) bool {
+		return persisted[i].Name < persisted[j].Name
+	})
+
+	data, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomically(r.file, data); err != nil {
+		return fmt.Errorf("can't write runtime records: %w", err)
+	}
+
+	return nil
+}
+
+// recordsData returns the records in zone file syntax without name, TTL and class
+func recordsData(rrs []dns.RR) []string {
+	result := make([]string, 0, len(rrs))
+
+	for _, rr := range rrs {
+		result = append(result, config.RecordData(rr))
+	}
+
+	return result
+}
+
+func ttlDuration(ttl uint32) *time.Duration {
+	d := time.Duration(ttl) * time.Second
+
+	return &d
+}
+
+// SetCustomDNSRecords implements `api.CustomDNSRecordsManager`.
+// The records take precedence over the mapping and the zone files. Like them, they are resolved before the
+// conditional mappings.
+func (r *CustomDNSResolver) SetCustomDNSRecords(name string, records []string, ttl *time.Duration) error {
+	name, err := r.runtime.set(name, records, ttl)
+	if err != nil {
+		return err
+	}
+
+	r.log().Infof("set runtime records of %s: %s", log.EscapeInput(name), log.EscapeInput(strings.Join(records, ", ")))
+
+	return nil
+}
+
+// RemoveCustomDNSRecords implements `api.CustomDNSRecordsManager`.
+func (r *CustomDNSResolver) RemoveCustomDNSRecords(name string) error {
+	name, err := r.runtime.remove(name)
+	if err != nil {
+		return err
+	}
+
+	r.log().Infof("removed runtime records of %s", log.EscapeInput(name))
+
+	return nil
+}
+
+// CustomDNSRecords implements `api.CustomDNSRecordsManager`.
+// Names of the mappings and the zone files with runtime records aren't listed, since they aren't answered.
+// The mappings of the client groups are listed in addition to the default mapping.
+func (r *CustomDNSResolver) CustomDNSRecords() []api.CustomDNSRecords {
+	result := r.runtime.list()
+
+	runtimeNames := make(map[string]bool, len(result))
+	for _, records := range result {
+		runtimeNames[records.Name] = true
+	}
+
+	listed := maps.Clone(runtimeNames)
+
+	for _, records := range r.mappingRecords(&r.mapping, customDNSSourceMapping) {
+		listed[records.Name] = true
+
+		if !runtimeNames[records.Name] {
+			result = append(result, records)
+		}
+	}
+
+	for i := range r.clientGroups {
+		group := &r.clientGroups[i]
+
+		for _, records := range r.mappingRecords(&group.mapping, customDNSSourceClientGroup+" "+group.client) {
+			if !runtimeNames[records.Name] {
+				result = append(result, records)
+			}
+		}
+	}
+
+	r.zoneLock.RLock()
+	defer r.zoneLock.RUnlock()
+
+	for name, rrs := range r.zone {
+		if !listed[name] {
+			result = append(result, api.CustomDNSRecords{
+				Name: name, Source: customDNSSourceZoneFile, Records: zoneRecordsData(rrs),
+			})
+		}
+	}
+
+	return result
+}
+
+// mappingRecords returns the records of each name of the mapping
+func (r *CustomDNSResolver) mappingRecords(mapping *customDNSMapping, source string) []api.CustomDNSRecords {
+	result := make([]api.CustomDNSRecords, 0, len(mapping.cfg.HostIPs)+len(mapping.cfg.CNAMEs)+len(mapping.records))
+
+	add := func(name string, records []string) {
+		ttl := time.Duration(r.cfg.MappingTTL(mapping.cfg, name))
+
+		result = append(result, api.CustomDNSRecords{Name: name, Source: source, Records: records, TTL: &ttl})
+	}
+
+	for name, ips := range mapping.cfg.HostIPs {
+		records := make([]string, 0, len(ips))
+
+		for _, ip := range ips {
+			if ip.To4() != nil {
+				records = append(records, "A "+ip.String())
+			} else {
+				records = append(records, "AAAA "+ip.String())
+			}
+		}
+
+		add(strings.ToLower(name), records)
+	}
+
+	for name, target := range mapping.cfg.CNAMEs {
+		add(name, []string{"CNAME " + dns.Fqdn(target)})
+	}
+
+	for name, rrs := range mapping.records {
+		add(name, recordsData(rrs))
+	}
+
+	return result
+}
+
+// zoneRecordsData returns the records in zone file syntax without name and class, but with their own TTL
+func zoneRecordsData(rrs []dns.RR) []string {
+	result := make([]string, 0, len(rrs))
+
+	for _, rr := range rrs {
+		result = append(result, fmt.Sprintf("%d %s", rr.Header().Ttl, config.RecordData(rr)))
+	}
+
+	return result
+}
@@ -1,49 +1,251 @@
 package resolver
 
 import (
+	"context"
+	"fmt"
 	"net"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/lists"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/trie"
 	"github.com/0xERR0R/blocky/util"
 
 	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
 )
 
-// CustomDNSResolver resolves passed domain name to ip address defined in domain-IP map
+// maxCNAMEChainLength limits the CNAMEs followed to resolve a query
+const maxCNAMEChainLength = 16
+
+// CustomDNSResolver resolves passed domain name to ip address defined in domain-IP map,
+// to the CNAME target defined in the domain-CNAME map or to the records defined in the domain-records map
 type CustomDNSResolver struct {
 	configurable[*config.CustomDNSConfig]
 	NextResolver
 	typed
 
-	mapping          map[string][]net.IP
+	mapping          customDNSMapping
+	clientGroups     []customDNSClientGroup
 	reverseAddresses map[string][]string
-}
 
-// NewCustomDNSResolver creates new resolver instance
-func NewCustomDNSResolver(cfg config.CustomDNSConfig) *CustomDNSResolver {
-	m := make(map[string][]net.IP, len(cfg.Mapping.HostIPs))
-	reverse := make(map[string][]string, len(cfg.Mapping.HostIPs))
+	downloader lists.FileDownloader
+	zoneLock   sync.RWMutex
+	zone       map[string][]dns.RR
 
-	for url, ips := range cfg.Mapping.HostIPs {
-		m[strings.ToLower(url)] = ips
+	// records of each zone file, so a single file can be refreshed
+	zoneFileRecords []map[string][]dns.RR
+	refreshLock     sync.Mutex
 
-		for _, ip := range ips {
-			r, _ := dns.ReverseAddr(ip.String())
-			reverse[r] = append(reverse[r], url)
-		}
+	// records set via API, they take precedence over the mapping and the zone files
+	runtime *runtimeRecords
+}
+
+// customDNSEntry is either a mapping to IP addresses or to a CNAME target, with the TTL of the mapped domain
+type customDNSEntry struct {
+	ips   []net.IP
+	cname string
+	ttl   uint32
+}
+
+// NewCustomDNSResolver creates new resolver instance, the zone files are loaded according to the loading config
+// and watched until ctx is done
+func NewCustomDNSResolver(
+	ctx context.Context, cfg config.CustomDNSConfig, bootstrap *Bootstrap,
+) (*CustomDNSResolver, error) {
+	runtime, err := newRuntimeRecords(cfg.RuntimeRecordsFile, cfg.CustomTTL)
+	if err != nil {
+		return nil, err
 	}
 
-	return &CustomDNSResolver{
+	r := &CustomDNSResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("custom_dns"),
 
-		mapping:          m,
-		reverseAddresses: reverse,
+		runtime: runtime,
+	}
+
+	r.mapping = newCustomDNSMapping(r.cfg, &r.cfg.Mapping)
+	r.clientGroups = newCustomDNSClientGroups(r.cfg)
+
+	if cfg.CreatePTR {
+		r.reverseAddresses = createReverseAddresses(cfg.Mapping)
+	}
+
+	if len(cfg.ZoneFiles) == 0 {
+		return r, nil
+	}
+
+	r.downloader = lists.NewDownloader(cfg.Loading.Downloads, bootstrap.NewHTTPTransport())
+	r.zoneFileRecords = make([]map[string][]dns.RR, len(cfg.ZoneFiles))
+
+	err = cfg.Loading.StartPeriodicRefresh(r.loadZoneFiles, func(err error) {
+		r.log().WithError(err).Errorf("could not load zone files")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Loading.WatchFiles {
+		err := lists.WatchSources(ctx, cfg.ZoneFiles, lists.WatchDebounce, func(only []int) {
+			if err := r.refreshZoneFiles(context.Background(), only); err != nil {
+				r.log().WithError(err).Errorf("could not refresh changed zone files")
+			}
+		})
+		if err != nil {
+			r.log().WithError(err).Warn("can't watch zone files, changes are picked up by the periodic refresh")
+		}
+	}
+
+	return r, nil
+}
+
+// LogConfig implements `config.Configurable`.
+func (r *CustomDNSResolver) LogConfig(logger *logrus.Entry) {
+	r.cfg.LogConfig(logger)
+
+	if len(r.cfg.ZoneFiles) != 0 {
+		r.zoneLock.RLock()
+		defer r.zoneLock.RUnlock()
+
+		logger.Infof("zone domains = %d", len(r.zone))
+	}
+
+	if r.cfg.RuntimeRecordsFile != "" {
+		r.runtime.lock.RLock()
+		defer r.runtime.lock.RUnlock()
+
+		logger.Infof("runtime names = %d", len(r.runtime.records))
+	}
+}
+
+// loadZoneFiles parses all zone files and replaces the records of the previous load.
+// Records of domains which are defined in the mapping are ignored: the mapping takes precedence.
+func (r *CustomDNSResolver) loadZoneFiles(ctx context.Context) error {
+	return r.refreshZoneFiles(ctx, nil)
+}
+
+// refreshZoneFiles parses the zone files with the indexes in `only`, or all zone files if it is nil.
+// The other files keep their records, if a file fails the records of the previous load are kept.
+func (r *CustomDNSResolver) refreshZoneFiles(ctx context.Context, only []int) error {
+	r.refreshLock.Lock()
+	defer r.refreshLock.Unlock()
+
+	r.log().Debug("loading zone files")
+
+	fileRecords := slices.Clone(r.zoneFileRecords)
+
+	for i, source := range r.cfg.ZoneFiles {
+		if only != nil && !slices.Contains(only, i) {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		opener, err := lists.NewSourceOpener(fmt.Sprintf("zone file #%d", i), source, r.downloader)
+		if err != nil {
+			return err
+		}
+
+		records := make(map[string][]dns.RR)
+
+		if err := r.parseZoneFile(opener, records); err != nil {
+			return err
+		}
+
+		fileRecords[i] = records
 	}
+
+	zone := make(map[string][]dns.RR)
+
+	for _, records := range fileRecords {
+		for domain, rrs := range records {
+			zone[domain] = append(zone[domain], rrs...)
+		}
+	}
+
+	r.zoneFileRecords = fileRecords
+
+	r.zoneLock.Lock()
+	defer r.zoneLock.Unlock()
+
+	r.zone = zone
+
+	return nil
+}
+
+func (r *CustomDNSResolver) parseZoneFile(opener lists.SourceOpener, zone map[string][]dns.RR) error {
+	reader, err := opener.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// the file name is part of the parse errors, includes aren't allowed
+	zp := dns.NewZoneParser(reader, "", opener.String())
+
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		domain := util.ExtractDomainOnly(rr.Header().Name)
+
+		if r.isMapped(domain) {
+			r.log().Debugf("ignoring zone record of %s, the domain is defined in the mapping", domain)
+
+			continue
+		}
+
+		zone[domain] = append(zone[domain], rr)
+	}
+
+	return zp.Err()
+}
+
+// isMapped returns true if the mapping defines the domain itself
+func (r *CustomDNSResolver) isMapped(domain string) bool {
+	_, entryFound := r.mapping.entries.Get(domain)
+	_, recordsFound := r.mapping.records[domain]
+
+	return entryFound || recordsFound
+}
+
+func (r *CustomDNSResolver) zoneRecords(domain string) []dns.RR {
+	r.zoneLock.RLock()
+	defer r.zoneLock.RUnlock()
+
+	return r.zone[domain]
+}
+
+// createReverseAddresses maps the reverse address of each IP to the sorted names which have the IP.
+// Wildcards have no name to point to and are skipped.
+func createReverseAddresses(mapping config.CustomDNSMapping) map[string][]string {
+	reverse := make(map[string][]string, len(mapping.HostIPs))
+
+	for url, ips := range mapping.HostIPs {
+		if strings.HasPrefix(url, trie.WildcardLabel) {
+			continue
+		}
+
+		name := strings.ToLower(url)
+
+		for _, ip := range ips {
+			addr, _ := dns.ReverseAddr(ip.String())
+
+			if !slices.Contains(reverse[addr], name) {
+				reverse[addr] = append(reverse[addr], name)
+			}
+		}
+	}
+
+	for _, names := range reverse {
+		slices.Sort(names)
+	}
+
+	return reverse
 }
 
 func isSupportedType(ip net.IP, question dns.Question) bool {
@@ -51,16 +253,25 @@ func isSupportedType(ip net.IP, question dns.Question) bool {
 		(strings.Contains(ip.String(), ":") && question.Qtype == dns.TypeAAAA)
 }
 
+// handleReverseDNS answers PTR queries for the IPs of the mapping authoritatively, with one record for each name
+// which has the IP. Explicit records of the reverse address in the mapping take precedence.
 func (r *CustomDNSResolver) handleReverseDNS(request *model.Request) *model.Response {
 	question := request.Req.Question[0]
 	if question.Qtype == dns.TypePTR {
-		urls, found := r.reverseAddresses[question.Name]
+		addr := strings.ToLower(question.Name)
+
+		if _, found := r.mapping.records[util.ExtractDomainOnly(addr)]; found {
+			return nil
+		}
+
+		urls, found := r.reverseAddresses[addr]
 		if found {
 			response := new(dns.Msg)
 			response.SetReply(request.Req)
+			response.Authoritative = true
 
 			for _, url := range urls {
-				h := util.CreateHeader(question, r.cfg.CustomTTL.SecondsU32())
+				h := util.CreateHeader(question, r.cfg.TTL(url).SecondsU32())
 				ptr := new(dns.PTR)
 				ptr.Ptr = dns.Fqdn(url)
 				ptr.Hdr = h
@@ -74,7 +285,7 @@ func (r *CustomDNSResolver) handleReverseDNS(request *model.Request) *model.Resp
 	return nil
 }
 
-func (r *CustomDNSResolver) processRequest(request *model.Request) *model.Response {
+func (r *CustomDNSResolver) processRequest(request *model.Request, depth int) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, "custom_dns_resolver")
 
 	response := new(dns.Msg)
@@ -83,58 +294,212 @@ func (r *CustomDNSResolver) processRequest(request *model.Request) *model.Respon
 	question := request.Req.Question[0]
 	domain := util.ExtractDomain(question)
 
-	for len(domain) > 0 {
-		ips, found := r.mapping[domain]
-		if found {
-			for _, ip := range ips {
-				if isSupportedType(ip, question) {
-					rr, _ := util.CreateAnswerFromQuestion(question, ip, r.cfg.CustomTTL.SecondsU32())
-					response.Answer = append(response.Answer, rr)
-				}
-			}
+	mapping := r.clientMapping(request, domain)
 
-			if len(response.Answer) > 0 {
-				logger.WithFields(logrus.Fields{
-					"answer": util.AnswerToString(response.Answer),
-					"domain": domain,
-				}).Debugf("returning custom dns entry")
+	if rrs, found := mapping.records[domain]; found {
+		ttl := r.cfg.MappingTTL(mapping.cfg, domain).SecondsU32()
 
-				return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}
-			}
+		return r.processRecords(request, rrs, &ttl, depth)
+	}
+
+	if mapping == &r.mapping {
+		if rrs := r.zoneRecords(domain); rrs != nil {
+			return r.processRecords(request, rrs, nil, depth)
+		}
+	}
+
+	// the most specific entry of the domain, a wildcard or a parent domain
+	entry, found := mapping.entries.Find(domain)
+	if !found {
+		return nil, nil //nolint:nilnil // nil response means the domain isn't mapped
+	}
+
+	if entry.cname != "" {
+		return r.processCNAME(request, entry.cname, entry.ttl, depth)
+	}
+
+	for _, ip := range entry.ips {
+		if isSupportedType(ip, question) {
+			rr, _ := util.CreateAnswerFromQuestion(question, ip, entry.ttl)
+			response.Answer = append(response.Answer, rr)
+		}
+	}
+
+	if len(response.Answer) > 0 {
+		logger.WithFields(logrus.Fields{
+			"answer": util.AnswerToString(response.Answer),
+			"domain": domain,
+		}).Debugf("returning custom dns entry")
+
+		return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}, nil
+	}
+
+	// Mapping exists for this domain, but for another type
+	if !r.cfg.FilterUnmappedTypes {
+		// go to next resolver
+		return nil, nil //nolint:nilnil // nil response means the domain isn't mapped
+	}
+
+	// return NOERROR with empty result
+	return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}, nil
+}
+
+// clientMapping returns the mapping of the most specific client group of the request which defines the domain,
+// the default mapping if there is none. Client groups take precedence over the default mapping and the zone files.
+func (r *CustomDNSResolver) clientMapping(request *model.Request, domain string) *customDNSMapping {
+	for i := range r.clientGroups {
+		group := &r.clientGroups[i]
+
+		if group.mapping.answers(domain) && group.matches(request) {
+			log.WithPrefix(request.Log, "custom_dns_resolver").Debugf("using mapping of client group '%s'", group.client)
+
+			return &group.mapping
+		}
+	}
+
+	return &r.mapping
+}
+
+// isCustomName returns true if the name of the query is defined by the runtime records, the mapping of the client
+// or the zone files, regardless of the query type
+func (r *CustomDNSResolver) isCustomName(request *model.Request) bool {
+	domain := util.ExtractDomain(request.Req.Question[0])
+
+	if r.runtime.get(domain) != nil {
+		return true
+	}
+
+	if !r.IsEnabled() {
+		return false
+	}
+
+	mapping := r.clientMapping(request, domain)
 
-			// Mapping exists for this domain, but for another type
-			if !r.cfg.FilterUnmappedTypes {
-				// go to next resolver
-				break
+	return mapping.answers(domain) || (mapping == &r.mapping && r.zoneRecords(domain) != nil)
+}
+
+// processRecords answers authoritatively with the records of the query type, or follows their CNAME record.
+// Other types are answered with NOERROR and an empty result, regardless of filterUnmappedTypes.
+// If ttl isn't nil, it replaces the TTL of the records.
+func (r *CustomDNSResolver) processRecords(
+	request *model.Request, rrs []dns.RR, ttl *uint32, depth int,
+) (*model.Response, error) {
+	logger := log.WithPrefix(request.Log, "custom_dns_resolver")
+
+	question := request.Req.Question[0]
+
+	response := new(dns.Msg)
+	response.SetReply(request.Req)
+	response.Authoritative = true
+
+	for _, rr := range rrs {
+		if rr.Header().Rrtype != question.Qtype {
+			continue
+		}
+
+		// copy, since the response may be modified by other resolvers
+		rr = dns.Copy(rr)
+		rr.Header().Name = question.Name
+
+		if ttl != nil {
+			rr.Header().Ttl = *ttl
+		}
+
+		response.Answer = append(response.Answer, rr)
+	}
+
+	if len(response.Answer) == 0 && question.Qtype != dns.TypeCNAME {
+		for _, rr := range rrs {
+			if cname, ok := rr.(*dns.CNAME); ok {
+				return r.processCNAME(request, util.ExtractDomainOnly(cname.Target), cname.Hdr.Ttl, depth)
 			}
+		}
+	}
 
-			// return NOERROR with empty result
-			return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}
+	logger.WithFields(logrus.Fields{
+		"answer": util.AnswerToString(response.Answer),
+		"domain": util.ExtractDomain(question),
+	}).Debugf("returning custom dns records")
+
+	return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}, nil
+}
+
+// processCNAME answers with the CNAME record. For other query types, the target is resolved too:
+// with the custom mapping if it contains the target, otherwise with the next resolvers.
+func (r *CustomDNSResolver) processCNAME(
+	request *model.Request, target string, ttl uint32, depth int,
+) (*model.Response, error) {
+	logger := log.WithPrefix(request.Log, "custom_dns_resolver")
+
+	question := request.Req.Question[0]
+
+	// loops in the mapping are rejected on load, but not across the mapping and zone files
+	if depth >= maxCNAMEChainLength {
+		return nil, fmt.Errorf("CNAME chain of %s is longer than %d", util.ExtractDomain(question), maxCNAMEChainLength)
+	}
+
+	response := new(dns.Msg)
+	response.SetReply(request.Req)
+
+	response.Answer = []dns.RR{&dns.CNAME{
+		Hdr: dns.RR_Header{
+			Name:   question.Name,
+			Rrtype: dns.TypeCNAME,
+			Class:  dns.ClassINET,
+			Ttl:    ttl,
+		},
+		Target: dns.Fqdn(target),
+	}}
+
+	if question.Qtype != dns.TypeCNAME {
+		targetReq := request.Req.Copy()
+		targetReq.Question[0].Name = dns.Fqdn(target)
+
+		targetRequest := *request
+		targetRequest.Req = targetReq
+
+		targetResponse, err := r.resolve(&targetRequest, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("can't resolve CNAME target %s: %w", target, err)
 		}
 
-		if i := strings.Index(domain, "."); i >= 0 {
-			domain = domain[i+1:]
-		} else {
-			break
+		// the next resolver may not answer, e.g. the branch of the rewriter
+		if targetResponse.Res != nil {
+			response.Rcode = targetResponse.Res.Rcode
+			response.Answer = append(response.Answer, targetResponse.Res.Answer...)
 		}
 	}
 
-	return nil
+	logger.WithFields(logrus.Fields{
+		"answer": util.AnswerToString(response.Answer),
+		"domain": util.ExtractDomain(question),
+	}).Debugf("returning custom dns CNAME")
+
+	return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}, nil
 }
 
 // Resolve uses internal mapping to resolve the query
 func (r *CustomDNSResolver) Resolve(request *model.Request) (*model.Response, error) {
+	return r.resolve(request, 0)
+}
+
+// resolve resolves the query, depth is the count of CNAMEs followed to get to this query
+func (r *CustomDNSResolver) resolve(request *model.Request, depth int) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, "custom_dns_resolver")
 
+	if rrs := r.runtime.get(util.ExtractDomain(request.Req.Question[0])); rrs != nil {
+		return r.processRecords(request, rrs, nil, depth)
+	}
+
 	reverseResp := r.handleReverseDNS(request)
 	if reverseResp != nil {
 		return reverseResp, nil
 	}
 
-	if len(r.mapping) > 0 {
-		resp := r.processRequest(request)
-		if resp != nil {
-			return resp, nil
+	if r.IsEnabled() {
+		resp, err := r.processRequest(request, depth)
+		if err != nil || resp != nil {
+			return resp, err
 		}
 	}
 
@@ -1,28 +1,43 @@
 package resolver
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net"
+	"os"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/mock"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("CustomDNSResolver", func() {
 	var (
 		TTL = uint32(time.Now().Second())
 
-		sut *CustomDNSResolver
-		m   *mockResolver
-		cfg config.CustomDNSConfig
+		sut    *CustomDNSResolver
+		m      *mockResolver
+		cfg    config.CustomDNSConfig
+		sutCtx context.Context
 	)
 
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+
+		sutCtx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
 	Describe("Type", func() {
 		It("follows conventions", func() {
 			expectValidResolverType(sut)
@@ -42,11 +57,16 @@ var _ = Describe("CustomDNSResolver", func() {
 			}},
 			CustomTTL:           config.Duration(time.Duration(TTL) * time.Second),
 			FilterUnmappedTypes: true,
+			CreatePTR:           true,
 		}
 	})
 
 	JustBeforeEach(func() {
-		sut = NewCustomDNSResolver(cfg)
+		var err error
+
+		sut, err = NewCustomDNSResolver(sutCtx, cfg, systemResolverBootstrap)
+		Expect(err).Should(Succeed())
+
 		m = &mockResolver{}
 		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
 		sut.Next(m)
@@ -248,6 +268,62 @@ var _ = Describe("CustomDNSResolver", func() {
 				})
 			})
 		})
+		When("Reverse DNS request is received", func() {
+			It("should answer authoritatively with the names in order", func() {
+				resp, err := sut.Resolve(newRequest("123.143.168.192.IN-ADDR.ARPA.", PTR))
+				Expect(err).Should(Succeed())
+				Expect(resp.Res.Authoritative).Should(BeTrue())
+				Expect(resp.Res.Answer).Should(HaveLen(2))
+				Expect(resp.Res.Answer[0]).Should(BeDNSRecord("123.143.168.192.IN-ADDR.ARPA.", PTR, "custom.domain."))
+				Expect(resp.Res.Answer[1]).Should(BeDNSRecord("123.143.168.192.IN-ADDR.ARPA.", PTR, "multiple.ips."))
+				Expect(resp.Res.Answer[0].Header().Ttl).Should(BeNumerically("==", TTL))
+			})
+
+			It("should delegate unknown IPs to the next resolver", func() {
+				Expect(sut.Resolve(newRequest("1.143.168.192.in-addr.arpa.", PTR))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+			})
+
+			When("a wildcard is mapped", func() {
+				BeforeEach(func() {
+					cfg.Mapping.HostIPs["*.apps.domain"] = []net.IP{net.ParseIP("192.168.143.200")}
+				})
+
+				It("should not create PTR records for it", func() {
+					Expect(sut.Resolve(newRequest("200.143.168.192.in-addr.arpa.", PTR))).
+						Should(HaveResponseType(ResponseTypeRESOLVED))
+				})
+			})
+
+			When("the mapping has explicit PTR records", func() {
+				BeforeEach(func() {
+					rr, err := dns.NewRR("123.143.168.192.in-addr.arpa. PTR printer.lan.")
+					Expect(err).Should(Succeed())
+
+					cfg.Mapping.Records = map[string][]dns.RR{"123.143.168.192.in-addr.arpa": {rr}}
+				})
+
+				It("should prefer them", func() {
+					Expect(sut.Resolve(newRequest("123.143.168.192.in-addr.arpa.", PTR))).
+						Should(BeDNSRecord("123.143.168.192.in-addr.arpa.", PTR, "printer.lan."))
+				})
+			})
+
+			When("createPTR is false", func() {
+				BeforeEach(func() {
+					cfg.CreatePTR = false
+				})
+
+				It("should delegate to the next resolver", func() {
+					Expect(sut.Resolve(newRequest("123.143.168.192.in-addr.arpa.", PTR))).
+						Should(HaveResponseType(ResponseTypeRESOLVED))
+
+					m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+				})
+			})
+		})
 		When("Domain mapping is defined", func() {
 			It("subdomain must also match", func() {
 				Expect(sut.Resolve(newRequest("ABC.CUSTOM.DOMAIN.", A))).
@@ -265,6 +341,611 @@ var _ = Describe("CustomDNSResolver", func() {
 		})
 	})
 
+	Describe("Resolving CNAME mappings", func() {
+		BeforeEach(func() {
+			cfg.Mapping.CNAMEs = map[string]string{
+				"grafana.home":  "custom.domain",
+				"alias.home":    "grafana.home",
+				"external.home": "example.com",
+			}
+		})
+
+		JustBeforeEach(func() {
+			m.AnswerFn = autoAnswer
+		})
+
+		It("should answer CNAME queries with the target", func() {
+			Expect(sut.Resolve(newRequest("grafana.home.", CNAME))).
+				Should(
+					SatisfyAll(
+						BeDNSRecord("grafana.home.", CNAME, "custom.domain."),
+						HaveTTL(BeNumerically("==", TTL)),
+						HaveResponseType(ResponseTypeCUSTOMDNS),
+						HaveReason("CUSTOM DNS"),
+						HaveReturnCode(dns.RcodeSuccess),
+					))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should resolve the target with the custom mapping", func() {
+			resp, err := sut.Resolve(newRequest("alias.home.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+				HaveReturnCode(dns.RcodeSuccess),
+			))
+			Expect(util.AnswerToString(resp.Res.Answer)).Should(Equal(
+				"CNAME (grafana.home.), CNAME (custom.domain.), A (192.168.143.123)"))
+			Expect(resp.Res.Answer[0].Header().Name).Should(Equal("alias.home."))
+			Expect(resp.Res.Answer[1].Header().Name).Should(Equal("grafana.home."))
+			Expect(resp.Res.Answer[2].Header().Name).Should(Equal("custom.domain."))
+			Expect(resp.Res.Answer).Should(HaveEach(HaveField("Header().Ttl", TTL)))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should resolve the target with the next resolver", func() {
+			resp, err := sut.Resolve(newRequest("www.external.home.", AAAA))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(HaveResponseType(ResponseTypeCUSTOMDNS))
+			Expect(util.AnswerToString(resp.Res.Answer)).Should(Equal("CNAME (example.com.), AAAA (::1)"))
+			Expect(resp.Res.Answer[0].Header().Name).Should(Equal("www.external.home."))
+
+			m.AssertCalled(GinkgoT(), "Resolve", mock.MatchedBy(func(req *Request) bool {
+				return req.Req.Question[0].Name == "example.com."
+			}))
+		})
+
+		It("should return the return code of the target", func() {
+			m.AnswerFn = func(dns.Type, string) (*dns.Msg, error) {
+				return nil, nil
+			}
+
+			resp, err := sut.Resolve(newRequest("external.home.", MX))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(HaveReturnCode(dns.RcodeBadName))
+			Expect(util.AnswerToString(resp.Res.Answer)).Should(Equal("CNAME (example.com.)"))
+		})
+
+		It("should return the error of the next resolver", func() {
+			m.AnswerFn = nil
+			m.ExpectedCalls = nil
+			m.On("Resolve", mock.Anything).Return(nil, errors.New("upstream failed"))
+
+			_, err := sut.Resolve(newRequest("external.home.", A))
+			Expect(err).Should(MatchError(ContainSubstring("upstream failed")))
+		})
+	})
+
+	Describe("Resolving wildcards", func() {
+		BeforeEach(func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+apps.home: 192.168.178.2
+"*.apps.home": 192.168.178.30
+special.apps.home: 192.168.178.31
+"*.svc.home": special.apps.home
+`), &cfg.Mapping)).Should(Succeed())
+		})
+
+		It("should answer subdomains with the wildcard", func() {
+			Expect(sut.Resolve(newRequest("a.apps.home.", A))).Should(SatisfyAll(
+				BeDNSRecord("a.apps.home.", A, "192.168.178.30"),
+				HaveTTL(BeNumerically("==", TTL)),
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+			))
+
+			Expect(sut.Resolve(newRequest("x.y.apps.home.", A))).
+				Should(BeDNSRecord("x.y.apps.home.", A, "192.168.178.30"))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should not answer the parent of the wildcard with it", func() {
+			Expect(sut.Resolve(newRequest("apps.home.", A))).Should(BeDNSRecord("apps.home.", A, "192.168.178.2"))
+		})
+
+		It("should prefer explicit entries", func() {
+			Expect(sut.Resolve(newRequest("special.apps.home.", A))).
+				Should(BeDNSRecord("special.apps.home.", A, "192.168.178.31"))
+
+			Expect(sut.Resolve(newRequest("www.special.apps.home.", A))).
+				Should(BeDNSRecord("www.special.apps.home.", A, "192.168.178.31"))
+		})
+
+		It("should answer with the CNAME target of the wildcard", func() {
+			resp, err := sut.Resolve(newRequest("grafana.svc.home.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+			Expect(resp.Res.Answer[0]).Should(BeDNSRecord("grafana.svc.home.", CNAME, "special.apps.home."))
+			Expect(resp.Res.Answer[1]).Should(BeDNSRecord("special.apps.home.", A, "192.168.178.31"))
+		})
+
+		It("should not answer the parent of a wildcard without entry", func() {
+			Expect(sut.Resolve(newRequest("svc.home.", A))).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+		})
+	})
+
+	Describe("Resolving typed records", func() {
+		BeforeEach(func() {
+			Expect(yaml.UnmarshalStrict([]byte(`
+_ldap._tcp.lab: SRV 0 0 389 dc1.lab
+lab: |
+  TXT "v=spf1 -all"
+  MX 10 mail.lab
+  MX 20 backup.lab
+1.2.0.192.in-addr.arpa: PTR printer.lab
+alias.lab: lab
+`), &cfg.Mapping)).Should(Succeed())
+		})
+
+		It("should answer authoritatively with the records of the type", func() {
+			resp, err := sut.Resolve(newRequest("_LDAP._tcp.lab.", SRV))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+				HaveReason("CUSTOM DNS"),
+				HaveReturnCode(dns.RcodeSuccess),
+				HaveTTL(BeNumerically("==", TTL)),
+			))
+			Expect(resp.Res.Authoritative).Should(BeTrue())
+			Expect(resp.Res.Answer[0].String()).Should(Equal(
+				fmt.Sprintf("_LDAP._tcp.lab.\t%d\tIN\tSRV\t0 0 389 dc1.lab.", TTL)))
+
+			Expect(sut.Resolve(newRequest("lab.", TXT))).Should(BeDNSRecord("lab.", TXT, "v=spf1 -all"))
+			Expect(sut.Resolve(newRequest("1.2.0.192.in-addr.arpa.", PTR))).
+				Should(BeDNSRecord("1.2.0.192.in-addr.arpa.", PTR, "printer.lab."))
+
+			resp, err = sut.Resolve(newRequest("lab.", MX))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should answer other types with an empty result", func() {
+			cfg.FilterUnmappedTypes = false
+
+			Expect(sut.Resolve(newRequest("lab.", A))).Should(SatisfyAll(
+				HaveNoAnswer(),
+				HaveReturnCode(dns.RcodeSuccess),
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+			))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should not answer subdomains", func() {
+			Expect(sut.Resolve(newRequest("www.lab.", TXT))).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+		})
+
+		It("should resolve CNAME targets with records", func() {
+			resp, err := sut.Resolve(newRequest("alias.lab.", TXT))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+			Expect(resp.Res.Answer[0]).Should(BeDNSRecord("alias.lab.", CNAME, "lab."))
+			Expect(resp.Res.Answer[1]).Should(BeDNSRecord("lab.", TXT, "v=spf1 -all"))
+		})
+	})
+
+	Describe("TTL of a domain", func() {
+		BeforeEach(func() {
+			cfg.Mapping = config.CustomDNSMapping{}
+			Expect(yaml.UnmarshalStrict([]byte(`
+vip.home: 192.168.178.100?ttl=30s
+"*.apps.home": 192.168.178.30?ttl=1m
+grafana.home: vip.home?ttl=5m
+lab: TXT "v=spf1 -all"?ttl=2h
+nas.home: 192.168.178.20
+`), &cfg.Mapping)).Should(Succeed())
+		})
+
+		It("should answer with the TTL of the domain", func() {
+			Expect(sut.Resolve(newRequest("vip.home.", A))).
+				Should(SatisfyAll(
+					BeDNSRecord("vip.home.", A, "192.168.178.100"),
+					HaveTTL(BeNumerically("==", 30)),
+				))
+			Expect(sut.Resolve(newRequest("sub.vip.home.", A))).Should(HaveTTL(BeNumerically("==", 30)))
+			Expect(sut.Resolve(newRequest("www.apps.home.", A))).Should(HaveTTL(BeNumerically("==", 60)))
+			Expect(sut.Resolve(newRequest("lab.", TXT))).Should(HaveTTL(BeNumerically("==", 7200)))
+			Expect(sut.Resolve(newRequest("nas.home.", A))).Should(HaveTTL(BeNumerically("==", TTL)))
+		})
+
+		It("should use the TTL of each domain of a CNAME chain", func() {
+			resp, err := sut.Resolve(newRequest("grafana.home.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+			Expect(resp.Res.Answer[0].Header().Ttl).Should(BeNumerically("==", 300))
+			Expect(resp.Res.Answer[1].Header().Ttl).Should(BeNumerically("==", 30))
+		})
+
+		It("should answer PTR queries with the TTL of the domain", func() {
+			Expect(sut.Resolve(newRequest("100.178.168.192.in-addr.arpa.", PTR))).
+				Should(SatisfyAll(
+					BeDNSRecord("100.178.168.192.in-addr.arpa.", PTR, "vip.home."),
+					HaveTTL(BeNumerically("==", 30)),
+				))
+		})
+	})
+
+	Describe("Zone files", func() {
+		var (
+			tmpDir   *TmpFolder
+			zoneFile *TmpFile
+		)
+
+		BeforeEach(func() {
+			tmpDir = NewTmpFolder("CustomDNSResolver")
+			Expect(tmpDir.Error).Should(Succeed())
+			DeferCleanup(tmpDir.Clean)
+
+			zoneFile = tmpDir.CreateStringFile("lab.zone",
+				"$ORIGIN lab.",
+				"$TTL 300",
+				"@        IN SOA ns.lab. admin.lab. 1 3600 600 86400 60",
+				"@        IN MX  10 mail",
+				"nas     60 IN A  192.168.178.10",
+				"nas        IN AAAA 2001:db8::10",
+				"files      IN CNAME nas",
+				"custom.domain. IN A 10.0.0.1",
+			)
+			Expect(zoneFile.Error).Should(Succeed())
+
+			cfg.ZoneFiles = config.NewBytesSources(zoneFile.Path)
+			cfg.Loading = config.SourceLoadingConfig{RefreshPeriod: -1}
+		})
+
+		It("should answer authoritatively with the records and TTLs of the zone", func() {
+			resp, err := sut.Resolve(newRequest("nas.lab.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(SatisfyAll(
+				BeDNSRecord("nas.lab.", A, "192.168.178.10"),
+				HaveTTL(BeNumerically("==", 60)),
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+				HaveReturnCode(dns.RcodeSuccess),
+			))
+			Expect(resp.Res.Authoritative).Should(BeTrue())
+
+			Expect(sut.Resolve(newRequest("NAS.lab.", AAAA))).Should(SatisfyAll(
+				BeDNSRecord("NAS.lab.", AAAA, "2001:db8::10"),
+				HaveTTL(BeNumerically("==", 300)),
+			))
+			Expect(sut.Resolve(newRequest("lab.", MX))).Should(BeDNSRecord("lab.", MX, "mail.lab."))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should answer other types of a zone domain with an empty result", func() {
+			Expect(sut.Resolve(newRequest("nas.lab.", TXT))).Should(SatisfyAll(
+				HaveNoAnswer(),
+				HaveReturnCode(dns.RcodeSuccess),
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+			))
+		})
+
+		It("should follow CNAME records of the zone", func() {
+			resp, err := sut.Resolve(newRequest("files.lab.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+			Expect(resp.Res.Answer[0]).Should(BeDNSRecord("files.lab.", CNAME, "nas.lab."))
+			Expect(resp.Res.Answer[0].Header().Ttl).Should(BeNumerically("==", 300))
+			Expect(resp.Res.Answer[1]).Should(BeDNSRecord("nas.lab.", A, "192.168.178.10"))
+
+			Expect(sut.Resolve(newRequest("files.lab.", CNAME))).Should(BeDNSRecord("files.lab.", CNAME, "nas.lab."))
+		})
+
+		It("should prefer the mapping over the zone", func() {
+			Expect(sut.Resolve(newRequest("custom.domain.", A))).Should(SatisfyAll(
+				BeDNSRecord("custom.domain.", A, "192.168.143.123"),
+				HaveTTL(BeNumerically("==", TTL)),
+			))
+		})
+
+		It("should load the changed zone file on refresh", func() {
+			zoneFile = tmpDir.CreateStringFile("lab.zone",
+				"$ORIGIN lab.",
+				"nas 60 IN A 192.168.178.11",
+			)
+			Expect(zoneFile.Error).Should(Succeed())
+
+			Expect(sut.loadZoneFiles(context.Background())).Should(Succeed())
+
+			Expect(sut.Resolve(newRequest("nas.lab.", A))).Should(BeDNSRecord("nas.lab.", A, "192.168.178.11"))
+			Expect(sut.Resolve(newRequest("lab.", MX))).Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		It("should report file and line of parse errors and keep the loaded records", func() {
+			zoneFile = tmpDir.CreateStringFile("lab.zone",
+				"$ORIGIN lab.",
+				"nas 60 IN A 192.168.178.11",
+				"broken 60 IN A 192.168.178.300",
+			)
+			Expect(zoneFile.Error).Should(Succeed())
+
+			Expect(sut.loadZoneFiles(context.Background())).Should(MatchError(SatisfyAll(
+				ContainSubstring(zoneFile.Path),
+				ContainSubstring("line: 3"),
+			)))
+
+			Expect(sut.Resolve(newRequest("nas.lab.", A))).Should(BeDNSRecord("nas.lab.", A, "192.168.178.10"))
+		})
+
+		When("multiple zone files are defined", func() {
+			var otherFile *TmpFile
+
+			BeforeEach(func() {
+				otherFile = tmpDir.CreateStringFile("other.zone", "printer.other. 300 IN A 192.168.179.3")
+				Expect(otherFile.Error).Should(Succeed())
+
+				cfg.ZoneFiles = config.NewBytesSources(zoneFile.Path, otherFile.Path)
+			})
+
+			It("should only refresh the given zone files", func() {
+				Expect(os.WriteFile(zoneFile.Path, []byte("nas.lab. 60 IN A 192.168.178.11"), 0o600)).Should(Succeed())
+				Expect(os.WriteFile(otherFile.Path, []byte("printer.other. 300 IN A 192.168.179.4"), 0o600)).
+					Should(Succeed())
+
+				Expect(sut.refreshZoneFiles(context.Background(), []int{1})).Should(Succeed())
+
+				Expect(sut.Resolve(newRequest("nas.lab.", A))).Should(BeDNSRecord("nas.lab.", A, "192.168.178.10"))
+				Expect(sut.Resolve(newRequest("printer.other.", A))).
+					Should(BeDNSRecord("printer.other.", A, "192.168.179.4"))
+			})
+
+			When("files are watched", func() {
+				BeforeEach(func() {
+					cfg.Loading.WatchFiles = true
+				})
+
+				It("should refresh a changed zone file", func() {
+					Expect(os.WriteFile(otherFile.Path, []byte("printer.other. 300 IN A 192.168.179.4"), 0o600)).
+						Should(Succeed())
+
+					Eventually(func() (*Response, error) {
+						return sut.Resolve(newRequest("printer.other.", A))
+					}, "3s").Should(BeDNSRecord("printer.other.", A, "192.168.179.4"))
+
+					Expect(sut.Resolve(newRequest("nas.lab.", A))).Should(BeDNSRecord("nas.lab.", A, "192.168.178.10"))
+				})
+			})
+		})
+
+		It("should log the count of zone domains", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(Equal("zone domains = 3")))
+		})
+	})
+
+	Describe("Runtime records", func() {
+		ttl := func(d time.Duration) *time.Duration { return &d }
+
+		It("should answer authoritatively with the records", func() {
+			Expect(sut.SetCustomDNSRecords("Container.Home.", []string{"A 172.17.0.2", "AAAA 2001:db8::2"}, nil)).
+				Should(Succeed())
+
+			resp, err := sut.Resolve(newRequest("container.home.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(SatisfyAll(
+				BeDNSRecord("container.home.", A, "172.17.0.2"),
+				HaveTTL(BeNumerically("==", TTL)),
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+			))
+			Expect(resp.Res.Authoritative).Should(BeTrue())
+
+			Expect(sut.Resolve(newRequest("container.home.", AAAA))).
+				Should(BeDNSRecord("container.home.", AAAA, "2001:db8::2"))
+			Expect(sut.Resolve(newRequest("container.home.", TXT))).Should(SatisfyAll(
+				HaveNoAnswer(),
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+			))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should take precedence over the mapping until they are removed", func() {
+			Expect(sut.SetCustomDNSRecords("custom.domain", []string{"A 10.0.0.1"}, ttl(time.Minute))).Should(Succeed())
+
+			Expect(sut.Resolve(newRequest("custom.domain.", A))).Should(SatisfyAll(
+				BeDNSRecord("custom.domain.", A, "10.0.0.1"),
+				HaveTTL(BeNumerically("==", 60)),
+			))
+
+			Expect(sut.RemoveCustomDNSRecords("custom.domain")).Should(Succeed())
+
+			Expect(sut.Resolve(newRequest("custom.domain.", A))).
+				Should(BeDNSRecord("custom.domain.", A, "192.168.143.123"))
+		})
+
+		It("should replace the records set before", func() {
+			Expect(sut.SetCustomDNSRecords("container.home", []string{"A 172.17.0.2"}, nil)).Should(Succeed())
+			Expect(sut.SetCustomDNSRecords("container.home", []string{"A 172.17.0.3"}, nil)).Should(Succeed())
+
+			Expect(sut.Resolve(newRequest("container.home.", A))).
+				Should(BeDNSRecord("container.home.", A, "172.17.0.3"))
+		})
+
+		It("should follow a CNAME record", func() {
+			Expect(sut.SetCustomDNSRecords("alias.home", []string{"CNAME custom.domain."}, nil)).Should(Succeed())
+
+			resp, err := sut.Resolve(newRequest("alias.home.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(HaveLen(2))
+			Expect(resp.Res.Answer[0]).Should(BeDNSRecord("alias.home.", CNAME, "custom.domain."))
+			Expect(resp.Res.Answer[1]).Should(BeDNSRecord("custom.domain.", A, "192.168.143.123"))
+		})
+
+		It("should reject invalid records", func() {
+			Expect(sut.SetCustomDNSRecords("container.home", []string{"A abc"}, nil)).
+				Should(MatchError(ContainSubstring("invalid record 'A abc' for container.home")))
+			Expect(sut.SetCustomDNSRecords("container.home", []string{"CNAME a.home.", "A 172.17.0.2"}, nil)).
+				Should(MatchError(ContainSubstring("a CNAME must be the only record")))
+			Expect(sut.SetCustomDNSRecords("container.home", nil, nil)).
+				Should(MatchError(ContainSubstring("no records for container.home")))
+			Expect(sut.SetCustomDNSRecords("*.home", []string{"A 172.17.0.2"}, nil)).
+				Should(MatchError(ContainSubstring("wildcards aren't supported")))
+			Expect(sut.SetCustomDNSRecords("", []string{"A 172.17.0.2"}, nil)).
+				Should(MatchError(ContainSubstring("invalid name")))
+
+			Expect(sut.CustomDNSRecords()).ShouldNot(ContainElement(HaveField("Name", "container.home")))
+		})
+
+		It("should fail to remove records which weren't set", func() {
+			Expect(sut.RemoveCustomDNSRecords("custom.domain")).Should(MatchError(errCustomDNSRecordsNotFound))
+		})
+
+		It("should list the effective records", func() {
+			Expect(sut.SetCustomDNSRecords("ip6.domain", []string{"A 10.0.0.1"}, ttl(time.Minute))).Should(Succeed())
+
+			Expect(sut.CustomDNSRecords()).Should(ConsistOf(
+				api.CustomDNSRecords{
+					Name: "ip6.domain", Source: "runtime", Records: []string{"A 10.0.0.1"}, TTL: ttl(time.Minute),
+				},
+				api.CustomDNSRecords{
+					Name: "custom.domain", Source: "mapping", Records: []string{"A 192.168.143.123"},
+					TTL: ttl(time.Duration(TTL) * time.Second),
+				},
+				api.CustomDNSRecords{
+					Name: "multiple.ips", Source: "mapping",
+					Records: []string{"A 192.168.143.123", "A 192.168.143.125", "AAAA 2001:db8:85a3::8a2e:370:7334"},
+					TTL:     ttl(time.Duration(TTL) * time.Second),
+				},
+			))
+		})
+
+		When("nothing else is configured", func() {
+			BeforeEach(func() {
+				cfg = config.CustomDNSConfig{CustomTTL: config.Duration(time.Hour)}
+			})
+
+			It("should answer with the records", func() {
+				Expect(sut.SetCustomDNSRecords("container.home", []string{"A 172.17.0.2"}, nil)).Should(Succeed())
+
+				Expect(sut.Resolve(newRequest("container.home.", A))).Should(SatisfyAll(
+					BeDNSRecord("container.home.", A, "172.17.0.2"),
+					HaveTTL(BeNumerically("==", 3600)),
+				))
+			})
+		})
+
+		When("the records are persisted", func() {
+			var tmpDir *TmpFolder
+
+			BeforeEach(func() {
+				tmpDir = NewTmpFolder("CustomDNSResolver")
+				Expect(tmpDir.Error).Should(Succeed())
+				DeferCleanup(tmpDir.Clean)
+
+				cfg.RuntimeRecordsFile = tmpDir.JoinPath("records.json")
+			})
+
+			It("should load the records on start", func() {
+				Expect(sut.SetCustomDNSRecords("container.home", []string{"A 172.17.0.2"}, ttl(time.Minute))).
+					Should(Succeed())
+				Expect(sut.SetCustomDNSRecords("other.home", []string{"A 172.17.0.3"}, nil)).Should(Succeed())
+				Expect(sut.RemoveCustomDNSRecords("other.home")).Should(Succeed())
+
+				restarted, err := NewCustomDNSResolver(sutCtx, cfg, systemResolverBootstrap)
+				Expect(err).Should(Succeed())
+
+				Expect(restarted.Resolve(newRequest("container.home.", A))).Should(SatisfyAll(
+					BeDNSRecord("container.home.", A, "172.17.0.2"),
+					HaveTTL(BeNumerically("==", 60)),
+				))
+				Expect(restarted.CustomDNSRecords()).ShouldNot(ContainElement(HaveField("Name", "other.home")))
+			})
+
+			It("should fail to start with an invalid file", func() {
+				Expect(os.WriteFile(cfg.RuntimeRecordsFile, []byte(`[{"name": "a.home", "records": ["A abc"]}]`), 0o600)).
+					Should(Succeed())
+
+				_, err := NewCustomDNSResolver(sutCtx, cfg, systemResolverBootstrap)
+				Expect(err).Should(MatchError(ContainSubstring("can't parse runtime records file")))
+			})
+
+			It("should keep the records if the file can't be written", func() {
+				cfg.RuntimeRecordsFile = tmpDir.JoinPath("missing/records.json")
+
+				resolver, err := NewCustomDNSResolver(sutCtx, cfg, systemResolverBootstrap)
+				Expect(err).Should(Succeed())
+
+				Expect(resolver.SetCustomDNSRecords("container.home", []string{"A 172.17.0.2"}, nil)).
+					Should(MatchError(ContainSubstring("can't write runtime records")))
+				Expect(resolver.CustomDNSRecords()).ShouldNot(ContainElement(HaveField("Name", "container.home")))
+			})
+		})
+	})
+
+	Describe("Client groups", func() {
+		mapping := func(domain, ip string) config.CustomDNSClientGroup {
+			return config.CustomDNSClientGroup{Mapping: config.CustomDNSMapping{
+				HostIPs: map[string][]net.IP{domain: {net.ParseIP(ip)}},
+			}}
+		}
+
+		BeforeEach(func() {
+			cfg.Mapping.HostIPs["nas.home"] = []net.IP{net.ParseIP("192.168.1.10")}
+			cfg.ClientGroups = map[string]config.CustomDNSClientGroup{
+				"10.6.0.0/16":  mapping("nas.home", "10.6.0.10"),
+				"10.6.1.0/24":  mapping("nas.home", "10.6.1.10"),
+				"10.6.1.5":     mapping("nas.home", "10.6.1.50"),
+				"laptop-*":     mapping("nas.home", "10.7.0.10"),
+				"laptop-work":  mapping("nas.home", "10.7.0.20"),
+				"192.168.1.99": mapping("printer.home", "10.8.0.10"),
+			}
+		})
+
+		DescribeTable("should answer with the mapping of the most specific group",
+			func(ip string, clientNames []string, expected string) {
+				Expect(sut.Resolve(newRequestWithClient("nas.home.", A, ip, clientNames...))).Should(SatisfyAll(
+					BeDNSRecord("nas.home.", A, expected),
+					HaveTTL(BeNumerically("==", TTL)),
+					HaveResponseType(ResponseTypeCUSTOMDNS),
+				))
+			},
+			Entry("network", "10.6.2.3", nil, "10.6.0.10"),
+			Entry("longer prefix", "10.6.1.3", nil, "10.6.1.10"),
+			Entry("IP", "10.6.1.5", nil, "10.6.1.50"),
+			Entry("name before network", "10.6.1.3", []string{"laptop-home"}, "10.7.0.10"),
+			Entry("name before wildcard", "10.6.1.3", []string{"laptop-work"}, "10.7.0.20"),
+			Entry("no group", "192.168.1.2", []string{"desktop"}, "192.168.1.10"),
+		)
+
+		It("should answer with the default mapping if the group doesn't define the domain", func() {
+			Expect(sut.Resolve(newRequestWithClient("custom.domain.", A, "10.6.1.5"))).
+				Should(BeDNSRecord("custom.domain.", A, "192.168.143.123"))
+			Expect(sut.Resolve(newRequestWithClient("nas.home.", A, "192.168.1.99"))).
+				Should(BeDNSRecord("nas.home.", A, "192.168.1.10"))
+		})
+
+		It("should answer a domain only defined in a group for other clients with the next resolver", func() {
+			Expect(sut.Resolve(newRequestWithClient("printer.home.", A, "192.168.1.99"))).
+				Should(BeDNSRecord("printer.home.", A, "10.8.0.10"))
+
+			Expect(sut.Resolve(newRequestWithClient("printer.home.", A, "192.168.1.2"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+			m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+		})
+
+		It("should list the mappings of the groups", func() {
+			Expect(sut.CustomDNSRecords()).Should(ContainElements(
+				HaveField("Source", "mapping"),
+				SatisfyAll(
+					HaveField("Name", "nas.home"),
+					HaveField("Source", "client group 10.6.1.0/24"),
+					HaveField("Records", []string{"A 10.6.1.10"}),
+				),
+			))
+		})
+	})
+
 	Describe("Delegating to next resolver", func() {
 		When("no mapping for domain exist", func() {
 			It("should delegate to next resolver", func() {
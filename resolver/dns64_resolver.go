@@ -0,0 +1,192 @@
+package resolver
+
+import (
+	"math"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// dns64UOctet is the index of the byte of IPv4-embedded IPv6 addresses, which must be zero (RFC 6052, 2.2)
+const dns64UOctet = 8
+
+// DNS64Resolver synthesizes AAAA records for clients behind NAT64 (RFC 6147):
+// if a domain has no AAAA records, its A records are queried and the IPv4 addresses embedded into the prefix.
+// NXDOMAIN is returned as is, all other rcodes are treated like a response without AAAA records.
+type DNS64Resolver struct {
+	configurable[*config.DNS64Config]
+	NextResolver
+	typed
+
+	prefix   *net.IPNet
+	excluded domainMatcher
+}
+
+// NewDNS64Resolver creates a new instance of the DNS64Resolver type
+func NewDNS64Resolver(cfg config.DNS64Config) (*DNS64Resolver, error) {
+	r := &DNS64Resolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("dns64"),
+
+		excluded: newDomainMatcher(cfg.ExcludedDomains),
+	}
+
+	if cfg.IsEnabled() {
+		prefix, err := cfg.PrefixNetwork()
+		if err != nil {
+			return nil, err
+		}
+
+		r.prefix = prefix
+	}
+
+	return r, nil
+}
+
+func (r *DNS64Resolver) Resolve(request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() || !r.mustSynthesize(request) {
+		return r.next.Resolve(request)
+	}
+
+	response, err := r.next.Resolve(request)
+	if err != nil || response.Res == nil || !r.lacksAAAA(response.Res) {
+		return response, err
+	}
+
+	aRequest := *request
+	aRequest.Req = request.Req.Copy()
+	aRequest.Req.Question[0].Qtype = dns.TypeA
+
+	logger := r.log().WithField("domain", util.ExtractDomain(request.Req.Question[0]))
+
+	// without A records there is nothing to synthesize, the client gets the original answer
+	aResponse, err := r.next.Resolve(&aRequest)
+	if err != nil {
+		logger.Debugf("can't query A records: %s", err)
+
+		return response, nil
+	}
+
+	if aResponse.Res == nil || aResponse.Res.Rcode != dns.RcodeSuccess {
+		return response, nil
+	}
+
+	answer := r.synthesize(aResponse.Res.Answer, negativeTTL(response.Res))
+	if len(answer) == 0 {
+		return response, nil
+	}
+
+	// the message may be shared with the cache
+	res := response.Res.Copy()
+	res.Rcode = dns.RcodeSuccess
+	res.AuthenticatedData = false
+	res.Answer = answer
+	res.Ns = nil
+
+	logger.Debugf("synthesized %d AAAA records", len(answer))
+
+	return &model.Response{Res: res, RType: aResponse.RType, Reason: "DNS64"}, nil
+}
+
+// mustSynthesize returns true for AAAA queries of domains, which aren't excluded.
+// Validating clients (DO and CD bit) get the original answer: synthesized records fail the DNSSEC validation
+func (r *DNS64Resolver) mustSynthesize(request *model.Request) bool {
+	question := request.Req.Question[0]
+
+	if question.Qtype != dns.TypeAAAA || question.Qclass != dns.ClassINET {
+		return false
+	}
+
+	if opt := request.Req.IsEdns0(); opt != nil && opt.Do() && request.Req.CheckingDisabled {
+		return false
+	}
+
+	return !r.excluded.matches(util.ExtractDomain(question))
+}
+
+// lacksAAAA returns true if the response has no usable AAAA records (RFC 6147, 5.1.1 - 5.1.4):
+// IPv4-mapped addresses (::ffff:0:0/96) don't count, NXDOMAIN means the domain doesn't exist at all
+func (r *DNS64Resolver) lacksAAAA(res *dns.Msg) bool {
+	if res.Rcode == dns.RcodeNameError {
+		return false
+	}
+
+	if res.Rcode != dns.RcodeSuccess {
+		return true
+	}
+
+	for _, rr := range res.Answer {
+		if aaaa, ok := rr.(*dns.AAAA); ok && aaaa.AAAA.To4() == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// synthesize returns the AAAA records for the A records of the answer, CNAME and DNAME records are kept.
+// The TTL is the one of the A record, limited to the negative TTL of the AAAA response (RFC 6147, 5.1.7)
+func (r *DNS64Resolver) synthesize(answer []dns.RR, maxTTL uint32) []dns.RR {
+	result := make([]dns.RR, 0, len(answer))
+	synthesized := false
+
+	for _, rr := range answer {
+		switch v := rr.(type) {
+		case *dns.A:
+			result = append(result, &dns.AAAA{
+				Hdr: dns.RR_Header{
+					Name:   v.Hdr.Name,
+					Rrtype: dns.TypeAAAA,
+					Class:  dns.ClassINET,
+					Ttl:    min(v.Hdr.Ttl, maxTTL),
+				},
+				AAAA: r.embed(v.A),
+			})
+
+			synthesized = true
+		case *dns.CNAME, *dns.DNAME:
+			result = append(result, dns.Copy(rr))
+		}
+	}
+
+	if !synthesized {
+		return nil
+	}
+
+	return result
+}
+
+// embed returns the IPv4 address embedded into the prefix (RFC 6052, 2.2): the address follows the prefix,
+// bits 64 to 71 are skipped and the suffix is zero
+func (r *DNS64Resolver) embed(ipv4 net.IP) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, r.prefix.IP.To16())
+
+	ones, _ := r.prefix.Mask.Size()
+	pos := ones / 8 //nolint:gomnd
+
+	for _, b := range ipv4.To4() {
+		if pos == dns64UOctet {
+			pos++
+		}
+
+		ip[pos] = b
+		pos++
+	}
+
+	return ip
+}
+
+// negativeTTL returns the TTL of negative answers of the response's SOA record or the maximum if there is none
+func negativeTTL(res *dns.Msg) uint32 {
+	for _, rr := range res.Ns {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return min(soa.Hdr.Ttl, soa.Minttl)
+		}
+	}
+
+	return math.MaxUint32
+}
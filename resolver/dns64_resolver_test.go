@@ -0,0 +1,307 @@
+package resolver
+
+import (
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("DNS64Resolver", func() {
+	var (
+		sut       *DNS64Resolver
+		sutConfig config.DNS64Config
+		m         *mockResolver
+
+		aaaaAnswer, aAnswer *dns.Msg
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.DNS64Config]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Enable = true
+
+		aaaaAnswer = new(dns.Msg)
+		aAnswer, err = util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.33")
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(request *Request) (*Response, error) {
+			if request.Req.Question[0].Qtype == dns.TypeA {
+				return &Response{Res: aAnswer, RType: ResponseTypeCACHED, Reason: "CACHED"}, nil
+			}
+
+			return &Response{Res: aaaaAnswer, RType: ResponseTypeRESOLVED, Reason: "RESOLVED"}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewDNS64Resolver(sutConfig)
+		Expect(err).Should(Succeed())
+
+		sut.Next(m)
+	})
+
+	// qTypes returns the query types the next resolver was called with
+	qTypes := func() []dns.Type {
+		GinkgoHelper()
+
+		result := make([]dns.Type, 0, len(m.Calls))
+
+		for _, call := range m.Calls {
+			request, ok := call.Arguments.Get(0).(*Request)
+			Expect(ok).Should(BeTrue())
+
+			result = append(result, dns.Type(request.Req.Question[0].Qtype))
+		}
+
+		return result
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Enable = false
+		})
+
+		It("should pass the AAAA query", func() {
+			resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveNoAnswer())
+			Expect(qTypes()).Should(Equal([]dns.Type{AAAA}))
+		})
+	})
+
+	It("should synthesize AAAA records for domains without AAAA records", func() {
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(SatisfyAll(
+			BeDNSRecord("example.com.", AAAA, "64:ff9b::c000:221"),
+			HaveTTL(BeNumerically("==", 300)),
+			HaveReturnCode(dns.RcodeSuccess),
+			HaveResponseType(ResponseTypeCACHED),
+			HaveReason("DNS64"),
+		))
+		Expect(qTypes()).Should(Equal([]dns.Type{AAAA, A}))
+
+		By("not modifying the message of the next resolver", func() {
+			Expect(aaaaAnswer.Answer).Should(BeEmpty())
+		})
+	})
+
+	It("should limit the TTL to the negative TTL of the AAAA response", func() {
+		aaaaAnswer.Ns = []dns.RR{&dns.SOA{
+			Hdr:    dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600},
+			Minttl: 60,
+		}}
+
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(HaveTTL(BeNumerically("==", 60)))
+		Expect(resp.Res.Ns).Should(BeEmpty())
+	})
+
+	It("should keep the CNAME records of the A answer", func() {
+		aAnswer = new(dns.Msg)
+		aAnswer.Answer = []dns.RR{
+			&dns.CNAME{
+				Hdr:    dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+				Target: "cdn.example.net.",
+			},
+			&dns.A{
+				Hdr: dns.RR_Header{Name: "cdn.example.net.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+				A:   net.ParseIP("198.51.100.7"),
+			},
+		}
+
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp.Res.Answer).Should(HaveLen(2))
+		Expect(resp.Res.Answer[0]).Should(BeDNSRecord("example.com.", CNAME, "cdn.example.net."))
+		Expect(resp.Res.Answer[1]).Should(BeDNSRecord("cdn.example.net.", AAAA, "64:ff9b::c633:6407"))
+	})
+
+	It("should return existing AAAA records", func() {
+		aaaaAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, AAAA, "2001:db8::1")
+
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(BeDNSRecord("example.com.", AAAA, "2001:db8::1"))
+		Expect(qTypes()).Should(Equal([]dns.Type{AAAA}))
+	})
+
+	It("should ignore IPv4-mapped AAAA records", func() {
+		aaaaAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, AAAA, "::ffff:192.0.2.1")
+
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(BeDNSRecord("example.com.", AAAA, "64:ff9b::c000:221"))
+	})
+
+	It("should return NXDOMAIN as is", func() {
+		aaaaAnswer.Rcode = dns.RcodeNameError
+
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(SatisfyAll(
+			HaveNoAnswer(),
+			HaveReturnCode(dns.RcodeNameError),
+		))
+		Expect(qTypes()).Should(Equal([]dns.Type{AAAA}))
+	})
+
+	It("should synthesize AAAA records if the AAAA query failed", func() {
+		aaaaAnswer.Rcode = dns.RcodeServerFailure
+
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(SatisfyAll(
+			BeDNSRecord("example.com.", AAAA, "64:ff9b::c000:221"),
+			HaveReturnCode(dns.RcodeSuccess),
+		))
+	})
+
+	It("should return the AAAA answer if there are no A records", func() {
+		aAnswer = new(dns.Msg)
+		aAnswer.Rcode = dns.RcodeServerFailure
+
+		resp, err := sut.Resolve(newRequest("example.com.", AAAA))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(SatisfyAll(
+			HaveNoAnswer(),
+			HaveReturnCode(dns.RcodeSuccess),
+			HaveReason("RESOLVED"),
+		))
+		Expect(qTypes()).Should(Equal([]dns.Type{AAAA, A}))
+	})
+
+	It("should pass other queries", func() {
+		resp, err := sut.Resolve(newRequest("example.com.", A))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(BeDNSRecord("example.com.", A, "192.0.2.33"))
+		Expect(qTypes()).Should(Equal([]dns.Type{A}))
+	})
+
+	It("should not synthesize for validating clients", func() {
+		request := newRequest("example.com.", AAAA)
+		request.Req.SetEdns0(dns.DefaultMsgSize, true)
+		request.Req.CheckingDisabled = true
+
+		resp, err := sut.Resolve(request)
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(HaveNoAnswer())
+		Expect(qTypes()).Should(Equal([]dns.Type{AAAA}))
+	})
+
+	When("domains are excluded", func() {
+		BeforeEach(func() {
+			sutConfig.ExcludedDomains = []string{"*.ipv4only.example"}
+		})
+
+		It("should not synthesize AAAA records for them", func() {
+			resp, err := sut.Resolve(newRequest("www.ipv4only.example.", AAAA))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveNoAnswer())
+			Expect(qTypes()).Should(Equal([]dns.Type{AAAA}))
+		})
+	})
+
+	DescribeTable("should embed the IPv4 address according to the prefix length (RFC 6052, 2.4)",
+		func(prefix, expected string) {
+			sutConfig.Prefix = prefix
+
+			sut, err := NewDNS64Resolver(sutConfig)
+			Expect(err).Should(Succeed())
+
+			Expect(sut.embed(net.ParseIP("192.0.2.33")).String()).Should(Equal(expected))
+		},
+		Entry("/32", "2001:db8::/32", "2001:db8:c000:221::"),
+		Entry("/40", "2001:db8:100::/40", "2001:db8:1c0:2:21::"),
+		Entry("/48", "2001:db8:122::/48", "2001:db8:122:c000:2:2100::"),
+		Entry("/56", "2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"),
+		Entry("/64", "2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"),
+		Entry("/96", "2001:db8:122:344::/96", "2001:db8:122:344::c000:221"),
+	)
+
+	When("the upstream has only A records", func() {
+		var mockUpstream *dnstest.MockUpstreamServer
+
+		JustBeforeEach(func() {
+			mockUpstream = dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+				response := new(dns.Msg)
+
+				if request.Question[0].Qtype == dns.TypeA {
+					rr, err := dns.NewRR(request.Question[0].Name + " 600 IN A 203.0.113.10")
+					Expect(err).Should(Succeed())
+
+					response.Answer = []dns.RR{rr}
+				}
+
+				return response
+			})
+			DeferCleanup(mockUpstream.Close)
+
+			sut.Next(newUpstreamResolverUnchecked(mockUpstream.Start(), nil))
+		})
+
+		It("should answer AAAA queries with synthesized records", func() {
+			resp, err := sut.Resolve(newRequest("ipv4only.example.", AAAA))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(SatisfyAll(
+				BeDNSRecord("ipv4only.example.", AAAA, "64:ff9b::cb00:710a"),
+				HaveTTL(BeNumerically("==", 600)),
+				HaveResponseType(ResponseTypeRESOLVED),
+			))
+			Expect(mockUpstream.GetCallCount()).Should(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,908 @@
+package resolver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/cache/expirationcache"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+const (
+	// nsec3OptOut is the flag of NSEC3 records, which may skip unsigned delegations (RFC 5155, 3.1.2.1)
+	nsec3OptOut = 1
+	// dnssecMaxNSEC3Iterations limits the hash iterations, zones with more are treated as insecure (RFC 9276)
+	dnssecMaxNSEC3Iterations = 150
+
+	// dnssecMaxCacheTime limits how long the keys and the insecure proof of a zone are cached
+	dnssecMaxCacheTime = time.Hour
+	// dnssecBogusCacheTime is how long a bogus zone isn't queried again (RFC 4035, 4.7)
+	dnssecBogusCacheTime = time.Minute
+)
+
+// dnssecError is the reason why an answer is bogus, the code is the extended DNS error (RFC 8914)
+type dnssecError struct {
+	code uint16
+	msg  string
+}
+
+func (e *dnssecError) Error() string {
+	return e.msg
+}
+
+func newDNSSECError(code uint16, format string, args ...any) error {
+	return &dnssecError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// dnssecExtendedError returns the extended DNS error code of the validation error
+func dnssecExtendedError(err error) uint16 {
+	var dnssecErr *dnssecError
+	if errors.As(err, &dnssecErr) {
+		return dnssecErr.code
+	}
+
+	return dns.ExtendedErrorCodeDNSBogus
+}
+
+// dnssecQueryFn queries the records of the name with the DO bit set
+type dnssecQueryFn func(name string, qType uint16) (*dns.Msg, error)
+
+// dnssecZone is a zone with its validated keys. Zones without keys are insecure: they or a parent aren't signed,
+// so everything below them is insecure
+type dnssecZone struct {
+	name string
+	keys []*dns.DNSKEY
+	// bogus is the error of a zone, which failed the validation
+	bogus error
+}
+
+func (z *dnssecZone) isSecure() bool {
+	return len(z.keys) != 0
+}
+
+// dnssecValidator validates DNS answers (RFC 4033 - 4035, RFC 5155): the chain of trust of the zones is built top-down
+// from the trust anchors with DS and DNSKEY queries. The keys and proofs of the zones are cached.
+type dnssecValidator struct {
+	anchors map[string][]*dns.DS
+	zones   *expirationcache.ExpiringLRUCache[dnssecZone]
+}
+
+func newDNSSECValidator(anchors []*dns.DS) *dnssecValidator {
+	v := &dnssecValidator{
+		anchors: make(map[string][]*dns.DS, len(anchors)),
+		zones:   expirationcache.NewCache(expirationcache.WithCleanUpInterval[dnssecZone](dnssecMaxCacheTime)),
+	}
+
+	for _, anchor := range anchors {
+		name := dns.CanonicalName(anchor.Hdr.Name)
+		v.anchors[name] = append(v.anchors[name], anchor)
+	}
+
+	return v
+}
+
+// validate validates the answer to the question: returns true if it is secure, false if it is insecure
+// and an error if it is bogus
+func (v *dnssecValidator) validate(question dns.Question, resp *dns.Msg, query dnssecQueryFn) (bool, error) {
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return false, nil
+	}
+
+	secure := true
+
+	sets, sigs := splitRRsets(resp.Answer)
+
+	for _, set := range sets {
+		owner := set[0].Header().Name
+
+		if set[0].Header().Rrtype == dns.TypeCNAME && isSynthesizedCNAME(owner, sets) {
+			// CNAMEs synthesized from a DNAME aren't signed, the DNAME is
+			continue
+		}
+
+		setSecure, wildcard, err := v.validateRRset(set, sigs[rrsetKey(owner, set[0].Header().Rrtype)], query)
+		if err != nil {
+			return false, err
+		}
+
+		if wildcard != nil && setSecure {
+			// the name of an answer from a wildcard must not exist
+			setSecure, err = v.validateWildcard(owner, wildcard, resp.Ns, query)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		secure = secure && setSecure
+	}
+
+	target, answered := answerTarget(question, resp.Answer)
+	if answered && resp.Rcode == dns.RcodeSuccess {
+		return secure, nil
+	}
+
+	denialSecure, err := v.validateDenial(target, question.Qtype, resp.Rcode == dns.RcodeNameError, resp.Ns, query)
+	if err != nil {
+		return false, err
+	}
+
+	return secure && denialSecure, nil
+}
+
+// validateRRset checks the signatures of the RRset: returns true if it is secure, false if it is insecure and
+// an error if it is bogus. The verified signature is returned, if the RRset was expanded from a wildcard
+func (v *dnssecValidator) validateRRset(
+	set []dns.RR, sigs []*dns.RRSIG, query dnssecQueryFn,
+) (secure bool, wildcard *dns.RRSIG, err error) {
+	owner := set[0].Header().Name
+	rrType := dns.Type(set[0].Header().Rrtype)
+
+	if len(sigs) == 0 {
+		zone, err := v.zone(owner, query)
+		if err != nil {
+			return false, nil, err
+		}
+
+		if zone.isSecure() {
+			return false, nil, newDNSSECError(dns.ExtendedErrorCodeRRSIGsMissing, "no signature of %s %s", owner, rrType)
+		}
+
+		return false, nil, nil
+	}
+
+	signer := sigs[0].SignerName
+	if !dns.IsSubDomain(signer, owner) {
+		return false, nil, newDNSSECError(dns.ExtendedErrorCodeDNSBogus, "%s %s is signed by %s", owner, rrType, signer)
+	}
+
+	zone, err := v.zone(signer, query)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if !zone.isSecure() {
+		return false, nil, nil
+	}
+
+	if zone.name != dns.CanonicalName(signer) {
+		return false, nil, newDNSSECError(dns.ExtendedErrorCodeDNSBogus, "signer %s of %s is no zone", signer, owner)
+	}
+
+	sig, err := verifyRRset(zone, set, sigs)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if int(sig.Labels) < countLabels(owner) {
+		return true, sig, nil
+	}
+
+	return true, nil, nil
+}
+
+// validateWildcard checks the proof, that the name of an answer expanded from a wildcard doesn't exist
+// (RFC 4035, 5.3.4 and RFC 5155, 8.8)
+func (v *dnssecValidator) validateWildcard(
+	owner string, sig *dns.RRSIG, auth []dns.RR, query dnssecQueryFn,
+) (bool, error) {
+	if !slices.ContainsFunc(auth, func(rr dns.RR) bool {
+		return rr.Header().Rrtype == dns.TypeNSEC || rr.Header().Rrtype == dns.TypeNSEC3
+	}) {
+		// the signature proves the zone is secure, so the proof must exist
+		return false, newDNSSECError(dns.ExtendedErrorCodeNSECMissing, "no proof of the wildcard expansion of %s", owner)
+	}
+
+	denial, secure, err := v.denial(owner, auth, query)
+	if err != nil || !secure {
+		return false, err
+	}
+
+	if !denial.provesWildcard(owner, int(sig.Labels)) {
+		return false, newDNSSECError(dns.ExtendedErrorCodeNSECMissing, "no proof of the wildcard expansion of %s", owner)
+	}
+
+	return true, nil
+}
+
+// validateDenial checks the proof of NXDOMAIN or NODATA of the name (RFC 4035, 5.4 and RFC 5155, 8)
+func (v *dnssecValidator) validateDenial(
+	name string, qType uint16, nxdomain bool, auth []dns.RR, query dnssecQueryFn,
+) (bool, error) {
+	denial, secure, err := v.denial(name, auth, query)
+	if err != nil || !secure {
+		return false, err
+	}
+
+	var proven, optOut bool
+
+	if nxdomain {
+		proven, optOut = denial.provesNXDomain(name)
+	} else {
+		proven, optOut = denial.provesNoData(name, qType)
+	}
+
+	if optOut {
+		// an unsigned delegation may exist
+		return false, nil
+	}
+
+	if !proven {
+		return false, newDNSSECError(dns.ExtendedErrorCodeNSECMissing, "no proof of the denial of %s %s",
+			name, dns.Type(qType))
+	}
+
+	return true, nil
+}
+
+// denial returns the validated NSEC and NSEC3 records of the authority section, which proof the missing records
+// of the name. It returns false, if the zone is insecure
+func (v *dnssecValidator) denial(name string, auth []dns.RR, query dnssecQueryFn) (*dnssecDenial, bool, error) {
+	sets, sigs := splitRRsets(auth)
+	denial := &dnssecDenial{}
+	signed := false
+
+	for _, set := range sets {
+		owner, rrType := set[0].Header().Name, set[0].Header().Rrtype
+		if rrType != dns.TypeSOA && rrType != dns.TypeNSEC && rrType != dns.TypeNSEC3 {
+			continue
+		}
+
+		setSigs := sigs[rrsetKey(owner, rrType)]
+		if len(setSigs) != 0 && !dns.IsSubDomain(setSigs[0].SignerName, name) {
+			return nil, false, newDNSSECError(dns.ExtendedErrorCodeDNSBogus, "proof of %s is signed by %s",
+				name, setSigs[0].SignerName)
+		}
+
+		secure, _, err := v.validateRRset(set, setSigs, query)
+		if err != nil || !secure {
+			return nil, false, err
+		}
+
+		signed = true
+
+		for _, rr := range set {
+			switch record := rr.(type) {
+			case *dns.NSEC:
+				denial.nsec = append(denial.nsec, record)
+			case *dns.NSEC3:
+				if record.Hash != dns.SHA1 || record.Iterations > dnssecMaxNSEC3Iterations {
+					return nil, false, nil
+				}
+
+				denial.nsec3 = append(denial.nsec3, record)
+			}
+		}
+	}
+
+	if !signed {
+		// without signed records, the name must be in an insecure zone
+		zone, err := v.zone(name, query)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if zone.isSecure() {
+			return nil, false, newDNSSECError(dns.ExtendedErrorCodeRRSIGsMissing, "no signed proof of the denial of %s",
+				name)
+		}
+
+		return nil, false, nil
+	}
+
+	return denial, true, nil
+}
+
+// zone returns the zone of the name: a secure zone with its keys or the insecure zone it is in.
+// An error is returned, if the chain of trust is bogus or the zone can't be queried
+func (v *dnssecValidator) zone(name string, query dnssecQueryFn) (*dnssecZone, error) {
+	name = dns.CanonicalName(name)
+
+	if zone, _ := v.zones.Get(name); zone != nil {
+		return zone, zone.bogus
+	}
+
+	zone, ttl, err := v.findZone(name, query)
+	if err != nil {
+		var dnssecErr *dnssecError
+		if !errors.As(err, &dnssecErr) {
+			// failed queries aren't cached
+			return nil, err
+		}
+
+		zone, ttl = &dnssecZone{name: name, bogus: err}, dnssecBogusCacheTime
+	}
+
+	v.zones.Put(name, zone, min(ttl, dnssecMaxCacheTime))
+
+	return zone, zone.bogus
+}
+
+// findZone finds the zone of the name: with a trust anchor, the zone's keys must match it. Otherwise the parent
+// zone proves if the name is a secure or an insecure zone or if it belongs to the parent zone
+func (v *dnssecValidator) findZone(name string, query dnssecQueryFn) (*dnssecZone, time.Duration, error) {
+	if anchors, ok := v.anchors[name]; ok {
+		return v.zoneKeys(name, anchors, query)
+	}
+
+	if name == "." {
+		// there is no chain of trust without the root's trust anchor
+		return &dnssecZone{name: name}, dnssecMaxCacheTime, nil
+	}
+
+	parent, err := v.zone(parentDomain(name), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !parent.isSecure() {
+		return parent, dnssecMaxCacheTime, nil
+	}
+
+	resp, err := query(name, dns.TypeDS)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case resp.Rcode == dns.RcodeNameError:
+		// names below don't exist either, their denial is proven by the parent
+		return parent, secondsDuration(negativeTTL(resp)), nil
+	case resp.Rcode != dns.RcodeSuccess:
+		return nil, 0, fmt.Errorf("DS query of %s failed with %s", name, dns.RcodeToString[resp.Rcode])
+	}
+
+	sets, sigs := splitRRsets(resp.Answer)
+
+	for _, set := range sets {
+		if !strings.EqualFold(set[0].Header().Name, name) {
+			continue
+		}
+
+		switch set[0].Header().Rrtype {
+		case dns.TypeDS:
+			return v.delegation(parent, name, set, sigs[rrsetKey(name, dns.TypeDS)], query)
+		case dns.TypeCNAME:
+			// the owner of a CNAME can't be a zone cut
+			return parent, dnssecMaxCacheTime, nil
+		}
+	}
+
+	return v.missingDS(parent, name, resp, query)
+}
+
+// delegation validates the DS records of the secure delegation from the parent and the keys of the zone
+func (v *dnssecValidator) delegation(
+	parent *dnssecZone, name string, set []dns.RR, sigs []*dns.RRSIG, query dnssecQueryFn,
+) (*dnssecZone, time.Duration, error) {
+	if len(sigs) == 0 {
+		return nil, 0, newDNSSECError(dns.ExtendedErrorCodeRRSIGsMissing, "no signature of the DS records of %s", name)
+	}
+
+	sig, err := verifyRRset(parent, set, sigs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl := rrsetTTL(set, sig)
+
+	anchors := make([]*dns.DS, 0, len(set))
+
+	for _, rr := range set {
+		if ds, ok := rr.(*dns.DS); ok {
+			anchors = append(anchors, ds)
+		}
+	}
+
+	zone, keysTTL, err := v.zoneKeys(name, anchors, query)
+
+	return zone, min(ttl, keysTTL), err
+}
+
+// missingDS checks the proof of the missing DS records: the name is an insecure delegation
+// or no zone cut at all (RFC 4035, 5.2 and RFC 5155, 8.9)
+func (v *dnssecValidator) missingDS(
+	parent *dnssecZone, name string, resp *dns.Msg, query dnssecQueryFn,
+) (*dnssecZone, time.Duration, error) {
+	for _, rr := range resp.Ns {
+		if sig, ok := rr.(*dns.RRSIG); ok && dns.CanonicalName(sig.SignerName) != parent.name {
+			return nil, 0, newDNSSECError(dns.ExtendedErrorCodeDNSBogus, "proof of the missing DS of %s is signed by %s",
+				name, sig.SignerName)
+		}
+	}
+
+	denial, secure, err := v.denial(name, resp.Ns, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !secure {
+		return nil, 0, newDNSSECError(dns.ExtendedErrorCodeDNSBogus, "insecure proof of the missing DS of %s", name)
+	}
+
+	ttl := secondsDuration(negativeTTL(resp))
+
+	switch cut, proven := denial.provesNoDS(name); {
+	case !proven:
+		return nil, 0, newDNSSECError(dns.ExtendedErrorCodeNSECMissing, "no proof of the missing DS of %s", name)
+	case cut:
+		return &dnssecZone{name: name}, ttl, nil
+	default:
+		return parent, ttl, nil
+	}
+}
+
+// zoneKeys queries and validates the keys of the zone: the DNSKEY RRset must be signed by a key of the DS records.
+// The zone is insecure, if no DS record is supported
+func (v *dnssecValidator) zoneKeys(
+	name string, anchors []*dns.DS, query dnssecQueryFn,
+) (*dnssecZone, time.Duration, error) {
+	supported := false
+
+	for _, ds := range anchors {
+		supported = supported || (isSupportedDNSSECAlgorithm(ds.Algorithm) && isSupportedDigestType(ds.DigestType))
+	}
+
+	if !supported {
+		return &dnssecZone{name: name}, dnssecMaxCacheTime, nil
+	}
+
+	resp, err := query(name, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sets, sigs := splitRRsets(resp.Answer)
+
+	var set []dns.RR
+
+	for _, s := range sets {
+		if strings.EqualFold(s[0].Header().Name, name) && s[0].Header().Rrtype == dns.TypeDNSKEY {
+			set = s
+		}
+	}
+
+	if len(set) == 0 {
+		return nil, 0, newDNSSECError(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY records of %s", name)
+	}
+
+	var keys, entryKeys []*dns.DNSKEY
+
+	for _, rr := range set {
+		key, ok := rr.(*dns.DNSKEY)
+		if !ok || key.Flags&dns.REVOKE != 0 {
+			continue
+		}
+
+		keys = append(keys, key)
+
+		if matchesDS(key, anchors) {
+			entryKeys = append(entryKeys, key)
+		}
+	}
+
+	if len(entryKeys) == 0 {
+		return nil, 0, newDNSSECError(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY of %s matches the DS records", name)
+	}
+
+	sig, err := verifyRRset(&dnssecZone{name: name, keys: entryKeys}, set, sigs[rrsetKey(name, dns.TypeDNSKEY)])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &dnssecZone{name: name, keys: keys}, rrsetTTL(set, sig), nil
+}
+
+// verifyRRset returns the first signature of the RRset, which is verified by a key of the zone and valid now
+func verifyRRset(zone *dnssecZone, set []dns.RR, sigs []*dns.RRSIG) (*dns.RRSIG, error) {
+	owner := set[0].Header().Name
+	rrType := dns.Type(set[0].Header().Rrtype)
+
+	if len(sigs) == 0 {
+		return nil, newDNSSECError(dns.ExtendedErrorCodeRRSIGsMissing, "no signature of %s %s", owner, rrType)
+	}
+
+	err := newDNSSECError(dns.ExtendedErrorCodeDNSKEYMissing, "no key of %s signed %s %s", zone.name, owner, rrType)
+	now := util.Now()
+
+	for _, sig := range sigs {
+		for _, key := range zone.keys {
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm {
+				continue
+			}
+
+			if verifyErr := sig.Verify(key, set); verifyErr != nil {
+				err = newDNSSECError(dns.ExtendedErrorCodeDNSBogus, "invalid signature of %s %s: %s", owner, rrType, verifyErr)
+
+				continue
+			}
+
+			if !sig.ValidityPeriod(now) {
+				if now.Unix() < int64(sig.Inception) {
+					err = newDNSSECError(dns.ExtendedErrorCodeSignatureNotYetValid, "signature of %s %s is not yet valid",
+						owner, rrType)
+				} else {
+					err = newDNSSECError(dns.ExtendedErrorCodeSignatureExpired, "signature of %s %s expired", owner, rrType)
+				}
+
+				continue
+			}
+
+			return sig, nil
+		}
+	}
+
+	return nil, err
+}
+
+// dnssecDenial are the validated NSEC or NSEC3 records of a response
+type dnssecDenial struct {
+	nsec  []*dns.NSEC
+	nsec3 []*dns.NSEC3
+}
+
+// provesNXDomain returns true if the name and the wildcard at its closest encloser don't exist.
+// Opt-out is true, if an unsigned delegation may exist instead
+func (d *dnssecDenial) provesNXDomain(name string) (proven, optOut bool) {
+	if len(d.nsec) != 0 {
+		covering := d.coveringNSEC(name)
+		if covering == nil {
+			return false, false
+		}
+
+		ce := nsecClosestEncloser(name, covering)
+
+		return d.coveringNSEC("*."+ce) != nil, false
+	}
+
+	ce, cover := d.nsec3ClosestEncloser(name)
+	if cover == nil {
+		return false, false
+	}
+
+	return d.coveringNSEC3("*."+ce) != nil, cover.Flags&nsec3OptOut != 0
+}
+
+// provesNoData returns true if the name exists, but has no records of the type and no CNAME.
+// Opt-out is true, if an unsigned delegation may exist instead
+func (d *dnssecDenial) provesNoData(name string, qType uint16) (proven, optOut bool) {
+	if len(d.nsec) != 0 {
+		if nsec := d.matchingNSEC(name); nsec != nil {
+			return !hasType(nsec.TypeBitMap, qType) && !hasType(nsec.TypeBitMap, dns.TypeCNAME), false
+		}
+
+		covering := d.coveringNSEC(name)
+		if covering == nil {
+			return false, false
+		}
+
+		if dns.IsSubDomain(name, covering.NextDomain) {
+			// an empty non-terminal has no records at all
+			return true, false
+		}
+
+		// the answer of a wildcard with other types
+		wildcard := d.matchingNSEC("*." + nsecClosestEncloser(name, covering))
+
+		return wildcard != nil && !hasType(wildcard.TypeBitMap, qType) && !hasType(wildcard.TypeBitMap, dns.TypeCNAME), false
+	}
+
+	if nsec3 := d.matchingNSEC3(name); nsec3 != nil {
+		return !hasType(nsec3.TypeBitMap, qType) && !hasType(nsec3.TypeBitMap, dns.TypeCNAME), false
+	}
+
+	ce, cover := d.nsec3ClosestEncloser(name)
+	if cover == nil {
+		return false, false
+	}
+
+	if cover.Flags&nsec3OptOut != 0 {
+		return false, true
+	}
+
+	wildcard := d.matchingNSEC3("*." + ce)
+
+	return wildcard != nil && !hasType(wildcard.TypeBitMap, qType) && !hasType(wildcard.TypeBitMap, dns.TypeCNAME), false
+}
+
+// provesNoDS returns if the name without DS records is a zone cut, i.e. an insecure delegation,
+// and if the records proved that at all
+func (d *dnssecDenial) provesNoDS(name string) (cut, proven bool) {
+	bitmap := func(types []uint16) (bool, bool) {
+		if hasType(types, dns.TypeDS) || hasType(types, dns.TypeSOA) {
+			// the NSEC record of a secure delegation or of the child zone
+			return false, false
+		}
+
+		return hasType(types, dns.TypeNS), true
+	}
+
+	if len(d.nsec) != 0 {
+		if nsec := d.matchingNSEC(name); nsec != nil {
+			return bitmap(nsec.TypeBitMap)
+		}
+
+		// an empty non-terminal is no zone cut
+		covering := d.coveringNSEC(name)
+
+		return false, covering != nil && dns.IsSubDomain(name, covering.NextDomain)
+	}
+
+	if nsec3 := d.matchingNSEC3(name); nsec3 != nil {
+		return bitmap(nsec3.TypeBitMap)
+	}
+
+	// insecure delegations may not have NSEC3 records in opt-out zones
+	_, cover := d.nsec3ClosestEncloser(name)
+
+	return true, cover != nil && cover.Flags&nsec3OptOut != 0
+}
+
+// provesWildcard returns true if the owner of an answer expanded from a wildcard with the labels doesn't exist
+func (d *dnssecDenial) provesWildcard(owner string, labels int) bool {
+	if len(d.nsec) != 0 {
+		return d.coveringNSEC(owner) != nil
+	}
+
+	// the next closer name of the wildcard's closest encloser must not exist
+	return d.coveringNSEC3(lastLabels(owner, labels+1)) != nil
+}
+
+func (d *dnssecDenial) matchingNSEC(name string) *dns.NSEC {
+	for _, nsec := range d.nsec {
+		if strings.EqualFold(nsec.Hdr.Name, name) {
+			return nsec
+		}
+	}
+
+	return nil
+}
+
+// coveringNSEC returns the NSEC record, which proves that the name doesn't exist
+func (d *dnssecDenial) coveringNSEC(name string) *dns.NSEC {
+	for _, nsec := range d.nsec {
+		owner, next := nsec.Hdr.Name, nsec.NextDomain
+
+		if canonicalCompare(owner, name) >= 0 {
+			continue
+		}
+
+		// the last NSEC record of the zone points back to the apex
+		if canonicalCompare(name, next) < 0 || (canonicalCompare(owner, next) >= 0 && dns.IsSubDomain(next, name)) {
+			return nsec
+		}
+	}
+
+	return nil
+}
+
+func (d *dnssecDenial) matchingNSEC3(name string) *dns.NSEC3 {
+	for _, nsec3 := range d.nsec3 {
+		if nsec3.Match(name) {
+			return nsec3
+		}
+	}
+
+	return nil
+}
+
+func (d *dnssecDenial) coveringNSEC3(name string) *dns.NSEC3 {
+	for _, nsec3 := range d.nsec3 {
+		if !nsec3.Match(name) && nsec3.Cover(name) {
+			return nsec3
+		}
+	}
+
+	return nil
+}
+
+// nsec3ClosestEncloser returns the closest encloser of the name and the NSEC3 record, which covers the next closer
+// name (RFC 5155, 8.3). The record is nil without proof
+func (d *dnssecDenial) nsec3ClosestEncloser(name string) (string, *dns.NSEC3) {
+	nextCloser := name
+
+	for candidate := parentDomain(name); ; candidate = parentDomain(candidate) {
+		if d.matchingNSEC3(candidate) != nil {
+			return candidate, d.coveringNSEC3(nextCloser)
+		}
+
+		if candidate == "." {
+			return "", nil
+		}
+
+		nextCloser = candidate
+	}
+}
+
+// nsecClosestEncloser returns the closest encloser of the name, which doesn't exist according to the NSEC record:
+// the longest ancestor of the name, which is an ancestor of the owner or the next name
+func nsecClosestEncloser(name string, nsec *dns.NSEC) string {
+	labels := max(dns.CompareDomainName(name, nsec.Hdr.Name), dns.CompareDomainName(name, nsec.NextDomain))
+
+	return lastLabels(name, labels)
+}
+
+// splitRRsets groups the records in RRsets by owner and type, the signatures by owner and covered type
+func splitRRsets(rrs []dns.RR) (sets [][]dns.RR, sigs map[string][]*dns.RRSIG) {
+	sigs = make(map[string][]*dns.RRSIG)
+	index := make(map[string]int)
+
+	for _, rr := range rrs {
+		hdr := rr.Header()
+
+		switch v := rr.(type) {
+		case *dns.RRSIG:
+			key := rrsetKey(hdr.Name, v.TypeCovered)
+			sigs[key] = append(sigs[key], v)
+		case *dns.OPT:
+		default:
+			key := rrsetKey(hdr.Name, hdr.Rrtype)
+
+			if i, ok := index[key]; ok {
+				sets[i] = append(sets[i], rr)
+			} else {
+				index[key] = len(sets)
+				sets = append(sets, []dns.RR{rr})
+			}
+		}
+	}
+
+	return sets, sigs
+}
+
+func rrsetKey(name string, rrType uint16) string {
+	return dns.CanonicalName(name) + "/" + dns.Type(rrType).String()
+}
+
+// isSynthesizedCNAME returns true if a DNAME of the answer is above the owner of the CNAME
+func isSynthesizedCNAME(owner string, sets [][]dns.RR) bool {
+	for _, set := range sets {
+		hdr := set[0].Header()
+
+		if hdr.Rrtype == dns.TypeDNAME && !strings.EqualFold(hdr.Name, owner) && dns.IsSubDomain(hdr.Name, owner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// answerTarget follows the CNAME chain of the answer: returns the last name and if it has records of the type
+func answerTarget(question dns.Question, answer []dns.RR) (string, bool) {
+	target := question.Name
+
+	// each CNAME is followed at most once, so loops end
+	for range answer {
+		next := ""
+
+		for _, rr := range answer {
+			if !strings.EqualFold(rr.Header().Name, target) {
+				continue
+			}
+
+			if rr.Header().Rrtype == question.Qtype {
+				return target, true
+			}
+
+			if cname, ok := rr.(*dns.CNAME); ok {
+				next = cname.Target
+			}
+		}
+
+		if next == "" {
+			break
+		}
+
+		target = next
+	}
+
+	return target, false
+}
+
+// matchesDS returns true if the key is the one of a DS record
+func matchesDS(key *dns.DNSKEY, records []*dns.DS) bool {
+	for _, ds := range records {
+		if ds.KeyTag != key.KeyTag() || ds.Algorithm != key.Algorithm || !isSupportedDigestType(ds.DigestType) {
+			continue
+		}
+
+		if keyDS := key.ToDS(ds.DigestType); keyDS != nil && strings.EqualFold(keyDS.Digest, ds.Digest) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSupportedDNSSECAlgorithm(algorithm uint8) bool {
+	switch algorithm {
+	case dns.RSASHA1, dns.RSASHA1NSEC3SHA1, dns.RSASHA256, dns.RSASHA512,
+		dns.ECDSAP256SHA256, dns.ECDSAP384SHA384, dns.ED25519:
+		return true
+	default:
+		return false
+	}
+}
+
+func isSupportedDigestType(digestType uint8) bool {
+	return digestType == dns.SHA1 || digestType == dns.SHA256 || digestType == dns.SHA384
+}
+
+func hasType(types []uint16, rrType uint16) bool {
+	for _, t := range types {
+		if t == rrType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rrsetTTL returns how long the validated RRset may be cached: not longer than its TTL and the signature is valid
+func rrsetTTL(set []dns.RR, sig *dns.RRSIG) time.Duration {
+	ttl := sig.OrigTtl
+
+	for _, rr := range set {
+		ttl = min(ttl, rr.Header().Ttl)
+	}
+
+	return min(secondsDuration(ttl), time.Unix(int64(sig.Expiration), 0).Sub(util.Now()))
+}
+
+func secondsDuration(ttl uint32) time.Duration {
+	return time.Duration(ttl) * time.Second
+}
+
+// countLabels returns the labels of the name as counted by RRSIG records: without the root and a wildcard label
+func countLabels(name string) int {
+	labels := dns.CountLabel(name)
+
+	if strings.HasPrefix(name, "*.") {
+		labels--
+	}
+
+	return labels
+}
+
+// lastLabels returns the name of the last labels of the name
+func lastLabels(name string, labels int) string {
+	indexes := dns.Split(name)
+
+	if labels <= 0 {
+		return "."
+	}
+
+	if labels >= len(indexes) {
+		return name
+	}
+
+	return name[indexes[len(indexes)-labels]:]
+}
+
+// parentDomain returns the name without its first label
+func parentDomain(name string) string {
+	offset, end := dns.NextLabel(name, 0)
+	if end {
+		return "."
+	}
+
+	return name[offset:]
+}
+
+// canonicalCompare compares the names in the canonical DNS name order (RFC 4034, 6.1)
+func canonicalCompare(a, b string) int {
+	labelsA := dns.SplitDomainName(strings.ToLower(a))
+	labelsB := dns.SplitDomainName(strings.ToLower(b))
+
+	for i := 1; i <= min(len(labelsA), len(labelsB)); i++ {
+		if c := strings.Compare(labelsA[len(labelsA)-i], labelsB[len(labelsB)-i]); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(labelsA), len(labelsB))
+}
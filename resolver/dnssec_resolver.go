@@ -0,0 +1,131 @@
+package resolver
+
+import (
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// DNSSECResolver validates the answers of the upstreams with DNSSEC: the queries have the DO bit set,
+// so the upstreams send the signatures, which are validated up to the trust anchors.
+// Secure answers get the AD bit, insecure ones are returned without it. Bogus answers are logged and, if enforced,
+// replaced with SERVFAIL and an extended DNS error. Clients with the CD bit get bogus answers to validate them.
+type DNSSECResolver struct {
+	configurable[*config.DNSSECConfig]
+	NextResolver
+	typed
+
+	validator *dnssecValidator
+	insecure  domainMatcher
+}
+
+// NewDNSSECResolver creates a new instance of the DNSSECResolver type
+func NewDNSSECResolver(cfg config.DNSSECConfig) (*DNSSECResolver, error) {
+	r := &DNSSECResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("dnssec"),
+
+		insecure: newDomainMatcher(cfg.InsecureDomains),
+	}
+
+	if cfg.IsEnabled() {
+		anchors, err := cfg.Anchors()
+		if err != nil {
+			return nil, err
+		}
+
+		r.validator = newDNSSECValidator(anchors)
+	}
+
+	return r, nil
+}
+
+func (r *DNSSECResolver) Resolve(request *model.Request) (*model.Response, error) {
+	question := request.Req.Question[0]
+	domain := util.ExtractDomain(question)
+
+	if !r.IsEnabled() || r.insecure.matches(domain) {
+		return r.next.Resolve(request)
+	}
+
+	// the request of the client is kept as is: its EDNS record defines the maximum response size
+	req := request.Req.Copy()
+	hasEdns := req.IsEdns0() != nil
+
+	r.prepareQuery(req)
+
+	validatedRequest := *request
+	validatedRequest.Req = req
+
+	response, err := r.next.Resolve(&validatedRequest)
+	if err != nil || response.Res == nil {
+		return response, err
+	}
+
+	logger := log.WithPrefix(request.Log, "dnssec_resolver").WithField("domain", domain)
+
+	response.Res.CheckingDisabled = request.Req.CheckingDisabled
+
+	if !hasEdns {
+		removeEdns0(response.Res)
+	}
+
+	secure, err := r.validator.validate(question, response.Res, r.queryFn(request))
+
+	switch {
+	case err == nil:
+		logger.Debugf("answer is secure: %t", secure)
+
+		if r.cfg.Mode == config.DNSSECModeEnforce {
+			response.Res.AuthenticatedData = secure
+		}
+
+		return response, nil
+	case r.cfg.Mode == config.DNSSECModeLog:
+		logger.Warnf("answer is bogus: %s", err)
+
+		return response, nil
+	case request.Req.CheckingDisabled:
+		logger.Debugf("passing bogus answer to the client, which validates itself: %s", err)
+
+		response.Res.AuthenticatedData = false
+
+		return response, nil
+	default:
+		logger.Warnf("replacing bogus answer with SERVFAIL: %s", err)
+
+		return &model.Response{
+			Res:    newExtendedErrorResponse(request.Req, dnssecExtendedError(err), err),
+			RType:  model.ResponseTypeRESOLVED,
+			Reason: "DNSSEC BOGUS",
+		}, nil
+	}
+}
+
+// prepareQuery sets the DO bit, so the upstreams send the signatures. If enforced, the CD bit is set as well:
+// validating upstreams must return bogus answers, so they're answered with the extended DNS error of the validation
+func (r *DNSSECResolver) prepareQuery(req *dns.Msg) {
+	util.SetEdns0Do(req)
+
+	req.CheckingDisabled = req.CheckingDisabled || r.cfg.Mode == config.DNSSECModeEnforce
+}
+
+// queryFn returns the function to query the DS and DNSKEY records via the upstreams of the request
+func (r *DNSSECResolver) queryFn(request *model.Request) dnssecQueryFn {
+	return func(name string, qType uint16) (*dns.Msg, error) {
+		req := util.NewMsgWithQuestion(name, dns.Type(qType))
+		r.prepareQuery(req)
+
+		keyRequest := *request
+		keyRequest.Req = req
+
+		response, err := r.next.Resolve(&keyRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		return response.Res, nil
+	}
+}
@@ -0,0 +1,304 @@
+package resolver
+
+import (
+	"errors"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("DNSSECResolver", func() {
+	var (
+		sut       *DNSSECResolver
+		sutConfig config.DNSSECConfig
+		m         *mockResolver
+		hierarchy *dnssecTestHierarchy
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		hierarchy = newDNSSECTestHierarchy()
+
+		sutConfig, err = config.WithDefaults[config.DNSSECConfig]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Enable = true
+		sutConfig.TrustAnchors = []string{hierarchy.root.ds().String()}
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(request *Request) (*Response, error) {
+			question := request.Req.Question[0]
+
+			msg, err := hierarchy.query(question.Name, question.Qtype)
+			Expect(err).Should(Succeed())
+
+			msg.Id = request.Req.Id
+			msg.Question = request.Req.Question
+
+			if opt := request.Req.IsEdns0(); opt != nil {
+				msg.SetEdns0(opt.UDPSize(), opt.Do())
+			}
+
+			return &Response{Res: msg, RType: ResponseTypeRESOLVED, Reason: "RESOLVED"}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewDNSSECResolver(sutConfig)
+		Expect(err).Should(Succeed())
+
+		sut.Next(m)
+	})
+
+	// requests returns the requests of the next resolver, which have the name and type
+	requests := func(name string, qType dns.Type) []*dns.Msg {
+		GinkgoHelper()
+
+		var result []*dns.Msg
+
+		for _, call := range m.Calls {
+			request, ok := call.Arguments.Get(0).(*Request)
+			Expect(ok).Should(BeTrue())
+
+			if request.Req.Question[0].Name == name && request.Req.Question[0].Qtype == uint16(qType) {
+				result = append(result, request.Req)
+			}
+		}
+
+		return result
+	}
+
+	extendedError := func(msg *dns.Msg) uint16 {
+		GinkgoHelper()
+
+		opt := msg.IsEdns0()
+		Expect(opt).ShouldNot(BeNil())
+
+		for _, option := range opt.Option {
+			if ede, ok := option.(*dns.EDNS0_EDE); ok {
+				return ede.InfoCode
+			}
+		}
+
+		Fail("no extended DNS error")
+
+		return 0
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	It("should fail for invalid trust anchors", func() {
+		sutConfig.TrustAnchors = []string{"invalid"}
+
+		_, err := NewDNSSECResolver(sutConfig)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	When("disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Enable = false
+		})
+
+		It("should pass the query", func() {
+			request := newRequest("www.example.", A)
+
+			resp, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Answer[0]).Should(BeDNSRecord("www.example.", A, "192.0.2.1"))
+			Expect(resp.Res.AuthenticatedData).Should(BeFalse())
+			Expect(m.Calls).Should(HaveLen(1))
+			Expect(m.Calls[0].Arguments.Get(0)).Should(BeIdenticalTo(request))
+		})
+	})
+
+	It("should set the AD bit for secure answers", func() {
+		resp, err := sut.Resolve(newRequest("www.example.", A))
+		Expect(err).Should(Succeed())
+
+		Expect(resp).Should(SatisfyAll(
+			HaveResponseType(ResponseTypeRESOLVED),
+			HaveReason("RESOLVED"),
+		))
+		Expect(resp.Res.Answer[0]).Should(BeDNSRecord("www.example.", A, "192.0.2.1"))
+		Expect(resp.Res.AuthenticatedData).Should(BeTrue())
+
+		By("querying with DO and CD bits", func() {
+			reqs := requests("www.example.", A)
+			Expect(reqs).Should(HaveLen(1))
+			Expect(reqs[0].IsEdns0().Do()).Should(BeTrue())
+			Expect(reqs[0].CheckingDisabled).Should(BeTrue())
+		})
+
+		By("querying the keys via the next resolver", func() {
+			Expect(requests("example.", dns.Type(dns.TypeDNSKEY))).Should(HaveLen(1))
+			Expect(requests("example.", dns.Type(dns.TypeDS))).Should(HaveLen(1))
+		})
+
+		By("keeping the flags of the client", func() {
+			Expect(resp.Res.CheckingDisabled).Should(BeFalse())
+			Expect(resp.Res.IsEdns0()).Should(BeNil())
+		})
+	})
+
+	It("should keep the EDNS record of clients", func() {
+		request := newRequest("www.example.", A)
+		request.Req.SetEdns0(1232, false)
+
+		resp, err := sut.Resolve(request)
+		Expect(err).Should(Succeed())
+
+		Expect(resp.Res.IsEdns0()).ShouldNot(BeNil())
+		Expect(request.Req.IsEdns0().Do()).Should(BeFalse())
+	})
+
+	It("should cache the keys of the zones", func() {
+		for i := 0; i < 2; i++ {
+			_, err := sut.Resolve(newRequest("www.example.", A))
+			Expect(err).Should(Succeed())
+		}
+
+		Expect(requests("example.", dns.Type(dns.TypeDNSKEY))).Should(HaveLen(1))
+	})
+
+	DescribeTable("should validate",
+		func(name string, qType dns.Type, rcode int, secure bool) {
+			resp, err := sut.Resolve(newRequest(name, qType))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveReturnCode(rcode))
+			Expect(resp.Res.AuthenticatedData).Should(Equal(secure))
+		},
+		Entry("NODATA", "www.example.", AAAA, dns.RcodeSuccess, true),
+		Entry("NXDOMAIN", "missing.example.", A, dns.RcodeNameError, true),
+		Entry("an insecure delegation", "host.insecure.example.", A, dns.RcodeSuccess, false),
+		Entry("a CNAME to an insecure delegation", "alias.example.", A, dns.RcodeSuccess, false),
+		Entry("a wildcard answer", "host.wild.", A, dns.RcodeSuccess, true),
+		Entry("NXDOMAIN with NSEC3", "missing.nsec3.", A, dns.RcodeNameError, true),
+		Entry("an opt-out delegation", "host.unsigned.nsec3.", A, dns.RcodeSuccess, false),
+	)
+
+	Describe("bogus answers", func() {
+		BeforeEach(func() {
+			hierarchy.answers[rrsetKey("www.example.", dns.TypeA)].Answer[0].(*dns.A).A = net.ParseIP("192.0.2.99")
+		})
+
+		It("should be replaced with SERVFAIL", func() {
+			resp, err := sut.Resolve(newRequest("www.example.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(SatisfyAll(
+				HaveNoAnswer(),
+				HaveReturnCode(dns.RcodeServerFailure),
+				HaveResponseType(ResponseTypeRESOLVED),
+				HaveReason("DNSSEC BOGUS"),
+			))
+			Expect(extendedError(resp.Res)).Should(Equal(dns.ExtendedErrorCodeDNSBogus))
+		})
+
+		It("should return the extended DNS error of the validation", func() {
+			hierarchy.add("missing.example.", dns.TypeA, newTestMsg(dns.RcodeNameError, nil, hierarchy.example.soa()))
+
+			resp, err := sut.Resolve(newRequest("missing.example.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveReturnCode(dns.RcodeServerFailure))
+			Expect(extendedError(resp.Res)).Should(Equal(dns.ExtendedErrorCodeNSECMissing))
+		})
+
+		It("should be passed to clients, which disabled checking", func() {
+			request := newRequest("www.example.", A)
+			request.Req.CheckingDisabled = true
+
+			resp, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Answer[0]).Should(BeDNSRecord("www.example.", A, "192.0.2.99"))
+			Expect(resp.Res.AuthenticatedData).Should(BeFalse())
+			Expect(resp.Res.CheckingDisabled).Should(BeTrue())
+		})
+
+		When("mode is log", func() {
+			BeforeEach(func() {
+				sutConfig.Mode = config.DNSSECModeLog
+			})
+
+			It("should be passed and logged", func() {
+				logger, hook := log.NewMockEntry()
+				request := newRequest("www.example.", A, logger)
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Res.Answer[0]).Should(BeDNSRecord("www.example.", A, "192.0.2.99"))
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("answer is bogus")))
+
+				By("not disabling checking of the upstreams", func() {
+					Expect(requests("www.example.", A)[0].CheckingDisabled).Should(BeFalse())
+				})
+			})
+
+			It("should not set the AD bit", func() {
+				resp, err := sut.Resolve(newRequest("missing.example.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Res.AuthenticatedData).Should(BeFalse())
+			})
+		})
+
+		When("the domain is insecure", func() {
+			BeforeEach(func() {
+				sutConfig.InsecureDomains = []string{"*.example"}
+			})
+
+			It("should not be validated", func() {
+				resp, err := sut.Resolve(newRequest("www.example.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Res.Answer[0]).Should(BeDNSRecord("www.example.", A, "192.0.2.99"))
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+		})
+	})
+
+	It("should return errors of the next resolver", func() {
+		m.ResolveFn = func(*Request) (*Response, error) {
+			return nil, errors.New("upstream error")
+		}
+
+		_, err := sut.Resolve(newRequest("www.example.", A))
+		Expect(err).Should(MatchError("upstream error"))
+	})
+})
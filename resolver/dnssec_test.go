@@ -0,0 +1,547 @@
+package resolver
+
+import (
+	"crypto"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// dnssecTestNSEC3Min and dnssecTestNSEC3Max are the smallest and largest NSEC3 hashes
+	dnssecTestNSEC3Min = "00000000000000000000000000000000"
+	dnssecTestNSEC3Max = "VVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVV"
+)
+
+// dnssecTestZone is a signed zone of the test hierarchy
+type dnssecTestZone struct {
+	name     string
+	ksk, zsk *dns.DNSKEY
+	kskPriv  crypto.Signer
+	zskPriv  crypto.Signer
+}
+
+func newDNSSECTestZone(name string) *dnssecTestZone {
+	GinkgoHelper()
+
+	z := &dnssecTestZone{name: name}
+	z.ksk, z.kskPriv = newDNSSECTestKey(name, dns.ZONE|dns.SEP)
+	z.zsk, z.zskPriv = newDNSSECTestKey(name, dns.ZONE)
+
+	return z
+}
+
+func newDNSSECTestKey(name string, flags uint16) (*dns.DNSKEY, crypto.Signer) {
+	GinkgoHelper()
+
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: name, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     flags,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+
+	priv, err := key.Generate(256)
+	Expect(err).Should(Succeed())
+
+	signer, ok := priv.(crypto.Signer)
+	Expect(ok).Should(BeTrue())
+
+	return key, signer
+}
+
+// signWith returns the RRset with its signature by the key, valid from an hour ago for a day
+func (z *dnssecTestZone) signWith(key *dns.DNSKEY, priv crypto.Signer, rrs ...dns.RR) []dns.RR {
+	GinkgoHelper()
+
+	now := util.Now()
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Ttl: rrs[0].Header().Ttl},
+		Algorithm:  key.Algorithm,
+		KeyTag:     key.KeyTag(),
+		SignerName: z.name,
+		Inception:  uint32(now.Add(-time.Hour).Unix()),
+		Expiration: uint32(now.Add(24 * time.Hour).Unix()),
+	}
+
+	Expect(sig.Sign(priv, rrs)).Should(Succeed())
+
+	return append(rrs, sig)
+}
+
+// sign returns the RRset with its signature by the zone signing key
+func (z *dnssecTestZone) sign(rrs ...dns.RR) []dns.RR {
+	GinkgoHelper()
+
+	return z.signWith(z.zsk, z.zskPriv, rrs...)
+}
+
+// keys returns the DNSKEY RRset signed by the key signing key
+func (z *dnssecTestZone) keys() []dns.RR {
+	GinkgoHelper()
+
+	return z.signWith(z.ksk, z.kskPriv, z.ksk, z.zsk)
+}
+
+func (z *dnssecTestZone) ds() *dns.DS {
+	return z.ksk.ToDS(dns.SHA256)
+}
+
+// soa returns the signed SOA record of the zone
+func (z *dnssecTestZone) soa() []dns.RR {
+	GinkgoHelper()
+
+	return z.sign(newTestRR(z.name + " 300 IN SOA ns." + z.name + " admin." + z.name + " 1 3600 600 86400 300"))
+}
+
+// nsec returns the signed NSEC record
+func (z *dnssecTestZone) nsec(owner, next string, types ...uint16) []dns.RR {
+	GinkgoHelper()
+
+	slices.Sort(types)
+
+	return z.sign(&dns.NSEC{
+		Hdr:        dns.RR_Header{Name: owner, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 300},
+		NextDomain: next,
+		TypeBitMap: types,
+	})
+}
+
+// nsec3 returns the signed NSEC3 record with the hashes of owner and next
+func (z *dnssecTestZone) nsec3(ownerHash, nextHash string, optOut bool, types ...uint16) []dns.RR {
+	GinkgoHelper()
+
+	var flags uint8
+	if optOut {
+		flags = nsec3OptOut
+	}
+
+	slices.Sort(types)
+
+	return z.sign(&dns.NSEC3{
+		Hdr:        dns.RR_Header{Name: ownerHash + "." + z.name, Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: 300},
+		Hash:       dns.SHA1,
+		Flags:      flags,
+		HashLength: 20,
+		NextDomain: nextHash,
+		TypeBitMap: types,
+	})
+}
+
+// nsec3Apex returns the NSEC3 records of a zone, which only has records at the apex:
+// they cover all hashes except the one of the apex
+func (z *dnssecTestZone) nsec3Apex(optOut bool) []dns.RR {
+	GinkgoHelper()
+
+	apex := dns.HashName(z.name, dns.SHA1, 0, "")
+
+	return append(
+		z.nsec3(apex, dnssecTestNSEC3Max, optOut, dns.TypeSOA, dns.TypeNS, dns.TypeDNSKEY, dns.TypeRRSIG, dns.TypeNSEC3PARAM),
+		z.nsec3(dnssecTestNSEC3Min, apex, optOut)...)
+}
+
+// answer answers the A query like a server of the zone, which has only the wildcard `*.<zone>`:
+// the signature and the NSEC proof of the wildcard expansion are only sent with the DO bit
+func (z *dnssecTestZone) answer(request *dns.Msg) *dns.Msg {
+	GinkgoHelper()
+
+	records := z.sign(newTestRR("*." + z.name + " 300 IN A 192.0.2.1"))
+	for _, rr := range records {
+		rr.Header().Name = request.Question[0].Name
+	}
+
+	response := new(dns.Msg)
+
+	opt := request.IsEdns0()
+	if opt == nil || !opt.Do() {
+		response.Answer = records[:1]
+
+		return response
+	}
+
+	response.Answer = records
+	response.Ns = z.nsec("*."+z.name, z.name, dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC)
+	response.SetEdns0(opt.UDPSize(), true)
+
+	return response
+}
+
+func newTestRR(s string) dns.RR {
+	GinkgoHelper()
+
+	rr, err := dns.NewRR(s)
+	Expect(err).Should(Succeed())
+
+	return rr
+}
+
+func newTestMsg(rcode int, answer, ns []dns.RR) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.Response = true
+	msg.Rcode = rcode
+	msg.Answer = answer
+	msg.Ns = ns
+
+	return msg
+}
+
+// dnssecTestHierarchy is a signed DNS tree with the zones:
+//   - `.` the root with the trust anchor
+//   - `example.` a secure zone with NSEC records and the insecure delegation `insecure.example.`
+//   - `wild.` a secure zone with the wildcard `*.wild.`
+//   - `nsec3.` a secure zone with opt-out NSEC3 records, `unsigned.nsec3.` is an insecure delegation
+type dnssecTestHierarchy struct {
+	root, example, wild, nsec3 *dnssecTestZone
+
+	// answers are the responses to the queries by name and type
+	answers map[string]*dns.Msg
+	// queries are the names and types of the queries
+	queries []string
+}
+
+func newDNSSECTestHierarchy() *dnssecTestHierarchy {
+	GinkgoHelper()
+
+	h := &dnssecTestHierarchy{
+		root:    newDNSSECTestZone("."),
+		example: newDNSSECTestZone("example."),
+		wild:    newDNSSECTestZone("wild."),
+		nsec3:   newDNSSECTestZone("nsec3."),
+		answers: make(map[string]*dns.Msg),
+	}
+
+	for _, zone := range []*dnssecTestZone{h.root, h.example, h.wild, h.nsec3} {
+		h.add(zone.name, dns.TypeDNSKEY, newTestMsg(dns.RcodeSuccess, zone.keys(), nil))
+	}
+
+	for _, zone := range []*dnssecTestZone{h.example, h.wild, h.nsec3} {
+		h.add(zone.name, dns.TypeDS, newTestMsg(dns.RcodeSuccess, h.root.sign(zone.ds()), nil))
+	}
+
+	// example.: example. -> insecure.example. -> www.example. -> example.
+	exampleApex := h.example.nsec("example.", "insecure.example.",
+		dns.TypeSOA, dns.TypeNS, dns.TypeDNSKEY, dns.TypeRRSIG, dns.TypeNSEC)
+	insecureNSEC := h.example.nsec("insecure.example.", "www.example.", dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC)
+	wwwNSEC := h.example.nsec("www.example.", "example.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC)
+
+	h.add("www.example.", dns.TypeA, newTestMsg(dns.RcodeSuccess,
+		h.example.sign(newTestRR("www.example. 300 IN A 192.0.2.1")), nil))
+	h.add("www.example.", dns.TypeAAAA, newTestMsg(dns.RcodeSuccess,
+		nil, append(h.example.soa(), wwwNSEC...)))
+	h.add("www.example.", dns.TypeDS, newTestMsg(dns.RcodeSuccess,
+		nil, append(h.example.soa(), wwwNSEC...)))
+	h.add("missing.example.", dns.TypeA, newTestMsg(dns.RcodeNameError,
+		nil, concatRRs(h.example.soa(), insecureNSEC, exampleApex)))
+	h.add("insecure.example.", dns.TypeDS, newTestMsg(dns.RcodeSuccess,
+		nil, append(h.example.soa(), insecureNSEC...)))
+	h.add("host.insecure.example.", dns.TypeA, newTestMsg(dns.RcodeSuccess,
+		[]dns.RR{newTestRR("host.insecure.example. 300 IN A 192.0.2.2")}, nil))
+	h.add("alias.example.", dns.TypeA, newTestMsg(dns.RcodeSuccess, concatRRs(
+		h.example.sign(newTestRR("alias.example. 300 IN CNAME host.insecure.example.")),
+		[]dns.RR{newTestRR("host.insecure.example. 300 IN A 192.0.2.2")}), nil))
+
+	// wild.: wild. -> *.wild. -> wild.
+	wildcard := h.wild.sign(newTestRR("*.wild. 300 IN A 192.0.2.3"))
+	for _, rr := range wildcard {
+		rr.Header().Name = "host.wild."
+	}
+
+	h.add("host.wild.", dns.TypeA, newTestMsg(dns.RcodeSuccess,
+		wildcard, h.wild.nsec("*.wild.", "wild.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC)))
+
+	// nsec3.: only the apex has records, the opt-out NSEC3 records don't cover the delegation unsigned.nsec3.
+	h.add("missing.nsec3.", dns.TypeA, newTestMsg(dns.RcodeNameError,
+		nil, append(h.nsec3.soa(), h.nsec3.nsec3Apex(false)...)))
+	h.add("unsigned.nsec3.", dns.TypeDS, newTestMsg(dns.RcodeSuccess,
+		nil, append(h.nsec3.soa(), h.nsec3.nsec3Apex(true)...)))
+	h.add("host.unsigned.nsec3.", dns.TypeA, newTestMsg(dns.RcodeSuccess,
+		[]dns.RR{newTestRR("host.unsigned.nsec3. 300 IN A 192.0.2.4")}, nil))
+
+	return h
+}
+
+func (h *dnssecTestHierarchy) add(name string, qType uint16, msg *dns.Msg) {
+	h.answers[rrsetKey(name, qType)] = msg
+}
+
+// query answers the query like an upstream, unknown queries fail with SERVFAIL
+func (h *dnssecTestHierarchy) query(name string, qType uint16) (*dns.Msg, error) {
+	key := rrsetKey(name, qType)
+	h.queries = append(h.queries, key)
+
+	msg, ok := h.answers[key]
+	if !ok {
+		return newTestMsg(dns.RcodeServerFailure, nil, nil), nil
+	}
+
+	return msg.Copy(), nil
+}
+
+// queryCount returns how often the name and type were queried
+func (h *dnssecTestHierarchy) queryCount(name string, qType uint16) int {
+	count := 0
+
+	for _, query := range h.queries {
+		if query == rrsetKey(name, qType) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func concatRRs(sets ...[]dns.RR) []dns.RR {
+	var result []dns.RR
+
+	for _, set := range sets {
+		result = append(result, set...)
+	}
+
+	return result
+}
+
+var _ = Describe("DNSSEC validator", func() {
+	var (
+		sut       *dnssecValidator
+		hierarchy *dnssecTestHierarchy
+	)
+
+	BeforeEach(func() {
+		hierarchy = newDNSSECTestHierarchy()
+		sut = newDNSSECValidator([]*dns.DS{hierarchy.root.ds()})
+	})
+
+	validate := func(name string, qType dns.Type) (bool, error) {
+		msg, err := hierarchy.query(name, uint16(qType))
+		Expect(err).Should(Succeed())
+
+		question := dns.Question{Name: name, Qtype: uint16(qType), Qclass: dns.ClassINET}
+
+		return sut.validate(question, msg, hierarchy.query)
+	}
+
+	expectBogus := func(name string, qType dns.Type, code uint16) {
+		GinkgoHelper()
+
+		_, err := validate(name, qType)
+		Expect(err).Should(HaveOccurred())
+		Expect(dnssecExtendedError(err)).Should(Equal(code), err.Error())
+	}
+
+	DescribeTable("should validate",
+		func(name string, qType dns.Type, secure bool) {
+			result, err := validate(name, qType)
+			Expect(err).Should(Succeed())
+			Expect(result).Should(Equal(secure))
+		},
+		Entry("a signed answer", "www.example.", A, true),
+		Entry("NODATA with NSEC", "www.example.", AAAA, true),
+		Entry("NXDOMAIN with NSEC", "missing.example.", A, true),
+		Entry("an answer of an insecure delegation", "host.insecure.example.", A, false),
+		Entry("a CNAME to an insecure delegation", "alias.example.", A, false),
+		Entry("an answer expanded from a wildcard", "host.wild.", A, true),
+		Entry("NXDOMAIN with NSEC3", "missing.nsec3.", A, true),
+		Entry("an answer of an insecure delegation in an opt-out zone", "host.unsigned.nsec3.", A, false),
+	)
+
+	It("should cache the keys of the zones", func() {
+		for i := 0; i < 2; i++ {
+			Expect(validate("www.example.", A)).Should(BeTrue())
+			Expect(validate("host.insecure.example.", A)).Should(BeFalse())
+		}
+
+		Expect(hierarchy.queryCount("example.", dns.TypeDNSKEY)).Should(Equal(1))
+		Expect(hierarchy.queryCount("insecure.example.", dns.TypeDS)).Should(Equal(1))
+	})
+
+	It("should answer without trust anchor as insecure", func() {
+		sut = newDNSSECValidator(nil)
+
+		Expect(validate("www.example.", A)).Should(BeFalse())
+	})
+
+	It("should accept trust anchors of zones below the root", func() {
+		sut = newDNSSECValidator([]*dns.DS{hierarchy.example.ds()})
+
+		Expect(validate("www.example.", A)).Should(BeTrue())
+		Expect(hierarchy.queryCount(".", dns.TypeDNSKEY)).Should(BeZero())
+	})
+
+	It("should pass other rcodes", func() {
+		Expect(sut.validate(dns.Question{Name: "example.", Qtype: dns.TypeA},
+			newTestMsg(dns.RcodeServerFailure, nil, nil), hierarchy.query)).Should(BeFalse())
+	})
+
+	Describe("bogus answers", func() {
+		It("should fail for changed records", func() {
+			hierarchy.answers[rrsetKey("www.example.", dns.TypeA)].Answer[0].(*dns.A).A = net.ParseIP("192.0.2.99")
+
+			expectBogus("www.example.", A, dns.ExtendedErrorCodeDNSBogus)
+		})
+
+		It("should fail for missing signatures in a secure zone", func() {
+			hierarchy.add("www.example.", dns.TypeA, newTestMsg(dns.RcodeSuccess,
+				[]dns.RR{newTestRR("www.example. 300 IN A 192.0.2.1")}, nil))
+
+			expectBogus("www.example.", A, dns.ExtendedErrorCodeRRSIGsMissing)
+		})
+
+		It("should fail if no key matches the DS records", func() {
+			hierarchy.add("example.", dns.TypeDS, newTestMsg(dns.RcodeSuccess,
+				hierarchy.root.sign(newDNSSECTestZone("example.").ds()), nil))
+
+			expectBogus("www.example.", A, dns.ExtendedErrorCodeDNSKEYMissing)
+		})
+
+		It("should fail for expired signatures", func() {
+			clock := util.NewFakeClock()
+			DeferCleanup(util.SetClock(clock))
+
+			clock.JumpWallClock(48 * time.Hour)
+
+			expectBogus("www.example.", A, dns.ExtendedErrorCodeSignatureExpired)
+		})
+
+		It("should fail for signatures, which are not yet valid", func() {
+			clock := util.NewFakeClock()
+			DeferCleanup(util.SetClock(clock))
+
+			clock.JumpWallClock(-2 * time.Hour)
+
+			expectBogus("www.example.", A, dns.ExtendedErrorCodeSignatureNotYetValid)
+		})
+
+		It("should fail for NXDOMAIN without proof", func() {
+			hierarchy.add("missing.example.", dns.TypeA, newTestMsg(dns.RcodeNameError, nil, hierarchy.example.soa()))
+
+			expectBogus("missing.example.", A, dns.ExtendedErrorCodeNSECMissing)
+		})
+
+		It("should fail for NXDOMAIN without proof of the missing wildcard", func() {
+			msg := hierarchy.answers[rrsetKey("missing.example.", dns.TypeA)]
+			msg.Ns = concatRRs(hierarchy.example.soa(),
+				hierarchy.example.nsec("insecure.example.", "www.example.", dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC))
+
+			expectBogus("missing.example.", A, dns.ExtendedErrorCodeNSECMissing)
+		})
+
+		It("should fail for NODATA of a type, which exists", func() {
+			msg := hierarchy.answers[rrsetKey("www.example.", dns.TypeAAAA)]
+			hierarchy.add("www.example.", dns.TypeA, msg)
+
+			expectBogus("www.example.", A, dns.ExtendedErrorCodeNSECMissing)
+		})
+
+		It("should fail for a wildcard answer without proof", func() {
+			hierarchy.answers[rrsetKey("host.wild.", dns.TypeA)].Ns = nil
+
+			expectBogus("host.wild.", A, dns.ExtendedErrorCodeNSECMissing)
+		})
+
+		It("should fail for an unsigned delegation without proof", func() {
+			hierarchy.add("insecure.example.", dns.TypeDS, newTestMsg(dns.RcodeSuccess, nil, hierarchy.example.soa()))
+
+			expectBogus("host.insecure.example.", A, dns.ExtendedErrorCodeNSECMissing)
+		})
+
+		It("should fail for records signed by another zone", func() {
+			hierarchy.add("www.example.", dns.TypeA, newTestMsg(dns.RcodeSuccess,
+				hierarchy.wild.sign(newTestRR("www.example. 300 IN A 192.0.2.1")), nil))
+
+			expectBogus("www.example.", A, dns.ExtendedErrorCodeDNSBogus)
+		})
+
+		It("should cache bogus zones", func() {
+			hierarchy.add("example.", dns.TypeDNSKEY, newTestMsg(dns.RcodeSuccess, nil, nil))
+
+			for i := 0; i < 2; i++ {
+				expectBogus("www.example.", A, dns.ExtendedErrorCodeDNSKEYMissing)
+			}
+
+			Expect(hierarchy.queryCount("example.", dns.TypeDNSKEY)).Should(Equal(1))
+		})
+	})
+
+	It("should not cache failed queries", func() {
+		delete(hierarchy.answers, rrsetKey("example.", dns.TypeDS))
+
+		_, err := validate("www.example.", A)
+		Expect(err).Should(MatchError(ContainSubstring("DS query of example. failed with SERVFAIL")))
+
+		_, err = validate("www.example.", A)
+		Expect(err).Should(HaveOccurred())
+		Expect(hierarchy.queryCount("example.", dns.TypeDS)).Should(Equal(2))
+	})
+
+	It("should treat NSEC3 records with too many iterations as insecure", func() {
+		msg := hierarchy.answers[rrsetKey("missing.nsec3.", dns.TypeA)]
+		msg.Ns = hierarchy.nsec3.soa()
+
+		for _, rr := range hierarchy.nsec3.nsec3Apex(false) {
+			if nsec3, ok := rr.(*dns.NSEC3); ok {
+				nsec3.Iterations = dnssecMaxNSEC3Iterations + 1
+				msg.Ns = append(msg.Ns, hierarchy.nsec3.sign(nsec3)...)
+			}
+		}
+
+		Expect(validate("missing.nsec3.", A)).Should(BeFalse())
+	})
+})
+
+var _ = Describe("DNSSEC helpers", func() {
+	DescribeTable("canonicalCompare orders names by their labels from the right",
+		func(a, b string, expected int) {
+			Expect(canonicalCompare(a, b)).Should(Equal(expected))
+		},
+		Entry("equal", "example.", "EXAMPLE.", 0),
+		Entry("parent first", "example.", "a.example.", -1),
+		Entry("wildcard first", "*.example.", "a.example.", -1),
+		Entry("by the last label", "z.a.example.", "a.b.example.", -1),
+		Entry("root first", ".", "example.", -1),
+		Entry("greater", "b.example.", "a.example.", 1),
+	)
+
+	DescribeTable("lastLabels returns the last labels",
+		func(labels int, expected string) {
+			Expect(lastLabels("a.b.example.", labels)).Should(Equal(expected))
+		},
+		Entry("none", 0, "."),
+		Entry("one", 1, "example."),
+		Entry("two", 2, "b.example."),
+		Entry("all", 5, "a.b.example."),
+	)
+
+	It("parentDomain removes the first label", func() {
+		Expect(parentDomain("www.example.")).Should(Equal("example."))
+		Expect(parentDomain("example.")).Should(Equal("."))
+		Expect(parentDomain(".")).Should(Equal("."))
+	})
+
+	It("countLabels doesn't count wildcard labels", func() {
+		Expect(countLabels("*.example.")).Should(Equal(1))
+		Expect(countLabels("www.example.")).Should(Equal(2))
+	})
+
+	It("answerTarget follows the CNAME chain", func() {
+		answer := []dns.RR{
+			newTestRR("a.example. 300 IN CNAME b.example."),
+			newTestRR("b.example. 300 IN CNAME c.example."),
+		}
+
+		Expect(answerTarget(dns.Question{Name: "a.example.", Qtype: dns.TypeA}, answer)).Should(Equal("c.example."))
+
+		answer = append(answer, newTestRR("c.example. 300 IN A 192.0.2.1"))
+
+		target, answered := answerTarget(dns.Question{Name: "A.example.", Qtype: dns.TypeA}, answer)
+		Expect(strings.ToLower(target)).Should(Equal("c.example."))
+		Expect(answered).Should(BeTrue())
+	})
+})
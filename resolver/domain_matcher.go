@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"strings"
+
+	"github.com/0xERR0R/blocky/util"
+)
+
+// domainMatcher matches domains exactly or, for wildcards like `*.example.com`, all domains below the parent
+type domainMatcher struct {
+	domains map[string]struct{}
+	// wildcard parents with leading dot, e.g. ".example.com" for `*.example.com`
+	wildcards []string
+}
+
+func newDomainMatcher(domains []string) domainMatcher {
+	m := domainMatcher{domains: make(map[string]struct{}, len(domains))}
+
+	for _, domain := range domains {
+		domain = util.ExtractDomainOnly(domain)
+
+		if parent, ok := strings.CutPrefix(domain, "*"); ok {
+			m.wildcards = append(m.wildcards, parent)
+		} else {
+			m.domains[domain] = struct{}{}
+		}
+	}
+
+	return m
+}
+
+// matches returns true if the domain (without trailing dot) matches
+func (m *domainMatcher) matches(domain string) bool {
+	if _, found := m.domains[domain]; found {
+		return true
+	}
+
+	for _, parent := range m.wildcards {
+		if strings.HasSuffix(domain, parent) {
+			return true
+		}
+	}
+
+	return false
+}
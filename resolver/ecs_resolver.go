@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"net"
+	"slices"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+const (
+	ecsFamilyIPv4 = 1
+
+	ecsIPv4Bits = 32
+	ecsIPv6Bits = 128
+)
+
+// EcsResolver decides which EDNS Client Subnet (RFC 7871) the upstreams get: the one of the client, truncated to
+// the configured prefix, one synthesized from the client address or none.
+// It comes right before the cache, so the cache is partitioned by the subnet sent upstream.
+type EcsResolver struct {
+	configurable[*config.ECSConfig]
+	NextResolver
+	typed
+}
+
+// NewEcsResolver creates a new instance of the EcsResolver type
+func NewEcsResolver(cfg config.ECSConfig) *EcsResolver {
+	return &EcsResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("ecs"),
+	}
+}
+
+// Resolve passes the request with the client subnet for the upstreams to the next resolver
+func (r *EcsResolver) Resolve(request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(request)
+	}
+
+	// the request of the client is kept as is: its EDNS record defines the maximum response size
+	req := request.Req.Copy()
+	hasEdns := req.IsEdns0() != nil
+	added := false
+
+	switch ecs := util.RemoveClientSubnet(req); {
+	case ecs != nil && r.cfg.Forward:
+		r.truncate(ecs)
+
+		req.IsEdns0().Option = append(req.IsEdns0().Option, ecs)
+	case ecs != nil && (!r.cfg.Strip || ecs.SourceNetmask == 0):
+		// a source prefix of 0 asks not to add a client subnet, it contains no client information
+		req.IsEdns0().Option = append(req.IsEdns0().Option, ecs)
+	case r.cfg.Add && request.ClientIP != nil:
+		util.SetClientSubnet(req, r.clientSubnet(request.ClientIP))
+
+		added = true
+	}
+
+	ecsRequest := *request
+	ecsRequest.Req = req
+
+	response, err := r.next.Resolve(&ecsRequest)
+	if err != nil || !added || response.Res == nil {
+		return response, err
+	}
+
+	log.WithPrefix(request.Log, "ecs_resolver").Debugf("added client subnet %s", util.ClientSubnet(req))
+
+	// the client didn't ask for the subnet, the message may be shared with the cache
+	response.Res = response.Res.Copy()
+
+	if hasEdns {
+		util.RemoveClientSubnet(response.Res)
+	} else {
+		response.Res.Extra = slices.DeleteFunc(response.Res.Extra, func(rr dns.RR) bool {
+			return rr.Header().Rrtype == dns.TypeOPT
+		})
+	}
+
+	return response, nil
+}
+
+// truncate limits the source prefix of the ECS option to the configured mask
+func (r *EcsResolver) truncate(ecs *dns.EDNS0_SUBNET) {
+	maxMask, bits := r.cfg.IPv4Mask, ecsIPv4Bits
+	if ecs.Family != ecsFamilyIPv4 {
+		maxMask, bits = r.cfg.IPv6Mask, ecsIPv6Bits
+	}
+
+	if ecs.SourceNetmask > maxMask {
+		ecs.SourceNetmask = maxMask
+	}
+
+	ecs.Address = ecs.Address.Mask(net.CIDRMask(int(ecs.SourceNetmask), bits))
+}
+
+// clientSubnet returns the subnet of the client address with the configured mask
+func (r *EcsResolver) clientSubnet(ip net.IP) *net.IPNet {
+	mask := net.CIDRMask(int(r.cfg.IPv6Mask), ecsIPv6Bits)
+
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip = ipv4
+		mask = net.CIDRMask(int(r.cfg.IPv4Mask), ecsIPv4Bits)
+	}
+
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
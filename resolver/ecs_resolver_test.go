@@ -0,0 +1,285 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("EcsResolver", func() {
+	var (
+		sut       *EcsResolver
+		sutConfig config.ECSConfig
+		m         *mockResolver
+
+		sent *dns.Msg
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	// echoReply answers the request and echoes its EDNS record like an ECS aware upstream
+	echoReply := func(req *dns.Msg) *dns.Msg {
+		msg := new(dns.Msg)
+		msg.SetReply(req)
+
+		if opt := req.IsEdns0(); opt != nil {
+			msg.Extra = append(msg.Extra, dns.Copy(opt))
+		}
+
+		rr, err := dns.NewRR(req.Question[0].Name + " 300 IN A 93.184.216.34")
+		Expect(err).Should(Succeed())
+
+		msg.Answer = []dns.RR{rr}
+
+		return msg
+	}
+
+	// ecsBytes returns the data of the ECS option in the wire format of the EDNS record of the message
+	ecsBytes := func(msg *dns.Msg) []byte {
+		GinkgoHelper()
+
+		opt := msg.IsEdns0()
+		Expect(opt).ShouldNot(BeNil())
+
+		buf := make([]byte, dns.Len(opt))
+		n, err := dns.PackRR(opt, buf, 0, nil, false)
+		Expect(err).Should(Succeed())
+
+		// root name, type, class, TTL and RDLENGTH precede the options
+		options := buf[11:n]
+
+		for len(options) >= 4 {
+			code := binary.BigEndian.Uint16(options)
+			length := int(binary.BigEndian.Uint16(options[2:]))
+
+			if code == dns.EDNS0SUBNET {
+				return options[4 : 4+length]
+			}
+
+			options = options[4+length:]
+		}
+
+		Fail("message has no ECS option")
+
+		return nil
+	}
+
+	withECS := func(request *Request, cidr string) *Request {
+		_, subnet, err := net.ParseCIDR(cidr)
+		Expect(err).Should(Succeed())
+
+		util.SetClientSubnet(request.Req, subnet)
+
+		return request
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.ECSConfig]()
+		Expect(err).Should(Succeed())
+
+		sent = nil
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(request *Request) (*Response, error) {
+			sent = request.Req
+
+			return &Response{Res: echoReply(request.Req), RType: ResponseTypeRESOLVED, Reason: "Test"}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewEcsResolver(sutConfig)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	Describe("strip", func() {
+		It("should remove the client subnet, but keep the request of the client", func() {
+			request := withECS(newRequestWithClient("example.com.", A, "192.168.178.25"), "203.0.113.0/24")
+
+			_, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(sent.IsEdns0()).ShouldNot(BeNil())
+			Expect(util.ClientSubnet(sent)).Should(BeNil())
+			Expect(util.ClientSubnet(request.Req).String()).Should(Equal("203.0.113.0/24"))
+		})
+
+		It("should keep the opt-out of the client", func() {
+			request := withECS(newRequestWithClient("example.com.", A, "192.168.178.25"), "0.0.0.0/0")
+
+			_, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 1, 0, 0}))
+		})
+	})
+
+	When("the client subnet is forwarded", func() {
+		BeforeEach(func() {
+			sutConfig.Forward = true
+		})
+
+		It("should truncate the client subnet to the masks", func() {
+			_, err := sut.Resolve(withECS(newRequestWithClient("example.com.", A, "::1"), "203.0.113.25/32"))
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 1, 24, 0, 203, 0, 113}))
+
+			_, err = sut.Resolve(withECS(newRequestWithClient("example.com.", AAAA, "::1"), "2001:db8:1:2::/64"))
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 2, 56, 0, 0x20, 0x01, 0x0d, 0xb8, 0, 1, 0}))
+		})
+
+		It("should keep shorter prefixes", func() {
+			_, err := sut.Resolve(withECS(newRequestWithClient("example.com.", A, "::1"), "203.0.0.0/16"))
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 1, 16, 0, 203, 0}))
+		})
+
+		It("should not add a client subnet", func() {
+			_, err := sut.Resolve(newRequestWithClient("example.com.", A, "192.168.178.25"))
+			Expect(err).Should(Succeed())
+
+			Expect(sent.IsEdns0()).Should(BeNil())
+		})
+	})
+
+	When("the client subnet is added", func() {
+		BeforeEach(func() {
+			sutConfig.Add = true
+		})
+
+		It("should add the subnet of the client address", func() {
+			request := newRequestWithClient("example.com.", A, "192.168.178.25")
+
+			resp, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 1, 24, 0, 192, 168, 178}))
+
+			By("removing the EDNS record, which the client didn't send", func() {
+				Expect(request.Req.IsEdns0()).Should(BeNil())
+				Expect(resp.Res.IsEdns0()).Should(BeNil())
+				Expect(resp).Should(BeDNSRecord("example.com.", A, "93.184.216.34"))
+				Expect(util.ValidateResponse(request.Req, resp.Res)).Should(Succeed())
+			})
+		})
+
+		It("should add the subnet of IPv6 clients", func() {
+			_, err := sut.Resolve(newRequestWithClient("example.com.", AAAA, "2001:db8:1:2::25"))
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 2, 56, 0, 0x20, 0x01, 0x0d, 0xb8, 0, 1, 0}))
+		})
+
+		It("should remove only the subnet from the response, if the client sent EDNS", func() {
+			request := newRequestWithClient("example.com.", A, "192.168.178.25")
+			request.Req.SetEdns0(dns.DefaultMsgSize, true)
+
+			resp, err := sut.Resolve(request)
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.IsEdns0()).ShouldNot(BeNil())
+			Expect(util.ClientSubnet(resp.Res)).Should(BeNil())
+		})
+
+		It("should replace the subnet of the client", func() {
+			_, err := sut.Resolve(withECS(newRequestWithClient("example.com.", A, "192.168.178.25"), "203.0.113.0/24"))
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 1, 24, 0, 192, 168, 178}))
+		})
+
+		When("the client subnet is forwarded too", func() {
+			BeforeEach(func() {
+				sutConfig.Forward = true
+			})
+
+			It("should prefer the subnet of the client", func() {
+				_, err := sut.Resolve(withECS(newRequestWithClient("example.com.", A, "192.168.178.25"), "203.0.113.0/24"))
+				Expect(err).Should(Succeed())
+
+				Expect(ecsBytes(sent)).Should(Equal([]byte{0, 1, 24, 0, 203, 0, 113}))
+			})
+		})
+
+		When("the upstream is queried", func() {
+			var received chan *dns.Msg
+
+			BeforeEach(func() {
+				received = make(chan *dns.Msg, 1)
+
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+					received <- request.Copy()
+
+					return echoReply(request)
+				})
+				DeferCleanup(mockUpstream.Close)
+
+				upstream := newUpstreamResolverUnchecked(mockUpstream.Start(), nil)
+
+				m.ResolveFn = upstream.Resolve
+			})
+
+			It("should send the subnet and answer with a valid response", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.25")
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+
+				Expect(ecsBytes(<-received)).Should(Equal([]byte{0, 1, 24, 0, 192, 168, 178}))
+				Expect(resp).Should(BeDNSRecord("example.com.", A, "93.184.216.34"))
+				Expect(util.ValidateResponse(request.Req, resp.Res)).Should(Succeed())
+			})
+		})
+	})
+
+	When("disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Strip = false
+		})
+
+		It("should pass the client subnet as is", func() {
+			_, err := sut.Resolve(withECS(newRequestWithClient("example.com.", A, "::1"), "203.0.113.25/32"))
+			Expect(err).Should(Succeed())
+
+			Expect(ecsBytes(sent)).Should(Equal([]byte{0, 1, 32, 0, 203, 0, 113, 25}))
+		})
+	})
+})
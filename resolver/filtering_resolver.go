@@ -3,6 +3,7 @@ package resolver
 import (
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
 	"github.com/miekg/dns"
 )
 
@@ -12,18 +13,34 @@ type FilteringResolver struct {
 	configurable[*config.FilteringConfig]
 	NextResolver
 	typed
+
+	rules []filteringRule
+}
+
+// filteringRule filters the query types for the exact domains and the domains below the wildcard parents
+type filteringRule struct {
+	queryTypes config.QTypeSet
+	domains    domainMatcher
 }
 
 func NewFilteringResolver(cfg config.FilteringConfig) *FilteringResolver {
-	return &FilteringResolver{
+	r := &FilteringResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("filtering"),
+
+		rules: make([]filteringRule, 0, len(cfg.Rules)),
 	}
+
+	for _, rule := range cfg.Rules {
+		r.rules = append(r.rules, filteringRule{queryTypes: rule.QueryTypes, domains: newDomainMatcher(rule.Domains)})
+	}
+
+	return r
 }
 
 func (r *FilteringResolver) Resolve(request *model.Request) (*model.Response, error) {
-	qType := request.Req.Question[0].Qtype
-	if r.cfg.QueryTypes.Contains(dns.Type(qType)) {
+	question := request.Req.Question[0]
+	if r.isFiltered(dns.Type(question.Qtype), util.ExtractDomain(question)) {
 		response := new(dns.Msg)
 		response.SetRcode(request.Req, dns.RcodeSuccess)
 
@@ -32,3 +49,22 @@ func (r *FilteringResolver) Resolve(request *model.Request) (*model.Response, er
 
 	return r.next.Resolve(request)
 }
+
+// isFiltered returns true if the query type is filtered for all domains or by a rule matching the domain
+func (r *FilteringResolver) isFiltered(qType dns.Type, domain string) bool {
+	if r.cfg.QueryTypes.Contains(qType) {
+		return true
+	}
+
+	for i := range r.rules {
+		if r.rules[i].matches(qType, domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *filteringRule) matches(qType dns.Type, domain string) bool {
+	return r.queryTypes.Contains(qType) && r.domains.matches(domain)
+}
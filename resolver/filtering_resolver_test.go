@@ -85,6 +85,47 @@ var _ = Describe("FilteringResolver", func() {
 		})
 	})
 
+	When("Filtering rules are defined", func() {
+		BeforeEach(func() {
+			sutConfig = config.FilteringConfig{
+				QueryTypes: config.NewQTypeSet(MX),
+				Rules: []config.FilteringRule{
+					{QueryTypes: config.NewQTypeSet(AAAA), Domains: []string{"*.corp.example.com", "Broken.Example.org"}},
+				},
+			}
+		})
+
+		DescribeTable("should only filter the query types of the matching domains",
+			func(domain string, qType dns.Type, filtered bool) {
+				expectedType := ResponseTypeRESOLVED
+				if filtered {
+					expectedType = ResponseTypeFILTERED
+				}
+
+				Expect(sut.Resolve(newRequest(domain, qType))).
+					Should(
+						SatisfyAll(
+							HaveNoAnswer(),
+							HaveResponseType(expectedType),
+							HaveReturnCode(dns.RcodeSuccess),
+						))
+			},
+			Entry("subdomain of wildcard", "host.corp.example.com.", AAAA, true),
+			Entry("deeper subdomain of wildcard", "a.host.corp.example.com.", AAAA, true),
+			Entry("parent of wildcard", "corp.example.com.", AAAA, false),
+			Entry("other domain with same suffix", "othercorp.example.com.", AAAA, false),
+			Entry("exact domain, case insensitive", "broken.example.ORG.", AAAA, true),
+			Entry("subdomain of exact domain", "www.broken.example.org.", AAAA, false),
+			Entry("other query type of matching domain", "host.corp.example.com.", A, false),
+			Entry("unscoped query type", "example.com.", MX, true),
+			Entry("rule query type of other domain", "example.com.", AAAA, false),
+		)
+
+		It("should be enabled", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
 	When("No filtering query types are defined", func() {
 		BeforeEach(func() {
 			sutConfig = config.FilteringConfig{}
@@ -2,8 +2,11 @@ package resolver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"slices"
+	"sync"
 
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/lists"
@@ -31,15 +34,31 @@ type HostsFileResolver struct {
 	typed
 
 	hosts      splitHostsFileData
+	hostsLock  sync.RWMutex
 	downloader lists.FileDownloader
+
+	// entries of each source, a failed source keeps the entries of its last successful load
+	sourceEntries [][]*HostsFileEntry
+	refreshLock   sync.Mutex
+}
+
+// hostsSourceEntry is an entry of the source with the index `source`
+type hostsSourceEntry struct {
+	source int
+	entry  *HostsFileEntry
 }
 
-func NewHostsFileResolver(cfg config.HostsFileConfig, bootstrap *Bootstrap) (*HostsFileResolver, error) {
+// NewHostsFileResolver creates new resolver instance, changed hosts files are watched until ctx is done
+func NewHostsFileResolver(
+	ctx context.Context, cfg config.HostsFileConfig, bootstrap *Bootstrap,
+) (*HostsFileResolver, error) {
 	r := HostsFileResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("hosts_file"),
 
 		downloader: lists.NewDownloader(cfg.Loading.Downloads, bootstrap.NewHTTPTransport()),
+
+		sourceEntries: make([][]*HostsFileEntry, len(cfg.Sources)),
 	}
 
 	err := cfg.Loading.StartPeriodicRefresh(r.loadSources, func(err error) {
@@ -49,6 +68,17 @@ func NewHostsFileResolver(cfg config.HostsFileConfig, bootstrap *Bootstrap) (*Ho
 		return nil, err
 	}
 
+	if cfg.Loading.WatchFiles {
+		err := lists.WatchSources(ctx, cfg.Sources, lists.WatchDebounce, func(only []int) {
+			if err := r.refreshSources(context.Background(), only); err != nil {
+				r.log().WithError(err).Errorf("could not refresh changed hosts files")
+			}
+		})
+		if err != nil {
+			r.log().WithError(err).Warn("can't watch hosts files, changes are picked up by the periodic refresh")
+		}
+	}
+
 	return &r, nil
 }
 
@@ -56,7 +86,7 @@ func NewHostsFileResolver(cfg config.HostsFileConfig, bootstrap *Bootstrap) (*Ho
 func (r *HostsFileResolver) LogConfig(logger *logrus.Entry) {
 	r.cfg.LogConfig(logger)
 
-	logger.Infof("cache entries = %d", r.hosts.len())
+	logger.Infof("cache entries = %d", r.currentHosts().len())
 }
 
 func (r *HostsFileResolver) handleReverseDNS(request *model.Request) *model.Response {
@@ -77,9 +107,11 @@ func (r *HostsFileResolver) handleReverseDNS(request *model.Request) *model.Resp
 	}
 
 	// search only in the hosts with an IP version that matches the question
-	hostsData := r.hosts.v4
+	hosts := r.currentHosts()
+
+	hostsData := hosts.v4
 	if questionIP.To4() == nil {
-		hostsData = r.hosts.v6
+		hostsData = hosts.v6
 	}
 
 	for host, hostData := range hostsData.hosts {
@@ -89,7 +121,7 @@ func (r *HostsFileResolver) handleReverseDNS(request *model.Request) *model.Resp
 
 			ptr := new(dns.PTR)
 			ptr.Ptr = dns.Fqdn(host)
-			ptr.Hdr = util.CreateHeader(question, r.cfg.HostsTTL.SecondsU32())
+			ptr.Hdr = util.CreateHeader(question, hostData.TTL)
 			response.Answer = append(response.Answer, ptr)
 
 			for _, alias := range hostData.Aliases {
@@ -135,12 +167,12 @@ func (r *HostsFileResolver) Resolve(request *model.Request) (*model.Response, er
 }
 
 func (r *HostsFileResolver) resolve(req *dns.Msg, question dns.Question, domain string) *dns.Msg {
-	ip := r.hosts.getIP(dns.Type(question.Qtype), domain)
+	ip, ttl := r.currentHosts().getIP(dns.Type(question.Qtype), domain)
 	if ip == nil {
 		return nil
 	}
 
-	rr, _ := util.CreateAnswerFromQuestion(question, ip, r.cfg.HostsTTL.SecondsU32())
+	rr, _ := util.CreateAnswerFromQuestion(question, ip, ttl)
 
 	response := new(dns.Msg)
 	response.SetReply(req)
@@ -149,11 +181,22 @@ func (r *HostsFileResolver) resolve(req *dns.Msg, question dns.Question, domain
 	return response
 }
 
+// loadSources loads all sources and merges their entries
 func (r *HostsFileResolver) loadSources(ctx context.Context) error {
+	return r.refreshSources(ctx, nil)
+}
+
+// refreshSources loads the sources with the indexes in `only`, or all sources if it is nil, and merges their entries
+// with the entries of the other sources. A failed source is reported and keeps the entries of its last successful
+// load, so it doesn't wipe the entries of the other sources. An error is only returned if all loaded sources failed.
+func (r *HostsFileResolver) refreshSources(ctx context.Context, only []int) error {
 	if !r.IsEnabled() {
 		return nil
 	}
 
+	r.refreshLock.Lock()
+	defer r.refreshLock.Unlock()
+
 	r.log().Debug("loading hosts files")
 
 	//nolint:ineffassign,staticcheck,wastedassign // keep `ctx :=` so if we use ctx in the future, we use the correct one
@@ -163,34 +206,51 @@ func (r *HostsFileResolver) loadSources(ctx context.Context) error {
 	producersGrp := jobgroup.WithMaxConcurrency(consumersGrp, r.cfg.Loading.Concurrency)
 	defer producersGrp.Close()
 
-	producers := parcour.NewProducersWithBuffer[*HostsFileEntry](producersGrp, consumersGrp, producersBuffCap)
+	producers := parcour.NewProducersWithBuffer[hostsSourceEntry](producersGrp, consumersGrp, producersBuffCap)
 	defer producers.Close()
 
+	sourceErrs := make([]error, len(r.cfg.Sources))
+
+	newEntries := make([][]*HostsFileEntry, len(r.cfg.Sources))
+	loaded := 0
+
 	for i, source := range r.cfg.Sources {
 		i, source := i, source
 
-		producers.GoProduce(func(ctx context.Context, hostsChan chan<- *HostsFileEntry) error {
+		if only != nil && !slices.Contains(only, i) {
+			// keep the entries of the source
+			newEntries[i] = r.sourceEntries[i]
+
+			continue
+		}
+
+		loaded++
+
+		producers.GoProduce(func(ctx context.Context, hostsChan chan<- hostsSourceEntry) error {
 			locInfo := fmt.Sprintf("item #%d", i)
 
 			opener, err := lists.NewSourceOpener(locInfo, source, r.downloader)
-			if err != nil {
-				return err
+			if err == nil {
+				err = r.parseFile(ctx, opener, i, hostsChan)
+				if err != nil {
+					err = fmt.Errorf("error parsing %s: %w", opener, err) // err is parsers.ErrTooManyErrors
+				}
 			}
 
-			err = r.parseFile(ctx, opener, hostsChan)
-			if err != nil {
-				return fmt.Errorf("error parsing %s: %w", opener, err) // err is parsers.ErrTooManyErrors
+			if err != nil && !errors.Is(err, context.Canceled) {
+				// don't cancel the other sources, the failed sources are checked once all are loaded
+				sourceErrs[i] = err
+
+				return nil
 			}
 
-			return nil
+			return err
 		})
 	}
 
-	newHosts := newSplitHostsDataWithSameCapacity(r.hosts)
-
-	producers.GoConsume(func(ctx context.Context, ch <-chan *HostsFileEntry) error {
+	producers.GoConsume(func(ctx context.Context, ch <-chan hostsSourceEntry) error {
 		for entry := range ch {
-			newHosts.add(entry)
+			newEntries[entry.source] = append(newEntries[entry.source], entry.entry)
 		}
 
 		return nil
@@ -201,13 +261,68 @@ func (r *HostsFileResolver) loadSources(ctx context.Context) error {
 		return err
 	}
 
+	failed := 0
+
+	for i, err := range sourceErrs {
+		if err == nil {
+			continue
+		}
+
+		failed++
+
+		r.log().WithError(err).WithField("source", r.cfg.Sources[i].String()).
+			Warnf("could not load hosts file, keeping %d entries of the last successful load", len(r.sourceEntries[i]))
+
+		newEntries[i] = r.sourceEntries[i]
+	}
+
+	if loaded > 0 && failed == loaded {
+		return fmt.Errorf("all %d loaded hosts file sources failed, first error: %w", failed, firstError(sourceErrs))
+	}
+
+	newHosts := newSplitHostsDataWithSameCapacity(r.hosts)
+
+	for i, entries := range newEntries {
+		ttl := r.cfg.HostsTTL
+		if r.cfg.Sources[i].TTL != nil {
+			ttl = *r.cfg.Sources[i].TTL
+		}
+
+		for _, entry := range entries {
+			newHosts.add(entry, ttl.SecondsU32())
+		}
+	}
+
+	r.sourceEntries = newEntries
+
+	r.hostsLock.Lock()
+	defer r.hostsLock.Unlock()
+
 	r.hosts = newHosts
 
 	return nil
 }
 
+// currentHosts returns the hosts of the last refresh, which are replaced but never modified by a refresh
+func (r *HostsFileResolver) currentHosts() splitHostsFileData {
+	r.hostsLock.RLock()
+	defer r.hostsLock.RUnlock()
+
+	return r.hosts
+}
+
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *HostsFileResolver) parseFile(
-	ctx context.Context, opener lists.SourceOpener, hostsChan chan<- *HostsFileEntry,
+	ctx context.Context, opener lists.SourceOpener, source int, hostsChan chan<- hostsSourceEntry,
 ) error {
 	reader, err := opener.Open()
 	if err != nil {
@@ -232,7 +347,7 @@ func (r *HostsFileResolver) parseFile(
 			return nil
 		}
 
-		hostsChan <- entry
+		hostsChan <- hostsSourceEntry{source: source, entry: entry}
 
 		return nil
 	})
@@ -263,7 +378,8 @@ func (d splitHostsFileData) len() int {
 	return d.v4.len() + d.v6.len()
 }
 
-func (d splitHostsFileData) getIP(qType dns.Type, domain string) net.IP {
+// getIP returns the IP of the domain and the TTL of its source
+func (d splitHostsFileData) getIP(qType dns.Type, domain string) (net.IP, uint32) {
 	switch uint16(qType) {
 	case dns.TypeA:
 		return d.v4.getIP(domain)
@@ -271,31 +387,37 @@ func (d splitHostsFileData) getIP(qType dns.Type, domain string) net.IP {
 		return d.v6.getIP(domain)
 	}
 
-	return nil
+	return nil, 0
 }
 
-func (d splitHostsFileData) add(entry *parsers.HostsFileEntry) {
+func (d splitHostsFileData) add(entry *parsers.HostsFileEntry, ttl uint32) {
 	if entry.IP.To4() != nil {
-		d.v4.add(entry)
+		d.v4.add(entry, ttl)
 	} else {
-		d.v6.add(entry)
+		d.v6.add(entry, ttl)
 	}
 }
 
 type hostsFileData struct {
 	hosts   map[string]hostData
-	aliases map[string]net.IP
+	aliases map[string]aliasData
 }
 
 type hostData struct {
 	IP      net.IP
 	Aliases []string
+	TTL     uint32
+}
+
+type aliasData struct {
+	IP  net.IP
+	TTL uint32
 }
 
 func newHostsDataWithSameCapacity(other hostsFileData) hostsFileData {
 	return hostsFileData{
 		hosts:   make(map[string]hostData, len(other.hosts)/memReleaseFactor),
-		aliases: make(map[string]net.IP, len(other.aliases)/memReleaseFactor),
+		aliases: make(map[string]aliasData, len(other.aliases)/memReleaseFactor),
 	}
 }
 
@@ -303,22 +425,22 @@ func (d hostsFileData) len() int {
 	return len(d.hosts) + len(d.aliases)
 }
 
-func (d hostsFileData) getIP(hostname string) net.IP {
+func (d hostsFileData) getIP(hostname string) (net.IP, uint32) {
 	if hostData, ok := d.hosts[hostname]; ok {
-		return hostData.IP
+		return hostData.IP, hostData.TTL
 	}
 
-	if ip, ok := d.aliases[hostname]; ok {
-		return ip
+	if alias, ok := d.aliases[hostname]; ok {
+		return alias.IP, alias.TTL
 	}
 
-	return nil
+	return nil, 0
 }
 
-func (d hostsFileData) add(entry *parsers.HostsFileEntry) {
-	d.hosts[entry.Name] = hostData{entry.IP, entry.Aliases}
+func (d hostsFileData) add(entry *parsers.HostsFileEntry, ttl uint32) {
+	d.hosts[entry.Name] = hostData{entry.IP, entry.Aliases, ttl}
 
 	for _, alias := range entry.Aliases {
-		d.aliases[alias] = entry.IP
+		d.aliases[alias] = aliasData{entry.IP, ttl}
 	}
 }
@@ -2,6 +2,7 @@ package resolver
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
@@ -23,8 +24,16 @@ var _ = Describe("HostsFileResolver", func() {
 		m         *mockResolver
 		tmpDir    *TmpFolder
 		tmpFile   *TmpFile
+		sutCtx    context.Context
 	)
 
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+
+		sutCtx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
 	Describe("Type", func() {
 		It("follows conventions", func() {
 			expectValidResolverType(sut)
@@ -53,7 +62,7 @@ var _ = Describe("HostsFileResolver", func() {
 	JustBeforeEach(func() {
 		var err error
 
-		sut, err = NewHostsFileResolver(sutConfig, systemResolverBootstrap)
+		sut, err = NewHostsFileResolver(sutCtx, sutConfig, systemResolverBootstrap)
 		Expect(err).Should(Succeed())
 
 		m = &mockResolver{}
@@ -178,6 +187,99 @@ var _ = Describe("HostsFileResolver", func() {
 			})
 		})
 
+		When("multiple sources are defined", func() {
+			var secondFile *TmpFile
+
+			BeforeEach(func() {
+				secondFile = tmpDir.CreateStringFile("hosts2.txt", "192.168.2.10 second")
+				Expect(secondFile.Error).Should(Succeed())
+
+				server := TestServer("192.168.2.20 router-dhcp")
+				DeferCleanup(server.Close)
+
+				sutConfig.Sources = config.NewBytesSources(tmpFile.Path, secondFile.Path, server.URL)
+			})
+
+			It("should merge the entries of all sources", func() {
+				Expect(sut.hosts.v4.hosts).Should(HaveLen(7))
+				Expect(sut.Resolve(newRequest("second.", A))).
+					Should(BeDNSRecord("second.", A, "192.168.2.10"))
+				Expect(sut.Resolve(newRequest("router-dhcp.", A))).
+					Should(BeDNSRecord("router-dhcp.", A, "192.168.2.20"))
+				Expect(sut.Resolve(newRequest("ipv4host.", A))).
+					Should(BeDNSRecord("ipv4host.", A, "192.168.2.1"))
+			})
+
+			It("should keep the entries of a source failing on refresh", func() {
+				Expect(os.Remove(secondFile.Path)).Should(Succeed())
+
+				Expect(sut.loadSources(context.Background())).Should(Succeed())
+
+				Expect(sut.Resolve(newRequest("second.", A))).
+					Should(BeDNSRecord("second.", A, "192.168.2.10"))
+				Expect(sut.Resolve(newRequest("router-dhcp.", A))).
+					Should(BeDNSRecord("router-dhcp.", A, "192.168.2.20"))
+			})
+
+			When("a source has a TTL", func() {
+				BeforeEach(func() {
+					ttl := config.Duration(30 * time.Second)
+					sutConfig.Sources[1].TTL = &ttl
+				})
+
+				It("should answer its entries with the TTL", func() {
+					Expect(sut.Resolve(newRequest("second.", A))).Should(HaveTTL(BeNumerically("==", 30)))
+					Expect(sut.Resolve(newRequest("10.2.168.192.in-addr.arpa.", PTR))).
+						Should(SatisfyAll(
+							BeDNSRecord("10.2.168.192.in-addr.arpa.", PTR, "second."),
+							HaveTTL(BeNumerically("==", 30)),
+						))
+					Expect(sut.Resolve(newRequest("ipv4host.", A))).Should(HaveTTL(BeNumerically("==", TTL)))
+					Expect(sut.Resolve(newRequest("router-dhcp.", A))).Should(HaveTTL(BeNumerically("==", TTL)))
+				})
+			})
+
+			When("files are watched", func() {
+				BeforeEach(func() {
+					sutConfig.Loading.WatchFiles = true
+				})
+
+				It("should refresh a changed file", func() {
+					Expect(os.WriteFile(secondFile.Path, []byte("192.168.2.11 second-changed"), 0o600)).Should(Succeed())
+
+					Eventually(func() (*Response, error) {
+						return sut.Resolve(newRequest("second-changed.", A))
+					}, "3s").Should(BeDNSRecord("second-changed.", A, "192.168.2.11"))
+
+					Expect(sut.Resolve(newRequest("ipv4host.", A))).
+						Should(BeDNSRecord("ipv4host.", A, "192.168.2.1"))
+				})
+			})
+
+			When("a source can't be loaded initially", func() {
+				BeforeEach(func() {
+					sutConfig.Sources = append(sutConfig.Sources, config.NewBytesSources("/this/file/does/not/exist")...)
+				})
+
+				It("should use the other sources", func() {
+					Expect(sut.hosts.v4.hosts).Should(HaveLen(7))
+					Expect(sut.Resolve(newRequest("second.", A))).
+						Should(BeDNSRecord("second.", A, "192.168.2.10"))
+				})
+			})
+
+			When("all sources fail", func() {
+				It("should return an error and keep the entries", func() {
+					sut.cfg.Sources = config.NewBytesSources("/this/file/does/not/exist", "/neither/does/this")
+					sut.sourceEntries = make([][]*HostsFileEntry, len(sut.cfg.Sources))
+
+					Expect(sut.loadSources(context.Background())).
+						Should(MatchError(ContainSubstring("all 2 loaded hosts file sources failed")))
+					Expect(sut.hosts.v4.hosts).Should(HaveLen(7))
+				})
+			})
+		})
+
 		When("IPv4 mapping is defined for a host", func() {
 			It("defined ipv4 query should be resolved", func() {
 				Expect(sut.Resolve(newRequest("ipv4host.", A))).
@@ -57,16 +57,20 @@ func (r *MetricsResolver) Resolve(request *model.Request) (*model.Response, erro
 	return response, err
 }
 
-// NewMetricsResolver creates a new intance of the MetricsResolver type
-func NewMetricsResolver(cfg config.MetricsConfig) *MetricsResolver {
+// NewMetricsResolver creates a new intance of the MetricsResolver type.
+// All metrics get a "profile" label with this value: the registry only accepts the metrics of several
+// resolver chains, if their label names are equal. An empty label is dropped by prometheus.
+func NewMetricsResolver(cfg config.MetricsConfig, profile string) *MetricsResolver {
+	constLabels := prometheus.Labels{"profile": profile}
+
 	m := MetricsResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("metrics"),
 
-		durationHistogram: durationHistogram(),
-		totalQueries:      totalQueriesMetric(),
-		totalResponse:     totalResponseMetric(),
-		totalErrors:       totalErrorMetric(),
+		durationHistogram: durationHistogram(constLabels),
+		totalQueries:      totalQueriesMetric(constLabels),
+		totalResponse:     totalResponseMetric(constLabels),
+		totalErrors:       totalErrorMetric(constLabels),
 	}
 
 	m.registerMetrics()
@@ -81,40 +85,44 @@ func (r *MetricsResolver) registerMetrics() {
 	metrics.RegisterMetric(r.totalErrors)
 }
 
-func totalQueriesMetric() *prometheus.CounterVec {
+func totalQueriesMetric(constLabels prometheus.Labels) *prometheus.CounterVec {
 	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "blocky_query_total",
-			Help: "Number of total queries",
+			Name:        "blocky_query_total",
+			Help:        "Number of total queries",
+			ConstLabels: constLabels,
 		}, []string{"client", "type"},
 	)
 }
 
-func totalErrorMetric() prometheus.Counter {
+func totalErrorMetric(constLabels prometheus.Labels) prometheus.Counter {
 	return prometheus.NewCounter(
 		prometheus.CounterOpts{
-			Name: "blocky_error_total",
-			Help: "Number of total errors",
+			Name:        "blocky_error_total",
+			Help:        "Number of total errors",
+			ConstLabels: constLabels,
 		},
 	)
 }
 
-func durationHistogram() *prometheus.HistogramVec {
+func durationHistogram(constLabels prometheus.Labels) *prometheus.HistogramVec {
 	return prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "blocky_request_duration_ms",
-			Help:    "Request duration distribution",
-			Buckets: []float64{5, 10, 20, 30, 50, 75, 100, 200, 500, 1000, 2000},
+			Name:        "blocky_request_duration_ms",
+			Help:        "Request duration distribution",
+			Buckets:     []float64{5, 10, 20, 30, 50, 75, 100, 200, 500, 1000, 2000},
+			ConstLabels: constLabels,
 		},
 		[]string{"response_type"},
 	)
 }
 
-func totalResponseMetric() *prometheus.CounterVec {
+func totalResponseMetric(constLabels prometheus.Labels) *prometheus.CounterVec {
 	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "blocky_response_total",
-			Help: "Number of total responses",
+			Name:        "blocky_response_total",
+			Help:        "Number of total responses",
+			ConstLabels: constLabels,
 		}, []string{"reason", "response_code", "response_type"},
 	)
 }
@@ -2,13 +2,18 @@ package resolver
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
 
 	. "github.com/0xERR0R/blocky/helpertest"
 	. "github.com/0xERR0R/blocky/model"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -30,7 +35,7 @@ var _ = Describe("MetricResolver", func() {
 	})
 
 	BeforeEach(func() {
-		sut = NewMetricsResolver(config.MetricsConfig{Enable: true})
+		sut = NewMetricsResolver(config.MetricsConfig{Enable: true}, "")
 		m = &mockResolver{}
 		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
 		sut.Next(m)
@@ -84,6 +89,43 @@ var _ = Describe("MetricResolver", func() {
 					Expect(testutil.ToFloat64(sut.totalErrors)).Should(BeNumerically("==", 1))
 				})
 			})
+			When("a profile is set", func() {
+				BeforeEach(func() {
+					sut = NewMetricsResolver(config.MetricsConfig{Enable: true}, "lab")
+					sut.Next(m)
+				})
+				It("should add the profile label", func() {
+					_, err := sut.Resolve(newRequestWithClient("example.com.", A, "", "client"))
+					Expect(err).Should(Succeed())
+
+					Expect(testutil.CollectAndCount(sut.totalQueries)).Should(Equal(1))
+					Expect(testutil.CollectAndCompare(sut.totalErrors, strings.NewReader(`
+# HELP blocky_error_total Number of total errors
+# TYPE blocky_error_total counter
+blocky_error_total{profile="lab"} 0
+`))).Should(Succeed())
+				})
+				It("should export the metrics of all profiles", func() {
+					router := chi.NewRouter()
+					metrics.Start(router, config.MetricsConfig{Enable: true, Path: "/metrics"})
+
+					main := NewMetricsResolver(config.MetricsConfig{Enable: true}, "")
+					main.Next(m)
+
+					_, err := main.Resolve(newRequestWithClient("example.com.", A, "", "client"))
+					Expect(err).Should(Succeed())
+
+					_, err = sut.Resolve(newRequestWithClient("example.com.", A, "", "client"))
+					Expect(err).Should(Succeed())
+
+					rec := httptest.NewRecorder()
+					router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+					Expect(rec.Body.String()).Should(ContainSubstring(
+						`blocky_query_total{client="client",profile="lab",type="A"} 1`,
+					))
+				})
+			})
 		})
 	})
 })
@@ -0,0 +1,97 @@
+package resolver
+
+import (
+	"encoding/hex"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// NsidResolver adds the name server identifier (RFC 5001) to responses if the client asks for it
+type NsidResolver struct {
+	configurable[*config.NSIDConfig]
+	NextResolver
+	typed
+
+	nsid string
+}
+
+func NewNsidResolver(cfg config.NSIDConfig) *NsidResolver {
+	identifier := cfg.Identifier
+	if identifier == "" {
+		identifier = util.HostnameString()
+	}
+
+	return &NsidResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("nsid"),
+
+		nsid: hex.EncodeToString([]byte(identifier)),
+	}
+}
+
+func (r *NsidResolver) Resolve(request *model.Request) (*model.Response, error) {
+	if !r.cfg.Enable {
+		return r.next.Resolve(request)
+	}
+
+	// the NSID is answered by blocky: upstreams shouldn't see the request and their identifier mustn't be cached
+	requested := removeNsidOption(request.Req)
+
+	resp, err := r.next.Resolve(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if requested {
+		r.addNsid(request.Req, resp)
+	}
+
+	return resp, nil
+}
+
+func (r *NsidResolver) addNsid(req *dns.Msg, resp *model.Response) {
+	// the message may be shared with the cache
+	resp.Res = resp.Res.Copy()
+
+	opt := resp.Res.IsEdns0()
+	if opt == nil {
+		reqOpt := req.IsEdns0()
+
+		resp.Res.SetEdns0(reqOpt.UDPSize(), reqOpt.Do())
+
+		opt = resp.Res.IsEdns0()
+	}
+
+	opt.Option = append(opt.Option, &dns.EDNS0_NSID{
+		Code: dns.EDNS0NSID,
+		Nsid: r.nsid,
+	})
+}
+
+// removeNsidOption removes the NSID option from the request and returns true if it was present
+func removeNsidOption(req *dns.Msg) bool {
+	opt := req.IsEdns0()
+	if opt == nil {
+		return false
+	}
+
+	found := false
+	options := make([]dns.EDNS0, 0, len(opt.Option))
+
+	for _, o := range opt.Option {
+		if o.Option() == dns.EDNS0NSID {
+			found = true
+
+			continue
+		}
+
+		options = append(options, o)
+	}
+
+	opt.Option = options
+
+	return found
+}
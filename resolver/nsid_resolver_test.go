@@ -0,0 +1,179 @@
+package resolver
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/util"
+
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("NsidResolver", func() {
+	var (
+		sut        *NsidResolver
+		sutConfig  config.NSIDConfig
+		m          *mockResolver
+		mockAnswer *dns.Msg
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		sutConfig = config.NSIDConfig{Enable: true, Identifier: "blocky-1"}
+
+		mockAnswer = new(dns.Msg)
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{
+			Res:    mockAnswer,
+			RType:  ResponseTypeRESOLVED,
+			Reason: "Test",
+		}, nil)
+	})
+
+	JustBeforeEach(func() {
+		sut = NewNsidResolver(sutConfig)
+		sut.Next(m)
+	})
+
+	newNsidRequest := func() *Request {
+		req := newRequest("example.com.", A)
+		req.Req.SetEdns0(dns.DefaultMsgSize, false)
+
+		opt := req.Req.IsEdns0()
+		opt.Option = append(opt.Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID})
+
+		return req
+	}
+
+	nsidOf := func(msg *dns.Msg) []string {
+		var res []string
+
+		if opt := msg.IsEdns0(); opt != nil {
+			for _, o := range opt.Option {
+				if nsid, ok := o.(*dns.EDNS0_NSID); ok {
+					res = append(res, nsid.Nsid)
+				}
+			}
+		}
+
+		return res
+	}
+
+	When("nsid is disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Enable = false
+		})
+
+		It("shouldn't add the NSID", func() {
+			resp, err := sut.Resolve(newNsidRequest())
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Extra).Should(BeEmpty())
+
+			// delegated to next resolver
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+
+		Describe("IsEnabled", func() {
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+		})
+	})
+
+	When("nsid is enabled", func() {
+		It("should add the NSID if the client asks for it", func() {
+			resp, err := sut.Resolve(newNsidRequest())
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(nsidOf(resp.Res)).Should(ConsistOf(hex.EncodeToString([]byte("blocky-1"))))
+		})
+
+		It("shouldn't add the NSID if the client doesn't ask for it", func() {
+			resp, err := sut.Resolve(newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Extra).Should(BeEmpty())
+		})
+
+		It("shouldn't forward the NSID option to the next resolver", func() {
+			_, err := sut.Resolve(newNsidRequest())
+			Expect(err).Should(Succeed())
+
+			Expect(m.Calls).Should(HaveLen(1))
+
+			forwarded := m.Calls[0].Arguments.Get(0).(*Request)
+			Expect(nsidOf(forwarded.Req)).Should(BeEmpty())
+		})
+
+		It("should add the NSID to an existing OPT record", func() {
+			mockAnswer.SetEdns0(dns.DefaultMsgSize, false)
+
+			resp, err := sut.Resolve(newNsidRequest())
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Extra).Should(HaveLen(1))
+			Expect(nsidOf(resp.Res)).Should(HaveLen(1))
+		})
+
+		It("shouldn't modify the response of the next resolver", func() {
+			_, err := sut.Resolve(newNsidRequest())
+			Expect(err).Should(Succeed())
+
+			Expect(mockAnswer.Extra).Should(BeEmpty())
+		})
+
+		When("no identifier is configured", func() {
+			BeforeEach(func() {
+				sutConfig.Identifier = ""
+			})
+
+			It("should use the hostname", func() {
+				resp, err := sut.Resolve(newNsidRequest())
+				Expect(err).Should(Succeed())
+
+				Expect(nsidOf(resp.Res)).Should(ConsistOf(hex.EncodeToString([]byte(util.HostnameString()))))
+			})
+		})
+
+		When("resolver returns an error", func() {
+			resolveErr := errors.New("test")
+
+			BeforeEach(func() {
+				m = &mockResolver{}
+				m.On("Resolve", mock.Anything).Return(nil, resolveErr)
+			})
+
+			It("should return it", func() {
+				resp, err := sut.Resolve(newNsidRequest())
+				Expect(resp).To(BeNil())
+				Expect(err).To(Equal(resolveErr))
+			})
+		})
+
+		Describe("LogConfig", func() {
+			It("should log something", func() {
+				logger, hook := log.NewMockEntry()
+
+				sut.LogConfig(logger)
+
+				Expect(hook.Calls).ShouldNot(BeEmpty())
+			})
+		})
+	})
+})
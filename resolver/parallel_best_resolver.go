@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/util"
@@ -31,19 +34,27 @@ type ParallelBestResolver struct {
 	typed
 
 	resolversPerClient map[string][]*upstreamResolverStatus
+
+	// number of upstream queries (goroutines) in flight, including the ones which lost the race
+	inFlight atomic.Int64
 }
 
 type upstreamResolverStatus struct {
-	resolver      Resolver
-	lastErrorTime atomic.Value
+	resolver Resolver
+	// lastErrorTime is a util.MonotonicTime, zero if the upstream didn't fail yet
+	lastErrorTime atomic.Int64
+	quality       config.UpstreamResponseQuality
+	rcodes        rcodeStats
+	verification  atomic.Pointer[upstreamVerification]
 }
 
-func newUpstreamResolverStatus(resolver Resolver) *upstreamResolverStatus {
+func newUpstreamResolverStatus(resolver Resolver, quality config.UpstreamResponseQuality) *upstreamResolverStatus {
 	status := &upstreamResolverStatus{
 		resolver: resolver,
+		quality:  quality,
 	}
 
-	status.lastErrorTime.Store(time.Unix(0, 0))
+	status.setVerification(api.UpstreamVerificationActive, 0, nil)
 
 	return status
 }
@@ -52,7 +63,11 @@ func (r *upstreamResolverStatus) resolve(req *model.Request, ch chan<- requestRe
 	resp, err := r.resolver.Resolve(req)
 	if err != nil && !errors.Is(err, context.Canceled) { // ignore `Canceled`: resolver lost the race, not an error
 		// update the last error time
-		r.lastErrorTime.Store(time.Now())
+		r.lastErrorTime.Store(int64(util.MonotonicNow()))
+	}
+
+	if err == nil {
+		r.rcodes.record(resp.Res.Rcode, r.quality.Window.ToDuration())
 	}
 
 	ch <- requestResponse{
@@ -62,65 +77,121 @@ func (r *upstreamResolverStatus) resolve(req *model.Request, ch chan<- requestRe
 	}
 }
 
-type requestResponse struct {
-	resolver *Resolver
-	response *model.Response
-	err      error
+// sinceLastError returns the elapsed time since the last error of the upstream, false if it didn't fail yet
+func (r *upstreamResolverStatus) sinceLastError() (time.Duration, bool) {
+	lastErrorTime := util.MonotonicTime(r.lastErrorTime.Load())
+	if lastErrorTime.IsZero() {
+		return 0, false
+	}
+
+	return util.MonotonicSince(lastErrorTime), true
 }
 
-// testResolver sends a test query to verify the resolver is reachable and working
-func testResolver(r *UpstreamResolver) error {
-	request := newRequest("github.com.", dns.Type(dns.TypeA))
+// responseQualityFactor returns a factor (0, 1] to reduce the weight of an upstream,
+// if its SERVFAIL or REFUSED rate exceeds the configured threshold
+func (r *upstreamResolverStatus) responseQualityFactor() float64 {
+	servFailRate, refusedRate := r.rcodes.rates(r.quality.MinQueries, r.quality.Window.ToDuration())
+
+	return ratePenalty(servFailRate, r.quality.ServFailThreshold) * ratePenalty(refusedRate, r.quality.RefusedThreshold)
+}
 
-	resp, err := r.Resolve(request)
-	if err != nil || resp.RType != model.ResponseTypeRESOLVED {
-		return fmt.Errorf("test resolve of upstream server failed: %w", err)
+func ratePenalty(rate, threshold float64) float64 {
+	if threshold <= 0 || rate <= threshold {
+		return 1
 	}
 
-	return nil
+	return threshold / rate
 }
 
-// NewParallelBestResolver creates new resolver instance
-func NewParallelBestResolver(
-	cfg config.UpstreamsConfig, bootstrap *Bootstrap, shouldVerifyUpstreams bool,
-) (*ParallelBestResolver, error) {
-	logger := log.PrefixedLog(parallelResolverType)
+// rcodeStats counts the responses of an upstream by return code.
+// The counts are halved after each window, so recent responses have more influence on the rates.
+type rcodeStats struct {
+	lock        sync.Mutex
+	windowStart util.MonotonicTime
+	total       float64
+	servFail    float64
+	refused     float64
+}
 
-	upstreamResolvers := cfg.Groups
-	resolverGroups := make(map[string][]Resolver, len(upstreamResolvers))
+func (s *rcodeStats) record(rcode int, window time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	for name, upstreamCfgs := range upstreamResolvers {
-		group := make([]Resolver, 0, len(upstreamCfgs))
-		hasValidResolver := false
+	s.decay(window)
 
-		for _, u := range upstreamCfgs {
-			resolver, err := NewUpstreamResolver(u, bootstrap, shouldVerifyUpstreams)
-			if err != nil {
-				logger.Warnf("upstream group %s: %v", name, err)
+	s.total++
 
-				continue
-			}
+	switch rcode {
+	case dns.RcodeServerFailure:
+		s.servFail++
+	case dns.RcodeRefused:
+		s.refused++
+	}
+}
 
-			if shouldVerifyUpstreams {
-				err = testResolver(resolver)
-				if err != nil {
-					logger.Warn(err)
-				} else {
-					hasValidResolver = true
-				}
-			}
+// rates returns the SERVFAIL and REFUSED rates, or 0 if there are less than minQueries responses
+func (s *rcodeStats) rates(minQueries uint, window time.Duration) (servFail, refused float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-			group = append(group, resolver)
-		}
+	s.decay(window)
+
+	if s.total == 0 || s.total < float64(minQueries) {
+		return 0, 0
+	}
+
+	return s.servFail / s.total, s.refused / s.total
+}
+
+func (s *rcodeStats) decay(window time.Duration) {
+	now := util.MonotonicNow()
+
+	if s.windowStart.IsZero() || window <= 0 {
+		s.windowStart = now
+
+		return
+	}
+
+	for elapsed := now.Sub(s.windowStart); elapsed >= window; elapsed -= window {
+		s.total /= 2
+		s.servFail /= 2
+		s.refused /= 2
+		s.windowStart = s.windowStart.Add(window)
 
-		if shouldVerifyUpstreams && !hasValidResolver {
-			return nil, fmt.Errorf("no valid upstream for group %s", name)
+		if s.total < 1 {
+			// nothing left to decay
+			s.total, s.servFail, s.refused = 0, 0, 0
+			s.windowStart = now
+
+			break
 		}
+	}
+}
+
+type requestResponse struct {
+	resolver *Resolver
+	response *model.Response
+	err      error
+}
+
+// NewParallelBestResolver creates new resolver instance
+func NewParallelBestResolver(
+	cfg config.UpstreamsConfig, bootstrap *Bootstrap, shouldVerifyUpstreams bool,
+) (*ParallelBestResolver, error) {
+	if err := cfg.ValidateEncryption(); err != nil {
+		return nil, err
+	}
+
+	r := newParallelBestResolver(cfg, createUpstreamGroups(cfg, bootstrap))
 
-		resolverGroups[name] = group
+	if shouldVerifyUpstreams {
+		err := verifyUpstreamGroups(cfg, r.resolversPerClient, log.PrefixedLog(parallelResolverType))
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return newParallelBestResolver(cfg, resolverGroups), nil
+	return r, nil
 }
 
 func newParallelBestResolver(
@@ -132,7 +203,7 @@ func newParallelBestResolver(
 		resolverStatuses := make([]*upstreamResolverStatus, 0, len(resolvers))
 
 		for _, r := range resolvers {
-			resolverStatuses = append(resolverStatuses, newUpstreamResolverStatus(r))
+			resolverStatuses = append(resolverStatuses, newUpstreamResolverStatus(r, cfg.ResponseQuality))
 		}
 
 		resolversPerClient[groupName] = resolverStatuses
@@ -167,56 +238,156 @@ func (r *ParallelBestResolver) String() string {
 	return fmt.Sprintf("parallel upstreams '%s'", strings.Join(result, "; "))
 }
 
+// upstreamStatus implements `upstreamStatusProvider`.
+func (r *ParallelBestResolver) upstreamStatus() []api.UpstreamStatus {
+	return upstreamGroupStatus(r.resolversPerClient)
+}
+
+// resetConnections implements `connectionResetter`.
+func (r *ParallelBestResolver) resetConnections() {
+	for _, resolvers := range r.resolversPerClient {
+		for _, status := range resolvers {
+			ResetConnections(status.resolver)
+		}
+	}
+}
+
 // Resolve sends the query request to multiple upstream resolvers and returns the fastest result
 func (r *ParallelBestResolver) Resolve(request *model.Request) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, parallelResolverType)
 
 	var resolvers []*upstreamResolverStatus
 	for _, r := range r.resolversPerClient {
-		resolvers = r
+		resolvers = activeResolvers(r)
 
 		break
 	}
 
+	if len(resolvers) == 0 {
+		return nil, errNoActiveUpstream
+	}
+
 	if len(resolvers) == 1 {
 		logger.WithField("resolver", resolvers[0].resolver).Debug("delegating to resolver")
 
 		return resolvers[0].resolver.Resolve(request)
 	}
 
+	if !r.acquireInFlight() {
+		return r.resolveSingle(logger, request, resolvers)
+	}
+
 	r1, r2 := pickRandom(resolvers)
 	logger.Debugf("using %s and %s as resolver", r1.resolver, r2.resolver)
 
+	// buffered for all resolvers: the slower one can always send its result and exit,
+	// even if this function already returned
 	ch := make(chan requestResponse, resolverCount)
 
-	var collectedErrors []error
+	var (
+		collectedErrors []error
+		servFailResult  *requestResponse
+		servFailWait    <-chan time.Time
+	)
 
 	logger.WithField("resolver", r1.resolver).Debug("delegating to resolver")
 
-	go r1.resolve(request, ch)
+	go r.race(r1, request, ch)
 
 	logger.WithField("resolver", r2.resolver).Debug("delegating to resolver")
 
-	go r2.resolve(request, ch)
+	go r.race(r2, request, ch)
 
-	for len(collectedErrors) < resolverCount {
-		result := <-ch
+	for i := 0; i < resolverCount; i++ {
+		var result requestResponse
+
+		select {
+		case result = <-ch:
+		case <-servFailWait:
+			logger.Debug("no other response in time, using SERVFAIL response")
+
+			return useResponse(logger, servFailResult), nil
+		}
 
 		if result.err != nil {
 			logger.Debug("resolution failed from resolver, cause: ", result.err)
 			collectedErrors = append(collectedErrors, result.err)
-		} else {
-			logger.WithFields(logrus.Fields{
-				"resolver": *result.resolver,
-				"answer":   util.AnswerToString(result.response.Res.Answer),
-			}).Debug("using response from resolver")
 
-			return result.response, nil
+			continue
 		}
+
+		if result.response.Res.Rcode == dns.RcodeServerFailure && servFailResult == nil &&
+			r.cfg.ResponseQuality.ServFailWait.IsAboveZero() {
+			// first response is SERVFAIL: give the other resolver a chance to return a better response
+			logger.WithField("resolver", *result.resolver).Debug("received SERVFAIL, waiting for other resolver")
+
+			servFailResult = &result
+			servFailWait = time.After(r.cfg.ResponseQuality.ServFailWait.ToDuration())
+
+			continue
+		}
+
+		return useResponse(logger, &result), nil
+	}
+
+	if servFailResult != nil {
+		// other resolver failed
+		return useResponse(logger, servFailResult), nil
+	}
+
+	return nil, fmt.Errorf("%w, used resolvers: '%s' and '%s' errors: %w", errResolutionFailed,
+		r1.resolver, r2.resolver, upstreamErrors(collectedErrors))
+}
+
+// acquireInFlight reserves the upstream queries of a race, returns false if the limit is reached
+func (r *ParallelBestResolver) acquireInFlight() bool {
+	limit := int64(r.cfg.MaxParallelQueries)
+	inFlight := r.inFlight.Add(resolverCount)
+
+	if limit > 0 && inFlight > limit {
+		r.inFlight.Add(-resolverCount)
+
+		return false
+	}
+
+	return true
+}
+
+func (r *ParallelBestResolver) race(status *upstreamResolverStatus, request *model.Request, ch chan<- requestResponse) {
+	defer r.inFlight.Add(-1)
+
+	status.resolve(request, ch)
+}
+
+// resolveSingle resolves the request with one upstream in the current goroutine
+func (r *ParallelBestResolver) resolveSingle(
+	logger *logrus.Entry, request *model.Request, resolvers []*upstreamResolverStatus,
+) (*model.Response, error) {
+	status := weightedRandom(resolvers, nil)
+
+	logger.WithField("resolver", status.resolver).Debug("max parallel queries reached, delegating to resolver")
+
+	evt.Bus().Publish(evt.UpstreamParallelLimitReached)
+
+	ch := make(chan requestResponse, 1)
+	status.resolve(request, ch)
+
+	result := <-ch
+	if result.err != nil {
+		return nil, fmt.Errorf("%w, used resolver: '%s' error: %w", errResolutionFailed,
+			status.resolver, result.err)
 	}
 
-	return nil, fmt.Errorf("resolution was not successful, used resolvers: '%s' and '%s' errors: %v",
-		r1.resolver, r2.resolver, collectedErrors)
+	return useResponse(logger, &result), nil
+}
+
+func useResponse(logger *logrus.Entry, result *requestResponse) *model.Response {
+	logger.WithFields(logrus.Fields{
+		"resolver": *result.resolver,
+		"answer":   util.AnswerToString(result.response.Res.Answer),
+	}).Debug("using response from resolver")
+
+	return result.response
 }
 
 // pick 2 different random resolvers from the resolver pool
@@ -239,12 +410,14 @@ func weightedRandom(in []*upstreamResolverStatus, exclude Resolver) *upstreamRes
 
 		var weight float64 = errorWindowInSec
 
-		if time.Since(res.lastErrorTime.Load().(time.Time)) < time.Hour {
+		if sinceLastError, failed := res.sinceLastError(); failed && sinceLastError < time.Hour {
 			// reduce weight: consider last error time
-			lastErrorTime := res.lastErrorTime.Load().(time.Time)
-			weight = math.Max(1, weight-(errorWindowInSec-time.Since(lastErrorTime).Minutes()))
+			weight = math.Max(1, weight-(errorWindowInSec-sinceLastError.Minutes()))
 		}
 
+		// reduce weight: consider SERVFAIL and REFUSED rates
+		weight = math.Max(1, weight*res.responseQualityFactor())
+
 		choices = append(choices, weightedrand.NewChoice(res, uint(weight)))
 	}
 
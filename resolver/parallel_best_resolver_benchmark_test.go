@@ -0,0 +1,75 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+)
+
+// latencyResolver answers all queries after a fixed delay
+type latencyResolver struct {
+	NoOpResolver
+
+	latency time.Duration
+	answer  *dns.Msg
+}
+
+func (r *latencyResolver) Resolve(*model.Request) (*model.Response, error) {
+	time.Sleep(r.latency)
+
+	return &model.Response{Res: r.answer, RType: model.ResponseTypeRESOLVED}, nil
+}
+
+func BenchmarkParallelBestResolverParallel(b *testing.B) {
+	// unlimited is the behavior without limit: each query starts 2 goroutines
+	b.Run("unlimited", func(b *testing.B) {
+		benchmarkParallelBestResolverParallel(b, 0)
+	})
+
+	b.Run("default limit", func(b *testing.B) {
+		cfg, err := config.WithDefaults[config.UpstreamsConfig]()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		benchmarkParallelBestResolverParallel(b, cfg.MaxParallelQueries)
+	})
+}
+
+func benchmarkParallelBestResolverParallel(b *testing.B, maxParallelQueries uint) {
+	const concurrency = 1000
+
+	answer, err := util.NewMsgWithAnswer("example.com.", 300, dns.Type(dns.TypeA), "1.2.3.4")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	sut := newParallelBestResolver(
+		config.UpstreamsConfig{MaxParallelQueries: maxParallelQueries},
+		map[string][]Resolver{upstreamDefaultCfgName: {
+			&latencyResolver{latency: time.Millisecond, answer: answer},
+			&latencyResolver{latency: 2 * time.Millisecond, answer: answer},
+		}},
+	)
+
+	request := newRequest("example.com.", dns.Type(dns.TypeA))
+
+	b.SetParallelism(concurrency)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := sut.Resolve(request); err != nil {
+				b.Error(err)
+
+				return
+			}
+		}
+	})
+}
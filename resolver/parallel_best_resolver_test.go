@@ -1,10 +1,15 @@
 package resolver
 
 import (
+	"errors"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
+	. "github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
@@ -12,6 +17,8 @@ import (
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/goleak"
 )
 
 var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
@@ -23,6 +30,7 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 	var (
 		sut        *ParallelBestResolver
 		sutMapping config.UpstreamGroups
+		sutQuality config.UpstreamResponseQuality
 		sutVerify  bool
 
 		err error
@@ -48,6 +56,8 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 			},
 		}
 
+		sutQuality = config.UpstreamResponseQuality{}
+
 		sutVerify = noVerifyUpstreams
 
 		bootstrap = systemResolverBootstrap
@@ -55,8 +65,9 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 
 	JustBeforeEach(func() {
 		sutConfig := config.UpstreamsConfig{
-			Timeout: config.Duration(1000 * time.Millisecond),
-			Groups:  sutMapping,
+			Timeout:         config.Duration(1000 * time.Millisecond),
+			Groups:          sutMapping,
+			ResponseQuality: sutQuality,
 		}
 
 		sut, err = NewParallelBestResolver(sutConfig, bootstrap, sutVerify)
@@ -86,26 +97,48 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 
 	When("some default upstream resolvers cannot be reached", func() {
 		It("should start normally", func() {
-			mockUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+			mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 				response, _ = util.NewMsgWithAnswer(request.Question[0].Name, 123, A, "123.124.122.122")
 
 				return
 			})
 			defer mockUpstream.Close()
 
+			mockUpstreamCfg := mockUpstream.Start()
+
 			upstream := config.UpstreamGroups{
 				upstreamDefaultCfgName: {
 					config.Upstream{
 						Host: "wrong",
 					},
-					mockUpstream.Start(),
+					mockUpstreamCfg,
 				},
 			}
 
-			_, err := NewParallelBestResolver(config.UpstreamsConfig{
+			r, err := NewParallelBestResolver(config.UpstreamsConfig{
 				Groups: upstream,
 			}, systemResolverBootstrap, verifyUpstreams)
 			Expect(err).Should(Not(HaveOccurred()))
+
+			By("marking the unreachable upstream as pending", func() {
+				Expect(r.upstreamStatus()).Should(ConsistOf(
+					SatisfyAll(
+						HaveField("Upstream", "tcp+udp:wrong:0"),
+						HaveField("State", api.UpstreamVerificationPending),
+						HaveField("FailedAttempts", BeEquivalentTo(1)),
+						HaveField("LastError", Not(BeEmpty())),
+					),
+					SatisfyAll(
+						HaveField("Upstream", mockUpstreamCfg.String()),
+						HaveField("State", api.UpstreamVerificationActive),
+					),
+				))
+			})
+
+			By("using the verified upstream only", func() {
+				Expect(r.Resolve(newRequest("example.com.", A))).
+					Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+			})
 		})
 	})
 
@@ -134,6 +167,21 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 			})
 		})
 
+		When("strict checking is enabled and empty groups are allowed", func() {
+			It("should start, but fail to resolve", func() {
+				r, err := NewParallelBestResolver(config.UpstreamsConfig{
+					Groups:            sutMapping,
+					AllowEmptyOnStart: true,
+				}, bootstrap, verifyUpstreams)
+				Expect(err).Should(Succeed())
+
+				Expect(r.upstreamStatus()).Should(HaveEach(HaveField("State", api.UpstreamVerificationPending)))
+
+				_, err = r.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(MatchError(errNoActiveUpstream))
+			})
+		})
+
 		When("strict checking is disabled", func() {
 			BeforeEach(func() {
 				sutVerify = noVerifyUpstreams
@@ -148,10 +196,10 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 		When("2 Upstream resolvers are defined", func() {
 			When("one resolver is fast and another is slow", func() {
 				BeforeEach(func() {
-					fastTestUpstream := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+					fastTestUpstream := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 					DeferCleanup(fastTestUpstream.Close)
 
-					slowTestUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+					slowTestUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 						response, err := util.NewMsgWithAnswer("example.com.", 123, A, "123.124.122.123")
 						time.Sleep(50 * time.Millisecond)
 
@@ -180,7 +228,7 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 			When("one resolver is slow, but another returns an error", func() {
 				BeforeEach(func() {
 					withErrorUpstream := config.Upstream{Host: "wrong"}
-					slowTestUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+					slowTestUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 						response, err := util.NewMsgWithAnswer("example.com.", 123, A, "123.124.122.123")
 						time.Sleep(50 * time.Millisecond)
 
@@ -206,6 +254,73 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 							))
 				})
 			})
+			When("fast resolver returns SERVFAIL and slow resolver a valid response", func() {
+				BeforeEach(func() {
+					servFailUpstream := dnstest.NewMockUpstreamServer().WithAnswerError(dns.RcodeServerFailure)
+					DeferCleanup(servFailUpstream.Close)
+
+					slowTestUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+						response, err := util.NewMsgWithAnswer("example.com.", 123, A, "123.124.122.123")
+						time.Sleep(50 * time.Millisecond)
+
+						Expect(err).Should(Succeed())
+
+						return response
+					})
+					DeferCleanup(slowTestUpstream.Close)
+
+					sutMapping = config.UpstreamGroups{
+						upstreamDefaultCfgName: {servFailUpstream.Start(), slowTestUpstream.Start()},
+					}
+				})
+				When("SERVFAIL wait time is configured", func() {
+					BeforeEach(func() {
+						sutQuality.ServFailWait = config.Duration(time.Second)
+					})
+					It("Should wait for the valid response", func() {
+						request := newRequest("example.com.", A)
+						Expect(sut.Resolve(request)).
+							Should(
+								SatisfyAll(
+									BeDNSRecord("example.com.", A, "123.124.122.123"),
+									HaveResponseType(ResponseTypeRESOLVED),
+									HaveReturnCode(dns.RcodeSuccess),
+								))
+					})
+				})
+				When("SERVFAIL wait time is not configured", func() {
+					It("Should use the SERVFAIL response", func() {
+						request := newRequest("example.com.", A)
+						Expect(sut.Resolve(request)).
+							Should(
+								SatisfyAll(
+									HaveResponseType(ResponseTypeRESOLVED),
+									HaveReturnCode(dns.RcodeServerFailure),
+								))
+					})
+				})
+			})
+			When("one resolver returns SERVFAIL and another an error", func() {
+				BeforeEach(func() {
+					sutQuality.ServFailWait = config.Duration(time.Second)
+
+					servFailUpstream := dnstest.NewMockUpstreamServer().WithAnswerError(dns.RcodeServerFailure)
+					DeferCleanup(servFailUpstream.Close)
+
+					sutMapping = config.UpstreamGroups{
+						upstreamDefaultCfgName: {servFailUpstream.Start(), {Host: "wrong"}},
+					}
+				})
+				It("Should use the SERVFAIL response", func() {
+					request := newRequest("example.com.", A)
+					Expect(sut.Resolve(request)).
+						Should(
+							SatisfyAll(
+								HaveResponseType(ResponseTypeRESOLVED),
+								HaveReturnCode(dns.RcodeServerFailure),
+							))
+				})
+			})
 			When("all resolvers return errors", func() {
 				BeforeEach(func() {
 					withError1 := config.Upstream{Host: "wrong"}
@@ -220,13 +335,17 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 					request := newRequest("example.com.", A)
 					_, err = sut.Resolve(request)
 
-					Expect(err).Should(HaveOccurred())
+					Expect(err).Should(MatchError(errResolutionFailed))
+
+					var upstreamErrs upstreamErrors
+					Expect(errors.As(err, &upstreamErrs)).Should(BeTrue())
+					Expect(upstreamErrs).Should(HaveLen(2))
 				})
 			})
 		})
 		When("only 1 upstream resolvers is defined", func() {
 			BeforeEach(func() {
-				mockUpstream := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 				DeferCleanup(mockUpstream.Close)
 
 				sutMapping = config.UpstreamGroups{
@@ -256,10 +375,10 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 				withError1 := config.Upstream{Host: "wrong1"}
 				withError2 := config.Upstream{Host: "wrong2"}
 
-				mockUpstream1 := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+				mockUpstream1 := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 				DeferCleanup(mockUpstream1.Close)
 
-				mockUpstream2 := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+				mockUpstream2 := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 				DeferCleanup(mockUpstream2.Close)
 
 				sut, _ = NewParallelBestResolver(config.UpstreamsConfig{Groups: config.UpstreamGroups{
@@ -326,6 +445,220 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 		})
 	})
 
+	Describe("Last error time", func() {
+		var (
+			clock  *util.FakeClock
+			status *upstreamResolverStatus
+		)
+
+		BeforeEach(func() {
+			clock = util.NewFakeClock()
+			DeferCleanup(util.SetClock(clock))
+
+			upstream := &mockResolver{}
+			upstream.On("Resolve", mock.Anything).Return(nil, errors.New("timeout"))
+
+			status = newUpstreamResolverStatus(upstream, config.UpstreamResponseQuality{})
+		})
+
+		It("should be unset if the upstream didn't fail", func() {
+			_, failed := status.sinceLastError()
+			Expect(failed).Should(BeFalse())
+		})
+
+		It("should measure the elapsed time since the error, regardless of wall clock jumps", func() {
+			status.resolve(newRequest("example.com.", A), make(chan requestResponse, 1))
+
+			clock.JumpWallClock(5 * time.Hour)
+
+			sinceLastError, failed := status.sinceLastError()
+			Expect(failed).Should(BeTrue())
+			Expect(sinceLastError).Should(BeNumerically("<", time.Minute))
+
+			clock.Advance(2 * time.Hour)
+
+			sinceLastError, _ = status.sinceLastError()
+			Expect(sinceLastError).Should(BeNumerically("~", 2*time.Hour, time.Minute))
+		})
+	})
+
+	Describe("Weighted random considering response quality", func() {
+		var quality config.UpstreamResponseQuality
+
+		BeforeEach(func() {
+			quality = config.UpstreamResponseQuality{
+				ServFailThreshold: 0.02,
+				RefusedThreshold:  0.02,
+				MinQueries:        20,
+				Window:            config.Duration(time.Hour),
+			}
+		})
+
+		recordResponses := func(status *upstreamResolverStatus, count, failures, rcode int) {
+			for i := 0; i < count; i++ {
+				if i < failures {
+					status.rcodes.record(rcode, quality.Window.ToDuration())
+				} else {
+					status.rcodes.record(dns.RcodeSuccess, quality.Window.ToDuration())
+				}
+			}
+		}
+
+		DescribeTable("upstream with high failure rate should be selected less often",
+			func(rcode int) {
+				good := newUpstreamResolverStatus(&mockResolver{}, quality)
+				bad := newUpstreamResolverStatus(&mockResolver{}, quality)
+
+				recordResponses(good, 100, 0, rcode)
+				// 10% failures -> weight is reduced to 20%
+				recordResponses(bad, 100, 10, rcode)
+
+				Expect(good.responseQualityFactor()).Should(BeNumerically("==", 1))
+				Expect(bad.responseQualityFactor()).Should(BeNumerically("~", 0.2, 0.001))
+
+				badCount := 0
+
+				for i := 0; i < 1000; i++ {
+					if weightedRandom([]*upstreamResolverStatus{good, bad}, nil) == bad {
+						badCount++
+					}
+				}
+
+				// weights 60 and 12 -> 1/6 of 1000
+				Expect(badCount).Should(BeNumerically("~", 167, 60))
+			},
+			Entry("SERVFAIL", dns.RcodeServerFailure),
+			Entry("REFUSED", dns.RcodeRefused),
+		)
+
+		It("should not consider the rate with less than min queries", func() {
+			status := newUpstreamResolverStatus(&mockResolver{}, quality)
+
+			recordResponses(status, 10, 10, dns.RcodeServerFailure)
+
+			Expect(status.responseQualityFactor()).Should(BeNumerically("==", 1))
+		})
+
+		It("should not consider the rate if threshold is disabled", func() {
+			quality.ServFailThreshold = 0
+			status := newUpstreamResolverStatus(&mockResolver{}, quality)
+
+			recordResponses(status, 100, 100, dns.RcodeServerFailure)
+
+			Expect(status.responseQualityFactor()).Should(BeNumerically("==", 1))
+		})
+
+		It("should halve the counts after each window", func() {
+			var stats rcodeStats
+
+			for i := 0; i < 40; i++ {
+				stats.record(dns.RcodeServerFailure, time.Hour)
+			}
+
+			stats.windowStart = stats.windowStart.Add(-2 * time.Hour)
+
+			servFail, _ := stats.rates(0, time.Hour)
+			Expect(servFail).Should(BeNumerically("==", 1))
+			Expect(stats.total).Should(BeNumerically("==", 10))
+		})
+	})
+
+	Describe("Goroutine usage", func() {
+		var (
+			fast, slow  *mockResolver
+			releaseSlow chan struct{}
+			limit       uint
+			// calls counts the calls of both resolvers, `mock.Calls` can't be read while the race is running
+			calls *atomic.Int32
+		)
+
+		BeforeEach(func() {
+			limit = 0
+			releaseSlow = make(chan struct{})
+			release := releaseSlow
+
+			cnt := new(atomic.Int32)
+			calls = cnt
+
+			fast = &mockResolver{}
+			fast.On("Resolve", mock.Anything).Return(nil, nil)
+			fast.ResponseFn = func(req *dns.Msg) *dns.Msg {
+				cnt.Add(1)
+
+				response, _ := util.NewMsgWithAnswer(req.Question[0].Name, 123, A, "123.124.122.122")
+
+				return response
+			}
+
+			slow = &mockResolver{}
+			slow.On("Resolve", mock.Anything).Return(nil, nil)
+			slow.ResponseFn = func(req *dns.Msg) *dns.Msg {
+				cnt.Add(1)
+
+				<-release
+
+				response, _ := util.NewMsgWithAnswer(req.Question[0].Name, 123, A, "1.1.1.1")
+
+				return response
+			}
+		})
+
+		JustBeforeEach(func() {
+			sut = newParallelBestResolver(config.UpstreamsConfig{MaxParallelQueries: limit},
+				map[string][]Resolver{upstreamDefaultCfgName: {fast, slow}})
+		})
+
+		It("should not leak the goroutine of the slower resolver", func() {
+			ignoreCurrent := goleak.IgnoreCurrent()
+
+			Expect(sut.Resolve(newRequest("example.com.", A))).
+				Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+
+			// slower resolver finishes after Resolve returned
+			close(releaseSlow)
+
+			// retries until all goroutines which were started in the meantime are finished
+			Expect(goleak.Find(ignoreCurrent)).Should(Succeed())
+			Expect(sut.inFlight.Load()).Should(BeZero())
+		})
+
+		When("max parallel queries is reached", func() {
+			BeforeEach(func() {
+				limit = 2
+			})
+
+			It("should resolve with a single upstream", func() {
+				limited := make(chan bool, 1)
+				Expect(Bus().SubscribeOnce(UpstreamParallelLimitReached, func() {
+					limited <- true
+				})).Should(Succeed())
+
+				// release the slow resolver in case it gets selected
+				close(releaseSlow)
+
+				// simulate a running race
+				sut.inFlight.Store(2)
+
+				Expect(sut.Resolve(newRequest("example.com.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				Expect(calls.Load()).Should(BeNumerically("==", 1))
+				Expect(limited).Should(Receive())
+				Expect(sut.inFlight.Load()).Should(BeNumerically("==", 2))
+			})
+
+			It("should race again if a query finished", func() {
+				close(releaseSlow)
+
+				Expect(sut.Resolve(newRequest("example.com.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				Eventually(calls.Load).Should(BeNumerically("==", 2))
+				Eventually(sut.inFlight.Load).Should(BeZero())
+			})
+		})
+	})
+
 	When("upstream is invalid", func() {
 		It("errors during construction", func() {
 			b := newTestBootstrap(&dns.Msg{MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure}})
@@ -338,4 +671,16 @@ var _ = Describe("ParallelBestResolver", Label("parallelBestResolver"), func() {
 			Expect(r).Should(BeNil())
 		})
 	})
+
+	When("group requires encryption", func() {
+		It("errors during construction for unencrypted upstreams", func() {
+			r, err := NewParallelBestResolver(config.UpstreamsConfig{
+				Groups:       config.UpstreamGroups{"test": {{Net: config.NetProtocolTcpUdp, Host: "1.1.1.1", Port: 53}}},
+				GroupOptions: map[string]config.UpstreamGroupOptions{"test": {RequireEncryption: true}},
+			}, systemResolverBootstrap, noVerifyUpstreams)
+
+			Expect(err).Should(MatchError(ContainSubstring("in group 'test' is not encrypted")))
+			Expect(r).Should(BeNil())
+		})
+	})
 })
@@ -147,12 +147,19 @@ func (r *QueryLoggingResolver) createLogEntry(request *model.Request, response *
 			entry.ResponseReason = response.Reason
 			entry.ResponseType = response.RType.String()
 			entry.ResponseCode = dns.RcodeToString[response.Res.Rcode]
+			entry.BlockOverride = response.BlockOverride
 
 		case config.QueryLogFieldResponseAnswer:
-			entry.Answer = util.AnswerToString(response.Res.Answer)
+			format := r.answerFormat()
+			entry.Answer = util.FormatAnswer(response.Res.Answer, format)
+
+			if format.IsShortened() {
+				entry.FullAnswer = util.AnswerToString(response.Res.Answer)
+			}
 
 		case config.QueryLogFieldQuestion:
-			entry.QuestionName = request.Req.Question[0].Name
+			entry.QuestionName = util.ToUnicode(request.Req.Question[0].Name)
+			entry.QuestionNamePunycode = request.Req.Question[0].Name
 			entry.QuestionType = dns.TypeToString[request.Req.Question[0].Qtype]
 
 		case config.QueryLogFieldDuration:
@@ -163,6 +170,15 @@ func (r *QueryLoggingResolver) createLogEntry(request *model.Request, response *
 	return &entry
 }
 
+func (r *QueryLoggingResolver) answerFormat() util.AnswerFormat {
+	return util.AnswerFormat{
+		MaxRecords:   r.cfg.Answer.MaxRecords,
+		MaxLength:    r.cfg.Answer.MaxLength,
+		OmitPayloads: r.cfg.Answer.OmitPayloads,
+		Compact:      r.cfg.Answer.Compact,
+	}
+}
+
 // write entry: if log directory is configured, write to log file
 func (r *QueryLoggingResolver) writeLog() {
 	for logEntry := range r.logChan {
@@ -278,6 +278,48 @@ var _ = Describe("QueryLoggingResolver", func() {
 				})
 			})
 		})
+
+		When("the answer format is configured", func() {
+			BeforeEach(func() {
+				sutConfig = config.QueryLogConfig{
+					Type:             config.QueryLogTypeNone,
+					CreationAttempts: 1,
+					CreationCooldown: config.Duration(time.Millisecond),
+					Fields:           []config.QueryLogField{config.QueryLogFieldResponseAnswer},
+					Answer:           config.QueryLogAnswer{MaxRecords: 1},
+				}
+
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.122.121.120")
+				mockAnswer.Answer = append(mockAnswer.Answer, mockAnswer.Answer[0], mockAnswer.Answer[0])
+			})
+
+			It("should shorten the answer and keep the complete answer", func() {
+				entry := sut.createLogEntry(newRequest("example.com.", A), &Response{Res: mockAnswer}, time.Now(), 1)
+
+				Expect(entry.Answer).Should(Equal("A (123.122.121.120), …+2 more"))
+				Expect(entry.FullAnswer).Should(Equal(
+					"A (123.122.121.120), A (123.122.121.120), A (123.122.121.120)"))
+			})
+		})
+
+		When("the response reason is logged", func() {
+			BeforeEach(func() {
+				sutConfig = config.QueryLogConfig{
+					Type:             config.QueryLogTypeNone,
+					CreationAttempts: 1,
+					CreationCooldown: config.Duration(time.Millisecond),
+					Fields:           []config.QueryLogField{config.QueryLogFieldResponseReason},
+				}
+			})
+
+			It("should log the block override", func() {
+				entry := sut.createLogEntry(newRequest("example.com.", A),
+					&Response{Res: mockAnswer, Reason: "RESOLVED", BlockOverride: "NOT ASSIGNED (ads)"}, time.Now(), 1)
+
+				Expect(entry.ResponseReason).Should(Equal("RESOLVED"))
+				Expect(entry.BlockOverride).Should(Equal("NOT ASSIGNED (ads)"))
+			})
+		})
 	})
 
 	Describe("Slow writer", func() {
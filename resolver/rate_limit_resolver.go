@@ -0,0 +1,183 @@
+package resolver
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+const (
+	// rateLimitOtherClients is the metrics label of the limited clients above `maxMetricsClients`
+	rateLimitOtherClients = "other"
+
+	// rateLimitMinSweepInterval is the minimal interval to remove the buckets of idle clients
+	rateLimitMinSweepInterval = time.Minute
+)
+
+var errRateLimited = errors.New("query dropped, rate limit exceeded")
+
+// IsRateLimited returns true if the query must not be answered, since the client exceeded the rate limit
+func IsRateLimited(err error) bool {
+	return errors.Is(err, errRateLimited)
+}
+
+// RateLimitResolver limits the queries per client IP with a token bucket:
+// a client may send `burst` queries at once, its bucket refills with `rate` queries per second.
+// The queries over the limit are refused or dropped.
+// Clients with a valid DNS cookie can't spoof their address, so they aren't limited.
+//
+// A full bucket is the same as no bucket, so the buckets of idle clients are removed once they're full again.
+type RateLimitResolver struct {
+	configurable[*config.RateLimitConfig]
+	NextResolver
+	typed
+
+	exempt []*net.IPNet
+	burst  float64
+	// refill is the time to refill an empty bucket
+	refill time.Duration
+
+	lock           sync.Mutex
+	buckets        map[string]*rateLimitBucket
+	lastSweep      util.MonotonicTime
+	metricsClients map[string]struct{}
+}
+
+type rateLimitBucket struct {
+	tokens float64
+	last   util.MonotonicTime
+}
+
+// NewRateLimitResolver creates a new instance of the RateLimitResolver type
+func NewRateLimitResolver(cfg config.RateLimitConfig) (*RateLimitResolver, error) {
+	exempt, err := cfg.ExemptNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RateLimitResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("rate_limit"),
+
+		exempt: exempt,
+		burst:  float64(cfg.BurstSize()),
+
+		buckets:        make(map[string]*rateLimitBucket),
+		lastSweep:      util.MonotonicNow(),
+		metricsClients: make(map[string]struct{}),
+	}
+
+	if cfg.IsEnabled() {
+		r.refill = time.Duration(r.burst / float64(cfg.Rate) * float64(time.Second))
+	}
+
+	return r, nil
+}
+
+// Resolve passes the query to the next resolver, if the client is within its rate limit
+func (r *RateLimitResolver) Resolve(request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() || request.ValidCookie || r.isExempt(request.ClientIP) {
+		return r.next.Resolve(request)
+	}
+
+	client := request.ClientIP.String()
+
+	allowed, metricsLabel := r.take(client)
+	if allowed {
+		return r.next.Resolve(request)
+	}
+
+	evt.Bus().Publish(evt.RateLimitQueryLimited, metricsLabel)
+
+	log.WithPrefix(request.Log, "rate_limit_resolver").
+		Debugf("client %s exceeded the rate limit, action: %s", client, r.cfg.Action)
+
+	if r.cfg.Action == config.RateLimitActionDrop {
+		return nil, errRateLimited
+	}
+
+	return newResponse(request, dns.RcodeRefused, model.ResponseTypeSPECIAL, "RATE LIMITED"), nil
+}
+
+// isExempt returns true if the client is never limited.
+// Queries without client IP are blocky's own, e.g. from the API
+func (r *RateLimitResolver) isExempt(ip net.IP) bool {
+	if ip == nil || (r.cfg.ExemptLocalhost && ip.IsLoopback()) {
+		return true
+	}
+
+	for _, network := range r.exempt {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// take takes a token of the client's bucket and returns true if there was one.
+// If not, the metrics label of the client is returned as well
+func (r *RateLimitResolver) take(client string) (allowed bool, metricsLabel string) {
+	now := util.MonotonicNow()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.sweep(now)
+
+	bucket, ok := r.buckets[client]
+	if !ok {
+		bucket = &rateLimitBucket{tokens: r.burst, last: now}
+		r.buckets[client] = bucket
+	}
+
+	bucket.tokens = min(r.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*float64(r.cfg.Rate))
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+
+		return true, ""
+	}
+
+	return false, r.metricsLabel(client)
+}
+
+// sweep removes the buckets, which are full again, must be called with the lock held
+func (r *RateLimitResolver) sweep(now util.MonotonicTime) {
+	if now.Sub(r.lastSweep) < max(r.refill, rateLimitMinSweepInterval) {
+		return
+	}
+
+	r.lastSweep = now
+
+	for client, bucket := range r.buckets {
+		if now.Sub(bucket.last) >= r.refill {
+			delete(r.buckets, client)
+		}
+	}
+}
+
+// metricsLabel returns the label of the limited client: its IP for the first `maxMetricsClients` limited clients,
+// so the offenders are visible without an unbounded number of series. Must be called with the lock held
+func (r *RateLimitResolver) metricsLabel(client string) string {
+	if _, ok := r.metricsClients[client]; ok {
+		return client
+	}
+
+	if uint(len(r.metricsClients)) < r.cfg.MaxMetricsClients {
+		r.metricsClients[client] = struct{}{}
+
+		return client
+	}
+
+	return rateLimitOtherClients
+}
@@ -0,0 +1,236 @@
+package resolver
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("RateLimitResolver", func() {
+	var (
+		sut       *RateLimitResolver
+		sutConfig config.RateLimitConfig
+		m         *mockResolver
+		clock     *util.FakeClock
+
+		lock    sync.Mutex
+		limited map[string]int
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.RateLimitConfig]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Rate = 2
+		sutConfig.Burst = 4
+
+		clock = util.NewFakeClock()
+		DeferCleanup(util.SetClock(clock))
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{
+			Res:    new(dns.Msg),
+			RType:  ResponseTypeRESOLVED,
+			Reason: "Test",
+		}, nil)
+
+		limited = make(map[string]int)
+		limitedFn := func(client string) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			limited[client]++
+		}
+
+		Expect(evt.Bus().Subscribe(evt.RateLimitQueryLimited, limitedFn)).Should(Succeed())
+		DeferCleanup(func() {
+			Expect(evt.Bus().Unsubscribe(evt.RateLimitQueryLimited, limitedFn)).Should(Succeed())
+		})
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewRateLimitResolver(sutConfig)
+		Expect(err).Should(Succeed())
+
+		sut.Next(m)
+	})
+
+	resolve := func(ip string) (*Response, error) {
+		return sut.Resolve(newRequestWithClient("example.com.", A, ip))
+	}
+
+	// resolveN sends n queries of the client and returns how many were passed to the next resolver
+	resolveN := func(ip string, n int) int {
+		passed := 0
+
+		for i := 0; i < n; i++ {
+			resp, err := resolve(ip)
+			if err == nil && resp.Res.Rcode == dns.RcodeSuccess {
+				passed++
+			}
+		}
+
+		return passed
+	}
+
+	recorded := func() map[string]int {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return maps.Clone(limited)
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Rate = 0
+		})
+
+		It("should pass all queries", func() {
+			Expect(resolveN("192.168.178.25", 100)).Should(Equal(100))
+		})
+	})
+
+	It("should allow a burst and then refuse the queries over the rate", func() {
+		Expect(resolveN("192.168.178.25", 4)).Should(Equal(4))
+
+		resp, err := resolve("192.168.178.25")
+		Expect(err).Should(Succeed())
+		Expect(resp).Should(SatisfyAll(
+			HaveReturnCode(dns.RcodeRefused),
+			HaveResponseType(ResponseTypeSPECIAL),
+			HaveReason("RATE LIMITED"),
+		))
+
+		By("refilling the bucket with the rate", func() {
+			clock.Advance(time.Second)
+
+			Expect(resolveN("192.168.178.25", 4)).Should(Equal(2))
+		})
+
+		By("limiting each client on its own", func() {
+			Expect(resolveN("192.168.178.26", 4)).Should(Equal(4))
+		})
+
+		Eventually(recorded).Should(Equal(map[string]int{"192.168.178.25": 3}))
+	})
+
+	When("the action is drop", func() {
+		BeforeEach(func() {
+			sutConfig.Action = config.RateLimitActionDrop
+		})
+
+		It("should return an error, so the query isn't answered", func() {
+			Expect(resolveN("192.168.178.25", 4)).Should(Equal(4))
+
+			_, err := resolve("192.168.178.25")
+			Expect(IsRateLimited(err)).Should(BeTrue())
+		})
+	})
+
+	Describe("Exemptions", func() {
+		BeforeEach(func() {
+			sutConfig.Exempt = []string{"192.168.178.0/24", "fd00::1"}
+		})
+
+		It("should never limit exempt clients and localhost", func() {
+			for _, ip := range []string{"192.168.178.25", "fd00::1", "127.0.0.1", "::1"} {
+				Expect(resolveN(ip, 10)).Should(Equal(10), ip)
+			}
+
+			Expect(resolveN("10.0.0.1", 10)).Should(Equal(4))
+		})
+
+		It("should never limit clients with a valid cookie", func() {
+			for i := 0; i < 10; i++ {
+				request := newRequestWithClient("example.com.", A, "10.0.0.1")
+				request.ValidCookie = true
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+				Expect(resp.Res.Rcode).Should(Equal(dns.RcodeSuccess))
+			}
+
+			Expect(resolveN("10.0.0.1", 10)).Should(Equal(4))
+		})
+
+		When("localhost isn't exempt", func() {
+			BeforeEach(func() {
+				sutConfig.ExemptLocalhost = false
+			})
+
+			It("should limit localhost", func() {
+				Expect(resolveN("127.0.0.1", 10)).Should(Equal(4))
+			})
+		})
+	})
+
+	It("should remove the buckets of idle clients", func() {
+		for i := 0; i < 10; i++ {
+			resolveN(fmt.Sprintf("10.0.0.%d", i), 4)
+		}
+
+		Expect(sut.buckets).Should(HaveLen(10))
+
+		clock.Advance(rateLimitMinSweepInterval)
+		resolveN("10.0.0.1", 1)
+
+		Expect(sut.buckets).Should(HaveLen(1))
+	})
+
+	When("more clients are limited than labeled in the metrics", func() {
+		BeforeEach(func() {
+			sutConfig.MaxMetricsClients = 2
+		})
+
+		It("should label only the first limited clients", func() {
+			for i := 0; i < 4; i++ {
+				Expect(resolveN(fmt.Sprintf("10.0.0.%d", i), 5)).Should(Equal(4))
+			}
+
+			Eventually(recorded).Should(Equal(map[string]int{
+				"10.0.0.0":            1,
+				"10.0.0.1":            1,
+				rateLimitOtherClients: 2,
+			}))
+		})
+	})
+})
@@ -0,0 +1,116 @@
+package resolver
+
+import (
+	"net"
+	"sync"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// rebindProtectionOtherDomains is the metrics label of the filtered domains above `maxMetricsDomains`
+const rebindProtectionOtherDomains = "other"
+
+// RebindProtectionResolver protects the clients from DNS rebinding: upstream answers of public domains with private,
+// link-local or loopback addresses are replaced with NXDOMAIN or the addresses are removed.
+// It comes right before the upstreams in the chain, so local answers (custom DNS, hosts file, conditional upstreams)
+// are never filtered.
+type RebindProtectionResolver struct {
+	configurable[*config.RebindProtectionConfig]
+	NextResolver
+	typed
+
+	allowed domainMatcher
+
+	lock           sync.Mutex
+	metricsDomains map[string]struct{}
+}
+
+// NewRebindProtectionResolver creates a new instance of the RebindProtectionResolver type
+func NewRebindProtectionResolver(cfg config.RebindProtectionConfig) *RebindProtectionResolver {
+	return &RebindProtectionResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("rebind_protection"),
+
+		allowed:        newDomainMatcher(cfg.AllowedDomains),
+		metricsDomains: make(map[string]struct{}),
+	}
+}
+
+// Resolve filters the private addresses of the answer of the next resolver
+func (r *RebindProtectionResolver) Resolve(request *model.Request) (*model.Response, error) {
+	response, err := r.next.Resolve(request)
+	if err != nil || !r.IsEnabled() {
+		return response, err
+	}
+
+	domain := util.ExtractDomain(request.Req.Question[0])
+	if r.allowed.matches(domain) {
+		return response, nil
+	}
+
+	answer := make([]dns.RR, 0, len(response.Res.Answer))
+
+	for _, rr := range response.Res.Answer {
+		if !isRebindAddress(rr) {
+			answer = append(answer, rr)
+		}
+	}
+
+	filtered := len(response.Res.Answer) - len(answer)
+	if filtered == 0 {
+		return response, nil
+	}
+
+	evt.Bus().Publish(evt.RebindProtectionFiltered, r.metricsLabel(domain))
+
+	log.WithPrefix(request.Log, "rebind_protection_resolver").
+		Debugf("answer of %s has %d private addresses, action: %s", domain, filtered, r.cfg.Action)
+
+	if r.cfg.Action == config.RebindProtectionActionStrip {
+		response.Res.Answer = answer
+
+		return response, nil
+	}
+
+	return newResponse(request, dns.RcodeNameError, model.ResponseTypeBLOCKED, "REBIND PROTECTION"), nil
+}
+
+// isRebindAddress returns true if the record is an address, which must not be answered for public domains
+func isRebindAddress(rr dns.RR) bool {
+	var ip net.IP
+
+	switch v := rr.(type) {
+	case *dns.A:
+		ip = v.A
+	case *dns.AAAA:
+		ip = v.AAAA
+	default:
+		return false
+	}
+
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()
+}
+
+// metricsLabel returns the label of the filtered domain: the domain itself for the first `maxMetricsDomains`
+// filtered domains, so false positives are visible without an unbounded number of series
+func (r *RebindProtectionResolver) metricsLabel(domain string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.metricsDomains[domain]; ok {
+		return domain
+	}
+
+	if uint(len(r.metricsDomains)) < r.cfg.MaxMetricsDomains {
+		r.metricsDomains[domain] = struct{}{}
+
+		return domain
+	}
+
+	return rebindProtectionOtherDomains
+}
@@ -0,0 +1,198 @@
+package resolver
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("RebindProtectionResolver", func() {
+	var (
+		sut       *RebindProtectionResolver
+		sutConfig config.RebindProtectionConfig
+		m         *mockResolver
+
+		lock     sync.Mutex
+		filtered map[string]int
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.RebindProtectionConfig]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Enable = true
+		sutConfig.AllowedDomains = []string{"*.plex.direct", "router.example.com"}
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(request *Request) (*Response, error) {
+			question := request.Req.Question[0]
+
+			var ips []string
+
+			switch util.ExtractDomain(question) {
+			case "public.example.com":
+				ips = []string{"93.184.216.34", "2606:2800:220:1::1"}
+			case "mixed.example.com":
+				ips = []string{"93.184.216.34", "192.168.178.1", "fd00::1"}
+			default:
+				ips = []string{"10.0.0.1", "127.0.0.1", "169.254.1.1", "fe80::1", "::1"}
+			}
+
+			msg := new(dns.Msg)
+			msg.SetReply(request.Req)
+
+			for _, ip := range ips {
+				rType := A
+				if strings.Contains(ip, ":") {
+					rType = AAAA
+				}
+
+				rr, err := dns.NewRR(fmt.Sprintf("%s 300 IN %s %s", question.Name, rType, ip))
+				Expect(err).Should(Succeed())
+
+				msg.Answer = append(msg.Answer, rr)
+			}
+
+			return &Response{Res: msg, RType: ResponseTypeRESOLVED, Reason: "Test"}, nil
+		}
+
+		filtered = make(map[string]int)
+		filteredFn := func(domain string) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			filtered[domain]++
+		}
+
+		Expect(evt.Bus().Subscribe(evt.RebindProtectionFiltered, filteredFn)).Should(Succeed())
+		DeferCleanup(func() {
+			Expect(evt.Bus().Unsubscribe(evt.RebindProtectionFiltered, filteredFn)).Should(Succeed())
+		})
+	})
+
+	JustBeforeEach(func() {
+		sut = NewRebindProtectionResolver(sutConfig)
+		sut.Next(m)
+	})
+
+	recorded := func() map[string]int {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return maps.Clone(filtered)
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	It("should answer NXDOMAIN for public domains with private addresses", func() {
+		for _, domain := range []string{"rebind.attacker.com.", "mixed.example.com."} {
+			Expect(sut.Resolve(newRequest(domain, A))).Should(SatisfyAll(
+				HaveNoAnswer(),
+				HaveReturnCode(dns.RcodeNameError),
+				HaveResponseType(ResponseTypeBLOCKED),
+				HaveReason("REBIND PROTECTION"),
+			))
+		}
+
+		Eventually(recorded).Should(Equal(map[string]int{"rebind.attacker.com": 1, "mixed.example.com": 1}))
+	})
+
+	It("should keep answers with public addresses", func() {
+		resp, err := sut.Resolve(newRequest("public.example.com.", A))
+		Expect(err).Should(Succeed())
+		Expect(resp).Should(SatisfyAll(
+			HaveResponseType(ResponseTypeRESOLVED),
+			HaveReturnCode(dns.RcodeSuccess),
+		))
+		Expect(resp.Res.Answer).Should(HaveLen(2))
+	})
+
+	It("should keep answers of the allowed domains", func() {
+		for _, domain := range []string{"1-2-3-4.abc.plex.direct.", "router.example.com."} {
+			resp, err := sut.Resolve(newRequest(domain, A))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(resp.Res.Answer).Should(HaveLen(5))
+		}
+	})
+
+	When("the action is strip", func() {
+		BeforeEach(func() {
+			sutConfig.Action = config.RebindProtectionActionStrip
+		})
+
+		It("should remove only the private addresses", func() {
+			Expect(sut.Resolve(newRequest("mixed.example.com.", A))).Should(SatisfyAll(
+				BeDNSRecord("mixed.example.com.", A, "93.184.216.34"),
+				HaveResponseType(ResponseTypeRESOLVED),
+				HaveReturnCode(dns.RcodeSuccess),
+			))
+		})
+	})
+
+	When("disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Enable = false
+		})
+
+		It("should not filter", func() {
+			resp, err := sut.Resolve(newRequest("rebind.attacker.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(HaveLen(5))
+		})
+	})
+
+	When("more domains are filtered than labeled in the metrics", func() {
+		BeforeEach(func() {
+			sutConfig.MaxMetricsDomains = 1
+		})
+
+		It("should label only the first filtered domains", func() {
+			for i := 0; i < 3; i++ {
+				_, err := sut.Resolve(newRequest(fmt.Sprintf("rebind%d.attacker.com.", i), A))
+				Expect(err).Should(Succeed())
+			}
+
+			Eventually(recorded).Should(Equal(map[string]int{
+				"rebind0.attacker.com":       1,
+				rebindProtectionOtherDomains: 2,
+			}))
+		})
+	})
+})
@@ -122,12 +122,27 @@ func Chain(resolvers ...Resolver) ChainedResolver {
 	return resolvers[0].(ChainedResolver)
 }
 
+// branchResolver is implemented by resolvers which create a branch in the chain, like the rewriter
+type branchResolver interface {
+	// branch returns the first resolver of the branch
+	branch() Resolver
+}
+
+// GetFromChainWithType returns the first resolver of the chain with the type, resolvers in branches included
 func GetFromChainWithType[T any](resolver ChainedResolver) (result T, err error) {
 	for resolver != nil {
 		if result, found := resolver.(T); found {
 			return result, nil
 		}
 
+		if b, ok := resolver.(branchResolver); ok {
+			if inner, ok := b.branch().(ChainedResolver); ok {
+				if result, err := GetFromChainWithType[T](inner); err == nil {
+					return result, nil
+				}
+			}
+		}
+
 		if cr, ok := resolver.GetNext().(ChainedResolver); ok {
 			resolver = cr
 		} else {
@@ -163,6 +178,22 @@ func ForEach(resolver Resolver, callback func(Resolver)) {
 	}
 }
 
+// connectionResetter is implemented by resolvers that hold upstream connections or resolve upstreams.
+type connectionResetter interface {
+	// resetConnections drops open upstream connections so they get re-established on the next request.
+	resetConnections()
+}
+
+// ResetConnections resets the upstream connections of all resolvers in the chain,
+// including the ones nested in upstream groups and conditional mappings.
+func ResetConnections(resolver Resolver) {
+	ForEach(resolver, func(res Resolver) {
+		if resetter, ok := res.(connectionResetter); ok {
+			resetter.resetConnections()
+		}
+	})
+}
+
 // LogResolverConfig logs the resolver's type and config.
 func LogResolverConfig(res Resolver, logger *logrus.Entry) {
 	// Use the type, not the full typeName, to avoid redundant information with the config
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"strings"
 
 	"github.com/0xERR0R/blocky/config"
@@ -62,6 +63,19 @@ var _ = Describe("Resolver", func() {
 
 				Expect(err).Should(Not(Succeed()))
 			})
+			It("should return resolver in the branch of a rewriter", func() {
+				customDNS := &CustomDNSResolver{}
+				rewriter, err := NewRewriterResolver(config.RewriterConfig{
+					Rewrite: map[string]string{"example.com": "example.org"},
+				}, customDNS)
+				Expect(err).Should(Succeed())
+
+				ch := Chain(rewriter, &BlockingResolver{})
+
+				res, err := GetFromChainWithType[*CustomDNSResolver](ch)
+				Expect(err).Should(Succeed())
+				Expect(res).Should(BeIdenticalTo(customDNS))
+			})
 		})
 
 		Describe("ForEach", func() {
@@ -80,6 +94,31 @@ var _ = Describe("Resolver", func() {
 			})
 		})
 
+		Describe("ResetConnections", func() {
+			It("should reset resolvers nested in upstream groups", func() {
+				upstream1 := &resetCountingResolver{}
+				upstream2 := &resetCountingResolver{}
+
+				branches := map[string]Resolver{
+					upstreamDefaultCfgName: newParallelBestResolver(config.UpstreamsConfig{},
+						map[string][]Resolver{upstreamDefaultCfgName: {upstream1}}),
+					"other": newStrictResolver(config.UpstreamsConfig{},
+						map[string][]Resolver{upstreamDefaultCfgName: {upstream2}}),
+				}
+
+				tree, err := NewUpstreamTreeResolver(config.UpstreamsConfig{Groups: config.UpstreamGroups{
+					upstreamDefaultCfgName: {{Host: "1.1.1.1"}},
+					"other":                {{Host: "1.1.1.1"}},
+				}}, branches)
+				Expect(err).Should(Succeed())
+
+				ResetConnections(Chain(r1, tree))
+
+				Expect(upstream1.resets).Should(Equal(1))
+				Expect(upstream2.resets).Should(Equal(1))
+			})
+		})
+
 		Describe("LogResolverConfig", func() {
 			It("should call the resolver's `LogConfig`", func() {
 				logger := logrus.NewEntry(log.Log())
@@ -111,17 +150,22 @@ var _ = Describe("Resolver", func() {
 	Describe("Name", func() {
 		When("'Name' is called", func() {
 			It("should return resolver name", func() {
-				br, _ := NewBlockingResolver(config.BlockingConfig{BlockType: "zeroIP"}, nil, systemResolverBootstrap)
+				br, _ := NewBlockingResolver(
+					context.Background(), config.BlockingConfig{BlockType: "zeroIP"}, nil, systemResolverBootstrap, "",
+				)
 				name := Name(br)
 				Expect(name).Should(Equal("blocking"))
 			})
 		})
 		When("'Name' is called on a NamedResolver", func() {
 			It("should return its custom name", func() {
-				br, _ := NewBlockingResolver(config.BlockingConfig{BlockType: "zeroIP"}, nil, systemResolverBootstrap)
+				br, _ := NewBlockingResolver(
+					context.Background(), config.BlockingConfig{BlockType: "zeroIP"}, nil, systemResolverBootstrap, "",
+				)
 
 				cfg := config.RewriterConfig{Rewrite: map[string]string{"not": "empty"}}
-				r := NewRewriterResolver(cfg, br)
+				r, err := NewRewriterResolver(cfg, br)
+				Expect(err).Should(Succeed())
 
 				name := Name(r)
 				Expect(name).Should(Equal("blocking w/ rewrite"))
@@ -130,6 +174,16 @@ var _ = Describe("Resolver", func() {
 	})
 })
 
+type resetCountingResolver struct {
+	NoOpResolver
+
+	resets int
+}
+
+func (r *resetCountingResolver) resetConnections() {
+	r.resets++
+}
+
 func expectValidResolverType(sut Resolver) {
 	By("it must not contain spaces", func() {
 		Expect(sut.Type()).ShouldNot(ContainSubstring(" "))
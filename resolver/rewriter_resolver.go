@@ -22,27 +22,53 @@ type RewriterResolver struct {
 	NextResolver
 	typed
 
-	inner Resolver
+	inner      Resolver
+	regexRules []config.RewriteRegexRule
 }
 
-func NewRewriterResolver(cfg config.RewriterConfig, inner ChainedResolver) ChainedResolver {
+// rewrittenName is a rewritten question name, used to map the names of the response back
+type rewrittenName struct {
+	original  string
+	rewritten string
+
+	// suffix rule (`from` rewritten to `to`), empty for regex rules which can't be inverted
+	from, to string
+}
+
+func NewRewriterResolver(cfg config.RewriterConfig, inner ChainedResolver) (ChainedResolver, error) {
 	if len(cfg.Rewrite) == 0 {
-		return inner
+		return inner, nil
+	}
+
+	regexRules, err := cfg.RegexRules()
+	if err != nil {
+		return nil, err
 	}
 
-	// ensures that the rewrites map contains all rewrites in lower case
+	// ensures that the rewrites map contains all suffix rewrites in lower case, regex patterns are kept as defined
+	rewrite := make(map[string]string, len(cfg.Rewrite))
+
 	for k, v := range cfg.Rewrite {
-		cfg.Rewrite[strings.ToLower(k)] = strings.ToLower(v)
+		if config.IsRewriteRegex(k) {
+			rewrite[k] = v
+
+			continue
+		}
+
+		rewrite[strings.ToLower(k)] = strings.ToLower(v)
 	}
 
+	cfg.Rewrite = rewrite
+
 	inner.Next(NewNoOpResolver())
 
 	return &RewriterResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("rewrite"),
 
-		inner: inner,
-	}
+		inner:      inner,
+		regexRules: regexRules,
+	}, nil
 }
 
 func (r *RewriterResolver) Name() string {
@@ -56,13 +82,23 @@ func (r *RewriterResolver) LogConfig(logger *logrus.Entry) {
 	r.cfg.LogConfig(logger)
 }
 
+// branch implements `branchResolver`.
+func (r *RewriterResolver) branch() Resolver {
+	return r.inner
+}
+
+// resetConnections implements `connectionResetter`.
+func (r *RewriterResolver) resetConnections() {
+	ResetConnections(r.inner)
+}
+
 // Resolve uses the inner resolver to resolve the rewritten query
 func (r *RewriterResolver) Resolve(request *model.Request) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, "rewriter_resolver")
 
 	original := request.Req
 
-	rewritten, originalNames := r.rewriteRequest(logger, original)
+	rewritten, names := r.rewriteRequest(logger, original)
 	if rewritten != nil {
 		request.Req = rewritten
 	}
@@ -96,24 +132,51 @@ func (r *RewriterResolver) Resolve(request *model.Request) (*model.Response, err
 
 	// Revert the rewrite in r.inner's response
 	if rewritten != nil {
-		for i := range originalNames {
-			response.Res.Question[i].Name = originalNames[i]
+		revertResponse(response.Res, original, names)
+	}
 
-			if i < len(response.Res.Answer) {
-				response.Res.Answer[i].Header().Name = originalNames[i]
-			}
+	return response, nil
+}
+
+// revertResponse restores the question and maps the owner names and CNAME targets of the answer back,
+// so clients see the names they asked for
+func revertResponse(res *dns.Msg, original *dns.Msg, names []rewrittenName) {
+	for i := range res.Question {
+		if i < len(original.Question) {
+			res.Question[i].Name = original.Question[i].Name
 		}
 	}
 
-	return response, nil
+	for _, rr := range res.Answer {
+		rr.Header().Name = revertName(rr.Header().Name, names)
+
+		if cname, ok := rr.(*dns.CNAME); ok {
+			cname.Target = revertName(cname.Target, names)
+		}
+	}
 }
 
-func (r *RewriterResolver) rewriteRequest(logger *logrus.Entry, request *dns.Msg) (rewritten *dns.Msg, originalNames []string) { //nolint: lll
-	originalNames = make([]string, len(request.Question))
+func revertName(name string, names []rewrittenName) string {
+	lower := strings.ToLower(name)
+
+	for _, n := range names {
+		if lower == n.rewritten {
+			return n.original
+		}
 
+		if len(n.to) > 0 && strings.HasSuffix(lower, "."+n.to+".") {
+			return strings.TrimSuffix(lower, "."+n.to+".") + "." + n.from + "."
+		}
+	}
+
+	return name
+}
+
+func (r *RewriterResolver) rewriteRequest(
+	logger *logrus.Entry, request *dns.Msg,
+) (rewritten *dns.Msg, names []rewrittenName) {
 	for i := range request.Question {
 		nameOriginal := request.Question[i].Name
-		originalNames[i] = nameOriginal
 
 		domainOriginal := util.ExtractDomainOnly(nameOriginal)
 		domainRewritten, rewriteKey := r.rewriteDomain(domainOriginal)
@@ -125,6 +188,15 @@ func (r *RewriterResolver) rewriteRequest(logger *logrus.Entry, request *dns.Msg
 
 			rewritten.Question[i].Name = dns.Fqdn(domainRewritten)
 
+			name := rewrittenName{original: nameOriginal, rewritten: dns.Fqdn(domainRewritten)}
+
+			if !config.IsRewriteRegex(rewriteKey) {
+				name.from = rewriteKey
+				name.to = r.cfg.Rewrite[rewriteKey]
+			}
+
+			names = append(names, name)
+
 			logger.WithFields(logrus.Fields{
 				"domain":  domainOriginal,
 				"rewrite": rewriteKey + ":" + r.cfg.Rewrite[rewriteKey],
@@ -132,11 +204,16 @@ func (r *RewriterResolver) rewriteRequest(logger *logrus.Entry, request *dns.Msg
 		}
 	}
 
-	return rewritten, originalNames
+	return rewritten, names
 }
 
+// rewriteDomain applies the first matching rule: suffix rules first, then regex rules sorted by key
 func (r *RewriterResolver) rewriteDomain(domain string) (string, string) {
 	for k, v := range r.cfg.Rewrite {
+		if config.IsRewriteRegex(k) {
+			continue
+		}
+
 		if strings.HasSuffix(domain, "."+k) {
 			newDomain := strings.TrimSuffix(domain, "."+k) + "." + v
 
@@ -144,5 +221,20 @@ func (r *RewriterResolver) rewriteDomain(domain string) (string, string) {
 		}
 	}
 
+	for _, rule := range r.regexRules {
+		if !rule.Pattern.MatchString(domain) {
+			continue
+		}
+
+		newDomain := strings.TrimSuffix(strings.ToLower(rule.Pattern.ReplaceAllString(domain, rule.Replacement)), ".")
+
+		if _, ok := dns.IsDomainName(newDomain); !ok || len(newDomain) == 0 {
+			// the replacement doesn't yield a valid domain, don't rewrite
+			continue
+		}
+
+		return newDomain, rule.Key
+	}
+
 	return domain, ""
 }
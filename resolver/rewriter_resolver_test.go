@@ -45,7 +45,10 @@ var _ = Describe("RewriterResolver", func() {
 	})
 
 	JustBeforeEach(func() {
-		sut = NewRewriterResolver(sutConfig, mInner)
+		var err error
+
+		sut, err = NewRewriterResolver(sutConfig, mInner)
+		Expect(err).Should(Succeed())
 		sut.Next(mNext)
 	})
 
@@ -199,6 +202,125 @@ var _ = Describe("RewriterResolver", func() {
 		})
 	})
 
+	When("has regex rewrite", func() {
+		BeforeEach(func() {
+			sutConfig = config.RewriterConfig{Rewrite: map[string]string{
+				`/^(.*)\.old\.lan$/`: "$1.new.lan",
+				`/^Upper\.lan$/`:     "lower.lan",
+			}}
+		})
+
+		DescribeTable("should rewrite matching names",
+			func(original, rewritten string) {
+				mInner.On("Resolve", mock.Anything)
+				mInner.ResponseFn = func(req *dns.Msg) *dns.Msg {
+					Expect(req.Question[0].Name).Should(Equal(rewritten))
+
+					res := new(dns.Msg)
+					res.SetReply(req)
+
+					return res
+				}
+
+				resp, err := sut.Resolve(newRequest(original, dns.Type(dns.TypeA)))
+				Expect(err).Should(Succeed())
+				Expect(resp.Res.Question[0].Name).Should(Equal(original))
+			},
+			Entry("with capture group", "printer.old.lan.", "printer.new.lan."),
+			Entry("with subdomain", "a.b.old.lan.", "a.b.new.lan."),
+			Entry("not matching", "printer.old.lan.com.", "printer.old.lan.com."),
+			Entry("case sensitive pattern on lower case name", "upper.lan.", "upper.lan."),
+		)
+	})
+
+	When("the answer contains rewritten names", func() {
+		BeforeEach(func() {
+			sutConfig = config.RewriterConfig{Rewrite: map[string]string{
+				"home":               "lan",
+				`/^(.*)\.old\.lan$/`: "$1.new.lan",
+			}}
+		})
+
+		// resolve returns the owner names and CNAME targets of the answer
+		resolve := func(question string, records ...string) []string {
+			mInner.On("Resolve", mock.Anything)
+			mInner.ResponseFn = func(req *dns.Msg) *dns.Msg {
+				res := new(dns.Msg)
+				res.SetReply(req)
+
+				for _, record := range records {
+					rr, err := dns.NewRR(record)
+					Expect(err).Should(Succeed())
+
+					res.Answer = append(res.Answer, rr)
+				}
+
+				return res
+			}
+
+			resp, err := sut.Resolve(newRequest(question, dns.Type(dns.TypeA)))
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Question[0].Name).Should(Equal(question))
+
+			var names []string
+
+			for _, rr := range resp.Res.Answer {
+				names = append(names, rr.Header().Name)
+
+				if cname, ok := rr.(*dns.CNAME); ok {
+					names = append(names, cname.Target)
+				}
+			}
+
+			return names
+		}
+
+		It("should map owner names back", func() {
+			Expect(resolve("Printer.home.", "printer.lan. 300 IN A 192.168.178.3")).
+				Should(Equal([]string{"Printer.home."}))
+		})
+
+		It("should map a CNAME chain inside the rewritten domain back", func() {
+			Expect(resolve("printer.home.",
+				"printer.lan. 300 IN CNAME nas.lan.",
+				"nas.lan. 300 IN A 192.168.178.3",
+			)).Should(Equal([]string{"printer.home.", "nas.home.", "nas.home."}))
+		})
+
+		It("should keep CNAME targets leaving the rewritten domain", func() {
+			Expect(resolve("printer.home.",
+				"printer.lan. 300 IN CNAME printer.example.com.",
+				"printer.example.com. 300 IN A 192.168.178.3",
+			)).Should(Equal([]string{"printer.home.", "printer.example.com.", "printer.example.com."}))
+		})
+
+		It("should map a CNAME chain entering the rewritten domain back", func() {
+			Expect(resolve("printer.home.",
+				"printer.lan. 300 IN CNAME printer.example.com.",
+				"printer.example.com. 300 IN CNAME nas.lan.",
+				"nas.lan. 300 IN A 192.168.178.3",
+			)).Should(Equal([]string{
+				"printer.home.", "printer.example.com.",
+				"printer.example.com.", "nas.home.",
+				"nas.home.",
+			}))
+		})
+
+		It("should only map the question name back for regex rules", func() {
+			Expect(resolve("printer.old.lan.",
+				"printer.new.lan. 300 IN CNAME nas.new.lan.",
+				"nas.new.lan. 300 IN A 192.168.178.3",
+			)).Should(Equal([]string{"printer.old.lan.", "nas.new.lan.", "nas.new.lan."}))
+		})
+
+		It("should map a CNAME back to the question name for regex rules", func() {
+			Expect(resolve("printer.old.lan.",
+				"alias.example.com. 300 IN CNAME printer.new.lan.",
+				"printer.new.lan. 300 IN A 192.168.178.3",
+			)).Should(Equal([]string{"alias.example.com.", "printer.old.lan.", "printer.old.lan."}))
+		})
+	})
+
 	Describe("Configuration output", func() {
 		When("resolver is enabled", func() {
 			It("should return configuration", func() {
@@ -0,0 +1,122 @@
+package resolver
+
+import (
+	"net"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/trie"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+const staticResponseReason = "STATIC RESPONSE"
+
+// StaticResponseResolver answers queries matching a rule with the fixed response of the rule.
+// The first matching rule is used.
+type StaticResponseResolver struct {
+	configurable[*config.StaticResponsesConfig]
+	NextResolver
+	typed
+}
+
+// NewStaticResponseResolver creates a new resolver instance
+func NewStaticResponseResolver(cfg config.StaticResponsesConfig) *StaticResponseResolver {
+	return &StaticResponseResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("static_response"),
+	}
+}
+
+// Resolve answers the request with the first matching rule
+func (r *StaticResponseResolver) Resolve(request *model.Request) (*model.Response, error) {
+	question := request.Req.Question[0]
+	domain := util.ExtractDomain(question)
+
+	for i := range r.cfg.Rules {
+		rule := &r.cfg.Rules[i]
+
+		if !rule.Matches(domain, dns.Type(question.Qtype)) || !matchesClient(request, rule.Clients) {
+			continue
+		}
+
+		r.log().WithFields(logrus.Fields{
+			"domain": domain,
+			"rule":   rule.String(),
+		}).Debug("returning static response")
+
+		return &model.Response{
+			Res:    newStaticResponse(request.Req, rule),
+			RType:  model.ResponseTypeCUSTOMDNS,
+			Reason: staticResponseReason,
+		}, nil
+	}
+
+	return r.next.Resolve(request)
+}
+
+func newStaticResponse(request *dns.Msg, rule *config.StaticResponseRule) *dns.Msg {
+	response := new(dns.Msg)
+	response.SetRcode(request, rule.Rcode)
+
+	response.Answer = copyStaticRRs(rule.Answer, request.Question[0].Name)
+	response.Ns = copyStaticRRs(rule.Authority, "")
+	response.Extra = copyStaticRRs(rule.Additional, "")
+
+	return response
+}
+
+// copyStaticRRs copies the records, since the response may be modified by other resolvers.
+// The wildcard owner names of the answer are replaced with the query name.
+func copyStaticRRs(rrs []dns.RR, qName string) []dns.RR {
+	if len(rrs) == 0 {
+		return nil
+	}
+
+	res := make([]dns.RR, 0, len(rrs))
+
+	for _, rr := range rrs {
+		rr = dns.Copy(rr)
+
+		owner := rr.Header().Name
+
+		if qName != "" && trie.IsWildcard(owner) && dns.IsSubDomain(strings.TrimPrefix(owner, trie.WildcardPrefix), qName) {
+			rr.Header().Name = qName
+		}
+
+		res = append(res, rr)
+	}
+
+	return res
+}
+
+// matchesClient returns true if no clients are given or if one matches the client names, IP or subnet of the request
+func matchesClient(request *model.Request, clients []string) bool {
+	if len(clients) == 0 {
+		return true
+	}
+
+	for _, client := range clients {
+		if ip := net.ParseIP(client); ip != nil {
+			if ip.Equal(request.ClientIP) {
+				return true
+			}
+
+			continue
+		}
+
+		if util.CidrContainsIP(client, request.ClientIP) {
+			return true
+		}
+
+		for _, name := range request.ClientNames {
+			if util.ClientNameMatchesGroupName(client, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+package resolver
+
+import (
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("StaticResponseResolver", func() {
+	var (
+		sut *StaticResponseResolver
+		m   *mockResolver
+		cfg config.StaticResponsesConfig
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		cfg = config.StaticResponsesConfig{}
+
+		Expect(yaml.UnmarshalStrict([]byte(`
+rules:
+  - name: _dmarc.home.lan
+    queryTypes: [TXT]
+    answer: _dmarc.home.lan. 300 IN TXT "v=DMARC1; p=reject"
+  - suffix: example.com
+    queryTypes: [NS]
+    clients: [lab-*, 10.0.42.0/24]
+    answer: example.com. 3600 IN NS ns.lab.lan.
+    additional: ns.lab.lan. 3600 IN A 10.0.42.53
+  - suffix: wild.home.lan
+    answer: "*.wild.home.lan. 60 IN A 192.0.2.1"
+  - name: refused.home.lan
+    rcode: REFUSED
+`), &cfg)).Should(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		sut = NewStaticResponseResolver(cfg)
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("no rule is configured", func() {
+			BeforeEach(func() {
+				cfg = config.StaticResponsesConfig{}
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("should answer with the records of the rule", func() {
+			Expect(sut.Resolve(newRequest("_dmarc.home.lan.", TXT))).Should(SatisfyAll(
+				BeDNSRecord("_dmarc.home.lan.", TXT, "v=DMARC1; p=reject"),
+				HaveTTL(BeNumerically("==", 300)),
+				HaveResponseType(ResponseTypeCUSTOMDNS),
+				HaveReason("STATIC RESPONSE"),
+				HaveReturnCode(dns.RcodeSuccess),
+			))
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		It("should answer with the rcode of the rule", func() {
+			Expect(sut.Resolve(newRequest("refused.home.lan.", AAAA))).Should(SatisfyAll(
+				HaveNoAnswer(),
+				HaveReturnCode(dns.RcodeRefused),
+				HaveReason("STATIC RESPONSE"),
+			))
+		})
+
+		It("should replace the wildcard owner with the query name", func() {
+			Expect(sut.Resolve(newRequest("host.wild.home.lan.", A))).
+				Should(BeDNSRecord("host.wild.home.lan.", A, "192.0.2.1"))
+
+			// the rule isn't changed
+			Expect(cfg.Rules[2].Answer[0].Header().Name).Should(Equal("*.wild.home.lan."))
+		})
+
+		It("should pass other queries to the next resolver", func() {
+			Expect(sut.Resolve(newRequest("_dmarc.home.lan.", A))).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(sut.Resolve(newRequest("other.home.lan.", TXT))).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			m.AssertNumberOfCalls(GinkgoT(), "Resolve", 2)
+		})
+
+		When("the rule is limited to clients", func() {
+			It("should answer matching client names and subnets", func() {
+				Expect(sut.Resolve(newRequestWithClient("www.example.com.", NS, "192.168.1.5", "lab-pc"))).
+					Should(SatisfyAll(
+						BeDNSRecord("example.com.", NS, "ns.lab.lan."),
+						WithTransform(func(resp *Response) []dns.RR { return resp.Res.Extra },
+							ContainElement(HaveField("Hdr.Name", "ns.lab.lan."))),
+					))
+
+				Expect(sut.Resolve(newRequestWithClient("example.com.", NS, "10.0.42.7"))).
+					Should(HaveReason("STATIC RESPONSE"))
+
+				m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+			})
+
+			It("should pass the queries of other clients to the next resolver", func() {
+				Expect(sut.Resolve(newRequestWithClient("example.com.", NS, "192.168.1.5", "laptop"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+			})
+		})
+	})
+})
@@ -2,10 +2,10 @@ package resolver
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
@@ -31,43 +31,20 @@ type StrictResolver struct {
 func NewStrictResolver(
 	cfg config.UpstreamsConfig, bootstrap *Bootstrap, shouldVerifyUpstreams bool,
 ) (*StrictResolver, error) {
-	logger := log.PrefixedLog(strictResolverType)
-
-	upstreamResolvers := cfg.Groups
-	resolverGroups := make(map[string][]Resolver, len(upstreamResolvers))
-
-	for name, upstreamCfgs := range upstreamResolvers {
-		group := make([]Resolver, 0, len(upstreamCfgs))
-		hasValidResolver := false
-
-		for _, u := range upstreamCfgs {
-			resolver, err := NewUpstreamResolver(u, bootstrap, shouldVerifyUpstreams)
-			if err != nil {
-				logger.Warnf("upstream group %s: %v", name, err)
-
-				continue
-			}
+	if err := cfg.ValidateEncryption(); err != nil {
+		return nil, err
+	}
 
-			if shouldVerifyUpstreams {
-				err = testResolver(resolver)
-				if err != nil {
-					logger.Warn(err)
-				} else {
-					hasValidResolver = true
-				}
-			}
+	r := newStrictResolver(cfg, createUpstreamGroups(cfg, bootstrap))
 
-			group = append(group, resolver)
+	if shouldVerifyUpstreams {
+		err := verifyUpstreamGroups(cfg, r.resolversPerClient, log.PrefixedLog(strictResolverType))
+		if err != nil {
+			return nil, err
 		}
-
-		if shouldVerifyUpstreams && !hasValidResolver {
-			return nil, fmt.Errorf("no valid upstream for group %s", name)
-		}
-
-		resolverGroups[name] = group
 	}
 
-	return newStrictResolver(cfg, resolverGroups), nil
+	return r, nil
 }
 
 func newStrictResolver(
@@ -79,7 +56,7 @@ func newStrictResolver(
 		resolverStatuses := make([]*upstreamResolverStatus, 0, len(resolvers))
 
 		for _, r := range resolvers {
-			resolverStatuses = append(resolverStatuses, newUpstreamResolverStatus(r))
+			resolverStatuses = append(resolverStatuses, newUpstreamResolverStatus(r, cfg.ResponseQuality))
 		}
 
 		resolversPerClient[groupName] = resolverStatuses
@@ -114,20 +91,40 @@ func (r *StrictResolver) String() string {
 	return fmt.Sprintf("%s upstreams %q", strictResolverType, strings.Join(result, "; "))
 }
 
+// upstreamStatus implements `upstreamStatusProvider`.
+func (r *StrictResolver) upstreamStatus() []api.UpstreamStatus {
+	return upstreamGroupStatus(r.resolversPerClient)
+}
+
+// resetConnections implements `connectionResetter`.
+func (r *StrictResolver) resetConnections() {
+	for _, resolvers := range r.resolversPerClient {
+		for _, status := range resolvers {
+			ResetConnections(status.resolver)
+		}
+	}
+}
+
 // Resolve sends the query request to multiple upstream resolvers and returns the fastest result
 func (r *StrictResolver) Resolve(request *model.Request) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, strictResolverType)
 
 	var resolvers []*upstreamResolverStatus
 	for _, r := range r.resolversPerClient {
-		resolvers = r
+		resolvers = activeResolvers(r)
 
 		break
 	}
 
+	if len(resolvers) == 0 {
+		return nil, errNoActiveUpstream
+	}
+
+	var collectedErrors []error
+
 	// start with first resolver
 	for i := range resolvers {
-		timeout := config.GetConfig().Upstreams.Timeout.ToDuration()
+		timeout := r.cfg.Timeout.ToDuration()
 
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
@@ -143,12 +140,16 @@ func (r *StrictResolver) Resolve(request *model.Request) (*model.Response, error
 			// log debug/info that timeout exceeded, call `continue` to try next upstream
 			logger.WithField("resolver", resolvers[i].resolver).Debug("upstream exceeded timeout, trying next upstream")
 
+			collectedErrors = append(collectedErrors, ctx.Err())
+
 			continue
 		case result := <-ch:
 			if result.err != nil {
 				// log error & call `continue` to try next upstream
 				logger.Debug("resolution failed from resolver, cause: ", result.err)
 
+				collectedErrors = append(collectedErrors, result.err)
+
 				continue
 			}
 
@@ -161,5 +162,6 @@ func (r *StrictResolver) Resolve(request *model.Request) (*model.Response, error
 		}
 	}
 
-	return nil, errors.New("resolution was not successful, no resolver returned an answer in time")
+	return nil, fmt.Errorf("%w, no resolver returned an answer in time, errors: %w", errResolutionFailed,
+		upstreamErrors(collectedErrors))
 }
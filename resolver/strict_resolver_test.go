@@ -1,9 +1,12 @@
 package resolver
 
 import (
+	"context"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
@@ -17,6 +20,8 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 	const (
 		verifyUpstreams   = true
 		noVerifyUpstreams = false
+
+		timeout = 1000 * time.Millisecond
 	)
 
 	var (
@@ -53,13 +58,11 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 	})
 
 	JustBeforeEach(func() {
-		sutConfig := config.UpstreamsConfig{Groups: sutMapping}
+		sutConfig := config.UpstreamsConfig{Groups: sutMapping, Timeout: config.Duration(timeout)}
 
 		sut, err = NewStrictResolver(sutConfig, bootstrap, sutVerify)
 	})
 
-	config.GetConfig().Upstreams.Timeout = config.Duration(1000 * time.Millisecond)
-
 	Describe("IsEnabled", func() {
 		It("is true", func() {
 			Expect(sut.IsEnabled()).Should(BeTrue())
@@ -92,26 +95,49 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 
 	When("some default upstream resolvers cannot be reached", func() {
 		It("should start normally", func() {
-			mockUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+			mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 				response, _ = util.NewMsgWithAnswer(request.Question[0].Name, 123, A, "123.124.122.122")
 
 				return
 			})
 			defer mockUpstream.Close()
 
+			mockUpstreamCfg := mockUpstream.Start()
+
 			upstream := config.UpstreamGroups{
 				upstreamDefaultCfgName: {
 					config.Upstream{
 						Host: "wrong",
 					},
-					mockUpstream.Start(),
+					mockUpstreamCfg,
 				},
 			}
 
-			_, err := NewStrictResolver(config.UpstreamsConfig{
-				Groups: upstream,
+			r, err := NewStrictResolver(config.UpstreamsConfig{
+				Timeout: config.Duration(time.Second),
+				Groups:  upstream,
 			}, systemResolverBootstrap, verifyUpstreams)
 			Expect(err).Should(Not(HaveOccurred()))
+
+			By("marking the unreachable upstream as pending", func() {
+				Expect(r.upstreamStatus()).Should(ConsistOf(
+					SatisfyAll(
+						HaveField("Upstream", "tcp+udp:wrong:0"),
+						HaveField("State", api.UpstreamVerificationPending),
+						HaveField("FailedAttempts", BeEquivalentTo(1)),
+						HaveField("LastError", Not(BeEmpty())),
+					),
+					SatisfyAll(
+						HaveField("Upstream", mockUpstreamCfg.String()),
+						HaveField("State", api.UpstreamVerificationActive),
+					),
+				))
+			})
+
+			By("using the verified upstream only", func() {
+				Expect(r.Resolve(newRequest("example.com.", A))).
+					Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+			})
 		})
 	})
 
@@ -153,10 +179,10 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 			When("Both are responding", func() {
 				When("they respond in time", func() {
 					BeforeEach(func() {
-						testUpstream1 := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+						testUpstream1 := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 						DeferCleanup(testUpstream1.Close)
 
-						testUpstream2 := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.123")
+						testUpstream2 := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.123")
 						DeferCleanup(testUpstream2.Close)
 
 						sutMapping = config.UpstreamGroups{
@@ -177,9 +203,9 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 				})
 				When("first upstream exceeds upstreamTimeout", func() {
 					BeforeEach(func() {
-						testUpstream1 := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+						testUpstream1 := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 							response, err := util.NewMsgWithAnswer("example.com", 123, A, "123.124.122.1")
-							time.Sleep(time.Duration(config.GetConfig().Upstreams.Timeout) + 2*time.Second)
+							time.Sleep(timeout + 2*time.Second)
 
 							Expect(err).To(Succeed())
 
@@ -187,7 +213,7 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 						})
 						DeferCleanup(testUpstream1.Close)
 
-						testUpstream2 := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.2")
+						testUpstream2 := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.2")
 						DeferCleanup(testUpstream2.Close)
 
 						sutMapping = config.UpstreamGroups{
@@ -207,9 +233,9 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 				})
 				When("all upstreams exceed upsteamTimeout", func() {
 					BeforeEach(func() {
-						testUpstream1 := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+						testUpstream1 := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 							response, err := util.NewMsgWithAnswer("example.com", 123, A, "123.124.122.1")
-							time.Sleep(config.GetConfig().Upstreams.Timeout.ToDuration() + 2*time.Second)
+							time.Sleep(timeout + 2*time.Second)
 
 							Expect(err).To(Succeed())
 
@@ -217,9 +243,9 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 						})
 						DeferCleanup(testUpstream1.Close)
 
-						testUpstream2 := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+						testUpstream2 := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 							response, err := util.NewMsgWithAnswer("example.com", 123, A, "123.124.122.2")
-							time.Sleep(config.GetConfig().Upstreams.Timeout.ToDuration() + 2*time.Second)
+							time.Sleep(timeout + 2*time.Second)
 
 							Expect(err).To(Succeed())
 
@@ -234,7 +260,8 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 					It("should return error", func() {
 						request := newRequest("example.com", A)
 						_, err := sut.Resolve(request)
-						Expect(err).To(HaveOccurred())
+						Expect(err).To(MatchError(errResolutionFailed))
+						Expect(err).To(MatchError(context.DeadlineExceeded))
 					})
 				})
 			})
@@ -242,7 +269,7 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 				BeforeEach(func() {
 					testUpstream1 := config.Upstream{Host: "wrong"}
 
-					testUpstream2 := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.123")
+					testUpstream2 := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.123")
 					DeferCleanup(testUpstream2.Close)
 
 					sutMapping = config.UpstreamGroups{
@@ -280,7 +307,7 @@ var _ = Describe("StrictResolver", Label("strictResolver"), func() {
 		})
 		When("only 1 upstream resolvers is defined", func() {
 			BeforeEach(func() {
-				mockUpstream := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 				DeferCleanup(mockUpstream.Close)
 
 				sutMapping = config.UpstreamGroups{
@@ -16,6 +16,9 @@ var (
 	loopbackV4 = net.ParseIP("127.0.0.1")
 	loopbackV6 = net.IPv6loopback
 
+	sudnPrivateReverseZone = sudnNXDomainIf(func(cfg *config.SUDNConfig) bool { return cfg.PrivateReverseZones })
+	sudnLocal              = sudnNXDomainIf(func(cfg *config.SUDNConfig) bool { return cfg.Local })
+
 	// See Wikipedia for an up-to-date reference:
 	// https://en.wikipedia.org/wiki/Special-use_domain_name
 	sudnHandlers = map[string]sudnHandler{
@@ -23,30 +26,30 @@ var (
 		// https://www.rfc-editor.org/rfc/rfc6761
 		//
 		// Section 6.1
-		"10.in-addr.arpa.":      sudnNXDomain,
-		"21.172.in-addr.arpa.":  sudnNXDomain,
-		"26.172.in-addr.arpa.":  sudnNXDomain,
-		"16.172.in-addr.arpa.":  sudnNXDomain,
-		"22.172.in-addr.arpa.":  sudnNXDomain,
-		"27.172.in-addr.arpa.":  sudnNXDomain,
-		"17.172.in-addr.arpa.":  sudnNXDomain,
-		"30.172.in-addr.arpa.":  sudnNXDomain,
-		"28.172.in-addr.arpa.":  sudnNXDomain,
-		"18.172.in-addr.arpa.":  sudnNXDomain,
-		"23.172.in-addr.arpa.":  sudnNXDomain,
-		"29.172.in-addr.arpa.":  sudnNXDomain,
-		"19.172.in-addr.arpa.":  sudnNXDomain,
-		"24.172.in-addr.arpa.":  sudnNXDomain,
-		"31.172.in-addr.arpa.":  sudnNXDomain,
-		"20.172.in-addr.arpa.":  sudnNXDomain,
-		"25.172.in-addr.arpa.":  sudnNXDomain,
-		"168.192.in-addr.arpa.": sudnNXDomain,
+		"10.in-addr.arpa.":      sudnPrivateReverseZone,
+		"21.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"26.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"16.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"22.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"27.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"17.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"30.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"28.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"18.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"23.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"29.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"19.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"24.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"31.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"20.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"25.172.in-addr.arpa.":  sudnPrivateReverseZone,
+		"168.192.in-addr.arpa.": sudnPrivateReverseZone,
 		// Section 6.2
-		"test.": sudnNXDomain,
+		"test.": sudnNXDomainIf(func(cfg *config.SUDNConfig) bool { return cfg.Test }),
 		// Section 6.3
 		"localhost.": sudnLocalhost,
 		// Section 6.4
-		"invalid.": sudnNXDomain,
+		"invalid.": sudnNXDomainIf(func(cfg *config.SUDNConfig) bool { return cfg.Invalid }),
 		// Section 6.5
 		"example.":     nil,
 		"example.com.": nil,
@@ -59,13 +62,13 @@ var (
 		// mDNS is not implemented, so just return NXDOMAIN
 		//
 		// Section 3
-		"local.": sudnNXDomain,
+		"local.": sudnLocal,
 		// Section 12
-		"254.169.in-addr.arpa.": sudnNXDomain, // also section 4
-		"8.e.f.ip6.arpa.":       sudnNXDomain,
-		"9.e.f.ip6.arpa.":       sudnNXDomain,
-		"a.e.f.ip6.arpa.":       sudnNXDomain,
-		"b.e.f.ip6.arpa.":       sudnNXDomain,
+		"254.169.in-addr.arpa.": sudnLocal, // also section 4
+		"8.e.f.ip6.arpa.":       sudnLocal,
+		"9.e.f.ip6.arpa.":       sudnLocal,
+		"a.e.f.ip6.arpa.":       sudnLocal,
+		"b.e.f.ip6.arpa.":       sudnLocal,
 		// Appendix G
 		"intranet.": sudnRFC6762AppendixG,
 		"internal.": sudnRFC6762AppendixG,
@@ -76,7 +79,7 @@ var (
 
 		// RFC 7686
 		// https://www.rfc-editor.org/rfc/rfc7686
-		"onion.": sudnNXDomain,
+		"onion.": sudnNXDomainIf(func(cfg *config.SUDNConfig) bool { return cfg.Onion }),
 
 		// RFC 8375
 		// https://www.rfc-editor.org/rfc/rfc8375
@@ -130,15 +133,34 @@ func (r *SpecialUseDomainNamesResolver) handler(request *model.Request) sudnHand
 	}
 }
 
+// newSUDNResponse creates an authoritative response, since these domains are never delegated
 func newSUDNResponse(response *model.Request, rcode int) *model.Response {
-	return newResponse(response, rcode, model.ResponseTypeSPECIAL, "Special-Use Domain Name")
+	resp := newResponse(response, rcode, model.ResponseTypeSPECIAL, "Special-Use Domain Name")
+	resp.Res.Authoritative = true
+
+	return resp
 }
 
 func sudnNXDomain(request *model.Request, _ *config.SUDNConfig) *model.Response {
 	return newSUDNResponse(request, dns.RcodeNameError)
 }
 
+// sudnNXDomainIf returns a handler, which answers NXDOMAIN if the domain kind is enabled
+func sudnNXDomainIf(enabled func(cfg *config.SUDNConfig) bool) sudnHandler {
+	return func(request *model.Request, cfg *config.SUDNConfig) *model.Response {
+		if !enabled(cfg) {
+			return nil
+		}
+
+		return sudnNXDomain(request, cfg)
+	}
+}
+
 func sudnLocalhost(request *model.Request, cfg *config.SUDNConfig) *model.Response {
+	if !cfg.Localhost {
+		return nil
+	}
+
 	q := request.Req.Question[0]
 
 	var rr dns.RR
@@ -174,7 +196,7 @@ func sudnRFC6762AppendixG(request *model.Request, cfg *config.SUDNConfig) *model
 }
 
 func sudnHomeArpa(request *model.Request, cfg *config.SUDNConfig) *model.Response {
-	if request.Req.Question[0].Qtype == dns.TypeDS {
+	if !cfg.HomeArpa || request.Req.Question[0].Qtype == dns.TypeDS {
 		// DS queries must be forwarded
 		return nil
 	}
@@ -97,6 +97,7 @@ var _ = Describe("SudnResolver", Label("sudnResolver"), func() {
 					HaveReason("Special-Use Domain Name"),
 					HaveReturnCode(expectedRCode),
 				))
+				Expect(resp.Res.Authoritative).Should(BeTrue())
 
 				switch expectedRCode {
 				case dns.RcodeSuccess:
@@ -149,6 +150,32 @@ var _ = Describe("SudnResolver", Label("sudnResolver"), func() {
 			)
 		})
 
+		DescribeTable("disabled domain kinds are forwarded",
+			func(disable func(cfg *config.SUDNConfig), qName string, qType dns.Type) {
+				disable(&sutConfig)
+
+				sut = NewSpecialUseDomainNamesResolver(sutConfig)
+				sut.Next(m)
+
+				resp, err := sut.Resolve(newRequest(qName, qType))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(SatisfyAll(
+					HaveResponseType(ResponseTypeRESOLVED),
+					HaveReturnCode(dns.RcodeSuccess),
+				))
+			},
+			Entry("private reverse zones", func(cfg *config.SUDNConfig) { cfg.PrivateReverseZones = false },
+				"1.178.168.192.in-addr.arpa.", PTR),
+			Entry("test", func(cfg *config.SUDNConfig) { cfg.Test = false }, "something.test.", A),
+			Entry("localhost", func(cfg *config.SUDNConfig) { cfg.Localhost = false }, "something.localhost.", A),
+			Entry("invalid", func(cfg *config.SUDNConfig) { cfg.Invalid = false }, "something.invalid.", A),
+			Entry("local", func(cfg *config.SUDNConfig) { cfg.Local = false }, "printer.local.", A),
+			Entry("link-local reverse zones", func(cfg *config.SUDNConfig) { cfg.Local = false },
+				"1.0.254.169.in-addr.arpa.", PTR),
+			Entry("onion", func(cfg *config.SUDNConfig) { cfg.Onion = false }, "something.onion.", A),
+			Entry("home.arpa", func(cfg *config.SUDNConfig) { cfg.HomeArpa = false }, "nas.home.arpa.", A),
+		)
+
 		It("should forward example.com", func() {
 			Expect(sut.Resolve(newRequest("example.com", A))).
 				Should(
@@ -0,0 +1,159 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// upstreamErrorLogInterval is the interval in which repeated upstream failures are summarized
+const upstreamErrorLogInterval = time.Minute
+
+// errResolutionFailed is wrapped by errors of resolvers which couldn't get an answer from any upstream.
+// The failures of the single upstreams are already logged by their upstreamErrorLog.
+var errResolutionFailed = errors.New("resolution was not successful")
+
+// IsResolutionFailed returns true if the error was caused by unreachable or failing upstreams
+func IsResolutionFailed(err error) bool {
+	return errors.Is(err, errResolutionFailed)
+}
+
+// upstreamErrors are the failures of the upstreams of a resolution, they can be inspected with `errors.Is` and `errors.As`
+type upstreamErrors []error
+
+func (e upstreamErrors) Error() string {
+	return fmt.Sprint([]error(e))
+}
+
+func (e upstreamErrors) Unwrap() []error {
+	return e
+}
+
+// upstreamErrorLog deduplicates the logging of failures of one upstream:
+// the first failure of each error class is logged, repeated failures are summarized once per interval
+// and a recovery message is logged with the next successful response.
+type upstreamErrorLog struct {
+	upstream string
+	interval time.Duration
+
+	lock       sync.Mutex
+	failures   uint
+	since      time.Time
+	lastReport time.Time
+	seen       map[string]bool
+	pending    map[string]uint
+}
+
+func newUpstreamErrorLog(upstream string, interval time.Duration) *upstreamErrorLog {
+	return &upstreamErrorLog{
+		upstream: upstream,
+		interval: interval,
+	}
+}
+
+// failure records a failed request to the upstream
+func (l *upstreamErrorLog) failure(logger *logrus.Entry, err error) {
+	class := upstreamErrorClass(err)
+	now := time.Now()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.failures == 0 {
+		l.since = now
+		l.lastReport = now
+		l.seen = make(map[string]bool)
+		l.pending = make(map[string]uint)
+	}
+
+	l.failures++
+
+	if !l.seen[class] {
+		l.seen[class] = true
+
+		logger.WithField("upstream", l.upstream).Warnf("upstream %s: %s", l.upstream, err)
+
+		return
+	}
+
+	l.pending[class]++
+
+	if now.Sub(l.lastReport) >= l.interval {
+		l.report(logger, now)
+	}
+}
+
+// success records a successful request to the upstream and logs the recovery of a failing upstream
+func (l *upstreamErrorLog) success(logger *logrus.Entry) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.failures == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	l.report(logger, now)
+
+	logger.WithField("upstream", l.upstream).Infof("upstream %s: recovered after %d failures in %s",
+		l.upstream, l.failures, now.Sub(l.since).Round(time.Second))
+
+	l.failures = 0
+}
+
+// report logs the summary of the failures since the last report, lock must be held
+func (l *upstreamErrorLog) report(logger *logrus.Entry, now time.Time) {
+	classes := make([]string, 0, len(l.pending))
+
+	for class, count := range l.pending {
+		if count > 0 {
+			classes = append(classes, class)
+		}
+	}
+
+	sort.Strings(classes)
+
+	elapsed := now.Sub(l.lastReport).Round(time.Second)
+
+	for _, class := range classes {
+		logger.WithField("upstream", l.upstream).Warnf("upstream %s: %d %s failures in the last %s",
+			l.upstream, l.pending[class], class, elapsed)
+
+		delete(l.pending, class)
+	}
+
+	l.lastReport = now
+}
+
+// upstreamErrorClass returns a short description of the kind of the error, used to aggregate failures
+func upstreamErrorClass(err error) string {
+	var (
+		netErr net.Error
+		dnsErr *net.DNSError
+	)
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection reset"
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH):
+		return "unreachable"
+	case errors.As(err, &dnsErr):
+		return "lookup"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "other"
+	}
+}
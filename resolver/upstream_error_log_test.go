@@ -0,0 +1,89 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("upstreamErrorLog", func() {
+	var (
+		sut    *upstreamErrorLog
+		logger *logrus.Entry
+		hook   *log.MockLoggerHook
+
+		errTimeout = fmt.Errorf("can't resolve request: %w", context.DeadlineExceeded)
+		errRefused = &net.OpError{Op: "read", Net: "udp", Err: syscall.ECONNREFUSED}
+	)
+
+	BeforeEach(func() {
+		logger, hook = log.NewMockEntry()
+		sut = newUpstreamErrorLog("1.2.3.4", time.Hour)
+	})
+
+	When("upstream fails repeatedly", func() {
+		It("should log only the first occurrence of each error class", func() {
+			for i := 0; i < 10; i++ {
+				sut.failure(logger, errTimeout)
+				sut.failure(logger, errRefused)
+			}
+
+			Expect(hook.Messages).Should(HaveLen(2))
+			Expect(hook.Messages[0]).Should(ContainSubstring("upstream 1.2.3.4: can't resolve request"))
+			Expect(hook.Messages[1]).Should(ContainSubstring("connection refused"))
+		})
+
+		It("should summarize the failures once the interval elapsed", func() {
+			sut.interval = 0
+
+			sut.failure(logger, errTimeout)
+			sut.failure(logger, errTimeout)
+
+			Expect(hook.Messages).Should(HaveLen(2))
+			Expect(hook.Messages[1]).Should(Equal("upstream 1.2.3.4: 1 timeout failures in the last 0s"))
+		})
+	})
+
+	When("upstream recovers", func() {
+		It("should log the pending failures and the recovery", func() {
+			sut.failure(logger, errTimeout)
+			sut.failure(logger, errTimeout)
+			sut.failure(logger, errTimeout)
+
+			sut.success(logger)
+
+			Expect(hook.Messages).Should(HaveLen(3))
+			Expect(hook.Messages[1]).Should(Equal("upstream 1.2.3.4: 2 timeout failures in the last 0s"))
+			Expect(hook.Messages[2]).Should(Equal("upstream 1.2.3.4: recovered after 3 failures in 0s"))
+
+			By("logging the first occurrence of a new outage again", func() {
+				sut.failure(logger, errTimeout)
+
+				Expect(hook.Messages).Should(HaveLen(4))
+			})
+		})
+
+		It("should not log anything if there were no failures", func() {
+			sut.success(logger)
+
+			Expect(hook.Messages).Should(BeEmpty())
+		})
+	})
+
+	Describe("upstreamErrorClass", func() {
+		It("should classify errors", func() {
+			Expect(upstreamErrorClass(errTimeout)).Should(Equal("timeout"))
+			Expect(upstreamErrorClass(errRefused)).Should(Equal("connection refused"))
+			Expect(upstreamErrorClass(&net.DNSError{Err: "no such host"})).Should(Equal("lookup"))
+			Expect(upstreamErrorClass(errors.New("boom"))).Should(Equal("other"))
+		})
+	})
+})
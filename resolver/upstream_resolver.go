@@ -8,12 +8,15 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/avast/retry-go/v4"
 
+	"github.com/0xERR0R/blocky/chaos"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/util"
@@ -32,9 +35,14 @@ const (
 type UpstreamResolver struct {
 	typed
 
-	upstream       config.Upstream
-	upstreamClient upstreamClient
-	bootstrap      *Bootstrap
+	upstream            config.Upstream
+	upstreamClient      upstreamClient
+	bootstrap           *Bootstrap
+	maxCNAMEChainLength uint
+	padding             bool
+	udpSize             uint16
+	cookies             *upstreamCookies
+	errorLog            *upstreamErrorLog
 }
 
 type upstreamClient interface {
@@ -45,16 +53,16 @@ type upstreamClient interface {
 
 type dnsUpstreamClient struct {
 	tcpClient, udpClient *dns.Client
+	zone                 string
 }
 
 type httpUpstreamClient struct {
-	client *http.Client
-	host   string
+	client    *http.Client
+	host      string
+	userAgent string
 }
 
-func createUpstreamClient(cfg config.Upstream) upstreamClient {
-	timeout := config.GetConfig().Upstreams.Timeout.ToDuration()
-
+func createUpstreamClient(cfg config.Upstream, timeout time.Duration, dohUserAgent string) upstreamClient {
 	tlsConfig := tls.Config{
 		ServerName: cfg.Host,
 		MinVersion: tls.VersionTLS12,
@@ -75,7 +83,8 @@ func createUpstreamClient(cfg config.Upstream) upstreamClient {
 				},
 				Timeout: timeout,
 			},
-			host: cfg.Host,
+			host:      cfg.Host,
+			userAgent: dohUserAgent,
 		}
 
 	case config.NetProtocolTcpTls:
@@ -86,6 +95,7 @@ func createUpstreamClient(cfg config.Upstream) upstreamClient {
 				Timeout:        timeout,
 				SingleInflight: true,
 			},
+			zone: cfg.Zone,
 		}
 
 	case config.NetProtocolTcpUdp:
@@ -100,6 +110,7 @@ func createUpstreamClient(cfg config.Upstream) upstreamClient {
 				Timeout:        timeout,
 				SingleInflight: true,
 			},
+			zone: cfg.Zone,
 		}
 
 	default:
@@ -127,7 +138,7 @@ func (r *httpUpstreamClient) callExternal(msg *dns.Msg,
 		return nil, 0, fmt.Errorf("can't create the new request %w", err)
 	}
 
-	req.Header.Set("User-Agent", config.GetConfig().DoHUserAgent)
+	req.Header.Set("User-Agent", r.userAgent)
 	req.Header.Set("Content-Type", dnsContentType)
 	req.Host = r.host
 
@@ -166,7 +177,14 @@ func (r *httpUpstreamClient) callExternal(msg *dns.Msg,
 }
 
 func (r *dnsUpstreamClient) fmtURL(ip net.IP, port uint16, _ string) string {
-	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+	host := ip.String()
+
+	if r.zone != "" {
+		// the zone selects the interface of a link-local address
+		host += "%" + r.zone
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
 }
 
 func (r *dnsUpstreamClient) callExternal(msg *dns.Msg,
@@ -186,7 +204,12 @@ func (r *dnsUpstreamClient) callExternal(msg *dns.Msg,
 	}
 
 	if r.udpClient != nil {
-		return r.udpClient.Exchange(msg, upstreamURL)
+		response, rtt, err = r.udpClient.Exchange(msg, upstreamURL)
+		if err == nil && chaos.DropUDPResponse() {
+			return nil, rtt, chaos.ErrDroppedPacket
+		}
+
+		return response, rtt, err
 	}
 
 	return r.tcpClient.Exchange(msg, upstreamURL)
@@ -208,14 +231,20 @@ func NewUpstreamResolver(upstream config.Upstream, bootstrap *Bootstrap, verify
 
 // newUpstreamResolverUnchecked creates new resolver instance without validating the upstream
 func newUpstreamResolverUnchecked(upstream config.Upstream, bootstrap *Bootstrap) *UpstreamResolver {
-	upstreamClient := createUpstreamClient(upstream)
+	timeout, dohUserAgent := bootstrap.upstreamClientConfig()
+	upstreamClient := createUpstreamClient(upstream, timeout, dohUserAgent)
 
 	return &UpstreamResolver{
 		typed: withType("upstream"),
 
-		upstream:       upstream,
-		upstreamClient: upstreamClient,
-		bootstrap:      bootstrap,
+		upstream:            upstream,
+		upstreamClient:      upstreamClient,
+		bootstrap:           bootstrap,
+		maxCNAMEChainLength: bootstrap.cnameChainLimit(),
+		padding:             bootstrap.padding(upstream),
+		udpSize:             bootstrap.udpSize(),
+		cookies:             bootstrap.cookies(upstream),
+		errorLog:            newUpstreamErrorLog(upstream.String(), upstreamErrorLogInterval),
 	}
 }
 
@@ -233,6 +262,176 @@ func (r UpstreamResolver) String() string {
 	return fmt.Sprintf("%s '%s'", r.Type(), r.upstream)
 }
 
+// resetConnections implements `connectionResetter`.
+func (r *UpstreamResolver) resetConnections() {
+	// DNS clients dial a new connection for each exchange, only DoH keeps connections open
+	if client, ok := r.upstreamClient.(*httpUpstreamClient); ok {
+		client.client.CloseIdleConnections()
+	}
+}
+
+// exchange sends the request to the upstream. Responses which don't match the request are dropped
+// and the request is retried via TCP, which is much harder to spoof than UDP.
+// Truncated UDP responses are retried via TCP as well.
+func (r *UpstreamResolver) exchange(
+	request *model.Request, ip net.IP, upstreamURL string,
+) (*dns.Msg, time.Duration, error) {
+	resp, rtt, err := r.upstreamClient.callExternal(r.query(request.Req, ip, request.Protocol), upstreamURL,
+		request.Protocol)
+	if err != nil {
+		return nil, rtt, err
+	}
+
+	err = r.validateResponse(request, ip, request.Protocol, resp)
+	if err == nil && resp.Rcode == dns.RcodeBadCookie {
+		// the upstream sent a new server cookie with the error, the query is repeated once with it (RFC 7873, 5.3)
+		resp, rtt, err = r.upstreamClient.callExternal(r.query(request.Req, ip, request.Protocol), upstreamURL,
+			request.Protocol)
+		if err != nil {
+			return nil, rtt, err
+		}
+
+		err = r.validateResponse(request, ip, request.Protocol, resp)
+	}
+
+	canRetryTCP := request.Protocol != model.RequestProtocolTCP && r.upstream.Net == config.NetProtocolTcpUdp
+
+	if err == nil {
+		if (!resp.Truncated && resp.Rcode != dns.RcodeBadCookie) || !canRetryTCP {
+			return r.removeEdns0Options(request, resp), rtt, nil
+		}
+
+		r.log().WithField("upstream", r.upstream.String()).
+			Debugf("response is truncated or the cookie was rejected, retrying via TCP")
+	} else if !canRetryTCP {
+		return nil, rtt, err
+	}
+
+	resp, rtt, err = r.upstreamClient.callExternal(r.query(request.Req, ip, model.RequestProtocolTCP), upstreamURL,
+		model.RequestProtocolTCP)
+	if err != nil {
+		return nil, rtt, err
+	}
+
+	if err = r.validateResponse(request, ip, model.RequestProtocolTCP, resp); err != nil {
+		return nil, rtt, err
+	}
+
+	return r.removeEdns0Options(request, resp), rtt, nil
+}
+
+// query returns the query for the upstream: the request with the DNS cookie for UDP,
+// the configured EDNS UDP size and padding
+func (r *UpstreamResolver) query(req *dns.Msg, ip net.IP, protocol model.RequestProtocol) *dns.Msg {
+	msg := req
+
+	if r.cookies != nil {
+		// the request of the client mustn't be modified
+		msg = msg.Copy()
+
+		// a cookie of the client is meant for blocky, the upstream gets its own
+		util.RemoveEdns0Cookie(msg)
+
+		if protocol == model.RequestProtocolUDP {
+			util.SetEdns0Cookie(msg, r.cookies.cookie(ip))
+		}
+	}
+
+	if opt := msg.IsEdns0(); opt != nil && r.udpSize > 0 && opt.UDPSize() != r.udpSize {
+		if msg == req {
+			msg = msg.Copy()
+		}
+
+		msg.IsEdns0().SetUDPSize(r.udpSize)
+	}
+
+	if r.padding {
+		// padded last, so the padding covers all other EDNS options of the query
+		msg = util.PadQuery(msg, util.QueryPaddingBlockSize)
+	}
+
+	return msg
+}
+
+// removeEdns0Options removes the padding and cookie echoed by the upstream and the EDNS record,
+// if it was only added for them
+func (r *UpstreamResolver) removeEdns0Options(request *model.Request, resp *dns.Msg) *dns.Msg {
+	if !r.padding && r.cookies == nil {
+		return resp
+	}
+
+	if r.padding {
+		util.RemoveEdns0Padding(resp)
+	}
+
+	if r.cookies != nil {
+		util.RemoveEdns0Cookie(resp)
+	}
+
+	if request.Req.IsEdns0() == nil {
+		resp.Extra = slices.DeleteFunc(resp.Extra, func(rr dns.RR) bool {
+			return rr.Header().Rrtype == dns.TypeOPT
+		})
+	}
+
+	return resp
+}
+
+// validateResponse checks that the response matches the request and echoes the cookie sent via UDP
+func (r *UpstreamResolver) validateResponse(
+	request *model.Request, ip net.IP, protocol model.RequestProtocol, resp *dns.Msg,
+) error {
+	err := util.ValidateResponse(request.Req, resp)
+	if err == nil && r.cookies != nil && protocol == model.RequestProtocolUDP {
+		err = r.cookies.update(ip, resp)
+	}
+
+	if err != nil {
+		r.log().WithField("upstream", r.upstream.String()).Warnf("dropping response: %s", err)
+
+		evt.Bus().Publish(evt.UpstreamResponseMismatch, r.upstream.String())
+	}
+
+	return err
+}
+
+// checkCNAMEChain rejects answers with CNAME loops or chains longer than the configured max length
+func (r *UpstreamResolver) checkCNAMEChain(request *model.Request, resp *dns.Msg) error {
+	if len(request.Req.Question) == 0 {
+		return nil
+	}
+
+	err := util.CheckCNAMEChain(request.Req.Question[0].Name, resp.Answer, r.maxCNAMEChainLength)
+	if err != nil {
+		r.log().WithField("upstream", r.upstream.String()).Warnf("replacing response with SERVFAIL: %s", err)
+	}
+
+	return err
+}
+
+// newInvalidDataResponse returns a SERVFAIL response with an extended DNS error explaining why
+// the upstream response was rejected
+func newInvalidDataResponse(request *dns.Msg, reason error) *dns.Msg {
+	return newExtendedErrorResponse(request, dns.ExtendedErrorCodeInvalidData, reason)
+}
+
+// newExtendedErrorResponse returns a SERVFAIL response with the extended DNS error (RFC 8914)
+func newExtendedErrorResponse(request *dns.Msg, infoCode uint16, reason error) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetRcode(request, dns.RcodeServerFailure)
+
+	opt := new(dns.OPT)
+	opt.Hdr.Name = "."
+	opt.Hdr.Rrtype = dns.TypeOPT
+	opt.Option = append(opt.Option, &dns.EDNS0_EDE{
+		InfoCode:  infoCode,
+		ExtraText: reason.Error(),
+	})
+	resp.Extra = append(resp.Extra, opt)
+
+	return resp
+}
+
 // Resolve calls external resolver
 func (r *UpstreamResolver) Resolve(request *model.Request) (response *model.Response, err error) {
 	ips, err := r.bootstrap.UpstreamIPs(r)
@@ -246,13 +445,15 @@ func (r *UpstreamResolver) Resolve(request *model.Request) (response *model.Resp
 		ip   net.IP
 	)
 
+	time.Sleep(chaos.UpstreamLatency())
+
 	err = retry.Do(
 		func() error {
 			ip = ips.Current()
 			upstreamURL := r.upstreamClient.fmtURL(ip, r.upstream.Port, r.upstream.Path)
 
 			var err error
-			resp, rtt, err = r.upstreamClient.callExternal(request.Req, upstreamURL, request.Protocol)
+			resp, rtt, err = r.exchange(request, ip, upstreamURL)
 			if err == nil {
 				r.log().WithFields(logrus.Fields{
 					"answer":           util.AnswerToString(resp.Answer),
@@ -289,8 +490,21 @@ func (r *UpstreamResolver) Resolve(request *model.Request) (response *model.Resp
 			ips.Next()
 		}))
 	if err != nil {
+		r.errorLog.failure(r.log(), err)
+
 		return nil, err
 	}
 
+	r.errorLog.success(r.log())
+
+	if chaos.ForceServFail(r.upstream) {
+		resp = new(dns.Msg)
+		resp.SetRcode(request.Req, dns.RcodeServerFailure)
+	}
+
+	if err := r.checkCNAMEChain(request, resp); err != nil {
+		resp = newInvalidDataResponse(request.Req, err)
+	}
+
 	return &model.Response{Res: resp, Reason: fmt.Sprintf("RESOLVED (%s)", r.upstream)}, nil
 }
@@ -3,11 +3,15 @@ package resolver
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
+	"github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
@@ -53,10 +57,37 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 		})
 	})
 
+	Describe("Padding", func() {
+		newResolver := func(upstream string, bootstrap *Bootstrap) *UpstreamResolver {
+			u, err := config.ParseUpstream(upstream)
+			Expect(err).Should(Succeed())
+
+			return newUpstreamResolverUnchecked(u, bootstrap)
+		}
+
+		It("should pad only encrypted upstreams by default", func() {
+			bootstrap := &Bootstrap{padEncrypted: true}
+
+			Expect(newResolver("tcp-tls:dns.example.com", bootstrap).padding).Should(BeTrue())
+			Expect(newResolver("https://dns.example.com/dns-query", bootstrap).padding).Should(BeTrue())
+			Expect(newResolver("dns.example.com", bootstrap).padding).Should(BeFalse())
+		})
+
+		It("should pad upstreams with the padding option", func() {
+			upstream, err := config.ParseUpstream("tcp-tls:dns.example.com")
+			Expect(err).Should(Succeed())
+
+			upstream.Padding = true
+
+			Expect(newUpstreamResolverUnchecked(upstream, &Bootstrap{}).padding).Should(BeTrue())
+			Expect(newResolver("tcp-tls:dns.example.com", &Bootstrap{}).padding).Should(BeFalse())
+		})
+	})
+
 	Describe("Using DNS upstream", func() {
 		When("Configured DNS resolver can resolve query", func() {
 			It("should return answer from DNS upstream", func() {
-				mockUpstream := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
 				DeferCleanup(mockUpstream.Close)
 
 				upstream := mockUpstream.Start()
@@ -75,7 +106,7 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 		})
 		When("Configured DNS resolver can't resolve query", func() {
 			It("should return response code from DNS upstream", func() {
-				mockUpstream := NewMockUDPUpstreamServer().WithAnswerError(dns.RcodeNameError)
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerError(dns.RcodeNameError)
 				DeferCleanup(mockUpstream.Close)
 
 				upstream := mockUpstream.Start()
@@ -93,7 +124,7 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 		})
 		When("Configured DNS resolver fails", func() {
 			It("should return error", func() {
-				mockUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 					return nil
 				})
 				DeferCleanup(mockUpstream.Close)
@@ -104,6 +135,143 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 				Expect(err).Should(HaveOccurred())
 			})
 		})
+		When("the answer is larger than the UDP size", func() {
+			var mockUpstream *dnstest.MockUpstreamServer
+
+			BeforeEach(func() {
+				txt := make([]string, 0, 30)
+				for i := 0; i < 30; i++ {
+					txt = append(txt, fmt.Sprintf(`example.com 123 IN TXT "%d %s"`, i, strings.Repeat("x", 100)))
+				}
+
+				mockUpstream = dnstest.NewMockUpstreamServer().WithAnswerRR(txt...)
+				DeferCleanup(mockUpstream.Close)
+			})
+
+			It("should retry the truncated UDP response via TCP", func() {
+				sut := newUpstreamResolverUnchecked(mockUpstream.Start(), nil)
+
+				resp, err := sut.Resolve(newRequest("example.com.", TXT))
+				Expect(err).Should(Succeed())
+				Expect(resp.Res.Truncated).Should(BeFalse())
+				Expect(resp.Res.Answer).Should(HaveLen(30))
+
+				Expect(mockUpstream.GetCallCount()).Should(Equal(2))
+			})
+
+			It("should advertise the configured UDP size", func() {
+				sut := newUpstreamResolverUnchecked(mockUpstream.Start(), &Bootstrap{ednsUDPSize: 1232})
+
+				request := newRequest("example.com.", TXT)
+				request.Req.SetEdns0(dns.DefaultMsgSize, false)
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+				Expect(resp.Res.Answer).Should(HaveLen(30))
+
+				requests := mockUpstream.Requests()
+				Expect(requests).Should(HaveLen(2), "the answer doesn't fit into 1232 bytes")
+				Expect(requests[0].IsEdns0().UDPSize()).Should(BeEquivalentTo(1232))
+
+				Expect(request.Req.IsEdns0().UDPSize()).Should(BeEquivalentTo(dns.DefaultMsgSize),
+					"the request must not be modified")
+			})
+		})
+
+		When("the upstream requires DNS cookies", func() {
+			var (
+				mockUpstream *dnstest.MockUpstreamServer
+				bootstrap    *Bootstrap
+			)
+
+			BeforeEach(func() {
+				mockUpstream = dnstest.NewMockUpstreamServer().
+					WithAnswerRR("example.com 123 IN A 123.124.122.122").
+					WithCookies()
+				DeferCleanup(mockUpstream.Close)
+
+				bootstrap = &Bootstrap{upstreamCookies: newUpstreamCookies(time.Hour)}
+			})
+
+			It("should repeat the query with the server cookie and send it afterwards", func() {
+				sut := newUpstreamResolverUnchecked(mockUpstream.Start(), bootstrap)
+
+				resp, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+				Expect(mockUpstream.GetCallCount()).Should(Equal(2))
+
+				By("removing the EDNS record, which the client didn't send", func() {
+					Expect(resp.Res.IsEdns0()).Should(BeNil())
+				})
+
+				resp, err = sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+				Expect(mockUpstream.GetCallCount()).Should(Equal(3))
+			})
+
+			It("should neither pass the cookie of the client nor the one of the upstream", func() {
+				sut := newUpstreamResolverUnchecked(mockUpstream.Start(), bootstrap)
+
+				request := newRequest("example.com.", A)
+				util.SetEdns0Cookie(request.Req, "0102030405060708")
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+				Expect(resp.Res.IsEdns0()).ShouldNot(BeNil())
+				Expect(util.Edns0Cookie(resp.Res)).Should(BeNil())
+
+				Expect(util.Edns0Cookie(mockUpstream.LastRequest()).Cookie).ShouldNot(HavePrefix("0102030405060708"))
+				Expect(util.Edns0Cookie(request.Req).Cookie).Should(Equal("0102030405060708"),
+					"the request must not be modified")
+			})
+
+			It("should send TCP queries without cookie", func() {
+				sut := newUpstreamResolverUnchecked(mockUpstream.Start(), bootstrap)
+
+				request := newRequest("example.com.", A)
+				request.Protocol = RequestProtocolTCP
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+				Expect(mockUpstream.LastRequest().IsEdns0()).Should(BeNil())
+			})
+
+			It("should be refused without cookies", func() {
+				sut := newUpstreamResolverUnchecked(mockUpstream.Start(), nil)
+
+				resp, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(HaveReturnCode(dns.RcodeRefused))
+			})
+		})
+
+		When("the response doesn't echo the client cookie", func() {
+			It("should drop the response and retry via TCP", func() {
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+					response, err := util.NewMsgWithAnswer("example.com", 123, A, "123.124.122.122")
+					Expect(err).Should(Succeed())
+
+					util.SetEdns0Cookie(response, "01020304050607080102030405060708")
+
+					return response
+				})
+				DeferCleanup(mockUpstream.Close)
+
+				sut := newUpstreamResolverUnchecked(mockUpstream.Start(),
+					&Bootstrap{upstreamCookies: newUpstreamCookies(time.Hour)})
+
+				resp, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+				Expect(resp.Res.IsEdns0()).Should(BeNil())
+
+				Expect(mockUpstream.GetCallCount()).Should(Equal(2))
+			})
+		})
+
 		When("Timeout occurs", func() {
 			var counter int32
 			var attemptsWithTimeout int32
@@ -120,7 +288,7 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 
 					return response
 				}
-				mockUpstream := NewMockUDPUpstreamServer().WithAnswerFn(resolveFn)
+				mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(resolveFn)
 				DeferCleanup(mockUpstream.Close)
 
 				upstream := mockUpstream.Start()
@@ -154,6 +322,246 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 		})
 	})
 
+	Describe("Response validation", func() {
+		var client *spoofingUpstreamClient
+
+		BeforeEach(func() {
+			sutConfig = config.Upstream{Net: config.NetProtocolTcpUdp, Host: "127.0.0.1", Port: 53}
+			client = &spoofingUpstreamClient{}
+		})
+
+		JustBeforeEach(func() {
+			sut.upstreamClient = client
+		})
+
+		When("the UDP response is for another name", func() {
+			It("should drop it and retry via TCP", func() {
+				mismatches := make(chan string, 1)
+				Expect(evt.Bus().SubscribeOnce(evt.UpstreamResponseMismatch, func(upstream string) {
+					mismatches <- upstream
+				})).Should(Succeed())
+
+				Expect(sut.Resolve(newRequest("example.com.", A))).
+					Should(SatisfyAll(
+						BeDNSRecord("example.com.", A, "123.124.122.122"),
+						HaveResponseType(ResponseTypeRESOLVED),
+					))
+
+				Expect(client.protocols).Should(Equal([]RequestProtocol{RequestProtocolUDP, RequestProtocolTCP}))
+				Expect(mismatches).Should(Receive(Equal(sutConfig.String())))
+			})
+		})
+
+		When("the TCP response is for another name too", func() {
+			BeforeEach(func() {
+				client.spoofTCP = true
+			})
+
+			It("should return an error", func() {
+				_, err := sut.Resolve(newRequest("example.com.", A))
+
+				Expect(err).Should(MatchError(util.ErrResponseMismatch))
+			})
+		})
+
+		When("the request was received via TCP", func() {
+			It("should not retry", func() {
+				req := newRequest("example.com.", A)
+				req.Protocol = RequestProtocolTCP
+				client.spoofTCP = true
+
+				_, err := sut.Resolve(req)
+
+				Expect(err).Should(MatchError(util.ErrResponseMismatch))
+				Expect(client.protocols).Should(Equal([]RequestProtocol{RequestProtocolTCP}))
+			})
+		})
+	})
+
+	Describe("CNAME chain validation", func() {
+		var answer []string
+
+		BeforeEach(func() {
+			answer = []string{
+				"example.com 123 IN CNAME a.example.net",
+				"a.example.net 123 IN CNAME b.example.net",
+				"b.example.net 123 IN A 123.124.122.122",
+			}
+		})
+
+		JustBeforeEach(func() {
+			mockUpstream := dnstest.NewMockUpstreamServer().WithAnswerRR(answer...)
+			DeferCleanup(mockUpstream.Close)
+
+			sutConfig = mockUpstream.Start()
+			sut = newUpstreamResolverUnchecked(sutConfig, nil)
+			sut.maxCNAMEChainLength = 2
+		})
+
+		It("should return a chain within the limit", func() {
+			resp, err := sut.Resolve(newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveReturnCode(dns.RcodeSuccess))
+			Expect(resp.Res.Answer).Should(HaveLen(3))
+		})
+
+		When("the chain is longer than the limit", func() {
+			JustBeforeEach(func() {
+				sut.maxCNAMEChainLength = 1
+			})
+
+			It("should return SERVFAIL with an extended error", func() {
+				resp, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(SatisfyAll(
+					HaveNoAnswer(),
+					HaveResponseType(ResponseTypeRESOLVED),
+					HaveReturnCode(dns.RcodeServerFailure),
+					HaveReason(fmt.Sprintf("RESOLVED (%s)", sutConfig)),
+				))
+				Expect(resp.Res.IsEdns0().Option).Should(ContainElement(SatisfyAll(
+					HaveField("InfoCode", dns.ExtendedErrorCodeInvalidData),
+					HaveField("ExtraText", ContainSubstring(util.ErrCNAMEChainTooLong.Error())),
+				)))
+			})
+		})
+
+		When("the upstream returns a CNAME loop", func() {
+			BeforeEach(func() {
+				answer = []string{
+					"example.com 123 IN CNAME a.example.net",
+					"a.example.net 123 IN CNAME example.com",
+				}
+			})
+
+			It("should return SERVFAIL with an extended error", func() {
+				resp, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(SatisfyAll(
+					HaveNoAnswer(),
+					HaveReturnCode(dns.RcodeServerFailure),
+				))
+				Expect(resp.Res.IsEdns0().Option).Should(ContainElement(SatisfyAll(
+					HaveField("InfoCode", dns.ExtendedErrorCodeInvalidData),
+					HaveField("ExtraText", ContainSubstring(util.ErrCNAMELoop.Error())),
+				)))
+			})
+
+			It("should detect the loop without a length limit", func() {
+				sut.maxCNAMEChainLength = 0
+
+				Expect(sut.Resolve(newRequest("example.com.", A))).
+					Should(HaveReturnCode(dns.RcodeServerFailure))
+			})
+		})
+	})
+
+	Describe("IPv6 zone", func() {
+		It("should add the zone to the address", func() {
+			client := createUpstreamClient(config.Upstream{
+				Net: config.NetProtocolTcpUdp, Host: "fe80::1", Zone: "eth0", Port: 53,
+			}, 0, "")
+
+			Expect(client.fmtURL(net.ParseIP("fe80::1"), 53, "")).Should(Equal("[fe80::1%eth0]:53"))
+		})
+
+		When("the upstream is reachable via a zoned IPv6 address", func() {
+			var loopback string
+
+			BeforeEach(func() {
+				ifaces, err := net.Interfaces()
+				Expect(err).Should(Succeed())
+
+				for _, iface := range ifaces {
+					if iface.Flags&net.FlagLoopback != 0 {
+						loopback = iface.Name
+
+						break
+					}
+				}
+
+				if loopback == "" {
+					Skip("no loopback interface")
+				}
+			})
+
+			// startIPv6Server starts a DNS server on the IPv6 loopback address and returns its port
+			startIPv6Server := func(network string) uint16 {
+				server := &dns.Server{
+					Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+						resp, err := util.NewMsgWithAnswer("example.com.", 123, A, "123.124.122.122")
+						Expect(err).Should(Succeed())
+
+						resp.SetReply(req)
+						_ = w.WriteMsg(resp)
+					}),
+				}
+
+				var addr net.Addr
+
+				if network == "udp" {
+					conn, err := net.ListenPacket("udp6", "[::1]:0")
+					if err != nil {
+						Skip("IPv6 loopback isn't available: " + err.Error())
+					}
+
+					server.PacketConn, addr = conn, conn.LocalAddr()
+				} else {
+					listener, err := net.Listen("tcp6", "[::1]:0")
+					if err != nil {
+						Skip("IPv6 loopback isn't available: " + err.Error())
+					}
+
+					server.Listener, addr = listener, listener.Addr()
+				}
+
+				started := make(chan struct{})
+				server.NotifyStartedFunc = func() { close(started) }
+
+				go func() { _ = server.ActivateAndServe() }()
+
+				Eventually(started).Should(BeClosed())
+				DeferCleanup(server.Shutdown)
+
+				_, port, err := net.SplitHostPort(addr.String())
+				Expect(err).Should(Succeed())
+
+				p, err := config.ConvertPort(port)
+				Expect(err).Should(Succeed())
+
+				return p
+			}
+
+			DescribeTable("should resolve via the interface of the zone",
+				func(network string, protocol RequestProtocol) {
+					upstream := config.Upstream{
+						Net:  config.NetProtocolTcpUdp,
+						Host: "::1",
+						Zone: loopback,
+						Port: startIPv6Server(network),
+					}
+
+					sut := newUpstreamResolverUnchecked(upstream, nil)
+
+					request := newRequest("example.com.", A)
+					request.Protocol = protocol
+
+					Expect(sut.Resolve(request)).
+						Should(SatisfyAll(
+							BeDNSRecord("example.com.", A, "123.124.122.122"),
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReason(fmt.Sprintf("RESOLVED (tcp+udp:[::1%%%s]:%d)", loopback, upstream.Port)),
+						))
+				},
+				Entry("UDP", "udp", RequestProtocolUDP),
+				Entry("TCP", "tcp", RequestProtocolTCP),
+			)
+		})
+	})
+
 	Describe("Using Dns over HTTP (DOH) upstream", func() {
 		var (
 			sut              *UpstreamResolver
@@ -196,6 +604,82 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 						))
 			})
 		})
+		When("padding is enabled", func() {
+			var (
+				queryLen    int
+				querySubnet *net.IPNet
+			)
+
+			BeforeEach(func() {
+				respFn = func(request *dns.Msg) *dns.Msg {
+					queryLen = request.Len()
+					querySubnet = util.ClientSubnet(request)
+
+					response := new(dns.Msg)
+					response.SetReply(request)
+
+					rr, err := dns.NewRR(request.Question[0].Name + " 123 IN A 123.124.122.122")
+					Expect(err).Should(Succeed())
+
+					response.Answer = []dns.RR{rr}
+
+					// echo padding like some upstreams do
+					response.SetEdns0(dns.DefaultMsgSize, false)
+					opt := response.IsEdns0()
+					opt.Option = append(opt.Option, &dns.EDNS0_PADDING{Padding: make([]byte, 20)})
+
+					return response
+				}
+			})
+
+			JustBeforeEach(func() {
+				sut.padding = true
+			})
+
+			It("should pad the query and strip the padding from the response", func() {
+				resp, err := sut.Resolve(newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp.Res).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+				Expect(resp.Res.IsEdns0()).Should(BeNil())
+
+				Expect(queryLen).Should(Equal(util.QueryPaddingBlockSize))
+			})
+
+			DescribeTable("should pad queries to a multiple of the block size",
+				func(domain string, expectedLen int) {
+					resp, err := sut.Resolve(newRequest(domain, A))
+					Expect(err).Should(Succeed())
+					Expect(resp.Res).Should(BeDNSRecord(domain, A, "123.124.122.122"))
+
+					Expect(queryLen).Should(Equal(expectedLen))
+				},
+				Entry("short name", "a.io.", 128),
+				Entry("exactly one block without padding",
+					strings.Repeat("a", 40)+"."+strings.Repeat("b", 42)+".example.com.", 128),
+				Entry("one byte above a block",
+					strings.Repeat("a", 40)+"."+strings.Repeat("b", 43)+".example.com.", 256),
+				Entry("longest name",
+					strings.Repeat("a", 63)+"."+strings.Repeat("b", 63)+"."+strings.Repeat("c", 63)+"."+
+						strings.Repeat("d", 61)+".", 384),
+			)
+
+			It("should pad after the other EDNS options", func() {
+				_, subnet, err := net.ParseCIDR("192.168.178.0/24")
+				Expect(err).Should(Succeed())
+
+				request := newRequest("example.com.", A)
+				util.SetClientSubnet(request.Req, subnet)
+
+				resp, err := sut.Resolve(request)
+				Expect(err).Should(Succeed())
+				Expect(resp.Res).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+				Expect(resp.Res.IsEdns0()).ShouldNot(BeNil(), "the client sent EDNS")
+
+				Expect(queryLen).Should(Equal(util.QueryPaddingBlockSize))
+				Expect(querySubnet).Should(Equal(subnet))
+				Expect(request.Req.IsEdns0().Option).Should(HaveLen(1), "the request must not be modified")
+			})
+		})
 		When("Configured DOH resolver returns wrong http status code", func() {
 			BeforeEach(func() {
 				modifyHTTPRespFn = func(w http.ResponseWriter) {
@@ -251,3 +735,33 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 		})
 	})
 })
+
+// spoofingUpstreamClient answers UDP queries with a response for another name,
+// TCP queries are answered correctly unless spoofTCP is set
+type spoofingUpstreamClient struct {
+	dnsUpstreamClient
+
+	spoofTCP  bool
+	protocols []RequestProtocol
+}
+
+func (c *spoofingUpstreamClient) callExternal(
+	msg *dns.Msg, _ string, protocol RequestProtocol,
+) (*dns.Msg, time.Duration, error) {
+	c.protocols = append(c.protocols, protocol)
+
+	name := msg.Question[0].Name
+	if protocol == RequestProtocolUDP || c.spoofTCP {
+		name = "evil.com."
+	}
+
+	response, err := util.NewMsgWithAnswer(name, 123, A, "123.124.122.122")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response.SetReply(msg)
+	response.Question[0].Name = name
+
+	return response, time.Millisecond, nil
+}
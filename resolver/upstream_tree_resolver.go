@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
@@ -64,6 +65,24 @@ func (r *UpstreamTreeResolver) String() string {
 	return fmt.Sprintf("%s upstreams %q", upstreamTreeResolverType, strings.Join(result, ", "))
 }
 
+// resetConnections implements `connectionResetter`.
+func (r *UpstreamTreeResolver) resetConnections() {
+	for _, branch := range r.branches {
+		ResetConnections(branch)
+	}
+}
+
+// upstreamStatus implements `upstreamStatusProvider`.
+func (r *UpstreamTreeResolver) upstreamStatus() []api.UpstreamStatus {
+	var result []api.UpstreamStatus
+
+	for _, branch := range r.branches {
+		result = append(result, UpstreamStatus(branch)...)
+	}
+
+	return result
+}
+
 func (r *UpstreamTreeResolver) Resolve(request *model.Request) (*model.Response, error) {
 	logger := log.WithPrefix(request.Log, upstreamTreeResolverType)
 
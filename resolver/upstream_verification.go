@@ -0,0 +1,235 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// used if the retry is not configured, e.g. for conditional upstreams
+const (
+	defaultVerifyRetryInterval    = 5 * time.Second
+	defaultVerifyRetryMaxInterval = 5 * time.Minute
+)
+
+var errNoActiveUpstream = errors.New("no active upstream, all upstreams failed the verification")
+
+// upstreamVerification is the verification state of an upstream, it is replaced as a whole on each change
+type upstreamVerification struct {
+	state          api.UpstreamVerificationState
+	failedAttempts uint
+	lastError      error
+}
+
+// upstreamStatusProvider is implemented by resolvers that hold upstream groups.
+type upstreamStatusProvider interface {
+	upstreamStatus() []api.UpstreamStatus
+}
+
+// UpstreamStatus returns the verification status of the upstreams of all resolvers in the chain.
+func UpstreamStatus(resolver Resolver) []api.UpstreamStatus {
+	var result []api.UpstreamStatus
+
+	ForEach(resolver, func(res Resolver) {
+		if provider, ok := res.(upstreamStatusProvider); ok {
+			result = append(result, provider.upstreamStatus()...)
+		}
+	})
+
+	return result
+}
+
+// testResolver sends a test query to verify the resolver is reachable and working
+func testResolver(r Resolver) error {
+	request := newRequest("github.com.", dns.Type(dns.TypeA))
+
+	resp, err := r.Resolve(request)
+	if err != nil || resp.RType != model.ResponseTypeRESOLVED {
+		return fmt.Errorf("test resolve of upstream server failed: %w", err)
+	}
+
+	return nil
+}
+
+// createUpstreamGroups creates the resolvers of the configured upstream groups.
+// The upstreams are not verified, see `verifyUpstreamGroups`.
+func createUpstreamGroups(cfg config.UpstreamsConfig, bootstrap *Bootstrap) map[string][]Resolver {
+	resolverGroups := make(map[string][]Resolver, len(cfg.Groups))
+
+	for name, upstreamCfgs := range cfg.Groups {
+		group := make([]Resolver, 0, len(upstreamCfgs))
+
+		for _, u := range upstreamCfgs {
+			group = append(group, newUpstreamResolverUnchecked(u, bootstrap))
+		}
+
+		resolverGroups[name] = group
+	}
+
+	return resolverGroups
+}
+
+// verifyUpstreamGroups tests each upstream. Upstreams which fail are set to pending and retried in the background,
+// until a verification succeeds. Returns an error if a group has no working upstream, unless empty groups are allowed.
+func verifyUpstreamGroups(
+	cfg config.UpstreamsConfig, groups map[string][]*upstreamResolverStatus, logger *logrus.Entry,
+) error {
+	var pending []*upstreamResolverStatus
+
+	for name, group := range groups {
+		start := time.Now()
+		hasValidResolver := false
+
+		for _, status := range group {
+			err := testResolver(status.resolver)
+			if err != nil {
+				logger.Warnf("upstream group %s: %s: %v, retrying in the background", name, status.resolver, err)
+
+				status.setVerification(api.UpstreamVerificationPending, 1, err)
+				pending = append(pending, status)
+
+				continue
+			}
+
+			hasValidResolver = true
+		}
+
+		evt.Bus().Publish(evt.StartupPhaseCompleted, "upstream verification "+name, time.Since(start))
+
+		if !hasValidResolver {
+			if !cfg.AllowEmptyOnStart {
+				return fmt.Errorf("no valid upstream for group %s", name)
+			}
+
+			logger.Warnf("no valid upstream for group %s, starting anyway", name)
+		}
+	}
+
+	for _, status := range pending {
+		go status.retryVerification(cfg.VerifyRetry, logger)
+	}
+
+	return nil
+}
+
+// retryVerification tests the upstream with exponential backoff until it succeeds or all attempts failed
+func (r *upstreamResolverStatus) retryVerification(cfg config.UpstreamVerifyRetry, logger *logrus.Entry) {
+	interval := cfg.Interval.ToDuration()
+	if interval <= 0 {
+		interval = defaultVerifyRetryInterval
+	}
+
+	maxInterval := cfg.MaxInterval.ToDuration()
+	if maxInterval <= 0 {
+		maxInterval = defaultVerifyRetryMaxInterval
+	}
+
+	for {
+		time.Sleep(interval)
+
+		err := testResolver(r.resolver)
+		if err == nil {
+			logger.Infof("upstream %s verified, using it from now on", r.resolver)
+
+			r.setVerification(api.UpstreamVerificationActive, 0, nil)
+
+			return
+		}
+
+		failedAttempts := r.verification.Load().failedAttempts + 1
+
+		if cfg.Attempts > 0 && failedAttempts >= cfg.Attempts {
+			logger.Errorf("upstream %s: %v, giving up after %d attempts", r.resolver, err, failedAttempts)
+
+			r.setVerification(api.UpstreamVerificationFailed, failedAttempts, err)
+
+			return
+		}
+
+		r.setVerification(api.UpstreamVerificationPending, failedAttempts, err)
+
+		interval = min(2*interval, maxInterval)
+
+		logger.Debugf("upstream %s: %v, retrying in %s", r.resolver, err, interval)
+	}
+}
+
+func (r *upstreamResolverStatus) setVerification(
+	state api.UpstreamVerificationState, failedAttempts uint, err error,
+) {
+	r.verification.Store(&upstreamVerification{
+		state:          state,
+		failedAttempts: failedAttempts,
+		lastError:      err,
+	})
+}
+
+// isActive returns true if the upstream can be used
+func (r *upstreamResolverStatus) isActive() bool {
+	return r.verification.Load().state == api.UpstreamVerificationActive
+}
+
+// activeResolvers returns the resolvers which can be used
+func activeResolvers(resolvers []*upstreamResolverStatus) []*upstreamResolverStatus {
+	for i, status := range resolvers {
+		if status.isActive() {
+			continue
+		}
+
+		// at least one inactive resolver: copy the active ones
+		result := make([]*upstreamResolverStatus, 0, len(resolvers)-1)
+		result = append(result, resolvers[:i]...)
+
+		for _, s := range resolvers[i+1:] {
+			if s.isActive() {
+				result = append(result, s)
+			}
+		}
+
+		return result
+	}
+
+	return resolvers
+}
+
+// upstreamGroupStatus returns the verification status of the upstreams of all groups
+func upstreamGroupStatus(groups map[string][]*upstreamResolverStatus) []api.UpstreamStatus {
+	var result []api.UpstreamStatus
+
+	for name, group := range groups {
+		for _, status := range group {
+			verification := status.verification.Load()
+
+			entry := api.UpstreamStatus{
+				Group:          name,
+				Upstream:       upstreamName(status.resolver),
+				State:          verification.state,
+				FailedAttempts: verification.failedAttempts,
+			}
+
+			if verification.lastError != nil {
+				entry.LastError = verification.lastError.Error()
+			}
+
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+func upstreamName(r Resolver) string {
+	if upstream, ok := r.(*UpstreamResolver); ok {
+		return upstream.upstream.String()
+	}
+
+	return Name(r)
+}
@@ -0,0 +1,185 @@
+package resolver
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Upstream verification", func() {
+	var (
+		sutConfig config.UpstreamsConfig
+
+		good, flaky *mockResolver
+		reachable   atomic.Bool
+		groups      map[string][]*upstreamResolverStatus
+	)
+
+	BeforeEach(func() {
+		sutConfig = config.UpstreamsConfig{
+			VerifyRetry: config.UpstreamVerifyRetry{
+				Interval:    config.Duration(10 * time.Millisecond),
+				MaxInterval: config.Duration(20 * time.Millisecond),
+			},
+		}
+
+		reachable.Store(false)
+
+		good = &mockResolver{}
+		good.On("Resolve", mock.Anything).Return(&Response{
+			Res:   new(dns.Msg),
+			RType: ResponseTypeRESOLVED,
+		}, nil)
+
+		flaky = &mockResolver{ResolveFn: func(*Request) (*Response, error) {
+			if !reachable.Load() {
+				return nil, errors.New("connection refused")
+			}
+
+			return &Response{Res: new(dns.Msg), RType: ResponseTypeRESOLVED}, nil
+		}}
+		flaky.On("Resolve", mock.Anything)
+	})
+
+	JustBeforeEach(func() {
+		groups = newParallelBestResolver(sutConfig, map[string][]Resolver{
+			upstreamDefaultCfgName: {good, flaky},
+			"laptop":               {flaky},
+		}).resolversPerClient
+	})
+
+	verify := func() error {
+		return verifyUpstreamGroups(sutConfig, groups, log.PrefixedLog("test"))
+	}
+
+	flakyStatus := func(group string) func() api.UpstreamStatus {
+		return func() api.UpstreamStatus {
+			status := upstreamGroupStatus(map[string][]*upstreamResolverStatus{group: groups[group]})
+
+			return status[len(status)-1]
+		}
+	}
+
+	When("a group has no working upstream", func() {
+		It("should fail", func() {
+			Expect(verify()).Should(MatchError("no valid upstream for group laptop"))
+		})
+
+		When("empty groups are allowed", func() {
+			BeforeEach(func() {
+				sutConfig.AllowEmptyOnStart = true
+			})
+
+			It("should add the upstream as pending", func() {
+				Expect(verify()).Should(Succeed())
+
+				Expect(flakyStatus("laptop")()).Should(SatisfyAll(
+					HaveField("State", api.UpstreamVerificationPending),
+					HaveField("LastError", ContainSubstring("connection refused")),
+				))
+				Expect(activeResolvers(groups["laptop"])).Should(BeEmpty())
+			})
+		})
+	})
+
+	When("a failing upstream becomes reachable", func() {
+		BeforeEach(func() {
+			sutConfig.AllowEmptyOnStart = true
+		})
+
+		It("should promote it to active", func() {
+			Expect(verify()).Should(Succeed())
+
+			active := activeResolvers(groups[upstreamDefaultCfgName])
+			Expect(active).Should(HaveLen(1))
+			Expect(active[0].resolver).Should(BeIdenticalTo(good))
+
+			Eventually(flakyStatus(upstreamDefaultCfgName)).Should(
+				HaveField("FailedAttempts", BeNumerically(">=", 2)),
+			)
+
+			reachable.Store(true)
+
+			Eventually(flakyStatus(upstreamDefaultCfgName)).Should(Equal(api.UpstreamStatus{
+				Group:    upstreamDefaultCfgName,
+				Upstream: "mock",
+				State:    api.UpstreamVerificationActive,
+			}))
+			Expect(activeResolvers(groups[upstreamDefaultCfgName])).Should(HaveLen(2))
+		})
+	})
+
+	When("the retry attempts are exhausted", func() {
+		BeforeEach(func() {
+			sutConfig.AllowEmptyOnStart = true
+			sutConfig.VerifyRetry.Attempts = 3
+		})
+
+		It("should mark the upstream as failed", func() {
+			Expect(verify()).Should(Succeed())
+
+			Eventually(flakyStatus("laptop")).Should(SatisfyAll(
+				HaveField("State", api.UpstreamVerificationFailed),
+				HaveField("FailedAttempts", BeEquivalentTo(3)),
+			))
+
+			reachable.Store(true)
+
+			Consistently(flakyStatus("laptop"), "50ms").Should(
+				HaveField("State", api.UpstreamVerificationFailed),
+			)
+		})
+	})
+
+	Describe("UpstreamStatus", func() {
+		It("should return the status of the upstreams in the chain", func() {
+			tree, err := NewUpstreamTreeResolver(config.UpstreamsConfig{
+				Groups: config.UpstreamGroups{
+					upstreamDefaultCfgName: {{Host: "1.1.1.1"}},
+					"laptop":               {{Host: "8.8.8.8"}},
+				},
+			}, map[string]Resolver{
+				upstreamDefaultCfgName: newStrictResolver(config.UpstreamsConfig{}, map[string][]Resolver{
+					upstreamDefaultCfgName: {good},
+				}),
+				"laptop": newParallelBestResolver(config.UpstreamsConfig{}, map[string][]Resolver{
+					"laptop": {good, flaky},
+				}),
+			})
+			Expect(err).Should(Succeed())
+
+			chain := Chain(NewFqdnOnlyResolver(config.FqdnOnlyConfig{}), tree)
+
+			Expect(UpstreamStatus(chain)).Should(ConsistOf(
+				api.UpstreamStatus{Group: upstreamDefaultCfgName, Upstream: "mock", State: api.UpstreamVerificationActive},
+				api.UpstreamStatus{Group: "laptop", Upstream: "mock", State: api.UpstreamVerificationActive},
+				api.UpstreamStatus{Group: "laptop", Upstream: "mock", State: api.UpstreamVerificationActive},
+			))
+		})
+	})
+
+	It("should not use unreachable upstreams", func() {
+		sutConfig.AllowEmptyOnStart = true
+		Expect(verify()).Should(Succeed())
+
+		sut := &ParallelBestResolver{
+			configurable:       withConfig(&sutConfig),
+			typed:              withType(parallelResolverType),
+			resolversPerClient: map[string][]*upstreamResolverStatus{"laptop": groups["laptop"]},
+		}
+
+		_, err := sut.Resolve(newRequest("example.com.", A))
+		Expect(err).Should(MatchError(errNoActiveUpstream))
+	})
+})
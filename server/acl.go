@@ -0,0 +1,163 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+)
+
+// aclRefusalLogInterval is the interval in which the refused queries are summarized in the log
+const aclRefusalLogInterval = time.Minute
+
+// clientACL decides which clients may query, it is enforced for all listeners before any resolver.
+// The refused queries are logged at most once per interval, so a scanner can't flood the log
+type clientACL struct {
+	cfg            config.ACLConfig
+	allowed        []*net.IPNet
+	denied         []*net.IPNet
+	trustedProxies []*net.IPNet
+
+	lock       sync.Mutex
+	lastReport time.Time
+	pending    uint
+}
+
+func newClientACL(cfg config.ACLConfig) (*clientACL, error) {
+	allowed, err := cfg.AllowedNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := cfg.DeniedNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := cfg.TrustedProxyNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientACL{
+		cfg:            cfg,
+		allowed:        allowed,
+		denied:         denied,
+		trustedProxies: trustedProxies,
+	}, nil
+}
+
+// isAllowed returns true if the client may query: it must not be denied and, if there are allowed clients,
+// it must be one of them. Clients without IP are refused, unless the ACL is disabled
+func (a *clientACL) isAllowed(ip net.IP) bool {
+	if !a.cfg.IsEnabled() {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	if containsIP(a.denied, ip) {
+		return false
+	}
+
+	return len(a.allowed) == 0 || containsIP(a.allowed, ip)
+}
+
+// refused records a refused query of the client: the first one of each interval is logged with the client,
+// the others are summarized with the next logged one
+func (a *clientACL) refused(ip net.IP, transport string) {
+	evt.Bus().Publish(evt.ServerACLRefused, transport)
+
+	now := time.Now()
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if now.Sub(a.lastReport) < aclRefusalLogInterval {
+		a.pending++
+
+		return
+	}
+
+	if a.pending > 0 {
+		logger().Infof("refused %d more queries of clients not allowed by the ACL in the last %s",
+			a.pending, now.Sub(a.lastReport).Round(time.Second))
+	}
+
+	logger().Infof("refused %s query of client %s, not allowed by the ACL", transport, ip)
+
+	a.lastReport = now
+	a.pending = 0
+}
+
+// dohClientIP returns the IP of the DoH client: the remote address of the connection or, if the connection comes
+// from a trusted proxy, the rightmost untrusted address of the client IP header.
+// Without ACL and trusted proxies, the client IP header is used as is for backwards compatibility
+func (a *clientACL) dohClientIP(r *http.Request) net.IP {
+	if !a.cfg.IsEnabled() && len(a.trustedProxies) == 0 {
+		return net.ParseIP(extractIP(r))
+	}
+
+	ip := parseHostIP(r.RemoteAddr)
+	if !containsIP(a.trustedProxies, ip) {
+		return ip
+	}
+
+	header := r.Header.Get(a.cfg.ClientIPHeader)
+	if header == "" {
+		return ip
+	}
+
+	entries := strings.Split(header, ",")
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entryIP := parseHostIP(entries[i])
+		if entryIP == nil {
+			break
+		}
+
+		ip = entryIP
+
+		if !containsIP(a.trustedProxies, ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// parseHostIP parses an IP with optional port, returns nil if it is no IP
+func parseHostIP(hostPort string) net.IP {
+	hostPort = strings.TrimSpace(hostPort)
+
+	if ip := net.ParseIP(hostPort); ip != nil {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return nil
+	}
+
+	return net.ParseIP(host)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client ACL", func() {
+	var (
+		cfg config.ACLConfig
+		sut *clientACL
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = config.WithDefaults[config.ACLConfig]()
+		Expect(err).Should(Succeed())
+
+		cfg.AllowedClients = []string{"192.168.178.0/24", "10.8.0.0/24", "fd00::/64"}
+		cfg.DeniedClients = []string{"192.168.178.66"}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = newClientACL(cfg)
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("isAllowed", func() {
+		It("should allow only the allowed clients, which aren't denied", func() {
+			Expect(sut.isAllowed(net.ParseIP("192.168.178.10"))).Should(BeTrue())
+			Expect(sut.isAllowed(net.ParseIP("10.8.0.2"))).Should(BeTrue())
+			Expect(sut.isAllowed(net.ParseIP("fd00::10"))).Should(BeTrue())
+
+			Expect(sut.isAllowed(net.ParseIP("192.168.178.66"))).Should(BeFalse())
+			Expect(sut.isAllowed(net.ParseIP("203.0.113.5"))).Should(BeFalse())
+			Expect(sut.isAllowed(nil)).Should(BeFalse())
+		})
+
+		When("only denied clients are configured", func() {
+			BeforeEach(func() {
+				cfg.AllowedClients = nil
+			})
+
+			It("should allow all other clients", func() {
+				Expect(sut.isAllowed(net.ParseIP("203.0.113.5"))).Should(BeTrue())
+				Expect(sut.isAllowed(net.ParseIP("192.168.178.66"))).Should(BeFalse())
+			})
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				cfg.AllowedClients = nil
+				cfg.DeniedClients = nil
+			})
+
+			It("should allow all clients", func() {
+				Expect(sut.isAllowed(net.ParseIP("203.0.113.5"))).Should(BeTrue())
+				Expect(sut.isAllowed(nil)).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("dohClientIP", func() {
+		request := func(remoteAddr, forwardedFor string) *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/dns-query", nil)
+			req.RemoteAddr = remoteAddr
+
+			if forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+			}
+
+			return req
+		}
+
+		It("should ignore the header without trusted proxies", func() {
+			Expect(sut.dohClientIP(request("203.0.113.5:4711", "192.168.178.10"))).
+				Should(Equal(net.ParseIP("203.0.113.5")))
+			Expect(sut.dohClientIP(request("[2001:db8::1]:4711", ""))).
+				Should(Equal(net.ParseIP("2001:db8::1")))
+		})
+
+		When("trusted proxies are configured", func() {
+			BeforeEach(func() {
+				cfg.TrustedProxies = []string{"127.0.0.1", "172.16.0.0/12"}
+			})
+
+			It("should use the rightmost untrusted address of the header", func() {
+				Expect(sut.dohClientIP(request("127.0.0.1:4711", "203.0.113.5, 192.168.178.10, 172.17.0.2"))).
+					Should(Equal(net.ParseIP("192.168.178.10")))
+			})
+
+			It("should use the remote address for untrusted connections", func() {
+				Expect(sut.dohClientIP(request("203.0.113.5:4711", "192.168.178.10"))).
+					Should(Equal(net.ParseIP("203.0.113.5")))
+			})
+
+			It("should use the remote address without header", func() {
+				Expect(sut.dohClientIP(request("127.0.0.1:4711", ""))).Should(Equal(net.ParseIP("127.0.0.1")))
+			})
+
+			When("another header is configured", func() {
+				BeforeEach(func() {
+					cfg.ClientIPHeader = "X-Real-IP"
+				})
+
+				It("should use it", func() {
+					req := request("127.0.0.1:4711", "203.0.113.5")
+					req.Header.Set("X-Real-IP", "192.168.178.10")
+
+					Expect(sut.dohClientIP(req)).Should(Equal(net.ParseIP("192.168.178.10")))
+				})
+			})
+		})
+
+		When("the ACL is disabled and there are no trusted proxies", func() {
+			BeforeEach(func() {
+				cfg.AllowedClients = nil
+				cfg.DeniedClients = nil
+			})
+
+			It("should use the header of all connections", func() {
+				Expect(sut.dohClientIP(request("203.0.113.5:4711", "192.168.178.10"))).
+					Should(Equal(net.ParseIP("192.168.178.10")))
+			})
+		})
+	})
+
+	Describe("refused", func() {
+		var refused atomic.Int32
+
+		BeforeEach(func() {
+			refused.Store(0)
+
+			refusedFn := func(transport string) {
+				if transport == transportDoT {
+					refused.Add(1)
+				}
+			}
+
+			Expect(evt.Bus().Subscribe(evt.ServerACLRefused, refusedFn)).Should(Succeed())
+			DeferCleanup(func() {
+				Expect(evt.Bus().Unsubscribe(evt.ServerACLRefused, refusedFn)).Should(Succeed())
+			})
+		})
+
+		It("should count all refused queries, but log only the first one of the interval", func() {
+			for i := 0; i < 5; i++ {
+				sut.refused(net.ParseIP("203.0.113.5"), transportDoT)
+			}
+
+			Eventually(refused.Load).Should(BeNumerically("==", 5))
+			Expect(sut.pending).Should(BeNumerically("==", 4))
+		})
+	})
+})
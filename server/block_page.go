@@ -0,0 +1,201 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/resolver"
+	"github.com/0xERR0R/blocky/web"
+)
+
+const pathAllowEntry = "/api/blocking/allow"
+
+// blockPage answers every request of browsers, which were sent to blocky by the block type,
+// with a page explaining the block. The blocked domain is taken from the host header or the TLS server name
+type blockPage struct {
+	tmpl    *template.Template
+	checker api.BlockingChecker
+	// address of the HTTP API, the page has no allow button if empty
+	apiAddress string
+}
+
+// blockPageData is passed to the template of the block page
+type blockPageData struct {
+	Domain string
+	// Groups which block the domain for the client
+	Groups []string
+	Reason string
+	// AllowURL is the URL to add a whitelist entry, see `POST /api/blocking/allow`. Empty if the API isn't reachable
+	AllowURL string
+}
+
+func createBlockPage(cfg *config.Config, queryResolver resolver.ChainedResolver) (*blockPage, error) {
+	checker, err := resolver.GetFromChainWithType[api.BlockingChecker](queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no blocking check implementation found for the block page %w", err)
+	}
+
+	return newBlockPage(cfg, checker)
+}
+
+func newBlockPage(cfg *config.Config, checker api.BlockingChecker) (*blockPage, error) {
+	text := web.BlockPageTmpl
+
+	if cfg.Blocking.BlockPage.Template != "" {
+		data, err := os.ReadFile(cfg.Blocking.BlockPage.Template)
+		if err != nil {
+			return nil, fmt.Errorf("can't read block page template: %w", err)
+		}
+
+		text = string(data)
+	}
+
+	tmpl, err := template.New("blockPage").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse block page template: %w", err)
+	}
+
+	page := &blockPage{tmpl: tmpl, checker: checker}
+
+	if len(cfg.Ports.HTTP) > 0 {
+		page.apiAddress = getServerAddress(cfg.Ports.HTTP[0])
+	}
+
+	return page, nil
+}
+
+// ServeHTTP implements `http.Handler`, all requests are answered with 403 and the page
+func (p *blockPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := blockPageData{Domain: blockedDomain(r)}
+
+	clientIP, _, _ := net.SplitHostPort(r.RemoteAddr)
+
+	if check, err := p.checker.CheckBlocking(data.Domain, clientIP); err == nil {
+		data.Domain = check.Domain
+		data.Groups = check.Groups
+		data.Reason = check.Reason
+	}
+
+	data.AllowURL = p.allowURL(r)
+
+	w.Header().Set(contentTypeHeader, htmlContentType)
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(http.StatusForbidden)
+
+	if err := p.tmpl.Execute(w, data); err != nil {
+		log.Log().Error("can't write block page: ", log.EscapeInput(err.Error()))
+	}
+}
+
+// allowURL returns the URL of the allow API, using the address of the connection if the API listens on all addresses
+func (p *blockPage) allowURL(r *http.Request) string {
+	if p.apiAddress == "" {
+		return ""
+	}
+
+	host, port, err := net.SplitHostPort(p.apiAddress)
+	if err != nil {
+		return ""
+	}
+
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		localAddr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+		if !ok {
+			return ""
+		}
+
+		host, _, err = net.SplitHostPort(localAddr.String())
+		if err != nil {
+			return ""
+		}
+	}
+
+	return "http://" + net.JoinHostPort(host, port) + pathAllowEntry
+}
+
+// blockedDomain returns the domain requested by the browser
+func blockedDomain(r *http.Request) string {
+	host := r.Host
+
+	if host == "" && r.TLS != nil {
+		host = r.TLS.ServerName
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
+
+func createBlockPageListeners(cfg *config.Config) (httpListeners, httpsListeners []net.Listener, err error) {
+	httpListeners, err = newListeners("block page http", cfg.Blocking.BlockPage.HTTP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	httpsListeners, err = newListeners("block page https", cfg.Blocking.BlockPage.HTTPS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpListeners, httpsListeners, nil
+}
+
+// startBlockPage serves the block page on its listeners. HTTPS uses blocky's certificate,
+// so browsers show a certificate warning before the page
+func (s *Server) startBlockPage(errCh chan<- error) {
+	for i, listener := range s.blockPageListeners {
+		listener := listener
+		address := s.cfg.Blocking.BlockPage.HTTP[i]
+
+		go func() {
+			logger().Infof("block page http server is up and running on addr/port %s", address)
+
+			srv := &http.Server{
+				ReadTimeout:       readTimeout,
+				ReadHeaderTimeout: readHeaderTimeout,
+				WriteTimeout:      writeTimeout,
+				Handler:           s.blockPage,
+			}
+
+			if err := srv.Serve(listener); err != nil {
+				errCh <- fmt.Errorf("start block page http listener failed: %w", err)
+			}
+		}()
+	}
+
+	for i, listener := range s.blockPageTLSListeners {
+		listener := listener
+		address := s.cfg.Blocking.BlockPage.HTTPS[i]
+
+		go func() {
+			logger().Infof("block page https server is up and running on addr/port %s", address)
+
+			srv := &http.Server{
+				ReadTimeout:       readTimeout,
+				ReadHeaderTimeout: readHeaderTimeout,
+				WriteTimeout:      writeTimeout,
+				Handler:           s.blockPage,
+				//nolint:gosec
+				TLSConfig: &tls.Config{
+					MinVersion:   minTLSVersion(s.cfg.MinTLSServeVer),
+					CipherSuites: tlsCipherSuites(),
+					Certificates: []tls.Certificate{s.cert},
+				},
+			}
+
+			if err := srv.ServeTLS(listener, "", ""); err != nil {
+				errCh <- fmt.Errorf("start block page https listener failed: %w", err)
+			}
+		}()
+	}
+}
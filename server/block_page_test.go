@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type blockingCheckerFunc func(domain, client string) (api.BlockingCheck, error)
+
+func (f blockingCheckerFunc) CheckBlocking(domain, client string) (api.BlockingCheck, error) {
+	return f(domain, client)
+}
+
+var _ = Describe("Block page", func() {
+	var (
+		cfg     *config.Config
+		checker blockingCheckerFunc
+		tmpDir  *TmpFolder
+
+		checkedDomain, checkedClient string
+	)
+
+	BeforeEach(func() {
+		cfg = &config.Config{
+			Ports: config.PortsConfig{HTTP: config.ListenConfig{"4000"}},
+		}
+		cfg.Blocking.BlockPage.HTTP = config.ListenConfig{"80"}
+
+		checker = func(domain, client string) (api.BlockingCheck, error) {
+			checkedDomain, checkedClient = domain, client
+
+			return api.BlockingCheck{
+				Domain:  domain,
+				Groups:  []string{"ads"},
+				Blocked: true,
+				Reason:  "BLOCKED (ads)",
+			}, nil
+		}
+
+		tmpDir = NewTmpFolder("blockPage")
+		DeferCleanup(tmpDir.Clean)
+	})
+
+	request := func(target string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = "192.168.178.20:51234"
+
+		return req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey,
+			&net.TCPAddr{IP: net.ParseIP("192.168.178.2"), Port: 80}))
+	}
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		page, err := newBlockPage(cfg, checker)
+		Expect(err).Should(Succeed())
+
+		rec := httptest.NewRecorder()
+		page.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	It("should answer with 403 and the blocked domain and groups", func() {
+		rec := serve(request("http://Ads.Example.com:8080/banner.gif"))
+
+		Expect(rec.Code).Should(Equal(http.StatusForbidden))
+		Expect(rec.Header().Get(contentTypeHeader)).Should(Equal(htmlContentType))
+		Expect(rec.Body.String()).Should(SatisfyAll(
+			ContainSubstring("<b>ads.example.com</b>"),
+			ContainSubstring("Blocking groups: ads"),
+			ContainSubstring(`"http://192.168.178.2:4000/api/blocking/allow"`),
+		))
+
+		Expect(checkedDomain).Should(Equal("ads.example.com"))
+		Expect(checkedClient).Should(Equal("192.168.178.20"))
+	})
+
+	It("should use the listen address of the API if it is specific", func() {
+		cfg.Ports.HTTP = config.ListenConfig{"10.0.0.1:4000"}
+
+		Expect(serve(request("http://ads.example.com/")).Body.String()).
+			Should(ContainSubstring(`"http://10.0.0.1:4000/api/blocking/allow"`))
+	})
+
+	It("should show no allow button without HTTP API", func() {
+		cfg.Ports.HTTP = nil
+
+		rec := serve(request("http://ads.example.com/"))
+
+		Expect(rec.Code).Should(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).ShouldNot(ContainSubstring("<form"))
+	})
+
+	It("should show the domain if the check fails", func() {
+		checker = func(string, string) (api.BlockingCheck, error) {
+			return api.BlockingCheck{}, errors.New("invalid domain")
+		}
+
+		rec := serve(request("http://ads.example.com/"))
+
+		Expect(rec.Code).Should(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).Should(SatisfyAll(
+			ContainSubstring("<b>ads.example.com</b>"),
+			Not(ContainSubstring("Blocking groups")),
+		))
+	})
+
+	When("a template is configured", func() {
+		It("should use the template", func() {
+			file := tmpDir.CreateStringFile("blocked.html", "{{.Domain}} is blocked by {{.Reason}}")
+			Expect(file.Error).Should(Succeed())
+
+			cfg.Blocking.BlockPage.Template = file.Path
+
+			Expect(serve(request("http://ads.example.com/")).Body.String()).
+				Should(Equal("ads.example.com is blocked by BLOCKED (ads)"))
+		})
+
+		It("should fail if the template is invalid", func() {
+			file := tmpDir.CreateStringFile("blocked.html", "{{.Domain")
+			Expect(file.Error).Should(Succeed())
+
+			cfg.Blocking.BlockPage.Template = file.Path
+
+			_, err := newBlockPage(cfg, checker)
+			Expect(err).Should(MatchError(ContainSubstring("can't parse block page template")))
+		})
+
+		It("should fail if the template doesn't exist", func() {
+			cfg.Blocking.BlockPage.Template = tmpDir.JoinPath("missing.html")
+
+			_, err := newBlockPage(cfg, checker)
+			Expect(err).Should(MatchError(ContainSubstring("can't read block page template")))
+		})
+	})
+})
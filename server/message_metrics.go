@@ -0,0 +1,138 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// transports of DNS messages, used as metric label
+const (
+	transportUDP = "udp"
+	transportTCP = "tcp"
+	transportDoT = "dot"
+	transportDoH = "doh"
+)
+
+// directions of DNS messages, used as metric label
+const (
+	directionRequest  = "request"
+	directionResponse = "response"
+)
+
+const (
+	// tcpFallbackWindow is how long a truncated UDP response is remembered to detect the retry over TCP
+	tcpFallbackWindow = 5 * time.Second
+
+	// tcpFallbackMaxTracked limits the number of remembered truncated responses
+	tcpFallbackMaxTracked = 10_000
+)
+
+// transportOf returns the transport of the DNS (not DoH) request written to w
+func transportOf(w dns.ResponseWriter) string {
+	if con, ok := w.(dns.ConnectionStater); ok && con.ConnectionState() != nil {
+		return transportDoT
+	}
+
+	if w.LocalAddr().Network() == "tcp" {
+		return transportTCP
+	}
+
+	return transportUDP
+}
+
+// messageSize records the size of a DNS message in bytes
+func messageSize(transport, direction string, size int) {
+	evt.Bus().Publish(evt.ServerMessageSize, transport, direction, size)
+}
+
+// writeMsg packs the message, records its size and writes it
+func writeMsg(w dns.ResponseWriter, transport string, msg *dns.Msg) error {
+	b, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+
+	messageSize(transport, directionResponse, len(b))
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// tcpFallbackTracker remembers truncated UDP responses, to count the clients which retry the query over TCP.
+// It's best-effort: a retry is detected by the client IP, query ID and question.
+// The zero value is ready to use
+type tcpFallbackTracker struct {
+	lock      sync.Mutex
+	truncated map[string]util.MonotonicTime // expiry by key
+}
+
+func tcpFallbackKey(clientIP net.IP, request *dns.Msg) string {
+	return clientIP.String() + "|" + strconv.Itoa(int(request.Id)) + "|" + util.QuestionToString(request.Question)
+}
+
+// truncatedSent counts and records a truncated UDP response
+func (t *tcpFallbackTracker) truncatedSent(clientIP net.IP, request *dns.Msg) {
+	evt.Bus().Publish(evt.ServerTruncatedResponse)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := util.MonotonicNow()
+
+	if len(t.truncated) >= tcpFallbackMaxTracked {
+		for key, expiry := range t.truncated {
+			if now.After(expiry) {
+				delete(t.truncated, key)
+			}
+		}
+
+		if len(t.truncated) >= tcpFallbackMaxTracked {
+			// too many truncated responses in the window, don't track this one
+			return
+		}
+	}
+
+	if t.truncated == nil {
+		t.truncated = make(map[string]util.MonotonicTime)
+	}
+
+	t.truncated[tcpFallbackKey(clientIP, request)] = now.Add(tcpFallbackWindow)
+}
+
+// checkRetry counts the request over TCP, if it retries a query which was answered with a truncated UDP response
+func (t *tcpFallbackTracker) checkRetry(clientIP net.IP, request *dns.Msg) bool {
+	if !t.isRetry(clientIP, request) {
+		return false
+	}
+
+	evt.Bus().Publish(evt.ServerTCPFallback)
+
+	return true
+}
+
+func (t *tcpFallbackTracker) isRetry(clientIP net.IP, request *dns.Msg) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.truncated) == 0 {
+		return false
+	}
+
+	key := tcpFallbackKey(clientIP, request)
+
+	expiry, found := t.truncated[key]
+	if !found {
+		return false
+	}
+
+	delete(t.truncated, key)
+
+	return !util.MonotonicNow().After(expiry)
+}
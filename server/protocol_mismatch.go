@@ -0,0 +1,125 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/miekg/dns"
+)
+
+const (
+	// alpnDoT is the ALPN protocol ID of DNS-over-TLS (RFC 7858)
+	alpnDoT = "dot"
+
+	protocolHTTP  = "http"
+	protocolHTTPS = "https"
+	protocolDoT   = "dot"
+)
+
+var errProtocolMismatch = errors.New("protocol mismatch")
+
+// alpnHTTP are the ALPN protocol IDs of HTTP clients
+//
+//nolint:gochecknoglobals
+var alpnHTTP = []string{"h2", "http/1.1", "http/1.0"}
+
+// httpMethods are the beginnings of an HTTP/1 request or the HTTP/2 connection preface
+//
+//nolint:gochecknoglobals
+var httpMethods = []string{"GET ", "POST ", "HEAD ", "PUT ", "DELETE ", "OPTIONS ", "PATCH ", "CONNECT ", "PRI * "}
+
+// protocolMismatch logs a connection of a client speaking the wrong protocol and counts it
+func protocolMismatch(listener, detected string, remote net.Addr, hint string) {
+	logger().Warnf("client %s sent %s to %s listener, connection closed: %s", remote, detected, listener, hint)
+
+	evt.Bus().Publish(evt.ServerProtocolMismatch, listener, detected)
+}
+
+// rejectHTTPSClients rejects the TLS handshake of HTTPS clients on the DoT listener.
+// Clients without ALPN are accepted for compatibility
+func rejectHTTPSClients(address string) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if offersHTTP(hello.SupportedProtos) && !slices.Contains(hello.SupportedProtos, alpnDoT) {
+			protocolMismatch("DoT "+address, protocolHTTPS, hello.Conn.RemoteAddr(),
+				"the DoH endpoint is served on the https port(s)")
+
+			return nil, fmt.Errorf("%w: HTTPS client on DoT port", errProtocolMismatch)
+		}
+
+		return nil, nil
+	}
+}
+
+// rejectDoTClients rejects the TLS handshake of DoT clients on the DoH listener
+func rejectDoTClients(address string) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if slices.Contains(hello.SupportedProtos, alpnDoT) && !offersHTTP(hello.SupportedProtos) {
+			protocolMismatch("DoH "+address, protocolDoT, hello.Conn.RemoteAddr(),
+				"DNS-over-TLS is served on the tls port(s)")
+
+			return nil, fmt.Errorf("%w: DoT client on HTTPS port", errProtocolMismatch)
+		}
+
+		return nil, nil
+	}
+}
+
+func offersHTTP(protos []string) bool {
+	for _, proto := range protos {
+		if slices.Contains(alpnHTTP, proto) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// plainHTTPDetector is a DNS reader of the DoT listener which detects plain HTTP requests
+type plainHTTPDetector struct {
+	dns.Reader
+
+	address string
+}
+
+func detectPlainHTTP(address string) dns.DecorateReader {
+	return func(r dns.Reader) dns.Reader {
+		return &plainHTTPDetector{Reader: r, address: address}
+	}
+}
+
+// ReadTCP implements `dns.Reader`.
+func (d *plainHTTPDetector) ReadTCP(conn net.Conn, timeout time.Duration) ([]byte, error) {
+	msg, err := d.Reader.ReadTCP(conn, timeout)
+
+	var recordErr tls.RecordHeaderError
+
+	if errors.As(err, &recordErr) && recordErr.Conn != nil && isHTTPRequest(recordErr.RecordHeader[:]) {
+		protocolMismatch("DoT "+d.address, protocolHTTP, conn.RemoteAddr(),
+			"the DoH endpoint is served on the http(s) port(s)")
+
+		// same as net/http does for HTTP requests on HTTPS ports, the connection is closed by the DNS server
+		_ = recordErr.Conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_, _ = fmt.Fprint(recordErr.Conn,
+			"HTTP/1.0 400 Bad Request\r\n\r\nClient sent an HTTP request to a DNS-over-TLS port.\n")
+	}
+
+	return msg, err
+}
+
+func isHTTPRequest(header []byte) bool {
+	for _, method := range httpMethods {
+		n := min(len(header), len(method))
+
+		if strings.HasPrefix(method, string(header[:n])) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -16,10 +17,12 @@ import (
 	"net/http"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/metrics"
 	"github.com/0xERR0R/blocky/model"
@@ -49,14 +52,25 @@ type Server struct {
 	httpMux        *chi.Mux
 	httpsMux       *chi.Mux
 	cert           tls.Certificate
+	acl            *clientACL
+	watchdog       *watchdog
+	profiles       map[string]*Server
+	startup        *startupTimer
+	tcpFallbacks   tcpFallbackTracker
+
+	// stopResolvers ends the background work of the resolvers, like the cache clean up
+	stopResolvers context.CancelFunc
+
+	blockPage             *blockPage
+	blockPageListeners    []net.Listener
+	blockPageTLSListeners []net.Listener
 }
 
 func logger() *logrus.Entry {
 	return log.PrefixedLog("server")
 }
 
-func minTLSVersion() uint16 {
-	minTLSVer := config.GetConfig().MinTLSServeVer
+func minTLSVersion(minTLSVer string) uint16 {
 	switch minTLSVer {
 	case "1.2":
 		return tls.VersionTLS12
@@ -116,15 +130,33 @@ func retrieveCertificate(cfg *config.Config) (cert tls.Certificate, err error) {
 func NewServer(cfg *config.Config) (server *Server, err error) {
 	log.ConfigureLogger(&cfg.Log)
 
+	startup := newStartupTimer()
+
+	// the lifetime of the resolvers, canceled when the server is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer func() {
+		if err != nil {
+			startup.stop()
+			cancel()
+		}
+	}()
+
 	var cert tls.Certificate
 
-	if len(cfg.Ports.HTTPS) > 0 || len(cfg.Ports.TLS) > 0 {
+	if needsCertificate(cfg) {
+		start := time.Now()
+
 		cert, err = retrieveCertificate(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("can't retrieve cert: %w", err)
 		}
+
+		startup.phaseCompleted("certificate", time.Since(start))
 	}
 
+	start := time.Now()
+
 	dnsServers, err := createServers(cfg, cert)
 	if err != nil {
 		return nil, fmt.Errorf("server creation failed: %w", err)
@@ -138,6 +170,13 @@ func NewServer(cfg *config.Config) (server *Server, err error) {
 		return nil, err
 	}
 
+	blockPageListeners, blockPageTLSListeners, err := createBlockPageListeners(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	startup.phaseCompleted("listener binding", time.Since(start))
+
 	if len(httpListeners) != 0 || len(httpsListeners) != 0 {
 		metrics.Start(httpRouter, cfg.Prometheus)
 		metrics.Start(httpsRouter, cfg.Prometheus)
@@ -145,21 +184,45 @@ func NewServer(cfg *config.Config) (server *Server, err error) {
 
 	metrics.RegisterEventListeners()
 
+	start = time.Now()
+
 	bootstrap, err := resolver.NewBootstrap(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	startup.phaseCompleted("bootstrap", time.Since(start))
+
 	redisClient, redisErr := redis.New(&cfg.Redis)
 	if redisErr != nil && cfg.Redis.Required {
 		return nil, redisErr
 	}
 
-	queryResolver, queryError := createQueryResolver(cfg, bootstrap, redisClient)
+	start = time.Now()
+
+	queryResolver, queryError := createQueryResolver(ctx, cfg, bootstrap, redisClient, defaultProfileLabel(cfg))
 	if queryError != nil {
 		return nil, queryError
 	}
 
+	acl, err := newClientACL(cfg.ACL)
+	if err != nil {
+		return nil, fmt.Errorf("acl: %w", err)
+	}
+
+	startup.phaseCompleted("resolvers", time.Since(start))
+
+	start = time.Now()
+
+	profiles, err := createProfiles(ctx, cfg, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profiles) > 0 {
+		startup.phaseCompleted("profiles", time.Since(start))
+	}
+
 	server = &Server{
 		dnsServers:     dnsServers,
 		queryResolver:  queryResolver,
@@ -169,6 +232,24 @@ func NewServer(cfg *config.Config) (server *Server, err error) {
 		httpMux:        httpRouter,
 		httpsMux:       httpsRouter,
 		cert:           cert,
+		acl:            acl,
+		profiles:       profiles,
+		startup:        startup,
+		stopResolvers:  cancel,
+
+		blockPageListeners:    blockPageListeners,
+		blockPageTLSListeners: blockPageTLSListeners,
+	}
+
+	if cfg.Blocking.BlockPage.IsEnabled() {
+		server.blockPage, err = createBlockPage(cfg, queryResolver)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Watchdog.IsEnabled() {
+		server.watchdog = newWatchdog(cfg, queryResolver, bootstrap)
 	}
 
 	server.printConfiguration()
@@ -189,6 +270,86 @@ func NewServer(cfg *config.Config) (server *Server, err error) {
 	return server, err
 }
 
+func needsCertificate(cfg *config.Config) bool {
+	if len(cfg.Ports.HTTPS) > 0 || len(cfg.Ports.TLS) > 0 || len(cfg.Blocking.BlockPage.HTTPS) > 0 {
+		return true
+	}
+
+	for _, profile := range cfg.Profiles {
+		if len(profile.Ports.TLS) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultProfileLabel returns the metrics label of the main configuration:
+// queries are only labelled by profile if additional profiles are configured
+func defaultProfileLabel(cfg *config.Config) string {
+	if cfg.Profiles.IsEnabled() {
+		return config.DefaultProfileName
+	}
+
+	return ""
+}
+
+// createProfiles creates a server with its own DNS listeners and resolver chain for each profile.
+// Profiles don't use redis, so their cache and blocking state stay independent.
+func createProfiles(ctx context.Context, cfg *config.Config, cert tls.Certificate) (map[string]*Server, error) {
+	profiles := make(map[string]*Server, len(cfg.Profiles))
+
+	for name := range cfg.Profiles {
+		profile, err := newProfileServer(ctx, cfg, name, cert)
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s': %w", name, err)
+		}
+
+		profiles[name] = profile
+	}
+
+	return profiles, nil
+}
+
+func newProfileServer(ctx context.Context, cfg *config.Config, name string, cert tls.Certificate) (*Server, error) {
+	profileCfg, err := cfg.ForProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsServers, err := createServers(profileCfg, cert)
+	if err != nil {
+		return nil, fmt.Errorf("server creation failed: %w", err)
+	}
+
+	bootstrap, err := resolver.NewBootstrap(profileCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResolver, err := createQueryResolver(ctx, profileCfg, bootstrap, nil, name)
+	if err != nil {
+		return nil, err
+	}
+
+	acl, err := newClientACL(profileCfg.ACL)
+	if err != nil {
+		return nil, fmt.Errorf("acl: %w", err)
+	}
+
+	profile := &Server{
+		dnsServers:    dnsServers,
+		queryResolver: queryResolver,
+		cfg:           profileCfg,
+		cert:          cert,
+		acl:           acl,
+	}
+
+	profile.registerDNSHandlers()
+
+	return profile, nil
+}
+
 func createServers(cfg *config.Config, cert tls.Certificate) ([]*dns.Server, error) {
 	var dnsServers []*dns.Server
 
@@ -211,7 +372,7 @@ func createServers(cfg *config.Config, cert tls.Certificate) ([]*dns.Server, err
 		addServers(createUDPServer, cfg.Ports.DNS),
 		addServers(createTCPServer, cfg.Ports.DNS),
 		addServers(func(address string) (*dns.Server, error) {
-			return createTLSServer(address, cert)
+			return createTLSServer(address, cert, cfg.MinTLSServeVer)
 		}, cfg.Ports.TLS))
 
 	return dnsServers, err.ErrorOrNil()
@@ -246,17 +407,20 @@ func newListeners(proto string, addresses config.ListenConfig) ([]net.Listener,
 	return listeners, nil
 }
 
-func createTLSServer(address string, cert tls.Certificate) (*dns.Server, error) {
+func createTLSServer(address string, cert tls.Certificate, minTLSVer string) (*dns.Server, error) {
 	return &dns.Server{
 		Addr: address,
 		Net:  "tcp-tls",
 		//nolint:gosec
 		TLSConfig: &tls.Config{
-			Certificates: []tls.Certificate{cert},
-			MinVersion:   minTLSVersion(),
-			CipherSuites: tlsCipherSuites(),
+			Certificates:       []tls.Certificate{cert},
+			MinVersion:         minTLSVersion(minTLSVer),
+			CipherSuites:       tlsCipherSuites(),
+			NextProtos:         []string{alpnDoT},
+			GetConfigForClient: rejectHTTPSClients(address),
 		},
-		Handler: dns.NewServeMux(),
+		DecorateReader: detectPlainHTTP(address),
+		Handler:        dns.NewServeMux(),
 		NotifyStartedFunc: func() {
 			logger().Infof("TLS server is up and running on address %s", address)
 		},
@@ -385,9 +549,11 @@ func createSelfSignedCert() (tls.Certificate, error) {
 }
 
 func createQueryResolver(
+	ctx context.Context,
 	cfg *config.Config,
 	bootstrap *resolver.Bootstrap,
 	redisClient *redis.Client,
+	profile string,
 ) (r resolver.ChainedResolver, err error) {
 	upstreamBranches, uErr := createUpstreamBranches(cfg, bootstrap)
 	if uErr != nil {
@@ -396,10 +562,16 @@ func createQueryResolver(
 
 	upstreamTree, utErr := resolver.NewUpstreamTreeResolver(cfg.Upstreams, upstreamBranches)
 
-	blocking, blErr := resolver.NewBlockingResolver(cfg.Blocking, redisClient, bootstrap)
-	clientNames, cnErr := resolver.NewClientNamesResolver(cfg.ClientLookup, bootstrap, cfg.StartVerifyUpstream)
-	condUpstream, cuErr := resolver.NewConditionalUpstreamResolver(cfg.Conditional, bootstrap, cfg.StartVerifyUpstream)
-	hostsFile, hfErr := resolver.NewHostsFileResolver(cfg.HostsFile, bootstrap)
+	blocking, blErr := resolver.NewBlockingResolver(ctx, cfg.Blocking, redisClient, bootstrap, profile)
+	clientNames, cnErr := resolver.NewClientNamesResolver(ctx, cfg.ClientLookup, bootstrap, cfg.StartVerifyUpstream)
+	condUpstream, cuErr := resolver.NewConditionalUpstreamResolver(
+		cfg.Conditional, createFallthroughUpstream(cfg, upstreamBranches), bootstrap, cfg.StartVerifyUpstream,
+	)
+	hostsFile, hfErr := resolver.NewHostsFileResolver(ctx, cfg.HostsFile, bootstrap)
+	customDNS, cdErr := resolver.NewCustomDNSResolver(ctx, cfg.CustomDNS, bootstrap)
+	rateLimit, rlErr := resolver.NewRateLimitResolver(cfg.RateLimit)
+	dns64, d6Err := resolver.NewDNS64Resolver(cfg.DNS64)
+	dnssec, dsErr := resolver.NewDNSSECResolver(cfg.DNSSEC)
 
 	err = multierror.Append(
 		multierror.Prefix(utErr, "upstream tree resolver: "),
@@ -407,30 +579,80 @@ func createQueryResolver(
 		multierror.Prefix(cnErr, "client names resolver: "),
 		multierror.Prefix(cuErr, "conditional upstream resolver: "),
 		multierror.Prefix(hfErr, "hosts file resolver: "),
+		multierror.Prefix(cdErr, "custom DNS resolver: "),
+		multierror.Prefix(rlErr, "rate limit resolver: "),
+		multierror.Prefix(d6Err, "DNS64 resolver: "),
+		multierror.Prefix(dsErr, "DNSSEC resolver: "),
 	).ErrorOrNil()
 	if err != nil {
 		return nil, err
 	}
 
+	customDNSRewriter, crErr := resolver.NewRewriterResolver(cfg.CustomDNS.RewriterConfig, customDNS)
+	condUpstreamRewriter, curErr := resolver.NewRewriterResolver(cfg.Conditional.RewriterConfig, condUpstream)
+
+	err = multierror.Append(
+		multierror.Prefix(crErr, "custom DNS rewriter: "),
+		multierror.Prefix(curErr, "conditional upstream rewriter: "),
+	).ErrorOrNil()
+	if err != nil {
+		return nil, err
+	}
+
+	// the answers depend on the client subnet sent upstream, the cache mustn't share them between subnets
+	cachingCfg := cfg.Caching
+	if cfg.ECS.SendsClientSubnet() {
+		cachingCfg.PartitionByECS = true
+	}
+
 	r = resolver.Chain(
+		resolver.NewCookieResolver(cfg.Cookies),
+		resolver.NewNsidResolver(cfg.NSID),
+		rateLimit,
 		resolver.NewFilteringResolver(cfg.Filtering),
 		resolver.NewFqdnOnlyResolver(cfg.FqdnOnly),
 		clientNames,
 		resolver.NewEdeResolver(cfg.Ede),
 		resolver.NewQueryLoggingResolver(cfg.QueryLog),
-		resolver.NewMetricsResolver(cfg.Prometheus),
-		resolver.NewRewriterResolver(cfg.CustomDNS.RewriterConfig, resolver.NewCustomDNSResolver(cfg.CustomDNS)),
+		resolver.NewMetricsResolver(cfg.Prometheus, profile),
+		resolver.NewClientStatsResolver(cfg.ClientStats),
+		resolver.NewChaosQueryResolver(cfg.ChaosQueries),
+		resolver.NewStaticResponseResolver(cfg.StaticResponses),
+		resolver.NewAnyQueryResolver(cfg.AnyQueries, customDNS),
+		customDNSRewriter,
 		hostsFile,
 		blocking,
-		resolver.NewCachingResolver(cfg.Caching, redisClient),
-		resolver.NewRewriterResolver(cfg.Conditional.RewriterConfig, condUpstream),
+		dns64,
+		resolver.NewEcsResolver(cfg.ECS),
+		resolver.NewCachingResolver(ctx, cachingCfg, redisClient, profile),
+		condUpstreamRewriter,
 		resolver.NewSpecialUseDomainNamesResolver(cfg.SUDN),
+		resolver.NewRebindProtectionResolver(cfg.RebindProtection),
+		dnssec,
 		upstreamTree,
 	)
 
 	return r, nil
 }
 
+// createFallthroughUpstream returns the default upstreams for the fall through of conditional upstreams.
+// Their answers are checked by the rebind protection and the DNSSEC validation like the answers of the default
+// upstreams in the chain, which the fall through doesn't pass. Returns nil without default upstreams
+func createFallthroughUpstream(cfg *config.Config, upstreamBranches map[string]resolver.Resolver) resolver.Resolver {
+	defaultUpstream, ok := upstreamBranches[config.UpstreamDefaultCfgName]
+	if !ok {
+		return nil
+	}
+
+	dnssec, err := resolver.NewDNSSECResolver(cfg.DNSSEC)
+	if err != nil {
+		// the same error is returned for the DNSSEC resolver of the chain
+		return nil
+	}
+
+	return resolver.Chain(resolver.NewRebindProtectionResolver(cfg.RebindProtection), dnssec, defaultUpstream)
+}
+
 func createUpstreamBranches(
 	cfg *config.Config,
 	bootstrap *resolver.Bootstrap,
@@ -445,7 +667,9 @@ func createUpstreamBranches(
 			err      error
 		)
 
-		resolverCfg := config.UpstreamsConfig{Groups: config.UpstreamGroups{group: upstreams}}
+		// each branch gets all upstream options (response quality, parallel query limit, ...), only the groups differ
+		resolverCfg := cfg.Upstreams
+		resolverCfg.Groups = config.UpstreamGroups{group: upstreams}
 
 		switch cfg.Upstreams.Strategy {
 		case config.UpstreamStrategyStrict:
@@ -476,9 +700,37 @@ func (s *Server) printConfiguration() {
 		resolver.LogResolverConfig(res, logger())
 	})
 
+	if s.watchdog != nil {
+		logger().Info("watchdog:")
+		log.WithIndent(logger(), "  ", s.watchdog.cfg.LogConfig)
+	}
+
 	logger().Info("listeners:")
 	log.WithIndent(logger(), "  ", s.cfg.Ports.LogConfig)
 
+	logger().Infof("maxAnswerAge = %s", s.cfg.MaxAnswerAge)
+	logger().Infof("minimalResponses = %t", s.cfg.MinimalResponses)
+	logger().Infof("ednsUdpSize = %d", s.cfg.UDPSize())
+
+	if s.cfg.ACL.IsEnabled() || len(s.cfg.ACL.TrustedProxies) != 0 {
+		logger().Info("acl:")
+		log.WithIndent(logger(), "  ", s.cfg.ACL.LogConfig)
+	}
+
+	for name, profile := range s.profiles {
+		profile := profile
+
+		logger().Infof("profile '%s':", name)
+		log.WithIndent(logger(), "  ", func(e *logrus.Entry) {
+			resolver.ForEach(profile.queryResolver, func(res resolver.Resolver) {
+				resolver.LogResolverConfig(res, e)
+			})
+
+			e.Info("listeners:")
+			log.WithIndent(e, "  ", profile.cfg.Ports.LogConfig)
+		})
+	}
+
 	logger().Info("runtime information:")
 
 	// force garbage collector
@@ -516,14 +768,10 @@ const (
 func (s *Server) Start(errCh chan<- error) {
 	logger().Info("Starting server")
 
-	for _, srv := range s.dnsServers {
-		srv := srv
+	s.startDNSServers(errCh)
 
-		go func() {
-			if err := srv.ListenAndServe(); err != nil {
-				errCh <- fmt.Errorf("start %s listener failed: %w", srv.Net, err)
-			}
-		}()
+	for _, profile := range s.profiles {
+		profile.startDNSServers(errCh)
 	}
 
 	for i, listener := range s.httpListeners {
@@ -560,9 +808,10 @@ func (s *Server) Start(errCh chan<- error) {
 				WriteTimeout:      writeTimeout,
 				//nolint:gosec
 				TLSConfig: &tls.Config{
-					MinVersion:   minTLSVersion(),
-					CipherSuites: tlsCipherSuites(),
-					Certificates: []tls.Certificate{s.cert},
+					MinVersion:         minTLSVersion(s.cfg.MinTLSServeVer),
+					CipherSuites:       tlsCipherSuites(),
+					Certificates:       []tls.Certificate{s.cert},
+					GetConfigForClient: rejectDoTClients(address),
 				},
 			}
 
@@ -572,13 +821,61 @@ func (s *Server) Start(errCh chan<- error) {
 		}()
 	}
 
+	s.startBlockPage(errCh)
+
+	if s.watchdog != nil {
+		go s.watchdog.run(errCh)
+	}
+
 	registerPrintConfigurationTrigger(s)
+
+	s.startup.finish()
+}
+
+// AddStartupPhase adds a phase of the startup which completed before the server was created,
+// e.g. loading of the configuration
+func (s *Server) AddStartupPhase(name string, start time.Time, duration time.Duration) {
+	s.startup.addPhase(name, start, duration)
+}
+
+func (s *Server) startDNSServers(errCh chan<- error) {
+	for _, srv := range s.dnsServers {
+		srv := srv
+
+		go func() {
+			if err := srv.ListenAndServe(); err != nil {
+				errCh <- fmt.Errorf("start %s listener failed: %w", srv.Net, err)
+			}
+		}()
+	}
 }
 
 // Stop stops the server
 func (s *Server) Stop() error {
 	logger().Info("Stopping server")
 
+	if s.stopResolvers != nil {
+		defer s.stopResolvers()
+	}
+
+	if s.watchdog != nil {
+		s.watchdog.Stop()
+	}
+
+	if err := s.stopDNSServers(); err != nil {
+		return err
+	}
+
+	for name, profile := range s.profiles {
+		if err := profile.stopDNSServers(); err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) stopDNSServers() error {
 	for _, server := range s.dnsServers {
 		if err := server.Shutdown(); err != nil {
 			return fmt.Errorf("stop %s listener failed: %w", server.Net, err)
@@ -638,40 +935,226 @@ func (s *Server) OnRequest(w dns.ResponseWriter, request *dns.Msg) {
 
 	r := createResolverRequest(w, request)
 
+	transport := transportOf(w)
+	messageSize(transport, directionRequest, request.Len())
+
+	if !s.acl.isAllowed(r.ClientIP) {
+		s.acl.refused(r.ClientIP, transport)
+
+		m := new(dns.Msg)
+		m.SetRcode(request, dns.RcodeRefused)
+		err := writeMsg(w, transport, m)
+		util.LogOnError("can't write message: ", err)
+
+		return
+	}
+
+	if transport != transportUDP {
+		s.tcpFallbacks.checkRetry(r.ClientIP, request)
+	}
+
 	response, err := s.queryResolver.Resolve(r)
 
+	if resolver.IsRateLimited(err) {
+		r.Log.Debug("not answering, the client exceeded the rate limit")
+
+		return
+	}
+
+	if transport == transportUDP && s.isLateAnswer(r) {
+		return
+	}
+
 	if err != nil {
-		logger().Error("error on processing request:", err)
+		if resolver.IsResolutionFailed(err) {
+			// the failures of the upstreams are already logged by the upstream resolvers
+			logger().Debug("error on processing request:", err)
+		} else {
+			logger().Error("error on processing request:", err)
+		}
 
 		m := new(dns.Msg)
 		m.SetRcode(request, dns.RcodeServerFailure)
-		err := w.WriteMsg(m)
+		err := writeMsg(w, transport, m)
 		util.LogOnError("can't write message: ", err)
 	} else {
 		response.Res.MsgHdr.RecursionAvailable = request.MsgHdr.RecursionDesired
 
-		// truncate if necessary
-		response.Res.Truncate(getMaxResponseSize(w.LocalAddr().Network(), request))
+		response.Res = dnssecResponse(request, response.Res)
+
+		if s.cfg.MinimalResponses {
+			response.Res = minimalResponse(response.Res)
+		}
+
+		response.Res = s.withEdnsRecord(request, response.Res)
 
 		// enable compression
 		response.Res.Compress = true
 
-		err := w.WriteMsg(response.Res)
+		// truncate if necessary
+		response.Res = truncate(response.Res, getMaxResponseSize(w.LocalAddr().Network(), request, s.cfg.UDPSize()))
+
+		if response.Res.Truncated && transport == transportUDP {
+			s.tcpFallbacks.truncatedSent(r.ClientIP, request)
+		}
+
+		err := writeMsg(w, transport, response.Res)
 		util.LogOnError("can't write message: ", err)
 	}
 }
 
-// returns EDNS UDP size or if not present, 512 for UDP and 64K for TCP
-func getMaxResponseSize(network string, request *dns.Msg) int {
-	edns := request.IsEdns0()
-	if edns != nil && edns.UDPSize() > 0 {
-		return int(edns.UDPSize())
+// isLateAnswer returns true if the answer to the request is older than `maxAnswerAge`.
+// The client most likely closed its socket, so sending it would only cause an ICMP port unreachable
+func (s *Server) isLateAnswer(request *model.Request) bool {
+	if !s.cfg.MaxAnswerAge.IsAboveZero() {
+		return false
+	}
+
+	age := time.Since(request.RequestTS)
+	if age <= s.cfg.MaxAnswerAge.ToDuration() {
+		return false
+	}
+
+	request.Log.Debugf("dropping answer, since it is %s old", age)
+	evt.Bus().Publish(evt.ServerLateAnswerDropped)
+
+	return true
+}
+
+// dnssecResponse returns the response as the client asked for it: with the AD bit only if the query had the AD or
+// DO bit (RFC 6840, 5.7) and with the DNSSEC records only if it had the DO bit (RFC 4035, 3.2.1).
+// The response isn't modified, since it may be stored in the cache.
+func dnssecResponse(request, msg *dns.Msg) *dns.Msg {
+	if opt := request.IsEdns0(); opt != nil && opt.Do() {
+		return msg
+	}
+
+	res := *msg
+	res.AuthenticatedData = msg.AuthenticatedData && request.AuthenticatedData
+
+	isDNSSECRecord := func(rr dns.RR) bool {
+		switch rr.Header().Rrtype {
+		case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// explicitly queried records are answered
+	res.Answer = slices.DeleteFunc(slices.Clone(msg.Answer), func(rr dns.RR) bool {
+		return isDNSSECRecord(rr) && rr.Header().Rrtype != request.Question[0].Qtype
+	})
+	res.Ns = slices.DeleteFunc(slices.Clone(msg.Ns), isDNSSECRecord)
+	res.Extra = slices.DeleteFunc(slices.Clone(msg.Extra), isDNSSECRecord)
+
+	for i, rr := range res.Extra {
+		if opt, ok := rr.(*dns.OPT); ok && opt.Do() {
+			opt = dns.Copy(opt).(*dns.OPT)
+			opt.SetDo(false)
+			res.Extra[i] = opt
+		}
+	}
+
+	return &res
+}
+
+// minimalResponse returns the positive response without the authority and additional sections,
+// except the OPT record, the NSEC/NSEC3 records with their signatures and the additional records
+// of the CNAME/DNAME targets of the answer.
+// Negative responses are returned unchanged, since they need the SOA for negative caching.
+// The response isn't modified, since it may be stored in the cache.
+func minimalResponse(msg *dns.Msg) *dns.Msg {
+	if msg.Rcode != dns.RcodeSuccess || len(msg.Answer) == 0 {
+		return msg
+	}
+
+	targets := make(map[string]struct{})
+
+	for _, rr := range msg.Answer {
+		switch v := rr.(type) {
+		case *dns.CNAME:
+			targets[dns.CanonicalName(v.Target)] = struct{}{}
+		case *dns.DNAME:
+			targets[dns.CanonicalName(v.Target)] = struct{}{}
+		}
+	}
+
+	res := *msg
+	res.Extra = nil
+
+	// the NSEC/NSEC3 records prove the answer of a wildcard to validating clients (RFC 4035, 3.1.3.3)
+	res.Ns = slices.DeleteFunc(slices.Clone(msg.Ns), func(rr dns.RR) bool {
+		switch v := rr.(type) {
+		case *dns.NSEC, *dns.NSEC3:
+			return false
+		case *dns.RRSIG:
+			return v.TypeCovered != dns.TypeNSEC && v.TypeCovered != dns.TypeNSEC3
+		default:
+			return true
+		}
+	})
+
+	for _, rr := range msg.Extra {
+		if _, ok := targets[dns.CanonicalName(rr.Header().Name)]; ok || rr.Header().Rrtype == dns.TypeOPT {
+			res.Extra = append(res.Extra, rr)
+		}
+	}
+
+	return &res
+}
+
+// withEdnsRecord returns the response with an EDNS record, which advertises the configured UDP size, if the request
+// has one. An added record has the DO bit of the request (RFC 3225, 3).
+// The response may be shared with the cache, so a copy is changed
+func (s *Server) withEdnsRecord(request, response *dns.Msg) *dns.Msg {
+	if request.IsEdns0() == nil {
+		return response
 	}
 
+	udpSize := s.cfg.UDPSize()
+
+	if opt := response.IsEdns0(); opt != nil && opt.UDPSize() == udpSize {
+		return response
+	}
+
+	res := response.Copy()
+
+	if opt := res.IsEdns0(); opt != nil {
+		opt.SetUDPSize(udpSize)
+	} else {
+		res.SetEdns0(udpSize, request.IsEdns0().Do())
+	}
+
+	return res
+}
+
+// truncate returns the response truncated to the size. The response may be shared with the cache,
+// so a copy is truncated
+func truncate(response *dns.Msg, size int) *dns.Msg {
+	if response.Len() <= max(size, dns.MinMsgSize) {
+		return response
+	}
+
+	res := response.Copy()
+	res.Truncate(size)
+
+	return res
+}
+
+// getMaxResponseSize returns the max size of the response: 64K for TCP, for UDP the EDNS UDP size of the request
+// limited to the configured size or 512 without EDNS. Larger UDP responses are truncated, so the client retries via TCP
+func getMaxResponseSize(network string, request *dns.Msg, ednsUDPSize uint16) int {
 	if network == "tcp" {
 		return dns.MaxMsgSize
 	}
 
+	edns := request.IsEdns0()
+	if edns != nil && edns.UDPSize() > 0 {
+		// sizes below 512 are treated as 512 by `Truncate`
+		return int(min(edns.UDPSize(), ednsUDPSize))
+	}
+
 	return dns.MinMsgSize
 }
 
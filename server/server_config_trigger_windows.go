@@ -1,4 +1,36 @@
 package server
 
+import (
+	"golang.org/x/sys/windows"
+)
+
+// PrintConfigurationEvent is the named event to print the configuration, since Windows has no SIGUSR1.
+// Creating a global event requires the privileges of a service
+const PrintConfigurationEvent = `Global\blocky-print-configuration`
+
 func registerPrintConfigurationTrigger(s *Server) {
+	name, err := windows.UTF16PtrFromString(PrintConfigurationEvent)
+	if err != nil {
+		return
+	}
+
+	// auto reset, so each signal prints the configuration once
+	event, err := windows.CreateEvent(nil, 0, 0, name)
+	if err != nil {
+		logger().Debug("can't create event to print the configuration: ", err)
+
+		return
+	}
+
+	go func() {
+		for {
+			if _, err := windows.WaitForSingleObject(event, windows.INFINITE); err != nil {
+				logger().Warn("can't wait for event to print the configuration: ", err)
+
+				return
+			}
+
+			s.printConfiguration()
+		}
+	}()
 }
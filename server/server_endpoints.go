@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"html/template"
 	"io"
-	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -52,15 +51,51 @@ func (s *Server) createOpenAPIInterfaceImpl() (impl api.StrictServerInterface, e
 		return nil, fmt.Errorf("no blocking API implementation found %w", err)
 	}
 
+	bEntries, err := resolver.GetFromChainWithType[api.BlockingEntries](s.queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no blocking entries API implementation found %w", err)
+	}
+
+	bChecker, err := resolver.GetFromChainWithType[api.BlockingChecker](s.queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no blocking check API implementation found %w", err)
+	}
+
 	refresher, err := resolver.GetFromChainWithType[api.ListRefresher](s.queryResolver)
 	if err != nil {
 		return nil, fmt.Errorf("no refresh API implementation found %w", err)
 	}
 
-	return api.NewOpenAPIInterfaceImpl(bControl, s, refresher), nil
+	clientStats, err := resolver.GetFromChainWithType[api.ClientStatsProvider](s.queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no client statistics API implementation found %w", err)
+	}
+
+	customDNS, err := resolver.GetFromChainWithType[api.CustomDNSRecordsManager](s.queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no custom DNS records API implementation found %w", err)
+	}
+
+	return api.NewOpenAPIInterfaceImpl(bControl, bEntries, bChecker, s, refresher, clientStats, s, s, customDNS,
+		s.cfg.API.ErrorDetails), nil
+}
+
+// UpstreamStatus implements `api.UpstreamStatusProvider`
+func (s *Server) UpstreamStatus() []api.UpstreamStatus {
+	return resolver.UpstreamStatus(s.queryResolver)
+}
+
+// StartupSummary implements `api.StartupReporter`
+func (s *Server) StartupSummary() api.StartupSummary {
+	if s.startup == nil {
+		// profiles are started with the main server
+		return api.StartupSummary{}
+	}
+
+	return s.startup.summary()
 }
 
-func (s *Server) registerAPIEndpoints(router *chi.Mux) error {
+func (s *Server) registerAPIEndpoints(router chi.Router) error {
 	const pathDohQuery = "/dns-query"
 
 	openAPIImpl, err := s.createOpenAPIInterfaceImpl()
@@ -77,6 +112,18 @@ func (s *Server) registerAPIEndpoints(router *chi.Mux) error {
 	router.Post(pathDohQuery+"/", s.dohPostRequestHandler)
 	router.Post(pathDohQuery+"/{clientID}", s.dohPostRequestHandler)
 
+	for name, profile := range s.profiles {
+		profile := profile
+
+		router.Route("/profiles/"+name, func(r chi.Router) {
+			err = profile.registerAPIEndpoints(r)
+		})
+
+		if err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -129,6 +176,8 @@ func (s *Server) dohPostRequestHandler(rw http.ResponseWriter, req *http.Request
 }
 
 func (s *Server) processDohMessage(rawMsg []byte, rw http.ResponseWriter, req *http.Request) {
+	messageSize(transportDoH, directionRequest, len(rawMsg))
+
 	msg := new(dns.Msg)
 
 	if err := msg.Unpack(rawMsg); err != nil {
@@ -143,9 +192,26 @@ func (s *Server) processDohMessage(rawMsg []byte, rw http.ResponseWriter, req *h
 		clientID = extractClientIDFromHost(req.Host)
 	}
 
-	r := newRequest(net.ParseIP(extractIP(req)), model.RequestProtocolTCP, clientID, msg)
+	clientIP := s.acl.dohClientIP(req)
+	if !s.acl.isAllowed(clientIP) {
+		s.acl.refused(clientIP, transportDoH)
+
+		refused := new(dns.Msg)
+		refused.SetRcode(msg, dns.RcodeRefused)
+		writeDohMsg(rw, refused)
+
+		return
+	}
+
+	r := newRequest(clientIP, model.RequestProtocolTCP, clientID, msg)
 
 	resResponse, err := s.queryResolver.Resolve(r)
+	if resolver.IsRateLimited(err) {
+		http.Error(rw, err.Error(), http.StatusTooManyRequests)
+
+		return
+	}
+
 	if err != nil {
 		logAndResponseWithError(err, "unable to process query: ", rw)
 
@@ -154,16 +220,30 @@ func (s *Server) processDohMessage(rawMsg []byte, rw http.ResponseWriter, req *h
 
 	response := new(dns.Msg)
 	response.SetReply(msg)
+
+	resResponse.Res = dnssecResponse(msg, resResponse.Res)
+
+	if s.cfg.MinimalResponses {
+		resResponse.Res = minimalResponse(resResponse.Res)
+	}
+
+	writeDohMsg(rw, resResponse.Res)
+}
+
+// writeDohMsg packs the message, records its size and writes it as DoH response
+func writeDohMsg(rw http.ResponseWriter, msg *dns.Msg) {
 	// enable compression
-	resResponse.Res.Compress = true
+	msg.Compress = true
 
-	b, err := resResponse.Res.Pack()
+	b, err := msg.Pack()
 	if err != nil {
 		logAndResponseWithError(err, "can't serialize message: ", rw)
 
 		return
 	}
 
+	messageSize(transportDoH, directionResponse, len(b))
+
 	rw.Header().Set("content-type", dnsContentType)
 
 	_, err = rw.Write(b)
@@ -2,16 +2,26 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/dnstest"
 	"github.com/0xERR0R/blocky/docs"
+	"github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
 	. "github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
@@ -32,10 +42,38 @@ var (
 
 var _ = BeforeSuite(func() {
 	var upstreamGoogle, upstreamFritzbox, upstreamClient config.Upstream
-	googleMockUpstream := resolver.NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+	googleMockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 		if request.Question[0].Name == "error." {
 			return nil
 		}
+		if request.Question[0].Name == "signed.example." {
+			// like a signed zone: the signature is only sent with the DO bit
+			response, err := util.NewMsgWithAnswer("signed.example.", 123, A, "192.0.2.1")
+			Expect(err).Should(Succeed())
+
+			if opt := request.IsEdns0(); opt != nil && opt.Do() {
+				response.Answer = append(response.Answer, &dns.RRSIG{
+					Hdr:         util.CreateHeader(dns.Question{Name: "signed.example.", Qtype: dns.TypeRRSIG}, 123),
+					TypeCovered: dns.TypeA, Algorithm: dns.ECDSAP256SHA256, SignerName: "example.", Signature: "AAAA",
+				})
+				response.SetEdns0(opt.UDPSize(), true)
+			}
+
+			return response
+		}
+		if request.Question[0].Name == "large.txt." {
+			// larger than the default EDNS UDP size of 1232 bytes
+			response := new(dns.Msg)
+
+			for i := 0; i < 30; i++ {
+				rr, err := dns.NewRR(fmt.Sprintf(`large.txt. 123 IN TXT "%d %s"`, i, strings.Repeat("x", 100)))
+				Expect(err).Should(Succeed())
+
+				response.Answer = append(response.Answer, rr)
+			}
+
+			return response
+		}
 		response, err := util.NewMsgWithAnswer(
 			util.ExtractDomain(request.Question[0]), 123, A, "123.124.122.122",
 		)
@@ -46,7 +84,7 @@ var _ = BeforeSuite(func() {
 	})
 	DeferCleanup(googleMockUpstream.Close)
 
-	fritzboxMockUpstream := resolver.NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+	fritzboxMockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 		response, err := util.NewMsgWithAnswer(
 			util.ExtractDomain(request.Question[0]), 3600, A, "192.168.178.2",
 		)
@@ -57,7 +95,7 @@ var _ = BeforeSuite(func() {
 	})
 	DeferCleanup(fritzboxMockUpstream.Close)
 
-	clientMockUpstream := resolver.NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+	clientMockUpstream := dnstest.NewMockUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
 		var clientName string
 		client := mockClientName.Load()
 
@@ -101,22 +139,31 @@ var _ = BeforeSuite(func() {
 	youtubeFile := tmpDir.CreateStringFile("youtube.com.txt", "youtube.com")
 	Expect(youtubeFile.Error).Should(Succeed())
 
+	// enough addresses to exceed the max UDP response size without EDNS
+	manyIPs := make([]net.IP, 0, 40)
+	for i := 1; i <= 40; i++ {
+		manyIPs = append(manyIPs, net.IPv4(192, 168, 178, byte(i)))
+	}
+
 	// create server
 	sut, err = NewServer(&config.Config{
 		CustomDNS: config.CustomDNSConfig{
 			CustomTTL: config.Duration(3600 * time.Second),
+			CreatePTR: true,
 			Mapping: config.CustomDNSMapping{
 				HostIPs: map[string][]net.IP{
 					"custom.lan": {net.ParseIP("192.168.178.55")},
 					"lan.home":   {net.ParseIP("192.168.178.56")},
+					"many.lan":   manyIPs,
 				},
 			},
 		},
 		Conditional: config.ConditionalUpstreamConfig{
 			Mapping: config.ConditionalUpstreamMapping{
 				Upstreams: map[string][]config.Upstream{
-					"net.cn":    {upstreamClient},
-					"fritz.box": {upstreamFritzbox},
+					"net.cn":                   {upstreamClient},
+					"fritz.box":                {upstreamFritzbox},
+					"178.168.192.in-addr.arpa": {upstreamClient},
 				},
 			},
 		},
@@ -155,8 +202,13 @@ var _ = BeforeSuite(func() {
 			HTTP:  config.ListenConfig{"4000"},
 			HTTPS: config.ListenConfig{"4443"},
 		},
-		CertFile: certPem.Path,
-		KeyFile:  keyPem.Path,
+		CertFile:    certPem.Path,
+		KeyFile:     keyPem.Path,
+		EDNSUDPSize: 1232,
+		ChaosQueries: config.ChaosQueriesConfig{
+			Enable:  true,
+			Version: "test-version",
+		},
 		Prometheus: config.MetricsConfig{
 			Enable: true,
 			Path:   "/metrics",
@@ -227,6 +279,23 @@ var _ = Describe("Running DNS server", func() {
 						))
 			})
 		})
+		Context("Reverse DNS of custom DNS entries", func() {
+			It("should answer PTR queries of custom DNS IPs before the conditional upstream", func() {
+				Expect(requestServer(util.NewMsgWithQuestion("55.178.168.192.in-addr.arpa.", PTR))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("55.178.168.192.in-addr.arpa.", PTR, "custom.lan."),
+							HaveTTL(BeNumerically("==", 3600)),
+						))
+			})
+
+			It("should resolve other IPs of the zone via conditional upstream resolver", func() {
+				mockClientName.Store("host.fritz.box")
+
+				Expect(requestServer(util.NewMsgWithQuestion("99.178.168.192.in-addr.arpa.", PTR))).
+					Should(BeDNSRecord("99.178.168.192.in-addr.arpa.", PTR, "host.fritz.box."))
+			})
+		})
 		Context("Conditional upstream blocking", func() {
 			It("Query should be blocked, domain is in default group", func() {
 				Expect(requestServer(util.NewMsgWithQuestion("doubleclick.net.cn.", A))).
@@ -537,6 +606,603 @@ var _ = Describe("Running DNS server", func() {
 		})
 	})
 
+	Describe("Message metrics", func() {
+		type messageSize struct {
+			transport, direction string
+		}
+
+		var (
+			lock      sync.Mutex
+			sizes     map[messageSize]int
+			truncated int
+			fallbacks int
+		)
+
+		BeforeEach(func() {
+			sizes = make(map[messageSize]int)
+			truncated = 0
+			fallbacks = 0
+
+			sizeFn := func(transport, direction string, size int) {
+				lock.Lock()
+				defer lock.Unlock()
+
+				sizes[messageSize{transport, direction}] = size
+			}
+			truncatedFn := func() {
+				lock.Lock()
+				defer lock.Unlock()
+
+				truncated++
+			}
+			fallbackFn := func() {
+				lock.Lock()
+				defer lock.Unlock()
+
+				fallbacks++
+			}
+
+			Expect(evt.Bus().Subscribe(evt.ServerMessageSize, sizeFn)).Should(Succeed())
+			Expect(evt.Bus().Subscribe(evt.ServerTruncatedResponse, truncatedFn)).Should(Succeed())
+			Expect(evt.Bus().Subscribe(evt.ServerTCPFallback, fallbackFn)).Should(Succeed())
+			DeferCleanup(func() {
+				Expect(evt.Bus().Unsubscribe(evt.ServerMessageSize, sizeFn)).Should(Succeed())
+				Expect(evt.Bus().Unsubscribe(evt.ServerTruncatedResponse, truncatedFn)).Should(Succeed())
+				Expect(evt.Bus().Unsubscribe(evt.ServerTCPFallback, fallbackFn)).Should(Succeed())
+			})
+		})
+
+		recorded := func() map[messageSize]int {
+			lock.Lock()
+			defer lock.Unlock()
+
+			return maps.Clone(sizes)
+		}
+
+		It("should record the size of DNS messages by transport", func() {
+			request := util.NewMsgWithQuestion("google.de.", A)
+			requestLen := request.Len()
+
+			client := dns.Client{Net: "udp"}
+			resp, _, err := client.Exchange(request, "127.0.0.1:55555")
+			Expect(err).Should(Succeed())
+
+			// the response is sent compressed
+			resp.Compress = true
+
+			Eventually(recorded).Should(SatisfyAll(
+				HaveKeyWithValue(messageSize{transportUDP, directionRequest}, requestLen),
+				HaveKeyWithValue(messageSize{transportUDP, directionResponse}, resp.Len()),
+			))
+
+			client = dns.Client{Net: "tcp"}
+			_, _, err = client.Exchange(request, "127.0.0.1:55555")
+			Expect(err).Should(Succeed())
+
+			Eventually(recorded).Should(HaveKey(messageSize{transportTCP, directionResponse}))
+
+			//nolint:gosec
+			client = dns.Client{Net: "tcp-tls", TLSConfig: &tls.Config{InsecureSkipVerify: true}}
+			_, _, err = client.Exchange(request, "127.0.0.1:8853")
+			Expect(err).Should(Succeed())
+
+			Eventually(recorded).Should(HaveKey(messageSize{transportDoT, directionResponse}))
+		})
+
+		It("should record the size of DoH messages", func() {
+			msg := util.NewMsgWithQuestion("www.example.com.", A)
+			rawDNSMessage, err := msg.Pack()
+			Expect(err).Should(Succeed())
+
+			resp, err := http.Post("http://localhost:4000/dns-query",
+				"application/dns-message", bytes.NewReader(rawDNSMessage))
+			Expect(err).Should(Succeed())
+			DeferCleanup(resp.Body.Close)
+
+			rawResponse, err := io.ReadAll(resp.Body)
+			Expect(err).Should(Succeed())
+
+			Expect(recorded()).Should(SatisfyAll(
+				HaveKeyWithValue(messageSize{transportDoH, directionRequest}, len(rawDNSMessage)),
+				HaveKeyWithValue(messageSize{transportDoH, directionResponse}, len(rawResponse)),
+			))
+		})
+
+		It("should count truncated UDP responses and the retries over TCP", func() {
+			request := util.NewMsgWithQuestion("many.lan.", A)
+
+			client := dns.Client{Net: "udp"}
+			resp, _, err := client.Exchange(request, "127.0.0.1:55555")
+			Expect(err).Should(Succeed())
+			Expect(resp.Truncated).Should(BeTrue())
+
+			client = dns.Client{Net: "tcp"}
+			resp, _, err = client.Exchange(request, "127.0.0.1:55555")
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(HaveLen(40))
+
+			// another query over TCP isn't a retry
+			_, _, err = client.Exchange(util.NewMsgWithQuestion("many.lan.", A), "127.0.0.1:55555")
+			Expect(err).Should(Succeed())
+
+			Eventually(func() []int {
+				lock.Lock()
+				defer lock.Unlock()
+
+				return []int{truncated, fallbacks}
+			}).Should(Equal([]int{1, 1}))
+		})
+	})
+
+	Describe("Late answers", func() {
+		var (
+			srv     *Server
+			dropped atomic.Int32
+		)
+
+		BeforeEach(func() {
+			srv = &Server{cfg: &config.Config{MaxAnswerAge: config.Duration(5 * time.Second)}}
+
+			dropped.Store(0)
+
+			droppedFn := func() {
+				dropped.Add(1)
+			}
+
+			Expect(evt.Bus().Subscribe(evt.ServerLateAnswerDropped, droppedFn)).Should(Succeed())
+			DeferCleanup(func() {
+				Expect(evt.Bus().Unsubscribe(evt.ServerLateAnswerDropped, droppedFn)).Should(Succeed())
+			})
+		})
+
+		requestOfAge := func(age time.Duration) *model.Request {
+			request := newRequest(net.ParseIP("192.168.178.10"), model.RequestProtocolUDP, "",
+				util.NewMsgWithQuestion("example.com.", A))
+			request.RequestTS = time.Now().Add(-age)
+
+			return request
+		}
+
+		It("should drop answers older than maxAnswerAge", func() {
+			Expect(srv.isLateAnswer(requestOfAge(time.Second))).Should(BeFalse())
+			Expect(dropped.Load()).Should(BeZero())
+
+			Expect(srv.isLateAnswer(requestOfAge(6 * time.Second))).Should(BeTrue())
+			Expect(dropped.Load()).Should(BeNumerically("==", 1))
+		})
+
+		It("should send all answers if disabled", func() {
+			srv.cfg.MaxAnswerAge = 0
+
+			Expect(srv.isLateAnswer(requestOfAge(time.Minute))).Should(BeFalse())
+			Expect(dropped.Load()).Should(BeZero())
+		})
+	})
+
+	Describe("Minimal responses", func() {
+		// a referral-heavy answer: the authority section with all name servers and their addresses as glue
+		referralHeavyResponse := func() *dns.Msg {
+			msg := new(dns.Msg)
+			msg.SetReply(util.NewMsgWithQuestion("www.example.com.", A))
+
+			msg.Answer = append(msg.Answer,
+				&dns.CNAME{Hdr: util.CreateHeader(dns.Question{Name: "www.example.com.", Qtype: dns.TypeCNAME}, 300),
+					Target: "cdn.example.net."},
+				&dns.A{Hdr: util.CreateHeader(dns.Question{Name: "cdn.example.net.", Qtype: dns.TypeA}, 300),
+					A: net.ParseIP("192.0.2.1")})
+
+			for i := 0; i < 13; i++ {
+				ns := fmt.Sprintf("ns%d.some-long-name-server-domain.example.net.", i)
+
+				msg.Ns = append(msg.Ns, &dns.NS{
+					Hdr: util.CreateHeader(dns.Question{Name: "example.net.", Qtype: dns.TypeNS}, 300), Ns: ns,
+				})
+				msg.Extra = append(msg.Extra,
+					&dns.A{Hdr: util.CreateHeader(dns.Question{Name: ns, Qtype: dns.TypeA}, 300),
+						A: net.IPv4(198, 51, 100, byte(i))},
+					&dns.AAAA{Hdr: util.CreateHeader(dns.Question{Name: ns, Qtype: dns.TypeAAAA}, 300),
+						AAAA: net.ParseIP(fmt.Sprintf("2001:db8::%d", i))})
+			}
+
+			msg.Extra = append(msg.Extra,
+				&dns.AAAA{Hdr: util.CreateHeader(dns.Question{Name: "cdn.example.net.", Qtype: dns.TypeAAAA}, 300),
+					AAAA: net.ParseIP("2001:db8::1")})
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+
+			return msg
+		}
+
+		It("should strip the authority and additional sections of positive answers", func() {
+			msg := referralHeavyResponse()
+
+			res := minimalResponse(msg)
+
+			Expect(res.Answer).Should(Equal(msg.Answer))
+			Expect(res.Ns).Should(BeEmpty())
+			Expect(res.Extra).Should(HaveLen(2))
+			Expect(res.IsEdns0()).ShouldNot(BeNil())
+			Expect(res.Extra).Should(ContainElement(SatisfyAll(
+				BeAssignableToTypeOf(&dns.AAAA{}), HaveField("Hdr.Name", "cdn.example.net."))))
+
+			By("keeping the original response, which may be cached", func() {
+				Expect(msg.Ns).Should(HaveLen(13))
+				Expect(msg.Extra).Should(HaveLen(28))
+			})
+		})
+
+		It("should keep the NSEC proof of wildcard answers", func() {
+			msg := referralHeavyResponse()
+			msg.Ns = append(msg.Ns,
+				&dns.NSEC{Hdr: util.CreateHeader(dns.Question{Name: "*.example.net.", Qtype: dns.TypeNSEC}, 300),
+					NextDomain: "example.net."},
+				&dns.RRSIG{Hdr: util.CreateHeader(dns.Question{Name: "*.example.net.", Qtype: dns.TypeRRSIG}, 300),
+					TypeCovered: dns.TypeNSEC, SignerName: "example.net."},
+				&dns.RRSIG{Hdr: util.CreateHeader(dns.Question{Name: "example.net.", Qtype: dns.TypeRRSIG}, 300),
+					TypeCovered: dns.TypeNS, SignerName: "example.net."},
+			)
+
+			res := minimalResponse(msg)
+
+			Expect(res.Ns).Should(HaveLen(2))
+			Expect(res.Ns[0]).Should(BeAssignableToTypeOf(&dns.NSEC{}))
+			Expect(res.Ns[1]).Should(HaveField("TypeCovered", dns.TypeNSEC))
+		})
+
+		It("should truncate large referral-heavy answers less often", func() {
+			full := referralHeavyResponse()
+			full.Truncate(dns.MinMsgSize)
+			Expect(full.Truncated).Should(BeTrue())
+
+			minimal := minimalResponse(referralHeavyResponse())
+			minimal.Truncate(dns.MinMsgSize)
+			Expect(minimal.Truncated).Should(BeFalse())
+			Expect(minimal.Answer).Should(HaveLen(2))
+		})
+
+		It("should keep the SOA of negative responses", func() {
+			msg := new(dns.Msg)
+			msg.SetRcode(util.NewMsgWithQuestion("nothing.example.com.", A), dns.RcodeNameError)
+			msg.Ns = []dns.RR{&dns.SOA{
+				Hdr: util.CreateHeader(dns.Question{Name: "example.com.", Qtype: dns.TypeSOA}, 300),
+				Ns:  "ns.example.com.", Mbox: "hostmaster.example.com.", Minttl: 60,
+			}}
+
+			Expect(minimalResponse(msg)).Should(BeIdenticalTo(msg))
+
+			By("also for NODATA", func() {
+				msg.Rcode = dns.RcodeSuccess
+
+				Expect(minimalResponse(msg).Ns).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("DNSSEC responses", func() {
+		signedResponse := func() *dns.Msg {
+			msg := new(dns.Msg)
+			msg.SetReply(util.NewMsgWithQuestion("example.com.", A))
+			msg.AuthenticatedData = true
+			msg.Answer = []dns.RR{
+				&dns.A{Hdr: util.CreateHeader(dns.Question{Name: "example.com.", Qtype: dns.TypeA}, 300),
+					A: net.ParseIP("192.0.2.1")},
+				&dns.RRSIG{Hdr: util.CreateHeader(dns.Question{Name: "example.com.", Qtype: dns.TypeRRSIG}, 300),
+					TypeCovered: dns.TypeA, SignerName: "example.com."},
+			}
+			msg.Ns = []dns.RR{
+				&dns.NSEC{Hdr: util.CreateHeader(dns.Question{Name: "example.com.", Qtype: dns.TypeNSEC}, 300),
+					NextDomain: "www.example.com."},
+			}
+			msg.SetEdns0(dns.DefaultMsgSize, true)
+
+			return msg
+		}
+
+		It("should return the DNSSEC records to clients with the DO bit", func() {
+			request := util.NewMsgWithQuestion("example.com.", A)
+			request.SetEdns0(dns.DefaultMsgSize, true)
+
+			msg := signedResponse()
+
+			Expect(dnssecResponse(request, msg)).Should(BeIdenticalTo(msg))
+		})
+
+		It("should strip the DNSSEC records for other clients", func() {
+			request := util.NewMsgWithQuestion("example.com.", A)
+			request.SetEdns0(dns.DefaultMsgSize, false)
+
+			msg := signedResponse()
+
+			res := dnssecResponse(request, msg)
+
+			Expect(res.Answer).Should(HaveLen(1))
+			Expect(res.Ns).Should(BeEmpty())
+			Expect(res.IsEdns0().Do()).Should(BeFalse())
+			Expect(res.AuthenticatedData).Should(BeFalse())
+
+			By("keeping the original response, which may be cached", func() {
+				Expect(msg.Answer).Should(HaveLen(2))
+				Expect(msg.Ns).Should(HaveLen(1))
+				Expect(msg.IsEdns0().Do()).Should(BeTrue())
+			})
+		})
+
+		It("should set the AD bit for clients with the AD bit", func() {
+			request := util.NewMsgWithQuestion("example.com.", A)
+			request.AuthenticatedData = true
+
+			Expect(dnssecResponse(request, signedResponse()).AuthenticatedData).Should(BeTrue())
+		})
+
+		It("should pass the DNSSEC records of the upstream to clients with the DO bit", func() {
+			request := util.NewMsgWithQuestion("signed.example.", A)
+			request.SetEdns0(dns.DefaultMsgSize, true)
+
+			resp := requestServer(request)
+
+			Expect(resp.Answer).Should(HaveLen(2))
+			Expect(resp.Answer).Should(ContainElement(BeAssignableToTypeOf(&dns.RRSIG{})))
+			Expect(resp.IsEdns0().Do()).Should(BeTrue())
+
+			By("removing them for other clients", func() {
+				request.IsEdns0().SetDo(false)
+
+				resp = requestServer(request)
+
+				Expect(resp.Answer).Should(HaveLen(1))
+				Expect(resp.IsEdns0().Do()).Should(BeFalse())
+
+				resp = requestServer(util.NewMsgWithQuestion("signed.example.", A))
+
+				Expect(resp.Answer).Should(HaveLen(1))
+				Expect(resp.IsEdns0()).Should(BeNil())
+			})
+		})
+
+		It("should set the DO bit of the request in added EDNS records", func() {
+			request := util.NewMsgWithQuestion("example.com.", A)
+			request.SetEdns0(dns.DefaultMsgSize, true)
+
+			Expect(sut.withEdnsRecord(request, new(dns.Msg)).IsEdns0().Do()).Should(BeTrue())
+		})
+
+		It("should answer explicitly queried DNSSEC records", func() {
+			request := util.NewMsgWithQuestion("example.com.", dns.Type(dns.TypeRRSIG))
+
+			Expect(dnssecResponse(request, signedResponse()).Answer).
+				Should(ContainElement(BeAssignableToTypeOf(&dns.RRSIG{})))
+		})
+	})
+
+	Describe("CHAOS queries", func() {
+		chaosRequest := func(name string) *dns.Msg {
+			request := util.NewMsgWithQuestion(name, TXT)
+			request.Question[0].Qclass = dns.ClassCHAOS
+
+			return request
+		}
+
+		It("should answer version.bind", func() {
+			resp := requestServer(chaosRequest("version.bind."))
+
+			Expect(resp.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(resp.Answer).Should(HaveLen(1))
+			Expect(resp.Answer[0].(*dns.TXT).Txt).Should(Equal([]string{"test-version"}))
+		})
+
+		It("should refuse other CHAOS queries", func() {
+			resp := requestServer(chaosRequest("authors.bind."))
+
+			Expect(resp.Rcode).Should(Equal(dns.RcodeRefused))
+		})
+	})
+
+	Describe("EDNS UDP size", func() {
+		query := func(network string, udpSize uint16) *dns.Msg {
+			request := util.NewMsgWithQuestion("large.txt.", TXT)
+			if udpSize > 0 {
+				request.SetEdns0(udpSize, false)
+			}
+
+			client := dns.Client{Net: network}
+			resp, _, err := client.Exchange(request, "127.0.0.1:55555")
+			Expect(err).Should(Succeed())
+
+			// the response is sent compressed
+			resp.Compress = true
+
+			return resp
+		}
+
+		It("should truncate UDP responses to the configured size", func() {
+			resp := query("udp", dns.DefaultMsgSize)
+			Expect(resp.Truncated).Should(BeTrue())
+			Expect(resp.Len()).Should(BeNumerically("<=", 1232))
+			Expect(resp.IsEdns0().UDPSize()).Should(BeEquivalentTo(1232))
+		})
+
+		It("should truncate UDP responses to the size advertised by the client", func() {
+			resp := query("udp", 1024)
+			Expect(resp.Truncated).Should(BeTrue())
+			Expect(resp.Len()).Should(BeNumerically("<=", 1024))
+
+			resp = query("udp", 0)
+			Expect(resp.Truncated).Should(BeTrue())
+			Expect(resp.Len()).Should(BeNumerically("<=", dns.MinMsgSize))
+		})
+
+		It("should send the whole response via TCP after a truncated UDP response", func() {
+			Expect(query("udp", 1232).Truncated).Should(BeTrue())
+
+			resp := query("tcp", 1232)
+			Expect(resp.Truncated).Should(BeFalse())
+			Expect(resp.Answer).Should(HaveLen(30))
+		})
+
+		DescribeTable("getMaxResponseSize",
+			func(network string, clientSize uint16, expected int) {
+				request := util.NewMsgWithQuestion("example.com.", A)
+				if clientSize > 0 {
+					request.SetEdns0(clientSize, false)
+				}
+
+				Expect(getMaxResponseSize(network, request, 1232)).Should(Equal(expected))
+			},
+			Entry("UDP without EDNS", "udp", uint16(0), dns.MinMsgSize),
+			Entry("UDP with smaller EDNS size", "udp", uint16(1024), 1024),
+			Entry("UDP with larger EDNS size", "udp", uint16(4096), 1232),
+			Entry("TCP", "tcp", uint16(1232), dns.MaxMsgSize),
+		)
+	})
+
+	Describe("TCP fallback tracker", func() {
+		var (
+			clock    *util.FakeClock
+			tracker  tcpFallbackTracker
+			clientIP net.IP
+			request  *dns.Msg
+		)
+
+		BeforeEach(func() {
+			clock = util.NewFakeClock()
+			DeferCleanup(util.SetClock(clock))
+
+			tracker = tcpFallbackTracker{}
+			clientIP = net.ParseIP("192.168.178.10")
+			request = util.NewMsgWithQuestion("many.lan.", A)
+		})
+
+		It("should detect a retry only once", func() {
+			Expect(tracker.isRetry(clientIP, request)).Should(BeFalse())
+
+			tracker.truncatedSent(clientIP, request)
+
+			Expect(tracker.isRetry(net.ParseIP("192.168.178.11"), request)).Should(BeFalse())
+			Expect(tracker.isRetry(clientIP, request)).Should(BeTrue())
+			Expect(tracker.isRetry(clientIP, request)).Should(BeFalse())
+		})
+
+		It("should ignore retries after the window", func() {
+			tracker.truncatedSent(clientIP, request)
+
+			clock.Advance(tcpFallbackWindow + time.Second)
+
+			Expect(tracker.isRetry(clientIP, request)).Should(BeFalse())
+		})
+	})
+
+	Describe("Protocol mismatch", func() {
+		//nolint:gosec
+		tlsConfig := func(protos ...string) *tls.Config {
+			return &tls.Config{InsecureSkipVerify: true, NextProtos: protos}
+		}
+
+		When("DoT client connects to the DoT port", func() {
+			It("should negotiate ALPN 'dot' and answer", func() {
+				for _, protos := range [][]string{{"dot"}, nil} {
+					client := dns.Client{Net: "tcp-tls", TLSConfig: tlsConfig(protos...)}
+
+					resp, _, err := client.Exchange(util.NewMsgWithQuestion("google.de.", A), "127.0.0.1:8853")
+					Expect(err).Should(Succeed())
+					Expect(resp.Rcode).Should(Equal(dns.RcodeSuccess))
+				}
+			})
+		})
+
+		When("HTTPS client connects to the DoT port", func() {
+			It("should reject the TLS handshake", func() {
+				conn, err := tls.Dial("tcp", "127.0.0.1:8853", tlsConfig("h2", "http/1.1"))
+				if err == nil {
+					conn.Close()
+				}
+
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		When("plain HTTP request is sent to the DoT port", func() {
+			It("should answer with bad request and close the connection", func() {
+				conn, err := net.Dial("tcp", "127.0.0.1:8853")
+				Expect(err).Should(Succeed())
+				DeferCleanup(conn.Close)
+
+				_, err = conn.Write([]byte("GET /dns-query HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+				Expect(err).Should(Succeed())
+
+				Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).Should(Succeed())
+
+				body, err := io.ReadAll(conn)
+				Expect(err).Should(Succeed())
+				Expect(string(body)).Should(HavePrefix("HTTP/1.0 400 Bad Request"))
+			})
+		})
+
+		When("DoT client connects to the HTTPS port", func() {
+			It("should reject the TLS handshake", func() {
+				conn, err := tls.Dial("tcp", "127.0.0.1:4443", tlsConfig("dot"))
+				if err == nil {
+					conn.Close()
+				}
+
+				Expect(err).Should(HaveOccurred())
+			})
+
+			It("should accept HTTPS clients", func() {
+				conn, err := tls.Dial("tcp", "127.0.0.1:4443", tlsConfig("h2", "http/1.1"))
+				Expect(err).Should(Succeed())
+				Expect(conn.ConnectionState().NegotiatedProtocol).Should(Equal("h2"))
+				Expect(conn.Close()).Should(Succeed())
+			})
+		})
+
+		Describe("isHTTPRequest", func() {
+			It("should detect HTTP requests", func() {
+				Expect(isHTTPRequest([]byte("GET /"))).Should(BeTrue())
+				Expect(isHTTPRequest([]byte("POST "))).Should(BeTrue())
+				Expect(isHTTPRequest([]byte("PRI *"))).Should(BeTrue())
+				Expect(isHTTPRequest([]byte{0x00, 0x1d, 0xab, 0xcd, 0x01})).Should(BeFalse())
+				Expect(isHTTPRequest([]byte("GETS "))).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("Startup summary", func() {
+		It("should contain the phases of the startup", func() {
+			summary := sut.StartupSummary()
+
+			Expect(summary.Duration).Should(BeNumerically(">", 0))
+			Expect(summary.Phases).Should(ContainElements(
+				HaveField("Name", "certificate"),
+				HaveField("Name", "listener binding"),
+				HaveField("Name", "bootstrap"),
+				HaveField("Name", "resolvers"),
+				HaveField("Name", "list load blacklist ads"),
+				HaveField("Name", "list load whitelist whitelist"),
+			))
+		})
+
+		It("should order the phases by their start and ignore phases after the start", func() {
+			timer := newStartupTimer()
+			now := time.Now()
+
+			timer.addPhase("resolvers", now.Add(-time.Second), time.Second)
+			timer.addPhase("config load", now.Add(-2*time.Second), time.Millisecond)
+			evt.Bus().Publish(evt.StartupPhaseCompleted, "list load blacklist ads", 500*time.Millisecond)
+
+			timer.finish()
+
+			evt.Bus().Publish(evt.StartupPhaseCompleted, "list load blacklist late", time.Second)
+
+			summary := timer.summary()
+
+			Expect(summary.Duration).Should(BeNumerically(">=", 2*time.Second))
+			Expect(summary.Phases).Should(Equal([]api.StartupPhase{
+				{Name: "config load", Duration: time.Millisecond},
+				{Name: "resolvers", Duration: time.Second},
+				{Name: "list load blacklist ads", Duration: 500 * time.Millisecond},
+			}))
+		})
+	})
+
 	Describe("Server create", func() {
 		var (
 			cfg  config.Config
@@ -659,6 +1325,75 @@ var _ = Describe("Running DNS server", func() {
 		})
 	})
 
+	Describe("Server with profiles", func() {
+		var server *Server
+
+		BeforeEach(func() {
+			upstream := dnstest.NewMockUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+			DeferCleanup(upstream.Close)
+
+			tmpDir := NewTmpFolder("server-profiles")
+			Expect(tmpDir.Error).Should(Succeed())
+			DeferCleanup(tmpDir.Clean)
+
+			blacklist := tmpDir.CreateStringFile("blacklist.txt", "example.com")
+			Expect(blacklist.Error).Should(Succeed())
+
+			server, err = NewServer(&config.Config{
+				Upstreams: config.UpstreamsConfig{
+					Groups: config.UpstreamGroups{"default": {upstream.Start()}},
+				},
+				Blocking: config.BlockingConfig{BlockType: "zeroIp"},
+				Ports: config.PortsConfig{
+					DNS: config.ListenConfig{"127.0.0.1:55558"},
+				},
+				Profiles: config.ProfilesConfig{
+					"kids": {
+						Ports: config.ProfilePortsConfig{
+							DNS: config.ListenConfig{"127.0.0.1:55559"},
+						},
+						Blocking: config.BlockingConfig{
+							BlackLists: map[string][]config.BytesSource{
+								"ads": config.NewBytesSources(blacklist.Path),
+							},
+							ClientGroupsBlock: map[string][]string{"default": {"ads"}},
+							BlockType:         "zeroIp",
+						},
+					},
+				},
+			})
+			Expect(err).Should(Succeed())
+
+			errChan := make(chan error, 10)
+
+			go server.Start(errChan)
+			DeferCleanup(server.Stop)
+
+			Consistently(errChan, "1s").ShouldNot(Receive())
+		})
+
+		It("should resolve queries with the resolver chain of the profile", func() {
+			request := util.NewMsgWithQuestion("example.com.", A)
+
+			resp, err := dns.Exchange(request, "127.0.0.1:55558")
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+
+			resp, err = dns.Exchange(request, "127.0.0.1:55559")
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(BeDNSRecord("example.com.", A, "0.0.0.0"))
+		})
+
+		It("should serve the API of the profile", func() {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/profiles/kids/api/blocking/status", nil)
+
+			server.httpMux.ServeHTTP(rec, req)
+
+			Expect(rec.Code).Should(Equal(http.StatusOK))
+		})
+	})
+
 	Describe("NewServer with strict upstream strategy", func() {
 		It("successfully returns upstream branches", func() {
 			branches, err := createUpstreamBranches(&config.Config{
@@ -678,10 +1413,65 @@ var _ = Describe("Running DNS server", func() {
 		})
 	})
 
+	Describe("upstream branches", func() {
+		var cfg config.Config
+
+		BeforeEach(func() {
+			Expect(defaults.Set(&cfg)).Should(Succeed())
+
+			cfg.Upstreams.Groups = config.UpstreamGroups{
+				"default": {{Net: config.NetProtocolTcpUdp, Host: "0.0.0.0", Port: 53}},
+			}
+		})
+
+		// branchConfig returns the logged configuration of the default branch
+		branchConfig := func() []string {
+			GinkgoHelper()
+
+			branches, err := createUpstreamBranches(&cfg, nil)
+			Expect(err).Should(Succeed())
+
+			logger, hook := NewMockEntry()
+			branches["default"].LogConfig(logger)
+
+			return hook.Messages
+		}
+
+		DescribeTable("should pass the response quality thresholds",
+			func(strategy config.UpstreamStrategy) {
+				cfg.Upstreams.Strategy = strategy
+				cfg.Upstreams.ResponseQuality.ServFailThreshold = 0.5
+				cfg.Upstreams.ResponseQuality.RefusedThreshold = 0.25
+				cfg.Upstreams.ResponseQuality.ServFailWait = config.Duration(time.Second)
+
+				Expect(branchConfig()).Should(ContainElements(
+					"  servFailThreshold = 0.5",
+					"  refusedThreshold  = 0.25",
+					"  servFailWait      = 1 second",
+				))
+			},
+			Entry("strict", config.UpstreamStrategyStrict),
+			Entry("parallel_best", config.UpstreamStrategyParallelBest),
+		)
+
+		It("should limit the parallel queries by default", func() {
+			Expect(branchConfig()).Should(ContainElement("maxParallelQueries: 1000"))
+		})
+
+		It("should pass the limit of parallel queries", func() {
+			cfg.Upstreams.MaxParallelQueries = 10
+
+			Expect(branchConfig()).Should(ContainElement("maxParallelQueries: 10"))
+		})
+	})
+
 	Describe("create query resolver", func() {
 		When("some upstream returns error", func() {
 			It("create query resolver should return error", func() {
-				r, err := createQueryResolver(&config.Config{
+				ctx, cancel := context.WithCancel(context.Background())
+				DeferCleanup(cancel)
+
+				r, err := createQueryResolver(ctx, &config.Config{
 					StartVerifyUpstream: true,
 					Upstreams: config.UpstreamsConfig{
 						Groups: config.UpstreamGroups{
@@ -689,7 +1479,7 @@ var _ = Describe("Running DNS server", func() {
 						},
 					},
 				},
-					nil, nil)
+					nil, nil, "")
 
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(MatchError(ContainSubstring("creation of upstream branches failed: ")))
@@ -698,6 +1488,81 @@ var _ = Describe("Running DNS server", func() {
 		})
 	})
 
+	Describe("conditional fall through", func() {
+		It("should protect the answers of the default upstreams", func() {
+			var cfg config.Config
+			Expect(defaults.Set(&cfg)).Should(Succeed())
+
+			defaultUpstream := dnstest.NewMockUpstreamServer().WithAnswerRR("host.corp.example.com 300 IN A 192.168.1.10")
+			DeferCleanup(defaultUpstream.Close)
+
+			corpUpstream := dnstest.NewMockUpstreamServer().WithAnswerError(dns.RcodeNameError)
+			DeferCleanup(corpUpstream.Close)
+
+			cfg.Upstreams.Groups = config.UpstreamGroups{"default": {defaultUpstream.Start()}}
+			cfg.Conditional.Mapping = config.ConditionalUpstreamMapping{
+				Upstreams: map[string][]config.Upstream{"corp.example.com": {corpUpstream.Start()}},
+				Fallthrough: map[string]config.ConditionalFallthrough{
+					"corp.example.com": {OnNXDomain: true},
+				},
+			}
+			cfg.RebindProtection.Enable = true
+
+			bootstrap, err := resolver.NewBootstrap(&cfg)
+			Expect(err).Should(Succeed())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			DeferCleanup(cancel)
+
+			r, err := createQueryResolver(ctx, &cfg, bootstrap, nil, "")
+			Expect(err).Should(Succeed())
+
+			resp, err := r.Resolve(&model.Request{
+				Req: util.NewMsgWithQuestion("host.corp.example.com.", A),
+				Log: Log().WithField("test", "fallthrough"),
+			})
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Rcode).Should(Equal(dns.RcodeNameError))
+			Expect(resp.Reason).Should(Equal("CONDITIONAL FALLTHROUGH (NXDOMAIN): REBIND PROTECTION"))
+		})
+	})
+
+	Describe("name server identifier", func() {
+		It("should identify short-circuited responses", func() {
+			var cfg config.Config
+			Expect(defaults.Set(&cfg)).Should(Succeed())
+
+			cfg.Upstreams.Groups = config.UpstreamGroups{
+				"default": {{Net: config.NetProtocolTcpUdp, Host: "0.0.0.0", Port: 53}},
+			}
+			cfg.Filtering.QueryTypes = config.NewQTypeSet(dns.Type(dns.TypeAAAA))
+			cfg.NSID = config.NSIDConfig{Enable: true, Identifier: "blocky-1"}
+
+			bootstrap, err := resolver.NewBootstrap(&cfg)
+			Expect(err).Should(Succeed())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			DeferCleanup(cancel)
+
+			r, err := createQueryResolver(ctx, &cfg, bootstrap, nil, "")
+			Expect(err).Should(Succeed())
+
+			req := util.NewMsgWithQuestion("example.com.", AAAA)
+			req.SetEdns0(dns.DefaultMsgSize, false)
+			opt := req.IsEdns0()
+			opt.Option = append(opt.Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID})
+
+			resp, err := r.Resolve(&model.Request{Req: req, Log: Log().WithField("test", "nsid")})
+			Expect(err).Should(Succeed())
+
+			Expect(resp.RType).Should(Equal(model.ResponseTypeFILTERED))
+			Expect(resp.Res.IsEdns0().Option).Should(ContainElement(
+				&dns.EDNS0_NSID{Code: dns.EDNS0NSID, Nsid: hex.EncodeToString([]byte("blocky-1"))},
+			))
+		})
+	})
+
 	Describe("resolve client IP", func() {
 		Context("UDP address", func() {
 			It("should correct resolve client IP", func() {
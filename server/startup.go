@@ -0,0 +1,113 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/evt"
+)
+
+// startupPhase is a completed phase of the startup
+type startupPhase struct {
+	name     string
+	start    time.Time
+	duration time.Duration
+}
+
+// startupTimer records the phases of the startup, published as `evt.StartupPhaseCompleted`,
+// until the server is started
+type startupTimer struct {
+	lock     sync.Mutex
+	phases   []startupPhase
+	duration time.Duration
+	finished bool
+}
+
+func newStartupTimer() *startupTimer {
+	t := &startupTimer{}
+
+	_ = evt.Bus().Subscribe(evt.StartupPhaseCompleted, t.phaseCompleted)
+
+	return t
+}
+
+// phaseCompleted records a phase which ended now
+func (t *startupTimer) phaseCompleted(name string, duration time.Duration) {
+	t.addPhase(name, time.Now().Add(-duration), duration)
+}
+
+func (t *startupTimer) addPhase(name string, start time.Time, duration time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.finished {
+		return
+	}
+
+	t.phases = append(t.phases, startupPhase{name: name, start: start, duration: duration})
+}
+
+// stop ends the recording without a summary, e.g. if the startup failed.
+// Returns false if the recording already ended.
+func (t *startupTimer) stop() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// the bus identifies handlers by their code, so the timer stays subscribed and ignores further phases
+	running := !t.finished
+	t.finished = true
+
+	return running
+}
+
+// finish ends the recording, logs the summary and publishes the durations
+func (t *startupTimer) finish() {
+	if !t.stop() {
+		return
+	}
+
+	t.lock.Lock()
+
+	// nested phases (e.g. list loads within the resolvers) follow their parent
+	slices.SortStableFunc(t.phases, func(a, b startupPhase) int {
+		return a.start.Compare(b.start)
+	})
+
+	if len(t.phases) > 0 {
+		t.duration = time.Since(t.phases[0].start)
+	}
+
+	logger().Infof("startup finished in %s:", t.duration.Round(time.Millisecond))
+
+	phaseDurations := make(map[string]time.Duration, len(t.phases))
+
+	for _, phase := range t.phases {
+		logger().Infof("  %-40s %10s", phase.name, phase.duration.Round(time.Millisecond))
+
+		phaseDurations[phase.name] = phase.duration
+	}
+
+	duration := t.duration
+
+	t.lock.Unlock()
+
+	evt.Bus().Publish(evt.StartupFinished, duration, phaseDurations)
+}
+
+func (t *startupTimer) summary() api.StartupSummary {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	result := api.StartupSummary{
+		Duration: t.duration,
+		Phases:   make([]api.StartupPhase, 0, len(t.phases)),
+	}
+
+	for _, phase := range t.phases {
+		result.Phases = append(result.Phases, api.StartupPhase{Name: phase.name, Duration: phase.duration})
+	}
+
+	return result
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/resolver"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// watchdogUpstreamsUnreachable classifies failures caused by upstreams, they don't trigger the mitigation
+	watchdogUpstreamsUnreachable = "upstreamsUnreachable"
+	// watchdogInternalStall classifies failures where blocky itself doesn't answer anymore
+	watchdogInternalStall = "internalStall"
+
+	watchdogClientName = "blocky-watchdog"
+)
+
+var errWatchdogTimeout = errors.New("no answer in time")
+
+// watchdog periodically resolves a canary name through the resolver chain and applies
+// the configured mitigation after too many consecutive internal stalls.
+type watchdog struct {
+	cfg         config.WatchdogConfig
+	localCanary string
+
+	query func(msg *dns.Msg) (*dns.Msg, error)
+	reset func()
+
+	failures uint
+	stop     chan struct{}
+}
+
+func newWatchdog(cfg *config.Config, queryResolver resolver.Resolver, bootstrap *resolver.Bootstrap) *watchdog {
+	w := &watchdog{
+		cfg:         cfg.Watchdog,
+		localCanary: cfg.Watchdog.LocalCanary,
+		reset: func() {
+			bootstrap.ResetConnections()
+			resolver.ResetConnections(queryResolver)
+		},
+		stop: make(chan struct{}),
+	}
+
+	if w.localCanary == "" && len(cfg.CustomDNS.Mapping.HostIPs) != 0 {
+		// any custom DNS name with IPs is answered without involving the upstreams, unlike CNAMEs
+		names := make([]string, 0, len(cfg.CustomDNS.Mapping.HostIPs))
+		for name := range cfg.CustomDNS.Mapping.HostIPs {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		w.localCanary = names[0]
+	}
+
+	if w.cfg.Address != "" {
+		client := dns.Client{Net: "udp", Timeout: w.cfg.CheckTimeout()}
+
+		w.query = func(msg *dns.Msg) (*dns.Msg, error) {
+			resp, _, err := client.Exchange(msg, w.cfg.Address)
+
+			return resp, err
+		}
+	} else {
+		w.query = func(msg *dns.Msg) (*dns.Msg, error) {
+			return resolveInProcess(queryResolver, msg, w.cfg.CheckTimeout())
+		}
+	}
+
+	return w
+}
+
+func (w *watchdog) log() *logrus.Entry {
+	return log.PrefixedLog("watchdog")
+}
+
+// run checks periodically until stopped. If the mitigation is to exit, the error is sent to `errCh`.
+func (w *watchdog) run(errCh chan<- error) {
+	ticker := time.NewTicker(w.cfg.Interval.ToDuration())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.check(); err != nil {
+				errCh <- err
+
+				return
+			}
+
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// check performs a single check, the returned error means blocky should exit.
+func (w *watchdog) check() error {
+	// random label: the answer can't be cached, so the request really goes through the chain
+	canary := fmt.Sprintf("%s-%08x.%s", watchdogClientName, rand.Uint32(), w.cfg.Canary) //nolint:gosec
+
+	err := w.resolve(canary)
+	if err == nil {
+		if w.failures > 0 {
+			w.log().Infof("recovered after %d failed checks", w.failures)
+		}
+
+		w.failures = 0
+
+		return nil
+	}
+
+	reason := w.classify(err)
+
+	evt.Bus().Publish(evt.WatchdogCheckFailed, reason)
+
+	if reason == watchdogUpstreamsUnreachable {
+		w.log().Warnf("check failed, upstreams seem to be unreachable: %s", err)
+
+		return nil
+	}
+
+	w.failures++
+
+	w.log().Warnf("check failed (%d/%d), blocky seems to be stalled: %s", w.failures, w.cfg.FailureThreshold, err)
+
+	if w.failures < w.cfg.FailureThreshold {
+		return nil
+	}
+
+	w.failures = 0
+
+	return w.mitigate()
+}
+
+// classify tells if a failure is caused by the upstreams or by blocky itself
+func (w *watchdog) classify(canaryErr error) string {
+	if w.localCanary != "" {
+		if err := w.resolve(w.localCanary); err == nil {
+			return watchdogUpstreamsUnreachable
+		}
+
+		return watchdogInternalStall
+	}
+
+	// without a local name, only a missing answer is considered a stall
+	if errors.Is(canaryErr, errWatchdogTimeout) {
+		return watchdogInternalStall
+	}
+
+	var netErr net.Error
+	if errors.As(canaryErr, &netErr) && netErr.Timeout() {
+		return watchdogInternalStall
+	}
+
+	return watchdogUpstreamsUnreachable
+}
+
+func (w *watchdog) mitigate() error {
+	action := w.cfg.Mitigation
+
+	evt.Bus().Publish(evt.WatchdogMitigationApplied, action.String())
+
+	switch action {
+	case config.WatchdogMitigationLog:
+		w.log().Errorf("%d consecutive checks failed", w.cfg.FailureThreshold)
+
+	case config.WatchdogMitigationReset:
+		w.log().Errorf("%d consecutive checks failed, resetting upstream connections", w.cfg.FailureThreshold)
+
+		w.reset()
+
+	case config.WatchdogMitigationExit:
+		return fmt.Errorf("watchdog: %d consecutive checks failed, exiting", w.cfg.FailureThreshold)
+	}
+
+	return nil
+}
+
+func (w *watchdog) resolve(name string) error {
+	msg := util.NewMsgWithQuestion(dns.Fqdn(name), dns.Type(dns.TypeA))
+
+	resp, err := w.query(msg)
+	if err != nil {
+		return err
+	}
+
+	if resp.Rcode == dns.RcodeServerFailure {
+		return fmt.Errorf("received %s for '%s'", dns.RcodeToString[resp.Rcode], name)
+	}
+
+	return nil
+}
+
+func (w *watchdog) Stop() {
+	close(w.stop)
+}
+
+// resolveInProcess resolves `msg` with `r`, and gives up waiting after `timeout`
+func resolveInProcess(r resolver.Resolver, msg *dns.Msg, timeout time.Duration) (*dns.Msg, error) {
+	type result struct {
+		resp *model.Response
+		err  error
+	}
+
+	ch := make(chan result, 1)
+
+	go func() {
+		request := newRequest(net.IPv4(127, 0, 0, 1), model.RequestProtocolUDP, "", msg) //nolint:gomnd
+		request.ClientNames = []string{watchdogClientName}
+
+		resp, err := r.Resolve(request)
+		ch <- result{resp, err}
+	}()
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return nil, res.err
+		}
+
+		return res.resp.Res, nil
+
+	case <-time.After(timeout):
+		return nil, errWatchdogTimeout
+	}
+}
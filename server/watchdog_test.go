@@ -0,0 +1,213 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+// blockingResolver never answers
+type blockingResolver struct {
+	release chan struct{}
+}
+
+func (r *blockingResolver) IsEnabled() bool         { return true }
+func (r *blockingResolver) LogConfig(*logrus.Entry) {}
+func (r *blockingResolver) Type() string            { return "blocking_test" }
+func (r *blockingResolver) Resolve(*model.Request) (*model.Response, error) {
+	<-r.release
+
+	return nil, errors.New("released")
+}
+
+var _ = Describe("Watchdog", func() {
+	var (
+		sut *watchdog
+
+		canaryErr, localErr error
+		resetCalls          int
+	)
+
+	BeforeEach(func() {
+		canaryErr = nil
+		localErr = nil
+		resetCalls = 0
+
+		cfg, err := config.WithDefaults[config.WatchdogConfig]()
+		Expect(err).Should(Succeed())
+
+		cfg.Enable = true
+		cfg.LocalCanary = "local.lan"
+		cfg.FailureThreshold = 2
+		cfg.Mitigation = config.WatchdogMitigationReset
+
+		sut = &watchdog{
+			cfg:         cfg,
+			localCanary: cfg.LocalCanary,
+			query: func(msg *dns.Msg) (*dns.Msg, error) {
+				if msg.Question[0].Name == "local.lan." {
+					return new(dns.Msg), localErr
+				}
+
+				Expect(msg.Question[0].Name).Should(HaveSuffix(".example.com."))
+
+				return new(dns.Msg), canaryErr
+			},
+			reset: func() { resetCalls++ },
+			stop:  make(chan struct{}),
+		}
+	})
+
+	When("the canary resolves", func() {
+		It("should not count a failure", func() {
+			Expect(sut.check()).Should(Succeed())
+			Expect(sut.failures).Should(BeZero())
+		})
+	})
+
+	When("only the canary fails", func() {
+		It("should classify it as an upstream failure and never mitigate", func() {
+			canaryErr = errors.New("upstream down")
+
+			for i := 0; i < 5; i++ {
+				Expect(sut.check()).Should(Succeed())
+			}
+
+			Expect(sut.failures).Should(BeZero())
+			Expect(resetCalls).Should(BeZero())
+		})
+	})
+
+	When("the local canary fails too", func() {
+		BeforeEach(func() {
+			canaryErr = errWatchdogTimeout
+			localErr = errWatchdogTimeout
+		})
+
+		It("should mitigate after the threshold is reached", func() {
+			Expect(sut.check()).Should(Succeed())
+			Expect(sut.failures).Should(BeEquivalentTo(1))
+			Expect(resetCalls).Should(BeZero())
+
+			Expect(sut.check()).Should(Succeed())
+			Expect(sut.failures).Should(BeZero())
+			Expect(resetCalls).Should(Equal(1))
+		})
+
+		It("should reset the failure count after a success", func() {
+			Expect(sut.check()).Should(Succeed())
+
+			canaryErr = nil
+			Expect(sut.check()).Should(Succeed())
+			Expect(sut.failures).Should(BeZero())
+
+			canaryErr = errWatchdogTimeout
+			Expect(sut.check()).Should(Succeed())
+			Expect(resetCalls).Should(BeZero())
+		})
+
+		It("should return an error with the exit mitigation", func() {
+			sut.cfg.Mitigation = config.WatchdogMitigationExit
+
+			Expect(sut.check()).Should(Succeed())
+			Expect(sut.check()).Should(MatchError(ContainSubstring("exiting")))
+		})
+	})
+
+	When("no local canary is configured", func() {
+		BeforeEach(func() {
+			sut.localCanary = ""
+			sut.cfg.FailureThreshold = 1
+		})
+
+		It("should only count missing answers", func() {
+			canaryErr = errors.New("SERVFAIL")
+			Expect(sut.check()).Should(Succeed())
+			Expect(resetCalls).Should(BeZero())
+
+			canaryErr = errWatchdogTimeout
+			Expect(sut.check()).Should(Succeed())
+			Expect(resetCalls).Should(Equal(1))
+		})
+	})
+
+	When("the answer is SERVFAIL", func() {
+		It("should be a failure", func() {
+			sut.query = func(msg *dns.Msg) (*dns.Msg, error) {
+				resp := new(dns.Msg)
+				resp.SetRcode(msg, dns.RcodeServerFailure)
+
+				return resp, nil
+			}
+
+			Expect(sut.resolve("example.com")).Should(MatchError(ContainSubstring("SERVFAIL")))
+		})
+	})
+
+	Describe("newWatchdog", func() {
+		It("should use a custom DNS name as local canary", func() {
+			cfg, err := config.WithDefaults[config.Config]()
+			Expect(err).Should(Succeed())
+
+			cfg.CustomDNS.Mapping.HostIPs = map[string][]net.IP{
+				"b.lan": {net.ParseIP("192.168.1.2")},
+				"a.lan": {net.ParseIP("192.168.1.1")},
+			}
+
+			Expect(newWatchdog(&cfg, nil, nil).localCanary).Should(Equal("a.lan"))
+		})
+	})
+
+	Describe("resolveInProcess", func() {
+		It("should give up after the timeout", func() {
+			r := &blockingResolver{release: make(chan struct{})}
+			DeferCleanup(func() { close(r.release) })
+
+			msg := new(dns.Msg)
+			msg.SetQuestion("example.com.", dns.TypeA)
+
+			start := time.Now()
+			_, err := resolveInProcess(r, msg, 10*time.Millisecond)
+
+			Expect(err).Should(MatchError(errWatchdogTimeout))
+			Expect(time.Since(start)).Should(BeNumerically("<", time.Second))
+		})
+	})
+
+	It("should stop running when stopped", func() {
+		sut.cfg.Interval = config.Duration(time.Millisecond)
+
+		done := make(chan struct{})
+
+		go func() {
+			sut.run(make(chan error))
+			close(done)
+		}()
+
+		sut.Stop()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("should send the exit error", func() {
+		sut.cfg.Interval = config.Duration(time.Millisecond)
+		sut.cfg.Mitigation = config.WatchdogMitigationExit
+		canaryErr = errWatchdogTimeout
+		localErr = errWatchdogTimeout
+
+		errCh := make(chan error, 1)
+		go sut.run(errCh)
+
+		Eventually(errCh).Should(Receive(WithTransform(func(err error) bool {
+			return strings.Contains(err.Error(), "exiting")
+		}, BeTrue())))
+	})
+})
@@ -0,0 +1,162 @@
+package trie
+
+import "strings"
+
+// SplitFunc splits a key into its first label in trie order and the rest of the key
+type SplitFunc func(key string) (label, rest string)
+
+// SplitTLD splits domain names from the right: "www.example.com" is split into "com" and "www.example"
+func SplitTLD(domain string) (label, rest string) {
+	idx := strings.LastIndexByte(domain, '.')
+	if idx < 0 {
+		return domain, ""
+	}
+
+	return domain[idx+1:], domain[:idx]
+}
+
+// Trie stores keys by their labels, a key covers itself and all keys below it.
+// With SplitTLD the lookup cost only depends on the number of labels of the searched domain, not on the number
+// of entries.
+//
+// The trie is not safe for concurrent modification, it should be built once and only read afterwards.
+type Trie struct {
+	split SplitFunc
+	root  parent
+	count int
+}
+
+// parent is a node with children. A label is either a parent or a terminal.
+//
+// A terminal holds the rest of a single key below its label, "" if the key ends at the label.
+// Storing the rest in a single string avoids a node per label for entries without siblings, which are most of them
+type parent struct {
+	parents   map[string]*parent
+	terminals map[string]string
+}
+
+// NewTrie creates an empty trie using split to traverse keys
+func NewTrie(split SplitFunc) *Trie {
+	return &Trie{split: split}
+}
+
+// Count returns the number of inserted keys which weren't already covered
+func (t *Trie) Count() int {
+	return t.count
+}
+
+// Insert adds key to the trie, returns false if the key is already covered by an entry
+func (t *Trie) Insert(key string) bool {
+	if len(key) == 0 {
+		return false
+	}
+
+	p := &t.root
+
+	for {
+		label, rest := t.split(key)
+
+		if terminal, found := p.terminals[label]; found {
+			if len(terminal) == 0 || t.covers(terminal, rest) {
+				return false
+			}
+
+			if len(rest) == 0 {
+				// the new key covers the existing one
+				p.setTerminal(label, "")
+				t.count++
+
+				return true
+			}
+
+			// both keys continue below the label: move the existing one into a new parent
+			np := new(parent)
+			np.setTerminal(t.split(terminal))
+			p.setParent(label, np)
+			p = np
+		} else if child, found := p.parents[label]; found {
+			if len(rest) == 0 {
+				// the new key covers all the children
+				p.setTerminal(label, "")
+				t.count++
+
+				return true
+			}
+
+			p = child
+		} else {
+			p.setTerminal(label, rest)
+			t.count++
+
+			return true
+		}
+
+		key = rest
+	}
+}
+
+// HasParentOf returns true if key or one of its parents was inserted
+func (t *Trie) HasParentOf(key string) bool {
+	p := &t.root
+
+	for len(key) > 0 {
+		label, rest := t.split(key)
+
+		if terminal, found := p.terminals[label]; found {
+			return len(terminal) == 0 || (len(rest) > 0 && t.covers(terminal, rest))
+		}
+
+		child, found := p.parents[label]
+		if !found {
+			return false
+		}
+
+		p = child
+		key = rest
+	}
+
+	// only the key itself or its parents match, its children don't
+	return false
+}
+
+// covers returns true if key is the terminal's key or below it
+func (t *Trie) covers(terminal, key string) bool {
+	for {
+		terminalLabel, terminalRest := t.split(terminal)
+		keyLabel, keyRest := t.split(key)
+
+		if terminalLabel != keyLabel {
+			return false
+		}
+
+		if len(terminalRest) == 0 {
+			return true
+		}
+
+		if len(keyRest) == 0 {
+			return false
+		}
+
+		terminal, key = terminalRest, keyRest
+	}
+}
+
+func (p *parent) setTerminal(label, rest string) {
+	delete(p.parents, label)
+
+	if p.terminals == nil {
+		p.terminals = make(map[string]string)
+	}
+
+	p.terminals[label] = rest
+}
+
+func (p *parent) setParent(label string, child *parent) {
+	delete(p.terminals, label)
+
+	if p.parents == nil {
+		p.parents = make(map[string]*parent)
+	}
+
+	p.parents[label] = child
+}
@@ -0,0 +1,93 @@
+package trie
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trie", func() {
+	var sut *Trie
+
+	BeforeEach(func() {
+		sut = NewTrie(SplitTLD)
+	})
+
+	Describe("SplitTLD", func() {
+		It("should split the last label", func() {
+			label, rest := SplitTLD("www.example.com")
+			Expect(label).Should(Equal("com"))
+			Expect(rest).Should(Equal("www.example"))
+
+			label, rest = SplitTLD("com")
+			Expect(label).Should(Equal("com"))
+			Expect(rest).Should(BeEmpty())
+		})
+	})
+
+	When("the trie is empty", func() {
+		It("should not match anything", func() {
+			Expect(sut.HasParentOf("example.com")).Should(BeFalse())
+			Expect(sut.HasParentOf("")).Should(BeFalse())
+			Expect(sut.Count()).Should(BeZero())
+		})
+
+		It("should ignore empty keys", func() {
+			Expect(sut.Insert("")).Should(BeFalse())
+			Expect(sut.HasParentOf("")).Should(BeFalse())
+		})
+	})
+
+	When("keys were inserted", func() {
+		BeforeEach(func() {
+			Expect(sut.Insert("example.com")).Should(BeTrue())
+			Expect(sut.Insert("ads.example.org")).Should(BeTrue())
+			Expect(sut.Insert("tracker.example.org")).Should(BeTrue())
+		})
+
+		It("should match the keys and their children", func() {
+			Expect(sut.HasParentOf("example.com")).Should(BeTrue())
+			Expect(sut.HasParentOf("www.example.com")).Should(BeTrue())
+			Expect(sut.HasParentOf("a.b.example.com")).Should(BeTrue())
+			Expect(sut.HasParentOf("ads.example.org")).Should(BeTrue())
+			Expect(sut.HasParentOf("x.tracker.example.org")).Should(BeTrue())
+		})
+
+		It("should not match parents or siblings", func() {
+			Expect(sut.HasParentOf("com")).Should(BeFalse())
+			Expect(sut.HasParentOf("example.org")).Should(BeFalse())
+			Expect(sut.HasParentOf("org")).Should(BeFalse())
+			Expect(sut.HasParentOf("myexample.com")).Should(BeFalse())
+			Expect(sut.HasParentOf("other.example.org")).Should(BeFalse())
+			Expect(sut.HasParentOf("ads.example.net")).Should(BeFalse())
+		})
+
+		It("should count the keys", func() {
+			Expect(sut.Count()).Should(Equal(3))
+		})
+
+		It("should not insert covered keys", func() {
+			Expect(sut.Insert("example.com")).Should(BeFalse())
+			Expect(sut.Insert("www.example.com")).Should(BeFalse())
+			Expect(sut.Insert("x.ads.example.org")).Should(BeFalse())
+			Expect(sut.Count()).Should(Equal(3))
+		})
+
+		It("should replace children by a covering key", func() {
+			Expect(sut.Insert("example.org")).Should(BeTrue())
+
+			Expect(sut.HasParentOf("example.org")).Should(BeTrue())
+			Expect(sut.HasParentOf("other.example.org")).Should(BeTrue())
+			Expect(sut.root.parents["org"].terminals).Should(Equal(map[string]string{"example": ""}))
+			Expect(sut.root.parents["org"].parents).Should(BeEmpty())
+		})
+
+		It("should split terminals with a common parent", func() {
+			Expect(sut.Insert("a.b.example.net")).Should(BeTrue())
+			Expect(sut.Insert("c.b.example.net")).Should(BeTrue())
+			Expect(sut.Insert("b.example.net")).Should(BeTrue())
+
+			Expect(sut.HasParentOf("x.b.example.net")).Should(BeTrue())
+			Expect(sut.HasParentOf("example.net")).Should(BeFalse())
+		})
+	})
+})
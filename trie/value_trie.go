@@ -0,0 +1,163 @@
+package trie
+
+import "strings"
+
+const (
+	// WildcardLabel is the first label of a wildcard key: "*.example.com" covers only the keys below "example.com"
+	WildcardLabel = "*"
+	// WildcardPrefix is the prefix of a wildcard key
+	WildcardPrefix = WildcardLabel + "."
+)
+
+// IsWildcard returns true if the key is a wildcard like "*.example.com"
+func IsWildcard(key string) bool {
+	return strings.HasPrefix(key, WildcardPrefix)
+}
+
+// ValueTrie maps keys to values. A key covers itself and all keys below it, a wildcard key only the keys below it.
+// Find returns the value of the most specific key covering the searched key: with SplitTLD the lookup cost only
+// depends on the number of labels of the searched domain, not on the number of entries.
+//
+// Like Trie, it is not safe for concurrent modification, it should be built once and only read afterwards.
+type ValueTrie[V any] struct {
+	split SplitFunc
+	root  valueNode[V]
+	count int
+}
+
+// valueNode is the node of a label, value is the value of the key ending at the label and
+// wildcard the value of the wildcard key below the label
+type valueNode[V any] struct {
+	children map[string]*valueNode[V]
+	value    *V
+	wildcard *V
+}
+
+// NewValueTrie creates an empty trie using split to traverse keys.
+// The wildcard label must be the last label returned by split, which is the case for SplitTLD.
+func NewValueTrie[V any](split SplitFunc) *ValueTrie[V] {
+	return &ValueTrie[V]{split: split}
+}
+
+// Count returns the number of keys with a value
+func (t *ValueTrie[V]) Count() int {
+	return t.count
+}
+
+// Insert sets the value of key, replacing the value of an already inserted equal key.
+// Empty keys are ignored.
+func (t *ValueTrie[V]) Insert(key string, value V) {
+	if len(key) == 0 {
+		return
+	}
+
+	n := &t.root
+
+	for len(key) > 0 {
+		label, rest := t.split(key)
+
+		if label == WildcardLabel && len(rest) == 0 {
+			t.set(&n.wildcard, value)
+
+			return
+		}
+
+		child, found := n.children[label]
+		if !found {
+			child = new(valueNode[V])
+
+			if n.children == nil {
+				n.children = make(map[string]*valueNode[V])
+			}
+
+			n.children[label] = child
+		}
+
+		n = child
+		key = rest
+	}
+
+	t.set(&n.value, value)
+}
+
+// Get returns the value of exactly the key, without looking at the keys covering it
+func (t *ValueTrie[V]) Get(key string) (V, bool) {
+	var zero V
+
+	n := &t.root
+
+	for len(key) > 0 {
+		label, rest := t.split(key)
+
+		if label == WildcardLabel && len(rest) == 0 {
+			if n.wildcard == nil {
+				return zero, false
+			}
+
+			return *n.wildcard, true
+		}
+
+		child, found := n.children[label]
+		if !found {
+			return zero, false
+		}
+
+		n = child
+		key = rest
+	}
+
+	if n.value == nil {
+		return zero, false
+	}
+
+	return *n.value, true
+}
+
+// Find returns the value of the most specific key covering key: the key itself, a wildcard key or a key above it.
+// A wildcard key is more specific than the key of the same parent: for "www.example.com", "*.example.com" is
+// preferred over "example.com".
+func (t *ValueTrie[V]) Find(key string) (V, bool) {
+	var best *V
+
+	n := &t.root
+
+	for {
+		if n.value != nil {
+			best = n.value
+		}
+
+		if len(key) == 0 {
+			break
+		}
+
+		if n.wildcard != nil {
+			best = n.wildcard
+		}
+
+		label, rest := t.split(key)
+
+		child, found := n.children[label]
+		if !found {
+			break
+		}
+
+		n = child
+		key = rest
+	}
+
+	if best == nil {
+		var zero V
+
+		return zero, false
+	}
+
+	return *best, true
+}
+
+func (t *ValueTrie[V]) set(ptr **V, value V) {
+	if *ptr == nil {
+		t.count++
+	}
+
+	*ptr = &value
+}
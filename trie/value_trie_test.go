@@ -0,0 +1,113 @@
+package trie
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValueTrie", func() {
+	var sut *ValueTrie[string]
+
+	BeforeEach(func() {
+		sut = NewValueTrie[string](SplitTLD)
+	})
+
+	// find and get return the value of a key which must exist
+	find := func(key string) string {
+		value, found := sut.Find(key)
+		ExpectWithOffset(1, found).Should(BeTrue())
+
+		return value
+	}
+
+	get := func(key string) string {
+		value, found := sut.Get(key)
+		ExpectWithOffset(1, found).Should(BeTrue())
+
+		return value
+	}
+
+	When("the trie is empty", func() {
+		It("should not find anything", func() {
+			_, found := sut.Find("example.com")
+			Expect(found).Should(BeFalse())
+
+			_, found = sut.Get("")
+			Expect(found).Should(BeFalse())
+
+			Expect(sut.Count()).Should(BeZero())
+		})
+
+		It("should ignore empty keys", func() {
+			sut.Insert("", "empty")
+
+			_, found := sut.Find("")
+			Expect(found).Should(BeFalse())
+			Expect(sut.Count()).Should(BeZero())
+		})
+	})
+
+	When("keys were inserted", func() {
+		BeforeEach(func() {
+			sut.Insert("home", "home")
+			sut.Insert("*.apps.home", "wildcard")
+			sut.Insert("special.apps.home", "special")
+			sut.Insert("example.com", "example")
+			sut.Insert("*.example.com", "example wildcard")
+		})
+
+		It("should count the keys", func() {
+			Expect(sut.Count()).Should(Equal(5))
+
+			sut.Insert("home", "replaced")
+			Expect(sut.Count()).Should(Equal(5))
+			Expect(get("home")).Should(Equal("replaced"))
+		})
+
+		It("should find the most specific key", func() {
+			Expect(find("a.apps.home")).Should(Equal("wildcard"))
+			Expect(find("x.y.apps.home")).Should(Equal("wildcard"))
+			Expect(find("special.apps.home")).Should(Equal("special"))
+			Expect(find("x.special.apps.home")).Should(Equal("special"))
+			Expect(find("nas.home")).Should(Equal("home"))
+		})
+
+		It("should not match the wildcard for its parent", func() {
+			Expect(find("apps.home")).Should(Equal("home"))
+		})
+
+		It("should prefer the wildcard over the key of the same parent", func() {
+			Expect(find("example.com")).Should(Equal("example"))
+			Expect(find("www.example.com")).Should(Equal("example wildcard"))
+		})
+
+		It("should not find other keys", func() {
+			_, found := sut.Find("com")
+			Expect(found).Should(BeFalse())
+
+			_, found = sut.Find("myexample.com")
+			Expect(found).Should(BeFalse())
+		})
+
+		It("should get only the exact key", func() {
+			Expect(get("*.apps.home")).Should(Equal("wildcard"))
+			Expect(get("special.apps.home")).Should(Equal("special"))
+
+			_, found := sut.Get("apps.home")
+			Expect(found).Should(BeFalse())
+
+			_, found = sut.Get("a.apps.home")
+			Expect(found).Should(BeFalse())
+		})
+	})
+})
+
+var _ = DescribeTable("IsWildcard",
+	func(key string, expected bool) {
+		Expect(IsWildcard(key)).Should(Equal(expected))
+	},
+	Entry("wildcard", "*.example.com", true),
+	Entry("domain", "example.com", false),
+	Entry("wildcard label only", "*", false),
+	Entry("wildcard in the middle", "www.*.example.com", false),
+)
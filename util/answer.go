@@ -0,0 +1,179 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/miekg/dns"
+)
+
+const truncationIndicator = "…"
+
+// AnswerFormat configures the rendering of an answer by `FormatAnswer`.
+// The zero value renders all records completely.
+type AnswerFormat struct {
+	// MaxRecords limits the number of rendered records, the others are replaced with "…+N more". 0 means unlimited
+	MaxRecords uint
+	// MaxLength limits the length of a single rendered record, longer records are cut. 0 means unlimited
+	MaxLength uint
+	// OmitPayloads replaces the content of TXT and NULL records with its size
+	OmitPayloads bool
+	// Compact renders the first record of each type only, followed by the number of other records of this type
+	Compact bool
+}
+
+// IsShortened returns true if the format may omit a part of the answer
+func (f AnswerFormat) IsShortened() bool {
+	return f != AnswerFormat{}
+}
+
+// FormatAnswer creates a user-friendly representation of an answer
+func FormatAnswer(answer []dns.RR, format AnswerFormat) string {
+	var records []string
+
+	if format.Compact {
+		records = compactRecords(answer, format)
+	} else {
+		records = make([]string, len(answer))
+
+		for i, record := range answer {
+			records[i] = formatRecord(record, format)
+		}
+	}
+
+	for i, record := range records {
+		records[i] = Obfuscate(truncate(record, format.MaxLength))
+	}
+
+	if format.MaxRecords > 0 && uint(len(records)) > format.MaxRecords {
+		more := uint(len(records)) - format.MaxRecords
+
+		records = append(records[:format.MaxRecords], fmt.Sprintf("%s+%d more", truncationIndicator, more))
+	}
+
+	return strings.Join(records, ", ")
+}
+
+// compactRecords renders the first record of each type, in the order of appearance
+func compactRecords(answer []dns.RR, format AnswerFormat) []string {
+	var (
+		types  []uint16
+		first  = make(map[uint16]dns.RR)
+		counts = make(map[uint16]int)
+	)
+
+	for _, record := range answer {
+		rrType := record.Header().Rrtype
+
+		if _, found := first[rrType]; !found {
+			types = append(types, rrType)
+			first[rrType] = record
+		}
+
+		counts[rrType]++
+	}
+
+	records := make([]string, len(types))
+
+	for i, rrType := range types {
+		value := recordValue(first[rrType], format)
+
+		if counts[rrType] > 1 {
+			value = fmt.Sprintf("%s %s+%d more", value, truncationIndicator, counts[rrType]-1)
+		}
+
+		records[i] = fmt.Sprintf("%s (%s)", dns.Type(rrType), value)
+	}
+
+	return records
+}
+
+func formatRecord(record dns.RR, format AnswerFormat) string {
+	switch record.(type) {
+	case *dns.A:
+		return fmt.Sprintf("A (%s)", recordValue(record, format))
+	case *dns.AAAA:
+		return fmt.Sprintf("AAAA (%s)", recordValue(record, format))
+	case *dns.CNAME:
+		return fmt.Sprintf("CNAME (%s)", recordValue(record, format))
+	case *dns.PTR:
+		return fmt.Sprintf("PTR (%s)", recordValue(record, format))
+	case *dns.TXT:
+		if format.OmitPayloads {
+			return fmt.Sprintf("TXT (%s)", recordValue(record, format))
+		}
+	case *dns.NULL:
+		if format.OmitPayloads {
+			return fmt.Sprintf("NULL (%s)", recordValue(record, format))
+		}
+	}
+
+	return record.String()
+}
+
+// recordValue returns the data of the record without the header
+func recordValue(record dns.RR, format AnswerFormat) string {
+	switch v := record.(type) {
+	case *dns.A:
+		return v.A.String()
+	case *dns.AAAA:
+		return v.AAAA.String()
+	case *dns.CNAME:
+		return ToUnicode(v.Target)
+	case *dns.PTR:
+		return ToUnicode(v.Ptr)
+	case *dns.TXT:
+		if format.OmitPayloads {
+			size := 0
+			for _, txt := range v.Txt {
+				size += len(txt)
+			}
+
+			return fmt.Sprintf("%d bytes", size)
+		}
+	case *dns.NULL:
+		if format.OmitPayloads {
+			return fmt.Sprintf("%d bytes", len(v.Data))
+		}
+	}
+
+	return strings.TrimPrefix(record.String(), record.Header().String())
+}
+
+// truncate cuts the string after maxLength characters, without splitting an escape sequence (\" or \DDD)
+func truncate(in string, maxLength uint) string {
+	if maxLength == 0 || uint(utf8.RuneCountInString(in)) <= maxLength {
+		return in
+	}
+
+	runes := []rune(in)
+	end := int(maxLength)
+
+	for i := 0; i < end; {
+		if runes[i] != '\\' {
+			i++
+
+			continue
+		}
+
+		escapeLength := 2
+		if i+3 < len(runes) && isDigit(runes[i+1]) && isDigit(runes[i+2]) && isDigit(runes[i+3]) {
+			escapeLength = 4
+		}
+
+		if i+escapeLength > end {
+			end = i
+
+			break
+		}
+
+		i += escapeLength
+	}
+
+	return string(runes[:end]) + truncationIndicator
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
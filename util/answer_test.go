@@ -0,0 +1,135 @@
+package util
+
+import (
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	. "github.com/0xERR0R/blocky/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FormatAnswer", func() {
+	newRR := func(s string) dns.RR {
+		rr, err := dns.NewRR(s)
+		Expect(err).Should(Succeed())
+
+		return rr
+	}
+
+	aRecords := func(count int) []dns.RR {
+		result := make([]dns.RR, count)
+
+		for i := range result {
+			result[i] = &dns.A{
+				Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.IPv4(10, 0, 0, byte(i+1)),
+			}
+		}
+
+		return result
+	}
+
+	When("the format is not configured", func() {
+		It("should render all records completely", func() {
+			answer := aRecords(3)
+			answer = append(answer, newRR(`example.com. 300 IN TXT "v=spf1 -all"`))
+
+			Expect(FormatAnswer(answer, AnswerFormat{})).Should(Equal(
+				"A (10.0.0.1), A (10.0.0.2), A (10.0.0.3), example.com.\t300\tIN\tTXT\t\"v=spf1 -all\""))
+			Expect(AnswerFormat{}.IsShortened()).Should(BeFalse())
+		})
+
+		It("should render an empty answer as empty string", func() {
+			Expect(FormatAnswer(nil, AnswerFormat{Compact: true, MaxRecords: 1})).Should(BeEmpty())
+		})
+	})
+
+	When("the number of records is limited", func() {
+		It("should add the number of omitted records", func() {
+			Expect(FormatAnswer(aRecords(14), AnswerFormat{MaxRecords: 2})).
+				Should(Equal("A (10.0.0.1), A (10.0.0.2), …+12 more"))
+		})
+
+		It("should not add an indicator if the limit isn't reached", func() {
+			Expect(FormatAnswer(aRecords(2), AnswerFormat{MaxRecords: 2})).
+				Should(Equal("A (10.0.0.1), A (10.0.0.2)"))
+		})
+	})
+
+	When("the record length is limited", func() {
+		It("should cut long records", func() {
+			answer := []dns.RR{newRR(`example.com. 300 IN TXT "` + strings.Repeat("a", 255) + `"`)}
+
+			result := FormatAnswer(answer, AnswerFormat{MaxLength: 40})
+			Expect(result).Should(HaveSuffix("…"))
+			Expect([]rune(result)).Should(HaveLen(41))
+		})
+
+		It("should not split escaped characters", func() {
+			answer := []dns.RR{newRR(`example.com. 300 IN TXT "ab\"cd\255ef"`)}
+
+			compact := FormatAnswer(answer, AnswerFormat{Compact: true})
+			Expect(compact).Should(Equal(`TXT ("ab\"cd\255ef")`))
+
+			Expect(FormatAnswer(answer, AnswerFormat{MaxLength: 9, Compact: true})).Should(Equal(`TXT ("ab…`))
+			Expect(FormatAnswer(answer, AnswerFormat{MaxLength: 10, Compact: true})).Should(Equal(`TXT ("ab\"…`))
+			Expect(FormatAnswer(answer, AnswerFormat{MaxLength: 15, Compact: true})).Should(Equal(`TXT ("ab\"cd…`))
+			Expect(FormatAnswer(answer, AnswerFormat{MaxLength: 16, Compact: true})).Should(Equal(`TXT ("ab\"cd\255…`))
+		})
+
+		It("should not split multibyte characters", func() {
+			answer := []dns.RR{&dns.CNAME{Target: "xn--mller-kva.de."}}
+
+			Expect(FormatAnswer(answer, AnswerFormat{MaxLength: 9})).Should(Equal("CNAME (mü…"))
+		})
+	})
+
+	When("payloads are omitted", func() {
+		It("should log the size of TXT and NULL records", func() {
+			answer := []dns.RR{
+				newRR(`example.com. 300 IN TXT "hello" "world"`),
+				&dns.NULL{Hdr: dns.RR_Header{Rrtype: dns.TypeNULL}, Data: "\x00\x01\x02"},
+				aRecords(1)[0],
+			}
+
+			Expect(FormatAnswer(answer, AnswerFormat{OmitPayloads: true})).
+				Should(Equal("TXT (10 bytes), NULL (3 bytes), A (10.0.0.1)"))
+		})
+	})
+
+	When("the compact mode is enabled", func() {
+		It("should log the first record of each type", func() {
+			answer := []dns.RR{newRR("example.com. 300 IN CNAME target.example.com.")}
+			answer = append(answer, aRecords(3)...)
+			answer = append(answer, newRR("target.example.com. 300 IN AAAA 2001:db8::1"))
+
+			Expect(FormatAnswer(answer, AnswerFormat{Compact: true})).
+				Should(Equal("CNAME (target.example.com.), A (10.0.0.1 …+2 more), AAAA (2001:db8::1)"))
+		})
+
+		It("should combine with the other options", func() {
+			answer := []dns.RR{newRR(`example.com. 300 IN TXT "hello"`), newRR(`example.com. 300 IN TXT "world"`)}
+			answer = append(answer, aRecords(2)...)
+			answer = append(answer, newRR("example.com. 300 IN MX 10 mail.example.com."))
+
+			Expect(FormatAnswer(answer, AnswerFormat{Compact: true, OmitPayloads: true, MaxRecords: 2})).
+				Should(Equal("TXT (5 bytes …+1 more), A (10.0.0.1 …+1 more), …+1 more"))
+		})
+	})
+
+	When("privacy is enabled", func() {
+		BeforeEach(func() {
+			ConfigureLogger(&Config{Privacy: true})
+			DeferCleanup(ConfigureLogger, &Config{Timestamp: true})
+		})
+
+		It("should obfuscate the records, but not the indicator", func() {
+			answer := []dns.RR{&dns.CNAME{Target: "target."}, &dns.CNAME{Target: "other."}}
+
+			Expect(FormatAnswer(answer, AnswerFormat{MaxRecords: 1})).Should(Equal("***** (******.), …+1 more"))
+		})
+	})
+})
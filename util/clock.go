@@ -0,0 +1,102 @@
+package util
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock is a source of time. Elapsed time must be measured with the monotonic clock,
+// since the wall clock can jump, e.g. if the system clock is stepped by NTP at boot
+type Clock interface {
+	// Now returns the current wall clock time
+	Now() time.Time
+
+	// Monotonic returns the elapsed time since an arbitrary, fixed point in the past.
+	// It is not affected by changes of the wall clock
+	Monotonic() time.Duration
+}
+
+type systemClock struct {
+	start time.Time
+}
+
+func (c systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c systemClock) Monotonic() time.Duration {
+	// time.Since uses the monotonic clock reading of start
+	return time.Since(c.start)
+}
+
+type clockHolder struct {
+	Clock
+}
+
+//nolint:gochecknoglobals
+var (
+	// SystemClock is the clock of the operating system.
+	// It starts shortly before process start, so `MonotonicNow` never returns the zero value
+	SystemClock Clock = systemClock{start: time.Now().Add(-time.Nanosecond)}
+
+	clock atomic.Pointer[clockHolder]
+)
+
+func init() {
+	clock.Store(&clockHolder{SystemClock})
+}
+
+// SetClock replaces the clock used by `Now` and `MonotonicNow`, should only be used in tests.
+// Returns a function to restore the previous clock
+func SetClock(c Clock) (restore func()) {
+	previous := clock.Swap(&clockHolder{c})
+
+	return func() {
+		clock.Store(previous)
+	}
+}
+
+// Now returns the current wall clock time
+func Now() time.Time {
+	return clock.Load().Now()
+}
+
+// MonotonicTime is a point in time of the monotonic clock. Unlike time.Time it has no wall clock reading,
+// so it can't be affected by wall clock jumps, even after a round trip through an integer.
+// The zero value is before all points in time returned by `MonotonicNow`
+type MonotonicTime time.Duration
+
+// MonotonicNow returns the current point in time of the monotonic clock
+func MonotonicNow() MonotonicTime {
+	return MonotonicTime(clock.Load().Monotonic())
+}
+
+// MonotonicSince returns the elapsed time since t
+func MonotonicSince(t MonotonicTime) time.Duration {
+	return MonotonicNow().Sub(t)
+}
+
+// MonotonicUntil returns the duration until t, negative if t is in the past
+func MonotonicUntil(t MonotonicTime) time.Duration {
+	return t.Sub(MonotonicNow())
+}
+
+// IsZero returns true for the zero value, which can be used as "never"
+func (t MonotonicTime) IsZero() bool {
+	return t == 0
+}
+
+// Add returns the point in time t+d
+func (t MonotonicTime) Add(d time.Duration) MonotonicTime {
+	return t + MonotonicTime(d)
+}
+
+// Sub returns the duration t-u
+func (t MonotonicTime) Sub(u MonotonicTime) time.Duration {
+	return time.Duration(t - u)
+}
+
+// After returns true if t is after u
+func (t MonotonicTime) After(u MonotonicTime) bool {
+	return t > u
+}
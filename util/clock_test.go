@@ -0,0 +1,57 @@
+package util
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Clock", func() {
+	var clock *FakeClock
+
+	BeforeEach(func() {
+		clock = NewFakeClock()
+		DeferCleanup(SetClock(clock))
+	})
+
+	It("should never return the zero monotonic time", func() {
+		Expect(MonotonicNow().IsZero()).Should(BeFalse())
+	})
+
+	When("the wall clock jumps", func() {
+		It("should not affect the monotonic clock", func() {
+			start := MonotonicNow()
+			wallStart := Now()
+
+			clock.JumpWallClock(5 * time.Hour)
+
+			Expect(Now().Sub(wallStart)).Should(BeNumerically(">=", 5*time.Hour))
+			Expect(MonotonicSince(start)).Should(BeNumerically("<", time.Minute))
+		})
+	})
+
+	When("time elapses", func() {
+		It("should advance the monotonic clock", func() {
+			deadline := MonotonicNow().Add(time.Hour)
+
+			Expect(MonotonicUntil(deadline)).Should(BeNumerically("~", time.Hour, time.Minute))
+
+			clock.Advance(2 * time.Hour)
+
+			Expect(MonotonicNow().After(deadline)).Should(BeTrue())
+			Expect(MonotonicUntil(deadline)).Should(BeNumerically("~", -time.Hour, time.Minute))
+		})
+	})
+
+	It("should restore the previous clock", func() {
+		restore := SetClock(SystemClock)
+		clock.Advance(time.Hour)
+
+		Expect(MonotonicNow()).Should(BeNumerically("<", SystemClock.Monotonic()+time.Minute))
+
+		restore()
+
+		Expect(MonotonicNow()).Should(BeNumerically(">", SystemClock.Monotonic()+time.Minute))
+	})
+})
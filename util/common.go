@@ -9,7 +9,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
 
 	"github.com/miekg/dns"
@@ -20,7 +19,7 @@ var alphanumeric = regexp.MustCompile("[a-zA-Z0-9]")
 
 // Obfuscate replaces all alphanumeric characters with * to obfuscate user sensitive data if LogPrivacy is enabled
 func Obfuscate(in string) string {
-	if config.GetConfig().Log.Privacy {
+	if log.IsPrivacyEnabled() {
 		return alphanumeric.ReplaceAllString(in, "*")
 	}
 
@@ -29,31 +28,14 @@ func Obfuscate(in string) string {
 
 // AnswerToString creates a user-friendly representation of an answer
 func AnswerToString(answer []dns.RR) string {
-	answers := make([]string, len(answer))
-
-	for i, record := range answer {
-		switch v := record.(type) {
-		case *dns.A:
-			answers[i] = fmt.Sprintf("A (%s)", v.A)
-		case *dns.AAAA:
-			answers[i] = fmt.Sprintf("AAAA (%s)", v.AAAA)
-		case *dns.CNAME:
-			answers[i] = fmt.Sprintf("CNAME (%s)", v.Target)
-		case *dns.PTR:
-			answers[i] = fmt.Sprintf("PTR (%s)", v.Ptr)
-		default:
-			answers[i] = fmt.Sprint(record.String())
-		}
-	}
-
-	return Obfuscate(strings.Join(answers, ", "))
+	return FormatAnswer(answer, AnswerFormat{})
 }
 
 // QuestionToString creates a user-friendly representation of a question
 func QuestionToString(questions []dns.Question) string {
 	result := make([]string, len(questions))
 	for i, question := range questions {
-		result[i] = fmt.Sprintf("%s (%s)", dns.TypeToString[question.Qtype], question.Name)
+		result[i] = fmt.Sprintf("%s (%s)", dns.TypeToString[question.Qtype], ToUnicode(question.Name))
 	}
 
 	return Obfuscate(strings.Join(result, ", "))
@@ -99,6 +81,11 @@ func ExtractDomainOnly(in string) string {
 	return strings.TrimSuffix(strings.ToLower(in), ".")
 }
 
+// NormalizeDomain returns the domain in lower case without surrounding white space and trailing dot
+func NormalizeDomain(domain string) string {
+	return ExtractDomainOnly(strings.TrimSpace(domain))
+}
+
 // NewMsgWithQuestion creates new DNS message with question
 func NewMsgWithQuestion(question string, qType dns.Type) *dns.Msg {
 	msg := new(dns.Msg)
@@ -163,6 +150,10 @@ func FatalOnError(message string, err error) {
 	}
 }
 
+// cacheKeySubnetSeparator separates the client subnet from the domain in a cache key.
+// It can't be part of a domain name in presentation format.
+const cacheKeySubnetSeparator = "\x00"
+
 // GenerateCacheKey return cacheKey by query type/domain
 func GenerateCacheKey(qType dns.Type, qName string) string {
 	const qTypeLength = 2
@@ -174,13 +165,38 @@ func GenerateCacheKey(qType dns.Type, qName string) string {
 	return string(b)
 }
 
+// GenerateCacheKeyWithSubnet return cacheKey by query type/domain/client subnet,
+// without subnet the key is the same as from GenerateCacheKey
+func GenerateCacheKeyWithSubnet(qType dns.Type, qName string, subnet *net.IPNet) string {
+	key := GenerateCacheKey(qType, qName)
+
+	if subnet == nil {
+		return key
+	}
+
+	return key + cacheKeySubnetSeparator + subnet.String()
+}
+
 // ExtractCacheKey return query type/domain from cacheKey
 func ExtractCacheKey(key string) (qType dns.Type, qName string) {
+	qType, qName, _ = ExtractCacheKeyWithSubnet(key)
+
+	return
+}
+
+// ExtractCacheKeyWithSubnet return query type/domain/client subnet from cacheKey,
+// subnet is nil if the key has no client subnet
+func ExtractCacheKeyWithSubnet(key string) (qType dns.Type, qName string, subnet *net.IPNet) {
 	b := []byte(key)
 
 	qType = dns.Type(binary.BigEndian.Uint16(b))
 	qName = string(b[2:])
 
+	if name, cidr, found := strings.Cut(qName, cacheKeySubnetSeparator); found {
+		qName = name
+		_, subnet, _ = net.ParseCIDR(cidr)
+	}
+
 	return
 }
 
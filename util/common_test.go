@@ -32,6 +32,25 @@ var _ = Describe("Common function tests", func() {
 		})
 	})
 
+	Describe("Obfuscate", func() {
+		When("privacy is disabled", func() {
+			It("should return the input", func() {
+				Expect(Obfuscate("example.com")).Should(Equal("example.com"))
+			})
+		})
+
+		When("privacy is enabled", func() {
+			BeforeEach(func() {
+				ConfigureLogger(&Config{Privacy: true})
+				DeferCleanup(ConfigureLogger, &Config{Timestamp: true})
+			})
+
+			It("should replace all alphanumeric characters", func() {
+				Expect(Obfuscate("example.com")).Should(Equal("*******.***"))
+			})
+		})
+	})
+
 	Describe("print question", func() {
 		When("question is provided", func() {
 			question := dns.Question{
@@ -44,6 +63,17 @@ var _ = Describe("Common function tests", func() {
 				Expect(questionToString).Should(Equal("A (google.de)"))
 			})
 		})
+		When("question contains an IDN", func() {
+			question := dns.Question{
+				Name:   "xn--mller-kva.de",
+				Qtype:  dns.TypeA,
+				Qclass: dns.ClassINET,
+			}
+			It("should print the unicode form", func() {
+				questionToString := QuestionToString([]dns.Question{question})
+				Expect(questionToString).Should(Equal("A (müller.de)"))
+			})
+		})
 	})
 
 	Describe("Extract domain from query", func() {
@@ -60,6 +90,13 @@ var _ = Describe("Common function tests", func() {
 		})
 	})
 
+	Describe("NormalizeDomain", func() {
+		It("should return the domain in lower case without white space and trailing dot", func() {
+			Expect(NormalizeDomain(" WWW.Example.com. ")).Should(Equal("www.example.com"))
+			Expect(NormalizeDomain("example.com")).Should(Equal("example.com"))
+		})
+	})
+
 	Describe("Create new DNS message", func() {
 		When("Question is provided", func() {
 			question := "google.com."
@@ -200,6 +237,27 @@ var _ = Describe("Common function tests", func() {
 			Expect(qType).Should(Equal(dns.Type(dns.TypeA)))
 			Expect(qName).Should(Equal("example.com"))
 		})
+
+		It("should include the client subnet", func() {
+			_, subnet, _ := net.ParseCIDR("10.1.2.0/24")
+
+			cacheKey := GenerateCacheKeyWithSubnet(dns.Type(dns.TypeA), "example.com", subnet)
+			Expect(cacheKey).ShouldNot(Equal(GenerateCacheKey(dns.Type(dns.TypeA), "example.com")))
+
+			qType, qName, keySubnet := ExtractCacheKeyWithSubnet(cacheKey)
+			Expect(qType).Should(Equal(dns.Type(dns.TypeA)))
+			Expect(qName).Should(Equal("example.com"))
+			Expect(keySubnet).Should(Equal(subnet))
+
+			qType, qName = ExtractCacheKey(cacheKey)
+			Expect(qType).Should(Equal(dns.Type(dns.TypeA)))
+			Expect(qName).Should(Equal("example.com"))
+		})
+
+		It("should be equal to the key without subnet if no subnet is given", func() {
+			Expect(GenerateCacheKeyWithSubnet(dns.Type(dns.TypeA), "example.com", nil)).
+				Should(Equal(GenerateCacheKey(dns.Type(dns.TypeA), "example.com")))
+		})
 	})
 
 	Describe("CIDR contains IP", func() {
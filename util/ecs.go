@@ -0,0 +1,95 @@
+package util
+
+import (
+	"net"
+	"slices"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	ecsFamilyIPv4 = 1
+	ecsFamilyIPv6 = 2
+
+	ecsIPv4Bits = 32
+	ecsIPv6Bits = 128
+)
+
+// ClientSubnet returns the source prefix of the EDNS Client Subnet option (RFC 7871) of the message.
+// Returns nil if the message has no ECS option or the source prefix length is 0 (client opted out).
+func ClientSubnet(msg *dns.Msg) *net.IPNet {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+
+	for _, o := range opt.Option {
+		subnet, ok := o.(*dns.EDNS0_SUBNET)
+		if !ok || subnet.SourceNetmask == 0 {
+			continue
+		}
+
+		bits := ecsIPv4Bits
+		if subnet.Family == ecsFamilyIPv6 {
+			bits = ecsIPv6Bits
+		}
+
+		if int(subnet.SourceNetmask) > bits {
+			return nil
+		}
+
+		mask := net.CIDRMask(int(subnet.SourceNetmask), bits)
+
+		return &net.IPNet{IP: subnet.Address.Mask(mask), Mask: mask}
+	}
+
+	return nil
+}
+
+// SetClientSubnet adds an EDNS Client Subnet option with the subnet as source prefix to the message
+func SetClientSubnet(msg *dns.Msg, subnet *net.IPNet) {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		opt = msg.SetEdns0(dns.DefaultMsgSize, false).IsEdns0()
+	}
+
+	ones, bits := subnet.Mask.Size()
+
+	family := uint16(ecsFamilyIPv4)
+	if bits == ecsIPv6Bits {
+		family = ecsFamilyIPv6
+	}
+
+	opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        family,
+		SourceNetmask: uint8(ones),
+		Address:       subnet.IP,
+	})
+}
+
+// RemoveClientSubnet removes the EDNS Client Subnet options from the message.
+// Returns the first removed option, nil if the message has no ECS option.
+func RemoveClientSubnet(msg *dns.Msg) *dns.EDNS0_SUBNET {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+
+	var removed *dns.EDNS0_SUBNET
+
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		subnet, ok := o.(*dns.EDNS0_SUBNET)
+		if !ok {
+			return false
+		}
+
+		if removed == nil {
+			removed = subnet
+		}
+
+		return true
+	})
+
+	return removed
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EDNS client subnet", func() {
+	var msg *dns.Msg
+
+	BeforeEach(func() {
+		msg = NewMsgWithQuestion("example.com.", dns.Type(dns.TypeA))
+	})
+
+	Describe("ClientSubnet", func() {
+		It("should return nil without EDNS", func() {
+			Expect(ClientSubnet(msg)).Should(BeNil())
+		})
+
+		It("should return nil without ECS option", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+
+			Expect(ClientSubnet(msg)).Should(BeNil())
+		})
+
+		It("should return nil if the source prefix length is 0", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			msg.IsEdns0().Option = append(msg.IsEdns0().Option, &dns.EDNS0_SUBNET{
+				Code:    dns.EDNS0SUBNET,
+				Family:  1,
+				Address: net.ParseIP("10.1.2.3"),
+			})
+
+			Expect(ClientSubnet(msg)).Should(BeNil())
+		})
+
+		It("should return the masked source prefix", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			msg.IsEdns0().Option = append(msg.IsEdns0().Option, &dns.EDNS0_SUBNET{
+				Code:          dns.EDNS0SUBNET,
+				Family:        1,
+				SourceNetmask: 24,
+				Address:       net.ParseIP("10.1.2.3").To4(),
+			})
+
+			Expect(ClientSubnet(msg).String()).Should(Equal("10.1.2.0/24"))
+		})
+	})
+
+	Describe("SetClientSubnet", func() {
+		It("should add an IPv4 subnet", func() {
+			_, subnet, _ := net.ParseCIDR("10.1.2.0/24")
+
+			SetClientSubnet(msg, subnet)
+
+			Expect(ClientSubnet(msg).String()).Should(Equal("10.1.2.0/24"))
+		})
+
+		It("should add an IPv6 subnet", func() {
+			_, subnet, _ := net.ParseCIDR("2001:db8::/56")
+
+			SetClientSubnet(msg, subnet)
+
+			Expect(msg.IsEdns0().Option[0].(*dns.EDNS0_SUBNET).Family).Should(BeEquivalentTo(2))
+			Expect(ClientSubnet(msg).String()).Should(Equal("2001:db8::/56"))
+		})
+	})
+
+	Describe("RemoveClientSubnet", func() {
+		It("should return nil without EDNS", func() {
+			Expect(RemoveClientSubnet(msg)).Should(BeNil())
+		})
+
+		It("should remove the ECS option and keep the others", func() {
+			_, subnet, _ := net.ParseCIDR("10.1.2.0/24")
+
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			msg.IsEdns0().Option = append(msg.IsEdns0().Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID})
+			SetClientSubnet(msg, subnet)
+
+			removed := RemoveClientSubnet(msg)
+			Expect(removed).ShouldNot(BeNil())
+			Expect(removed.SourceNetmask).Should(BeEquivalentTo(24))
+
+			Expect(ClientSubnet(msg)).Should(BeNil())
+			Expect(msg.IsEdns0().Option).Should(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"slices"
+
+	"github.com/miekg/dns"
+)
+
+// RemoveEdns0LocalOption removes the local EDNS0 option (RFC 6891, codes 65001-65534) with the code from the message.
+// Returns the data of the option, nil if the message has no such option.
+func RemoveEdns0LocalOption(msg *dns.Msg, code uint16) []byte {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+
+	var data []byte
+
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		local, ok := o.(*dns.EDNS0_LOCAL)
+		if !ok || local.Code != code {
+			return false
+		}
+
+		if data == nil {
+			data = local.Data
+		}
+
+		return true
+	})
+
+	return data
+}
+
+// QueryPaddingBlockSize is the block length for padded queries recommended by RFC 8467
+const QueryPaddingBlockSize = 128
+
+// edns0PaddingOverhead is the length of the code and length fields of the padding option
+const edns0PaddingOverhead = 4
+
+// PadQuery returns a copy of the query with an EDNS0 padding option (RFC 7830), which pads its length to a
+// multiple of blockSize. An existing padding option is replaced, EDNS is added to queries without it.
+func PadQuery(msg *dns.Msg, blockSize int) *dns.Msg {
+	padded := msg.Copy()
+
+	RemoveEdns0Padding(padded)
+
+	opt := padded.IsEdns0()
+	if opt == nil {
+		padded.SetEdns0(dns.DefaultMsgSize, false)
+		opt = padded.IsEdns0()
+	}
+
+	length := padded.Len() + edns0PaddingOverhead
+	paddingLen := (blockSize - length%blockSize) % blockSize
+
+	opt.Option = append(opt.Option, &dns.EDNS0_PADDING{Padding: make([]byte, paddingLen)})
+
+	return padded
+}
+
+// RemoveEdns0Padding removes all EDNS0 padding options (RFC 7830) from the message
+func RemoveEdns0Padding(msg *dns.Msg) {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return
+	}
+
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		return o.Option() == dns.EDNS0PADDING
+	})
+}
+
+// Edns0Cookie returns the DNS cookie option (RFC 7873) of the message, nil if it has none
+func Edns0Cookie(msg *dns.Msg) *dns.EDNS0_COOKIE {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+
+	for _, o := range opt.Option {
+		if cookie, ok := o.(*dns.EDNS0_COOKIE); ok {
+			return cookie
+		}
+	}
+
+	return nil
+}
+
+// RemoveEdns0Cookie removes all DNS cookie options (RFC 7873) from the message and returns the first one
+func RemoveEdns0Cookie(msg *dns.Msg) *dns.EDNS0_COOKIE {
+	cookie := Edns0Cookie(msg)
+	if cookie == nil {
+		return nil
+	}
+
+	opt := msg.IsEdns0()
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		return o.Option() == dns.EDNS0COOKIE
+	})
+
+	return cookie
+}
+
+// SetEdns0Cookie replaces the DNS cookie option (RFC 7873) of the message with the cookie in hex presentation.
+// EDNS is added to messages without it
+func SetEdns0Cookie(msg *dns.Msg, cookie string) {
+	RemoveEdns0Cookie(msg)
+
+	opt := msg.IsEdns0()
+	if opt == nil {
+		opt = msg.SetEdns0(dns.DefaultMsgSize, false).IsEdns0()
+	}
+
+	opt.Option = append(opt.Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+}
+
+// SetEdns0Do sets the DNSSEC OK bit (RFC 3225) of the message, EDNS is added to messages without it
+func SetEdns0Do(msg *dns.Msg) {
+	if opt := msg.IsEdns0(); opt != nil {
+		opt.SetDo()
+
+		return
+	}
+
+	msg.SetEdns0(dns.DefaultMsgSize, true)
+}
@@ -0,0 +1,166 @@
+package util
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EDNS0 local option", func() {
+	var msg *dns.Msg
+
+	BeforeEach(func() {
+		msg = NewMsgWithQuestion("example.com.", dns.Type(dns.TypeA))
+	})
+
+	Describe("RemoveEdns0LocalOption", func() {
+		It("should return nil without EDNS", func() {
+			Expect(RemoveEdns0LocalOption(msg, 65001)).Should(BeNil())
+		})
+
+		It("should return nil if the option is missing", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			opt := msg.IsEdns0()
+			opt.Option = append(opt.Option, &dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")})
+
+			Expect(RemoveEdns0LocalOption(msg, 65001)).Should(BeNil())
+			Expect(opt.Option).Should(HaveLen(1))
+		})
+
+		It("should return the data and remove all options with the code", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			opt := msg.IsEdns0()
+			opt.Option = append(opt.Option,
+				&dns.EDNS0_LOCAL{Code: 65001, Data: []byte("first")},
+				&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")},
+				&dns.EDNS0_LOCAL{Code: 65001, Data: []byte("second")},
+			)
+
+			Expect(RemoveEdns0LocalOption(msg, 65001)).Should(Equal([]byte("first")))
+			Expect(opt.Option).Should(Equal([]dns.EDNS0{&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")}}))
+		})
+	})
+
+	Describe("PadQuery", func() {
+		packedLen := func(msg *dns.Msg) int {
+			data, err := msg.Pack()
+			Expect(err).Should(Succeed())
+
+			return len(data)
+		}
+
+		It("should pad a query without EDNS to the block size", func() {
+			padded := PadQuery(msg, QueryPaddingBlockSize)
+
+			Expect(packedLen(padded)).Should(Equal(QueryPaddingBlockSize))
+			Expect(padded.IsEdns0()).ShouldNot(BeNil())
+			Expect(msg.IsEdns0()).Should(BeNil(), "the original query must not be modified")
+		})
+
+		It("should pad to the next multiple of the block size", func() {
+			msg = NewMsgWithQuestion(strings.Repeat("a", 63)+"."+strings.Repeat("b", 63)+".example.com.", dns.Type(dns.TypeA))
+			msg.SetEdns0(dns.DefaultMsgSize, true)
+
+			padded := PadQuery(msg, QueryPaddingBlockSize)
+
+			Expect(packedLen(padded)).Should(Equal(2 * QueryPaddingBlockSize))
+			Expect(padded.IsEdns0().Do()).Should(BeTrue())
+		})
+
+		It("should replace an existing padding option", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			msg.IsEdns0().Option = append(msg.IsEdns0().Option, &dns.EDNS0_PADDING{Padding: make([]byte, 300)})
+
+			padded := PadQuery(msg, QueryPaddingBlockSize)
+
+			Expect(packedLen(padded)).Should(Equal(QueryPaddingBlockSize))
+			Expect(padded.IsEdns0().Option).Should(HaveLen(1))
+		})
+	})
+
+	Describe("RemoveEdns0Padding", func() {
+		It("should do nothing without EDNS", func() {
+			RemoveEdns0Padding(msg)
+
+			Expect(msg.IsEdns0()).Should(BeNil())
+		})
+
+		It("should remove the padding and keep other options", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			opt := msg.IsEdns0()
+			opt.Option = append(opt.Option,
+				&dns.EDNS0_PADDING{Padding: make([]byte, 10)},
+				&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")},
+			)
+
+			RemoveEdns0Padding(msg)
+
+			Expect(opt.Option).Should(Equal([]dns.EDNS0{&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")}}))
+		})
+	})
+
+	Describe("DNS cookies", func() {
+		It("should return nil without EDNS", func() {
+			Expect(Edns0Cookie(msg)).Should(BeNil())
+			Expect(RemoveEdns0Cookie(msg)).Should(BeNil())
+		})
+
+		It("should add EDNS for the cookie", func() {
+			SetEdns0Cookie(msg, "0102030405060708")
+
+			Expect(msg.IsEdns0()).ShouldNot(BeNil())
+			Expect(Edns0Cookie(msg).Cookie).Should(Equal("0102030405060708"))
+		})
+
+		It("should replace the cookie and keep other options", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			opt := msg.IsEdns0()
+			opt.Option = append(opt.Option,
+				&dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"},
+				&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")},
+			)
+
+			SetEdns0Cookie(msg, "1112131415161718")
+
+			Expect(opt.Option).Should(HaveLen(2))
+			Expect(Edns0Cookie(msg).Cookie).Should(Equal("1112131415161718"))
+		})
+
+		It("should remove the cookies and return the first one", func() {
+			msg.SetEdns0(dns.DefaultMsgSize, false)
+			opt := msg.IsEdns0()
+			opt.Option = append(opt.Option,
+				&dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"},
+				&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")},
+				&dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "1112131415161718"},
+			)
+
+			Expect(RemoveEdns0Cookie(msg).Cookie).Should(Equal("0102030405060708"))
+			Expect(opt.Option).Should(Equal([]dns.EDNS0{&dns.EDNS0_LOCAL{Code: 65002, Data: []byte("other")}}))
+		})
+	})
+
+	Describe("SetEdns0Do", func() {
+		It("should add EDNS with the DO bit", func() {
+			SetEdns0Do(msg)
+
+			Expect(msg.IsEdns0()).ShouldNot(BeNil())
+			Expect(msg.IsEdns0().Do()).Should(BeTrue())
+			Expect(msg.IsEdns0().UDPSize()).Should(BeEquivalentTo(dns.DefaultMsgSize))
+		})
+
+		It("should keep the existing EDNS record", func() {
+			msg.SetEdns0(1232, false)
+			opt := msg.IsEdns0()
+
+			SetEdns0Do(msg)
+
+			Expect(msg.IsEdns0()).Should(BeIdenticalTo(opt))
+			Expect(opt.Do()).Should(BeTrue())
+			Expect(opt.UDPSize()).Should(BeEquivalentTo(1232))
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// FakeClock is a clock for tests, which can simulate jumps of the wall clock (e.g. an NTP step)
+// and the elapse of time without waiting. Install it with `DeferCleanup(util.SetClock(clock))` in ginkgo tests
+type FakeClock struct {
+	wallOffset      atomic.Int64
+	monotonicOffset atomic.Int64
+}
+
+// NewFakeClock creates a clock, which runs like the system clock until it is changed
+func NewFakeClock() *FakeClock {
+	return &FakeClock{}
+}
+
+// Now returns the wall clock time, without monotonic clock reading
+func (c *FakeClock) Now() time.Time {
+	return SystemClock.Now().Add(time.Duration(c.wallOffset.Load())).Round(0)
+}
+
+// Monotonic returns the elapsed time of the monotonic clock
+func (c *FakeClock) Monotonic() time.Duration {
+	return SystemClock.Monotonic() + time.Duration(c.monotonicOffset.Load())
+}
+
+// JumpWallClock changes the wall clock by d, the monotonic clock is not affected
+func (c *FakeClock) JumpWallClock(d time.Duration) {
+	c.wallOffset.Add(int64(d))
+}
+
+// Advance lets the time d elapse on both, wall and monotonic clock.
+// Timers of the time package are not affected
+func (c *FakeClock) Advance(d time.Duration) {
+	c.wallOffset.Add(int64(d))
+	c.monotonicOffset.Add(int64(d))
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const acePrefix = "xn--"
+
+// ToUnicode converts a domain name with punycode labels (IDN) to its unicode form for display purposes.
+// Labels which are not valid punycode or don't convert back to the same ASCII form are rejected,
+// in this case the raw name is returned.
+// The result must not be used for matching or caching, which operate on the punycode form.
+func ToUnicode(name string) string {
+	if !strings.Contains(strings.ToLower(name), acePrefix) {
+		return name
+	}
+
+	labels := strings.Split(name, ".")
+
+	for i, label := range labels {
+		if !strings.HasPrefix(strings.ToLower(label), acePrefix) {
+			continue
+		}
+
+		unicode, err := idna.Lookup.ToUnicode(label)
+		if err != nil {
+			return name
+		}
+
+		// reject labels which are not in canonical form, for example "xn--zz-" (would be displayed as "zz")
+		ascii, err := idna.Lookup.ToASCII(unicode)
+		if err != nil || !strings.EqualFold(ascii, label) {
+			return name
+		}
+
+		labels[i] = unicode
+	}
+
+	return strings.Join(labels, ".")
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ToUnicode", func() {
+	It("converts punycode labels to unicode", func() {
+		Expect(ToUnicode("xn--mller-kva.example.")).Should(Equal("müller.example."))
+		Expect(ToUnicode("www.XN--MLLER-KVA.example")).Should(Equal("www.müller.example"))
+		Expect(ToUnicode("_dmarc.xn--mller-kva.example.")).Should(Equal("_dmarc.müller.example."))
+	})
+
+	It("returns ASCII names unchanged", func() {
+		Expect(ToUnicode("example.com.")).Should(Equal("example.com."))
+		Expect(ToUnicode("")).Should(Equal(""))
+	})
+
+	It("returns the raw name for invalid punycode", func() {
+		Expect(ToUnicode("xn--a.example.")).Should(Equal("xn--a.example."))
+		Expect(ToUnicode("xn--zz-.example.")).Should(Equal("xn--zz-.example."))
+		Expect(ToUnicode("xn--.example.")).Should(Equal("xn--.example."))
+		Expect(ToUnicode("sub.xn--mller-kva.xn--a.example")).Should(Equal("sub.xn--mller-kva.xn--a.example"))
+	})
+})
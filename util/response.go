@@ -0,0 +1,145 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	// ErrResponseMismatch is returned if a response doesn't belong to the query
+	ErrResponseMismatch = errors.New("response doesn't match the query")
+
+	// ErrCNAMELoop is returned if the CNAME chain of an answer points back to one of its names
+	ErrCNAMELoop = errors.New("CNAME loop")
+
+	// ErrCNAMEChainTooLong is returned if the CNAME chain of an answer exceeds the max length
+	ErrCNAMEChainTooLong = errors.New("CNAME chain too long")
+)
+
+// ValidateResponse checks that the response answers the query: the ID and question must be the same and
+// the answer may only contain records of the queried name, of the targets of its CNAME chain and DNAMEs of its parents
+func ValidateResponse(query, response *dns.Msg) error {
+	if response.Id != query.Id {
+		return fmt.Errorf("%w: ID %d, expected %d", ErrResponseMismatch, response.Id, query.Id)
+	}
+
+	if len(response.Question) == 0 && !isCacheableRcode(response.Rcode) {
+		// error responses often don't repeat the question
+		return nil
+	}
+
+	if !QuestionsMatch(query.Question, response.Question) {
+		return fmt.Errorf("%w: question %s, expected %s",
+			ErrResponseMismatch, QuestionToString(response.Question), QuestionToString(query.Question))
+	}
+
+	if len(query.Question) == 0 {
+		return nil
+	}
+
+	if rr := outOfBailiwick(query.Question[0].Name, response.Answer); rr != nil {
+		return fmt.Errorf("%w: answer for '%s' is out of bailiwick", ErrResponseMismatch, rr.Header().Name)
+	}
+
+	return nil
+}
+
+// QuestionsMatch returns true if both question sections ask for the same records, names are compared case-insensitive
+func QuestionsMatch(a, b []dns.Question) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Qtype != b[i].Qtype || a[i].Qclass != b[i].Qclass || !strings.EqualFold(a[i].Name, b[i].Name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isCacheableRcode(rcode int) bool {
+	return rcode == dns.RcodeSuccess || rcode == dns.RcodeNameError
+}
+
+// outOfBailiwick returns the first answer record which belongs neither to the queried name nor to its CNAME chain
+func outOfBailiwick(qName string, answer []dns.RR) dns.RR {
+	names := map[string]struct{}{strings.ToLower(qName): {}}
+
+	// the records of a CNAME chain aren't necessarily ordered
+	for changed := true; changed; {
+		changed = false
+
+		for _, rr := range answer {
+			cname, ok := rr.(*dns.CNAME)
+			if !ok {
+				continue
+			}
+
+			target := strings.ToLower(cname.Target)
+
+			if _, ok := names[strings.ToLower(cname.Hdr.Name)]; !ok {
+				continue
+			}
+
+			if _, ok := names[target]; !ok {
+				names[target] = struct{}{}
+				changed = true
+			}
+		}
+	}
+
+	for _, rr := range answer {
+		if _, ok := names[strings.ToLower(rr.Header().Name)]; ok {
+			continue
+		}
+
+		if _, ok := rr.(*dns.DNAME); ok && dns.IsSubDomain(rr.Header().Name, qName) {
+			continue
+		}
+
+		return rr
+	}
+
+	return nil
+}
+
+// CheckCNAMEChain follows the CNAME chain of the queried name through the answer and returns an error
+// if it contains a loop or is longer than maxLength. 0 disables the length check
+func CheckCNAMEChain(qName string, answer []dns.RR, maxLength uint) error {
+	targets := make(map[string]string)
+
+	for _, rr := range answer {
+		if cname, ok := rr.(*dns.CNAME); ok {
+			targets[strings.ToLower(cname.Hdr.Name)] = strings.ToLower(cname.Target)
+		}
+	}
+
+	name := strings.ToLower(qName)
+	seen := map[string]struct{}{name: {}}
+
+	var length uint
+
+	for {
+		target, ok := targets[name]
+		if !ok {
+			return nil
+		}
+
+		if _, ok := seen[target]; ok {
+			return fmt.Errorf("%w: '%s' points back to '%s'", ErrCNAMELoop, name, target)
+		}
+
+		length++
+		if maxLength > 0 && length > maxLength {
+			return fmt.Errorf("%w: '%s' has more than %d CNAMEs", ErrCNAMEChainTooLong, qName, maxLength)
+		}
+
+		seen[target] = struct{}{}
+		name = target
+	}
+}
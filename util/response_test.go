@@ -0,0 +1,166 @@
+package util
+
+import (
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response validation", func() {
+	var query, response *dns.Msg
+
+	BeforeEach(func() {
+		query = NewMsgWithQuestion("www.example.com.", dns.Type(dns.TypeA))
+
+		response = new(dns.Msg)
+		response.SetReply(query)
+	})
+
+	withAnswer := func(records ...string) {
+		for _, record := range records {
+			rr, err := dns.NewRR(record)
+			Expect(err).Should(Succeed())
+
+			response.Answer = append(response.Answer, rr)
+		}
+	}
+
+	Describe("ValidateResponse", func() {
+		It("should accept a matching response", func() {
+			withAnswer("www.example.com. 300 IN A 1.2.3.4")
+
+			Expect(ValidateResponse(query, response)).Should(Succeed())
+		})
+
+		It("should compare names case-insensitive", func() {
+			response.Question[0].Name = "WWW.Example.COM."
+			withAnswer("WWW.example.com. 300 IN A 1.2.3.4")
+
+			Expect(ValidateResponse(query, response)).Should(Succeed())
+		})
+
+		It("should reject a response with another ID", func() {
+			response.Id = query.Id + 1
+
+			Expect(ValidateResponse(query, response)).Should(MatchError(ErrResponseMismatch))
+		})
+
+		It("should reject a response for another name", func() {
+			response.Question[0].Name = "evil.com."
+
+			Expect(ValidateResponse(query, response)).Should(MatchError(ErrResponseMismatch))
+		})
+
+		It("should reject a response for another type", func() {
+			response.Question[0].Qtype = dns.TypeAAAA
+
+			Expect(ValidateResponse(query, response)).Should(MatchError(ErrResponseMismatch))
+		})
+
+		It("should reject a successful response without question", func() {
+			response.Question = nil
+
+			Expect(ValidateResponse(query, response)).Should(MatchError(ErrResponseMismatch))
+		})
+
+		It("should accept an error response without question", func() {
+			response.Question = nil
+			response.Rcode = dns.RcodeServerFailure
+
+			Expect(ValidateResponse(query, response)).Should(Succeed())
+		})
+
+		It("should accept a CNAME chain in any order", func() {
+			withAnswer(
+				"cdn.example.net. 300 IN A 1.2.3.4",
+				"edge.example.org. 300 IN CNAME cdn.example.net.",
+				"www.example.com. 300 IN CNAME edge.example.org.",
+			)
+
+			Expect(ValidateResponse(query, response)).Should(Succeed())
+		})
+
+		It("should accept a DNAME of a parent domain", func() {
+			withAnswer(
+				"example.com. 300 IN DNAME example.net.",
+				"www.example.com. 300 IN CNAME www.example.net.",
+				"www.example.net. 300 IN A 1.2.3.4",
+			)
+
+			Expect(ValidateResponse(query, response)).Should(Succeed())
+		})
+
+		It("should reject records out of bailiwick", func() {
+			withAnswer(
+				"www.example.com. 300 IN A 1.2.3.4",
+				"bank.com. 300 IN A 6.6.6.6",
+			)
+
+			Expect(ValidateResponse(query, response)).Should(MatchError(ContainSubstring("'bank.com.' is out of bailiwick")))
+		})
+
+		It("should reject records of a name the CNAME doesn't point to", func() {
+			withAnswer(
+				"www.example.com. 300 IN CNAME cdn.example.net.",
+				"other.example.net. 300 IN A 1.2.3.4",
+			)
+
+			Expect(ValidateResponse(query, response)).Should(MatchError(ErrResponseMismatch))
+		})
+	})
+
+	Describe("QuestionsMatch", func() {
+		It("should be false if the number of questions differs", func() {
+			Expect(QuestionsMatch(query.Question, nil)).Should(BeFalse())
+		})
+
+		It("should be true for the same question", func() {
+			Expect(QuestionsMatch(query.Question, response.Question)).Should(BeTrue())
+		})
+	})
+
+	Describe("CheckCNAMEChain", func() {
+		It("should accept an answer without CNAMEs", func() {
+			withAnswer("www.example.com. 300 IN A 1.2.3.4")
+
+			Expect(CheckCNAMEChain("www.example.com.", response.Answer, 10)).Should(Succeed())
+		})
+
+		It("should accept an unordered chain within the limit", func() {
+			withAnswer(
+				"b.example.net. 300 IN A 1.2.3.4",
+				"a.example.net. 300 IN CNAME b.example.net.",
+				"www.example.com. 300 IN CNAME A.example.net.",
+			)
+
+			Expect(CheckCNAMEChain("www.example.com.", response.Answer, 2)).Should(Succeed())
+		})
+
+		It("should reject a chain longer than the limit", func() {
+			withAnswer(
+				"www.example.com. 300 IN CNAME a.example.net.",
+				"a.example.net. 300 IN CNAME b.example.net.",
+				"b.example.net. 300 IN CNAME c.example.net.",
+			)
+
+			Expect(CheckCNAMEChain("www.example.com.", response.Answer, 2)).Should(MatchError(ErrCNAMEChainTooLong))
+			Expect(CheckCNAMEChain("www.example.com.", response.Answer, 0)).Should(Succeed())
+		})
+
+		It("should reject a loop", func() {
+			withAnswer(
+				"www.example.com. 300 IN CNAME a.example.net.",
+				"a.example.net. 300 IN CNAME WWW.example.com.",
+			)
+
+			Expect(CheckCNAMEChain("www.example.com.", response.Answer, 0)).Should(MatchError(ErrCNAMELoop))
+		})
+
+		It("should reject a CNAME pointing to itself", func() {
+			withAnswer("www.example.com. 300 IN CNAME www.example.com.")
+
+			Expect(CheckCNAMEChain("www.example.com.", response.Answer, 10)).Should(MatchError(ErrCNAMELoop))
+		})
+	})
+})
@@ -10,6 +10,11 @@ import (
 //go:embed index.html
 var IndexTmpl string
 
+// BlockPageTmpl html template for the block page
+//
+//go:embed block_page.html
+var BlockPageTmpl string
+
 //go:embed all:static
 var static embed.FS
 